@@ -14,3 +14,17 @@ type Adaptor interface {
 type Porter interface {
 	Port() string
 }
+
+// PinState is the mode of a pin of an adaptor and the value last read from
+// or written to it
+type PinState struct {
+	Mode  string `json:"mode"`
+	Value int    `json:"value"`
+}
+
+// PinStater is the interface that describes an adaptor which tracks the
+// modes and values of its pins
+type PinStater interface {
+	// PinStates returns the state of each pin which has been used, by pin
+	PinStates() map[string]PinState
+}
@@ -1,10 +1,11 @@
 package api
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"log"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -12,8 +13,11 @@ import (
 	"github.com/bmizerany/pat"
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/api/robeaux"
+	"github.com/hybridgroup/gobot/metrics"
 )
 
+var _ gobot.APIServer = (*API)(nil)
+
 // API represents an API server
 type API struct {
 	gobot    *gobot.Gobot
@@ -23,45 +27,132 @@ type API struct {
 	Cert     string
 	Key      string
 	handlers []func(http.ResponseWriter, *http.Request)
-	start    func(*API)
-}
-
-// NewAPI returns a new api instance
+	listener net.Listener
+	jwt      *JWT
+	cors     *CORS
+	start    func(*API) error
+
+	// SelfSigned serves HTTPS with a certificate generated on start when
+	// there is no Cert and Key, for development
+	SelfSigned bool
+	// ClientCA is the path of the PEM certificates of the authorities which
+	// must have signed the certificate of a client for it to connect
+	ClientCA string
+	// Socket is the path of a unix domain socket serving the api over plain
+	// HTTP, for the local processes allowed by its permissions, along with
+	// Port, or instead of it when Port is empty
+	Socket string
+	// AllowShutdown serves POST /api/shutdown, draining the api and halting
+	// the Gobot, see Drain. The route must be protected by the
+	// authentication of the api, and can be restricted to some clients as
+	// the "shutdown" command of the api with UseRBAC.
+	AllowShutdown bool
+	// MetricsPort is the port serving the metrics of UseMetrics over plain
+	// HTTP, without the authentication of the api. They are served by the
+	// api when it is empty.
+	MetricsPort string
+
+	metrics         *metrics.Metrics
+	metricsListener net.Listener
+	socketListener  net.Listener
+	servers         []*http.Server
+	middleware      []Middleware
+	chain           http.Handler
+	ui              http.FileSystem
+	audit           AuditStore
+	sites           map[string]*Site
+	siteNames       []string
+	webhooks        *Webhooks
+	longPoll        int
+	drain           drain
+}
+
+// NewAPI returns a new api instance. g may be nil if the api is added to a
+// Gobot with AddAPIServer, which starts and stops it with the Gobot.
 func NewAPI(g *gobot.Gobot) *API {
 	return &API{
 		gobot:  g,
 		router: pat.New(),
 		Port:   "3000",
-		start: func(a *API) {
-			log.Println("Initializing API on " + a.Host + ":" + a.Port + "...")
-			http.Handle("/", a)
-
-			go func() {
-				if a.Cert != "" && a.Key != "" {
-					http.ListenAndServeTLS(a.Host+":"+a.Port, a.Cert, a.Key, nil)
+		start: func(a *API) (err error) {
+			if a.Port != "" {
+				gobot.Log(gobot.InfoLevel, "Initializing API", gobot.Fields{"address": a.Host + ":" + a.Port})
+				listener, err := net.Listen("tcp", a.Host+":"+a.Port)
+				if err != nil {
+					return err
+				}
+				config, err := a.tlsConfig()
+				if err != nil {
+					listener.Close()
+					return err
+				}
+				if config != nil {
+					listener = tls.NewListener(listener, config)
 				} else {
-					log.Println("WARNING: API using insecure connection. " +
-						"We recommend using an SSL certificate with Gobot.")
-					http.ListenAndServe(a.Host+":"+a.Port, nil)
+					gobot.Log(gobot.WarnLevel, "API using insecure connection. "+
+						"We recommend using an SSL certificate with Gobot.", nil)
+				}
+				a.listener = listener
+				a.serve(listener, a)
+			}
+			if a.Socket != "" {
+				gobot.Log(gobot.InfoLevel, "Initializing API", gobot.Fields{"socket": a.Socket})
+				listener, err := listenUnix(a.Socket)
+				if err != nil {
+					return err
 				}
-			}()
+				a.socketListener = listener
+				a.serve(listener, a)
+			}
+			return
 		},
 	}
 }
 
-// ServeHTTP calls api handlers and then serves request using api router
+// Attach sets the Gobot exposed by the api.
+func (a *API) Attach(g *gobot.Gobot) {
+	a.gobot = g
+}
+
+// ServeHTTP serves request through the middleware of the api, see Use.
 func (a *API) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if a.chain != nil {
+		a.chain.ServeHTTP(res, req)
+		return
+	}
+	a.serveHTTP(res, req)
+}
+
+// serveHTTP calls api handlers and then serves request using api router. A
+// handler answering with an error, e.g. 401 Not Authorized, ends the request.
+func (a *API) serveHTTP(res http.ResponseWriter, req *http.Request) {
+	if a.cors != nil && a.cors.apply(res, req) {
+		return
+	}
+	req = withAuthState(req)
 	for _, handler := range a.handlers {
 		rec := httptest.NewRecorder()
 		handler(rec, req)
 		for k, v := range rec.Header() {
 			res.Header()[k] = v
 		}
-		if rec.Code == http.StatusUnauthorized {
-			http.Error(res, "Not Authorized", http.StatusUnauthorized)
+		if rec.Code >= http.StatusBadRequest {
+			res.WriteHeader(rec.Code)
+			res.Write(rec.Body.Bytes())
 			return
 		}
 	}
+	if _, ok := a.rbacTarget(req); ok && req.URL.Path != shutdownRoute {
+		if !a.beginCommand() {
+			http.Error(res, "Draining", http.StatusServiceUnavailable)
+			return
+		}
+		defer a.endCommand()
+	}
+	if a.audit != nil {
+		a.routeAudited(res, req)
+		return
+	}
 	a.router.ServeHTTP(res, req)
 }
 
@@ -100,44 +191,114 @@ func (a *API) AddHandler(f func(http.ResponseWriter, *http.Request)) {
 	a.handlers = append(a.handlers, f)
 }
 
-// Start initializes the api by setting up c3pio routes and robeaux
-func (a *API) Start() {
+// Start initializes the api by setting up c3pio routes, the dashboard and
+// robeaux, and starts serving them in the background. Returns an error if
+// the address can not be listened on.
+func (a *API) Start() error {
 	mcpCommandRoute := "/api/commands/:command"
 	robotDeviceCommandRoute := "/api/robots/:robot/devices/:device/commands/:command"
 	robotCommandRoute := "/api/robots/:robot/commands/:command"
+	robotGroupCommandRoute := "/api/robots/:robot/groups/:group/commands/:command"
 
 	a.Get("/api/commands", a.mcpCommands)
 	a.Get(mcpCommandRoute, a.executeMcpCommand)
 	a.Post(mcpCommandRoute, a.executeMcpCommand)
+	a.Get("/api/jobs/:job", a.mcpJob)
 	a.Get("/api/robots", a.robots)
 	a.Get("/api/robots/:robot", a.robot)
 	a.Get("/api/robots/:robot/commands", a.robotCommands)
+	a.Get("/api/robots/:robot/health", a.robotHealth)
+	a.Get("/api/robots/:robot/state", a.robotState)
+	a.Get("/api/robots/:robot/events", a.robotEvents)
 	a.Get(robotCommandRoute, a.executeRobotCommand)
+	a.Post(batchRoute, a.executeBatch)
 	a.Post(robotCommandRoute, a.executeRobotCommand)
+	a.Get("/api/robots/:robot/jobs/:job", a.robotJob)
 	a.Get("/api/robots/:robot/devices", a.robotDevices)
 	a.Get("/api/robots/:robot/devices/:device", a.robotDevice)
+	a.Get("/api/robots/:robot/devices/:device/events", a.robotDeviceEvents)
 	a.Get("/api/robots/:robot/devices/:device/events/:event", a.robotDeviceEvent)
 	a.Get("/api/robots/:robot/devices/:device/commands", a.robotDeviceCommands)
 	a.Get(robotDeviceCommandRoute, a.executeRobotDeviceCommand)
 	a.Post(robotDeviceCommandRoute, a.executeRobotDeviceCommand)
+	a.Get("/api/robots/:robot/devices/:device/jobs/:job", a.robotDeviceJob)
+	a.Post("/api/robots/:robot/devices/:device/disable", a.disableRobotDevice)
+	a.Post("/api/robots/:robot/devices/:device/enable", a.enableRobotDevice)
+	a.Get("/api/robots/:robot/groups", a.robotGroups)
+	a.Get("/api/robots/:robot/groups/:group", a.robotGroup)
+	a.Post("/api/robots/:robot/groups/:group/start", a.startRobotGroup)
+	a.Post("/api/robots/:robot/groups/:group/halt", a.haltRobotGroup)
+	a.Get(robotGroupCommandRoute, a.executeRobotGroupCommand)
+	a.Post(robotGroupCommandRoute, a.executeRobotGroupCommand)
 	a.Get("/api/robots/:robot/connections", a.robotConnections)
 	a.Get("/api/robots/:robot/connections/:connection", a.robotConnection)
+	a.Get(pinRoute, a.readPin)
+	a.Post(pinRoute, a.writePin)
+	a.startV2()
+	a.Get("/api/openapi.json", a.openAPI)
+	a.Get("/api/graphql", a.graphQL)
+	a.Post("/api/graphql", a.graphQL)
+	if a.audit != nil {
+		a.Get("/api/audit", a.auditLog)
+	}
+	a.startSites()
+	if a.AllowShutdown {
+		a.Post(shutdownRoute, a.shutdown)
+	}
+	a.startWebhooks()
+	a.startLongPoll()
 	a.Get("/api/", a.mcp)
 
-	a.Get("/", func(res http.ResponseWriter, req *http.Request) {
-		http.Redirect(res, req, "/index.html", http.StatusMovedPermanently)
-	})
-	a.Get("/index.html", a.robeaux)
-	a.Get("/images/:a", a.robeaux)
-	a.Get("/js/:a", a.robeaux)
-	a.Get("/js/:a/", a.robeaux)
-	a.Get("/js/:a/:b", a.robeaux)
-	a.Get("/css/:a", a.robeaux)
-	a.Get("/css/:a/", a.robeaux)
-	a.Get("/css/:a/:b", a.robeaux)
-	a.Get("/partials/:a", a.robeaux)
+	if a.ui != nil {
+		a.Get("/", a.serveUI)
+		a.router.NotFound = http.HandlerFunc(a.serveUI)
+	} else {
+		a.Get("/dashboard", a.dashboard)
+		a.Get("/", func(res http.ResponseWriter, req *http.Request) {
+			http.Redirect(res, req, "/dashboard", http.StatusFound)
+		})
+		a.Get("/index.html", a.robeaux)
+		a.Get("/images/:a", a.robeaux)
+		a.Get("/js/:a", a.robeaux)
+		a.Get("/js/:a/", a.robeaux)
+		a.Get("/js/:a/:b", a.robeaux)
+		a.Get("/css/:a", a.robeaux)
+		a.Get("/css/:a/", a.robeaux)
+		a.Get("/css/:a/:b", a.robeaux)
+		a.Get("/partials/:a", a.robeaux)
+	}
+
+	if err := a.startMetrics(); err != nil {
+		return err
+	}
+	if err := a.start(a); err != nil {
+		a.Stop()
+		return err
+	}
+	return nil
+}
 
-	a.start(a)
+// serve serves handler on listener until the api is stopped
+func (a *API) serve(listener net.Listener, handler http.Handler) {
+	server := &http.Server{Handler: handler}
+	a.servers = append(a.servers, server)
+	go server.Serve(listener)
+}
+
+// Stop stops serving the api, closing its listeners along with the
+// connections they accepted. Returns the first error closing them.
+func (a *API) Stop() (err error) {
+	if a.webhooks != nil {
+		a.webhooks.detach()
+	}
+	for _, server := range a.servers {
+		if e := server.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	a.servers = nil
+	a.metricsListener, a.socketListener, a.listener = nil, nil, nil
+	return
 }
 
 // robeaux returns handler for robeaux routes.
@@ -173,8 +334,17 @@ func (a *API) mcpCommands(res http.ResponseWriter, req *http.Request) {
 // robots returns route handler.
 // Writes JSON with robots representation
 func (a *API) robots(res http.ResponseWriter, req *http.Request) {
+	filters := []gobot.Tags{}
+	for _, tag := range req.URL.Query()["tag"] {
+		filter, err := gobot.ParseTags(tag)
+		if err != nil {
+			a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+			return
+		}
+		filters = append(filters, filter)
+	}
 	jsonRobots := []*gobot.JSONRobot{}
-	a.gobot.Robots().Each(func(r *gobot.Robot) {
+	a.gobot.Robots(filters...).Each(func(r *gobot.Robot) {
 		jsonRobots = append(jsonRobots, gobot.NewJSONRobot(r))
 	})
 	a.writeJSON(map[string]interface{}{"robots": jsonRobots}, res)
@@ -190,6 +360,22 @@ func (a *API) robot(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// robotHealth returns health route handler.
+// Writes JSON with the errors of the unhealthy connections and devices
+func (a *API) robotHealth(res http.ResponseWriter, req *http.Request) {
+	if _, err := a.jsonRobotFor(req.URL.Query().Get(":robot")); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+	} else {
+		errs := []string{}
+		for _, err := range a.gobot.Robot(req.URL.Query().Get(":robot")).CheckHealth() {
+			errs = append(errs, err.Error())
+		}
+		a.writeJSON(map[string]interface{}{
+			"health": map[string]interface{}{"healthy": len(errs) == 0, "errors": errs},
+		}, res)
+	}
+}
+
 // robotCommands returns commands route handler
 // Writes JSON with robot commands representation
 func (a *API) robotCommands(res http.ResponseWriter, req *http.Request) {
@@ -214,6 +400,73 @@ func (a *API) robotDevices(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// robotGroups returns groups route handler.
+// Writes JSON with robot groups representation
+func (a *API) robotGroups(res http.ResponseWriter, req *http.Request) {
+	if robot := a.gobot.Robot(req.URL.Query().Get(":robot")); robot != nil {
+		jsonGroups := []*gobot.JSONGroup{}
+		for _, group := range robot.Groups() {
+			jsonGroups = append(jsonGroups, gobot.NewJSONGroup(group))
+		}
+		a.writeJSON(map[string]interface{}{"groups": jsonGroups}, res)
+	} else {
+		a.writeJSON(map[string]interface{}{"error": "No Robot found with the name " + req.URL.Query().Get(":robot")}, res)
+	}
+}
+
+// robotGroup returns group route handler.
+// Writes JSON with robot group representation
+func (a *API) robotGroup(res http.ResponseWriter, req *http.Request) {
+	if group, err := a.groupFor(req.URL.Query().Get(":robot"), req.URL.Query().Get(":group")); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+	} else {
+		a.writeJSON(map[string]interface{}{"group": gobot.NewJSONGroup(group)}, res)
+	}
+}
+
+// startRobotGroup returns start group route handler.
+// Starts the devices of the group and writes JSON with its representation
+func (a *API) startRobotGroup(res http.ResponseWriter, req *http.Request) {
+	a.runRobotGroup((*gobot.Group).Start, res, req)
+}
+
+// haltRobotGroup returns halt group route handler.
+// Halts the devices of the group and writes JSON with its representation
+func (a *API) haltRobotGroup(res http.ResponseWriter, req *http.Request) {
+	a.runRobotGroup((*gobot.Group).Halt, res, req)
+}
+
+func (a *API) runRobotGroup(f func(*gobot.Group) []error, res http.ResponseWriter, req *http.Request) {
+	group, err := a.groupFor(req.URL.Query().Get(":robot"), req.URL.Query().Get(":group"))
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	if errs := f(group); len(errs) > 0 {
+		a.writeJSON(map[string]interface{}{"error": errs[0].Error()}, res)
+		return
+	}
+	a.writeJSON(map[string]interface{}{"group": gobot.NewJSONGroup(group)}, res)
+}
+
+// executeRobotGroupCommand calls a command on each device of a group and
+// writes JSON with the results by device name
+func (a *API) executeRobotGroupCommand(res http.ResponseWriter, req *http.Request) {
+	group, err := a.groupFor(req.URL.Query().Get(":robot"), req.URL.Query().Get(":group"))
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	body, _ := a.commandParams(req)
+	results := group.Execute(req.URL.Query().Get(":command"), body)
+	for device, result := range results {
+		if err, ok := result.(error); ok {
+			results[device] = map[string]interface{}{"error": err.Error()}
+		}
+	}
+	a.writeJSON(map[string]interface{}{"results": results}, res)
+}
+
 // robotDevice returns device route handler.
 // Writes JSON with robot device representation
 func (a *API) robotDevice(res http.ResponseWriter, req *http.Request) {
@@ -224,43 +477,37 @@ func (a *API) robotDevice(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
-// robotDeviceEvent returns device event route handler.
-// Creates an event stream connection
-// and queries event data to be written when received
-func (a *API) robotDeviceEvent(res http.ResponseWriter, req *http.Request) {
-	f, _ := res.(http.Flusher)
-	c, _ := res.(http.CloseNotifier)
-
-	closer := c.CloseNotify()
-	msg := make(chan string)
-
-	res.Header().Set("Content-Type", "text/event-stream")
-	res.Header().Set("Cache-Control", "no-cache")
-	res.Header().Set("Connection", "keep-alive")
+// disableRobotDevice returns disable device route handler.
+// Takes the device offline and writes JSON with its representation
+func (a *API) disableRobotDevice(res http.ResponseWriter, req *http.Request) {
+	a.setRobotDeviceEnabled(false, res, req)
+}
 
-	if event := a.gobot.Robot(req.URL.Query().Get(":robot")).
-		Device(req.URL.Query().Get(":device")).(gobot.Eventer).
-		Event(req.URL.Query().Get(":event")); event != nil {
-		gobot.On(event, func(data interface{}) {
-			d, _ := json.Marshal(data)
-			msg <- string(d)
-		})
+// enableRobotDevice returns enable device route handler.
+// Brings the device back online and writes JSON with its representation
+func (a *API) enableRobotDevice(res http.ResponseWriter, req *http.Request) {
+	a.setRobotDeviceEnabled(true, res, req)
+}
 
-		for {
-			select {
-			case data := <-msg:
-				fmt.Fprintf(res, "data: %v\n\n", data)
-				f.Flush()
-			case <-closer:
-				log.Println("Closing connection")
-				return
-			}
-		}
+func (a *API) setRobotDeviceEnabled(enabled bool, res http.ResponseWriter, req *http.Request) {
+	robot, name := req.URL.Query().Get(":robot"), req.URL.Query().Get(":device")
+	if _, err := a.jsonDeviceFor(robot, name); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	r := a.gobot.Robot(robot)
+	var errs []error
+	if enabled {
+		errs = r.EnableDevice(name)
 	} else {
-		a.writeJSON(map[string]interface{}{
-			"error": errors.New("No Event found with the name " + req.URL.Query().Get(":event")),
-		}, res)
+		errs = r.DisableDevice(name)
 	}
+	if len(errs) > 0 {
+		a.writeJSON(map[string]interface{}{"error": errs[0].Error()}, res)
+		return
+	}
+	device, _ := a.jsonDeviceFor(robot, name)
+	a.writeJSON(map[string]interface{}{"device": device}, res)
 }
 
 // robotDeviceCommands returns device commands route handler
@@ -300,7 +547,7 @@ func (a *API) robotConnection(res http.ResponseWriter, req *http.Request) {
 
 // executeMcpCommand calls a global command asociated to requested route
 func (a *API) executeMcpCommand(res http.ResponseWriter, req *http.Request) {
-	a.executeCommand(a.gobot.Command(req.URL.Query().Get(":command")),
+	a.executeCommand(a.gobot, req.URL.Query().Get(":command"),
 		res,
 		req,
 	)
@@ -311,11 +558,13 @@ func (a *API) executeRobotDeviceCommand(res http.ResponseWriter, req *http.Reque
 	if _, err := a.jsonDeviceFor(req.URL.Query().Get(":robot"),
 		req.URL.Query().Get(":device")); err != nil {
 		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+	} else if commander, ok := a.gobot.Robot(req.URL.Query().Get(":robot")).
+		Device(req.URL.Query().Get(":device")).(gobot.Commander); !ok {
+		a.writeJSON(map[string]interface{}{"error": "Unknown Command"}, res)
 	} else {
 		a.executeCommand(
-			a.gobot.Robot(req.URL.Query().Get(":robot")).
-				Device(req.URL.Query().Get(":device")).(gobot.Commander).
-				Command(req.URL.Query().Get(":command")),
+			commander,
+			req.URL.Query().Get(":command"),
 			res,
 			req,
 		)
@@ -328,30 +577,139 @@ func (a *API) executeRobotCommand(res http.ResponseWriter, req *http.Request) {
 		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
 	} else {
 		a.executeCommand(
-			a.gobot.Robot(req.URL.Query().Get(":robot")).
-				Command(req.URL.Query().Get(":command")),
+			a.gobot.Robot(req.URL.Query().Get(":robot")),
+			req.URL.Query().Get(":command"),
 			res,
 			req,
 		)
 	}
 }
 
-// executeCommand writes JSON response with `f` returned value.
-func (a *API) executeCommand(f func(map[string]interface{}) interface{},
+// executeCommand writes JSON response with the value returned by the command
+// `name` of `c`, or the error it returned. With the query parameter
+// async=true the command is executed in the background and its job is
+// written instead.
+func (a *API) executeCommand(c gobot.Commander,
+	name string,
 	res http.ResponseWriter,
 	req *http.Request,
 ) {
 
-	body := make(map[string]interface{})
-	json.NewDecoder(req.Body).Decode(&body)
+	body, _ := a.commandParams(req)
+
+	if req.URL.Query().Get("async") == "true" {
+		if job, err := c.ExecuteAsync(name, body); err != nil {
+			a.writeJSON(map[string]interface{}{"error": "Unknown Command"}, res)
+		} else {
+			a.writeJSON(map[string]interface{}{"job": gobot.NewJSONJob(job)}, res)
+		}
+		return
+	}
 
-	if f != nil {
-		a.writeJSON(map[string]interface{}{"result": f(body)}, res)
+	if f := c.Command(name); f != nil {
+		result := f(body)
+		if err, ok := result.(error); ok {
+			a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+			return
+		}
+		a.writeJSON(map[string]interface{}{"result": result}, res)
 	} else {
 		a.writeJSON(map[string]interface{}{"error": "Unknown Command"}, res)
 	}
 }
 
+// commandParams returns the parameters of the command requested by req, read
+// from its JSON body, along with the claims of its token under ClaimsParam
+// and the span of the trace context of its headers under gobot.TraceParam.
+// Returns an error if the body is not a JSON object, along with the other
+// parameters.
+func (a *API) commandParams(req *http.Request) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err == io.EOF {
+		err = nil
+	}
+	if params == nil {
+		params = make(map[string]interface{})
+	}
+	delete(params, ClaimsParam)
+	delete(params, gobot.TraceParam)
+	if a.jwt != nil {
+		if claims, err := a.jwt.claims(req); err == nil {
+			params[ClaimsParam] = claims
+		}
+	}
+	if span := a.traceSpan(req); span != nil {
+		params[gobot.TraceParam] = span
+	}
+	return params, err
+}
+
+// traceSpan returns the span of the "traceparent" and "tracestate" headers
+// of req, extracted by the Tracer of the robot requested, or of the Gobot for
+// global commands, if it is a gobot.TraceExtractor. Returns nil otherwise.
+func (a *API) traceSpan(req *http.Request) gobot.Span {
+	if req.Header.Get("traceparent") == "" {
+		return nil
+	}
+	tracer := a.gobot.Tracer
+	if name := req.URL.Query().Get(":robot"); name != "" {
+		robot := a.gobot.Robot(name)
+		if robot == nil {
+			return nil
+		}
+		if robot.Tracer != nil {
+			tracer = robot.Tracer
+		}
+	}
+	extractor, ok := tracer.(gobot.TraceExtractor)
+	if !ok {
+		return nil
+	}
+	return extractor.ExtractSpan(map[string]string{
+		"traceparent": req.Header.Get("traceparent"),
+		"tracestate":  req.Header.Get("tracestate"),
+	})
+}
+
+// mcpJob returns the job route handler of a global command.
+func (a *API) mcpJob(res http.ResponseWriter, req *http.Request) {
+	a.writeJob(a.gobot, res, req)
+}
+
+// robotJob returns the job route handler of a robot command.
+func (a *API) robotJob(res http.ResponseWriter, req *http.Request) {
+	if _, err := a.jsonRobotFor(req.URL.Query().Get(":robot")); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+	} else {
+		a.writeJob(a.gobot.Robot(req.URL.Query().Get(":robot")), res, req)
+	}
+}
+
+// robotDeviceJob returns the job route handler of a device command.
+func (a *API) robotDeviceJob(res http.ResponseWriter, req *http.Request) {
+	if _, err := a.jsonDeviceFor(req.URL.Query().Get(":robot"),
+		req.URL.Query().Get(":device")); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+	} else {
+		a.writeJob(
+			a.gobot.Robot(req.URL.Query().Get(":robot")).
+				Device(req.URL.Query().Get(":device")).(gobot.Commander),
+			res,
+			req,
+		)
+	}
+}
+
+// writeJob writes JSON response with the job of `c` requested by route
+func (a *API) writeJob(c gobot.Commander, res http.ResponseWriter, req *http.Request) {
+	if job := c.Job(req.URL.Query().Get(":job")); job != nil {
+		a.writeJSON(map[string]interface{}{"job": gobot.NewJSONJob(job)}, res)
+	} else {
+		a.writeJSON(map[string]interface{}{"error": "No Job found with the id " + req.URL.Query().Get(":job")}, res)
+	}
+}
+
 // writeJSON writes `j` as JSON in response
 func (a *API) writeJSON(j interface{}, res http.ResponseWriter) {
 	data, _ := json.Marshal(j)
@@ -362,7 +720,7 @@ func (a *API) writeJSON(j interface{}, res http.ResponseWriter) {
 // Debug add handler to api that prints each request
 func (a *API) Debug() {
 	a.AddHandler(func(res http.ResponseWriter, req *http.Request) {
-		log.Println(req)
+		gobot.Log(gobot.InfoLevel, "Request", gobot.Fields{"method": req.Method, "url": req.URL, "remote": req.RemoteAddr})
 	})
 }
 
@@ -375,9 +733,19 @@ func (a *API) jsonRobotFor(name string) (jrobot *gobot.JSONRobot, err error) {
 	return
 }
 
+func (a *API) groupFor(robot string, name string) (group *gobot.Group, err error) {
+	if r := a.gobot.Robot(robot); r == nil {
+		err = errors.New("No Robot found with the name " + robot)
+	} else if group = r.Group(name); group == nil {
+		err = errors.New("No Group found with the name " + name)
+	}
+	return
+}
+
 func (a *API) jsonDeviceFor(robot string, name string) (jdevice *gobot.JSONDevice, err error) {
 	if device := a.gobot.Robot(robot).Device(name); device != nil {
 		jdevice = gobot.NewJSONDevice(device)
+		jdevice.Disabled = a.gobot.Robot(robot).DeviceDisabled(name)
 	} else {
 		err = errors.New("No Device found with the name " + name)
 	}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/hybridgroup/gobot"
@@ -16,7 +17,7 @@ func initTestAPI() *API {
 	log.SetOutput(NullReadWriteCloser{})
 	g := gobot.NewGobot()
 	a := NewAPI(g)
-	a.start = func(m *API) {}
+	a.start = func(m *API) error { return nil }
 	a.Start()
 	a.Debug()
 
@@ -62,8 +63,20 @@ func TestIndex(t *testing.T) {
 
 	a.ServeHTTP(response, request)
 
-	gobot.Assert(t, http.StatusMovedPermanently, response.Code)
-	gobot.Assert(t, "/index.html", response.HeaderMap["Location"][0])
+	gobot.Assert(t, http.StatusFound, response.Code)
+	gobot.Assert(t, "/dashboard", response.HeaderMap["Location"][0])
+}
+
+func TestDashboard(t *testing.T) {
+	a := initTestAPI()
+	request, _ := http.NewRequest("GET", "/dashboard", nil)
+	response := httptest.NewRecorder()
+
+	a.ServeHTTP(response, request)
+
+	gobot.Assert(t, response.Code, 200)
+	gobot.Assert(t, response.Header().Get("Content-Type"), "text/html; charset=utf-8")
+	gobot.Assert(t, strings.Contains(response.Body.String(), "/api/v2/robots"), true)
 }
 
 func TestMcp(t *testing.T) {
@@ -129,6 +142,30 @@ func TestRobots(t *testing.T) {
 	gobot.Assert(t, len(body["robots"].([]interface{})), 3)
 }
 
+func TestRobotsTagFilter(t *testing.T) {
+	a := initTestAPI()
+	a.gobot.Robot("Robot1").Tags = gobot.Tags{"zone": "warehouse-a", "type": "agv"}
+	a.gobot.Robot("Robot2").Tags = gobot.Tags{"zone": "warehouse-a", "type": "arm"}
+
+	request, _ := http.NewRequest("GET", "/api/robots?tag=zone=warehouse-a&tag=type=agv", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	var body map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&body)
+	robots := body["robots"].([]interface{})
+	gobot.Assert(t, len(robots), 1)
+	gobot.Assert(t, robots[0].(map[string]interface{})["name"], "Robot1")
+
+	request, _ = http.NewRequest("GET", "/api/robots?tag=zone", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["error"], "Tag \"zone\" is not a key=value pair")
+}
+
 func TestRobot(t *testing.T) {
 	a := initTestAPI()
 
@@ -189,6 +226,46 @@ func TestRobotCommands(t *testing.T) {
 	gobot.Assert(t, body["error"], "No Robot found with the name UnknownRobot1")
 }
 
+func TestExecuteRobotCommandParams(t *testing.T) {
+	var body map[string]interface{}
+	a := initTestAPI()
+	a.gobot.Robot("Robot1").AddCommandWithParams("Move",
+		[]gobot.Param{{Name: "speed", Type: gobot.ParamInt, Required: true, Min: 0, Max: 255}},
+		func(params map[string]interface{}) interface{} {
+			return params["speed"].(int) * 2
+		},
+	)
+
+	request, _ := http.NewRequest("POST",
+		"/api/robots/Robot1/commands/Move",
+		bytes.NewBufferString(`{"speed":"100"}`),
+	)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["result"], 200.0)
+
+	request, _ = http.NewRequest("POST",
+		"/api/robots/Robot1/commands/Move",
+		bytes.NewBufferString(`{"speed":300}`),
+	)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["error"], "Parameter \"speed\": 300 is out of range 0-255")
+
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	params := body["robot"].(map[string]interface{})["command_params"].(map[string]interface{})
+	gobot.Assert(t, params["Move"], []interface{}{map[string]interface{}{
+		"name": "speed", "type": "int", "required": true, "max": 255.0,
+	}})
+}
+
 func TestExecuteRobotCommand(t *testing.T) {
 	var body interface{}
 	a := initTestAPI()
@@ -252,6 +329,54 @@ func TestRobotDevice(t *testing.T) {
 	gobot.Assert(t, body["error"], "No Device found with the name UnknownDevice1")
 }
 
+func TestExecuteRobotCommandAsync(t *testing.T) {
+	var body map[string]interface{}
+	a := initTestAPI()
+	request, _ := http.NewRequest("POST",
+		"/api/robots/Robot1/commands/robotTestFunction?async=true",
+		bytes.NewBufferString(`{"message":"Beep Boop", "robot":"Robot1"}`),
+	)
+	request.Header.Add("Content-Type", "application/json")
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	json.NewDecoder(response.Body).Decode(&body)
+	job := body["job"].(map[string]interface{})
+	gobot.Assert(t, job["command"], "robotTestFunction")
+	<-a.gobot.Robot("Robot1").Job(job["id"].(string)).Done()
+
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/jobs/"+job["id"].(string), nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	job = body["job"].(map[string]interface{})
+	gobot.Assert(t, job["status"], "done")
+	gobot.Assert(t, job["result"], "hey Robot1, Beep Boop")
+
+	// unknown job
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/jobs/42", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["error"], "No Job found with the id 42")
+
+	// unknown command
+	request, _ = http.NewRequest("POST",
+		"/api/commands/UnknownFunction?async=true",
+		bytes.NewBufferString(`{}`),
+	)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["error"], "Unknown Command")
+}
+
 func TestRobotDeviceCommands(t *testing.T) {
 	a := initTestAPI()
 
@@ -402,3 +527,174 @@ func TestAPIRouter(t *testing.T) {
 	a.ServeHTTP(response, request)
 	gobot.Assert(t, response.Code, 200)
 }
+
+func TestRobotHealth(t *testing.T) {
+	var body map[string]interface{}
+	a := initTestAPI()
+	request, _ := http.NewRequest("GET", "/api/robots/Robot1/health", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["health"], map[string]interface{}{"healthy": true, "errors": []interface{}{}})
+
+	body = nil
+	request, _ = http.NewRequest("GET", "/api/robots/UnknownRobot1/health", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["error"], "No Robot found with the name UnknownRobot1")
+}
+
+func TestAPIServer(t *testing.T) {
+	log.SetOutput(NullReadWriteCloser{})
+	g := gobot.NewGobot()
+	a := NewAPI(nil)
+	a.Host = "127.0.0.1"
+	a.Port = "0"
+	g.AddAPIServer(a)
+	gobot.Assert(t, a.gobot, g)
+
+	// the connection kept alive by the client must not be served once stopped
+	client := &http.Client{Transport: &http.Transport{}}
+	gobot.Assert(t, a.Start(), nil)
+	response, err := client.Get("http://" + a.listener.Addr().String() + "/api/robots")
+	gobot.Assert(t, err, nil)
+	response.Body.Close()
+	gobot.Assert(t, response.StatusCode, 200)
+
+	addr := a.listener.Addr().String()
+	gobot.Assert(t, a.Stop(), nil)
+	_, err = client.Get("http://" + addr + "/api/robots")
+	gobot.Refute(t, err, nil)
+
+	a.Port = "invalid"
+	gobot.Refute(t, a.Start(), nil)
+}
+
+func TestDisableRobotDevice(t *testing.T) {
+	var body map[string]interface{}
+	a := initTestAPI()
+	request, _ := http.NewRequest("POST", "/api/robots/Robot1/devices/Device1/disable", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["device"].(map[string]interface{})["disabled"], true)
+	gobot.Assert(t, a.gobot.Robot("Robot1").DeviceDisabled("Device1"), true)
+
+	body = nil
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/devices/Device1", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["device"].(map[string]interface{})["disabled"], true)
+
+	body = nil
+	request, _ = http.NewRequest("POST", "/api/robots/Robot1/devices/Device1/enable", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["device"].(map[string]interface{})["disabled"], false)
+
+	body = nil
+	request, _ = http.NewRequest("POST", "/api/robots/Robot1/devices/UnknownDevice1/disable", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["error"], "No Device found with the name UnknownDevice1")
+}
+
+func TestRobotGroups(t *testing.T) {
+	var body map[string]interface{}
+	a := initTestAPI()
+	a.gobot.Robot("Robot1").AddGroup("all", "Device1", "Device2")
+
+	request, _ := http.NewRequest("GET", "/api/robots/Robot1/groups", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, len(body["groups"].([]interface{})), 1)
+
+	body = nil
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/groups/all", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	json.NewDecoder(response.Body).Decode(&body)
+	group := body["group"].(map[string]interface{})
+	gobot.Assert(t, group["name"], "all")
+	gobot.Assert(t, len(group["devices"].([]interface{})), 2)
+
+	body = nil
+	request, _ = http.NewRequest("POST", "/api/robots/Robot1/groups/all/halt", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["group"].(map[string]interface{})["name"], "all")
+
+	body = nil
+	request, _ = http.NewRequest("POST", "/api/robots/Robot1/groups/all/commands/DriverCommand", bytes.NewBufferString(`{"name":"fred"}`))
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["results"], map[string]interface{}{
+		"Device1": "hello fred",
+		"Device2": "hello fred",
+	})
+
+	body = nil
+	request, _ = http.NewRequest("POST", "/api/robots/Robot1/groups/all/commands/UnknownCommand", bytes.NewBufferString(`{}`))
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["results"].(map[string]interface{})["Device1"], map[string]interface{}{"error": "Command does not exist"})
+
+	body = nil
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/groups/UnknownGroup1", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["error"], "No Group found with the name UnknownGroup1")
+}
+
+// testExtractor extracts the traceparent header as the span
+type testExtractor struct{}
+
+type testSpan string
+
+func (testSpan) End(err error) {}
+
+func (testExtractor) StartSpan(parent gobot.Span, name string, attributes map[string]string) gobot.Span {
+	return nil
+}
+
+func (testExtractor) ExtractSpan(carrier map[string]string) gobot.Span {
+	if carrier["traceparent"] == "" {
+		return nil
+	}
+	return testSpan(carrier["traceparent"])
+}
+
+func TestExecuteCommandTraceParent(t *testing.T) {
+	a := initTestAPI()
+	var span interface{}
+	robot := a.gobot.Robot("Robot1")
+	robot.AddCommand("Span", func(params map[string]interface{}) interface{} {
+		span = params[gobot.TraceParam]
+		return nil
+	})
+
+	// without a TraceExtractor, nor from the body
+	request, _ := http.NewRequest("GET", "/api/robots/Robot1/commands/Span", bytes.NewBufferString(`{"trace_span": "forged"}`))
+	request.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	a.ServeHTTP(httptest.NewRecorder(), request)
+	gobot.Assert(t, span, nil)
+
+	robot.Tracer = testExtractor{}
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/commands/Span", bytes.NewBufferString("{}"))
+	request.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	a.ServeHTTP(httptest.NewRecorder(), request)
+	gobot.Assert(t, span, testSpan("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"))
+
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/commands/Span", bytes.NewBufferString("{}"))
+	a.ServeHTTP(httptest.NewRecorder(), request)
+	gobot.Assert(t, span, nil)
+}
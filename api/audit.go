@@ -0,0 +1,326 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// maxAuditBody bounds the request and response bodies kept in an AuditEntry
+const maxAuditBody = 64 << 10
+
+// AuditEntry is a command executed through the api, see API.UseAudit.
+type AuditEntry struct {
+	Time time.Time `json:"time"`
+	// Identity is the identity of the client, authenticated by UseAuth, the
+	// subject of its JWT or its basic authorization username, empty if it is
+	// anonymous
+	Identity string `json:"identity"`
+	Remote   string `json:"remote"`
+	// Robot and Devices are empty for the commands of the api, Devices for
+	// the commands of a robot
+	Robot   string                 `json:"robot,omitempty"`
+	Devices []string               `json:"devices,omitempty"`
+	Command string                 `json:"command"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+	// Result is what the command returned, or Error its error
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+	// Duration is in nanoseconds in JSON
+	Duration time.Duration `json:"duration"`
+}
+
+// AuditFilter selects the entries of an AuditStore, its empty fields select
+// every entry.
+type AuditFilter struct {
+	Identity string
+	Robot    string
+	Device   string
+	Command  string
+	Since    time.Time
+	Until    time.Time
+	// Limit is the maximum number of entries, the most recent
+	Limit int
+}
+
+// Match returns true if f selects e.
+func (f AuditFilter) Match(e AuditEntry) bool {
+	if (f.Identity != "" && e.Identity != f.Identity) ||
+		(f.Robot != "" && e.Robot != f.Robot) ||
+		(f.Command != "" && e.Command != f.Command) ||
+		(!f.Since.IsZero() && e.Time.Before(f.Since)) ||
+		(!f.Until.IsZero() && e.Time.After(f.Until)) {
+		return false
+	}
+	if f.Device == "" {
+		return true
+	}
+	for _, device := range e.Devices {
+		if device == f.Device {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditStore stores the audit log of an api.
+type AuditStore interface {
+	// Record stores entry
+	Record(entry AuditEntry) error
+	// Query returns the entries matching filter, the most recent first
+	Query(filter AuditFilter) ([]AuditEntry, error)
+}
+
+// MemoryAuditStore is an AuditStore keeping the most recent entries in
+// memory.
+type MemoryAuditStore struct {
+	size    int
+	entries []AuditEntry
+	mutex   sync.Mutex
+}
+
+// NewMemoryAuditStore returns a new MemoryAuditStore keeping size entries.
+func NewMemoryAuditStore(size int) *MemoryAuditStore {
+	return &MemoryAuditStore{size: size}
+}
+
+// Record stores entry, forgetting the oldest entry if the store is full.
+func (s *MemoryAuditStore) Record(entry AuditEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries = append(s.entries, entry)
+	if len(s.entries) > s.size {
+		s.entries = s.entries[len(s.entries)-s.size:]
+	}
+	return nil
+}
+
+// Query returns the entries matching filter, the most recent first.
+func (s *MemoryAuditStore) Query(filter AuditFilter) ([]AuditEntry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return queryAudit(s.entries, filter), nil
+}
+
+// FileAuditStore is an AuditStore appending the entries to a file, one JSON
+// object per line, so the audit log survives restarts.
+type FileAuditStore struct {
+	path  string
+	file  *os.File
+	mutex sync.Mutex
+}
+
+// NewFileAuditStore returns a new FileAuditStore appending to the file at
+// path, which is created if it does not exist.
+func NewFileAuditStore(path string) (*FileAuditStore, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileAuditStore{path: path, file: file}, nil
+}
+
+// Record appends entry to the file.
+func (s *FileAuditStore) Record(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+// Query reads the entries matching filter from the file, the most recent
+// first.
+func (s *FileAuditStore) Query(filter AuditFilter) ([]AuditEntry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	entries := []AuditEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 4096), 4*maxAuditBody)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return queryAudit(entries, filter), nil
+}
+
+// Close closes the file.
+func (s *FileAuditStore) Close() error {
+	return s.file.Close()
+}
+
+// queryAudit returns the entries matching filter, the most recent first
+func queryAudit(entries []AuditEntry, filter AuditFilter) []AuditEntry {
+	matched := []AuditEntry{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(matched) == filter.Limit {
+			break
+		}
+		if filter.Match(entries[i]) {
+			matched = append(matched, entries[i])
+		}
+	}
+	return matched
+}
+
+// UseAudit records every command executed through the api in store, with
+// the identity of its client, its parameters, result and duration, and
+// serves them at /api/audit, the most recent first, filtered by the
+// identity, robot, device, command, since and until (RFC 3339) query
+// parameters, and at most limit of them. The commands of the api, robots,
+// devices and groups are recorded, along with the pin routes, enabling and
+// disabling devices, and starting and halting groups, as in UseRBAC. The
+// requests refused by the handlers of the api, e.g. unauthorized, are not.
+// UseAudit must be called before the api is started.
+func (a *API) UseAudit(store AuditStore) {
+	a.audit = store
+}
+
+// auditLog returns the audit route handler.
+// Writes JSON with the entries of the audit log matching the query
+func (a *API) auditLog(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	filter := AuditFilter{
+		Identity: query.Get("identity"),
+		Robot:    query.Get("robot"),
+		Device:   query.Get("device"),
+		Command:  query.Get("command"),
+	}
+	var err error
+	if v := query.Get("since"); v != "" && err == nil {
+		filter.Since, err = time.Parse(time.RFC3339, v)
+	}
+	if v := query.Get("until"); v != "" && err == nil {
+		filter.Until, err = time.Parse(time.RFC3339, v)
+	}
+	if v := query.Get("limit"); v != "" && err == nil {
+		filter.Limit, err = strconv.Atoi(v)
+	}
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	entries, err := a.audit.Query(filter)
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	a.writeJSON(map[string]interface{}{"entries": entries}, res)
+}
+
+// routeAudited routes req, recording the command it executes, if any, in the
+// audit log of the api
+func (a *API) routeAudited(res http.ResponseWriter, req *http.Request) {
+	target, ok := a.rbacTarget(req)
+	if !ok {
+		a.router.ServeHTTP(res, req)
+		return
+	}
+	entry := AuditEntry{
+		Time:     time.Now(),
+		Identity: a.identity(req),
+		Remote:   req.RemoteAddr,
+		Robot:    target.robot,
+		Devices:  target.devices,
+		Command:  target.command,
+		Params:   auditParams(req),
+	}
+	w := &auditResponseWriter{responseWriter: responseWriter{ResponseWriter: res}}
+	a.router.ServeHTTP(w, req)
+	entry.Duration = time.Since(entry.Time)
+	entry.Result, entry.Error = auditResult(w.Status(), w.body.Bytes())
+	if err := a.audit.Record(entry); err != nil {
+		gobot.Log(gobot.ErrorLevel, "Recording audit entry failed", gobot.Fields{"error": err.Error()})
+	}
+}
+
+// auditParams returns the parameters of the command requested by req, from
+// its query and JSON body, leaving the body to read for the command
+func auditParams(req *http.Request) map[string]interface{} {
+	params := make(map[string]interface{})
+	for name, values := range req.URL.Query() {
+		if name != "access_token" && name != "async" && len(values) > 0 {
+			params[name] = values[0]
+		}
+	}
+	if req.Body != nil {
+		body, _ := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if len(body) <= maxAuditBody {
+			var fields map[string]interface{}
+			json.Unmarshal(body, &fields)
+			for name, value := range fields {
+				params[name] = value
+			}
+		}
+	}
+	delete(params, ClaimsParam)
+	delete(params, gobot.TraceParam)
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+// auditResult returns the result or the error of a command from the status
+// and JSON body of its response
+func auditResult(status int, body []byte) (interface{}, string) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		if status >= http.StatusBadRequest {
+			return nil, http.StatusText(status)
+		}
+		return nil, ""
+	}
+	switch e := fields["error"].(type) {
+	case string:
+		return nil, e
+	case map[string]interface{}:
+		if message, ok := e["message"].(string); ok {
+			return nil, message
+		}
+	}
+	if result, ok := fields["result"]; ok {
+		return result, ""
+	}
+	return fields, ""
+}
+
+// auditResponseWriter keeps the beginning of the body of a response
+type auditResponseWriter struct {
+	responseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if room := maxAuditBody - w.body.Len(); room > 0 {
+		if len(b) < room {
+			room = len(b)
+		}
+		w.body.Write(b[:room])
+	}
+	return w.responseWriter.Write(b)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestUseAudit(t *testing.T) {
+	log.SetOutput(NullReadWriteCloser{})
+	g := gobot.NewGobot()
+	g.AddRobot(newTestRobot("Robot1"))
+	a := NewAPI(g)
+	a.start = func(m *API) error { return nil }
+	store := NewMemoryAuditStore(10)
+	a.UseAudit(store)
+	a.Start()
+
+	request := func(method string, url string, user string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		if user != "" {
+			req.SetBasicAuth(user, "secret")
+		}
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		return res
+	}
+	res := request("POST", "/api/robots/Robot1/commands/robotTestFunction", "alice",
+		`{"message":"hi","robot":"Robot1"}`)
+	gobot.Assert(t, res.Body.String(), "{\"result\":\"hey Robot1, hi\"}")
+	request("POST", "/api/robots/Robot1/devices/Device1/commands/Missing", "bob", "{}")
+	request("POST", "/api/v2/robots/Robot1/devices/Device1/commands/Missing", "bob", "{}")
+	request("GET", "/api/robots/Robot1", "alice", "")
+
+	entries, _ := store.Query(AuditFilter{})
+	gobot.Assert(t, len(entries), 3)
+	gobot.Assert(t, entries[0].Error, "No Command found with the name Missing")
+	gobot.Assert(t, entries[1].Identity, "bob")
+	gobot.Assert(t, entries[1].Devices, []string{"Device1"})
+	gobot.Assert(t, entries[1].Error, "Unknown Command")
+	gobot.Assert(t, entries[2].Identity, "alice")
+	gobot.Assert(t, entries[2].Robot, "Robot1")
+	gobot.Assert(t, entries[2].Command, "robotTestFunction")
+	gobot.Assert(t, entries[2].Params["message"], "hi")
+	gobot.Assert(t, entries[2].Result, "hey Robot1, hi")
+
+	var body struct {
+		Entries []AuditEntry `json:"entries"`
+	}
+	res = request("GET", "/api/audit?identity=bob&limit=1", "", "")
+	json.Unmarshal(res.Body.Bytes(), &body)
+	gobot.Assert(t, len(body.Entries), 1)
+	gobot.Assert(t, body.Entries[0].Error, "No Command found with the name Missing")
+
+	res = request("GET", "/api/audit?device=Device1&since="+time.Now().Add(-time.Minute).Format(time.RFC3339), "", "")
+	json.Unmarshal(res.Body.Bytes(), &body)
+	gobot.Assert(t, len(body.Entries), 2)
+
+	res = request("GET", "/api/audit?since=yesterday", "", "")
+	gobot.Assert(t, bytes.Contains(res.Body.Bytes(), []byte(`"error"`)), true)
+}
+
+func TestFileAuditStore(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gobot-audit")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	store, err := NewFileAuditStore(path)
+	gobot.Assert(t, err, nil)
+	store.Record(AuditEntry{Identity: "alice", Command: "Stop", Duration: time.Second})
+	store.Record(AuditEntry{Identity: "bob", Robot: "bot", Command: "Drive"})
+	store.Close()
+
+	store, _ = NewFileAuditStore(path)
+	defer store.Close()
+	entries, err := store.Query(AuditFilter{})
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, len(entries), 2)
+	gobot.Assert(t, entries[0].Command, "Drive")
+	gobot.Assert(t, entries[1].Duration, time.Second)
+
+	entries, _ = store.Query(AuditFilter{Robot: "bot"})
+	gobot.Assert(t, len(entries), 1)
+	gobot.Assert(t, entries[0].Identity, "bob")
+}
+
+func TestMemoryAuditStoreSize(t *testing.T) {
+	store := NewMemoryAuditStore(2)
+	for _, command := range []string{"a", "b", "c"} {
+		store.Record(AuditEntry{Command: command})
+	}
+	entries, _ := store.Query(AuditFilter{})
+	gobot.Assert(t, len(entries), 2)
+	gobot.Assert(t, entries[0].Command, "c")
+	gobot.Assert(t, entries[1].Command, "b")
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Identity is the client of a request, authenticated by an AuthProvider.
+type Identity struct {
+	// Name identifies the client, e.g. its username
+	Name string
+	// Roles are the names of more roles of the client, see RBAC
+	Roles []string
+	// Attributes are what else the provider knows of the client, e.g. the
+	// groups of an LDAP user or the claims of an introspected token
+	Attributes map[string]interface{}
+}
+
+// AuthProvider authenticates the clients of the api, see API.UseAuth.
+type AuthProvider interface {
+	// Authenticate returns the identity of the client of req, or an error if
+	// it cannot be authenticated.
+	Authenticate(req *http.Request) (*Identity, error)
+}
+
+// AuthProviderFunc is a function used as an AuthProvider.
+type AuthProviderFunc func(req *http.Request) (*Identity, error)
+
+// Authenticate returns f(req).
+func (f AuthProviderFunc) Authenticate(req *http.Request) (*Identity, error) {
+	return f(req)
+}
+
+// challenger is an AuthProvider telling the clients it fails to
+// authenticate how to authenticate, in the WWW-Authenticate header
+type challenger interface {
+	Challenge() string
+}
+
+// BasicAuthUsers is an AuthProvider authenticating the clients with their
+// basic authorization, given the passwords of the users by username.
+type BasicAuthUsers map[string]string
+
+// Authenticate returns the identity of the user of the basic authorization
+// of req, named after the username.
+func (u BasicAuthUsers) Authenticate(req *http.Request) (*Identity, error) {
+	username, password, ok := req.BasicAuth()
+	if !ok {
+		return nil, errors.New("Missing basic authorization")
+	}
+	// compare a password even for unknown users, to keep constant time
+	actual, known := u[username]
+	if !secureCompare(password, actual) || !known {
+		return nil, errors.New("Invalid username or password")
+	}
+	return &Identity{Name: username}, nil
+}
+
+// Challenge returns the basic authentication challenge.
+func (u BasicAuthUsers) Challenge() string {
+	return "Basic realm=\"Authorization Required\""
+}
+
+// ClientCertAuth is an AuthProvider authenticating the clients with the
+// certificate they connected with, verified against the ClientCA of the
+// api. The identity is named after the common name of the subject of the
+// certificate, and has its organizational units as roles.
+type ClientCertAuth struct{}
+
+// Authenticate returns the identity of the certificate of the client of
+// req.
+func (ClientCertAuth) Authenticate(req *http.Request) (*Identity, error) {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+		return nil, errors.New("Missing client certificate")
+	}
+	cert := req.TLS.VerifiedChains[0][0]
+	return &Identity{
+		Name:  cert.Subject.CommonName,
+		Roles: cert.Subject.OrganizationalUnit,
+		Attributes: map[string]interface{}{
+			"organization": cert.Subject.Organization,
+			"serial":       cert.SerialNumber.String(),
+		},
+	}, nil
+}
+
+// authKey is the key of the authState of a request in its context
+type authKey struct{}
+
+// authState holds the identity of the client of a request once it is
+// authenticated, and its bearer token once validated by UseJWT
+type authState struct {
+	identity *Identity
+	token    string
+}
+
+// withAuthState returns req with a context holding the identity its client
+// is authenticated as
+func withAuthState(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), authKey{}, &authState{}))
+}
+
+// RequestIdentity returns the identity of the client of req authenticated
+// by an AuthProvider, nil if there is none. Handlers added after UseAuth can
+// use it.
+func RequestIdentity(req *http.Request) *Identity {
+	if state, ok := req.Context().Value(authKey{}).(*authState); ok {
+		return state.identity
+	}
+	return nil
+}
+
+// authenticate returns a handler answering the requests whose client p
+// fails to authenticate with 401 Not Authorized
+func authenticate(p AuthProvider) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		identity, err := p.Authenticate(req)
+		if err != nil || identity == nil {
+			if c, ok := p.(challenger); ok {
+				res.Header().Set("WWW-Authenticate", c.Challenge())
+			}
+			http.Error(res, "Not Authorized", http.StatusUnauthorized)
+			return
+		}
+		if state, ok := req.Context().Value(authKey{}).(*authState); ok {
+			state.identity = identity
+		}
+	}
+}
+
+// UseAuth makes the api answer the requests whose client p fails to
+// authenticate with 401 Not Authorized, so deployments can authenticate
+// their clients with LDAP, OAuth2 token introspection or their certificate,
+// with BasicAuthUsers, ClientCertAuth or their own AuthProvider. The
+// identities are used by UseRBAC, with their roles, and recorded by
+// UseAudit.
+func (a *API) UseAuth(p AuthProvider) {
+	a.AddHandler(authenticate(p))
+}
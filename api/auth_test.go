@@ -0,0 +1,82 @@
+package api
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestUseAuth(t *testing.T) {
+	a := initTestAPI()
+	a.UseAuth(AuthProviderFunc(func(req *http.Request) (*Identity, error) {
+		switch req.Header.Get("X-Token") {
+		case "alice":
+			return &Identity{Name: "alice", Roles: []string{"operator"}}, nil
+		case "bob":
+			return &Identity{Name: "bob"}, nil
+		}
+		return nil, errors.New("Unknown token")
+	}))
+	a.UseRBAC(&RBAC{Roles: map[string]*Role{"operator": {}}})
+	store := NewMemoryAuditStore(10)
+	a.audit = store
+
+	request := func(token string) int {
+		req, _ := http.NewRequest("POST", "/api/robots/Robot1/commands/robotTestFunction",
+			bytes.NewBufferString(`{"message":"hi","robot":"Robot1"}`))
+		req.Header.Set("X-Token", token)
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		return res.Code
+	}
+	gobot.Assert(t, request("alice"), 200)
+	gobot.Assert(t, request("bob"), 403)
+	gobot.Assert(t, request("carol"), 401)
+
+	entries, _ := store.Query(AuditFilter{})
+	gobot.Assert(t, len(entries), 1)
+	gobot.Assert(t, entries[0].Identity, "alice")
+}
+
+func TestBasicAuthUsers(t *testing.T) {
+	a := initTestAPI()
+	a.UseAuth(BasicAuthUsers{"admin": "password", "guest": "guest"})
+
+	request := func(username string, password string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/", nil)
+		req.SetBasicAuth(username, password)
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		return res
+	}
+	gobot.Assert(t, request("admin", "password").Code, 200)
+	gobot.Assert(t, request("guest", "guest").Code, 200)
+	gobot.Assert(t, request("admin", "guest").Code, 401)
+	gobot.Assert(t, request("nobody", "").Code, 401)
+	gobot.Assert(t, request("nobody", "").Header().Get("WWW-Authenticate"), "Basic realm=\"Authorization Required\"")
+}
+
+func TestClientCertAuth(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/api/", nil)
+	_, err := ClientCertAuth{}.Authenticate(req)
+	gobot.Refute(t, err, nil)
+
+	cert := &x509.Certificate{
+		Subject:      pkix.Name{CommonName: "rover", OrganizationalUnit: []string{"driver"}},
+		SerialNumber: big.NewInt(7),
+	}
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	identity, err := ClientCertAuth{}.Authenticate(req)
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, identity.Name, "rover")
+	gobot.Assert(t, identity.Roles, []string{"driver"})
+	gobot.Assert(t, identity.Attributes["serial"], "7")
+}
@@ -2,23 +2,13 @@ package api
 
 import (
 	"crypto/subtle"
-	"encoding/base64"
 	"net/http"
 )
 
-// BasicAuth returns basic auth handler.
+// BasicAuth returns basic auth handler, authenticating the user username
+// with password, see BasicAuthUsers.
 func BasicAuth(username, password string) http.HandlerFunc {
-	// Inspired by https://github.com/codegangsta/martini-contrib/blob/master/auth/
-	return func(res http.ResponseWriter, req *http.Request) {
-		if !secureCompare(req.Header.Get("Authorization"),
-			"Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)),
-		) {
-			res.Header().Set("WWW-Authenticate",
-				"Basic realm=\"Authorization Required\"",
-			)
-			http.Error(res, "Not Authorized", http.StatusUnauthorized)
-		}
-	}
+	return authenticate(BasicAuthUsers{username: password})
 }
 
 func secureCompare(given string, actual string) bool {
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// batchRoute is the route executing a batch of commands of a robot
+const batchRoute = "/api/robots/:robot/commands/batch"
+
+// maxBatch bounds the number of commands of a batch
+const maxBatch = 100
+
+// batch is a list of commands of a robot and its devices executed in one
+// request
+type batch struct {
+	Commands []batchCommand `json:"commands"`
+	// StopOnError skips the commands following one which failed
+	StopOnError bool `json:"stop_on_error"`
+	// Parallel executes the commands at once instead of in order
+	Parallel bool `json:"parallel"`
+}
+
+// batchCommand is a command of a batch, of the robot if Device is empty
+type batchCommand struct {
+	Device  string                 `json:"device,omitempty"`
+	Command string                 `json:"command"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
+// batchResult is the result of a command of a batch
+type batchResult struct {
+	Device  string      `json:"device,omitempty"`
+	Command string      `json:"command"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Skipped bool        `json:"skipped,omitempty"`
+}
+
+// readBatch reads the batch of the body of req, leaving the body to read
+// again
+func readBatch(req *http.Request) (*batch, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	b := &batch{}
+	if err := json.Unmarshal(body, b); err != nil {
+		return nil, err
+	}
+	if len(b.Commands) > maxBatch {
+		return nil, fmt.Errorf("Batches are limited to %v commands", maxBatch)
+	}
+	return b, nil
+}
+
+// executeBatch returns the batch route handler.
+// Executes the commands of the batch of the JSON body, once they are all
+// found, and writes JSON with their results in order
+func (a *API) executeBatch(res http.ResponseWriter, req *http.Request) {
+	robot := a.gobot.Robot(req.URL.Query().Get(":robot"))
+	if robot == nil {
+		a.writeJSON(map[string]interface{}{"error": "No Robot found with the name " + req.URL.Query().Get(":robot")}, res)
+		return
+	}
+	b, err := readBatch(req)
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	var claims Claims
+	if a.jwt != nil {
+		claims, _ = a.jwt.claims(req)
+	}
+	span := a.traceSpan(req)
+
+	commands := make([]func(map[string]interface{}) interface{}, len(b.Commands))
+	for i, c := range b.Commands {
+		var commander gobot.Commander = robot
+		if c.Device != "" {
+			device := robot.Device(c.Device)
+			if device == nil {
+				a.writeJSON(map[string]interface{}{"error": "No Device found with the name " + c.Device}, res)
+				return
+			}
+			var ok bool
+			if commander, ok = device.(gobot.Commander); !ok {
+				a.writeJSON(map[string]interface{}{"error": "Unknown Command " + c.Command}, res)
+				return
+			}
+		}
+		if commands[i] = commander.Command(c.Command); commands[i] == nil {
+			a.writeJSON(map[string]interface{}{"error": "Unknown Command " + c.Command}, res)
+			return
+		}
+	}
+
+	results := make([]batchResult, len(b.Commands))
+	execute := func(i int) bool {
+		c := b.Commands[i]
+		params := map[string]interface{}{}
+		for name, value := range c.Params {
+			params[name] = value
+		}
+		delete(params, ClaimsParam)
+		if claims != nil {
+			params[ClaimsParam] = claims
+		}
+		delete(params, gobot.TraceParam)
+		if span != nil {
+			params[gobot.TraceParam] = span
+		}
+		results[i] = batchResult{Device: c.Device, Command: c.Command}
+		result := commands[i](params)
+		if err, ok := result.(error); ok {
+			results[i].Error = err.Error()
+			return false
+		}
+		results[i].Result = result
+		return true
+	}
+	if b.Parallel {
+		var wg sync.WaitGroup
+		for i := range b.Commands {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				execute(i)
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range b.Commands {
+			if !execute(i) && b.StopOnError {
+				for j := i + 1; j < len(b.Commands); j++ {
+					results[j] = batchResult{Device: b.Commands[j].Device, Command: b.Commands[j].Command, Skipped: true}
+				}
+				break
+			}
+		}
+	}
+	a.writeJSON(map[string]interface{}{"results": results}, res)
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestExecuteBatch(t *testing.T) {
+	a := initTestAPI()
+	a.gobot.Robot("Robot1").AddCommand("Fail", func(params map[string]interface{}) interface{} {
+		return errors.New("failed")
+	})
+
+	request := func(body string) string {
+		req, _ := http.NewRequest("POST", "/api/robots/Robot1/commands/batch", bytes.NewBufferString(body))
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		return res.Body.String()
+	}
+
+	gobot.Assert(t, request(`{"commands":[
+		{"device":"Device1","command":"TestDriverCommand","params":{"name":"one"}},
+		{"command":"robotTestFunction","params":{"message":"two","robot":"Robot1"}}
+	]}`), `{"results":[{"device":"Device1","command":"TestDriverCommand","result":"hello one"},`+
+		`{"command":"robotTestFunction","result":"hey Robot1, two"}]}`)
+
+	gobot.Assert(t, request(`{"stop_on_error":true,"commands":[
+		{"command":"Fail"},
+		{"device":"Device1","command":"TestDriverCommand","params":{"name":"one"}}
+	]}`), `{"results":[{"command":"Fail","error":"failed"},`+
+		`{"device":"Device1","command":"TestDriverCommand","skipped":true}]}`)
+
+	gobot.Assert(t, request(`{"parallel":true,"commands":[
+		{"command":"Fail"},
+		{"device":"Device2","command":"DriverCommand","params":{"name":"two"}}
+	]}`), `{"results":[{"command":"Fail","error":"failed"},`+
+		`{"device":"Device2","command":"DriverCommand","result":"hello two"}]}`)
+
+	gobot.Assert(t, request(`{"commands":[{"device":"Device1","command":"Missing"}]}`),
+		`{"error":"Unknown Command Missing"}`)
+	gobot.Assert(t, request(`{"commands":[{"device":"Device9","command":"DriverCommand"}]}`),
+		`{"error":"No Device found with the name Device9"}`)
+}
+
+func TestBatchRBAC(t *testing.T) {
+	a := initTestAPI()
+	a.AddHandler(BasicAuth("operator", "password"))
+	a.UseRBAC(&RBAC{
+		Roles:    map[string]*Role{"operator": {Commands: []string{"TestDriverCommand"}}},
+		Bindings: map[string][]string{"operator": {"operator"}},
+	})
+
+	request := func(body string) int {
+		req, _ := http.NewRequest("POST", "/api/robots/Robot1/commands/batch", bytes.NewBufferString(body))
+		req.SetBasicAuth("operator", "password")
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		return res.Code
+	}
+	gobot.Assert(t, request(`{"commands":[{"device":"Device1","command":"TestDriverCommand","params":{"name":"x"}}]}`), 200)
+	gobot.Assert(t, request(`{"commands":[
+		{"device":"Device1","command":"TestDriverCommand","params":{"name":"x"}},
+		{"device":"Device1","command":"DriverCommand","params":{"name":"x"}}
+	]}`), 403)
+}
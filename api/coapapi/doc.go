@@ -0,0 +1,19 @@
+/*
+Package coapapi provides a CoAP (RFC 7252) server of the robots of a Gobot,
+for constrained IoT networks where HTTP is too heavy for peer controllers.
+Their state can be read, their commands executed, and their events observed
+(RFC 7641), see Server.
+
+Example:
+
+	gbot := gobot.NewGobot()
+	gbot.AddAPIServer(coapapi.NewServer(nil))
+	gbot.Start()
+
+With the coap-client of libcoap:
+
+	coap-client -m get coap://robot.local/robots/bot
+	coap-client -m post coap://robot.local/robots/bot/devices/led/commands/Toggle
+	coap-client -m get -s 60 coap://robot.local/robots/bot/events?event=button_*
+*/
+package coapapi
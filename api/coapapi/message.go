@@ -0,0 +1,217 @@
+package coapapi
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// The types of a CoAP message
+const (
+	confirmable     = 0
+	nonConfirmable  = 1
+	acknowledgement = 2
+	reset           = 3
+)
+
+// The codes of a CoAP message, the class in the upper 3 bits and the detail
+// in the lower 5
+const (
+	codeEmpty            = 0
+	codeGet              = 1
+	codePost             = 2
+	codeChanged          = 2<<5 | 4
+	codeContent          = 2<<5 | 5
+	codeBadRequest       = 4<<5 | 0
+	codeNotFound         = 4<<5 | 4
+	codeMethodNotAllowed = 4<<5 | 5
+	codeInternalError    = 5<<5 | 0
+)
+
+// The numbers of the CoAP options used by the server
+const (
+	optionObserve       = 6
+	optionURIPath       = 11
+	optionContentFormat = 12
+	optionURIQuery      = 15
+)
+
+// The content formats of the payloads of the server
+const (
+	formatLinks = 40
+	formatJSON  = 50
+)
+
+// option is a CoAP option
+type option struct {
+	number uint16
+	value  []byte
+}
+
+// message is a CoAP message, RFC 7252
+type message struct {
+	typ     uint8
+	code    uint8
+	id      uint16
+	token   []byte
+	options []option
+	payload []byte
+}
+
+// parseMessage parses a CoAP message from a datagram
+func parseMessage(data []byte) (*message, error) {
+	if len(data) < 4 {
+		return nil, errors.New("Message too short")
+	}
+	if data[0]>>6 != 1 {
+		return nil, errors.New("Unsupported CoAP version")
+	}
+	m := &message{
+		typ:  data[0] >> 4 & 3,
+		code: data[1],
+		id:   binary.BigEndian.Uint16(data[2:4]),
+	}
+	tkl := int(data[0] & 15)
+	if tkl > 8 || len(data) < 4+tkl {
+		return nil, errors.New("Malformed token")
+	}
+	m.token = data[4 : 4+tkl]
+	data = data[4+tkl:]
+
+	number := 0
+	for len(data) > 0 {
+		if data[0] == 0xff {
+			if len(data) == 1 {
+				return nil, errors.New("Empty payload after the payload marker")
+			}
+			m.payload = data[1:]
+			break
+		}
+		delta, length := int(data[0]>>4), int(data[0]&15)
+		data = data[1:]
+		var err error
+		if delta, data, err = extend(delta, data); err != nil {
+			return nil, err
+		}
+		if length, data, err = extend(length, data); err != nil {
+			return nil, err
+		}
+		if len(data) < length {
+			return nil, errors.New("Malformed option")
+		}
+		number += delta
+		m.options = append(m.options, option{number: uint16(number), value: data[:length]})
+		data = data[length:]
+	}
+	return m, nil
+}
+
+// extend returns the value of an option delta or length nibble, extended
+// by the bytes following the option header
+func extend(nibble int, data []byte) (int, []byte, error) {
+	switch nibble {
+	case 13:
+		if len(data) < 1 {
+			return 0, nil, errors.New("Malformed option")
+		}
+		return int(data[0]) + 13, data[1:], nil
+	case 14:
+		if len(data) < 2 {
+			return 0, nil, errors.New("Malformed option")
+		}
+		return int(binary.BigEndian.Uint16(data)) + 269, data[2:], nil
+	case 15:
+		return 0, nil, errors.New("Malformed option")
+	}
+	return nibble, data, nil
+}
+
+// marshal returns the datagram of m
+func (m *message) marshal() []byte {
+	data := []byte{1<<6 | m.typ<<4 | uint8(len(m.token)), m.code, 0, 0}
+	binary.BigEndian.PutUint16(data[2:], m.id)
+	data = append(data, m.token...)
+
+	sort.SliceStable(m.options, func(i, j int) bool { return m.options[i].number < m.options[j].number })
+	number := 0
+	for _, o := range m.options {
+		delta, length := int(o.number)-number, len(o.value)
+		header := len(data)
+		data = append(data, 0)
+		var d, l byte
+		d, data = nibble(delta, data)
+		l, data = nibble(length, data)
+		data[header] = d<<4 | l
+		data = append(data, o.value...)
+		number = int(o.number)
+	}
+	if len(m.payload) > 0 {
+		data = append(data, 0xff)
+		data = append(data, m.payload...)
+	}
+	return data
+}
+
+// nibble returns the nibble of an option delta or length and appends its
+// extended bytes to data
+func nibble(v int, data []byte) (byte, []byte) {
+	switch {
+	case v < 13:
+		return byte(v), data
+	case v < 269:
+		return 13, append(data, byte(v-13))
+	}
+	return 14, append(data, byte((v-269)>>8), byte(v-269))
+}
+
+// strings returns the values of the options of m with number
+func (m *message) strings(number uint16) []string {
+	values := []string{}
+	for _, o := range m.options {
+		if o.number == number {
+			values = append(values, string(o.value))
+		}
+	}
+	return values
+}
+
+// uint returns the value of the option of m with number, false if m does not
+// have it
+func (m *message) uint(number uint16) (uint32, bool) {
+	for _, o := range m.options {
+		if o.number == number {
+			v := uint32(0)
+			for _, b := range o.value {
+				v = v<<8 | uint32(b)
+			}
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// addUint adds an option with number and the value v to m
+func (m *message) addUint(number uint16, v uint32) {
+	value := []byte{}
+	for ; v > 0; v >>= 8 {
+		value = append([]byte{byte(v)}, value...)
+	}
+	m.options = append(m.options, option{number: number, value: value})
+}
+
+// path returns the segments of the Uri-Path of m
+func (m *message) path() []string {
+	return m.strings(optionURIPath)
+}
+
+// query returns the values of the Uri-Query parameter of m with name
+func (m *message) query(name string) []string {
+	values := []string{}
+	for _, q := range m.strings(optionURIQuery) {
+		if strings.HasPrefix(q, name+"=") {
+			values = append(values, q[len(name)+1:])
+		}
+	}
+	return values
+}
@@ -0,0 +1,446 @@
+package coapapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/api"
+)
+
+var _ gobot.APIServer = (*Server)(nil)
+
+// exchangeLifetime is how long the response to a confirmable request is
+// kept, to answer its retransmissions without executing it again
+var exchangeLifetime = 247 * time.Second
+
+// Server is a CoAP server of the robots of a Gobot. Its resources are:
+//
+//	GET  /robots                                  the robots
+//	GET  /robots/:robot                           a robot
+//	GET  /robots/:robot/devices/:device           a device
+//	POST /commands/:command                       executes a command of the Gobot
+//	POST /robots/:robot/commands/:command         executes a command of a robot
+//	POST /robots/:robot/devices/:device/commands/:command
+//	                                              executes a command of a device
+//	GET  /robots/:robot/events                    the events of a robot and its devices
+//	GET  /robots/:robot/devices/:device/events    the events of a device
+//	GET  /.well-known/core                        the resources, RFC 6690
+//
+// Payloads are JSON. The payload of a command is a JSON object of its
+// parameters, or empty for none, and its result is answered as
+// {"result": ...} or {"error": "..."}. An events resource answers with the
+// last event published, as {"robot", "device", "event", "data"}, and clients
+// observing it, RFC 7641, are notified of each event, filtered by name with
+// the "event" query parameters, e.g. ?event=button_*. Notifications are
+// non-confirmable, an observer is forgotten once it resets one.
+type Server struct {
+	Host string
+	Port string
+
+	gobot      *gobot.Gobot
+	conn       *net.UDPConn
+	observers  map[string]*observer
+	last       map[string][]byte
+	recent     map[string]*exchange
+	pruned     time.Time
+	messageID  uint16
+	generation int
+	stops      []func()
+	mutex      sync.Mutex
+}
+
+// observer is a client observing the events of a robot or device
+type observer struct {
+	addr   *net.UDPAddr
+	token  []byte
+	robot  string
+	device string
+	events []string
+	seq    uint32
+	lastID uint16
+}
+
+// exchange is the response to a confirmable request, nil until the request
+// is handled
+type exchange struct {
+	response []byte
+	received time.Time
+}
+
+// NewServer returns a new Server of g, listening on the CoAP port 5683. g
+// may be nil if the server is added to a Gobot with AddAPIServer.
+func NewServer(g *gobot.Gobot) *Server {
+	return &Server{
+		gobot: g,
+		Port:  "5683",
+	}
+}
+
+// Attach sets the Gobot exposed by the server.
+func (s *Server) Attach(g *gobot.Gobot) {
+	s.gobot = g
+}
+
+// Start starts listening for CoAP requests and the events of the robots and
+// their devices. The robots and devices added afterwards are served once it
+// is started again.
+func (s *Server) Start() error {
+	gobot.Log(gobot.InfoLevel, "Initializing CoAP API", gobot.Fields{"address": s.Host + ":" + s.Port})
+	addr, err := net.ResolveUDPAddr("udp", s.Host+":"+s.Port)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.conn = conn
+	s.generation++
+	generation := s.generation
+	s.observers = make(map[string]*observer)
+	s.last = make(map[string][]byte)
+	s.recent = make(map[string]*exchange)
+	s.mutex.Unlock()
+
+	s.gobot.Robots().Each(func(robot *gobot.Robot) {
+		s.subscribe(generation, robot, robot.Name, "")
+		robot.Devices().Each(func(device gobot.Device) {
+			if eventer, ok := device.(gobot.Eventer); ok {
+				s.subscribe(generation, eventer, robot.Name, device.Name())
+			}
+		})
+	})
+	go s.serve(conn)
+	return nil
+}
+
+// Stop stops the server, forgetting its observers.
+func (s *Server) Stop() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.generation++
+	for _, stop := range s.stops {
+		stop()
+	}
+	s.stops = nil
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn, s.observers = nil, nil
+	return err
+}
+
+// serve handles the datagrams received on conn until it is closed
+func (s *Server) serve(conn *net.UDPConn) {
+	buf := make([]byte, 65536)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		req, err := parseMessage(append([]byte{}, buf[:n]...))
+		if err != nil {
+			continue
+		}
+		go s.receive(conn, addr, req)
+	}
+}
+
+// receive answers req, received from addr
+func (s *Server) receive(conn *net.UDPConn, addr *net.UDPAddr, req *message) {
+	switch {
+	case req.typ == reset:
+		s.forget(addr, req.id)
+		return
+	case req.typ == acknowledgement:
+		return
+	case req.code == codeEmpty:
+		// a ping
+		if req.typ == confirmable {
+			conn.WriteToUDP((&message{typ: reset, id: req.id}).marshal(), addr)
+		}
+		return
+	}
+
+	key := fmt.Sprintf("%v#%v", addr, req.id)
+	if req.typ == confirmable {
+		if response, duplicate := s.exchange(key); duplicate {
+			if response != nil {
+				conn.WriteToUDP(response, addr)
+			}
+			return
+		}
+	}
+
+	res := s.handle(addr, req)
+	res.token = req.token
+	if req.typ == confirmable {
+		res.typ, res.id = acknowledgement, req.id
+	} else {
+		res.typ, res.id = nonConfirmable, s.nextID()
+	}
+	data := res.marshal()
+	if req.typ == confirmable {
+		s.mutex.Lock()
+		if e := s.recent[key]; e != nil {
+			e.response = data
+		}
+		s.mutex.Unlock()
+	}
+	conn.WriteToUDP(data, addr)
+}
+
+// exchange returns the response to the confirmable request with key and
+// true if it was already received, or else records it
+func (s *Server) exchange(key string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	if now.Sub(s.pruned) > exchangeLifetime/4 {
+		for k, e := range s.recent {
+			if now.Sub(e.received) > exchangeLifetime {
+				delete(s.recent, k)
+			}
+		}
+		s.pruned = now
+	}
+	if e, ok := s.recent[key]; ok {
+		return e.response, true
+	}
+	if s.recent != nil {
+		s.recent[key] = &exchange{received: now}
+	}
+	return nil, false
+}
+
+// handle returns the response to req, received from addr
+func (s *Server) handle(addr *net.UDPAddr, req *message) *message {
+	p := req.path()
+	if len(p) == 2 && p[0] == ".well-known" && p[1] == "core" {
+		if req.code != codeGet {
+			return diagnostic(codeMethodNotAllowed, "Method Not Allowed")
+		}
+		res := &message{code: codeContent, payload: []byte(s.links())}
+		res.addUint(optionContentFormat, formatLinks)
+		return res
+	}
+
+	switch {
+	case len(p) == 2 && p[0] == "commands":
+		return s.command(req, s.gobot.Commander, p[1])
+	case len(p) == 0 || p[0] != "robots":
+		return diagnostic(codeNotFound, "Not Found")
+	case len(p) == 1:
+		if req.code != codeGet {
+			return diagnostic(codeMethodNotAllowed, "Method Not Allowed")
+		}
+		robots := []*gobot.JSONRobot{}
+		s.gobot.Robots().Each(func(robot *gobot.Robot) {
+			robots = append(robots, gobot.NewJSONRobot(robot))
+		})
+		return content(robots)
+	}
+
+	robot := s.gobot.Robot(p[1])
+	if robot == nil {
+		return diagnostic(codeNotFound, "No Robot found with the name "+p[1])
+	}
+	switch {
+	case len(p) == 2:
+		if req.code != codeGet {
+			return diagnostic(codeMethodNotAllowed, "Method Not Allowed")
+		}
+		return content(gobot.NewJSONRobot(robot))
+	case len(p) == 3 && p[2] == "events":
+		return s.events(addr, req, robot.Name, "")
+	case len(p) == 4 && p[2] == "commands":
+		return s.command(req, robot.Commander, p[3])
+	case len(p) < 4 || p[2] != "devices":
+		return diagnostic(codeNotFound, "Not Found")
+	}
+
+	device := robot.Device(p[3])
+	if device == nil {
+		return diagnostic(codeNotFound, "No Device found with the name "+p[3])
+	}
+	switch {
+	case len(p) == 4:
+		if req.code != codeGet {
+			return diagnostic(codeMethodNotAllowed, "Method Not Allowed")
+		}
+		return content(gobot.NewJSONDevice(device))
+	case len(p) == 5 && p[4] == "events":
+		if _, ok := device.(gobot.Eventer); !ok {
+			return diagnostic(codeNotFound, "No Events found for the device "+device.Name())
+		}
+		return s.events(addr, req, robot.Name, device.Name())
+	case len(p) == 6 && p[4] == "commands":
+		commander, ok := device.(gobot.Commander)
+		if !ok {
+			return diagnostic(codeNotFound, "Unknown Command")
+		}
+		return s.command(req, commander, p[5])
+	}
+	return diagnostic(codeNotFound, "Not Found")
+}
+
+// command executes the command name of c with the parameters of the payload
+// of req
+func (s *Server) command(req *message, c gobot.Commander, name string) *message {
+	if req.code != codePost {
+		return diagnostic(codeMethodNotAllowed, "Method Not Allowed")
+	}
+	command := c.Command(name)
+	if command == nil {
+		return diagnostic(codeNotFound, "Unknown Command")
+	}
+	params := make(map[string]interface{})
+	if len(strings.TrimSpace(string(req.payload))) > 0 {
+		if err := json.Unmarshal(req.payload, &params); err != nil {
+			return diagnostic(codeBadRequest, err.Error())
+		}
+	}
+	result := command(params)
+	res := content(map[string]interface{}{"result": result})
+	if err, ok := result.(error); ok {
+		res = content(map[string]interface{}{"error": err.Error()})
+	}
+	res.code = codeChanged
+	return res
+}
+
+// events answers with the last event of the robot or device, registering
+// or deregistering addr as an observer of its events
+func (s *Server) events(addr *net.UDPAddr, req *message, robot string, device string) *message {
+	if req.code != codeGet {
+		return diagnostic(codeMethodNotAllowed, "Method Not Allowed")
+	}
+	events := req.query("event")
+	if err := api.CheckEventFilters(events); err != nil {
+		return diagnostic(codeBadRequest, err.Error())
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	res := &message{code: codeContent, payload: []byte("null")}
+	if last, ok := s.last[robot+"/"+device]; ok {
+		res.payload = last
+	}
+	res.addUint(optionContentFormat, formatJSON)
+
+	key := addr.String() + "#" + string(req.token)
+	switch observe, ok := req.uint(optionObserve); {
+	case ok && observe == 0 && s.observers != nil:
+		s.observers[key] = &observer{
+			addr:   addr,
+			token:  req.token,
+			robot:  robot,
+			device: device,
+			events: events,
+		}
+		res.addUint(optionObserve, 0)
+	case ok && observe == 1:
+		delete(s.observers, key)
+	}
+	return res
+}
+
+// forget forgets the observer which reset the notification with id
+func (s *Server) forget(addr *net.UDPAddr, id uint16) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for key, o := range s.observers {
+		if o.addr.String() == addr.String() && o.lastID == id {
+			delete(s.observers, key)
+		}
+	}
+}
+
+// subscribe notifies the observers of the events of e, of the device of
+// robot if any, until the server is stopped
+func (s *Server) subscribe(generation int, e gobot.Eventer, robot string, device string) {
+	stop, err := e.OnPattern("*", func(name string, data interface{}) {
+		payload, err := json.Marshal(api.NewJSONEvent(robot, device, name, data))
+		if err != nil {
+			gobot.Log(gobot.ErrorLevel, err.Error(), gobot.Fields{"event": name})
+			return
+		}
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if s.generation != generation || s.conn == nil {
+			return
+		}
+		s.last[robot+"/"+device] = payload
+		if device != "" {
+			s.last[robot+"/"] = payload
+		}
+		for _, o := range s.observers {
+			if o.robot != robot || (o.device != "" && o.device != device) || !api.MatchEvent(o.events, name) {
+				continue
+			}
+			// the sequence numbers are 24 bits
+			o.seq = (o.seq + 1) & 0xffffff
+			o.lastID = s.messageID + 1
+			s.messageID++
+			n := &message{typ: nonConfirmable, code: codeContent, id: o.lastID, token: o.token, payload: payload}
+			n.addUint(optionObserve, o.seq)
+			n.addUint(optionContentFormat, formatJSON)
+			s.conn.WriteToUDP(n.marshal(), o.addr)
+		}
+	})
+	if err != nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stops = append(s.stops, stop)
+}
+
+// nextID returns the id of a new message
+func (s *Server) nextID() uint16 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.messageID++
+	return s.messageID
+}
+
+// links returns the resources of the server in the CoRE Link Format
+func (s *Server) links() string {
+	links := []string{"</robots>;ct=50"}
+	s.gobot.Robots().Each(func(robot *gobot.Robot) {
+		prefix := "</robots/" + robot.Name
+		links = append(links, prefix+">;ct=50", prefix+"/events>;obs;ct=50")
+		robot.Devices().Each(func(device gobot.Device) {
+			links = append(links, prefix+"/devices/"+device.Name()+">;ct=50")
+			if _, ok := device.(gobot.Eventer); ok {
+				links = append(links, prefix+"/devices/"+device.Name()+"/events>;obs;ct=50")
+			}
+		})
+	})
+	return strings.Join(links, ",")
+}
+
+// content returns a 2.05 Content response with v as JSON
+func content(v interface{}) *message {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return diagnostic(codeInternalError, err.Error())
+	}
+	res := &message{code: codeContent, payload: payload}
+	res.addUint(optionContentFormat, formatJSON)
+	return res
+}
+
+// diagnostic returns an error response with a diagnostic payload
+func diagnostic(code uint8, text string) *message {
+	return &message{code: code, payload: []byte(text)}
+}
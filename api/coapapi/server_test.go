@@ -0,0 +1,214 @@
+package coapapi
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func init() {
+	log.SetOutput(&bytes.Buffer{})
+}
+
+type testAdaptor struct{}
+
+func (t *testAdaptor) Connect() (errs []error)  { return }
+func (t *testAdaptor) Finalize() (errs []error) { return }
+func (t *testAdaptor) Name() string             { return "board" }
+func (t *testAdaptor) Port() string             { return "" }
+
+type testDriver struct {
+	name       string
+	connection gobot.Connection
+	gobot.Commander
+	gobot.Eventer
+}
+
+func (t *testDriver) Start() (errs []error)        { return }
+func (t *testDriver) Halt() (errs []error)         { return }
+func (t *testDriver) Name() string                 { return t.name }
+func (t *testDriver) Connection() gobot.Connection { return t.connection }
+
+func initTestServer() (*Server, *testDriver, *int32) {
+	g := gobot.NewGobot()
+	g.AddCommand("Ping", func(params map[string]interface{}) interface{} { return "pong" })
+	toggles := new(int32)
+	board := &testAdaptor{}
+	led := &testDriver{name: "led", connection: board, Commander: gobot.NewCommander(), Eventer: gobot.NewEventer()}
+	led.AddCommand("Toggle", func(params map[string]interface{}) interface{} {
+		return atomic.AddInt32(toggles, 1)
+	})
+	led.AddCommand("Fail", func(params map[string]interface{}) interface{} { return errors.New("failed") })
+	led.AddEvent("toggled")
+	led.AddEvent("error")
+	g.AddRobot(gobot.NewRobot("bot", []gobot.Connection{board}, []gobot.Device{led}))
+	s := NewServer(g)
+	s.Host, s.Port = "127.0.0.1", "0"
+	return s, led, toggles
+}
+
+// dial returns a connection to s
+func dial(t *testing.T, s *Server) *net.UDPConn {
+	conn, err := net.DialUDP("udp", nil, s.conn.LocalAddr().(*net.UDPAddr))
+	gobot.Assert(t, err, nil)
+	return conn
+}
+
+// send sends req on conn and returns the next message received
+func send(t *testing.T, conn *net.UDPConn, req *message) *message {
+	conn.Write(req.marshal())
+	return receive(t, conn)
+}
+
+// receive returns the next message received on conn
+func receive(t *testing.T, conn *net.UDPConn) *message {
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	gobot.Assert(t, err, nil)
+	m, err := parseMessage(buf[:n])
+	gobot.Assert(t, err, nil)
+	return m
+}
+
+// request returns a request for the resource at path
+func request(typ uint8, code uint8, id uint16, resource string, payload string) *message {
+	m := &message{typ: typ, code: code, id: id, token: []byte{byte(id)}, payload: []byte(payload)}
+	p := resource
+	if i := strings.Index(resource, "?"); i >= 0 {
+		p = resource[:i]
+		for _, q := range strings.Split(resource[i+1:], "&") {
+			m.options = append(m.options, option{number: optionURIQuery, value: []byte(q)})
+		}
+	}
+	for _, segment := range strings.Split(strings.Trim(p, "/"), "/") {
+		m.options = append(m.options, option{number: optionURIPath, value: []byte(segment)})
+	}
+	return m
+}
+
+func TestMessage(t *testing.T) {
+	m := &message{typ: confirmable, code: codeGet, id: 0x1234, token: []byte{1, 2}, payload: []byte("{}")}
+	m.options = append(m.options, option{number: optionURIQuery, value: []byte(strings.Repeat("q", 300))})
+	m.options = append(m.options, option{number: optionURIPath, value: []byte("robots")})
+	m.addUint(optionObserve, 0)
+	m.addUint(2048, 0x10203)
+
+	parsed, err := parseMessage(m.marshal())
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, parsed.typ, uint8(confirmable))
+	gobot.Assert(t, parsed.id, uint16(0x1234))
+	gobot.Assert(t, parsed.token, []byte{1, 2})
+	gobot.Assert(t, parsed.path(), []string{"robots"})
+	gobot.Assert(t, parsed.query("q"), []string{})
+	gobot.Assert(t, len(parsed.strings(optionURIQuery)[0]), 300)
+	observe, ok := parsed.uint(optionObserve)
+	gobot.Assert(t, ok, true)
+	gobot.Assert(t, observe, uint32(0))
+	v, _ := parsed.uint(2048)
+	gobot.Assert(t, v, uint32(0x10203))
+	gobot.Assert(t, string(parsed.payload), "{}")
+
+	_, err = parseMessage([]byte{0x80, 1, 0, 0})
+	gobot.Assert(t, err, errors.New("Unsupported CoAP version"))
+	_, err = parseMessage([]byte{0x40, 1, 0, 0, 0xff})
+	gobot.Assert(t, err, errors.New("Empty payload after the payload marker"))
+}
+
+func TestServerResources(t *testing.T) {
+	s, _, toggles := initTestServer()
+	gobot.Assert(t, s.Start(), nil)
+	defer s.Stop()
+	conn := dial(t, s)
+	defer conn.Close()
+
+	res := send(t, conn, request(confirmable, codeGet, 1, "/robots/bot", ""))
+	gobot.Assert(t, res.typ, uint8(acknowledgement))
+	gobot.Assert(t, res.id, uint16(1))
+	gobot.Assert(t, res.token, []byte{1})
+	gobot.Assert(t, res.code, uint8(codeContent))
+	format, _ := res.uint(optionContentFormat)
+	gobot.Assert(t, format, uint32(formatJSON))
+	gobot.Assert(t, strings.HasPrefix(string(res.payload), `{"name":"bot"`), true)
+
+	res = send(t, conn, request(nonConfirmable, codeGet, 2, "/robots/rover", ""))
+	gobot.Assert(t, res.typ, uint8(nonConfirmable))
+	gobot.Assert(t, res.code, uint8(codeNotFound))
+	gobot.Assert(t, string(res.payload), "No Robot found with the name rover")
+
+	res = send(t, conn, request(confirmable, codePost, 3, "/commands/Ping", ""))
+	gobot.Assert(t, res.code, uint8(codeChanged))
+	gobot.Assert(t, string(res.payload), `{"result":"pong"}`)
+
+	res = send(t, conn, request(confirmable, codePost, 4, "/robots/bot/devices/led/commands/Fail", ""))
+	gobot.Assert(t, string(res.payload), `{"error":"failed"}`)
+
+	res = send(t, conn, request(confirmable, codePost, 5, "/robots/bot/devices/led/commands/Toggle", `{}`))
+	gobot.Assert(t, string(res.payload), `{"result":1}`)
+	// a retransmission is answered without executing the command again
+	res = send(t, conn, request(confirmable, codePost, 5, "/robots/bot/devices/led/commands/Toggle", `{}`))
+	gobot.Assert(t, string(res.payload), `{"result":1}`)
+	gobot.Assert(t, atomic.LoadInt32(toggles), int32(1))
+
+	res = send(t, conn, request(confirmable, codeGet, 6, "/robots/bot/devices/led/commands/Toggle", ""))
+	gobot.Assert(t, res.code, uint8(codeMethodNotAllowed))
+	res = send(t, conn, request(confirmable, codePost, 7, "/robots/bot/commands/Toggle", "not json"))
+	gobot.Assert(t, res.code, uint8(codeNotFound))
+
+	res = send(t, conn, request(confirmable, codeGet, 8, "/.well-known/core", ""))
+	gobot.Assert(t, string(res.payload), "</robots>;ct=50,</robots/bot>;ct=50,</robots/bot/events>;obs;ct=50,"+
+		"</robots/bot/devices/led>;ct=50,</robots/bot/devices/led/events>;obs;ct=50")
+
+	// a ping
+	res = send(t, conn, &message{typ: confirmable, code: codeEmpty, id: 9})
+	gobot.Assert(t, res.typ, uint8(reset))
+	gobot.Assert(t, res.id, uint16(9))
+}
+
+func TestServerObserve(t *testing.T) {
+	s, led, _ := initTestServer()
+	gobot.Assert(t, s.Start(), nil)
+	defer s.Stop()
+	conn := dial(t, s)
+	defer conn.Close()
+
+	req := request(confirmable, codeGet, 1, "/robots/bot/devices/led/events?event=togg*", "")
+	req.addUint(optionObserve, 0)
+	res := send(t, conn, req)
+	gobot.Assert(t, res.code, uint8(codeContent))
+	gobot.Assert(t, string(res.payload), "null")
+	_, ok := res.uint(optionObserve)
+	gobot.Assert(t, ok, true)
+
+	gobot.Publish(led.Event("toggled"), true)
+	n := receive(t, conn)
+	gobot.Assert(t, n.typ, uint8(nonConfirmable))
+	gobot.Assert(t, n.token, []byte{1})
+	seq, _ := n.uint(optionObserve)
+	gobot.Assert(t, seq, uint32(1))
+	gobot.Assert(t, string(n.payload), `{"robot":"bot","device":"led","event":"toggled","data":true}`)
+
+	// the last event is the state of the resource
+	res = send(t, conn, request(confirmable, codeGet, 2, "/robots/bot/devices/led/events", ""))
+	gobot.Assert(t, string(res.payload), `{"robot":"bot","device":"led","event":"toggled","data":true}`)
+
+	// the events filtered out are not notified
+	gobot.Publish(led.Event("error"), errors.New("burnt out"))
+	conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	_, err := conn.Read(make([]byte, 1024))
+	gobot.Refute(t, err, nil)
+
+	// an observer resetting a notification is forgotten
+	conn.Write((&message{typ: reset, id: n.id}).marshal())
+	time.Sleep(20 * time.Millisecond)
+	s.mutex.Lock()
+	gobot.Assert(t, len(s.observers), 0)
+	s.mutex.Unlock()
+}
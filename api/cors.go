@@ -3,7 +3,9 @@ package api
 import (
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // CORS represents CORS configuration
@@ -13,6 +15,60 @@ type CORS struct {
 	AllowMethods        []string
 	ContentType         string
 	allowOriginPatterns []string
+
+	// AllowCredentials lets browsers send cookies and authorization headers
+	// with requests from the allowed origins
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache the answer to a preflight request
+	MaxAge time.Duration
+}
+
+// UseCORS makes the api answer the requests from the origins allowed by c
+// with the CORS headers, and answer their preflight requests itself, before
+// any handler, so browser based control panels hosted on another origin can
+// call it. AllowMethods defaults to GET and POST, and AllowHeaders to
+// Origin, Content-Type and Authorization.
+func (a *API) UseCORS(c *CORS) {
+	if len(c.AllowMethods) == 0 {
+		c.AllowMethods = []string{"GET", "POST"}
+	}
+	if len(c.AllowHeaders) == 0 {
+		c.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
+	}
+	c.allowOriginPatterns = nil
+	c.generatePatterns()
+	a.cors = c
+}
+
+// apply sets the CORS headers of the response to req if its origin is
+// allowed. Returns true if req is a preflight request, which was answered.
+func (c *CORS) apply(res http.ResponseWriter, req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	res.Header().Add("Vary", "Origin")
+	preflight := req.Method == "OPTIONS" && req.Header.Get("Access-Control-Request-Method") != ""
+	if !c.isOriginAllowed(origin) {
+		if preflight {
+			http.Error(res, "Origin not allowed", http.StatusForbidden)
+		}
+		return preflight
+	}
+	res.Header().Set("Access-Control-Allow-Origin", origin)
+	if c.AllowCredentials {
+		res.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+	if !preflight {
+		return false
+	}
+	res.Header().Set("Access-Control-Allow-Methods", c.AllowedMethods())
+	res.Header().Set("Access-Control-Allow-Headers", c.AllowedHeaders())
+	if c.MaxAge > 0 {
+		res.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge/time.Second)))
+	}
+	res.WriteHeader(http.StatusNoContent)
+	return true
 }
 
 // AllowRequestsFrom returns handler to verify that requests come from allowedOrigins
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/hybridgroup/gobot"
 )
@@ -81,3 +82,43 @@ func TestCORS(t *testing.T) {
 	gobot.Refute(t, response.Header()["Access-Control-Allow-Origin"], disallowedOrigin)
 	gobot.Refute(t, response.Header()["Access-Control-Allow-Origin"], allowedOrigin)
 }
+
+func TestUseCORS(t *testing.T) {
+	a := initTestAPI()
+	a.UseCORS(&CORS{
+		AllowOrigins:     []string{"http://*.server.com"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	a.AddHandler(BasicAuth("admin", "password"))
+
+	// preflight requests are answered before authentication
+	request, _ := http.NewRequest("OPTIONS", "/api/robots", nil)
+	request.Header.Set("Origin", "http://panel.server.com")
+	request.Header.Set("Access-Control-Request-Method", "POST")
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, http.StatusNoContent)
+	gobot.Assert(t, response.Header().Get("Access-Control-Allow-Origin"), "http://panel.server.com")
+	gobot.Assert(t, response.Header().Get("Access-Control-Allow-Credentials"), "true")
+	gobot.Assert(t, response.Header().Get("Access-Control-Allow-Methods"), "GET,POST")
+	gobot.Assert(t, response.Header().Get("Access-Control-Allow-Headers"), "Origin,Content-Type,Authorization")
+	gobot.Assert(t, response.Header().Get("Access-Control-Max-Age"), "600")
+
+	request, _ = http.NewRequest("OPTIONS", "/api/robots", nil)
+	request.Header.Set("Origin", "http://disallowed.com")
+	request.Header.Set("Access-Control-Request-Method", "POST")
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, http.StatusForbidden)
+	gobot.Assert(t, response.Header().Get("Access-Control-Allow-Origin"), "")
+
+	request, _ = http.NewRequest("GET", "/api/robots", nil)
+	request.Header.Set("Origin", "http://panel.server.com")
+	request.SetBasicAuth("admin", "password")
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, http.StatusOK)
+	gobot.Assert(t, response.Header().Get("Access-Control-Allow-Origin"), "http://panel.server.com")
+	gobot.Assert(t, response.Header().Get("Access-Control-Allow-Methods"), "")
+}
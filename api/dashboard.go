@@ -0,0 +1,341 @@
+package api
+
+import "net/http"
+
+// dashboard returns the dashboard route handler.
+// Writes the dashboard, a page listing the robots with their devices, forms
+// running their commands, live charts of the numeric data of their events
+// and an event log, built from the /api/v2 routes and the WebSocket stream
+// of the robot events. The page reads an access token from its fragment,
+// e.g. /dashboard#access_token=..., when the api uses UseJWT.
+func (a *API) dashboard(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Write([]byte(dashboardHTML))
+}
+
+// dashboardHTML is the dashboard page, self contained so it works without
+// network access to anything but the api
+const dashboardHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Gobot</title>
+<style>
+* { box-sizing: border-box; }
+body { margin: 0; font: 14px/1.4 -apple-system, "Segoe UI", Roboto, sans-serif; color: #1f2933; background: #f0f2f5; }
+header { display: flex; align-items: center; gap: 1em; padding: .75em 1.5em; color: #fff; background: #1f2933; }
+header h1 { margin: 0; font-size: 1.25em; }
+header select { margin-left: auto; }
+#status { font-size: .85em; opacity: .8; }
+main { display: grid; grid-template-columns: minmax(0, 2fr) minmax(0, 1fr); gap: 1em; padding: 1em 1.5em; }
+@media (max-width: 900px) { main { grid-template-columns: 1fr; } }
+section { padding: 1em; background: #fff; border-radius: 6px; box-shadow: 0 1px 2px rgba(0, 0, 0, .1); }
+section + section { margin-top: 1em; }
+h2 { margin: 0 0 .5em; font-size: 1.1em; }
+h3 { margin: 0; font-size: 1em; }
+.meta { color: #616e7c; font-size: .85em; }
+.devices { display: grid; grid-template-columns: repeat(auto-fill, minmax(260px, 1fr)); gap: 1em; }
+.device { padding: .75em; border: 1px solid #e4e7eb; border-radius: 4px; }
+.device.disabled { opacity: .5; }
+.toggles { margin: .5em 0; }
+button { padding: .25em .75em; border: 1px solid #3e4c59; border-radius: 3px; color: #fff; background: #3e4c59; cursor: pointer; }
+button.secondary { color: #3e4c59; background: #fff; }
+form { margin: .5em 0 0; padding-top: .5em; border-top: 1px solid #e4e7eb; }
+form label { display: block; margin: .25em 0; }
+form input[type=text], form input[type=number], form textarea { width: 100%; padding: .25em; font: inherit; }
+.result { margin: .25em 0 0; font-family: monospace; font-size: .85em; white-space: pre-wrap; word-break: break-all; }
+.result.error { color: #cf1124; }
+canvas { display: block; width: 100%; height: 60px; margin: .25em 0 .75em; background: #f5f7fa; }
+#log { max-height: 70vh; margin: 0; padding: 0; overflow-y: auto; list-style: none; font-family: monospace; font-size: .85em; }
+#log li { padding: .25em 0; border-bottom: 1px solid #e4e7eb; word-break: break-all; }
+#log time { color: #616e7c; }
+</style>
+</head>
+<body>
+<header>
+<h1>Gobot</h1>
+<span id="status">connecting</span>
+<select id="robot"></select>
+</header>
+<main>
+<div>
+<section id="commands"></section>
+<section id="devices"></section>
+</div>
+<div>
+<section><h2>Charts</h2><div id="charts" class="meta">Numeric event data is charted here.</div></section>
+<section><h2>Events</h2><ul id="log"></ul></section>
+</div>
+</main>
+<script>
+(function () {
+  "use strict";
+
+  var token = new URLSearchParams(location.hash.slice(1)).get("access_token");
+  var maxEvents = 200, maxPoints = 120;
+  var socket = null, series = {};
+
+  function $(id) { return document.getElementById(id); }
+
+  // el creates an element with attributes and children, strings become text
+  function el(tag, attrs, children) {
+    var e = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (k) {
+      if (k.slice(0, 2) === "on") {
+        e.addEventListener(k.slice(2), attrs[k]);
+      } else {
+        e.setAttribute(k, attrs[k]);
+      }
+    });
+    (children || []).forEach(function (c) {
+      e.appendChild(typeof c === "string" ? document.createTextNode(c) : c);
+    });
+    return e;
+  }
+
+  function request(method, url, body) {
+    var headers = {"Accept": "application/json"};
+    if (token) {
+      headers["Authorization"] = "Bearer " + token;
+    }
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+      body = JSON.stringify(body);
+    }
+    return fetch(url, {method: method, headers: headers, body: body}).then(function (res) {
+      return res.json().then(function (j) {
+        if (!res.ok) {
+          throw new Error(j.error ? j.error.message : res.statusText);
+        }
+        return j;
+      });
+    });
+  }
+
+  function path() {
+    return "/api/v2/robots/" + Array.prototype.map.call(arguments, encodeURIComponent).join("/");
+  }
+
+  // run runs a command and shows its result in out
+  function run(url, params, out) {
+    out.className = "result";
+    out.textContent = "...";
+    request("POST", url, params).then(function (j) {
+      out.textContent = JSON.stringify(j.result !== undefined ? j.result : j);
+    }).catch(function (err) {
+      out.className = "result error";
+      out.textContent = err.message;
+    });
+  }
+
+  // commandForm returns a form running the command at url, with an input
+  // per parameter of the command, or a JSON body if it declares none
+  function commandForm(url, command) {
+    var out = el("div", {"class": "result"});
+    var inputs = command.params.map(function (p) {
+      var attrs = {name: p.name};
+      if (p.type === "bool") {
+        attrs.type = "checkbox";
+        if (p.default === true) {
+          attrs.checked = "";
+        }
+      } else {
+        attrs.type = p.type === "int" || p.type === "float" ? "number" : "text";
+        if (p.type === "float") {
+          attrs.step = "any";
+        }
+        if (p.min < p.max) {
+          attrs.min = p.min;
+          attrs.max = p.max;
+        }
+        if (p.default !== undefined) {
+          attrs.placeholder = String(p.default);
+        }
+        if (p.required) {
+          attrs.required = "";
+        }
+      }
+      return el("input", attrs);
+    });
+    var body = command.params.length ? null : el("textarea", {rows: 1, placeholder: "{}"});
+    var fields = command.params.map(function (p, i) {
+      return el("label", {}, [p.name + (p.required ? " *" : "") + " ", inputs[i]]);
+    });
+    return el("form", {
+      onsubmit: function (e) {
+        e.preventDefault();
+        var params = {};
+        if (body) {
+          try {
+            params = JSON.parse(body.value || "{}");
+          } catch (err) {
+            out.className = "result error";
+            out.textContent = err.message;
+            return;
+          }
+        }
+        command.params.forEach(function (p, i) {
+          var input = inputs[i];
+          if (p.type === "bool") {
+            params[p.name] = input.checked;
+          } else if (input.value !== "") {
+            params[p.name] = p.type === "string" ? input.value : Number(input.value);
+          }
+        });
+        run(url, params, out);
+      }
+    }, [el("h3", {}, [command.name])].concat(fields, body ? [body] : [],
+      [el("button", {type: "submit", "class": "secondary"}, ["Run"]), out]));
+  }
+
+  // toggles returns buttons for the on/off commands of a device
+  function toggles(robot, device) {
+    var names = {}, out = el("div", {"class": "result"}), buttons = [];
+    device.commands.forEach(function (c) { names[c.name] = true; });
+    function button(label, command, params) {
+      buttons.push(el("button", {onclick: function () {
+        run(path(robot, "devices", device.name, "commands", command), params, out);
+      }}, [label]));
+    }
+    if (names.Toggle) {
+      button("Toggle", "Toggle", {});
+    }
+    if (names.On && names.Off) {
+      button("On", "On", {});
+      button("Off", "Off", {});
+    } else if (names.DigitalWrite) {
+      button("High", "DigitalWrite", {level: "1"});
+      button("Low", "DigitalWrite", {level: "0"});
+    }
+    return buttons.length ? el("div", {"class": "toggles"}, buttons.concat([out])) : null;
+  }
+
+  function render(robot) {
+    var commands = $("commands"), devices = $("devices");
+    commands.textContent = "";
+    commands.appendChild(el("h2", {}, [robot.name + " commands"]));
+    if (!robot.commands.length) {
+      commands.appendChild(el("div", {"class": "meta"}, ["The robot has no commands."]));
+    }
+    robot.commands.forEach(function (c) {
+      commands.appendChild(commandForm(path(robot.name, "commands", c.name), c));
+    });
+
+    devices.textContent = "";
+    devices.appendChild(el("h2", {}, ["Devices"]));
+    var grid = el("div", {"class": "devices"});
+    robot.devices.forEach(function (d) {
+      var card = el("div", {"class": "device" + (d.disabled ? " disabled" : "")}, [
+        el("h3", {}, [d.name]),
+        el("div", {"class": "meta"}, [d.driver + " on " + d.connection + (d.pin ? " pin " + d.pin : "")])
+      ]);
+      var t = toggles(robot.name, d);
+      if (t) {
+        card.appendChild(t);
+      }
+      d.commands.forEach(function (c) {
+        card.appendChild(commandForm(path(robot.name, "devices", d.name, "commands", c.name), c));
+      });
+      grid.appendChild(card);
+    });
+    devices.appendChild(grid);
+  }
+
+  // chart adds the value of an event to its series and redraws it
+  function chart(key, value) {
+    var s = series[key];
+    if (!s) {
+      if (!Object.keys(series).length) {
+        $("charts").textContent = "";
+      }
+      s = series[key] = {values: [], label: el("div", {"class": "meta"}), canvas: el("canvas")};
+      $("charts").appendChild(s.label);
+      $("charts").appendChild(s.canvas);
+    }
+    s.values.push(value);
+    if (s.values.length > maxPoints) {
+      s.values.shift();
+    }
+    var min = Math.min.apply(null, s.values), max = Math.max.apply(null, s.values);
+    s.label.textContent = key + ": " + value + " (" + min + " to " + max + ")";
+
+    var c = s.canvas, ctx = c.getContext("2d");
+    c.width = c.clientWidth;
+    c.height = c.clientHeight;
+    ctx.clearRect(0, 0, c.width, c.height);
+    ctx.strokeStyle = "#2680c2";
+    ctx.lineWidth = 2;
+    ctx.beginPath();
+    s.values.forEach(function (v, i) {
+      var x = c.width * i / (maxPoints - 1);
+      var y = max === min ? c.height / 2 : c.height - 4 - (c.height - 8) * (v - min) / (max - min);
+      if (i === 0) {
+        ctx.moveTo(x, y);
+      } else {
+        ctx.lineTo(x, y);
+      }
+    });
+    ctx.stroke();
+  }
+
+  function log(event) {
+    var list = $("log");
+    var name = (event.device ? event.device + " " : "") + event.name;
+    list.insertBefore(el("li", {}, [
+      el("time", {}, [new Date(event.time).toLocaleTimeString()]), " " + name + " " + JSON.stringify(event.data)
+    ]), list.firstChild);
+    while (list.childNodes.length > maxEvents) {
+      list.removeChild(list.lastChild);
+    }
+    if (typeof event.data === "number") {
+      chart(name, event.data);
+    }
+  }
+
+  function connect(robot) {
+    if (socket) {
+      socket.onclose = null;
+      socket.close();
+    }
+    series = {};
+    $("charts").textContent = "Numeric event data is charted here.";
+    $("log").textContent = "";
+    var url = (location.protocol === "https:" ? "wss://" : "ws://") + location.host + path(robot, "events");
+    if (token) {
+      url += "?access_token=" + encodeURIComponent(token);
+    }
+    socket = new WebSocket(url);
+    socket.onopen = function () { $("status").textContent = "live"; };
+    socket.onmessage = function (m) { log(JSON.parse(m.data)); };
+    socket.onclose = function () {
+      $("status").textContent = "disconnected, reconnecting";
+      setTimeout(function () { connect(robot); }, 2000);
+    };
+  }
+
+  function select(name) {
+    request("GET", path(name)).then(function (robot) {
+      render(robot);
+      connect(robot.name);
+    }).catch(function (err) { $("status").textContent = err.message; });
+  }
+
+  request("GET", "/api/v2/robots").then(function (j) {
+    var robots = $("robot");
+    j.robots.forEach(function (r) {
+      robots.appendChild(el("option", {value: r.name}, [r.name]));
+    });
+    robots.onchange = function () { select(robots.value); };
+    if (j.robots.length) {
+      select(j.robots[0].name);
+    } else {
+      $("status").textContent = "no robots";
+    }
+  }).catch(function (err) { $("status").textContent = err.message; });
+})();
+</script>
+</body>
+</html>
+`
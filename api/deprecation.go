@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Deprecation describes routes which are deprecated, see API.Deprecate.
+type Deprecation struct {
+	// Routes are the deprecated paths, as path.Match patterns optionally
+	// prefixed with a method, e.g. "GET /api/robots/*".
+	Routes []string
+	// Sunset is when the routes will be removed, if known
+	Sunset time.Time
+	// Successor returns the path replacing a deprecated path, or "" if
+	// there is none
+	Successor func(path string) string
+	// Link is the URL of the documentation of the deprecation, if any
+	Link string
+}
+
+// v1Routes are the legacy routes which have a /api/v2 successor
+var v1Routes = []string{
+	"/api/commands",
+	"/api/commands/*",
+	"/api/jobs/*",
+	"/api/robots",
+	"/api/robots/*",
+	"/api/robots/*/commands",
+	"/api/robots/*/commands/*",
+	"/api/robots/*/jobs/*",
+	"/api/robots/*/events",
+	"/api/robots/*/devices",
+	"/api/robots/*/devices/*",
+	"/api/robots/*/devices/*/commands",
+	"/api/robots/*/devices/*/commands/*",
+	"/api/robots/*/devices/*/jobs/*",
+	"/api/robots/*/devices/*/events",
+}
+
+// Deprecate makes the api answer the requests to the routes of d with a
+// "Deprecation: true" header, a Sunset header if d.Sunset is set, and Link
+// headers to the successor of the route and to the documentation, so
+// clients can find out about the deprecation before the routes are removed.
+func (a *API) Deprecate(d *Deprecation) {
+	a.AddHandler(func(res http.ResponseWriter, req *http.Request) {
+		if !matchRoute(d.Routes, req) {
+			return
+		}
+		res.Header().Set("Deprecation", "true")
+		if !d.Sunset.IsZero() {
+			res.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Successor != nil {
+			if successor := d.Successor(req.URL.Path); successor != "" {
+				res.Header().Add("Link", "<"+successor+`>; rel="successor-version"`)
+			}
+		}
+		if d.Link != "" {
+			res.Header().Add("Link", "<"+d.Link+`>; rel="deprecation"`)
+		}
+	})
+}
+
+// DeprecateV1 deprecates the legacy routes which have a /api/v2 successor,
+// to be removed at sunset, or at an unknown time if it is zero. The legacy
+// routes keep working.
+func (a *API) DeprecateV1(sunset time.Time) {
+	a.Deprecate(&Deprecation{
+		Routes: v1Routes,
+		Sunset: sunset,
+		Successor: func(path string) string {
+			return "/api/v2" + strings.TrimPrefix(path, "/api")
+		},
+	})
+}
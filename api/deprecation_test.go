@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestDeprecateV1(t *testing.T) {
+	a := initTestAPI()
+	a.DeprecateV1(time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC))
+
+	request, _ := http.NewRequest("GET", "/api/robots/Robot1", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 200)
+	gobot.Assert(t, response.Header().Get("Deprecation"), "true")
+	gobot.Assert(t, response.Header().Get("Sunset"), "Sun, 31 Jan 2027 00:00:00 GMT")
+	gobot.Assert(t, response.Header().Get("Link"), `</api/v2/robots/Robot1>; rel="successor-version"`)
+
+	// the v2 routes and the legacy routes without a successor are not
+	// deprecated
+	for _, path := range []string{"/api/v2/robots/Robot1", "/api/robots/Robot1/health"} {
+		request, _ = http.NewRequest("GET", path, nil)
+		response = httptest.NewRecorder()
+		a.ServeHTTP(response, request)
+		gobot.Assert(t, response.Code, 200)
+		gobot.Assert(t, response.Header().Get("Deprecation"), "")
+	}
+}
+
+func TestDeprecate(t *testing.T) {
+	a := initTestAPI()
+	a.Deprecate(&Deprecation{
+		Routes: []string{"POST /api/commands/*"},
+		Link:   "https://example.com/migration",
+	})
+
+	request, _ := http.NewRequest("GET", "/api/commands", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Header().Get("Deprecation"), "")
+
+	request, _ = http.NewRequest("POST", "/api/commands/TestFunction", strings.NewReader(`{"message":"hi"}`))
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Header().Get("Deprecation"), "true")
+	gobot.Assert(t, response.Header().Get("Sunset"), "")
+	gobot.Assert(t, response.Header()["Link"], []string{`<https://example.com/migration>; rel="deprecation"`})
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// shutdownRoute is the route draining the api, see AllowShutdown
+const shutdownRoute = "/api/shutdown"
+
+// drain tracks the commands executed by the api, so it can wait for them
+type drain struct {
+	draining bool
+	inflight int
+	idle     *sync.Cond
+	mutex    sync.Mutex
+	once     sync.Once
+	errs     []error
+}
+
+// Drain stops the api from executing new commands, answering the requests
+// executing them with 503 Service Unavailable, waits for the commands being
+// executed to finish, then halts the Gobot with Gobot.Shutdown. Drain
+// returns once the robots have halted and their hardware is safe, with the
+// errors of halting them. The commands run in the background with async=true
+// are not waited for. The Gobot is halted once, later calls return the same
+// errors.
+func (a *API) Drain() []error {
+	a.drain.once.Do(func() {
+		a.drain.mutex.Lock()
+		a.drain.draining = true
+		if a.drain.idle == nil {
+			a.drain.idle = sync.NewCond(&a.drain.mutex)
+		}
+		for a.drain.inflight > 0 {
+			a.drain.idle.Wait()
+		}
+		a.drain.mutex.Unlock()
+		gobot.Log(gobot.InfoLevel, "API drained, halting the robots", nil)
+		a.drain.errs = a.gobot.Shutdown()
+	})
+	return a.drain.errs
+}
+
+// Draining returns true once the api has started draining, see Drain.
+func (a *API) Draining() bool {
+	a.drain.mutex.Lock()
+	defer a.drain.mutex.Unlock()
+	return a.drain.draining
+}
+
+// beginCommand tracks a command, returns false if the api is draining
+func (a *API) beginCommand() bool {
+	a.drain.mutex.Lock()
+	defer a.drain.mutex.Unlock()
+	if a.drain.draining {
+		return false
+	}
+	a.drain.inflight++
+	return true
+}
+
+// endCommand stops tracking a command
+func (a *API) endCommand() {
+	a.drain.mutex.Lock()
+	defer a.drain.mutex.Unlock()
+	a.drain.inflight--
+	if a.drain.inflight == 0 && a.drain.idle != nil {
+		a.drain.idle.Broadcast()
+	}
+}
+
+// shutdown returns the shutdown route handler.
+// Drains the api and writes JSON once the robots have halted
+func (a *API) shutdown(res http.ResponseWriter, req *http.Request) {
+	if errs := a.Drain(); len(errs) > 0 {
+		a.writeJSON(map[string]interface{}{"error": errs[0].Error()}, res)
+		return
+	}
+	a.writeJSON(map[string]interface{}{"result": "halted"}, res)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestDrain(t *testing.T) {
+	log.SetOutput(NullReadWriteCloser{})
+	g := gobot.NewGobot()
+	robot := newTestRobot("Robot1")
+	release := make(chan struct{})
+	robot.AddCommand("Slow", func(params map[string]interface{}) interface{} {
+		<-release
+		return "done"
+	})
+	g.AddRobot(robot)
+	a := NewAPI(g)
+	a.start = func(m *API) error { return nil }
+	a.AllowShutdown = true
+	a.Start()
+
+	request := func(method string, url string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString("{}"))
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		return res
+	}
+	slow := make(chan *httptest.ResponseRecorder)
+	go func() { slow <- request("POST", "/api/robots/Robot1/commands/Slow") }()
+	for inflight := 0; inflight == 0; time.Sleep(time.Millisecond) {
+		a.drain.mutex.Lock()
+		inflight = a.drain.inflight
+		a.drain.mutex.Unlock()
+	}
+
+	shutdown := make(chan *httptest.ResponseRecorder)
+	go func() { shutdown <- request("POST", "/api/shutdown") }()
+	for draining := false; !draining; time.Sleep(time.Millisecond) {
+		a.drain.mutex.Lock()
+		draining = a.drain.draining
+		a.drain.mutex.Unlock()
+	}
+	gobot.Assert(t, request("POST", "/api/robots/Robot1/commands/Slow").Code, http.StatusServiceUnavailable)
+	gobot.Assert(t, request("GET", "/api/robots/Robot1").Code, http.StatusOK)
+	select {
+	case <-shutdown:
+		t.Fatal("Drained before the commands finished")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	gobot.Assert(t, (<-slow).Body.String(), "{\"result\":\"done\"}")
+	select {
+	case res := <-shutdown:
+		gobot.Assert(t, res.Body.String(), "{\"result\":\"halted\"}")
+	case <-time.After(time.Second):
+		t.Fatal("Drain did not return")
+	}
+	gobot.Assert(t, a.Draining(), true)
+	gobot.Assert(t, len(a.Drain()), 0)
+}
+
+func TestShutdownRoute(t *testing.T) {
+	a := initTestAPI()
+	request, _ := http.NewRequest("POST", "/api/shutdown", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, http.StatusMethodNotAllowed)
+}
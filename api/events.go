@@ -0,0 +1,365 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// EventBuffer is the number of events buffered for a slow client, further
+// events are dropped until it catches up. It is shared by the API servers
+// streaming events.
+const EventBuffer = 64
+
+// eventHeartbeat is how often a comment is written to an idle Server-Sent
+// Events stream, so proxies do not close it
+var eventHeartbeat = 15 * time.Second
+
+// JSONEvent is a JSON representation of an event published by a robot or
+// one of its devices
+type JSONEvent struct {
+	Site   string      `json:"site,omitempty"`
+	Robot  string      `json:"robot"`
+	Device string      `json:"device,omitempty"`
+	Event  string      `json:"event"`
+	Data   interface{} `json:"data"`
+	Time   time.Time   `json:"-"`
+}
+
+// NewJSONEvent returns the JSONEvent name of the device of robot, if any,
+// published now with data. Errors are replaced by their message, so they
+// are not marshalled as empty objects.
+func NewJSONEvent(robot string, device string, name string, data interface{}) JSONEvent {
+	if err, ok := data.(error); ok {
+		data = err.Error()
+	}
+	return JSONEvent{Robot: robot, Device: device, Event: name, Data: data, Time: time.Now()}
+}
+
+// eventData returns the data of event
+func eventData(event JSONEvent) interface{} {
+	return event.Data
+}
+
+// eventSource is a robot or device whose events are streamed
+type eventSource struct {
+	device  string
+	eventer gobot.Eventer
+}
+
+// robotEvents returns the robot events route handler.
+// Streams the events published by the robot and its devices, see
+// streamEvents.
+func (a *API) robotEvents(res http.ResponseWriter, req *http.Request) {
+	robot := a.gobot.Robot(req.URL.Query().Get(":robot"))
+	if robot == nil {
+		a.writeJSON(map[string]interface{}{
+			"error": "No Robot found with the name " + req.URL.Query().Get(":robot"),
+		}, res)
+		return
+	}
+	a.streamEvents(robot.Name, robotEventSources(robot), req.URL.Query()["event"], nil, res, req)
+}
+
+// robotEventSources returns the robot and those of its devices which are
+// Eventers
+func robotEventSources(robot *gobot.Robot) []eventSource {
+	sources := []eventSource{{eventer: robot}}
+	robot.Devices().Each(func(device gobot.Device) {
+		if eventer, ok := device.(gobot.Eventer); ok {
+			sources = append(sources, eventSource{device: device.Name(), eventer: eventer})
+		}
+	})
+	return sources
+}
+
+// robotDeviceEvents returns the device events route handler.
+// Streams the events published by the device, see streamEvents.
+func (a *API) robotDeviceEvents(res http.ResponseWriter, req *http.Request) {
+	if _, err := a.jsonDeviceFor(req.URL.Query().Get(":robot"), req.URL.Query().Get(":device")); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	robot := a.gobot.Robot(req.URL.Query().Get(":robot"))
+	device := robot.Device(req.URL.Query().Get(":device"))
+	eventer, ok := device.(gobot.Eventer)
+	if !ok {
+		a.writeJSON(map[string]interface{}{
+			"error": "No Events found for the device " + device.Name(),
+		}, res)
+		return
+	}
+	sources := []eventSource{{device: device.Name(), eventer: eventer}}
+	a.streamEvents(robot.Name, sources, req.URL.Query()["event"], nil, res, req)
+}
+
+// robotDeviceEvent returns the device event route handler.
+// Streams the data published on the event, see streamEvents.
+func (a *API) robotDeviceEvent(res http.ResponseWriter, req *http.Request) {
+	robot := a.gobot.Robot(req.URL.Query().Get(":robot"))
+	name := req.URL.Query().Get(":event")
+	if eventer, ok := robot.Device(req.URL.Query().Get(":device")).(gobot.Eventer); ok && eventer.Event(name) != nil {
+		sources := []eventSource{{device: req.URL.Query().Get(":device"), eventer: eventer}}
+		a.streamEvents(robot.Name, sources, []string{name}, eventData, res, req)
+	} else {
+		a.writeJSON(map[string]interface{}{
+			"error": "No Event found with the name " + name,
+		}, res)
+	}
+}
+
+// streamEvents writes the events published by sources whose name matches
+// one of filters, all events if there are no filters. Filters accept the
+// wildcards of path.Match, e.g. "button_*". The events are also filtered by
+// the query parameters of req, see newEventFilter.
+//
+// A WebSocket request is upgraded and each event written as a JSON frame.
+// Any other request is answered with a Server-Sent Events stream, with an
+// "event:" line naming the event followed by a "data:" line, and a comment
+// written every eventHeartbeat while idle. The data is the JSON event, or
+// what payload returns given the event if it is not nil.
+func (a *API) streamEvents(robot string, sources []eventSource, filters []string,
+	payload func(JSONEvent) interface{}, res http.ResponseWriter, req *http.Request) {
+	if err := CheckEventFilters(filters); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	filter, err := newEventFilter(req.URL.Query())
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	a.writeEventStream(func(closed <-chan struct{}) <-chan JSONEvent {
+		return subscribeEvents(robot, sources, filters, closed)
+	}, filter, payload, res, req)
+}
+
+// writeEventStream writes the events received from the channel returned by
+// subscribe, which must stop sending once closed is closed, and allowed by
+// filter, as a WebSocket or a Server-Sent Events stream, see streamEvents.
+func (a *API) writeEventStream(subscribe func(closed <-chan struct{}) <-chan JSONEvent,
+	filter *eventFilter, payload func(JSONEvent) interface{}, res http.ResponseWriter, req *http.Request) {
+	if headerContains(req.Header, "Upgrade", "websocket") {
+		serveWebSocket(res, req, "", nil, func(ws *webSocket) {
+			writeEvents(subscribe(ws.Closed()), filter, payload, ws.Closed(),
+				func(event string, data []byte) error {
+					return ws.WriteText(data)
+				}, nil)
+		})
+		return
+	}
+
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		http.Error(res, "Streaming not supported by the server", http.StatusInternalServerError)
+		return
+	}
+	done := make(chan struct{})
+	defer close(done)
+	closed := closeNotify(res, done)
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	writeEvents(subscribe(closed), filter, payload, closed,
+		func(event string, data []byte) error {
+			_, err := fmt.Fprintf(res, "event: %v\ndata: %s\n\n", event, data)
+			flusher.Flush()
+			return err
+		},
+		func() error {
+			_, err := fmt.Fprint(res, ": heartbeat\n\n")
+			flusher.Flush()
+			return err
+		})
+}
+
+// writeEvents writes the events allowed by filter with write, given the name
+// of the event and its JSON data, and calls heartbeat, if not nil, every
+// eventHeartbeat while idle, until closed is closed or writing fails.
+func writeEvents(events <-chan JSONEvent, filter *eventFilter, payload func(JSONEvent) interface{},
+	closed <-chan struct{}, write func(event string, data []byte) error, heartbeat func() error) {
+	ticker := time.NewTicker(eventHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-events:
+			if !filter.allow(event) {
+				continue
+			}
+			var v interface{} = event
+			if payload != nil {
+				v = payload(event)
+			}
+			data, err := json.Marshal(v)
+			if err != nil {
+				gobot.Log(gobot.ErrorLevel, err.Error(), gobot.Fields{"event": event.Event})
+				continue
+			}
+			if err := write(event.Event, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if heartbeat != nil {
+				if err := heartbeat(); err != nil {
+					return
+				}
+			}
+		case <-closed:
+			gobot.Log(gobot.DebugLevel, "Closing connection", nil)
+			return
+		}
+	}
+}
+
+// subscribeEvents returns a channel receiving the events published by
+// sources of robot whose name matches one of filters, until closed is
+// closed, which unsubscribes from the sources
+func subscribeEvents(robot string, sources []eventSource, filters []string, closed <-chan struct{}) <-chan JSONEvent {
+	events := make(chan JSONEvent, EventBuffer)
+	stops := []func(){}
+	for _, source := range sources {
+		device := source.device
+		stop, err := source.eventer.OnPattern("*", func(name string, data interface{}) {
+			if !MatchEvent(filters, name) {
+				return
+			}
+			select {
+			case <-closed:
+			case events <- NewJSONEvent(robot, device, name, data):
+			default:
+				gobot.Log(gobot.WarnLevel, "Dropping event for slow client", gobot.Fields{"event": name})
+			}
+		})
+		if err == nil {
+			stops = append(stops, stop)
+		}
+	}
+	go func() {
+		<-closed
+		for _, stop := range stops {
+			stop()
+		}
+	}()
+	return events
+}
+
+// eventFilter selects the events of a stream, so clients on slow links are
+// not sent every update of a fast sensor
+type eventFilter struct {
+	devices  []string
+	interval time.Duration
+	decimate int
+	last     map[string]time.Time
+	seen     map[string]int
+}
+
+// newEventFilter returns the filter of the query of a stream: the "device"
+// parameters are path.Match patterns of the names of the devices whose
+// events are sent, excluding the events of the robot, "interval" is the
+// minimum duration between two events of a device with the same name, e.g.
+// 100ms, and "decimate" sends one of every n such events.
+func newEventFilter(query url.Values) (*eventFilter, error) {
+	f := &eventFilter{
+		devices: query["device"],
+		last:    make(map[string]time.Time),
+		seen:    make(map[string]int),
+	}
+	for _, device := range f.devices {
+		if _, err := path.Match(device, ""); err != nil {
+			return nil, err
+		}
+	}
+	if v := query.Get("interval"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval < 0 {
+			return nil, errors.New("Invalid interval " + v)
+		}
+		f.interval = interval
+	}
+	if v := query.Get("decimate"); v != "" {
+		decimate, err := strconv.Atoi(v)
+		if err != nil || decimate < 1 {
+			return nil, errors.New("Invalid decimate " + v)
+		}
+		f.decimate = decimate
+	}
+	return f, nil
+}
+
+// allow returns true if event is sent, every event if f is nil
+func (f *eventFilter) allow(event JSONEvent) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.devices) > 0 && (event.Device == "" || !matchName(f.devices, event.Device)) {
+		return false
+	}
+	key := event.Site + "/" + event.Robot + "/" + event.Device + "/" + event.Event
+	if f.decimate > 1 {
+		n := f.seen[key]
+		f.seen[key] = (n + 1) % f.decimate
+		if n != 0 {
+			return false
+		}
+	}
+	if f.interval > 0 {
+		if last, ok := f.last[key]; ok && event.Time.Sub(last) < f.interval {
+			return false
+		}
+		f.last[key] = event.Time
+	}
+	return true
+}
+
+// closeNotify returns a channel which is closed once the client of res goes
+// away, or done is closed
+func closeNotify(res http.ResponseWriter, done chan struct{}) <-chan struct{} {
+	notifier, ok := res.(http.CloseNotifier)
+	if !ok {
+		return done
+	}
+	closed := make(chan struct{})
+	gone := notifier.CloseNotify()
+	go func() {
+		select {
+		case <-gone:
+		case <-done:
+		}
+		close(closed)
+	}()
+	return closed
+}
+
+// CheckEventFilters returns an error if one of filters is not a valid
+// path.Match pattern
+func CheckEventFilters(filters []string) error {
+	for _, filter := range filters {
+		if _, err := path.Match(filter, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MatchEvent returns true if name matches one of filters, path.Match
+// patterns, or if there are no filters
+func MatchEvent(filters []string, name string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, filter := range filters {
+		if matched, _ := path.Match(filter, name); matched {
+			return true
+		}
+	}
+	return false
+}
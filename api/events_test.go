@@ -0,0 +1,246 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// The WebSocket frame opcodes used by the tests
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opClose        = 0x8
+)
+
+// dialWebSocket makes a WebSocket handshake for path with server, asking
+// for the subprotocols if any
+func dialWebSocket(t *testing.T, server *httptest.Server, path string, protocols ...string) (net.Conn, *bufio.Reader) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	gobot.Assert(t, err, nil)
+	header := ""
+	if len(protocols) > 0 {
+		header = "Sec-WebSocket-Protocol: " + strings.Join(protocols, ", ") + "\r\n"
+	}
+	io.WriteString(conn, "GET "+path+" HTTP/1.1\r\n"+
+		"Host: localhost\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"+
+		header+
+		"Sec-WebSocket-Version: 13\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	res, err := http.ReadResponse(reader, nil)
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, res.StatusCode, http.StatusSwitchingProtocols)
+	gobot.Assert(t, res.Header.Get("Sec-WebSocket-Accept"), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=")
+	return conn, reader
+}
+
+// readWebSocketFrame reads an unmasked frame of less than 64KB
+func readWebSocketFrame(t *testing.T, conn net.Conn, reader *bufio.Reader) (byte, []byte) {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	header := make([]byte, 2)
+	_, err := io.ReadFull(reader, header)
+	gobot.Assert(t, err, nil)
+	n := int(header[1] & 0x7F)
+	if n == 126 {
+		ext := make([]byte, 2)
+		io.ReadFull(reader, ext)
+		n = int(ext[0])<<8 | int(ext[1])
+	}
+	data := make([]byte, n)
+	io.ReadFull(reader, data)
+	return header[0] & 0x0F, data
+}
+
+func TestRobotEvents(t *testing.T) {
+	a := initTestAPI()
+	server := httptest.NewServer(a)
+	defer server.Close()
+	robot := a.gobot.Robot("Robot1")
+	robot.AddEvent("moved")
+	robot.AddEvent("stopped")
+
+	conn, reader := dialWebSocket(t, server, "/api/robots/Robot1/events?event=mov*")
+	defer conn.Close()
+	// give the handler time to subscribe
+	time.Sleep(20 * time.Millisecond)
+	gobot.Publish(robot.Event("stopped"), 1)
+	gobot.Publish(robot.Event("moved"), 2)
+
+	opcode, data := readWebSocketFrame(t, conn, reader)
+	gobot.Assert(t, opcode, byte(opText))
+	var event map[string]interface{}
+	json.Unmarshal(data, &event)
+	gobot.Assert(t, event, map[string]interface{}{
+		"robot": "Robot1",
+		"event": "moved",
+		"data":  2.0,
+	})
+
+	// a masked close frame is answered with a close frame
+	conn.Write([]byte{0x80 | opClose, 0x80, 1, 2, 3, 4})
+	opcode, _ = readWebSocketFrame(t, conn, reader)
+	gobot.Assert(t, opcode, byte(opClose))
+
+	// the client is unsubscribed once it is gone
+	time.Sleep(20 * time.Millisecond)
+	gobot.Assert(t, robot.Event("moved").Subscribers(), 0)
+	gobot.Assert(t, robot.Event("stopped").Subscribers(), 0)
+}
+
+func TestRobotEventsErrors(t *testing.T) {
+	a := initTestAPI()
+	request, _ := http.NewRequest("GET", "/api/robots/UnknownRobot1/events", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	var body map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["error"], "No Robot found with the name UnknownRobot1")
+
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/events?event=[", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	body = map[string]interface{}{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["error"], "syntax error in pattern")
+
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/events?interval=fast", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	body = map[string]interface{}{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["error"], "Invalid interval fast")
+
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/devices/UnknownDevice1/events", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	body = map[string]interface{}{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["error"], "No Device found with the name UnknownDevice1")
+
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/devices/Device1/events", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	body = map[string]interface{}{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["error"], "No Events found for the device Device1")
+
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/devices/Device1/events/moved", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	body = map[string]interface{}{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, body["error"], "No Event found with the name moved")
+}
+
+// eventDriver is a testDriver which publishes events
+type eventDriver struct {
+	*testDriver
+	gobot.Eventer
+}
+
+func TestRobotDeviceEventsStream(t *testing.T) {
+	defer func(d time.Duration) { eventHeartbeat = d }(eventHeartbeat)
+	eventHeartbeat = 10 * time.Millisecond
+	a := initTestAPI()
+	server := httptest.NewServer(a)
+	defer server.Close()
+	device := &eventDriver{
+		testDriver: newTestDriver(newTestAdaptor("Connection1", "/dev/null"), "sensor", "3"),
+		Eventer:    gobot.NewEventer(),
+	}
+	device.AddEvent("data")
+	device.AddEvent("error")
+	a.gobot.Robot("Robot1").AddDevice(device)
+
+	res, err := http.Get(server.URL + "/api/robots/Robot1/devices/sensor/events?event=data")
+	gobot.Assert(t, err, nil)
+	defer res.Body.Close()
+	gobot.Assert(t, res.Header.Get("Content-Type"), "text/event-stream")
+	reader := bufio.NewReader(res.Body)
+	line, _ := reader.ReadString('\n')
+	gobot.Assert(t, line, ": heartbeat\n")
+	reader.ReadString('\n')
+
+	gobot.Publish(device.Event("error"), errors.New("sensor failure"))
+	gobot.Publish(device.Event("data"), 42)
+	line, _ = reader.ReadString('\n')
+	for line == ": heartbeat\n" || line == "\n" {
+		line, _ = reader.ReadString('\n')
+	}
+	gobot.Assert(t, line, "event: data\n")
+	line, _ = reader.ReadString('\n')
+	gobot.Assert(t, line, `data: {"robot":"Robot1","device":"sensor","event":"data","data":42}`+"\n")
+
+	res, err = http.Get(server.URL + "/api/robots/Robot1/devices/sensor/events/data")
+	gobot.Assert(t, err, nil)
+	defer res.Body.Close()
+	reader = bufio.NewReader(res.Body)
+	time.Sleep(20 * time.Millisecond)
+	gobot.Publish(device.Event("data"), 43)
+	line, _ = reader.ReadString('\n')
+	for line == ": heartbeat\n" || line == "\n" {
+		line, _ = reader.ReadString('\n')
+	}
+	gobot.Assert(t, line, "event: data\n")
+	line, _ = reader.ReadString('\n')
+	gobot.Assert(t, line, "data: 43\n")
+}
+
+func TestEventFilter(t *testing.T) {
+	_, err := newEventFilter(url.Values{"decimate": {"0"}})
+	gobot.Assert(t, err.Error(), "Invalid decimate 0")
+	_, err = newEventFilter(url.Values{"device": {"["}})
+	gobot.Refute(t, err, nil)
+
+	start := time.Now()
+	filter, _ := newEventFilter(url.Values{"device": {"analog*"}, "interval": {"100ms"}})
+	allowed := []bool{}
+	for _, event := range []JSONEvent{
+		{Robot: "bot", Device: "analog1", Event: "data", Time: start},
+		{Robot: "bot", Device: "analog1", Event: "data", Time: start.Add(50 * time.Millisecond)},
+		{Robot: "bot", Device: "analog2", Event: "data", Time: start.Add(60 * time.Millisecond)},
+		{Robot: "bot", Device: "analog1", Event: "data", Time: start.Add(100 * time.Millisecond)},
+		{Robot: "bot", Device: "button", Event: "push", Time: start.Add(110 * time.Millisecond)},
+		{Robot: "bot", Event: "moved", Time: start.Add(120 * time.Millisecond)},
+	} {
+		allowed = append(allowed, filter.allow(event))
+	}
+	gobot.Assert(t, allowed, []bool{true, false, true, true, false, false})
+
+	filter, _ = newEventFilter(url.Values{"decimate": {"3"}})
+	allowed = []bool{}
+	for i := 0; i < 7; i++ {
+		allowed = append(allowed, filter.allow(JSONEvent{Robot: "bot", Device: "imu", Event: "data", Time: start}))
+	}
+	gobot.Assert(t, allowed, []bool{true, false, false, true, false, false, true})
+	gobot.Assert(t, (*eventFilter)(nil).allow(JSONEvent{}), true)
+}
+
+func TestMatchEvent(t *testing.T) {
+	gobot.Assert(t, MatchEvent(nil, "moved"), true)
+	gobot.Assert(t, MatchEvent([]string{"mov*", "stopped"}, "moved"), true)
+	gobot.Assert(t, MatchEvent([]string{"mov*", "stopped"}, "stopped"), true)
+	gobot.Assert(t, MatchEvent([]string{"mov*", "stopped"}, "error"), false)
+
+	gobot.Assert(t, CheckEventFilters([]string{"mov*", "stopped"}), nil)
+	gobot.Refute(t, CheckEventFilters([]string{"mov*", "["}), nil)
+
+	event := NewJSONEvent("bot", "led", "error", errors.New("write failed"))
+	gobot.Assert(t, event.Data, "write failed")
+	gobot.Assert(t, event.Device, "led")
+	b, _ := json.Marshal(event)
+	gobot.Assert(t, string(b), `{"robot":"bot","device":"led","event":"error","data":"write failed"}`)
+}
@@ -0,0 +1,580 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file holds a small GraphQL engine: a parser for queries and
+// subscriptions without fragments or directives, and an executor of their
+// selections against a schema of resolvers.
+
+// gqlOperation is an operation of a GraphQL document
+type gqlOperation struct {
+	kind       string
+	name       string
+	defaults   map[string]interface{}
+	selections []*gqlField
+}
+
+// gqlField is a field selected in a GraphQL document
+type gqlField struct {
+	alias      string
+	name       string
+	args       map[string]interface{}
+	selections []*gqlField
+}
+
+// gqlVariable is a reference to a variable in an argument value
+type gqlVariable string
+
+// gqlType is an object type of a GraphQL schema
+type gqlType struct {
+	name   string
+	fields map[string]*gqlFieldDef
+}
+
+// gqlFieldDef is a field of a gqlType. typ is the name of an object type, or
+// of a scalar if it is not one, and resolve returns its value given the
+// value of its parent and its arguments.
+type gqlFieldDef struct {
+	typ     string
+	list    bool
+	resolve func(parent interface{}, args map[string]interface{}) (interface{}, error)
+}
+
+// gqlSchema maps the names of the types of a GraphQL schema to them
+type gqlSchema map[string]*gqlType
+
+// gqlError is an error of a GraphQL response
+type gqlError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// gqlObject is a JSON object keeping the order of its fields, as GraphQL
+// responses follow the order of the selections
+type gqlObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *gqlObject) set(key string, value interface{}) {
+	if o.values == nil {
+		o.values = make(map[string]interface{})
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *gqlObject) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBufferString("{")
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		k, _ := json.Marshal(key)
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteString(":")
+		buf.Write(v)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// execute resolves the selections of the field of root selected, with
+// variables, and returns the data and the errors of the fields which could
+// not be resolved
+func (s gqlSchema) execute(root string, selections []*gqlField, variables map[string]interface{}) (*gqlObject, []gqlError) {
+	var errs []gqlError
+	data := s.selectFields(s[root], nil, selections, variables, nil, &errs)
+	return data, errs
+}
+
+// selectFields returns the fields of parent, of type typ, selected
+func (s gqlSchema) selectFields(typ *gqlType, parent interface{}, selections []*gqlField,
+	variables map[string]interface{}, path []interface{}, errs *[]gqlError) *gqlObject {
+	object := &gqlObject{}
+	for _, field := range selections {
+		key := field.alias
+		if key == "" {
+			key = field.name
+		}
+		fieldPath := append(append([]interface{}{}, path...), key)
+		if field.name == "__typename" {
+			object.set(key, typ.name)
+			continue
+		}
+		def, ok := typ.fields[field.name]
+		if !ok {
+			*errs = append(*errs, gqlError{
+				Message: fmt.Sprintf("Cannot query field %q on type %q", field.name, typ.name),
+				Path:    fieldPath,
+			})
+			object.set(key, nil)
+			continue
+		}
+		args, err := resolveVariables(field.args, variables)
+		if err != nil {
+			*errs = append(*errs, gqlError{Message: err.Error(), Path: fieldPath})
+			object.set(key, nil)
+			continue
+		}
+		value, err := def.resolve(parent, args.(map[string]interface{}))
+		if err != nil {
+			*errs = append(*errs, gqlError{Message: err.Error(), Path: fieldPath})
+			object.set(key, nil)
+			continue
+		}
+		object.set(key, s.complete(def, value, field, variables, fieldPath, errs))
+	}
+	return object
+}
+
+// complete returns the value of a field resolved, selecting the fields of an
+// object type
+func (s gqlSchema) complete(def *gqlFieldDef, value interface{}, field *gqlField,
+	variables map[string]interface{}, path []interface{}, errs *[]gqlError) interface{} {
+	typ, object := s[def.typ]
+	if !object {
+		if len(field.selections) > 0 {
+			*errs = append(*errs, gqlError{
+				Message: fmt.Sprintf("Field %q of type %q must not have a selection", field.name, def.typ),
+				Path:    path,
+			})
+			return nil
+		}
+		return value
+	}
+	if len(field.selections) == 0 {
+		*errs = append(*errs, gqlError{
+			Message: fmt.Sprintf("Field %q of type %q must have a selection of subfields", field.name, def.typ),
+			Path:    path,
+		})
+		return nil
+	}
+	if value == nil {
+		return nil
+	}
+	if !def.list {
+		return s.selectFields(typ, value, field.selections, variables, path, errs)
+	}
+	list := []interface{}{}
+	for i, item := range value.([]interface{}) {
+		list = append(list, s.selectFields(typ, item, field.selections, variables, append(path, i), errs))
+	}
+	return list
+}
+
+// resolveVariables returns value with its variables replaced by their value
+func resolveVariables(value interface{}, variables map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case gqlVariable:
+		value, ok := variables[string(v)]
+		if !ok {
+			return nil, fmt.Errorf("Variable \"$%v\" is not defined", v)
+		}
+		return value, nil
+	case []interface{}:
+		list := []interface{}{}
+		for _, item := range v {
+			item, err := resolveVariables(item, variables)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case map[string]interface{}:
+		object := make(map[string]interface{})
+		for key, item := range v {
+			item, err := resolveVariables(item, variables)
+			if err != nil {
+				return nil, err
+			}
+			object[key] = item
+		}
+		return object, nil
+	}
+	return value, nil
+}
+
+// parseOperation returns the operation of document named name, or its only
+// operation if name is empty
+func parseOperation(document string, name string) (*gqlOperation, error) {
+	p := &gqlParser{lexer: &gqlLexer{src: document}}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	var operations []*gqlOperation
+	for p.token.kind != gqlEOF {
+		op, err := p.parseOperation()
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, op)
+	}
+	if len(operations) == 0 {
+		return nil, errors.New("Syntax Error: no operation")
+	}
+	if name == "" {
+		if len(operations) > 1 {
+			return nil, errors.New("Must provide operation name if query contains multiple operations")
+		}
+		return operations[0], nil
+	}
+	for _, op := range operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown operation named %q", name)
+}
+
+// The kinds of GraphQL tokens
+const (
+	gqlEOF = iota
+	gqlPunctuator
+	gqlName
+	gqlInt
+	gqlFloat
+	gqlString
+)
+
+type gqlToken struct {
+	kind  int
+	value string
+}
+
+// gqlLexer splits a GraphQL document into tokens
+type gqlLexer struct {
+	src string
+	pos int
+}
+
+func (l *gqlLexer) next() (gqlToken, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '#' {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+			continue
+		}
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			l.pos++
+			continue
+		}
+		break
+	}
+	if l.pos >= len(l.src) {
+		return gqlToken{kind: gqlEOF}, nil
+	}
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("!$():=@[]{}|", c) >= 0:
+		l.pos++
+		return gqlToken{kind: gqlPunctuator, value: string(c)}, nil
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		return gqlToken{}, errors.New("Fragments are not supported")
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		for l.pos < len(l.src) && isNameChar(l.src[l.pos]) {
+			l.pos++
+		}
+		return gqlToken{kind: gqlName, value: l.src[start:l.pos]}, nil
+	case c == '-' || c >= '0' && c <= '9':
+		l.pos++
+		kind := gqlInt
+		for l.pos < len(l.src) {
+			c := l.src[l.pos]
+			if c == '.' || c == 'e' || c == 'E' || c == '+' || c == '-' {
+				kind = gqlFloat
+			} else if c < '0' || c > '9' {
+				break
+			}
+			l.pos++
+		}
+		return gqlToken{kind: kind, value: l.src[start:l.pos]}, nil
+	case c == '"':
+		return l.string()
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return gqlToken{}, fmt.Errorf("Syntax Error: unexpected character %q", r)
+}
+
+// string reads a string token, unquoting it
+func (l *gqlLexer) string() (gqlToken, error) {
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		return gqlToken{}, errors.New("Block strings are not supported")
+	}
+	for end := l.pos + 1; end < len(l.src); end++ {
+		switch l.src[end] {
+		case '\\':
+			end++
+		case '\n':
+			return gqlToken{}, errors.New("Syntax Error: unterminated string")
+		case '"':
+			var s string
+			if err := json.Unmarshal([]byte(l.src[l.pos:end+1]), &s); err != nil {
+				return gqlToken{}, errors.New("Syntax Error: invalid string")
+			}
+			l.pos = end + 1
+			return gqlToken{kind: gqlString, value: s}, nil
+		}
+	}
+	return gqlToken{}, errors.New("Syntax Error: unterminated string")
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// gqlParser parses the operations of a GraphQL document
+type gqlParser struct {
+	lexer *gqlLexer
+	token gqlToken
+}
+
+func (p *gqlParser) next() (err error) {
+	p.token, err = p.lexer.next()
+	return
+}
+
+// is returns true if the current token is the punctuator value
+func (p *gqlParser) is(value string) bool {
+	return p.token.kind == gqlPunctuator && p.token.value == value
+}
+
+// expect reads the punctuator value
+func (p *gqlParser) expect(value string) error {
+	if !p.is(value) {
+		return fmt.Errorf("Syntax Error: expected %q, found %q", value, p.token.value)
+	}
+	return p.next()
+}
+
+// name reads a name
+func (p *gqlParser) name() (string, error) {
+	if p.token.kind != gqlName {
+		return "", fmt.Errorf("Syntax Error: expected a name, found %q", p.token.value)
+	}
+	name := p.token.value
+	return name, p.next()
+}
+
+func (p *gqlParser) parseOperation() (op *gqlOperation, err error) {
+	op = &gqlOperation{kind: "query", defaults: make(map[string]interface{})}
+	if p.token.kind == gqlName {
+		switch p.token.value {
+		case "query", "mutation", "subscription":
+			op.kind = p.token.value
+		case "fragment":
+			return nil, errors.New("Fragments are not supported")
+		default:
+			return nil, fmt.Errorf("Syntax Error: unexpected %q", p.token.value)
+		}
+		if err = p.next(); err != nil {
+			return
+		}
+		if p.token.kind == gqlName {
+			if op.name, err = p.name(); err != nil {
+				return
+			}
+		}
+		if p.is("(") {
+			if err = p.parseVariableDefinitions(op); err != nil {
+				return
+			}
+		}
+	}
+	op.selections, err = p.parseSelections()
+	return
+}
+
+func (p *gqlParser) parseVariableDefinitions(op *gqlOperation) (err error) {
+	if err = p.expect("("); err != nil {
+		return
+	}
+	for !p.is(")") {
+		if err = p.expect("$"); err != nil {
+			return
+		}
+		var name string
+		if name, err = p.name(); err != nil {
+			return
+		}
+		if err = p.expect(":"); err != nil {
+			return
+		}
+		if err = p.skipType(); err != nil {
+			return
+		}
+		if p.is("=") {
+			if err = p.next(); err != nil {
+				return
+			}
+			if op.defaults[name], err = p.parseValue(); err != nil {
+				return
+			}
+		}
+	}
+	return p.next()
+}
+
+// skipType reads a type, e.g. [String!]!
+func (p *gqlParser) skipType() (err error) {
+	if p.is("[") {
+		if err = p.next(); err != nil {
+			return
+		}
+		if err = p.skipType(); err != nil {
+			return
+		}
+		if err = p.expect("]"); err != nil {
+			return
+		}
+	} else if _, err = p.name(); err != nil {
+		return
+	}
+	if p.is("!") {
+		err = p.next()
+	}
+	return
+}
+
+func (p *gqlParser) parseSelections() (selections []*gqlField, err error) {
+	if err = p.expect("{"); err != nil {
+		return
+	}
+	for !p.is("}") {
+		if p.token.kind == gqlEOF {
+			return nil, errors.New("Syntax Error: expected \"}\"")
+		}
+		field := &gqlField{}
+		if field.name, err = p.name(); err != nil {
+			return
+		}
+		if p.is(":") {
+			if err = p.next(); err != nil {
+				return
+			}
+			field.alias = field.name
+			if field.name, err = p.name(); err != nil {
+				return
+			}
+		}
+		if p.is("(") {
+			if field.args, err = p.parseArguments(); err != nil {
+				return
+			}
+		}
+		if p.is("@") {
+			return nil, errors.New("Directives are not supported")
+		}
+		if p.is("{") {
+			if field.selections, err = p.parseSelections(); err != nil {
+				return
+			}
+		}
+		selections = append(selections, field)
+	}
+	return selections, p.next()
+}
+
+func (p *gqlParser) parseArguments() (args map[string]interface{}, err error) {
+	args = make(map[string]interface{})
+	if err = p.expect("("); err != nil {
+		return
+	}
+	for !p.is(")") {
+		var name string
+		if name, err = p.name(); err != nil {
+			return
+		}
+		if err = p.expect(":"); err != nil {
+			return
+		}
+		if args[name], err = p.parseValue(); err != nil {
+			return
+		}
+	}
+	return args, p.next()
+}
+
+func (p *gqlParser) parseValue() (value interface{}, err error) {
+	token := p.token
+	switch {
+	case p.is("$"):
+		if err = p.next(); err != nil {
+			return
+		}
+		var name string
+		name, err = p.name()
+		return gqlVariable(name), err
+	case p.is("["):
+		if err = p.next(); err != nil {
+			return
+		}
+		list := []interface{}{}
+		for !p.is("]") {
+			var item interface{}
+			if item, err = p.parseValue(); err != nil {
+				return
+			}
+			list = append(list, item)
+		}
+		return list, p.next()
+	case p.is("{"):
+		if err = p.next(); err != nil {
+			return
+		}
+		object := make(map[string]interface{})
+		for !p.is("}") {
+			var name string
+			if name, err = p.name(); err != nil {
+				return
+			}
+			if err = p.expect(":"); err != nil {
+				return
+			}
+			if object[name], err = p.parseValue(); err != nil {
+				return
+			}
+		}
+		return object, p.next()
+	case token.kind == gqlInt || token.kind == gqlFloat:
+		// numbers are float64, as in variables decoded from JSON
+		if value, err = strconv.ParseFloat(token.value, 64); err != nil {
+			return nil, fmt.Errorf("Syntax Error: invalid number %q", token.value)
+		}
+	case token.kind == gqlString:
+		value = token.value
+	case token.kind == gqlName:
+		switch token.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		default:
+			// an enum value
+			value = token.value
+		}
+	default:
+		return nil, fmt.Errorf("Syntax Error: unexpected %q", token.value)
+	}
+	return value, p.next()
+}
@@ -0,0 +1,565 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// graphQLProtocol is the WebSocket subprotocol of GraphQL subscriptions, see
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+const graphQLProtocol = "graphql-transport-ws"
+
+// graphQLRequest is the body of a GraphQL request
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphQLResponse is the body of a GraphQL response
+type graphQLResponse struct {
+	Data   *gqlObject `json:"data"`
+	Errors []gqlError `json:"errors,omitempty"`
+}
+
+// graphQLMessage is a message of the graphql-transport-ws protocol
+type graphQLMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// gqlCommand is a command and its parameters, resolved by the Command type
+type gqlCommand struct {
+	name   string
+	params []gobot.Param
+}
+
+// gqlDevice is a device of a robot, resolved by the Device type
+type gqlDevice struct {
+	robot  *gobot.Robot
+	device gobot.Device
+}
+
+// graphQL returns the GraphQL route handler.
+// Executes the query of a GET request, given by its query, operationName
+// and variables parameters, or of a POST request with a JSON body, and
+// writes JSON with its data and errors. WebSocket requests speak the
+// graphql-transport-ws protocol, which also runs subscriptions. The schema
+// is:
+//
+//	type Query {
+//	  robots(tag: String): [Robot]
+//	  robot(name: String!): Robot
+//	  commands: [Command]
+//	}
+//	type Subscription {
+//	  events(robot: String!, device: String, names: [String]): Event
+//	}
+//	type Robot {
+//	  name: String
+//	  tags: [Tag]
+//	  commands: [Command]
+//	  connections: [Connection]
+//	  connection(name: String!): Connection
+//	  devices: [Device]
+//	  device(name: String!): Device
+//	  health: [String]
+//	}
+//	type Device {
+//	  name: String
+//	  driver: String
+//	  pin: String
+//	  connection: String
+//	  disabled: Boolean
+//	  commands: [Command]
+//	  events: [String]
+//	  state: JSON
+//	}
+//	type Connection { name: String, adaptor: String }
+//	type Command { name: String, params: [Param] }
+//	type Param { name: String, type: String, required: Boolean, min: Float, max: Float, default: JSON }
+//	type Tag { key: String, value: String }
+//	type Event { robot: String, device: String, name: String, data: JSON }
+//
+// The tag argument of robots takes tags as key=value pairs, and the names
+// argument of events takes patterns of path.Match, e.g. "button_*".
+// Fragments and directives are not supported.
+func (a *API) graphQL(res http.ResponseWriter, req *http.Request) {
+	if headerContains(req.Header, "Upgrade", "websocket") {
+		a.graphQLWebSocket(res, req)
+		return
+	}
+	r := graphQLRequest{}
+	if req.Method == "POST" {
+		if err := json.NewDecoder(req.Body).Decode(&r); err != nil {
+			a.writeJSON(graphQLResponse{Errors: []gqlError{{Message: err.Error()}}}, res)
+			return
+		}
+	} else {
+		r.Query = req.URL.Query().Get("query")
+		r.OperationName = req.URL.Query().Get("operationName")
+		if variables := req.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &r.Variables); err != nil {
+				a.writeJSON(graphQLResponse{Errors: []gqlError{{Message: err.Error()}}}, res)
+				return
+			}
+		}
+	}
+	op, variables, err := parseGraphQL(r)
+	if err == nil && op.kind == "subscription" {
+		err = errors.New("Subscriptions require a WebSocket with the " + graphQLProtocol + " protocol")
+	}
+	if err != nil {
+		a.writeJSON(graphQLResponse{Errors: []gqlError{{Message: err.Error()}}}, res)
+		return
+	}
+	data, errs := a.graphQLSchema().execute("Query", op.selections, variables)
+	a.writeJSON(graphQLResponse{Data: data, Errors: errs}, res)
+}
+
+// parseGraphQL returns the query or subscription of r, and its variables
+// with the defaults of the operation
+func parseGraphQL(r graphQLRequest) (*gqlOperation, map[string]interface{}, error) {
+	op, err := parseOperation(r.Query, r.OperationName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if op.kind == "mutation" {
+		return nil, nil, errors.New("Mutations are not supported, execute commands with the REST routes")
+	}
+	variables := make(map[string]interface{})
+	for name, value := range op.defaults {
+		variables[name] = value
+	}
+	for name, value := range r.Variables {
+		variables[name] = value
+	}
+	return op, variables, nil
+}
+
+// graphQLWebSocket speaks the graphql-transport-ws protocol with the client
+// of req, running the queries and subscriptions it subscribes to until it
+// goes away. The connection is closed on an unexpected message.
+func (a *API) graphQLWebSocket(res http.ResponseWriter, req *http.Request) {
+	messages := make(chan []byte)
+	serveWebSocket(res, req, graphQLProtocol, messages, func(ws *webSocket) {
+		a.serveGraphQLWebSocket(ws, messages)
+	})
+}
+
+// serveGraphQLWebSocket answers the graphql-transport-ws messages of the
+// client of ws, see graphQLWebSocket
+func (a *API) serveGraphQLWebSocket(ws *webSocket, messages <-chan []byte) {
+	subscriptions := make(map[string]chan struct{})
+	defer func() {
+		for _, done := range subscriptions {
+			close(done)
+		}
+	}()
+	finished := make(chan string)
+	acknowledged := false
+	for {
+		select {
+		case data := <-messages:
+			msg := graphQLMessage{}
+			if err := json.Unmarshal(data, &msg); err != nil {
+				return
+			}
+			switch msg.Type {
+			case "connection_init":
+				if acknowledged {
+					return
+				}
+				acknowledged = true
+				writeGraphQLMessage(ws, graphQLMessage{Type: "connection_ack"})
+			case "ping":
+				writeGraphQLMessage(ws, graphQLMessage{Type: "pong"})
+			case "pong":
+			case "subscribe":
+				r := graphQLRequest{}
+				if !acknowledged || msg.ID == "" || json.Unmarshal(msg.Payload, &r) != nil {
+					return
+				}
+				if _, ok := subscriptions[msg.ID]; ok {
+					return
+				}
+				done := make(chan struct{})
+				subscriptions[msg.ID] = done
+				go func(id string) {
+					a.runGraphQL(ws, id, r, done)
+					select {
+					case finished <- id:
+					case <-ws.Closed():
+					}
+				}(msg.ID)
+			case "complete":
+				if done, ok := subscriptions[msg.ID]; ok {
+					close(done)
+					delete(subscriptions, msg.ID)
+				}
+			default:
+				return
+			}
+		case id := <-finished:
+			delete(subscriptions, id)
+		case <-ws.Closed():
+			return
+		}
+	}
+}
+
+// runGraphQL runs the operation of r subscribed to by the message id,
+// writing its results to ws until done is closed
+func (a *API) runGraphQL(ws *webSocket, id string, r graphQLRequest, done <-chan struct{}) {
+	schema := a.graphQLSchema()
+	op, variables, err := parseGraphQL(r)
+	if err != nil {
+		payload, _ := json.Marshal([]gqlError{{Message: err.Error()}})
+		writeGraphQLMessage(ws, graphQLMessage{ID: id, Type: "error", Payload: payload})
+		return
+	}
+	next := func(data *gqlObject, errs []gqlError) error {
+		payload, _ := json.Marshal(graphQLResponse{Data: data, Errors: errs})
+		return writeGraphQLMessage(ws, graphQLMessage{ID: id, Type: "next", Payload: payload})
+	}
+	if op.kind == "query" {
+		next(schema.execute("Query", op.selections, variables))
+	} else if err := a.subscribeGraphQL(schema, op, variables, next, done, ws.Closed()); err != nil {
+		payload, _ := json.Marshal([]gqlError{{Message: err.Error()}})
+		writeGraphQLMessage(ws, graphQLMessage{ID: id, Type: "error", Payload: payload})
+		return
+	}
+	select {
+	case <-done:
+		// completed by the client
+	default:
+		writeGraphQLMessage(ws, graphQLMessage{ID: id, Type: "complete"})
+	}
+}
+
+// subscribeGraphQL calls next with the selections of the subscription op for
+// each event of its events field, until done or closed is closed
+func (a *API) subscribeGraphQL(schema gqlSchema, op *gqlOperation, variables map[string]interface{},
+	next func(*gqlObject, []gqlError) error, done <-chan struct{}, closed <-chan struct{}) error {
+	if len(op.selections) != 1 || op.selections[0].name != "events" {
+		return errors.New("Subscriptions must select the events field only")
+	}
+	args, err := resolveVariables(op.selections[0].args, variables)
+	if err != nil {
+		return err
+	}
+	name, err := requiredStringArg(args.(map[string]interface{}), "robot")
+	if err != nil {
+		return err
+	}
+	device, err := stringArg(args.(map[string]interface{}), "device")
+	if err != nil {
+		return err
+	}
+	filters, err := stringsArg(args.(map[string]interface{}), "names")
+	if err != nil {
+		return err
+	}
+	if err := CheckEventFilters(filters); err != nil {
+		return err
+	}
+	robot := a.gobot.Robot(name)
+	if robot == nil {
+		return errors.New("No Robot found with the name " + name)
+	}
+	sources := robotEventSources(robot)
+	if device != "" {
+		eventer, ok := robot.Device(device).(gobot.Eventer)
+		if !ok {
+			return errors.New("No Events found for the device " + device)
+		}
+		sources = []eventSource{{device: device, eventer: eventer}}
+	}
+
+	stopped := make(chan struct{})
+	defer close(stopped)
+	events := subscribeEvents(robot.Name, sources, filters, stopped)
+	for {
+		select {
+		case event := <-events:
+			var errs []gqlError
+			data := schema.selectFields(schema["Subscription"], event, op.selections, variables, nil, &errs)
+			if err := next(data, errs); err != nil {
+				return nil
+			}
+		case <-done:
+			return nil
+		case <-closed:
+			return nil
+		}
+	}
+}
+
+// writeGraphQLMessage writes msg to ws
+func writeGraphQLMessage(ws *webSocket, msg graphQLMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return ws.WriteText(data)
+}
+
+// graphQLSchema returns the schema of the robots of the api, see graphQL
+func (a *API) graphQLSchema() gqlSchema {
+	robot := func(parent interface{}) *gobot.Robot { return parent.(*gobot.Robot) }
+	device := func(parent interface{}) *gqlDevice { return parent.(*gqlDevice) }
+	command := func(parent interface{}) *gqlCommand { return parent.(*gqlCommand) }
+	param := func(parent interface{}) gobot.Param { return parent.(gobot.Param) }
+	connection := func(parent interface{}) gobot.Connection { return parent.(gobot.Connection) }
+	event := func(parent interface{}) JSONEvent { return parent.(JSONEvent) }
+
+	return gqlSchema{
+		"Query": {name: "Query", fields: map[string]*gqlFieldDef{
+			"robots": {typ: "Robot", list: true, resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+				tag, err := stringArg(args, "tag")
+				if err != nil {
+					return nil, err
+				}
+				tags, err := gobot.ParseTags(tag)
+				if err != nil {
+					return nil, err
+				}
+				robots := []interface{}{}
+				a.gobot.Robots(tags).Each(func(r *gobot.Robot) {
+					robots = append(robots, r)
+				})
+				return robots, nil
+			}},
+			"robot": {typ: "Robot", resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+				name, err := requiredStringArg(args, "name")
+				if err != nil {
+					return nil, err
+				}
+				if r := a.gobot.Robot(name); r != nil {
+					return r, nil
+				}
+				return nil, errors.New("No Robot found with the name " + name)
+			}},
+			"commands": {typ: "Command", list: true, resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+				return gqlCommands(a.gobot.Commander), nil
+			}},
+		}},
+		"Subscription": {name: "Subscription", fields: map[string]*gqlFieldDef{
+			// the event being published is the parent of the subscription
+			"events": {typ: "Event", resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+				return parent, nil
+			}},
+		}},
+		"Robot": {name: "Robot", fields: map[string]*gqlFieldDef{
+			"name": gqlScalar("String", func(parent interface{}) interface{} { return robot(parent).Name }),
+			"tags": {typ: "Tag", list: true, resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+				robotTags := robot(parent).CurrentTags()
+				keys := []string{}
+				for key := range robotTags {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				tags := []interface{}{}
+				for _, key := range keys {
+					tags = append(tags, [2]string{key, robotTags[key]})
+				}
+				return tags, nil
+			}},
+			"commands": {typ: "Command", list: true, resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+				return gqlCommands(robot(parent).Commander), nil
+			}},
+			"connections": {typ: "Connection", list: true, resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+				connections := []interface{}{}
+				robot(parent).Connections().Each(func(c gobot.Connection) {
+					connections = append(connections, c)
+				})
+				return connections, nil
+			}},
+			"connection": {typ: "Connection", resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+				name, err := requiredStringArg(args, "name")
+				if err != nil {
+					return nil, err
+				}
+				if c := robot(parent).Connection(name); c != nil {
+					return c, nil
+				}
+				return nil, errors.New("No Connection found with the name " + name)
+			}},
+			"devices": {typ: "Device", list: true, resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+				devices := []interface{}{}
+				robot(parent).Devices().Each(func(d gobot.Device) {
+					devices = append(devices, &gqlDevice{robot: robot(parent), device: d})
+				})
+				return devices, nil
+			}},
+			"device": {typ: "Device", resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+				name, err := requiredStringArg(args, "name")
+				if err != nil {
+					return nil, err
+				}
+				if d := robot(parent).Device(name); d != nil {
+					return &gqlDevice{robot: robot(parent), device: d}, nil
+				}
+				return nil, errors.New("No Device found with the name " + name)
+			}},
+			"health": {typ: "String", list: true, resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+				errs := []interface{}{}
+				for _, err := range robot(parent).CheckHealth() {
+					errs = append(errs, err.Error())
+				}
+				return errs, nil
+			}},
+		}},
+		"Device": {name: "Device", fields: map[string]*gqlFieldDef{
+			"name": gqlScalar("String", func(parent interface{}) interface{} { return device(parent).device.Name() }),
+			"driver": gqlScalar("String", func(parent interface{}) interface{} {
+				return gobot.NewJSONDevice(device(parent).device).Driver
+			}),
+			"pin": gqlScalar("String", func(parent interface{}) interface{} {
+				if pinner, ok := device(parent).device.(gobot.Pinner); ok {
+					return pinner.Pin()
+				}
+				return nil
+			}),
+			"connection": gqlScalar("String", func(parent interface{}) interface{} {
+				return gobot.NewJSONDevice(device(parent).device).Connection
+			}),
+			"disabled": gqlScalar("Boolean", func(parent interface{}) interface{} {
+				return device(parent).robot.DeviceDisabled(device(parent).device.Name())
+			}),
+			"commands": {typ: "Command", list: true, resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+				if commander, ok := device(parent).device.(gobot.Commander); ok {
+					return gqlCommands(commander), nil
+				}
+				return []interface{}{}, nil
+			}},
+			"events": {typ: "String", list: true, resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+				names := []string{}
+				if eventer, ok := device(parent).device.(gobot.Eventer); ok {
+					for name := range eventer.Events() {
+						names = append(names, name)
+					}
+				}
+				sort.Strings(names)
+				events := []interface{}{}
+				for _, name := range names {
+					events = append(events, name)
+				}
+				return events, nil
+			}},
+			"state": gqlScalar("JSON", func(parent interface{}) interface{} {
+				if snapshotter, ok := device(parent).device.(gobot.Snapshotter); ok {
+					return snapshotter.Snapshot()
+				}
+				return nil
+			}),
+		}},
+		"Connection": {name: "Connection", fields: map[string]*gqlFieldDef{
+			"name":    gqlScalar("String", func(parent interface{}) interface{} { return connection(parent).Name() }),
+			"adaptor": gqlScalar("String", func(parent interface{}) interface{} { return gobot.NewJSONConnection(connection(parent)).Adaptor }),
+		}},
+		"Command": {name: "Command", fields: map[string]*gqlFieldDef{
+			"name": gqlScalar("String", func(parent interface{}) interface{} { return command(parent).name }),
+			"params": {typ: "Param", list: true, resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+				params := []interface{}{}
+				for _, p := range command(parent).params {
+					params = append(params, p)
+				}
+				return params, nil
+			}},
+		}},
+		"Param": {name: "Param", fields: map[string]*gqlFieldDef{
+			"name":     gqlScalar("String", func(parent interface{}) interface{} { return param(parent).Name }),
+			"type":     gqlScalar("String", func(parent interface{}) interface{} { return string(param(parent).Type) }),
+			"required": gqlScalar("Boolean", func(parent interface{}) interface{} { return param(parent).Required }),
+			"min":      gqlScalar("Float", func(parent interface{}) interface{} { return param(parent).Min }),
+			"max":      gqlScalar("Float", func(parent interface{}) interface{} { return param(parent).Max }),
+			"default":  gqlScalar("JSON", func(parent interface{}) interface{} { return param(parent).Default }),
+		}},
+		"Tag": {name: "Tag", fields: map[string]*gqlFieldDef{
+			"key":   gqlScalar("String", func(parent interface{}) interface{} { return parent.([2]string)[0] }),
+			"value": gqlScalar("String", func(parent interface{}) interface{} { return parent.([2]string)[1] }),
+		}},
+		"Event": {name: "Event", fields: map[string]*gqlFieldDef{
+			"robot": gqlScalar("String", func(parent interface{}) interface{} { return event(parent).Robot }),
+			"device": gqlScalar("String", func(parent interface{}) interface{} {
+				if event(parent).Device == "" {
+					return nil
+				}
+				return event(parent).Device
+			}),
+			"name": gqlScalar("String", func(parent interface{}) interface{} { return event(parent).Event }),
+			"data": gqlScalar("JSON", func(parent interface{}) interface{} { return event(parent).Data }),
+		}},
+	}
+}
+
+// gqlScalar returns a field without arguments of the scalar type typ, whose
+// value is returned by get given its parent
+func gqlScalar(typ string, get func(parent interface{}) interface{}) *gqlFieldDef {
+	return &gqlFieldDef{typ: typ, resolve: func(parent interface{}, args map[string]interface{}) (interface{}, error) {
+		return get(parent), nil
+	}}
+}
+
+// gqlCommands returns the commands of c sorted by name
+func gqlCommands(c gobot.Commander) []interface{} {
+	names := []string{}
+	for name := range c.Commands() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	commands := []interface{}{}
+	for _, name := range names {
+		commands = append(commands, &gqlCommand{name: name, params: c.Params(name)})
+	}
+	return commands
+}
+
+// stringArg returns the String argument name, or "" if it is not given
+func stringArg(args map[string]interface{}, name string) (string, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	}
+	return "", fmt.Errorf("Argument %q must be a String", name)
+}
+
+// requiredStringArg returns the String argument name, which must be given
+func requiredStringArg(args map[string]interface{}, name string) (string, error) {
+	s, err := stringArg(args, name)
+	if err == nil && s == "" {
+		err = fmt.Errorf("Argument %q is required", name)
+	}
+	return s, err
+}
+
+// stringsArg returns the [String] argument name, a single String being a
+// list of one
+func stringsArg(args map[string]interface{}, name string) ([]string, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		list := []string{}
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("Argument %q must be a [String]", name)
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("Argument %q must be a [String]", name)
+}
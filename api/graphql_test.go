@@ -0,0 +1,232 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// graphQLQuery executes query with variables against a, with a POST request
+func graphQLQuery(a *API, query string, variables map[string]interface{}) string {
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	request, _ := http.NewRequest("POST", "/api/graphql", strings.NewReader(string(body)))
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	return response.Body.String()
+}
+
+func TestGraphQL(t *testing.T) {
+	a := initTestAPI()
+	a.gobot.Robot("Robot2").Tags = gobot.Tags{"zone": "lab", "type": "agv"}
+	a.gobot.Robot("Robot1").AddCommandWithParams("drive", []gobot.Param{
+		{Name: "speed", Type: gobot.ParamInt, Required: true, Min: 0, Max: 10},
+	}, func(params map[string]interface{}) interface{} { return nil })
+
+	gobot.Assert(t, graphQLQuery(a, `query Robot($name: String!) {
+		robot(name: $name) {
+			__typename
+			name
+			devices { name pin connection disabled state }
+			drive: commands { name params { name type required max } }
+		}
+	}`, map[string]interface{}{"name": "Robot1"}),
+		`{"data":{"robot":{"__typename":"Robot","name":"Robot1",`+
+			`"devices":[{"name":"Device1","pin":"0","connection":"Connection1","disabled":false,"state":null},`+
+			`{"name":"Device2","pin":"2","connection":"Connection2","disabled":false,"state":null},`+
+			`{"name":"","pin":"1","connection":"","disabled":false,"state":null}],`+
+			`"drive":[{"name":"drive","params":[{"name":"speed","type":"int","required":true,"max":10}]},`+
+			`{"name":"robotTestFunction","params":[]}]}}}`)
+
+	gobot.Assert(t, graphQLQuery(a, `query($tag: String = "zone=lab") {
+		robots(tag: $tag) { name tags { key value } }
+		commands { name }
+	}`, nil),
+		`{"data":{"robots":[{"name":"Robot2","tags":[{"key":"type","value":"agv"},{"key":"zone","value":"lab"}]}],`+
+			`"commands":[{"name":"TestFunction"}]}}`)
+
+	// queries can be given in the URL
+	request, _ := http.NewRequest("GET", "/api/graphql?query="+url.QueryEscape(
+		`query($name: String) { robot(name: $name) { connection(name: "Connection2") { name adaptor } } }`)+
+		"&variables="+url.QueryEscape(`{"name":"Robot3"}`), nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Body.String(),
+		`{"data":{"robot":{"connection":{"name":"Connection2","adaptor":"*api.testAdaptor"}}}}`)
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	a := initTestAPI()
+
+	gobot.Assert(t, graphQLQuery(a, `{ robot(name: "UnknownRobot1") { name } robots { speed } }`, nil),
+		`{"data":{"robot":null,"robots":[{"speed":null},{"speed":null},{"speed":null}]},"errors":[`+
+			`{"message":"No Robot found with the name UnknownRobot1","path":["robot"]},`+
+			`{"message":"Cannot query field \"speed\" on type \"Robot\"","path":["robots",0,"speed"]},`+
+			`{"message":"Cannot query field \"speed\" on type \"Robot\"","path":["robots",1,"speed"]},`+
+			`{"message":"Cannot query field \"speed\" on type \"Robot\"","path":["robots",2,"speed"]}]}`)
+
+	gobot.Assert(t, graphQLQuery(a, `{ robot(name: $name) { name } }`, nil),
+		`{"data":{"robot":null},"errors":[{"message":"Variable \"$name\" is not defined","path":["robot"]}]}`)
+
+	gobot.Assert(t, graphQLQuery(a, `{ robot(name: "Robot1") }`, nil),
+		`{"data":{"robot":null},"errors":[{"message":"Field \"robot\" of type \"Robot\" must have a selection of subfields","path":["robot"]}]}`)
+
+	gobot.Assert(t, graphQLQuery(a, `{ robot(name: "Robot1") { name`, nil),
+		`{"data":null,"errors":[{"message":"Syntax Error: expected \"}\""}]}`)
+
+	gobot.Assert(t, graphQLQuery(a, `{ ...robot }`, nil),
+		`{"data":null,"errors":[{"message":"Fragments are not supported"}]}`)
+
+	gobot.Assert(t, graphQLQuery(a, `mutation { drive }`, nil),
+		`{"data":null,"errors":[{"message":"Mutations are not supported, execute commands with the REST routes"}]}`)
+
+	gobot.Assert(t, graphQLQuery(a, `subscription { events(robot: "Robot1") { name } }`, nil),
+		`{"data":null,"errors":[{"message":"Subscriptions require a WebSocket with the graphql-transport-ws protocol"}]}`)
+}
+
+func TestParseOperation(t *testing.T) {
+	op, err := parseOperation(`
+		# the robots
+		query Robots($tag: String = "zone=lab", $names: [String!]! = ["a", "b"]) {
+			robots(tag: $tag, limit: -1.5e1, where: {active: true, owner: null}) { name }
+		}
+		query Other { commands { name } }`, "Robots")
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, op.kind, "query")
+	gobot.Assert(t, op.name, "Robots")
+	gobot.Assert(t, op.defaults, map[string]interface{}{
+		"tag":   "zone=lab",
+		"names": []interface{}{"a", "b"},
+	})
+	gobot.Assert(t, len(op.selections), 1)
+	gobot.Assert(t, op.selections[0].name, "robots")
+	gobot.Assert(t, op.selections[0].args, map[string]interface{}{
+		"tag":   gqlVariable("tag"),
+		"limit": -15.0,
+		"where": map[string]interface{}{"active": true, "owner": nil},
+	})
+	gobot.Assert(t, op.selections[0].selections[0].name, "name")
+
+	_, err = parseOperation(`query A { a } query B { b }`, "")
+	gobot.Assert(t, err.Error(), "Must provide operation name if query contains multiple operations")
+	_, err = parseOperation(`query A { a }`, "B")
+	gobot.Assert(t, err.Error(), `Unknown operation named "B"`)
+	_, err = parseOperation(`{ a @include(if: true) }`, "")
+	gobot.Assert(t, err.Error(), "Directives are not supported")
+	_, err = parseOperation(`{ a(b: "c) }`, "")
+	gobot.Assert(t, err.Error(), "Syntax Error: unterminated string")
+}
+
+// writeWebSocketFrame writes a frame of less than 126 bytes, masked as the
+// frames of clients must be unless mask is nil
+func writeWebSocketFrame(conn net.Conn, fin bool, opcode byte, data string, mask []byte) {
+	frame := []byte{opcode, byte(len(data))}
+	if fin {
+		frame[0] |= 0x80
+	}
+	if mask != nil {
+		frame[1] |= 0x80
+		frame = append(frame, mask...)
+	}
+	for i := 0; i < len(data); i++ {
+		if mask != nil {
+			frame = append(frame, data[i]^mask[i%4])
+		} else {
+			frame = append(frame, data[i])
+		}
+	}
+	conn.Write(frame)
+}
+
+// writeWebSocketText writes a masked text frame of less than 126 bytes
+func writeWebSocketText(conn net.Conn, data string) {
+	writeWebSocketFrame(conn, true, opText, data, []byte{1, 2, 3, 4})
+}
+
+// readGraphQLMessage reads a graphql-transport-ws message
+func readGraphQLMessage(t *testing.T, conn net.Conn, reader *bufio.Reader) string {
+	opcode, data := readWebSocketFrame(t, conn, reader)
+	gobot.Assert(t, opcode, byte(opText))
+	return string(data)
+}
+
+func TestGraphQLWebSocket(t *testing.T) {
+	a := initTestAPI()
+	server := httptest.NewServer(a)
+	defer server.Close()
+	robot := a.gobot.Robot("Robot1")
+	robot.AddEvent("moved")
+	robot.AddEvent("stopped")
+
+	// the subprotocol is required
+	res, err := http.Get(server.URL + "/api/graphql")
+	gobot.Assert(t, err, nil)
+	res.Body.Close()
+	request, _ := http.NewRequest("GET", server.URL+"/api/graphql", nil)
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	res, err = http.DefaultClient.Do(request)
+	gobot.Assert(t, err, nil)
+	res.Body.Close()
+	gobot.Assert(t, res.StatusCode, http.StatusForbidden)
+
+	conn, reader := dialWebSocket(t, server, "/api/graphql", "graphql-transport-ws")
+	defer conn.Close()
+	writeWebSocketText(conn, `{"type":"connection_init"}`)
+	gobot.Assert(t, readGraphQLMessage(t, conn, reader), `{"type":"connection_ack"}`)
+
+	writeWebSocketText(conn, `{"id":"1","type":"subscribe","payload":{"query":"{ robot(name: \"Robot1\") { name } }"}}`)
+	gobot.Assert(t, readGraphQLMessage(t, conn, reader), `{"id":"1","type":"next","payload":{"data":{"robot":{"name":"Robot1"}}}}`)
+	gobot.Assert(t, readGraphQLMessage(t, conn, reader), `{"id":"1","type":"complete"}`)
+
+	writeWebSocketText(conn, `{"id":"2","type":"subscribe","payload":{"query":"subscription { events(robot: \"Robot1\", names: \"mov*\") { name data } }"}}`)
+	// give the handler time to subscribe
+	time.Sleep(20 * time.Millisecond)
+	gobot.Publish(robot.Event("stopped"), 1)
+	gobot.Publish(robot.Event("moved"), 2)
+	gobot.Assert(t, readGraphQLMessage(t, conn, reader), `{"id":"2","type":"next","payload":{"data":{"events":{"name":"moved","data":2}}}}`)
+
+	writeWebSocketText(conn, `{"id":"3","type":"subscribe","payload":{"query":"subscription { events(robot: \"UnknownRobot1\") { name } }"}}`)
+	gobot.Assert(t, readGraphQLMessage(t, conn, reader), `{"id":"3","type":"error","payload":[{"message":"No Robot found with the name UnknownRobot1"}]}`)
+
+	// a completed subscription is not sent further events
+	writeWebSocketText(conn, `{"id":"2","type":"complete"}`)
+	writeWebSocketText(conn, `{"type":"ping"}`)
+	gobot.Assert(t, readGraphQLMessage(t, conn, reader), `{"type":"pong"}`)
+	gobot.Publish(robot.Event("moved"), 3)
+	writeWebSocketText(conn, `{"type":"ping"}`)
+	gobot.Assert(t, readGraphQLMessage(t, conn, reader), `{"type":"pong"}`)
+
+	// an unexpected message closes the connection
+	writeWebSocketText(conn, `{"type":"connection_init"}`)
+	opcode, _ := readWebSocketFrame(t, conn, reader)
+	gobot.Assert(t, opcode, byte(opClose))
+}
+
+func TestGraphQLWebSocketFrames(t *testing.T) {
+	a := initTestAPI()
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// a message may be fragmented across frames
+	conn, reader := dialWebSocket(t, server, "/api/graphql", "graphql-transport-ws")
+	defer conn.Close()
+	mask := []byte{1, 2, 3, 4}
+	writeWebSocketFrame(conn, false, opText, `{"type":`, mask)
+	writeWebSocketFrame(conn, true, opContinuation, `"connection_init"}`, mask)
+	gobot.Assert(t, readGraphQLMessage(t, conn, reader), `{"type":"connection_ack"}`)
+
+	// the frames of clients must be masked
+	writeWebSocketFrame(conn, true, opText, `{"type":"ping"}`, nil)
+	opcode, _ := readWebSocketFrame(t, conn, reader)
+	gobot.Assert(t, opcode, byte(opClose))
+}
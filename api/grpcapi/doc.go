@@ -0,0 +1,26 @@
+/*
+Package grpcapi provides a gRPC server of the robots of a Gobot, as an
+alternative to the REST API for fleet controllers which need typed clients,
+streaming and less overhead. The service and its messages are described in
+gobot.proto, from which clients are generated with protoc, and the Go
+messages and client of this package with make grpc.
+
+Example:
+
+	gbot := gobot.NewGobot()
+	gbot.AddAPIServer(grpcapi.NewServer(nil))
+	gbot.Start()
+
+A Go client:
+
+	conn, err := grpc.NewClient("robot.local:50051",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	client := grpcapi.NewGobotClient(conn)
+	res, err := client.ExecuteCommand(ctx, &grpcapi.CommandRequest{
+		Robot:   "bot",
+		Device:  "led",
+		Command: "Toggle",
+	})
+*/
+package grpcapi
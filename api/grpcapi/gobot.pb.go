@@ -0,0 +1,921 @@
+// Protocol of the gRPC server of package grpcapi. gobot.pb.go and
+// gobot_grpc.pb.go are generated from it with make grpc.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: gobot.proto
+
+package grpcapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListRobotsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tags          map[string]string      `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRobotsRequest) Reset() {
+	*x = ListRobotsRequest{}
+	mi := &file_gobot_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRobotsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRobotsRequest) ProtoMessage() {}
+
+func (x *ListRobotsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gobot_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRobotsRequest.ProtoReflect.Descriptor instead.
+func (*ListRobotsRequest) Descriptor() ([]byte, []int) {
+	return file_gobot_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListRobotsRequest) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type ListRobotsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Robots        []*Robot               `protobuf:"bytes,1,rep,name=robots,proto3" json:"robots,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRobotsResponse) Reset() {
+	*x = ListRobotsResponse{}
+	mi := &file_gobot_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRobotsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRobotsResponse) ProtoMessage() {}
+
+func (x *ListRobotsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gobot_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRobotsResponse.ProtoReflect.Descriptor instead.
+func (*ListRobotsResponse) Descriptor() ([]byte, []int) {
+	return file_gobot_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListRobotsResponse) GetRobots() []*Robot {
+	if x != nil {
+		return x.Robots
+	}
+	return nil
+}
+
+type GetRobotRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRobotRequest) Reset() {
+	*x = GetRobotRequest{}
+	mi := &file_gobot_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRobotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRobotRequest) ProtoMessage() {}
+
+func (x *GetRobotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gobot_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRobotRequest.ProtoReflect.Descriptor instead.
+func (*GetRobotRequest) Descriptor() ([]byte, []int) {
+	return file_gobot_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetRobotRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type Param struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Required      bool                   `protobuf:"varint,3,opt,name=required,proto3" json:"required,omitempty"`
+	Min           float64                `protobuf:"fixed64,4,opt,name=min,proto3" json:"min,omitempty"`
+	Max           float64                `protobuf:"fixed64,5,opt,name=max,proto3" json:"max,omitempty"`
+	Default       *structpb.Value        `protobuf:"bytes,6,opt,name=default,proto3" json:"default,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Param) Reset() {
+	*x = Param{}
+	mi := &file_gobot_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Param) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Param) ProtoMessage() {}
+
+func (x *Param) ProtoReflect() protoreflect.Message {
+	mi := &file_gobot_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Param.ProtoReflect.Descriptor instead.
+func (*Param) Descriptor() ([]byte, []int) {
+	return file_gobot_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Param) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Param) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Param) GetRequired() bool {
+	if x != nil {
+		return x.Required
+	}
+	return false
+}
+
+func (x *Param) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *Param) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *Param) GetDefault() *structpb.Value {
+	if x != nil {
+		return x.Default
+	}
+	return nil
+}
+
+type Params struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Params        []*Param               `protobuf:"bytes,1,rep,name=params,proto3" json:"params,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Params) Reset() {
+	*x = Params{}
+	mi := &file_gobot_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Params) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Params) ProtoMessage() {}
+
+func (x *Params) ProtoReflect() protoreflect.Message {
+	mi := &file_gobot_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Params.ProtoReflect.Descriptor instead.
+func (*Params) Descriptor() ([]byte, []int) {
+	return file_gobot_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Params) GetParams() []*Param {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+type Connection struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Adaptor       string                 `protobuf:"bytes,2,opt,name=adaptor,proto3" json:"adaptor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Connection) Reset() {
+	*x = Connection{}
+	mi := &file_gobot_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Connection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Connection) ProtoMessage() {}
+
+func (x *Connection) ProtoReflect() protoreflect.Message {
+	mi := &file_gobot_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Connection.ProtoReflect.Descriptor instead.
+func (*Connection) Descriptor() ([]byte, []int) {
+	return file_gobot_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Connection) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Connection) GetAdaptor() string {
+	if x != nil {
+		return x.Adaptor
+	}
+	return ""
+}
+
+type Device struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Driver        string                 `protobuf:"bytes,2,opt,name=driver,proto3" json:"driver,omitempty"`
+	Connection    string                 `protobuf:"bytes,3,opt,name=connection,proto3" json:"connection,omitempty"`
+	Commands      []string               `protobuf:"bytes,4,rep,name=commands,proto3" json:"commands,omitempty"`
+	CommandParams map[string]*Params     `protobuf:"bytes,5,rep,name=command_params,json=commandParams,proto3" json:"command_params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Disabled      bool                   `protobuf:"varint,6,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Device) Reset() {
+	*x = Device{}
+	mi := &file_gobot_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Device) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Device) ProtoMessage() {}
+
+func (x *Device) ProtoReflect() protoreflect.Message {
+	mi := &file_gobot_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Device.ProtoReflect.Descriptor instead.
+func (*Device) Descriptor() ([]byte, []int) {
+	return file_gobot_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Device) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Device) GetDriver() string {
+	if x != nil {
+		return x.Driver
+	}
+	return ""
+}
+
+func (x *Device) GetConnection() string {
+	if x != nil {
+		return x.Connection
+	}
+	return ""
+}
+
+func (x *Device) GetCommands() []string {
+	if x != nil {
+		return x.Commands
+	}
+	return nil
+}
+
+func (x *Device) GetCommandParams() map[string]*Params {
+	if x != nil {
+		return x.CommandParams
+	}
+	return nil
+}
+
+func (x *Device) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+type Robot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Tags          map[string]string      `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Commands      []string               `protobuf:"bytes,3,rep,name=commands,proto3" json:"commands,omitempty"`
+	CommandParams map[string]*Params     `protobuf:"bytes,4,rep,name=command_params,json=commandParams,proto3" json:"command_params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Connections   []*Connection          `protobuf:"bytes,5,rep,name=connections,proto3" json:"connections,omitempty"`
+	Devices       []*Device              `protobuf:"bytes,6,rep,name=devices,proto3" json:"devices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Robot) Reset() {
+	*x = Robot{}
+	mi := &file_gobot_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Robot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Robot) ProtoMessage() {}
+
+func (x *Robot) ProtoReflect() protoreflect.Message {
+	mi := &file_gobot_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Robot.ProtoReflect.Descriptor instead.
+func (*Robot) Descriptor() ([]byte, []int) {
+	return file_gobot_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Robot) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Robot) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Robot) GetCommands() []string {
+	if x != nil {
+		return x.Commands
+	}
+	return nil
+}
+
+func (x *Robot) GetCommandParams() map[string]*Params {
+	if x != nil {
+		return x.CommandParams
+	}
+	return nil
+}
+
+func (x *Robot) GetConnections() []*Connection {
+	if x != nil {
+		return x.Connections
+	}
+	return nil
+}
+
+func (x *Robot) GetDevices() []*Device {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+// CommandRequest executes a command of the Gobot without a robot, of a
+// robot without a device, or of a device.
+type CommandRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Robot         string                 `protobuf:"bytes,1,opt,name=robot,proto3" json:"robot,omitempty"`
+	Device        string                 `protobuf:"bytes,2,opt,name=device,proto3" json:"device,omitempty"`
+	Command       string                 `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"`
+	Params        *structpb.Struct       `protobuf:"bytes,4,opt,name=params,proto3" json:"params,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandRequest) Reset() {
+	*x = CommandRequest{}
+	mi := &file_gobot_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandRequest) ProtoMessage() {}
+
+func (x *CommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gobot_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandRequest.ProtoReflect.Descriptor instead.
+func (*CommandRequest) Descriptor() ([]byte, []int) {
+	return file_gobot_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CommandRequest) GetRobot() string {
+	if x != nil {
+		return x.Robot
+	}
+	return ""
+}
+
+func (x *CommandRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *CommandRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *CommandRequest) GetParams() *structpb.Struct {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+type CommandResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Result        *structpb.Value        `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandResponse) Reset() {
+	*x = CommandResponse{}
+	mi := &file_gobot_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandResponse) ProtoMessage() {}
+
+func (x *CommandResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gobot_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandResponse.ProtoReflect.Descriptor instead.
+func (*CommandResponse) Descriptor() ([]byte, []int) {
+	return file_gobot_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CommandResponse) GetResult() *structpb.Value {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *CommandResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// EventsRequest subscribes to the events of a robot, or of one of its
+// devices, whose name matches one of events, all events if empty.
+type EventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Robot         string                 `protobuf:"bytes,1,opt,name=robot,proto3" json:"robot,omitempty"`
+	Device        string                 `protobuf:"bytes,2,opt,name=device,proto3" json:"device,omitempty"`
+	Events        []string               `protobuf:"bytes,3,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventsRequest) Reset() {
+	*x = EventsRequest{}
+	mi := &file_gobot_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventsRequest) ProtoMessage() {}
+
+func (x *EventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gobot_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventsRequest.ProtoReflect.Descriptor instead.
+func (*EventsRequest) Descriptor() ([]byte, []int) {
+	return file_gobot_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *EventsRequest) GetRobot() string {
+	if x != nil {
+		return x.Robot
+	}
+	return ""
+}
+
+func (x *EventsRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *EventsRequest) GetEvents() []string {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Robot         string                 `protobuf:"bytes,1,opt,name=robot,proto3" json:"robot,omitempty"`
+	Device        string                 `protobuf:"bytes,2,opt,name=device,proto3" json:"device,omitempty"`
+	Event         string                 `protobuf:"bytes,3,opt,name=event,proto3" json:"event,omitempty"`
+	Data          *structpb.Value        `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_gobot_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_gobot_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_gobot_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Event) GetRobot() string {
+	if x != nil {
+		return x.Robot
+	}
+	return ""
+}
+
+func (x *Event) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *Event) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
+
+func (x *Event) GetData() *structpb.Value {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_gobot_proto protoreflect.FileDescriptor
+
+const file_gobot_proto_rawDesc = "" +
+	"\n" +
+	"\vgobot.proto\x12\x05gobot\x1a\x1cgoogle/protobuf/struct.proto\"\x84\x01\n" +
+	"\x11ListRobotsRequest\x126\n" +
+	"\x04tags\x18\x01 \x03(\v2\".gobot.ListRobotsRequest.TagsEntryR\x04tags\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\":\n" +
+	"\x12ListRobotsResponse\x12$\n" +
+	"\x06robots\x18\x01 \x03(\v2\f.gobot.RobotR\x06robots\"%\n" +
+	"\x0fGetRobotRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\xa1\x01\n" +
+	"\x05Param\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1a\n" +
+	"\brequired\x18\x03 \x01(\bR\brequired\x12\x10\n" +
+	"\x03min\x18\x04 \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\x05 \x01(\x01R\x03max\x120\n" +
+	"\adefault\x18\x06 \x01(\v2\x16.google.protobuf.ValueR\adefault\".\n" +
+	"\x06Params\x12$\n" +
+	"\x06params\x18\x01 \x03(\v2\f.gobot.ParamR\x06params\":\n" +
+	"\n" +
+	"Connection\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aadaptor\x18\x02 \x01(\tR\aadaptor\"\xa6\x02\n" +
+	"\x06Device\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06driver\x18\x02 \x01(\tR\x06driver\x12\x1e\n" +
+	"\n" +
+	"connection\x18\x03 \x01(\tR\n" +
+	"connection\x12\x1a\n" +
+	"\bcommands\x18\x04 \x03(\tR\bcommands\x12G\n" +
+	"\x0ecommand_params\x18\x05 \x03(\v2 .gobot.Device.CommandParamsEntryR\rcommandParams\x12\x1a\n" +
+	"\bdisabled\x18\x06 \x01(\bR\bdisabled\x1aO\n" +
+	"\x12CommandParamsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12#\n" +
+	"\x05value\x18\x02 \x01(\v2\r.gobot.ParamsR\x05value:\x028\x01\"\x93\x03\n" +
+	"\x05Robot\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12*\n" +
+	"\x04tags\x18\x02 \x03(\v2\x16.gobot.Robot.TagsEntryR\x04tags\x12\x1a\n" +
+	"\bcommands\x18\x03 \x03(\tR\bcommands\x12F\n" +
+	"\x0ecommand_params\x18\x04 \x03(\v2\x1f.gobot.Robot.CommandParamsEntryR\rcommandParams\x123\n" +
+	"\vconnections\x18\x05 \x03(\v2\x11.gobot.ConnectionR\vconnections\x12'\n" +
+	"\adevices\x18\x06 \x03(\v2\r.gobot.DeviceR\adevices\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aO\n" +
+	"\x12CommandParamsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12#\n" +
+	"\x05value\x18\x02 \x01(\v2\r.gobot.ParamsR\x05value:\x028\x01\"\x89\x01\n" +
+	"\x0eCommandRequest\x12\x14\n" +
+	"\x05robot\x18\x01 \x01(\tR\x05robot\x12\x16\n" +
+	"\x06device\x18\x02 \x01(\tR\x06device\x12\x18\n" +
+	"\acommand\x18\x03 \x01(\tR\acommand\x12/\n" +
+	"\x06params\x18\x04 \x01(\v2\x17.google.protobuf.StructR\x06params\"W\n" +
+	"\x0fCommandResponse\x12.\n" +
+	"\x06result\x18\x01 \x01(\v2\x16.google.protobuf.ValueR\x06result\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"U\n" +
+	"\rEventsRequest\x12\x14\n" +
+	"\x05robot\x18\x01 \x01(\tR\x05robot\x12\x16\n" +
+	"\x06device\x18\x02 \x01(\tR\x06device\x12\x16\n" +
+	"\x06events\x18\x03 \x03(\tR\x06events\"w\n" +
+	"\x05Event\x12\x14\n" +
+	"\x05robot\x18\x01 \x01(\tR\x05robot\x12\x16\n" +
+	"\x06device\x18\x02 \x01(\tR\x06device\x12\x14\n" +
+	"\x05event\x18\x03 \x01(\tR\x05event\x12*\n" +
+	"\x04data\x18\x04 \x01(\v2\x16.google.protobuf.ValueR\x04data2\xf5\x01\n" +
+	"\x05Gobot\x12A\n" +
+	"\n" +
+	"ListRobots\x12\x18.gobot.ListRobotsRequest\x1a\x19.gobot.ListRobotsResponse\x120\n" +
+	"\bGetRobot\x12\x16.gobot.GetRobotRequest\x1a\f.gobot.Robot\x12?\n" +
+	"\x0eExecuteCommand\x12\x15.gobot.CommandRequest\x1a\x16.gobot.CommandResponse\x126\n" +
+	"\fStreamEvents\x12\x14.gobot.EventsRequest\x1a\f.gobot.Event(\x010\x01B*Z(github.com/hybridgroup/gobot/api/grpcapib\x06proto3"
+
+var (
+	file_gobot_proto_rawDescOnce sync.Once
+	file_gobot_proto_rawDescData []byte
+)
+
+func file_gobot_proto_rawDescGZIP() []byte {
+	file_gobot_proto_rawDescOnce.Do(func() {
+		file_gobot_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gobot_proto_rawDesc), len(file_gobot_proto_rawDesc)))
+	})
+	return file_gobot_proto_rawDescData
+}
+
+var file_gobot_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_gobot_proto_goTypes = []any{
+	(*ListRobotsRequest)(nil),  // 0: gobot.ListRobotsRequest
+	(*ListRobotsResponse)(nil), // 1: gobot.ListRobotsResponse
+	(*GetRobotRequest)(nil),    // 2: gobot.GetRobotRequest
+	(*Param)(nil),              // 3: gobot.Param
+	(*Params)(nil),             // 4: gobot.Params
+	(*Connection)(nil),         // 5: gobot.Connection
+	(*Device)(nil),             // 6: gobot.Device
+	(*Robot)(nil),              // 7: gobot.Robot
+	(*CommandRequest)(nil),     // 8: gobot.CommandRequest
+	(*CommandResponse)(nil),    // 9: gobot.CommandResponse
+	(*EventsRequest)(nil),      // 10: gobot.EventsRequest
+	(*Event)(nil),              // 11: gobot.Event
+	nil,                        // 12: gobot.ListRobotsRequest.TagsEntry
+	nil,                        // 13: gobot.Device.CommandParamsEntry
+	nil,                        // 14: gobot.Robot.TagsEntry
+	nil,                        // 15: gobot.Robot.CommandParamsEntry
+	(*structpb.Value)(nil),     // 16: google.protobuf.Value
+	(*structpb.Struct)(nil),    // 17: google.protobuf.Struct
+}
+var file_gobot_proto_depIdxs = []int32{
+	12, // 0: gobot.ListRobotsRequest.tags:type_name -> gobot.ListRobotsRequest.TagsEntry
+	7,  // 1: gobot.ListRobotsResponse.robots:type_name -> gobot.Robot
+	16, // 2: gobot.Param.default:type_name -> google.protobuf.Value
+	3,  // 3: gobot.Params.params:type_name -> gobot.Param
+	13, // 4: gobot.Device.command_params:type_name -> gobot.Device.CommandParamsEntry
+	14, // 5: gobot.Robot.tags:type_name -> gobot.Robot.TagsEntry
+	15, // 6: gobot.Robot.command_params:type_name -> gobot.Robot.CommandParamsEntry
+	5,  // 7: gobot.Robot.connections:type_name -> gobot.Connection
+	6,  // 8: gobot.Robot.devices:type_name -> gobot.Device
+	17, // 9: gobot.CommandRequest.params:type_name -> google.protobuf.Struct
+	16, // 10: gobot.CommandResponse.result:type_name -> google.protobuf.Value
+	16, // 11: gobot.Event.data:type_name -> google.protobuf.Value
+	4,  // 12: gobot.Device.CommandParamsEntry.value:type_name -> gobot.Params
+	4,  // 13: gobot.Robot.CommandParamsEntry.value:type_name -> gobot.Params
+	0,  // 14: gobot.Gobot.ListRobots:input_type -> gobot.ListRobotsRequest
+	2,  // 15: gobot.Gobot.GetRobot:input_type -> gobot.GetRobotRequest
+	8,  // 16: gobot.Gobot.ExecuteCommand:input_type -> gobot.CommandRequest
+	10, // 17: gobot.Gobot.StreamEvents:input_type -> gobot.EventsRequest
+	1,  // 18: gobot.Gobot.ListRobots:output_type -> gobot.ListRobotsResponse
+	7,  // 19: gobot.Gobot.GetRobot:output_type -> gobot.Robot
+	9,  // 20: gobot.Gobot.ExecuteCommand:output_type -> gobot.CommandResponse
+	11, // 21: gobot.Gobot.StreamEvents:output_type -> gobot.Event
+	18, // [18:22] is the sub-list for method output_type
+	14, // [14:18] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_gobot_proto_init() }
+func file_gobot_proto_init() {
+	if File_gobot_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gobot_proto_rawDesc), len(file_gobot_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gobot_proto_goTypes,
+		DependencyIndexes: file_gobot_proto_depIdxs,
+		MessageInfos:      file_gobot_proto_msgTypes,
+	}.Build()
+	File_gobot_proto = out.File
+	file_gobot_proto_goTypes = nil
+	file_gobot_proto_depIdxs = nil
+}
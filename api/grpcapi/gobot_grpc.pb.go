@@ -0,0 +1,243 @@
+// Protocol of the gRPC server of package grpcapi. gobot.pb.go and
+// gobot_grpc.pb.go are generated from it with make grpc.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: gobot.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Gobot_ListRobots_FullMethodName     = "/gobot.Gobot/ListRobots"
+	Gobot_GetRobot_FullMethodName       = "/gobot.Gobot/GetRobot"
+	Gobot_ExecuteCommand_FullMethodName = "/gobot.Gobot/ExecuteCommand"
+	Gobot_StreamEvents_FullMethodName   = "/gobot.Gobot/StreamEvents"
+)
+
+// GobotClient is the client API for Gobot service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GobotClient interface {
+	// ListRobots returns the robots, all of them or those with the tags.
+	ListRobots(ctx context.Context, in *ListRobotsRequest, opts ...grpc.CallOption) (*ListRobotsResponse, error)
+	// GetRobot returns a robot given its name.
+	GetRobot(ctx context.Context, in *GetRobotRequest, opts ...grpc.CallOption) (*Robot, error)
+	// ExecuteCommand executes a command of the Gobot, a robot or a device.
+	ExecuteCommand(ctx context.Context, in *CommandRequest, opts ...grpc.CallOption) (*CommandResponse, error)
+	// StreamEvents streams the events of the robots and devices subscribed
+	// to, a subscription can be added at any time by sending it.
+	StreamEvents(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[EventsRequest, Event], error)
+}
+
+type gobotClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGobotClient(cc grpc.ClientConnInterface) GobotClient {
+	return &gobotClient{cc}
+}
+
+func (c *gobotClient) ListRobots(ctx context.Context, in *ListRobotsRequest, opts ...grpc.CallOption) (*ListRobotsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRobotsResponse)
+	err := c.cc.Invoke(ctx, Gobot_ListRobots_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gobotClient) GetRobot(ctx context.Context, in *GetRobotRequest, opts ...grpc.CallOption) (*Robot, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Robot)
+	err := c.cc.Invoke(ctx, Gobot_GetRobot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gobotClient) ExecuteCommand(ctx context.Context, in *CommandRequest, opts ...grpc.CallOption) (*CommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommandResponse)
+	err := c.cc.Invoke(ctx, Gobot_ExecuteCommand_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gobotClient) StreamEvents(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[EventsRequest, Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Gobot_ServiceDesc.Streams[0], Gobot_StreamEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EventsRequest, Event]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Gobot_StreamEventsClient = grpc.BidiStreamingClient[EventsRequest, Event]
+
+// GobotServer is the server API for Gobot service.
+// All implementations must embed UnimplementedGobotServer
+// for forward compatibility.
+type GobotServer interface {
+	// ListRobots returns the robots, all of them or those with the tags.
+	ListRobots(context.Context, *ListRobotsRequest) (*ListRobotsResponse, error)
+	// GetRobot returns a robot given its name.
+	GetRobot(context.Context, *GetRobotRequest) (*Robot, error)
+	// ExecuteCommand executes a command of the Gobot, a robot or a device.
+	ExecuteCommand(context.Context, *CommandRequest) (*CommandResponse, error)
+	// StreamEvents streams the events of the robots and devices subscribed
+	// to, a subscription can be added at any time by sending it.
+	StreamEvents(grpc.BidiStreamingServer[EventsRequest, Event]) error
+	mustEmbedUnimplementedGobotServer()
+}
+
+// UnimplementedGobotServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGobotServer struct{}
+
+func (UnimplementedGobotServer) ListRobots(context.Context, *ListRobotsRequest) (*ListRobotsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRobots not implemented")
+}
+func (UnimplementedGobotServer) GetRobot(context.Context, *GetRobotRequest) (*Robot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRobot not implemented")
+}
+func (UnimplementedGobotServer) ExecuteCommand(context.Context, *CommandRequest) (*CommandResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExecuteCommand not implemented")
+}
+func (UnimplementedGobotServer) StreamEvents(grpc.BidiStreamingServer[EventsRequest, Event]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedGobotServer) mustEmbedUnimplementedGobotServer() {}
+func (UnimplementedGobotServer) testEmbeddedByValue()               {}
+
+// UnsafeGobotServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GobotServer will
+// result in compilation errors.
+type UnsafeGobotServer interface {
+	mustEmbedUnimplementedGobotServer()
+}
+
+func RegisterGobotServer(s grpc.ServiceRegistrar, srv GobotServer) {
+	// If the following call pancis, it indicates UnimplementedGobotServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Gobot_ServiceDesc, srv)
+}
+
+func _Gobot_ListRobots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRobotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GobotServer).ListRobots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gobot_ListRobots_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GobotServer).ListRobots(ctx, req.(*ListRobotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gobot_GetRobot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRobotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GobotServer).GetRobot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gobot_GetRobot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GobotServer).GetRobot(ctx, req.(*GetRobotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gobot_ExecuteCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GobotServer).ExecuteCommand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gobot_ExecuteCommand_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GobotServer).ExecuteCommand(ctx, req.(*CommandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gobot_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GobotServer).StreamEvents(&grpc.GenericServerStream[EventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Gobot_StreamEventsServer = grpc.BidiStreamingServer[EventsRequest, Event]
+
+// Gobot_ServiceDesc is the grpc.ServiceDesc for Gobot service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Gobot_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gobot.Gobot",
+	HandlerType: (*GobotServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListRobots",
+			Handler:    _Gobot_ListRobots_Handler,
+		},
+		{
+			MethodName: "GetRobot",
+			Handler:    _Gobot_GetRobot_Handler,
+		},
+		{
+			MethodName: "ExecuteCommand",
+			Handler:    _Gobot_ExecuteCommand_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _Gobot_StreamEvents_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "gobot.proto",
+}
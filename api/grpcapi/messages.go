@@ -0,0 +1,86 @@
+package grpcapi
+
+import (
+	"encoding/json"
+
+	"github.com/hybridgroup/gobot"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// newRobot returns a Robot message given a robot
+func newRobot(robot *gobot.Robot) (*Robot, error) {
+	j := gobot.NewJSONRobot(robot)
+	commandParams, err := newParams(j.CommandParams)
+	if err != nil {
+		return nil, err
+	}
+	r := &Robot{
+		Name:          j.Name,
+		Tags:          j.Tags,
+		Commands:      j.Commands,
+		CommandParams: commandParams,
+		Connections:   []*Connection{},
+		Devices:       []*Device{},
+	}
+	for _, c := range j.Connections {
+		r.Connections = append(r.Connections, &Connection{Name: c.Name, Adaptor: c.Adaptor})
+	}
+	for _, d := range j.Devices {
+		commandParams, err := newParams(d.CommandParams)
+		if err != nil {
+			return nil, err
+		}
+		r.Devices = append(r.Devices, &Device{
+			Name:          d.Name,
+			Driver:        d.Driver,
+			Connection:    d.Connection,
+			Commands:      d.Commands,
+			CommandParams: commandParams,
+			Disabled:      robot.DeviceDisabled(d.Name),
+		})
+	}
+	return r, nil
+}
+
+// newParams returns the Params messages of commands given their parameters
+func newParams(params map[string][]gobot.Param) (map[string]*Params, error) {
+	if params == nil {
+		return nil, nil
+	}
+	p := make(map[string]*Params)
+	for name, params := range params {
+		p[name] = &Params{Params: []*Param{}}
+		for _, param := range params {
+			m := &Param{
+				Name:     param.Name,
+				Type:     string(param.Type),
+				Required: param.Required,
+				Min:      param.Min,
+				Max:      param.Max,
+			}
+			if param.Default != nil {
+				value, err := newValue(param.Default)
+				if err != nil {
+					return nil, err
+				}
+				m.Default = value
+			}
+			p[name].Params = append(p[name].Params, m)
+		}
+	}
+	return p, nil
+}
+
+// newValue returns the Value message of v, holding v as the REST API
+// encodes it in JSON
+func newValue(v interface{}) (*structpb.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return structpb.NewValue(decoded)
+}
@@ -0,0 +1,232 @@
+package grpcapi
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ gobot.APIServer = (*Server)(nil)
+var _ GobotServer = (*Server)(nil)
+
+// Server is a gRPC server of the robots of a Gobot, implementing the Gobot
+// service of gobot.proto
+type Server struct {
+	Host string
+	Port string
+	// Options are the options of the grpc.Server, e.g. grpc.Creds to serve
+	// TLS
+	Options []grpc.ServerOption
+
+	gobot    *gobot.Gobot
+	server   *grpc.Server
+	listener net.Listener
+	mutex    sync.Mutex
+	UnimplementedGobotServer
+}
+
+// NewServer returns a new Server of g, listening on port 50051. g may be nil
+// if the server is added to a Gobot with AddAPIServer.
+func NewServer(g *gobot.Gobot) *Server {
+	return &Server{
+		gobot: g,
+		Port:  "50051",
+	}
+}
+
+// Attach sets the Gobot exposed by the server.
+func (s *Server) Attach(g *gobot.Gobot) {
+	s.gobot = g
+}
+
+// Start starts listening for gRPC requests.
+func (s *Server) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	gobot.Log(gobot.InfoLevel, "Initializing gRPC API", gobot.Fields{"address": s.Host + ":" + s.Port})
+	listener, err := net.Listen("tcp", s.Host+":"+s.Port)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.server = grpc.NewServer(s.Options...)
+	RegisterGobotServer(s.server, s)
+	go s.server.Serve(listener)
+	return nil
+}
+
+// Stop stops the server, closing the open streams.
+func (s *Server) Stop() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.server != nil {
+		s.server.Stop()
+		s.server, s.listener = nil, nil
+	}
+	return nil
+}
+
+// ListRobots returns the robots, all of them or those with the tags of req
+func (s *Server) ListRobots(ctx context.Context, req *ListRobotsRequest) (*ListRobotsResponse, error) {
+	filters := []gobot.Tags{}
+	if len(req.Tags) > 0 {
+		filters = append(filters, gobot.Tags(req.Tags))
+	}
+	res := &ListRobotsResponse{Robots: []*Robot{}}
+	var err error
+	s.gobot.Robots(filters...).Each(func(robot *gobot.Robot) {
+		if err != nil {
+			return
+		}
+		var r *Robot
+		if r, err = newRobot(robot); err == nil {
+			res.Robots = append(res.Robots, r)
+		}
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return res, nil
+}
+
+// GetRobot returns a robot given its name
+func (s *Server) GetRobot(ctx context.Context, req *GetRobotRequest) (*Robot, error) {
+	robot := s.gobot.Robot(req.Name)
+	if robot == nil {
+		return nil, status.Error(codes.NotFound, "No Robot found with the name "+req.Name)
+	}
+	r, err := newRobot(robot)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return r, nil
+}
+
+// ExecuteCommand executes a command of the Gobot, a robot or a device
+func (s *Server) ExecuteCommand(ctx context.Context, req *CommandRequest) (*CommandResponse, error) {
+	var commander gobot.Commander = s.gobot.Commander
+	if req.Robot != "" {
+		robot := s.gobot.Robot(req.Robot)
+		if robot == nil {
+			return nil, status.Error(codes.NotFound, "No Robot found with the name "+req.Robot)
+		}
+		commander = robot.Commander
+		if req.Device != "" {
+			device := robot.Device(req.Device)
+			if device == nil {
+				return nil, status.Error(codes.NotFound, "No Device found with the name "+req.Device)
+			}
+			var ok bool
+			if commander, ok = device.(gobot.Commander); !ok {
+				return nil, status.Error(codes.NotFound, "Unknown Command")
+			}
+		}
+	}
+	command := commander.Command(req.Command)
+	if command == nil {
+		return nil, status.Error(codes.NotFound, "Unknown Command")
+	}
+	result := command(req.GetParams().AsMap())
+	if err, ok := result.(error); ok {
+		return &CommandResponse{Error: err.Error()}, nil
+	}
+	value, err := newValue(result)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &CommandResponse{Result: value}, nil
+}
+
+// StreamEvents streams the events subscribed to by the EventsRequests
+// received, until the client cancels the stream
+func (s *Server) StreamEvents(stream Gobot_StreamEventsServer) error {
+	done := stream.Context().Done()
+	events := make(chan *Event, api.EventBuffer)
+	errs := make(chan error, 1)
+
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err == io.EOF {
+				// the client is done subscribing
+				return
+			} else if err != nil {
+				errs <- err
+				return
+			}
+			if err := s.subscribe(req, events, done); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case event := <-events:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		case err := <-errs:
+			if status.Code(err) == codes.Canceled {
+				return nil
+			}
+			return err
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// subscribe sends the events of the robot or device of req to events, until
+// done is closed, which unsubscribes from them
+func (s *Server) subscribe(req *EventsRequest, events chan<- *Event, done <-chan struct{}) error {
+	if err := api.CheckEventFilters(req.Events); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	robot := s.gobot.Robot(req.Robot)
+	if robot == nil {
+		return status.Error(codes.NotFound, "No Robot found with the name "+req.Robot)
+	}
+	var eventer gobot.Eventer = robot
+	if req.Device != "" {
+		var ok bool
+		if eventer, ok = robot.Device(req.Device).(gobot.Eventer); !ok {
+			return status.Error(codes.NotFound, "No Events found for the device "+req.Device)
+		}
+	}
+	stop, err := eventer.OnPattern("*", func(name string, data interface{}) {
+		if !api.MatchEvent(req.Events, name) {
+			return
+		}
+		if err, ok := data.(error); ok {
+			data = err.Error()
+		}
+		value, err := newValue(data)
+		if err != nil {
+			gobot.Log(gobot.WarnLevel, "Dropping event which can not be encoded", gobot.Fields{"event": name, "error": err.Error()})
+			return
+		}
+		select {
+		case <-done:
+		case events <- &Event{Robot: robot.Name, Device: req.Device, Event: name, Data: value}:
+		default:
+			gobot.Log(gobot.WarnLevel, "Dropping event for slow client", gobot.Fields{"event": name})
+		}
+	})
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-done
+		stop()
+	}()
+	return nil
+}
@@ -0,0 +1,168 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type testAdaptor struct {
+	name string
+}
+
+func (t *testAdaptor) Connect() (errs []error)  { return }
+func (t *testAdaptor) Finalize() (errs []error) { return }
+func (t *testAdaptor) Name() string             { return t.name }
+
+type testDriver struct {
+	name       string
+	connection gobot.Connection
+	gobot.Commander
+	gobot.Eventer
+}
+
+func (t *testDriver) Start() (errs []error)        { return }
+func (t *testDriver) Halt() (errs []error)         { return }
+func (t *testDriver) Name() string                 { return t.name }
+func (t *testDriver) Connection() gobot.Connection { return t.connection }
+
+// testStream is a Gobot_StreamEventsServer receiving requests and recording
+// the events sent
+type testStream struct {
+	ctx      context.Context
+	requests chan *EventsRequest
+	sent     chan *Event
+	grpc.ServerStream
+}
+
+func (t *testStream) Context() context.Context { return t.ctx }
+func (t *testStream) Send(event *Event) error {
+	t.sent <- event
+	return nil
+}
+func (t *testStream) Recv() (*EventsRequest, error) {
+	req, ok := <-t.requests
+	if !ok {
+		return nil, io.EOF
+	}
+	return req, nil
+}
+
+func initTestServer() (*Server, *testDriver) {
+	g := gobot.NewGobot()
+	board := &testAdaptor{name: "board"}
+	led := &testDriver{name: "led", connection: board, Commander: gobot.NewCommander(), Eventer: gobot.NewEventer()}
+	led.AddCommand("Toggle", func(params map[string]interface{}) interface{} { return params["state"] })
+	led.AddCommand("Fail", func(params map[string]interface{}) interface{} { return errors.New("failed") })
+	led.AddEvent("toggled")
+	g.AddRobot(gobot.NewRobot("bot", gobot.Tags{"zone": "lab"}, []gobot.Connection{board}, []gobot.Device{led}))
+	g.AddRobot(gobot.NewRobot("rover"))
+	return NewServer(g), led
+}
+
+func TestServerRobots(t *testing.T) {
+	s, _ := initTestServer()
+	res, err := s.ListRobots(context.Background(), &ListRobotsRequest{})
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, len(res.Robots), 2)
+
+	res, _ = s.ListRobots(context.Background(), &ListRobotsRequest{Tags: map[string]string{"zone": "lab"}})
+	gobot.Assert(t, len(res.Robots), 1)
+	gobot.Assert(t, res.Robots[0].Devices[0].Name, "led")
+	commands := res.Robots[0].Devices[0].Commands
+	sort.Strings(commands)
+	gobot.Assert(t, commands, []string{"Fail", "Toggle"})
+
+	robot, err := s.GetRobot(context.Background(), &GetRobotRequest{Name: "rover"})
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, robot.Name, "rover")
+
+	_, err = s.GetRobot(context.Background(), &GetRobotRequest{Name: "missing"})
+	gobot.Assert(t, status.Code(err), codes.NotFound)
+}
+
+func TestServerExecuteCommand(t *testing.T) {
+	s, _ := initTestServer()
+	params, _ := structpb.NewStruct(map[string]interface{}{"state": true})
+	res, err := s.ExecuteCommand(context.Background(), &CommandRequest{
+		Robot: "bot", Device: "led", Command: "Toggle", Params: params,
+	})
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, res.Result.AsInterface(), true)
+
+	res, _ = s.ExecuteCommand(context.Background(), &CommandRequest{Robot: "bot", Device: "led", Command: "Fail"})
+	gobot.Assert(t, res.Error, "failed")
+
+	_, err = s.ExecuteCommand(context.Background(), &CommandRequest{Robot: "bot", Device: "led", Command: "Blink"})
+	gobot.Assert(t, status.Code(err), codes.NotFound)
+
+	_, err = s.ExecuteCommand(context.Background(), &CommandRequest{Robot: "bot", Device: "missing", Command: "Toggle"})
+	gobot.Assert(t, status.Code(err), codes.NotFound)
+}
+
+func TestServerStreamEvents(t *testing.T) {
+	s, led := initTestServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &testStream{ctx: ctx, requests: make(chan *EventsRequest, 1), sent: make(chan *Event, 1)}
+	done := make(chan error)
+	go func() { done <- s.StreamEvents(stream) }()
+
+	stream.requests <- &EventsRequest{Robot: "bot", Device: "led", Events: []string{"togg*"}}
+	time.Sleep(10 * time.Millisecond)
+	gobot.Publish(led.Event("toggled"), 1)
+	select {
+	case event := <-stream.sent:
+		gobot.Assert(t, proto.Equal(event, &Event{
+			Robot: "bot", Device: "led", Event: "toggled", Data: structpb.NewNumberValue(1),
+		}), true)
+	case <-time.After(time.Second):
+		t.Fatal("event not sent")
+	}
+
+	cancel()
+	gobot.Assert(t, <-done, nil)
+
+	stream = &testStream{ctx: context.Background(), requests: make(chan *EventsRequest, 1), sent: make(chan *Event)}
+	stream.requests <- &EventsRequest{Robot: "missing"}
+	gobot.Assert(t, status.Code(s.StreamEvents(stream)), codes.NotFound)
+}
+
+func TestServerStart(t *testing.T) {
+	s, _ := initTestServer()
+	s.Host = "127.0.0.1"
+	s.Port = "0"
+	gobot.Assert(t, s.Start(), nil)
+	gobot.Refute(t, s.listener, nil)
+
+	// a client of the stubs generated from gobot.proto, with the default
+	// proto codec
+	conn, err := grpc.NewClient(s.listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	gobot.Assert(t, err, nil)
+	defer conn.Close()
+	client := NewGobotClient(conn)
+	robot, err := client.GetRobot(context.Background(), &GetRobotRequest{Name: "bot"})
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, robot.Devices[0].Name, "led")
+	params, _ := structpb.NewStruct(map[string]interface{}{"state": "on"})
+	res, err := client.ExecuteCommand(context.Background(), &CommandRequest{
+		Robot: "bot", Device: "led", Command: "Toggle", Params: params,
+	})
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, res.Result.GetStringValue(), "on")
+
+	gobot.Assert(t, s.Stop(), nil)
+
+	s.Port = "invalid"
+	gobot.Refute(t, s.Start(), nil)
+}
@@ -0,0 +1,336 @@
+package api
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClaimsParam is the command parameter holding the Claims of the token a
+// command was requested with, so CommandMiddleware can authorize commands
+// per user. It is removed from the parameters sent by clients.
+const ClaimsParam = "jwt_claims"
+
+// jwksRefresh is how often the keys of a JSON Web Key Set are fetched again
+// at most, when a token names an unknown key
+var jwksRefresh = 5 * time.Minute
+
+// jwksClient fetches the JSON Web Key Sets, giving up on servers which do
+// not answer in time
+var jwksClient = &http.Client{Timeout: 10 * time.Second}
+
+// Claims are the claims of a validated JSON Web Token
+type Claims map[string]interface{}
+
+// Subject returns the "sub" claim
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// HasScope returns true if scope is one of the space separated scopes of the
+// "scope" claim
+func (c Claims) HasScope(scope string) bool {
+	s, _ := c["scope"].(string)
+	for _, field := range strings.Fields(s) {
+		if field == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// JWT validates the JSON Web Tokens sent as bearer tokens to the API, see
+// API.UseJWT.
+type JWT struct {
+	// Secret validates the tokens signed with HS256, HS384 or HS512
+	Secret []byte
+	// JWKSURL is the URL of a JSON Web Key Set, whose RSA keys validate the
+	// tokens signed with RS256, RS384 or RS512
+	JWKSURL string
+	// Issuer and Audience must match the "iss" and "aud" claims, if set
+	Issuer   string
+	Audience string
+	// Routes are the paths which require a token, as path.Match patterns
+	// optionally prefixed with a method, e.g. "POST /api/robots/*/commands/*".
+	// Every route requires a token if Routes is empty.
+	Routes []string
+
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+	mutex   sync.Mutex
+	// refresh serializes the fetches of the keys, which are looked up
+	// meanwhile without waiting for them
+	refresh sync.Mutex
+}
+
+// UseJWT makes the api reject the requests to the routes of j without a
+// valid bearer token, sent in the Authorization header or the access_token
+// query parameter, and pass the claims of the token to the commands it
+// executes in the ClaimsParam parameter.
+func (a *API) UseJWT(j *JWT) {
+	a.jwt = j
+	a.AddHandler(func(res http.ResponseWriter, req *http.Request) {
+		if !matchRoute(j.Routes, req) {
+			return
+		}
+		if _, err := j.claims(req); err != nil {
+			res.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer error=%q", "invalid_token"))
+			http.Error(res, "Not Authorized", http.StatusUnauthorized)
+			return
+		}
+		if state, ok := req.Context().Value(authKey{}).(*authState); ok {
+			state.token = bearerToken(req)
+		}
+	})
+}
+
+// matchRoute returns true if req is to one of routes, path.Match patterns
+// optionally prefixed with a method, or if there are no routes
+func matchRoute(routes []string, req *http.Request) bool {
+	if len(routes) == 0 {
+		return true
+	}
+	for _, route := range routes {
+		pattern := route
+		if i := strings.Index(route, " "); i >= 0 {
+			if route[:i] != req.Method {
+				continue
+			}
+			pattern = route[i+1:]
+		}
+		if matched, _ := path.Match(pattern, req.URL.Path); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// bearerToken returns the bearer token of req, sent in the Authorization
+// header or the access_token query parameter
+func bearerToken(req *http.Request) string {
+	if auth := req.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return req.URL.Query().Get("access_token")
+}
+
+// claims returns the claims of the bearer token of req
+func (j *JWT) claims(req *http.Request) (Claims, error) {
+	token := bearerToken(req)
+	if token == "" {
+		return nil, errors.New("Missing bearer token")
+	}
+	return j.Validate(token)
+}
+
+// Validate checks the signature, expiry, issuer and audience of token and
+// returns its claims.
+func (j *JWT) Validate(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("Malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("Malformed token signature")
+	}
+	if err := j.verify(header.Alg, header.Kid, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	claims := Claims{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	now := float64(time.Now().Unix())
+	if exp, ok := claims["exp"].(float64); ok && now >= exp {
+		return nil, errors.New("Token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now < nbf {
+		return nil, errors.New("Token not valid yet")
+	}
+	if j.Issuer != "" && claims["iss"] != j.Issuer {
+		return nil, errors.New("Invalid token issuer")
+	}
+	if j.Audience != "" && !hasAudience(claims["aud"], j.Audience) {
+		return nil, errors.New("Invalid token audience")
+	}
+	return claims, nil
+}
+
+// verify checks the signature of signed with the algorithm alg
+func (j *JWT) verify(alg string, kid string, signed string, signature []byte) error {
+	var (
+		newHash func() hash.Hash
+		h       crypto.Hash
+	)
+	if len(alg) != 5 {
+		return fmt.Errorf("Unsupported token algorithm %q", alg)
+	}
+	switch alg[2:] {
+	case "256":
+		newHash, h = sha256.New, crypto.SHA256
+	case "384":
+		newHash, h = sha512.New384, crypto.SHA384
+	case "512":
+		newHash, h = sha512.New, crypto.SHA512
+	default:
+		return fmt.Errorf("Unsupported token algorithm %q", alg)
+	}
+	switch alg[:2] {
+	case "HS":
+		if len(j.Secret) == 0 {
+			return fmt.Errorf("Unsupported token algorithm %q", alg)
+		}
+		mac := hmac.New(newHash, j.Secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errors.New("Invalid token signature")
+		}
+		return nil
+	case "RS":
+		key, err := j.key(kid)
+		if err != nil {
+			return err
+		}
+		digest := newHash()
+		digest.Write([]byte(signed))
+		if rsa.VerifyPKCS1v15(key, h, digest.Sum(nil), signature) != nil {
+			return errors.New("Invalid token signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("Unsupported token algorithm %q", alg)
+}
+
+// key returns the RSA key of the JSON Web Key Set with the id kid, fetching
+// the keys if kid is unknown and they were not fetched recently
+func (j *JWT) key(kid string) (*rsa.PublicKey, error) {
+	if j.JWKSURL == "" {
+		return nil, errors.New("Unsupported token algorithm, no JWKSURL")
+	}
+	if key, fetch := j.cachedKey(kid); !fetch {
+		return key, unknownKey(key, kid)
+	}
+	j.refresh.Lock()
+	defer j.refresh.Unlock()
+	// the keys may have been fetched while waiting for the refresh
+	if key, fetch := j.cachedKey(kid); !fetch {
+		return key, unknownKey(key, kid)
+	}
+	keys, err := fetchJWKS(j.JWKSURL)
+	if err != nil {
+		return nil, err
+	}
+	j.mutex.Lock()
+	j.keys, j.fetched = keys, time.Now()
+	j.mutex.Unlock()
+	return keys[kid], unknownKey(keys[kid], kid)
+}
+
+// cachedKey returns the fetched RSA key with the id kid, or whether the keys
+// should be fetched again to find it
+func (j *JWT) cachedKey(kid string) (key *rsa.PublicKey, fetch bool) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if key, ok := j.keys[kid]; ok {
+		return key, false
+	}
+	return nil, time.Since(j.fetched) >= jwksRefresh
+}
+
+// unknownKey returns an error if no key with the id kid was found
+func unknownKey(key *rsa.PublicKey, kid string) error {
+	if key == nil {
+		return fmt.Errorf("Unknown token key %q", kid)
+	}
+	return nil
+}
+
+// fetchJWKS returns the RSA keys of the JSON Web Key Set at url by key id
+func fetchJWKS(url string) (map[string]*rsa.PublicKey, error) {
+	res, err := jwksClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS %v: %v", url, res.Status)
+	}
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&jwks); err != nil {
+		return nil, fmt.Errorf("JWKS %v: %v", url, err)
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			return nil, fmt.Errorf("JWKS %v: malformed key %q", url, k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+// decodeSegment decodes the base64url encoded JSON segment of a token into v
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.New("Malformed token")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("Malformed token")
+	}
+	return nil
+}
+
+// hasAudience returns true if the "aud" claim, a string or an array of
+// strings, holds audience
+func hasAudience(aud interface{}, audience string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == audience
+	case []interface{}:
+		for _, a := range v {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
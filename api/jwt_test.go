@@ -0,0 +1,178 @@
+package api
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// signJWT returns a token of claims signed by sign with the algorithm alg
+func signJWT(alg string, kid string, claims Claims, sign func(signed []byte) []byte) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func hs256(secret string) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+func TestJWTValidate(t *testing.T) {
+	j := &JWT{Secret: []byte("secret"), Issuer: "gort", Audience: "gobot"}
+
+	claims, err := j.Validate(signJWT("HS256", "", Claims{
+		"sub": "eve", "iss": "gort", "aud": []string{"gobot"}, "scope": "read drive",
+	}, hs256("secret")))
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, claims.Subject(), "eve")
+	gobot.Assert(t, claims.HasScope("drive"), true)
+	gobot.Assert(t, claims.HasScope("admin"), false)
+
+	_, err = j.Validate(signJWT("HS256", "", Claims{"iss": "gort", "aud": "gobot"}, hs256("wrong")))
+	gobot.Assert(t, err, errors.New("Invalid token signature"))
+
+	_, err = j.Validate(signJWT("HS256", "", Claims{
+		"iss": "gort", "aud": "gobot", "exp": time.Now().Add(-time.Minute).Unix(),
+	}, hs256("secret")))
+	gobot.Assert(t, err, errors.New("Token expired"))
+
+	_, err = j.Validate(signJWT("HS256", "", Claims{"iss": "other", "aud": "gobot"}, hs256("secret")))
+	gobot.Assert(t, err, errors.New("Invalid token issuer"))
+
+	_, err = j.Validate(signJWT("HS256", "", Claims{"iss": "gort", "aud": "other"}, hs256("secret")))
+	gobot.Assert(t, err, errors.New("Invalid token audience"))
+
+	_, err = j.Validate(signJWT("none", "", Claims{"iss": "gort", "aud": "gobot"}, func([]byte) []byte { return nil }))
+	gobot.Assert(t, err, errors.New(`Unsupported token algorithm "none"`))
+
+	_, err = j.Validate("not.a-token")
+	gobot.Assert(t, err, errors.New("Malformed token"))
+}
+
+func TestJWTValidateJWKS(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 1024)
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		fetches++
+		json.NewEncoder(res).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer server.Close()
+	rs256 := func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		return signature
+	}
+
+	j := &JWT{JWKSURL: server.URL}
+	claims, err := j.Validate(signJWT("RS256", "key1", Claims{"sub": "eve"}, rs256))
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, claims.Subject(), "eve")
+
+	_, err = j.Validate(signJWT("RS256", "key2", Claims{"sub": "eve"}, rs256))
+	gobot.Assert(t, err, errors.New(`Unknown token key "key2"`))
+	gobot.Assert(t, fetches, 1)
+
+	// HS256 tokens are not accepted without a Secret
+	_, err = j.Validate(signJWT("HS256", "", Claims{"sub": "eve"}, hs256("")))
+	gobot.Assert(t, err, errors.New(`Unsupported token algorithm "HS256"`))
+}
+
+func TestJWTValidateJWKSTimeout(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 1024)
+	requested := make(chan bool, 1)
+	release := make(chan bool)
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		requested <- true
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	rs256 := func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		return signature
+	}
+	client := jwksClient
+	jwksClient = &http.Client{Timeout: 100 * time.Millisecond}
+	defer func() { jwksClient = client }()
+
+	j := &JWT{JWKSURL: server.URL, keys: map[string]*rsa.PublicKey{"key1": &key.PublicKey}}
+	failed := make(chan error)
+	go func() {
+		_, err := j.Validate(signJWT("RS256", "key2", Claims{"sub": "eve"}, rs256))
+		failed <- err
+	}()
+
+	// known keys are looked up while the keys are being fetched
+	<-requested
+	claims, err := j.Validate(signJWT("RS256", "key1", Claims{"sub": "eve"}, rs256))
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, claims.Subject(), "eve")
+
+	select {
+	case err = <-failed:
+		gobot.Refute(t, err, nil)
+	case <-time.After(time.Second):
+		t.Errorf("Fetching the keys did not time out")
+	}
+}
+
+func TestUseJWT(t *testing.T) {
+	a := initTestAPI()
+	a.UseJWT(&JWT{Secret: []byte("secret"), Routes: []string{"POST /api/commands/*"}})
+	var claims interface{}
+	a.gobot.AddCommand("WhoAmI", func(params map[string]interface{}) interface{} {
+		claims = params[ClaimsParam]
+		return nil
+	})
+
+	// routes which do not require a token
+	request, _ := http.NewRequest("GET", "/api/commands/WhoAmI", bytes.NewBufferString(`{"jwt_claims": {"sub": "root"}}`))
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 200)
+	gobot.Assert(t, claims, nil)
+
+	request, _ = http.NewRequest("POST", "/api/commands/WhoAmI", bytes.NewBufferString("{}"))
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 401)
+	gobot.Assert(t, response.Header().Get("WWW-Authenticate"), `Bearer error="invalid_token"`)
+
+	token := signJWT("HS256", "", Claims{"sub": "eve"}, hs256("secret"))
+	request, _ = http.NewRequest("POST", "/api/commands/WhoAmI", bytes.NewBufferString("{}"))
+	request.Header.Set("Authorization", "Bearer "+token)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 200)
+	gobot.Assert(t, claims.(Claims).Subject(), "eve")
+
+	request, _ = http.NewRequest("POST", "/api/commands/WhoAmI?access_token="+token, bytes.NewBufferString("{}"))
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 200)
+}
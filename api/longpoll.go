@@ -0,0 +1,142 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// longPollTimeout is the longest a poll waits for events before answering
+// without any
+var longPollTimeout = 30 * time.Second
+
+// longPollCheck is how often a waiting poll checks for new events
+var longPollCheck = 50 * time.Millisecond
+
+// UseLongPoll serves GET /api/robots/:robot/events/poll, for the clients
+// which can use neither WebSocket nor Server-Sent Events. The robots and
+// their devices retain the last size events published on them in their
+// replay buffer, see gobot.Eventer, from which each poll returns those
+// published since the cursor of the previous one. Only the robots of the
+// Gobot when the api starts retain their events.
+func (a *API) UseLongPoll(size int) {
+	a.longPoll = size
+}
+
+// startLongPoll makes the robots retain their events and registers the
+// long poll route if UseLongPoll was used
+func (a *API) startLongPoll() {
+	if a.longPoll <= 0 {
+		return
+	}
+	a.gobot.Robots().Each(func(robot *gobot.Robot) {
+		for _, source := range robotEventSources(robot) {
+			source.eventer.RetainEvents(a.longPoll)
+		}
+	})
+	a.Get("/api/robots/:robot/events/poll", a.pollEvents)
+}
+
+// pollEvents returns the long poll route handler.
+// Writes JSON with the events published by the robot and its devices since
+// the "cursor" of the query, and the cursor of the next poll, waiting for
+// them up to the "timeout" of the query, or longPollTimeout. The events are
+// filtered by name with the "event" parameters, and by the other parameters
+// of the query, see newEventFilter.
+func (a *API) pollEvents(res http.ResponseWriter, req *http.Request) {
+	robot := a.gobot.Robot(req.URL.Query().Get(":robot"))
+	if robot == nil {
+		a.writeJSON(map[string]interface{}{
+			"error": "No Robot found with the name " + req.URL.Query().Get(":robot"),
+		}, res)
+		return
+	}
+	query := req.URL.Query()
+	filters := query["event"]
+	if err := CheckEventFilters(filters); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	filter, err := newEventFilter(query)
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	var cursor uint64
+	if v := query.Get("cursor"); v != "" {
+		if cursor, err = strconv.ParseUint(v, 10, 64); err != nil {
+			a.writeJSON(map[string]interface{}{"error": "Invalid cursor " + v}, res)
+			return
+		}
+	}
+	timeout := longPollTimeout
+	if v := query.Get("timeout"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			a.writeJSON(map[string]interface{}{"error": "Invalid timeout " + v}, res)
+			return
+		}
+		if d < timeout {
+			timeout = d
+		}
+	}
+
+	sources := robotEventSources(robot)
+	expired := time.After(timeout)
+	check := time.NewTicker(longPollCheck)
+	defer check.Stop()
+	for {
+		var events []JSONEvent
+		events, cursor = pollSources(robot.Name, sources, cursor, filters, filter)
+		if len(events) > 0 {
+			a.writeJSON(map[string]interface{}{"cursor": strconv.FormatUint(cursor, 10), "events": events}, res)
+			return
+		}
+		select {
+		case <-check.C:
+		case <-expired:
+			a.writeJSON(map[string]interface{}{"cursor": strconv.FormatUint(cursor, 10), "events": events}, res)
+			return
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
+
+// pollSources returns the events retained by sources of robot since cursor
+// whose name matches one of filters and which filter allows, oldest first,
+// and the cursor following them
+func pollSources(robot string, sources []eventSource, cursor uint64, filters []string,
+	filter *eventFilter) ([]JSONEvent, uint64) {
+	type polled struct {
+		device string
+		gobot.EventRecord
+	}
+	records := []polled{}
+	for _, source := range sources {
+		for _, record := range source.eventer.EventsSince(cursor) {
+			records = append(records, polled{device: source.device, EventRecord: record})
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Seq < records[j].Seq })
+
+	events := []JSONEvent{}
+	for _, record := range records {
+		cursor = record.Seq
+		if !MatchEvent(filters, record.Name) {
+			continue
+		}
+		data := record.Data
+		if err, ok := data.(error); ok {
+			data = err.Error()
+		}
+		event := JSONEvent{Robot: robot, Device: record.device, Event: record.Name, Data: data, Time: record.Time}
+		if filter.allow(event) {
+			events = append(events, event)
+		}
+	}
+	return events, cursor
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestPollEvents(t *testing.T) {
+	g := gobot.NewGobot()
+	robot := newTestRobot("Robot1")
+	robot.AddEvent("status")
+	device := &eventDriver{
+		testDriver: newTestDriver(newTestAdaptor("Connection1", "/dev/null"), "sensor", "3"),
+		Eventer:    gobot.NewEventer(),
+	}
+	device.AddEvent("reading")
+	robot.AddDevice(device)
+	g.AddRobot(robot)
+	a := NewAPI(g)
+	a.start = func(m *API) error { return nil }
+	a.UseLongPoll(10)
+	a.Start()
+
+	type poll struct {
+		Cursor string
+		Events []map[string]interface{}
+		Error  string
+	}
+	request := func(url string) (p poll) {
+		req, _ := http.NewRequest("GET", url, nil)
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		json.NewDecoder(res.Body).Decode(&p)
+		return
+	}
+
+	gobot.Publish(robot.Event("status"), "ready")
+	gobot.Publish(device.Event("reading"), 42)
+	p := request("/api/robots/Robot1/events/poll")
+	gobot.Assert(t, len(p.Events), 2)
+	gobot.Assert(t, p.Events[0]["event"], "status")
+	gobot.Assert(t, p.Events[0]["data"], "ready")
+	gobot.Assert(t, p.Events[1]["device"], "sensor")
+	gobot.Assert(t, p.Events[1]["data"], 42.0)
+
+	p = request("/api/robots/Robot1/events/poll?timeout=10ms&cursor=" + p.Cursor)
+	gobot.Assert(t, len(p.Events), 0)
+	cursor := p.Cursor
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		gobot.Publish(robot.Event("status"), "busy")
+	}()
+	p = request("/api/robots/Robot1/events/poll?timeout=1s&cursor=" + cursor)
+	gobot.Assert(t, len(p.Events), 1)
+	gobot.Assert(t, p.Events[0]["data"], "busy")
+
+	p = request("/api/robots/Robot1/events/poll?device=sensor&cursor=0")
+	gobot.Assert(t, len(p.Events), 1)
+	gobot.Assert(t, p.Events[0]["event"], "reading")
+
+	gobot.Assert(t, request("/api/robots/Robot1/events/poll?cursor=x").Error, "Invalid cursor x")
+	gobot.Assert(t, request("/api/robots/Robot1/events/poll?timeout=x").Error, "Invalid timeout x")
+	gobot.Assert(t, request("/api/robots/Robot2/events/poll").Error, "No Robot found with the name Robot2")
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/metrics"
+)
+
+// UseMetrics serves the counters of m at /metrics in the Prometheus text
+// format, so monitoring stacks can scrape the robots. m counts what the
+// robots instrumented with m.InstrumentGobot or m.Instrument do. The counters
+// are served by the api, behind its authentication, or at /metrics on their
+// own port when MetricsPort is set.
+func (a *API) UseMetrics(m *metrics.Metrics) {
+	a.metrics = m
+}
+
+// startMetrics serves the metrics of the api, if any, on the api or on
+// MetricsPort
+func (a *API) startMetrics() error {
+	if a.metrics == nil {
+		return nil
+	}
+	if a.MetricsPort == "" {
+		a.Get("/metrics", a.metrics.ServeHTTP)
+		return nil
+	}
+	gobot.Log(gobot.InfoLevel, "Initializing metrics", gobot.Fields{"address": a.Host + ":" + a.MetricsPort})
+	listener, err := net.Listen("tcp", a.Host+":"+a.MetricsPort)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", a.metrics)
+	a.metricsListener = listener
+	a.serve(listener, mux)
+	return nil
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/metrics"
+)
+
+func TestUseMetrics(t *testing.T) {
+	a := initTestAPI()
+	m := metrics.New()
+	m.AddDevice(metrics.DeviceErrors, "Robot1", "Device1", 2)
+	a.UseMetrics(m)
+	a.Start()
+
+	request, _ := http.NewRequest("GET", "/metrics", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 200)
+	gobot.Assert(t, strings.Contains(response.Body.String(),
+		"gobot_device_errors_total{robot=\"Robot1\",device=\"Device1\"} 2\n"), true)
+}
+
+func TestMetricsPort(t *testing.T) {
+	log.SetOutput(NullReadWriteCloser{})
+	a := NewAPI(gobot.NewGobot())
+	a.start = func(m *API) error { return nil }
+	a.MetricsPort = "0"
+	m := metrics.New()
+	m.Add(metrics.CommandsExecuted, "bot", 1)
+	a.UseMetrics(m)
+	gobot.Assert(t, a.Start(), nil)
+	defer a.Stop()
+
+	res, err := http.Get("http://" + a.metricsListener.Addr().String() + "/metrics")
+	gobot.Assert(t, err, nil)
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	gobot.Assert(t, strings.Contains(string(body), "gobot_commands_executed_total{robot=\"bot\"} 1\n"), true)
+
+	// the api does not serve them
+	request, _ := http.NewRequest("GET", "/metrics", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 404)
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// Middleware wraps the handler serving the requests to the api, see API.Use.
+type Middleware func(next http.Handler) http.Handler
+
+// Use wraps the api with middleware, around its handlers and router, so they
+// see every request and response, e.g. to add observability. The first
+// middleware used is the outermost. Use must be called before the api
+// serves requests.
+func (a *API) Use(middleware ...Middleware) {
+	a.middleware = append(a.middleware, middleware...)
+	var h http.Handler = http.HandlerFunc(a.serveHTTP)
+	for i := len(a.middleware) - 1; i >= 0; i-- {
+		h = a.middleware[i](h)
+	}
+	a.chain = h
+}
+
+// Logging is a Middleware logging each request with the status, size and
+// duration of its response.
+func Logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		// the router adds the route parameters to the query of req
+		start, url := time.Now(), req.URL.String()
+		w := &responseWriter{ResponseWriter: res}
+		next.ServeHTTP(w, req)
+		gobot.Log(gobot.InfoLevel, "Request", gobot.Fields{
+			"method":   req.Method,
+			"url":      url,
+			"remote":   req.RemoteAddr,
+			"status":   w.Status(),
+			"bytes":    w.written,
+			"duration": time.Since(start).String(),
+		})
+	})
+}
+
+// Recover is a Middleware answering the requests whose handler panics with
+// 500 Internal Server Error, and logging the panic, instead of dropping the
+// connection.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		url := req.URL.String()
+		w := &responseWriter{ResponseWriter: res}
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			if r == http.ErrAbortHandler {
+				panic(r)
+			}
+			gobot.Log(gobot.ErrorLevel, "API handler panicked", gobot.Fields{
+				"method": req.Method,
+				"url":    url,
+				"panic":  fmt.Sprint(r),
+				"stack":  string(debug.Stack()),
+			})
+			if w.status == 0 {
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, req)
+	})
+}
+
+// Gzip is a Middleware compressing the responses to the clients accepting
+// gzip. Event streams are flushed as they are written, WebSockets are not
+// compressed.
+func Gzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if !headerContains(req.Header, "Accept-Encoding", "gzip") ||
+			headerContains(req.Header, "Upgrade", "websocket") {
+			next.ServeHTTP(res, req)
+			return
+		}
+		res.Header().Add("Vary", "Accept-Encoding")
+		w := &gzipResponseWriter{responseWriter: responseWriter{ResponseWriter: res}}
+		defer w.Close()
+		next.ServeHTTP(w, req)
+	})
+}
+
+// responseWriter records the status and size of a response, keeping the
+// Flusher, Hijacker and CloseNotifier of the writer it wraps for event
+// streams and WebSockets
+type responseWriter struct {
+	http.ResponseWriter
+	status  int
+	written int
+}
+
+// Status returns the status of the response, 200 if it was not written yet
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.written += n
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("Hijacking not supported by the server")
+	}
+	w.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+func (w *responseWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// gzipResponseWriter compresses a response, unless it is empty or already
+// encoded
+type gzipResponseWriter struct {
+	responseWriter
+	gz *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.status == 0 && code != http.StatusNoContent && code != http.StatusNotModified &&
+		code >= http.StatusOK && w.Header().Get("Content-Encoding") == "" {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.responseWriter.WriteHeader(code)
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz == nil {
+		return w.responseWriter.Write(b)
+	}
+	w.written += len(b)
+	return w.gz.Write(b)
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.responseWriter.Flush()
+}
+
+// Close writes the end of the compressed response
+func (w *gzipResponseWriter) Close() error {
+	if w.gz == nil {
+		return nil
+	}
+	return w.gz.Close()
+}
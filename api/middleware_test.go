@@ -0,0 +1,88 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestUse(t *testing.T) {
+	a := initTestAPI()
+	order := []string{}
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				order = append(order, name)
+				res.Header().Set("X-Middleware", name)
+				next.ServeHTTP(res, req)
+			})
+		}
+	}
+	a.Use(mark("outer"), mark("inner"))
+
+	request, _ := http.NewRequest("GET", "/api/robots", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 200)
+	gobot.Assert(t, order, []string{"outer", "inner"})
+	gobot.Assert(t, response.Header().Get("X-Middleware"), "inner")
+}
+
+func TestRecover(t *testing.T) {
+	a := initTestAPI()
+	a.Use(Recover)
+	a.Get("/panic", func(res http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	request, _ := http.NewRequest("GET", "/panic", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 500)
+	gobot.Assert(t, response.Body.String(), "Internal Server Error\n")
+}
+
+func TestLogging(t *testing.T) {
+	a := initTestAPI()
+	a.Use(Logging)
+	buf := &bytes.Buffer{}
+	log.SetOutput(buf)
+	defer log.SetOutput(NullReadWriteCloser{})
+
+	request, _ := http.NewRequest("GET", "/api/robots/UnknownRobot1/connections/Connection1/pins/1/digital", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	a.ServeHTTP(httptest.NewRecorder(), request)
+	gobot.Assert(t, strings.Contains(buf.String(),
+		"Request bytes=54 duration="), true)
+	gobot.Assert(t, strings.Contains(buf.String(),
+		" method=GET remote=10.0.0.1:1234 status=200 url=/api/robots/UnknownRobot1/connections/Connection1/pins/1/digital\n"), true)
+}
+
+func TestGzip(t *testing.T) {
+	a := initTestAPI()
+	a.Use(Gzip)
+
+	request, _ := http.NewRequest("GET", "/api/robots/Robot1", nil)
+	request.Header.Set("Accept-Encoding", "gzip, deflate")
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Header().Get("Content-Encoding"), "gzip")
+	gobot.Assert(t, response.Header().Get("Content-Type"), "application/json; charset=utf-8")
+	reader, err := gzip.NewReader(response.Body)
+	gobot.Assert(t, err, nil)
+	body, _ := ioutil.ReadAll(reader)
+	gobot.Assert(t, strings.HasPrefix(string(body), `{"robot":{"name":"Robot1"`), true)
+
+	request.Header.Del("Accept-Encoding")
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Header().Get("Content-Encoding"), "")
+	gobot.Assert(t, strings.HasPrefix(response.Body.String(), `{"robot":{"name":"Robot1"`), true)
+}
@@ -0,0 +1,224 @@
+package mqttapi
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/api"
+)
+
+var _ gobot.APIServer = (*Bridge)(nil)
+
+// Client is a connected MQTT client, such as the MqttAdaptor of the
+// platforms/mqtt package
+type Client interface {
+	// Publish publishes message on topic, returns false if it could not
+	Publish(topic string, message []byte) bool
+	// On calls f with the messages published on topic, returns false if it
+	// could not subscribe
+	On(topic string, f func(message []byte)) bool
+}
+
+// The default topic templates of a Bridge
+const (
+	DefaultEventTopic   = "gobot/{robot}/{device}/events/{event}"
+	DefaultCommandTopic = "gobot/{robot}/{device}/commands/{command}"
+)
+
+// Bridge publishes the events of the robots of a Gobot and their devices on
+// MQTT topics, and executes the commands published on command topics.
+//
+// Topics are written as templates in which {robot}, {device}, {event} and
+// {command} are replaced by their names. A placeholder without a value, the
+// device of a robot's events and commands or the robot of the Gobot's
+// commands, is left out along with its topic level, e.g. the command "Drive"
+// of the robot "rover" is "gobot/rover/commands/Drive" with the default
+// CommandTopic.
+//
+// The message of a command is a JSON object of its parameters, or empty for
+// none, and its result is published as JSON on the command topic followed by
+// "/result", as {"result": ...} or {"error": "..."}. The message of an event
+// is its data as JSON, errors being published as their message.
+type Bridge struct {
+	// EventTopic is the template of the topics on which events are published
+	EventTopic string
+	// CommandTopic is the template of the topics subscribed to for commands
+	CommandTopic string
+	// Events are patterns of path.Match of the names of the events
+	// published, e.g. "button_*", all events if empty
+	Events []string
+
+	gobot      *gobot.Gobot
+	client     Client
+	generation int
+	running    bool
+	stops      []func()
+	mutex      sync.Mutex
+}
+
+// NewBridge returns a new Bridge of g over client, with the default topics.
+// g may be nil if the bridge is added to a Gobot with AddAPIServer.
+func NewBridge(g *gobot.Gobot, client Client) *Bridge {
+	return &Bridge{
+		EventTopic:   DefaultEventTopic,
+		CommandTopic: DefaultCommandTopic,
+		gobot:        g,
+		client:       client,
+	}
+}
+
+// Attach sets the Gobot exposed by the bridge.
+func (b *Bridge) Attach(g *gobot.Gobot) {
+	b.gobot = g
+}
+
+// Start subscribes to the command topics of the Gobot, its robots and their
+// devices, and starts publishing their events. The robots, devices and
+// commands added afterwards are bridged once it is started again.
+func (b *Bridge) Start() error {
+	if err := api.CheckEventFilters(b.Events); err != nil {
+		return err
+	}
+	b.mutex.Lock()
+	b.generation++
+	b.running = true
+	generation := b.generation
+	b.mutex.Unlock()
+
+	gobot.Log(gobot.InfoLevel, "Initializing MQTT bridge", nil)
+	if err := b.subscribe(generation, b.gobot.Commander, "", ""); err != nil {
+		return err
+	}
+	var errs []error
+	b.gobot.Robots().Each(func(robot *gobot.Robot) {
+		if err := b.subscribe(generation, robot.Commander, robot.Name, ""); err != nil {
+			errs = append(errs, err)
+		}
+		b.publish(generation, robot, robot.Name, "")
+		robot.Devices().Each(func(device gobot.Device) {
+			if commander, ok := device.(gobot.Commander); ok {
+				if err := b.subscribe(generation, commander, robot.Name, device.Name()); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			if eventer, ok := device.(gobot.Eventer); ok {
+				b.publish(generation, eventer, robot.Name, device.Name())
+			}
+		})
+	})
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// Stop stops executing commands and publishing events.
+func (b *Bridge) Stop() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.running = false
+	for _, stop := range b.stops {
+		stop()
+	}
+	b.stops = nil
+	return nil
+}
+
+// active returns true if the bridge is running since it was started for
+// generation, as a client cannot unsubscribe from the command topics
+func (b *Bridge) active(generation int) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.running && b.generation == generation
+}
+
+// subscribe subscribes to the topics of the commands of c, of the device of
+// robot if any
+func (b *Bridge) subscribe(generation int, c gobot.Commander, robot string, device string) error {
+	names := []string{}
+	for name := range c.Commands() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		name := name
+		topic := Topic(b.CommandTopic, map[string]string{"robot": robot, "device": device, "command": name})
+		ok := b.client.On(topic, func(message []byte) {
+			if !b.active(generation) {
+				return
+			}
+			result, _ := json.Marshal(execute(c, name, message))
+			b.client.Publish(topic+"/result", result)
+		})
+		if !ok {
+			return errors.New("Could not subscribe to " + topic + ", is the MQTT client connected?")
+		}
+	}
+	return nil
+}
+
+// execute executes the command name of c with the parameters of message and
+// returns the body of its result
+func execute(c gobot.Commander, name string, message []byte) map[string]interface{} {
+	params := make(map[string]interface{})
+	if len(strings.TrimSpace(string(message))) > 0 {
+		if err := json.Unmarshal(message, &params); err != nil {
+			return map[string]interface{}{"error": err.Error()}
+		}
+	}
+	command := c.Command(name)
+	if command == nil {
+		return map[string]interface{}{"error": "Unknown Command"}
+	}
+	result := command(params)
+	if err, ok := result.(error); ok {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return map[string]interface{}{"result": result}
+}
+
+// publish publishes the events of e, of the device of robot if any, until
+// the bridge is stopped
+func (b *Bridge) publish(generation int, e gobot.Eventer, robot string, device string) {
+	stop, err := e.OnPattern("*", func(name string, data interface{}) {
+		if !b.active(generation) || !api.MatchEvent(b.Events, name) {
+			return
+		}
+		if err, ok := data.(error); ok {
+			data = err.Error()
+		}
+		message, err := json.Marshal(data)
+		if err != nil {
+			gobot.Log(gobot.ErrorLevel, err.Error(), gobot.Fields{"event": name})
+			return
+		}
+		b.client.Publish(Topic(b.EventTopic, map[string]string{"robot": robot, "device": device, "event": name}), message)
+	})
+	if err != nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.stops = append(b.stops, stop)
+}
+
+// Topic returns the topic of template with its placeholders replaced by
+// values, leaving out the levels of those without a value.
+func Topic(template string, values map[string]string) string {
+	levels := []string{}
+	for _, level := range strings.Split(template, "/") {
+		if strings.HasPrefix(level, "{") && strings.Index(level, "}") == len(level)-1 &&
+			values[level[1:len(level)-1]] == "" {
+			continue
+		}
+		for name, value := range values {
+			level = strings.Replace(level, "{"+name+"}", value, -1)
+		}
+		levels = append(levels, level)
+	}
+	return strings.Join(levels, "/")
+}
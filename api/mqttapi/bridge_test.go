@@ -0,0 +1,152 @@
+package mqttapi
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+type testDriver struct {
+	name string
+	gobot.Commander
+	gobot.Eventer
+}
+
+func (t *testDriver) Start() (errs []error)        { return }
+func (t *testDriver) Halt() (errs []error)         { return }
+func (t *testDriver) Name() string                 { return t.name }
+func (t *testDriver) Connection() gobot.Connection { return nil }
+
+// testClient is a Client recording the messages published and delivering
+// those sent with send to the subscribers of their topic
+type testClient struct {
+	connected   bool
+	subscribers map[string][]func([]byte)
+	published   map[string][]string
+	mutex       sync.Mutex
+}
+
+func newTestClient() *testClient {
+	return &testClient{
+		connected:   true,
+		subscribers: make(map[string][]func([]byte)),
+		published:   make(map[string][]string),
+	}
+}
+
+func (t *testClient) Publish(topic string, message []byte) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.published[topic] = append(t.published[topic], string(message))
+	return t.connected
+}
+
+func (t *testClient) On(topic string, f func([]byte)) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.subscribers[topic] = append(t.subscribers[topic], f)
+	return t.connected
+}
+
+func (t *testClient) send(topic string, message string) {
+	t.mutex.Lock()
+	subscribers := t.subscribers[topic]
+	t.mutex.Unlock()
+	for _, f := range subscribers {
+		f([]byte(message))
+	}
+}
+
+func (t *testClient) messages(topic string) []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]string{}, t.published[topic]...)
+}
+
+// wait returns the messages published on topic once there are n of them,
+// or after a second
+func (t *testClient) wait(topic string, n int) []string {
+	deadline := time.Now().Add(time.Second)
+	for len(t.messages(topic)) < n && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	return t.messages(topic)
+}
+
+func initTestBridge() (*Bridge, *testClient, *testDriver) {
+	g := gobot.NewGobot()
+	g.AddCommand("Ping", func(params map[string]interface{}) interface{} { return "pong" })
+	led := &testDriver{name: "led", Commander: gobot.NewCommander(), Eventer: gobot.NewEventer()}
+	led.AddCommand("Toggle", func(params map[string]interface{}) interface{} { return params["state"] })
+	led.AddCommand("Fail", func(params map[string]interface{}) interface{} { return errors.New("failed") })
+	led.AddEvent("toggled")
+	led.AddEvent("error")
+	robot := gobot.NewRobot("bot", []gobot.Device{led})
+	robot.AddCommand("Drive", func(params map[string]interface{}) interface{} { return params["speed"] })
+	g.AddRobot(robot)
+	client := newTestClient()
+	return NewBridge(g, client), client, led
+}
+
+func TestTopic(t *testing.T) {
+	values := map[string]string{"robot": "bot", "device": "", "command": "Drive"}
+	gobot.Assert(t, Topic(DefaultCommandTopic, values), "gobot/bot/commands/Drive")
+	values["device"] = "led"
+	gobot.Assert(t, Topic(DefaultCommandTopic, values), "gobot/bot/led/commands/Drive")
+	gobot.Assert(t, Topic("site/{robot}-{device}/cmd/{command}", values), "site/bot-led/cmd/Drive")
+	gobot.Assert(t, Topic(DefaultCommandTopic, map[string]string{"command": "Ping"}), "gobot/commands/Ping")
+}
+
+func TestBridgeCommands(t *testing.T) {
+	b, client, _ := initTestBridge()
+	gobot.Assert(t, b.Start(), nil)
+
+	client.send("gobot/commands/Ping", "")
+	gobot.Assert(t, client.messages("gobot/commands/Ping/result"), []string{`{"result":"pong"}`})
+
+	client.send("gobot/bot/commands/Drive", `{"speed": 5}`)
+	gobot.Assert(t, client.messages("gobot/bot/commands/Drive/result"), []string{`{"result":5}`})
+
+	client.send("gobot/bot/led/commands/Toggle", `{"state": true}`)
+	client.send("gobot/bot/led/commands/Toggle", `not json`)
+	client.send("gobot/bot/led/commands/Fail", ``)
+	gobot.Assert(t, client.messages("gobot/bot/led/commands/Toggle/result"), []string{
+		`{"result":true}`,
+		`{"error":"invalid character 'o' in literal null (expecting 'u')"}`,
+	})
+	gobot.Assert(t, client.messages("gobot/bot/led/commands/Fail/result"), []string{`{"error":"failed"}`})
+
+	// a stopped bridge ignores commands, and starting it again does not
+	// execute them twice
+	gobot.Assert(t, b.Stop(), nil)
+	client.send("gobot/commands/Ping", "")
+	gobot.Assert(t, len(client.messages("gobot/commands/Ping/result")), 1)
+	b.Start()
+	client.send("gobot/commands/Ping", "")
+	gobot.Assert(t, len(client.messages("gobot/commands/Ping/result")), 2)
+}
+
+func TestBridgeEvents(t *testing.T) {
+	b, client, led := initTestBridge()
+	b.EventTopic = "site/{robot}/{device}/{event}"
+	b.Events = []string{"togg*", "error"}
+	gobot.Assert(t, b.Start(), nil)
+
+	gobot.Publish(led.Event("toggled"), map[string]interface{}{"state": true})
+	gobot.Publish(led.Event("error"), errors.New("burnt out"))
+	gobot.Assert(t, client.wait("site/bot/led/toggled", 1), []string{`{"state":true}`})
+	gobot.Assert(t, client.wait("site/bot/led/error", 1), []string{`"burnt out"`})
+}
+
+func TestBridgeStartErrors(t *testing.T) {
+	b, client, _ := initTestBridge()
+	client.connected = false
+	gobot.Assert(t, b.Start().Error(), "Could not subscribe to gobot/commands/Ping, is the MQTT client connected?")
+
+	b, _, _ = initTestBridge()
+	b.Events = []string{"["}
+	gobot.Assert(t, b.Start().Error(), "syntax error in pattern")
+}
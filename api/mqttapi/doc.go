@@ -0,0 +1,24 @@
+/*
+Package mqttapi provides a bridge between the robots of a Gobot and MQTT, so
+they integrate with MQTT based home and industrial automation: their events
+are published on MQTT topics, and the commands published on command topics
+are executed, see Bridge.
+
+Example:
+
+	gbot := gobot.NewGobot()
+	client := mqtt.NewMqttAdaptor("broker", "tcp://localhost:1883", "gobot")
+	gbot.AddRobot(gobot.NewRobot("rover", []gobot.Connection{client}, ...))
+	bridge := mqttapi.NewBridge(nil, client)
+	bridge.Events = []string{"button_*"}
+	gbot.AddAPIServer(bridge)
+	gbot.Start()
+
+The robot "rover" then publishes its events on gobot/rover/events/:event and
+the events of its devices on gobot/rover/:device/events/:event, and executes
+the commands published on gobot/rover/commands/:command and
+gobot/rover/:device/commands/:command, e.g.:
+
+	mosquitto_pub -t gobot/rover/commands/Drive -m '{"speed": 5}'
+*/
+package mqttapi
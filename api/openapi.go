@@ -0,0 +1,176 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// openAPI returns the OpenAPI route handler.
+// Writes JSON with the OpenAPI document of the api
+func (a *API) openAPI(res http.ResponseWriter, req *http.Request) {
+	a.writeJSON(a.OpenAPI(), res)
+}
+
+// OpenAPI returns an OpenAPI 3 document describing the routes of the api for
+// the robots, devices and commands of its Gobot, with the parameters of the
+// commands added with AddCommandWithParams, so clients in other languages
+// can be generated from it.
+func (a *API) OpenAPI() map[string]interface{} {
+	paths := map[string]interface{}{
+		"/api/": getOperation("mcp", "The Gobot, its robots and commands"),
+		"/api/robots": withParameters(getOperation("robots", "The robots"),
+			map[string]interface{}{
+				"name":        "tag",
+				"in":          "query",
+				"description": "Only the robots with the tags, e.g. zone=lab",
+				"schema":      map[string]interface{}{"type": "string"},
+			}),
+		"/api/robots/{robot}": withParameters(getOperation("robot", "A robot"),
+			pathParameter("robot")),
+		"/api/robots/{robot}/devices": withParameters(getOperation("robotDevices", "The devices of a robot"),
+			pathParameter("robot")),
+		"/api/robots/{robot}/devices/{device}": withParameters(getOperation("robotDevice", "A device of a robot"),
+			pathParameter("robot"), pathParameter("device")),
+		"/api/robots/{robot}/connections": withParameters(getOperation("robotConnections", "The connections of a robot"),
+			pathParameter("robot")),
+		"/api/robots/{robot}/connections/{connection}": withParameters(getOperation("robotConnection", "A connection of a robot"),
+			pathParameter("robot"), pathParameter("connection")),
+		"/api/robots/{robot}/health": withParameters(getOperation("robotHealth", "The health of a robot"),
+			pathParameter("robot")),
+	}
+
+	addCommands(paths, a.gobot.Commander, "/api/commands/", "")
+	a.gobot.Robots().Each(func(robot *gobot.Robot) {
+		addCommands(paths, robot.Commander, "/api/robots/"+robot.Name+"/commands/", robot.Name+".")
+		robot.Devices().Each(func(device gobot.Device) {
+			if commander, ok := device.(gobot.Commander); ok {
+				addCommands(paths, commander,
+					"/api/robots/"+robot.Name+"/devices/"+device.Name()+"/commands/",
+					robot.Name+"."+device.Name()+".")
+			}
+		})
+	})
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Gobot API",
+			"version": gobot.Version(),
+		},
+		"paths": paths,
+	}
+}
+
+// addCommands adds the paths executing the commands of c under prefix to
+// paths, with operation ids prefixed by id
+func addCommands(paths map[string]interface{}, c gobot.Commander, prefix string, id string) {
+	names := []string{}
+	for name := range c.Commands() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		schema := map[string]interface{}{"type": "object"}
+		if params := c.Params(name); params != nil {
+			properties := map[string]interface{}{}
+			required := []string{}
+			for _, param := range params {
+				properties[param.Name] = paramSchema(param)
+				if param.Required {
+					required = append(required, param.Name)
+				}
+			}
+			schema["properties"] = properties
+			if len(required) > 0 {
+				schema["required"] = required
+			}
+		}
+		paths[prefix+name] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"operationId": id + name,
+				"summary":     "Executes the " + name + " command",
+				"parameters": []interface{}{map[string]interface{}{
+					"name":        "async",
+					"in":          "query",
+					"description": "Executes the command in the background and returns its job",
+					"schema":      map[string]interface{}{"type": "boolean"},
+				}},
+				"requestBody": map[string]interface{}{
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": schema},
+					},
+				},
+				"responses": map[string]interface{}{
+					"200": jsonResponse("The result of the command", map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"result": map[string]interface{}{},
+							"error":  map[string]interface{}{"type": "string"},
+							"job":    map[string]interface{}{"type": "object"},
+						},
+					}),
+				},
+			},
+		}
+	}
+}
+
+// paramSchema returns the JSON schema of a command parameter
+func paramSchema(param gobot.Param) map[string]interface{} {
+	types := map[gobot.ParamType]string{
+		gobot.ParamString: "string",
+		gobot.ParamInt:    "integer",
+		gobot.ParamFloat:  "number",
+		gobot.ParamBool:   "boolean",
+	}
+	schema := map[string]interface{}{"type": types[param.Type]}
+	if (param.Type == gobot.ParamInt || param.Type == gobot.ParamFloat) && param.Min < param.Max {
+		schema["minimum"] = param.Min
+		schema["maximum"] = param.Max
+	}
+	if param.Default != nil {
+		schema["default"] = param.Default
+	}
+	return schema
+}
+
+// getOperation returns a path with a GET operation answering JSON
+func getOperation(id string, summary string) map[string]interface{} {
+	return map[string]interface{}{
+		"get": map[string]interface{}{
+			"operationId": id,
+			"summary":     summary,
+			"responses": map[string]interface{}{
+				"200": jsonResponse(summary, map[string]interface{}{"type": "object"}),
+			},
+		},
+	}
+}
+
+// withParameters adds parameters to the operations of path
+func withParameters(path map[string]interface{}, parameters ...interface{}) map[string]interface{} {
+	path["parameters"] = parameters
+	return path
+}
+
+// pathParameter returns a required path parameter given a name
+func pathParameter(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":     name,
+		"in":       "path",
+		"required": true,
+		"schema":   map[string]interface{}{"type": "string"},
+	}
+}
+
+// jsonResponse returns a response with a JSON body of schema
+func jsonResponse(description string, schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestOpenAPI(t *testing.T) {
+	a := initTestAPI()
+	a.gobot.Robot("Robot1").AddCommandWithParams("Drive", []gobot.Param{
+		{Name: "speed", Type: gobot.ParamInt, Required: true, Min: 0, Max: 255},
+		{Name: "forward", Type: gobot.ParamBool, Default: true},
+	}, func(params map[string]interface{}) interface{} { return nil })
+
+	request, _ := http.NewRequest("GET", "/api/openapi.json", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	var doc map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&doc)
+	gobot.Assert(t, doc["openapi"], "3.0.3")
+	paths := doc["paths"].(map[string]interface{})
+	gobot.Refute(t, paths["/api/robots/{robot}/devices/{device}"], nil)
+	gobot.Refute(t, paths["/api/commands/TestFunction"], nil)
+	gobot.Refute(t, paths["/api/robots/Robot2/devices/Device1/commands/TestDriverCommand"], nil)
+
+	drive := paths["/api/robots/Robot1/commands/Drive"].(map[string]interface{})["post"].(map[string]interface{})
+	gobot.Assert(t, drive["operationId"], "Robot1.Drive")
+	schema := drive["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"]
+	gobot.Assert(t, schema, map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"speed"},
+		"properties": map[string]interface{}{
+			"speed":   map[string]interface{}{"type": "integer", "minimum": 0.0, "maximum": 255.0},
+			"forward": map[string]interface{}{"type": "boolean", "default": true},
+		},
+	})
+}
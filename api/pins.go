@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/gpio"
+)
+
+// pinRoute is the route reading and writing a pin of a connection, where
+// mode is digital, analog or servo
+const pinRoute = "/api/robots/:robot/connections/:connection/pins/:pin/:mode"
+
+// pinParams are the parameters of the writes to a pin in each mode
+var pinParams = map[string][]gobot.Param{
+	"digital": {{Name: "value", Type: gobot.ParamInt, Required: true, Min: 0, Max: 1}},
+	"analog":  {{Name: "value", Type: gobot.ParamInt, Required: true, Min: 0, Max: 255}},
+	"servo":   {{Name: "value", Type: gobot.ParamInt, Required: true, Min: 0, Max: 180}},
+}
+
+// readPin returns the pin read route handler.
+// Writes JSON with the value read from the pin by the adaptor of the
+// connection, with DigitalRead or AnalogRead.
+func (a *API) readPin(res http.ResponseWriter, req *http.Request) {
+	connection, err := a.connectionFor(req.URL.Query().Get(":robot"), req.URL.Query().Get(":connection"))
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	pin := req.URL.Query().Get(":pin")
+	var val int
+	switch mode := req.URL.Query().Get(":mode"); mode {
+	case "digital":
+		reader, ok := connection.(gpio.DigitalReader)
+		if !ok {
+			err = errors.New("Connection " + connection.Name() + " can not read digital pins")
+			break
+		}
+		val, err = reader.DigitalRead(pin)
+	case "analog":
+		reader, ok := connection.(gpio.AnalogReader)
+		if !ok {
+			err = errors.New("Connection " + connection.Name() + " can not read analog pins")
+			break
+		}
+		val, err = reader.AnalogRead(pin)
+	default:
+		err = errors.New("No pin reads in the mode " + mode)
+	}
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	a.writeJSON(map[string]interface{}{"value": val}, res)
+}
+
+// writePin returns the pin write route handler.
+// Writes the value of the request to the pin with the adaptor of the
+// connection, with DigitalWrite, PwmWrite for analog or ServoWrite, and
+// writes JSON with the value written.
+func (a *API) writePin(res http.ResponseWriter, req *http.Request) {
+	connection, err := a.connectionFor(req.URL.Query().Get(":robot"), req.URL.Query().Get(":connection"))
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	mode := req.URL.Query().Get(":mode")
+	params, ok := pinParams[mode]
+	if !ok {
+		a.writeJSON(map[string]interface{}{"error": "No pin writes in the mode " + mode}, res)
+		return
+	}
+	body, err := a.commandParams(req)
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	if value := req.URL.Query().Get("value"); value != "" {
+		body["value"] = value
+	}
+	values, err := gobot.ValidateParams(params, body)
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+
+	pin, val := req.URL.Query().Get(":pin"), byte(values["value"].(int))
+	switch mode {
+	case "digital":
+		if writer, ok := connection.(gpio.DigitalWriter); ok {
+			err = writer.DigitalWrite(pin, val)
+		} else {
+			err = errors.New("Connection " + connection.Name() + " can not write digital pins")
+		}
+	case "analog":
+		if writer, ok := connection.(gpio.PwmWriter); ok {
+			err = writer.PwmWrite(pin, val)
+		} else {
+			err = errors.New("Connection " + connection.Name() + " can not write analog pins")
+		}
+	case "servo":
+		if writer, ok := connection.(gpio.ServoWriter); ok {
+			err = writer.ServoWrite(pin, val)
+		} else {
+			err = errors.New("Connection " + connection.Name() + " can not write servo pins")
+		}
+	}
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	a.writeJSON(map[string]interface{}{"value": val}, res)
+}
+
+// connectionFor returns the connection of robot given a name
+func (a *API) connectionFor(robot string, name string) (connection gobot.Connection, err error) {
+	if r := a.gobot.Robot(robot); r == nil {
+		err = errors.New("No Robot found with the name " + robot)
+	} else if connection = r.Connection(name); connection == nil {
+		err = errors.New("No Connection found with the name " + name)
+	}
+	return
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+type testPinAdaptor struct {
+	*testAdaptor
+	writes map[string]byte
+}
+
+func (t *testPinAdaptor) DigitalRead(pin string) (int, error) { return 1, nil }
+func (t *testPinAdaptor) AnalogRead(pin string) (int, error) {
+	if pin == "A9" {
+		return 0, errors.New("no such pin")
+	}
+	return 512, nil
+}
+func (t *testPinAdaptor) DigitalWrite(pin string, val byte) error {
+	t.writes["digital "+pin] = val
+	return nil
+}
+func (t *testPinAdaptor) PwmWrite(pin string, val byte) error {
+	t.writes["pwm "+pin] = val
+	return nil
+}
+
+func TestPins(t *testing.T) {
+	a := initTestAPI()
+	adaptor := &testPinAdaptor{testAdaptor: newTestAdaptor("board", "/dev/null"), writes: map[string]byte{}}
+	a.gobot.AddRobot(gobot.NewRobot("pins", []gobot.Connection{adaptor}))
+
+	_, body := requestV2(a, "GET", "/api/robots/pins/connections/board/pins/13/digital", "")
+	gobot.Assert(t, body, map[string]interface{}{"value": 1.0})
+	_, body = requestV2(a, "GET", "/api/robots/pins/connections/board/pins/A0/analog", "")
+	gobot.Assert(t, body, map[string]interface{}{"value": 512.0})
+	_, body = requestV2(a, "GET", "/api/robots/pins/connections/board/pins/A9/analog", "")
+	gobot.Assert(t, body, map[string]interface{}{"error": "no such pin"})
+
+	_, body = requestV2(a, "POST", "/api/robots/pins/connections/board/pins/13/digital", `{"value": 1}`)
+	gobot.Assert(t, body, map[string]interface{}{"value": 1.0})
+	_, body = requestV2(a, "POST", "/api/robots/pins/connections/board/pins/3/analog?value=128", "")
+	gobot.Assert(t, body, map[string]interface{}{"value": 128.0})
+	gobot.Assert(t, adaptor.writes, map[string]byte{"digital 13": 1, "pwm 3": 128})
+
+	_, body = requestV2(a, "POST", "/api/robots/pins/connections/board/pins/13/digital", `{"value": 2}`)
+	gobot.Assert(t, body, map[string]interface{}{"error": `Parameter "value": 2 is out of range 0-1`})
+	_, body = requestV2(a, "POST", "/api/robots/pins/connections/board/pins/9/servo", `{"value": 90}`)
+	gobot.Assert(t, body, map[string]interface{}{"error": "Connection board can not write servo pins"})
+	_, body = requestV2(a, "GET", "/api/robots/pins/connections/board/pins/9/servo", "")
+	gobot.Assert(t, body, map[string]interface{}{"error": "No pin reads in the mode servo"})
+	_, body = requestV2(a, "GET", "/api/robots/Robot1/connections/Connection1/pins/13/digital", "")
+	gobot.Assert(t, body, map[string]interface{}{"error": "Connection Connection1 can not read digital pins"})
+	_, body = requestV2(a, "GET", "/api/robots/pins/connections/UnknownConnection1/pins/13/digital", "")
+	gobot.Assert(t, body, map[string]interface{}{"error": "No Connection found with the name UnknownConnection1"})
+	_, body = requestV2(a, "GET", "/api/robots/UnknownRobot1/connections/board/pins/13/digital", "")
+	gobot.Assert(t, body, map[string]interface{}{"error": "No Robot found with the name UnknownRobot1"})
+}
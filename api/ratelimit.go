@@ -0,0 +1,146 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimitPrune is how often the buckets of the clients which stopped
+// making requests are forgotten
+var rateLimitPrune = time.Minute
+
+// RateLimit limits the rate of the requests of each client with a token
+// bucket, see API.UseRateLimit.
+type RateLimit struct {
+	// Rate is the number of requests per second a client may make
+	Rate float64
+	// Burst is the number of requests a client may make at once, Rate
+	// rounded up if 0
+	Burst int
+	// Key returns the key of the client of a request, whose requests share a
+	// bucket, KeyByIP if nil
+	Key func(req *http.Request) string
+	// Routes are the paths which are limited, as path.Match patterns
+	// optionally prefixed with a method, e.g. "POST /api/robots/*/commands/*".
+	// Every route is limited if Routes is empty.
+	Routes []string
+
+	buckets map[string]*tokenBucket
+	pruned  time.Time
+	mutex   sync.Mutex
+}
+
+// tokenBucket holds the requests a client may still make
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// UseRateLimit makes the api answer the requests to the routes of l beyond
+// the rate allowed to their client with 429 Too Many Requests, and a
+// Retry-After header. Several limits can be used, e.g. a strict one for
+// commands and a loose one for every route. A limit used before UseAuth,
+// UseJWT or BasicAuth also limits the requests failing authentication, by
+// IP address as their clients are not authenticated yet.
+func (a *API) UseRateLimit(l *RateLimit) {
+	a.AddHandler(func(res http.ResponseWriter, req *http.Request) {
+		if !matchRoute(l.Routes, req) {
+			return
+		}
+		if ok, wait := l.allow(l.key(req), time.Now()); !ok {
+			if wait > 0 {
+				res.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			}
+			http.Error(res, "Too Many Requests", http.StatusTooManyRequests)
+		}
+	})
+}
+
+// KeyByIP returns the IP address of the client of req. Behind a proxy every
+// request comes from the proxy, use a Key reading the header the proxy sets
+// instead.
+func KeyByIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// KeyByToken returns the credentials the client of req is authenticated
+// with, the name of the identity authenticated by UseAuth or BasicAuth, or
+// else the bearer token validated by UseJWT, so each API token gets its own
+// bucket. Returns the IP address of the client of the requests which are not
+// authenticated, so made up credentials do not get fresh buckets. Requests
+// are only authenticated by the handlers used before, use a limit keyed by
+// token after UseAuth, BasicAuth or UseJWT.
+func KeyByToken(req *http.Request) string {
+	if id := RequestIdentity(req); id != nil {
+		return "Identity " + id.Name
+	}
+	if state, ok := req.Context().Value(authKey{}).(*authState); ok && state.token != "" {
+		return "Bearer " + state.token
+	}
+	return KeyByIP(req)
+}
+
+// key returns the key of the client of req
+func (l *RateLimit) key(req *http.Request) string {
+	if l.Key != nil {
+		return l.Key(req)
+	}
+	return KeyByIP(req)
+}
+
+// burst returns the size of the buckets
+func (l *RateLimit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Ceil(l.Rate)
+}
+
+// allow takes a token from the bucket of key at now. Returns false and how
+// long until the next token if the bucket is empty, 0 if it is never
+// refilled.
+func (l *RateLimit) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.buckets == nil {
+		l.buckets = make(map[string]*tokenBucket)
+		l.pruned = now
+	}
+	if now.Sub(l.pruned) >= rateLimitPrune {
+		for k, b := range l.buckets {
+			if l.refill(b, now) >= l.burst() {
+				delete(l.buckets, k)
+			}
+		}
+		l.pruned = now
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst(), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.last = now
+	if b.tokens < 1 {
+		if l.Rate <= 0 {
+			return false, 0
+		}
+		return false, time.Duration((1 - b.tokens) / l.Rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// refill returns the tokens of b at now
+func (l *RateLimit) refill(b *tokenBucket, now time.Time) float64 {
+	tokens := b.tokens + now.Sub(b.last).Seconds()*l.Rate
+	return math.Min(tokens, l.burst())
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestRateLimitAllow(t *testing.T) {
+	l := &RateLimit{Rate: 2, Burst: 3}
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		ok, _ := l.allow("client", now)
+		gobot.Assert(t, ok, true)
+	}
+	ok, wait := l.allow("client", now)
+	gobot.Assert(t, ok, false)
+	gobot.Assert(t, wait, 500*time.Millisecond)
+	// other clients have their own bucket
+	ok, _ = l.allow("other", now)
+	gobot.Assert(t, ok, true)
+
+	ok, _ = l.allow("client", now.Add(500*time.Millisecond))
+	gobot.Assert(t, ok, true)
+	ok, _ = l.allow("client", now.Add(500*time.Millisecond))
+	gobot.Assert(t, ok, false)
+	// the bucket holds Burst tokens at most
+	for i := 0; i < 3; i++ {
+		ok, _ = l.allow("client", now.Add(time.Hour))
+		gobot.Assert(t, ok, true)
+	}
+	ok, _ = l.allow("client", now.Add(time.Hour))
+	gobot.Assert(t, ok, false)
+
+	// the buckets of idle clients are forgotten
+	l.allow("client", now.Add(2*time.Hour))
+	gobot.Assert(t, len(l.buckets), 1)
+
+	l = &RateLimit{Rate: 0.5}
+	ok, _ = l.allow("client", now)
+	gobot.Assert(t, ok, true)
+	ok, wait = l.allow("client", now)
+	gobot.Assert(t, ok, false)
+	gobot.Assert(t, wait, 2*time.Second)
+}
+
+func TestUseRateLimit(t *testing.T) {
+	a := initTestAPI()
+	a.UseJWT(&JWT{Secret: []byte("secret"), Routes: []string{"POST /api/robots/*/commands/*"}})
+	a.UseRateLimit(&RateLimit{
+		Rate:   0.1,
+		Burst:  1,
+		Key:    KeyByToken,
+		Routes: []string{"POST /api/robots/*/commands/*"},
+	})
+	dashboard := signJWT("HS256", "", Claims{"sub": "dashboard"}, hs256("secret"))
+	operator := signJWT("HS256", "", Claims{"sub": "operator"}, hs256("secret"))
+
+	command := func(token string) *httptest.ResponseRecorder {
+		request, _ := http.NewRequest("POST", "/api/robots/Robot1/commands/robotTestFunction",
+			bytes.NewBufferString(`{"message":"Beep Boop", "robot":"Robot1"}`))
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.RemoteAddr = "192.168.1.2:40000"
+		response := httptest.NewRecorder()
+		a.ServeHTTP(response, request)
+		return response
+	}
+	gobot.Assert(t, command(dashboard).Code, 200)
+	response := command(dashboard)
+	gobot.Assert(t, response.Code, http.StatusTooManyRequests)
+	gobot.Assert(t, response.Header().Get("Retry-After"), "10")
+	gobot.Assert(t, response.Body.String(), "Too Many Requests\n")
+	gobot.Assert(t, command(operator).Code, 200)
+
+	// other routes are not limited
+	for i := 0; i < 3; i++ {
+		request, _ := http.NewRequest("GET", "/api/robots/Robot1", nil)
+		response := httptest.NewRecorder()
+		a.ServeHTTP(response, request)
+		gobot.Assert(t, response.Code, 200)
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	request, _ := http.NewRequest("GET", "/api/?access_token=abc", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	gobot.Assert(t, KeyByIP(request), "10.0.0.1")
+	request, _ = http.NewRequest("GET", "/api/", nil)
+	request.RemoteAddr = "[::1]:1234"
+	gobot.Assert(t, KeyByToken(request), "::1")
+
+	// credentials only key the requests once authenticated
+	var keys []string
+	key := func(req *http.Request) string {
+		keys = append(keys, KeyByToken(req))
+		return keys[len(keys)-1]
+	}
+	a := initTestAPI()
+	a.UseRateLimit(&RateLimit{Rate: 10, Key: key})
+	a.AddHandler(BasicAuth("admin", "password"))
+	a.UseRateLimit(&RateLimit{Rate: 10, Key: key})
+	request, _ = http.NewRequest("GET", "/api/", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.SetBasicAuth("admin", "password")
+	a.ServeHTTP(httptest.NewRecorder(), request)
+	gobot.Assert(t, keys, []string{"10.0.0.1", "Identity admin"})
+
+	keys = nil
+	a = initTestAPI()
+	a.UseJWT(&JWT{Secret: []byte("secret")})
+	a.UseRateLimit(&RateLimit{Rate: 10, Key: key})
+	token := signJWT("HS256", "", Claims{"sub": "eve"}, hs256("secret"))
+	request, _ = http.NewRequest("GET", "/api/?access_token="+token, nil)
+	a.ServeHTTP(httptest.NewRecorder(), request)
+	gobot.Assert(t, keys, []string{"Bearer " + token})
+
+	// made up tokens are keyed by IP address
+	keys = nil
+	request, _ = http.NewRequest("GET", "/api/", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("Authorization", "Bearer made.up.token")
+	a = initTestAPI()
+	a.UseRateLimit(&RateLimit{Rate: 10, Key: key})
+	a.ServeHTTP(httptest.NewRecorder(), request)
+	gobot.Assert(t, keys, []string{"10.0.0.1"})
+}
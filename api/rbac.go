@@ -0,0 +1,277 @@
+package api
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// Role allows executing some commands, see RBAC.
+type Role struct {
+	// Robots, Devices and Commands are path.Match patterns of the names of
+	// the robots, devices and commands allowed. Every name is allowed if
+	// empty. A role restricted to some devices does not allow the commands
+	// of the robots, nor one restricted to some robots the commands of the
+	// api.
+	Robots   []string
+	Devices  []string
+	Commands []string
+}
+
+// RBAC authorizes the commands requested to the api by the roles of their
+// client, see API.UseRBAC.
+type RBAC struct {
+	// Roles are the roles by name
+	Roles map[string]*Role
+	// Bindings are the names of the roles of each identity
+	Bindings map[string][]string
+	// RolesClaim is the claim of the JWT of a request holding the names of
+	// more roles of its client, an array or a space separated string
+	RolesClaim string
+	// Identity returns the identity of the client of a request. When nil it
+	// is the name of the identity authenticated by UseAuth, or else the
+	// subject of its JWT if the api uses UseJWT, or else the username of its
+	// basic authorization.
+	Identity func(req *http.Request) string
+}
+
+// rbacTarget is what a request executes, a command of the api, a robot, or
+// some devices
+type rbacTarget struct {
+	robot   string
+	devices []string
+	command string
+}
+
+// pinCommands are the commands the pin routes are authorized as, by method
+// and mode, so the roles allowing the commands of a DirectPinDriver also
+// allow the pins of the connections
+var pinCommands = map[string]string{
+	"GET digital":  "DigitalRead",
+	"GET analog":   "AnalogRead",
+	"POST digital": "DigitalWrite",
+	"POST analog":  "PwmWrite",
+	"POST servo":   "ServoWrite",
+}
+
+// UseRBAC makes the api answer the requests executing commands which none
+// of the roles of their client allow with 403 Forbidden. The commands of the
+// api, robots, devices and groups are authorized, along with the pin routes,
+// as the DigitalRead, AnalogRead, DigitalWrite, PwmWrite and ServoWrite
+// commands of the connection, enabling and disabling devices, as their
+// "enable" and "disable" commands, and starting and halting groups, as the
+// "start" and "halt" commands of their devices, shutting down, as the
+// "shutdown" command of the api, and adding and removing webhooks, as the
+// "webhooks" command of the api. Each command of a batch is authorized. The
+// routes proxied to the sites of the api are authorized like its own, except
+// the groups of a site, which are authorized as commands of their robot.
+// UseRBAC relies on the identities authenticated by UseAuth, UseJWT or
+// BasicAuth, and the roles of those of UseAuth, it must be used after them.
+func (a *API) UseRBAC(r *RBAC) {
+	a.AddHandler(func(res http.ResponseWriter, req *http.Request) {
+		target, ok := a.rbacTarget(req)
+		if !ok {
+			return
+		}
+		roles := r.roles(a, req)
+		for _, target := range batchTargets(target, req) {
+			if !r.allows(roles, target) {
+				http.Error(res, "Forbidden", http.StatusForbidden)
+				return
+			}
+		}
+	})
+}
+
+// allows returns true if one of roles allows executing target
+func (r *RBAC) allows(roles []string, target rbacTarget) bool {
+	for _, name := range roles {
+		if role := r.Roles[name]; role != nil && role.allows(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// batchTargets returns the commands of the batch req executes, so each is
+// authorized, or target if req does not execute a batch
+func batchTargets(target rbacTarget, req *http.Request) []rbacTarget {
+	p, _ := rbacPath(req)
+	if req.Method != "POST" || !strings.HasPrefix(p, "robots/") ||
+		target.command != "batch" || len(target.devices) > 0 {
+		return []rbacTarget{target}
+	}
+	b, err := readBatch(req)
+	if err != nil {
+		return []rbacTarget{target}
+	}
+	targets := []rbacTarget{}
+	for _, c := range b.Commands {
+		t := rbacTarget{robot: target.robot, command: c.Command}
+		if c.Device != "" {
+			t.devices = []string{c.Device}
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+// rbacPath returns the path req requests relative to the api, and whether
+// it is proxied to a site. The routes of the sites are relative to the api
+// of their site, so they are authorized like the local routes.
+func rbacPath(req *http.Request) (p string, site bool) {
+	p = req.URL.Path
+	if !strings.HasPrefix(p, "/api/") {
+		return "", false
+	}
+	p = p[len("/api/"):]
+	if strings.HasPrefix(p, "sites/") {
+		s := strings.SplitN(p, "/", 3)
+		if len(s) < 3 {
+			return "", false
+		}
+		p, site = s[2], true
+	}
+	return strings.TrimPrefix(p, "v2/"), site
+}
+
+// rbacTarget returns what req executes, false if it does not execute
+// anything
+func (a *API) rbacTarget(req *http.Request) (rbacTarget, bool) {
+	p, site := rbacPath(req)
+	if p == "" {
+		return rbacTarget{}, false
+	}
+	s := strings.Split(strings.TrimSuffix(p, "/"), "/")
+	switch {
+	case len(s) == 1 && s[0] == "shutdown" && req.Method == "POST":
+		return rbacTarget{command: "shutdown"}, true
+	case len(s) == 2 && s[0] == "commands":
+		return rbacTarget{command: s[1]}, true
+	case len(s) == 1 && s[0] == "webhooks" && req.Method == "POST",
+		len(s) == 2 && s[0] == "webhooks" && req.Method == "DELETE":
+		return rbacTarget{command: "webhooks"}, true
+	case len(s) < 4 || s[0] != "robots":
+		return rbacTarget{}, false
+	case len(s) == 4 && s[2] == "commands":
+		return rbacTarget{robot: s[1], command: s[3]}, true
+	case len(s) == 6 && s[2] == "devices" && s[4] == "commands":
+		return rbacTarget{robot: s[1], devices: []string{s[3]}, command: s[5]}, true
+	case len(s) == 5 && s[2] == "devices" && (s[4] == "enable" || s[4] == "disable") &&
+		req.Method == "POST":
+		return rbacTarget{robot: s[1], devices: []string{s[3]}, command: s[4]}, true
+	case len(s) == 6 && s[2] == "groups" && s[4] == "commands",
+		len(s) == 5 && s[2] == "groups" && (s[4] == "start" || s[4] == "halt") && req.Method == "POST":
+		if site {
+			// the devices of the groups of a site are not known here, so
+			// they are authorized as commands of their robot
+			return rbacTarget{robot: s[1], command: s[len(s)-1]}, true
+		}
+		robot := a.gobot.Robot(s[1])
+		if robot == nil || robot.Group(s[3]) == nil {
+			return rbacTarget{}, false
+		}
+		group := robot.Group(s[3])
+		target := rbacTarget{robot: s[1], devices: []string{}, command: s[len(s)-1]}
+		group.Devices().Each(func(device gobot.Device) {
+			target.devices = append(target.devices, device.Name())
+		})
+		return target, true
+	case len(s) == 7 && s[2] == "connections" && s[4] == "pins":
+		command, ok := pinCommands[req.Method+" "+s[6]]
+		return rbacTarget{robot: s[1], devices: []string{s[3]}, command: command}, ok
+	}
+	return rbacTarget{}, false
+}
+
+// roles returns the names of the roles of the client of req
+func (r *RBAC) roles(a *API, req *http.Request) []string {
+	var claims Claims
+	if a.jwt != nil {
+		claims, _ = a.jwt.claims(req)
+	}
+
+	identity := ""
+	if r.Identity != nil {
+		identity = r.Identity(req)
+	} else {
+		identity = a.identity(req)
+	}
+	roles := []string{}
+	if identity != "" {
+		roles = append(roles, r.Bindings[identity]...)
+	}
+	if id := RequestIdentity(req); id != nil {
+		roles = append(roles, id.Roles...)
+	}
+
+	if r.RolesClaim != "" && claims != nil {
+		switch v := claims[r.RolesClaim].(type) {
+		case string:
+			roles = append(roles, strings.Fields(v)...)
+		case []interface{}:
+			for _, role := range v {
+				if s, ok := role.(string); ok {
+					roles = append(roles, s)
+				}
+			}
+		}
+	}
+	return roles
+}
+
+// identity returns the identity of the client of req, the name of the
+// identity authenticated by UseAuth, or else the subject of its JWT if the
+// api uses UseJWT, or else the username of its basic authorization
+func (a *API) identity(req *http.Request) string {
+	if id := RequestIdentity(req); id != nil {
+		return id.Name
+	}
+	if a.jwt != nil {
+		if claims, err := a.jwt.claims(req); err == nil {
+			return claims.Subject()
+		}
+	}
+	if username, _, ok := req.BasicAuth(); ok {
+		return username
+	}
+	return ""
+}
+
+// allows returns true if the role allows executing target
+func (r *Role) allows(target rbacTarget) bool {
+	if !matchName(r.Commands, target.command) {
+		return false
+	}
+	if target.robot == "" {
+		return len(r.Robots) == 0 && len(r.Devices) == 0
+	}
+	if !matchName(r.Robots, target.robot) {
+		return false
+	}
+	if target.devices == nil {
+		return len(r.Devices) == 0
+	}
+	for _, device := range target.devices {
+		if !matchName(r.Devices, device) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchName returns true if name matches one of patterns, path.Match
+// patterns, or if there are no patterns
+func matchName(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestUseRBAC(t *testing.T) {
+	a := initTestAPI()
+	a.UseJWT(&JWT{Secret: []byte("secret")})
+	a.UseRBAC(&RBAC{
+		Roles: map[string]*Role{
+			"admin":    {},
+			"operator": {Commands: []string{"*Read", "TestDriverCommand"}},
+			"driver":   {Robots: []string{"Robot1"}, Devices: []string{"Device1"}},
+		},
+		Bindings:   map[string][]string{"alice": {"admin"}, "bob": {"operator"}},
+		RolesClaim: "roles",
+	})
+	a.gobot.Robot("Robot1").AddGroup("wheels", "Device1", "Device2")
+
+	request := func(method string, url string, claims Claims) int {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(`{"message":"hi","robot":"Robot1","name":"x"}`))
+		req.Header.Set("Authorization", "Bearer "+signJWT("HS256", "", claims, hs256("secret")))
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		return res.Code
+	}
+	alice, bob := Claims{"sub": "alice"}, Claims{"sub": "bob"}
+	carol := Claims{"sub": "carol", "roles": []string{"driver"}}
+
+	gobot.Assert(t, request("POST", "/api/commands/TestFunction", alice), 200)
+	gobot.Assert(t, request("POST", "/api/robots/Robot1/commands/robotTestFunction", alice), 200)
+	gobot.Assert(t, request("POST", "/api/robots/Robot1/connections/Connection1/pins/13/digital", alice), 200)
+
+	gobot.Assert(t, request("POST", "/api/robots/Robot1/devices/Device1/commands/TestDriverCommand", bob), 200)
+	gobot.Assert(t, request("POST", "/api/v2/robots/Robot1/devices/Device1/commands/DriverCommand", bob), 403)
+	gobot.Assert(t, request("GET", "/api/robots/Robot1/connections/Connection1/pins/A0/analog", bob), 200)
+	gobot.Assert(t, request("POST", "/api/robots/Robot1/connections/Connection1/pins/3/analog", bob), 403)
+	gobot.Assert(t, request("POST", "/api/robots/Robot1/devices/Device1/disable", bob), 403)
+	// reading the state is not authorized by the roles
+	gobot.Assert(t, request("GET", "/api/robots/Robot1/devices/Device1", bob), 200)
+
+	gobot.Assert(t, request("POST", "/api/robots/Robot1/devices/Device1/commands/DriverCommand", carol), 200)
+	gobot.Assert(t, request("POST", "/api/robots/Robot2/devices/Device1/commands/DriverCommand", carol), 403)
+	gobot.Assert(t, request("POST", "/api/robots/Robot1/devices/Device2/commands/DriverCommand", carol), 403)
+	gobot.Assert(t, request("POST", "/api/robots/Robot1/commands/robotTestFunction", carol), 403)
+	gobot.Assert(t, request("POST", "/api/robots/Robot1/groups/wheels/commands/DriverCommand", carol), 403)
+	gobot.Assert(t, request("POST", "/api/commands/TestFunction", carol), 403)
+
+	gobot.Assert(t, request("POST", "/api/robots/Robot1/groups/wheels/halt", Claims{"sub": "dave"}), 403)
+	gobot.Assert(t, request("POST", "/api/robots/Robot1/groups/wheels/halt", alice), 200)
+
+	gobot.Assert(t, request("POST", "/api/webhooks", bob), 403)
+	gobot.Assert(t, request("DELETE", "/api/webhooks/1", carol), 403)
+}
+
+func TestRBACTargetWebhooks(t *testing.T) {
+	a := initTestAPI()
+	target := func(method string, url string) (rbacTarget, bool) {
+		req, _ := http.NewRequest(method, url, nil)
+		return a.rbacTarget(req)
+	}
+
+	webhooks, ok := target("POST", "/api/webhooks")
+	gobot.Assert(t, ok, true)
+	gobot.Assert(t, webhooks, rbacTarget{command: "webhooks"})
+	webhooks, ok = target("DELETE", "/api/webhooks/1")
+	gobot.Assert(t, ok, true)
+	gobot.Assert(t, webhooks, rbacTarget{command: "webhooks"})
+
+	_, ok = target("GET", "/api/webhooks")
+	gobot.Assert(t, ok, false)
+	_, ok = target("GET", "/api/webhooks/1")
+	gobot.Assert(t, ok, false)
+}
+
+func TestRBACBasicAuth(t *testing.T) {
+	a := initTestAPI()
+	a.AddHandler(BasicAuth("admin", "password"))
+	a.UseRBAC(&RBAC{
+		Roles:    map[string]*Role{"reader": {Commands: []string{"Read"}}},
+		Bindings: map[string][]string{"admin": {"reader"}},
+	})
+
+	req, _ := http.NewRequest("POST", "/api/robots/Robot1/commands/robotTestFunction", nil)
+	req.SetBasicAuth("admin", "password")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+	gobot.Assert(t, res.Code, 403)
+	gobot.Assert(t, res.Body.String(), "Forbidden\n")
+}
+
+func TestRBACTargetSites(t *testing.T) {
+	a := initTestAPI()
+	a.gobot.Robot("Robot1").AddGroup("wheels", "Device1", "Device2")
+	target := func(method string, url string) (rbacTarget, bool) {
+		req, _ := http.NewRequest(method, url, nil)
+		return a.rbacTarget(req)
+	}
+
+	local, ok := target("POST", "/api/robots/Robot1/devices/Device1/commands/DriverCommand")
+	gobot.Assert(t, ok, true)
+	for _, url := range []string{
+		"/api/sites/lab/robots/Robot1/devices/Device1/commands/DriverCommand",
+		"/api/sites/lab/v2/robots/Robot1/devices/Device1/commands/DriverCommand",
+	} {
+		site, ok := target("POST", url)
+		gobot.Assert(t, ok, true)
+		gobot.Assert(t, site, local)
+	}
+	site, ok := target("POST", "/api/sites/lab/v2/commands/TestFunction")
+	gobot.Assert(t, ok, true)
+	gobot.Assert(t, site, rbacTarget{command: "TestFunction"})
+	// the groups of a site are not resolved locally
+	site, ok = target("POST", "/api/sites/lab/robots/Robot1/groups/wheels/halt")
+	gobot.Assert(t, ok, true)
+	gobot.Assert(t, site, rbacTarget{robot: "Robot1", command: "halt"})
+
+	_, ok = target("GET", "/api/sites/lab")
+	gobot.Assert(t, ok, false)
+	_, ok = target("GET", "/api/sites/events")
+	gobot.Assert(t, ok, false)
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// siteTimeout bounds the requests listing the robots of a site
+var siteTimeout = 5 * time.Second
+
+// siteRetry is how long the events of a site wait before reconnecting to it
+var siteRetry = time.Second
+
+// Site is a remote Gobot whose api is proxied by the api, see API.AddSite.
+type Site struct {
+	// Name is the name of the site in the routes of the api
+	Name string
+	// URL is the address of the api of the site, e.g. http://10.8.0.2:3000
+	URL string
+	// Token is the bearer token, or Username and Password the basic
+	// authorization, sent to the site instead of the credentials of the
+	// clients of the api
+	Token    string
+	Username string
+	Password string
+	// Transport sends the requests to the site, http.DefaultTransport when
+	// nil, e.g. one with a client certificate
+	Transport http.RoundTripper
+
+	url   *url.URL
+	proxy *httputil.ReverseProxy
+}
+
+// jsonSite is a JSON representation of a site and its robots
+type jsonSite struct {
+	Name   string        `json:"name"`
+	Robots []interface{} `json:"robots"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// AddSite makes the api proxy the robots of a remote Gobot, so a fleet
+// behind NAT can be managed from a single endpoint. The routes of the robots
+// of the site are served under /api/sites/:site/robots, and its v2 routes
+// under /api/sites/:site/v2, event streams included. The robots of every
+// site are listed at /api/sites, or of a site at /api/sites/:site, and
+// /api/sites/events streams the events of all their robots, with the name
+// of their site, filtered by the "site" and "event" query parameters, and
+// those of the event streams of the robots.
+// Returns an error if the name of site is taken or its URL is invalid.
+// AddSite must be called before the api is started.
+func (a *API) AddSite(site *Site) error {
+	if site.Name == "" || site.Name == "events" || strings.Contains(site.Name, "/") {
+		return errors.New("Invalid site name " + site.Name)
+	}
+	if a.sites[site.Name] != nil {
+		return errors.New("Site " + site.Name + " already exists")
+	}
+	u, err := url.Parse(site.URL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("Invalid site URL " + site.URL)
+	}
+	site.url = u
+	site.proxy = &httputil.ReverseProxy{
+		Director:      site.direct,
+		Transport:     site.transport(),
+		FlushInterval: -1,
+		ErrorHandler: func(res http.ResponseWriter, req *http.Request, err error) {
+			gobot.Log(gobot.WarnLevel, "Proxying site failed", gobot.Fields{"site": site.Name, "error": err.Error()})
+			http.Error(res, "Site "+site.Name+" is unreachable", http.StatusBadGateway)
+		},
+	}
+	if a.sites == nil {
+		a.sites = make(map[string]*Site)
+	}
+	a.sites[site.Name] = site
+	a.siteNames = append(a.siteNames, site.Name)
+	return nil
+}
+
+// startSites adds the routes of the sites of the api, if any
+func (a *API) startSites() {
+	if len(a.sites) == 0 {
+		return
+	}
+	a.Get("/api/sites", a.listSites)
+	a.Get("/api/sites/events", a.siteEvents)
+	a.Get("/api/sites/:site", a.site)
+	for _, route := range []string{"/api/sites/:site/robots", "/api/sites/:site/robots/", "/api/sites/:site/v2/"} {
+		a.Get(route, a.proxySite)
+		a.Post(route, a.proxySite)
+		a.Put(route, a.proxySite)
+		a.Delete(route, a.proxySite)
+	}
+}
+
+// listSites returns the sites route handler.
+// Writes JSON with the robots of every site
+func (a *API) listSites(res http.ResponseWriter, req *http.Request) {
+	sites := make([]jsonSite, len(a.siteNames))
+	var wg sync.WaitGroup
+	for i, name := range a.siteNames {
+		wg.Add(1)
+		go func(i int, site *Site) {
+			defer wg.Done()
+			sites[i] = site.jsonSite()
+		}(i, a.sites[name])
+	}
+	wg.Wait()
+	a.writeJSON(map[string]interface{}{"sites": sites}, res)
+}
+
+// site returns the site route handler.
+// Writes JSON with the robots of the site
+func (a *API) site(res http.ResponseWriter, req *http.Request) {
+	if site := a.sites[req.URL.Query().Get(":site")]; site != nil {
+		a.writeJSON(map[string]interface{}{"site": site.jsonSite()}, res)
+	} else {
+		a.writeJSON(map[string]interface{}{"error": "No Site found with the name " + req.URL.Query().Get(":site")}, res)
+	}
+}
+
+// proxySite returns the site robots route handler.
+// Proxies the request to the api of the site
+func (a *API) proxySite(res http.ResponseWriter, req *http.Request) {
+	if site := a.sites[req.URL.Query().Get(":site")]; site != nil {
+		site.proxy.ServeHTTP(res, req)
+	} else {
+		a.writeJSON(map[string]interface{}{"error": "No Site found with the name " + req.URL.Query().Get(":site")}, res)
+	}
+}
+
+// siteEvents returns the site events route handler.
+// Streams the events of the robots of the sites, see streamEvents.
+func (a *API) siteEvents(res http.ResponseWriter, req *http.Request) {
+	filters := req.URL.Query()["event"]
+	if err := CheckEventFilters(filters); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	filter, err := newEventFilter(req.URL.Query())
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	sites := []*Site{}
+	for _, name := range a.siteNames {
+		if matchName(req.URL.Query()["site"], name) {
+			sites = append(sites, a.sites[name])
+		}
+	}
+	a.writeEventStream(func(closed <-chan struct{}) <-chan JSONEvent {
+		events := make(chan JSONEvent, EventBuffer)
+		for _, site := range sites {
+			go site.streamEvents(filters, events, closed)
+		}
+		return events
+	}, filter, nil, res, req)
+}
+
+// direct rewrites a request to the api for the api of the site
+func (s *Site) direct(req *http.Request) {
+	rest := strings.TrimPrefix(req.URL.Path, "/api/sites/"+s.Name)
+	query := url.Values{}
+	for name, values := range req.URL.Query() {
+		// the router adds the route parameters to the query
+		if !strings.HasPrefix(name, ":") && name != "access_token" {
+			query[name] = values
+		}
+	}
+	req.URL.Scheme = s.url.Scheme
+	req.URL.Host = s.url.Host
+	req.URL.Path = strings.TrimSuffix(s.url.Path, "/") + "/api" + rest
+	req.URL.RawPath = ""
+	req.URL.RawQuery = query.Encode()
+	req.Host = s.url.Host
+	req.Header.Del("Cookie")
+	s.authorize(req)
+}
+
+// authorize sets the credentials of the site on req
+func (s *Site) authorize(req *http.Request) {
+	req.Header.Del("Authorization")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	} else if s.Username != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+}
+
+// transport returns the transport of the requests to the site
+func (s *Site) transport() http.RoundTripper {
+	if s.Transport != nil {
+		return s.Transport
+	}
+	return http.DefaultTransport
+}
+
+// get sends a GET request to the route of the api of the site, canceled
+// with ctx
+func (s *Site) get(ctx context.Context, route string, query url.Values) (*http.Response, error) {
+	u := *s.url
+	u.Path = strings.TrimSuffix(s.url.Path, "/") + route
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.authorize(req)
+	res, err := (&http.Client{Transport: s.transport()}).Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, errors.New("Site " + s.Name + " answered " + res.Status)
+	}
+	return res, nil
+}
+
+// robots returns the JSON robots of the site
+func (s *Site) robots() ([]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), siteTimeout)
+	defer cancel()
+	res, err := s.get(ctx, "/api/robots", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	var body struct {
+		Robots []interface{} `json:"robots"`
+		Error  string        `json:"error"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Error != "" {
+		return nil, errors.New(body.Error)
+	}
+	return body.Robots, nil
+}
+
+// jsonSite returns the JSON representation of the site and its robots
+func (s *Site) jsonSite() jsonSite {
+	site := jsonSite{Name: s.Name, Robots: []interface{}{}}
+	if robots, err := s.robots(); err != nil {
+		site.Error = err.Error()
+	} else {
+		site.Robots = robots
+	}
+	return site
+}
+
+// streamEvents sends the events of the robots of the site matching filters
+// to events until closed is closed, reconnecting to the site when its
+// streams end
+func (s *Site) streamEvents(filters []string, events chan<- JSONEvent, closed <-chan struct{}) {
+	for {
+		robots, err := s.robots()
+		if err == nil {
+			var wg sync.WaitGroup
+			for _, robot := range robots {
+				r, _ := robot.(map[string]interface{})
+				name, _ := r["name"].(string)
+				wg.Add(1)
+				go func(name string) {
+					defer wg.Done()
+					s.streamRobotEvents(name, filters, events, closed)
+				}(name)
+			}
+			wg.Wait()
+		} else {
+			gobot.Log(gobot.WarnLevel, "Listing robots of site failed", gobot.Fields{"site": s.Name, "error": err.Error()})
+		}
+		select {
+		case <-closed:
+			return
+		case <-time.After(siteRetry):
+		}
+	}
+}
+
+// streamRobotEvents sends the events of a robot of the site matching
+// filters to events until its stream ends or closed is closed
+func (s *Site) streamRobotEvents(robot string, filters []string, events chan<- JSONEvent, closed <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	res, err := s.get(ctx, "/api/robots/"+url.PathEscape(robot)+"/events", url.Values{"event": filters})
+	if err != nil {
+		gobot.Log(gobot.WarnLevel, "Streaming events of site failed", gobot.Fields{"site": s.Name, "robot": robot, "error": err.Error()})
+		return
+	}
+	defer res.Body.Close()
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		var event JSONEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(line[len("data:"):])), &event); err != nil {
+			continue
+		}
+		event.Site, event.Time = s.Name, time.Now()
+		select {
+		case <-closed:
+			return
+		case events <- event:
+		default:
+			gobot.Log(gobot.WarnLevel, "Dropping event for slow client", gobot.Fields{"event": event.Event})
+		}
+	}
+}
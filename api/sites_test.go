@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func initTestSites() (*API, *gobot.Robot, *httptest.Server) {
+	log.SetOutput(NullReadWriteCloser{})
+	remote := NewAPI(gobot.NewGobot())
+	remote.start = func(m *API) error { return nil }
+	remote.AddHandler(BasicAuth("master", "secret"))
+	remote.Start()
+	robot := newTestRobot("Robot1")
+	robot.AddEvent("ping")
+	remote.gobot.AddRobot(robot)
+	server := httptest.NewServer(remote)
+
+	a := NewAPI(gobot.NewGobot())
+	a.start = func(m *API) error { return nil }
+	a.AddSite(&Site{Name: "lab", URL: server.URL, Username: "master", Password: "secret"})
+	a.AddSite(&Site{Name: "down", URL: "http://127.0.0.1:1"})
+	a.Start()
+	return a, robot, server
+}
+
+func TestAddSite(t *testing.T) {
+	a := NewAPI(gobot.NewGobot())
+	gobot.Assert(t, a.AddSite(&Site{Name: "lab", URL: "http://10.8.0.2:3000"}), nil)
+	gobot.Refute(t, a.AddSite(&Site{Name: "lab", URL: "http://10.8.0.3:3000"}), nil)
+	gobot.Refute(t, a.AddSite(&Site{Name: "events", URL: "http://10.8.0.3:3000"}), nil)
+	gobot.Refute(t, a.AddSite(&Site{Name: "shed", URL: "10.8.0.3:3000"}), nil)
+}
+
+func TestSites(t *testing.T) {
+	a, _, server := initTestSites()
+	defer server.Close()
+
+	request, _ := http.NewRequest("GET", "/api/sites", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	var body struct {
+		Sites []jsonSite `json:"sites"`
+	}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobot.Assert(t, len(body.Sites), 2)
+	gobot.Assert(t, body.Sites[0].Name, "lab")
+	gobot.Assert(t, body.Sites[0].Robots[0].(map[string]interface{})["name"], "Robot1")
+	gobot.Assert(t, body.Sites[1].Name, "down")
+	gobot.Refute(t, body.Sites[1].Error, "")
+
+	request, _ = http.NewRequest("GET", "/api/sites/shed", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Body.String(), "{\"error\":\"No Site found with the name shed\"}")
+}
+
+func TestProxySite(t *testing.T) {
+	a, _, server := initTestSites()
+	defer server.Close()
+
+	request, _ := http.NewRequest("POST", "/api/sites/lab/robots/Robot1/commands/robotTestFunction",
+		bytes.NewBufferString(`{"message":"hi","robot":"Robot1"}`))
+	request.SetBasicAuth("someone", "else")
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Body.String(), "{\"result\":\"hey Robot1, hi\"}")
+
+	request, _ = http.NewRequest("GET", "/api/sites/lab/v2/robots/Robot1/devices/Device1", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 200)
+	gobot.Assert(t, strings.Contains(response.Body.String(), "\"name\":\"Device1\""), true)
+
+	request, _ = http.NewRequest("GET", "/api/sites/down/robots", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, http.StatusBadGateway)
+}
+
+func TestSiteEvents(t *testing.T) {
+	a, robot, server := initTestSites()
+	defer server.Close()
+	local := httptest.NewServer(a)
+	defer local.Close()
+
+	res, err := http.Get(local.URL + "/api/sites/events?site=lab&event=ping")
+	gobot.Assert(t, err, nil)
+	defer res.Body.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				gobot.Publish(robot.Event("ping"), 1)
+			}
+		}
+	}()
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			if strings.HasPrefix(scanner.Text(), "data:") {
+				lines <- scanner.Text()
+				return
+			}
+		}
+	}()
+	select {
+	case line := <-lines:
+		gobot.Assert(t, line, "data: {\"site\":\"lab\",\"robot\":\"Robot1\",\"event\":\"ping\",\"data\":1}")
+	case <-time.After(5 * time.Second):
+		t.Fatal("No event of the site")
+	}
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// listenUnix listens on the unix domain socket at path, removing the socket
+// left behind by a previous run which was not stopped
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, errors.New("Socket " + path + " is already in use")
+		}
+		os.Remove(path)
+	}
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestSocket(t *testing.T) {
+	log.SetOutput(NullReadWriteCloser{})
+	dir, _ := ioutil.TempDir("", "gobot")
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "api.sock")
+
+	g := gobot.NewGobot()
+	g.AddRobot(newTestRobot("Robot1"))
+	a := NewAPI(g)
+	a.Port = ""
+	a.Socket = socket
+	gobot.Assert(t, a.Start(), nil)
+	gobot.Assert(t, a.listener, nil)
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	res, err := client.Get("http://gobot/api/robots/Robot1")
+	gobot.Assert(t, err, nil)
+	res.Body.Close()
+	gobot.Assert(t, res.StatusCode, 200)
+
+	// the socket is in use
+	b := NewAPI(g)
+	b.Port = ""
+	b.Socket = socket
+	gobot.Refute(t, b.Start(), nil)
+
+	gobot.Assert(t, a.Stop(), nil)
+	_, err = os.Stat(socket)
+	gobot.Assert(t, os.IsNotExist(err), true)
+
+	// a stale socket is replaced
+	listener, _ := net.Listen("unix", socket)
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+	gobot.Assert(t, a.Start(), nil)
+	a.Stop()
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// jsonState is a JSON representation of the state of a robot, its
+// connections and devices
+type jsonState struct {
+	Robot       string                `json:"robot"`
+	Time        time.Time             `json:"time"`
+	Healthy     bool                  `json:"healthy"`
+	Connections []jsonConnectionState `json:"connections"`
+	Devices     []jsonDeviceState     `json:"devices"`
+}
+
+// jsonConnectionState is a JSON representation of the state of a connection
+type jsonConnectionState struct {
+	Name    string                    `json:"name"`
+	Adaptor string                    `json:"adaptor"`
+	Healthy bool                      `json:"healthy"`
+	Error   string                    `json:"error,omitempty"`
+	Pins    map[string]gobot.PinState `json:"pins,omitempty"`
+}
+
+// jsonDeviceState is a JSON representation of the state of a device
+type jsonDeviceState struct {
+	Name       string                 `json:"name"`
+	Driver     string                 `json:"driver"`
+	Connection string                 `json:"connection"`
+	Pin        string                 `json:"pin,omitempty"`
+	Disabled   bool                   `json:"disabled"`
+	Healthy    bool                   `json:"healthy"`
+	Error      string                 `json:"error,omitempty"`
+	State      map[string]interface{} `json:"state,omitempty"`
+	Readings   map[string]interface{} `json:"readings,omitempty"`
+}
+
+// robotState returns the robot state route handler.
+// Writes JSON with the state of the robot in a single document: the health
+// and pins of each connection, and the health, state and readings of each
+// device, see newJSONState.
+func (a *API) robotState(res http.ResponseWriter, req *http.Request) {
+	robot := a.gobot.Robot(req.URL.Query().Get(":robot"))
+	if robot == nil {
+		a.writeJSON(map[string]interface{}{
+			"error": "No Robot found with the name " + req.URL.Query().Get(":robot"),
+		}, res)
+		return
+	}
+	a.writeJSON(map[string]interface{}{"state": newJSONState(robot)}, res)
+}
+
+// newJSONState returns the state of robot. The pins are those of the
+// adaptors which are gobot.PinStaters, the state that of the devices which
+// are gobot.Snapshotters, and the readings the last value retained from each
+// event of a device, see gobot.Event.Retain and UseLongPoll. Disabled
+// devices are not checked for health.
+func newJSONState(robot *gobot.Robot) *jsonState {
+	state := &jsonState{
+		Robot:       robot.Name,
+		Time:        time.Now(),
+		Healthy:     true,
+		Connections: []jsonConnectionState{},
+		Devices:     []jsonDeviceState{},
+	}
+	robot.Connections().Each(func(connection gobot.Connection) {
+		c := jsonConnectionState{
+			Name:    connection.Name(),
+			Adaptor: reflect.TypeOf(connection).String(),
+			Healthy: true,
+		}
+		if checker, ok := connection.(gobot.HealthChecker); ok {
+			if err := checker.Health(); err != nil {
+				c.Healthy, c.Error = false, err.Error()
+			}
+		}
+		if stater, ok := connection.(gobot.PinStater); ok {
+			c.Pins = stater.PinStates()
+		}
+		state.Healthy = state.Healthy && c.Healthy
+		state.Connections = append(state.Connections, c)
+	})
+	robot.Devices().Each(func(device gobot.Device) {
+		d := jsonDeviceState{
+			Name:     device.Name(),
+			Driver:   reflect.TypeOf(device).String(),
+			Disabled: robot.DeviceDisabled(device.Name()),
+			Healthy:  true,
+		}
+		if device.Connection() != nil {
+			d.Connection = device.Connection().Name()
+		}
+		if pinner, ok := device.(gobot.Pinner); ok {
+			d.Pin = pinner.Pin()
+		}
+		if checker, ok := device.(gobot.HealthChecker); ok && !d.Disabled {
+			if err := checker.Health(); err != nil {
+				d.Healthy, d.Error = false, err.Error()
+			}
+		}
+		if snapshotter, ok := device.(gobot.Snapshotter); ok {
+			d.State = snapshotter.Snapshot()
+		}
+		if eventer, ok := device.(gobot.Eventer); ok {
+			d.Readings = lastReadings(eventer)
+		}
+		state.Healthy = state.Healthy && d.Healthy
+		state.Devices = append(state.Devices, d)
+	})
+	return state
+}
+
+// lastReadings returns the last value retained from each event of eventer,
+// in its history or its replay buffer, by event name
+func lastReadings(eventer gobot.Eventer) map[string]interface{} {
+	last := make(map[string]gobot.Record)
+	keep := func(name string, record gobot.Record) {
+		if current, ok := last[name]; !ok || record.Seq > current.Seq {
+			last[name] = record
+		}
+	}
+	for name, event := range eventer.Events() {
+		if history := event.History(); len(history) > 0 {
+			keep(name, history[len(history)-1])
+		}
+	}
+	for _, record := range eventer.EventsSince(0) {
+		keep(record.Name, record.Record)
+	}
+
+	readings := make(map[string]interface{})
+	for name, record := range last {
+		if err, ok := record.Data.(error); ok {
+			readings[name] = err.Error()
+		} else {
+			readings[name] = record.Data
+		}
+	}
+	return readings
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// stateDriver is an eventDriver with a state and a health
+type stateDriver struct {
+	*eventDriver
+	health error
+}
+
+func (s *stateDriver) Health() error                        { return s.health }
+func (s *stateDriver) Snapshot() map[string]interface{}     { return map[string]interface{}{"level": 3} }
+func (s *stateDriver) Restore(map[string]interface{}) error { return nil }
+
+func TestRobotState(t *testing.T) {
+	a := initTestAPI()
+	device := &stateDriver{
+		eventDriver: &eventDriver{
+			testDriver: newTestDriver(newTestAdaptor("Connection1", "/dev/null"), "sensor", "3"),
+			Eventer:    gobot.NewEventer(),
+		},
+		health: errors.New("not responding"),
+	}
+	device.AddEvent("data")
+	device.Event("data").Retain(1)
+	a.gobot.Robot("Robot1").AddDevice(device)
+	gobot.Publish(device.Event("data"), 41)
+	gobot.Publish(device.Event("data"), 42)
+
+	request := func(url string) map[string]interface{} {
+		req, _ := http.NewRequest("GET", url, nil)
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		body := map[string]interface{}{}
+		json.NewDecoder(res.Body).Decode(&body)
+		return body
+	}
+	state := request("/api/robots/Robot1/state")["state"].(map[string]interface{})
+	gobot.Assert(t, state["robot"], "Robot1")
+	gobot.Assert(t, state["healthy"], false)
+	gobot.Assert(t, len(state["connections"].([]interface{})), 3)
+	devices := state["devices"].([]interface{})
+	gobot.Assert(t, len(devices), 4)
+	gobot.Assert(t, devices[3], map[string]interface{}{
+		"name":       "sensor",
+		"driver":     "*api.stateDriver",
+		"connection": "Connection1",
+		"pin":        "3",
+		"disabled":   false,
+		"healthy":    false,
+		"error":      "not responding",
+		"state":      map[string]interface{}{"level": 3.0},
+		"readings":   map[string]interface{}{"data": 42.0},
+	})
+
+	a.gobot.Robot("Robot1").DisableDevice("sensor")
+	state = request("/api/robots/Robot1/state")["state"].(map[string]interface{})
+	gobot.Assert(t, state["healthy"], true)
+
+	gobot.Assert(t, request("/api/robots/UnknownRobot1/state")["error"], "No Robot found with the name UnknownRobot1")
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestAPISelfSigned(t *testing.T) {
+	log.SetOutput(NullReadWriteCloser{})
+	a := NewAPI(gobot.NewGobot())
+	a.Host = "127.0.0.1"
+	a.Port = "0"
+	a.SelfSigned = true
+	gobot.Assert(t, a.Start(), nil)
+	defer a.Stop()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	response, err := client.Get("https://" + a.listener.Addr().String() + "/api/robots")
+	gobot.Assert(t, err, nil)
+	response.Body.Close()
+	gobot.Assert(t, response.StatusCode, 200)
+	cert := response.TLS.PeerCertificates[0]
+	gobot.Assert(t, cert.VerifyHostname("127.0.0.1"), nil)
+	gobot.Assert(t, cert.VerifyHostname("localhost"), nil)
+}
+
+func TestAPIClientCA(t *testing.T) {
+	log.SetOutput(NullReadWriteCloser{})
+	clientCert, _ := selfSignedCert("")
+	ca, _ := ioutil.TempFile("", "gobot")
+	defer os.Remove(ca.Name())
+	pem.Encode(ca, &pem.Block{Type: "CERTIFICATE", Bytes: clientCert.Certificate[0]})
+	ca.Close()
+
+	a := NewAPI(gobot.NewGobot())
+	a.Host = "127.0.0.1"
+	a.Port = "0"
+	a.ClientCA = ca.Name()
+	gobot.Refute(t, a.Start(), nil)
+
+	a.SelfSigned = true
+	gobot.Assert(t, a.Start(), nil)
+	defer a.Stop()
+	config, _ := a.tlsConfig()
+	gobot.Assert(t, config.ClientAuth, tls.RequireAndVerifyClientCert)
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	_, err := client.Get("https://" + a.listener.Addr().String() + "/api/robots")
+	gobot.Refute(t, err, nil)
+
+	a.ClientCA = "does_not_exist.pem"
+	_, err = a.tlsConfig()
+	gobot.Refute(t, err, nil)
+}
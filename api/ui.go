@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// ServeUI serves the web UI of fs at / instead of the dashboard and robeaux,
+// e.g. http.Dir("ui/dist") or http.FS of an embed.FS. The paths of fs which
+// do not exist and have no extension are answered with its index.html, so a
+// single page application can route them in the browser. The routes of the
+// api take precedence over the files of fs. ServeUI must be called before
+// the api is started.
+func (a *API) ServeUI(fs http.FileSystem) {
+	a.ui = fs
+}
+
+// serveUI returns the handler of the web UI of the api.
+// Serves the file of the requested path, or the index of the UI when the
+// path is a route of the UI.
+func (a *API) serveUI(res http.ResponseWriter, req *http.Request) {
+	if (req.Method != "GET" && req.Method != "HEAD") || strings.HasPrefix(req.URL.Path, "/api/") {
+		http.NotFound(res, req)
+		return
+	}
+	name := path.Clean("/" + req.URL.Path)
+	if f, err := a.ui.Open(name); err == nil {
+		f.Close()
+	} else if os.IsNotExist(err) && path.Ext(name) == "" {
+		req.URL.Path = "/"
+	}
+	http.FileServer(a.ui).ServeHTTP(res, req)
+}
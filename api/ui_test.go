@@ -0,0 +1,61 @@
+package api
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestServeUI(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gobot-ui")
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "js"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("<html>ui</html>"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "js", "app.js"), []byte("app()"), 0644)
+
+	log.SetOutput(NullReadWriteCloser{})
+	g := gobot.NewGobot()
+	g.AddRobot(newTestRobot("Robot1"))
+	a := NewAPI(g)
+	a.start = func(m *API) error { return nil }
+	a.ServeUI(http.Dir(dir))
+	a.Start()
+
+	get := func(url string) *httptest.ResponseRecorder {
+		request, _ := http.NewRequest("GET", url, nil)
+		response := httptest.NewRecorder()
+		a.ServeHTTP(response, request)
+		return response
+	}
+
+	response := get("/")
+	gobot.Assert(t, response.Code, 200)
+	gobot.Assert(t, response.Body.String(), "<html>ui</html>")
+
+	response = get("/js/app.js")
+	gobot.Assert(t, response.Code, 200)
+	gobot.Assert(t, response.Body.String(), "app()")
+
+	// the routes of the single page application fall back to its index
+	response = get("/robots/Robot1")
+	gobot.Assert(t, response.Code, 200)
+	gobot.Assert(t, response.Body.String(), "<html>ui</html>")
+
+	// missing assets are not found, nor the routes of other methods
+	gobot.Assert(t, get("/js/missing.js").Code, 404)
+	request, _ := http.NewRequest("POST", "/robots/Robot1", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 404)
+	gobot.Assert(t, get("/index.html").Code, 301)
+
+	response = get("/api/robots")
+	gobot.Assert(t, response.Code, 200)
+	gobot.Assert(t, response.Header().Get("Content-Type"), "application/json; charset=utf-8")
+}
@@ -0,0 +1,356 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// The /api/v2 routes answer with the typed payloads below and with HTTP
+// status codes matching their errors, while the legacy /api routes keep
+// their payloads and answer errors with 200 OK, see API.DeprecateV1.
+
+// jsonCommandV2 is a command and the parameters it takes
+type jsonCommandV2 struct {
+	Name   string        `json:"name"`
+	Params []gobot.Param `json:"params"`
+}
+
+// jsonDeviceV2 is a device of a robot
+type jsonDeviceV2 struct {
+	Name       string          `json:"name"`
+	Driver     string          `json:"driver"`
+	Connection string          `json:"connection"`
+	Pin        string          `json:"pin,omitempty"`
+	Disabled   bool            `json:"disabled"`
+	Commands   []jsonCommandV2 `json:"commands"`
+	Events     []string        `json:"events"`
+}
+
+// jsonRobotV2 is a robot, its connections and devices
+type jsonRobotV2 struct {
+	Name        string                  `json:"name"`
+	Tags        gobot.Tags              `json:"tags"`
+	Commands    []jsonCommandV2         `json:"commands"`
+	Connections []*gobot.JSONConnection `json:"connections"`
+	Devices     []jsonDeviceV2          `json:"devices"`
+}
+
+// jsonEventV2 is an event published by a robot or device, with the time it
+// was published
+type jsonEventV2 struct {
+	Robot  string      `json:"robot"`
+	Device string      `json:"device,omitempty"`
+	Name   string      `json:"name"`
+	Data   interface{} `json:"data"`
+	Time   time.Time   `json:"time"`
+}
+
+// jsonErrorV2 is an error, with a code clients can act on and a message
+type jsonErrorV2 struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// The codes of the errors of the v2 routes
+const (
+	errNotFound      = "not_found"
+	errInvalidParams = "invalid_params"
+	errInvalidBody   = "invalid_body"
+	errCommandFailed = "command_failed"
+)
+
+// startV2 adds the /api/v2 routes
+func (a *API) startV2() {
+	a.Get("/api/v2/commands", a.commandsV2)
+	a.Post("/api/v2/commands/:command", a.executeCommandV2)
+	a.Get("/api/v2/jobs/:job", a.jobV2)
+	a.Get("/api/v2/robots", a.robotsV2)
+	a.Get("/api/v2/robots/:robot", a.robotV2)
+	a.Get("/api/v2/robots/:robot/commands", a.commandsV2)
+	a.Post("/api/v2/robots/:robot/commands/:command", a.executeCommandV2)
+	a.Get("/api/v2/robots/:robot/jobs/:job", a.jobV2)
+	a.Get("/api/v2/robots/:robot/events", a.eventsV2)
+	a.Get("/api/v2/robots/:robot/devices", a.devicesV2)
+	a.Get("/api/v2/robots/:robot/devices/:device", a.deviceV2)
+	a.Get("/api/v2/robots/:robot/devices/:device/commands", a.commandsV2)
+	a.Post("/api/v2/robots/:robot/devices/:device/commands/:command", a.executeCommandV2)
+	a.Get("/api/v2/robots/:robot/devices/:device/jobs/:job", a.jobV2)
+	a.Get("/api/v2/robots/:robot/devices/:device/events", a.eventsV2)
+}
+
+// robotsV2 returns the v2 robots route handler.
+// Writes JSON with the robots, all of them or those with the tags of the tag
+// parameters
+func (a *API) robotsV2(res http.ResponseWriter, req *http.Request) {
+	filters := []gobot.Tags{}
+	for _, tag := range req.URL.Query()["tag"] {
+		filter, err := gobot.ParseTags(tag)
+		if err != nil {
+			writeErrorV2(res, http.StatusBadRequest, errInvalidParams, err.Error())
+			return
+		}
+		filters = append(filters, filter)
+	}
+	robots := []jsonRobotV2{}
+	a.gobot.Robots(filters...).Each(func(r *gobot.Robot) {
+		robots = append(robots, newJSONRobotV2(r))
+	})
+	writeJSONV2(res, http.StatusOK, map[string]interface{}{"robots": robots})
+}
+
+// robotV2 returns the v2 robot route handler.
+func (a *API) robotV2(res http.ResponseWriter, req *http.Request) {
+	if robot, ok := a.robotForV2(res, req); ok {
+		writeJSONV2(res, http.StatusOK, newJSONRobotV2(robot))
+	}
+}
+
+// devicesV2 returns the v2 devices route handler.
+func (a *API) devicesV2(res http.ResponseWriter, req *http.Request) {
+	if robot, ok := a.robotForV2(res, req); ok {
+		writeJSONV2(res, http.StatusOK, map[string]interface{}{"devices": newJSONRobotV2(robot).Devices})
+	}
+}
+
+// deviceV2 returns the v2 device route handler.
+func (a *API) deviceV2(res http.ResponseWriter, req *http.Request) {
+	if robot, device, ok := a.deviceForV2(res, req); ok {
+		writeJSONV2(res, http.StatusOK, newJSONDeviceV2(robot, device))
+	}
+}
+
+// commandsV2 returns the v2 commands route handler.
+// Writes JSON with the commands of the Gobot, robot or device of the route
+func (a *API) commandsV2(res http.ResponseWriter, req *http.Request) {
+	if c, ok := a.commanderForV2(res, req); ok {
+		writeJSONV2(res, http.StatusOK, map[string]interface{}{"commands": newJSONCommandsV2(c)})
+	}
+}
+
+// executeCommandV2 returns the v2 command route handler.
+// Validates the parameters of the JSON body against those of the command,
+// answering 400 Bad Request if they are invalid, and writes JSON with the
+// result of the command, or 500 Internal Server Error with the error it
+// returned. With the query parameter async=true the command is executed in
+// the background, answering 202 Accepted with its job.
+func (a *API) executeCommandV2(res http.ResponseWriter, req *http.Request) {
+	c, ok := a.commanderForV2(res, req)
+	if !ok {
+		return
+	}
+	name := req.URL.Query().Get(":command")
+	command := c.Command(name)
+	if command == nil {
+		writeErrorV2(res, http.StatusNotFound, errNotFound, "No Command found with the name "+name)
+		return
+	}
+	params, err := a.commandParams(req)
+	if err != nil {
+		writeErrorV2(res, http.StatusBadRequest, errInvalidBody, err.Error())
+		return
+	}
+	if p := c.Params(name); p != nil {
+		if _, err := gobot.ValidateParams(p, params); err != nil {
+			writeErrorV2(res, http.StatusBadRequest, errInvalidParams, err.Error())
+			return
+		}
+	}
+	if req.URL.Query().Get("async") == "true" {
+		job, err := c.ExecuteAsync(name, params)
+		if err != nil {
+			writeErrorV2(res, http.StatusNotFound, errNotFound, err.Error())
+			return
+		}
+		writeJSONV2(res, http.StatusAccepted, map[string]interface{}{"job": gobot.NewJSONJob(job)})
+		return
+	}
+	result := command(params)
+	if err, ok := result.(error); ok {
+		writeErrorV2(res, http.StatusInternalServerError, errCommandFailed, err.Error())
+		return
+	}
+	writeJSONV2(res, http.StatusOK, map[string]interface{}{"result": result})
+}
+
+// jobV2 returns the v2 job route handler of a command executed in the
+// background.
+func (a *API) jobV2(res http.ResponseWriter, req *http.Request) {
+	c, ok := a.commanderForV2(res, req)
+	if !ok {
+		return
+	}
+	if job := c.Job(req.URL.Query().Get(":job")); job != nil {
+		writeJSONV2(res, http.StatusOK, map[string]interface{}{"job": gobot.NewJSONJob(job)})
+	} else {
+		writeErrorV2(res, http.StatusNotFound, errNotFound, "No Job found with the id "+req.URL.Query().Get(":job"))
+	}
+}
+
+// eventsV2 returns the v2 events route handler.
+// Streams the events of the robot and its devices, or of the device of the
+// route, as jsonEventV2, see streamEvents.
+func (a *API) eventsV2(res http.ResponseWriter, req *http.Request) {
+	robot, ok := a.robotForV2(res, req)
+	if !ok {
+		return
+	}
+	if err := CheckEventFilters(req.URL.Query()["event"]); err != nil {
+		writeErrorV2(res, http.StatusBadRequest, errInvalidParams, err.Error())
+		return
+	}
+	if _, err := newEventFilter(req.URL.Query()); err != nil {
+		writeErrorV2(res, http.StatusBadRequest, errInvalidParams, err.Error())
+		return
+	}
+	sources := robotEventSources(robot)
+	if req.URL.Query().Get(":device") != "" {
+		_, device, ok := a.deviceForV2(res, req)
+		if !ok {
+			return
+		}
+		eventer, ok := device.(gobot.Eventer)
+		if !ok {
+			writeErrorV2(res, http.StatusNotFound, errNotFound, "No Events found for the device "+device.Name())
+			return
+		}
+		sources = []eventSource{{device: device.Name(), eventer: eventer}}
+	}
+	a.streamEvents(robot.Name, sources, req.URL.Query()["event"], func(event JSONEvent) interface{} {
+		return jsonEventV2{
+			Robot:  event.Robot,
+			Device: event.Device,
+			Name:   event.Event,
+			Data:   event.Data,
+			Time:   event.Time,
+		}
+	}, res, req)
+}
+
+// robotForV2 returns the robot of the route, or answers 404 Not Found
+func (a *API) robotForV2(res http.ResponseWriter, req *http.Request) (*gobot.Robot, bool) {
+	name := req.URL.Query().Get(":robot")
+	robot := a.gobot.Robot(name)
+	if robot == nil {
+		writeErrorV2(res, http.StatusNotFound, errNotFound, "No Robot found with the name "+name)
+		return nil, false
+	}
+	return robot, true
+}
+
+// deviceForV2 returns the robot and device of the route, or answers 404 Not
+// Found
+func (a *API) deviceForV2(res http.ResponseWriter, req *http.Request) (*gobot.Robot, gobot.Device, bool) {
+	robot, ok := a.robotForV2(res, req)
+	if !ok {
+		return nil, nil, false
+	}
+	name := req.URL.Query().Get(":device")
+	device := robot.Device(name)
+	if device == nil {
+		writeErrorV2(res, http.StatusNotFound, errNotFound, "No Device found with the name "+name)
+		return nil, nil, false
+	}
+	return robot, device, true
+}
+
+// commanderForV2 returns the device, robot or Gobot of the route, or answers
+// 404 Not Found
+func (a *API) commanderForV2(res http.ResponseWriter, req *http.Request) (gobot.Commander, bool) {
+	if req.URL.Query().Get(":device") != "" {
+		_, device, ok := a.deviceForV2(res, req)
+		if !ok {
+			return nil, false
+		}
+		commander, ok := device.(gobot.Commander)
+		if !ok {
+			writeErrorV2(res, http.StatusNotFound, errNotFound, "No Commands found for the device "+device.Name())
+		}
+		return commander, ok
+	}
+	if req.URL.Query().Get(":robot") != "" {
+		robot, ok := a.robotForV2(res, req)
+		if !ok {
+			return nil, false
+		}
+		return robot, true
+	}
+	return a.gobot, true
+}
+
+// newJSONRobotV2 returns a jsonRobotV2 given a robot
+func newJSONRobotV2(robot *gobot.Robot) jsonRobotV2 {
+	r := jsonRobotV2{
+		Name:        robot.Name,
+		Tags:        robot.CurrentTags(),
+		Commands:    newJSONCommandsV2(robot),
+		Connections: []*gobot.JSONConnection{},
+		Devices:     []jsonDeviceV2{},
+	}
+	if r.Tags == nil {
+		r.Tags = gobot.Tags{}
+	}
+	robot.Connections().Each(func(c gobot.Connection) {
+		r.Connections = append(r.Connections, gobot.NewJSONConnection(c))
+	})
+	robot.Devices().Each(func(d gobot.Device) {
+		r.Devices = append(r.Devices, newJSONDeviceV2(robot, d))
+	})
+	return r
+}
+
+// newJSONDeviceV2 returns a jsonDeviceV2 given a device of robot
+func newJSONDeviceV2(robot *gobot.Robot, device gobot.Device) jsonDeviceV2 {
+	j := gobot.NewJSONDevice(device)
+	d := jsonDeviceV2{
+		Name:       j.Name,
+		Driver:     j.Driver,
+		Connection: j.Connection,
+		Disabled:   robot.DeviceDisabled(j.Name),
+		Commands:   []jsonCommandV2{},
+		Events:     []string{},
+	}
+	if pinner, ok := device.(gobot.Pinner); ok {
+		d.Pin = pinner.Pin()
+	}
+	if commander, ok := device.(gobot.Commander); ok {
+		d.Commands = newJSONCommandsV2(commander)
+	}
+	if eventer, ok := device.(gobot.Eventer); ok {
+		for name := range eventer.Events() {
+			d.Events = append(d.Events, name)
+		}
+		sort.Strings(d.Events)
+	}
+	return d
+}
+
+// newJSONCommandsV2 returns the commands of c sorted by name
+func newJSONCommandsV2(c gobot.Commander) []jsonCommandV2 {
+	commands := []jsonCommandV2{}
+	for name := range c.Commands() {
+		params := c.Params(name)
+		if params == nil {
+			params = []gobot.Param{}
+		}
+		commands = append(commands, jsonCommandV2{Name: name, Params: params})
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+// writeJSONV2 writes j as JSON in response, with status
+func writeJSONV2(res http.ResponseWriter, status int, j interface{}) {
+	data, _ := json.Marshal(j)
+	res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res.WriteHeader(status)
+	res.Write(data)
+}
+
+// writeErrorV2 writes a jsonErrorV2 in response, with status
+func writeErrorV2(res http.ResponseWriter, status int, code string, message string) {
+	writeJSONV2(res, status, map[string]interface{}{"error": jsonErrorV2{Code: code, Message: message}})
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// requestV2 makes a request to a and returns the status and JSON body of
+// the response
+func requestV2(a *API, method string, url string, body string) (int, map[string]interface{}) {
+	request, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	var j map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&j)
+	return response.Code, j
+}
+
+func TestRobotsV2(t *testing.T) {
+	a := initTestAPI()
+	a.gobot.Robot("Robot2").Tags = gobot.Tags{"zone": "lab"}
+
+	code, body := requestV2(a, "GET", "/api/v2/robots?tag=zone=lab", "")
+	gobot.Assert(t, code, 200)
+	robots := body["robots"].([]interface{})
+	gobot.Assert(t, len(robots), 1)
+	robot := robots[0].(map[string]interface{})
+	gobot.Assert(t, robot["name"], "Robot2")
+	gobot.Assert(t, robot["tags"], map[string]interface{}{"zone": "lab"})
+	gobot.Assert(t, robot["commands"], []interface{}{
+		map[string]interface{}{"name": "robotTestFunction", "params": []interface{}{}},
+	})
+	gobot.Assert(t, len(robot["connections"].([]interface{})), 3)
+	gobot.Assert(t, robot["devices"].([]interface{})[0], map[string]interface{}{
+		"name":       "Device1",
+		"driver":     "*api.testDriver",
+		"connection": "Connection1",
+		"pin":        "0",
+		"disabled":   false,
+		"commands": []interface{}{
+			map[string]interface{}{"name": "DriverCommand", "params": []interface{}{}},
+			map[string]interface{}{"name": "TestDriverCommand", "params": []interface{}{}},
+		},
+		"events": []interface{}{},
+	})
+
+	code, body = requestV2(a, "GET", "/api/v2/robots/Robot1/devices/Device2", "")
+	gobot.Assert(t, code, 200)
+	gobot.Assert(t, body["name"], "Device2")
+
+	code, body = requestV2(a, "GET", "/api/v2/robots/Robot1/devices", "")
+	gobot.Assert(t, code, 200)
+	gobot.Assert(t, len(body["devices"].([]interface{})), 3)
+
+	code, body = requestV2(a, "GET", "/api/v2/commands", "")
+	gobot.Assert(t, code, 200)
+	gobot.Assert(t, body["commands"], []interface{}{
+		map[string]interface{}{"name": "TestFunction", "params": []interface{}{}},
+	})
+
+	code, body = requestV2(a, "GET", "/api/v2/robots/UnknownRobot1", "")
+	gobot.Assert(t, code, 404)
+	gobot.Assert(t, body["error"], map[string]interface{}{
+		"code":    "not_found",
+		"message": "No Robot found with the name UnknownRobot1",
+	})
+
+	code, _ = requestV2(a, "GET", "/api/v2/robots/Robot1/devices/UnknownDevice1/commands", "")
+	gobot.Assert(t, code, 404)
+
+	code, body = requestV2(a, "GET", "/api/v2/robots?tag=zone", "")
+	gobot.Assert(t, code, 400)
+	gobot.Assert(t, body["error"].(map[string]interface{})["code"], "invalid_params")
+}
+
+func TestExecuteCommandV2(t *testing.T) {
+	a := initTestAPI()
+	robot := a.gobot.Robot("Robot1")
+	robot.AddCommandWithParams("drive", []gobot.Param{
+		{Name: "speed", Type: gobot.ParamInt, Required: true, Min: 0, Max: 10},
+	}, func(params map[string]interface{}) interface{} {
+		if params["speed"] == 0 {
+			return errors.New("stalled")
+		}
+		return params["speed"]
+	})
+
+	code, body := requestV2(a, "POST", "/api/v2/robots/Robot1/commands/drive", `{"speed": "5"}`)
+	gobot.Assert(t, code, 200)
+	gobot.Assert(t, body, map[string]interface{}{"result": 5.0})
+
+	code, body = requestV2(a, "POST", "/api/v2/robots/Robot1/commands/drive", `{"speed": 11}`)
+	gobot.Assert(t, code, 400)
+	gobot.Assert(t, body["error"], map[string]interface{}{
+		"code":    "invalid_params",
+		"message": `Parameter "speed": 11 is out of range 0-10`,
+	})
+
+	code, body = requestV2(a, "POST", "/api/v2/robots/Robot1/commands/drive", `{"speed":`)
+	gobot.Assert(t, code, 400)
+	gobot.Assert(t, body["error"].(map[string]interface{})["code"], "invalid_body")
+
+	code, body = requestV2(a, "POST", "/api/v2/robots/Robot1/commands/drive", `{"speed": 0}`)
+	gobot.Assert(t, code, 500)
+	gobot.Assert(t, body["error"], map[string]interface{}{"code": "command_failed", "message": "stalled"})
+
+	code, body = requestV2(a, "POST", "/api/v2/robots/Robot1/commands/fly", `{}`)
+	gobot.Assert(t, code, 404)
+	gobot.Assert(t, body["error"].(map[string]interface{})["message"], "No Command found with the name fly")
+
+	code, body = requestV2(a, "POST", "/api/v2/commands/TestFunction", `{"message": "Beep Boop"}`)
+	gobot.Assert(t, code, 200)
+	gobot.Assert(t, body["result"], "hey Beep Boop")
+
+	code, body = requestV2(a, "POST", "/api/v2/robots/Robot1/devices/Device1/commands/TestDriverCommand?async=true",
+		`{"name": "human"}`)
+	gobot.Assert(t, code, 202)
+	id := body["job"].(map[string]interface{})["id"].(string)
+	code, body = requestV2(a, "GET", "/api/v2/robots/Robot1/devices/Device1/jobs/"+id, "")
+	gobot.Assert(t, code, 200)
+	gobot.Assert(t, body["job"].(map[string]interface{})["id"], id)
+	code, _ = requestV2(a, "GET", "/api/v2/robots/Robot1/jobs/"+id, "")
+	gobot.Assert(t, code, 404)
+}
+
+func TestEventsV2(t *testing.T) {
+	a := initTestAPI()
+	server := httptest.NewServer(a)
+	defer server.Close()
+	robot := a.gobot.Robot("Robot1")
+	robot.AddEvent("moved")
+
+	res, err := http.Get(server.URL + "/api/v2/robots/Robot1/events?event=moved")
+	gobot.Assert(t, err, nil)
+	defer res.Body.Close()
+	time.Sleep(20 * time.Millisecond)
+	gobot.Publish(robot.Event("moved"), 2)
+	reader := bufio.NewReader(res.Body)
+	line, _ := reader.ReadString('\n')
+	gobot.Assert(t, line, "event: moved\n")
+	line, _ = reader.ReadString('\n')
+	event := map[string]interface{}{}
+	json.Unmarshal([]byte(line[len("data: "):]), &event)
+	gobot.Assert(t, event["robot"], "Robot1")
+	gobot.Assert(t, event["name"], "moved")
+	gobot.Assert(t, event["data"], 2.0)
+	_, err = time.Parse(time.RFC3339, event["time"].(string))
+	gobot.Assert(t, err, nil)
+
+	code, body := requestV2(a, "GET", "/api/v2/robots/Robot1/devices/Device1/events", "")
+	gobot.Assert(t, code, 404)
+	gobot.Assert(t, body["error"].(map[string]interface{})["message"], "No Events found for the device Device1")
+}
@@ -0,0 +1,345 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// maxWebhookRetries bounds the retries of a Webhook
+const maxWebhookRetries = 10
+
+// Webhook posts the events of the robots matching its filters to its URL,
+// see Webhooks.
+type Webhook struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Robots, Devices and Events are path.Match patterns of the names of the
+	// robots, devices and events posted. Every name matches if empty, the
+	// events of the robots themselves do not match when Devices is set.
+	Robots  []string `json:"robots,omitempty"`
+	Devices []string `json:"devices,omitempty"`
+	Events  []string `json:"events,omitempty"`
+	// Secret signs the body of each request with HMAC-SHA256, in the
+	// X-Gobot-Signature header as "sha256=" and the hex signature. It is
+	// never listed.
+	Secret string `json:"secret,omitempty"`
+	// Retries is how many times a failed request is retried, waiting Backoff
+	// before the first retry and twice as long before each next one, 1s when
+	// zero. Backoff is in nanoseconds in JSON.
+	Retries int           `json:"retries"`
+	Backoff time.Duration `json:"backoff"`
+
+	queue chan []byte
+	done  chan struct{}
+}
+
+// Webhooks posts the events of the robots of an api as JSON to the webhooks
+// registered with it, see API.UseWebhooks.
+type Webhooks struct {
+	// Transport sends the requests, http.DefaultTransport when nil
+	Transport http.RoundTripper
+	// Timeout bounds each request, 10s when zero
+	Timeout time.Duration
+
+	hooks map[string]*Webhook
+	order []string
+	stops []func()
+	mutex sync.Mutex
+}
+
+// Add validates and registers hook, with a new ID.
+func (w *Webhooks) Add(hook *Webhook) error {
+	u, err := url.Parse(hook.URL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("Invalid webhook URL " + hook.URL)
+	}
+	for _, patterns := range [][]string{hook.Robots, hook.Devices, hook.Events} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return err
+			}
+		}
+	}
+	if hook.Retries < 0 || hook.Retries > maxWebhookRetries {
+		return fmt.Errorf("Retries must be between 0 and %v", maxWebhookRetries)
+	}
+	if hook.Backoff <= 0 {
+		hook.Backoff = time.Second
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	hook.ID = hex.EncodeToString(id)
+	hook.queue = make(chan []byte, EventBuffer)
+	hook.done = make(chan struct{})
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.hooks == nil {
+		w.hooks = make(map[string]*Webhook)
+	}
+	w.hooks[hook.ID] = hook
+	w.order = append(w.order, hook.ID)
+	go w.deliver(hook)
+	return nil
+}
+
+// Remove unregisters the webhook with id, returns false if there is none.
+func (w *Webhooks) Remove(id string) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	hook, ok := w.hooks[id]
+	if !ok {
+		return false
+	}
+	close(hook.done)
+	delete(w.hooks, id)
+	for i, hookID := range w.order {
+		if hookID == id {
+			w.order = append(w.order[:i], w.order[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Webhook returns the webhook with id, nil if there is none.
+func (w *Webhooks) Webhook(id string) *Webhook {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.hooks[id]
+}
+
+// Webhooks returns the registered webhooks, oldest first.
+func (w *Webhooks) Webhooks() []*Webhook {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	hooks := []*Webhook{}
+	for _, id := range w.order {
+		hooks = append(hooks, w.hooks[id])
+	}
+	return hooks
+}
+
+// attach posts the events of the robots of g to the webhooks, until detach
+func (w *Webhooks) attach(g *gobot.Gobot) {
+	stops := []func(){}
+	g.Robots().Each(func(robot *gobot.Robot) {
+		for _, source := range robotEventSources(robot) {
+			robot, device := robot.Name, source.device
+			stop, err := source.eventer.OnPattern("*", func(name string, data interface{}) {
+				w.publish(jsonEventV2{Robot: robot, Device: device, Name: name, Data: data, Time: time.Now()})
+			})
+			if err == nil {
+				stops = append(stops, stop)
+			}
+		}
+	})
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.stops = append(w.stops, stops...)
+}
+
+// detach stops posting events to the webhooks
+func (w *Webhooks) detach() {
+	w.mutex.Lock()
+	stops := w.stops
+	w.stops = nil
+	w.mutex.Unlock()
+	for _, stop := range stops {
+		stop()
+	}
+}
+
+// publish queues event for the webhooks it matches
+func (w *Webhooks) publish(event jsonEventV2) {
+	if err, ok := event.Data.(error); ok {
+		event.Data = err.Error()
+	}
+	var body []byte
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for _, id := range w.order {
+		hook := w.hooks[id]
+		if !hook.matches(event) {
+			continue
+		}
+		if body == nil {
+			var err error
+			if body, err = json.Marshal(event); err != nil {
+				gobot.Log(gobot.ErrorLevel, err.Error(), gobot.Fields{"event": event.Name})
+				return
+			}
+		}
+		select {
+		case hook.queue <- body:
+		default:
+			gobot.Log(gobot.WarnLevel, "Dropping event for slow webhook", gobot.Fields{"webhook": hook.URL, "event": event.Name})
+		}
+	}
+}
+
+// matches returns true if the webhook posts event
+func (h *Webhook) matches(event jsonEventV2) bool {
+	if event.Device == "" && len(h.Devices) > 0 {
+		return false
+	}
+	return matchName(h.Robots, event.Robot) &&
+		(event.Device == "" || matchName(h.Devices, event.Device)) &&
+		matchName(h.Events, event.Name)
+}
+
+// deliver posts the events queued for hook in order, until it is removed
+func (w *Webhooks) deliver(hook *Webhook) {
+	timeout := w.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	client := &http.Client{Transport: w.Transport, Timeout: timeout}
+	deliveries := 0
+	for {
+		select {
+		case <-hook.done:
+			return
+		case body := <-hook.queue:
+			deliveries++
+			delivery := fmt.Sprintf("%v-%v", hook.ID, deliveries)
+			backoff := hook.Backoff
+			for attempt := 0; ; attempt++ {
+				err := hook.post(client, delivery, body)
+				if err == nil {
+					break
+				}
+				if attempt == hook.Retries {
+					gobot.Log(gobot.ErrorLevel, "Webhook failed", gobot.Fields{"webhook": hook.URL, "error": err.Error()})
+					break
+				}
+				select {
+				case <-hook.done:
+					return
+				case <-time.After(backoff):
+				}
+				backoff *= 2
+			}
+		}
+	}
+}
+
+// post sends body to the URL of the webhook, signed with its secret
+func (h *Webhook) post(client *http.Client, delivery string, body []byte) error {
+	req, err := http.NewRequest("POST", h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("X-Gobot-Delivery", delivery)
+	if h.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(h.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Gobot-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return errors.New("Webhook answered " + res.Status)
+	}
+	return nil
+}
+
+// listed returns hook without its secret
+func (h *Webhook) listed() *Webhook {
+	listed := *h
+	listed.Secret = ""
+	return &listed
+}
+
+// UseWebhooks makes the api post the events of its robots as JSON to the
+// webhooks of w, so serverless functions and other systems can react to
+// them without holding a stream open. Webhooks are registered by POSTing
+// them to /api/webhooks, listed there, and removed with DELETE
+// /api/webhooks/:id. Each event is posted as the v2 events are streamed,
+// with its robot, device, name, data and time, in order for each webhook.
+// UseWebhooks must be called before the api is started, with the robots of
+// its Gobot.
+func (a *API) UseWebhooks(w *Webhooks) {
+	a.webhooks = w
+}
+
+// startWebhooks adds the routes of the webhooks of the api, if any, and
+// posts the events of its robots to them
+func (a *API) startWebhooks() {
+	if a.webhooks == nil {
+		return
+	}
+	a.Get("/api/webhooks", a.listWebhooks)
+	a.Post("/api/webhooks", a.addWebhook)
+	a.Get("/api/webhooks/:id", a.webhook)
+	a.Delete("/api/webhooks/:id", a.removeWebhook)
+	a.webhooks.attach(a.gobot)
+}
+
+// listWebhooks returns the webhooks route handler.
+// Writes JSON with the registered webhooks
+func (a *API) listWebhooks(res http.ResponseWriter, req *http.Request) {
+	hooks := []*Webhook{}
+	for _, hook := range a.webhooks.Webhooks() {
+		hooks = append(hooks, hook.listed())
+	}
+	a.writeJSON(map[string]interface{}{"webhooks": hooks}, res)
+}
+
+// addWebhook returns the add webhook route handler.
+// Registers the webhook of the JSON body and writes JSON with it
+func (a *API) addWebhook(res http.ResponseWriter, req *http.Request) {
+	hook := &Webhook{}
+	if err := json.NewDecoder(req.Body).Decode(hook); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	if err := a.webhooks.Add(hook); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	a.writeJSON(map[string]interface{}{"webhook": hook.listed()}, res)
+}
+
+// webhook returns the webhook route handler.
+// Writes JSON with the webhook
+func (a *API) webhook(res http.ResponseWriter, req *http.Request) {
+	if hook := a.webhooks.Webhook(req.URL.Query().Get(":id")); hook != nil {
+		a.writeJSON(map[string]interface{}{"webhook": hook.listed()}, res)
+	} else {
+		a.writeJSON(map[string]interface{}{"error": "No Webhook found with the id " + req.URL.Query().Get(":id")}, res)
+	}
+}
+
+// removeWebhook returns the remove webhook route handler.
+// Unregisters the webhook and writes JSON with it
+func (a *API) removeWebhook(res http.ResponseWriter, req *http.Request) {
+	hook := a.webhooks.Webhook(req.URL.Query().Get(":id"))
+	if hook == nil || !a.webhooks.Remove(hook.ID) {
+		a.writeJSON(map[string]interface{}{"error": "No Webhook found with the id " + req.URL.Query().Get(":id")}, res)
+		return
+	}
+	a.writeJSON(map[string]interface{}{"webhook": hook.listed()}, res)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestUseWebhooks(t *testing.T) {
+	type delivery struct {
+		signature string
+		body      []byte
+	}
+	deliveries := make(chan delivery, 10)
+	failures := 1
+	receiver := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		if failures > 0 {
+			failures--
+			res.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		deliveries <- delivery{signature: req.Header.Get("X-Gobot-Signature"), body: body}
+	}))
+	defer receiver.Close()
+
+	log.SetOutput(NullReadWriteCloser{})
+	g := gobot.NewGobot()
+	robot := newTestRobot("Robot1")
+	robot.AddEvent("ping")
+	robot.AddEvent("pong")
+	g.AddRobot(robot)
+	a := NewAPI(g)
+	a.start = func(m *API) error { return nil }
+	a.UseWebhooks(&Webhooks{})
+	a.Start()
+	defer a.Stop()
+
+	request := func(method string, url string, body string) map[string]interface{} {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+		var v map[string]interface{}
+		json.NewDecoder(res.Body).Decode(&v)
+		return v
+	}
+
+	hook := request("POST", "/api/webhooks",
+		`{"url":"`+receiver.URL+`","events":["ping"],"secret":"s3cret","retries":2,"backoff":1000000}`)["webhook"].(map[string]interface{})
+	gobot.Assert(t, hook["secret"], nil)
+	id := hook["id"].(string)
+	gobot.Assert(t, len(request("GET", "/api/webhooks", "")["webhooks"].([]interface{})), 1)
+	gobot.Assert(t, request("GET", "/api/webhooks/"+id, "")["webhook"].(map[string]interface{})["url"], receiver.URL)
+	gobot.Assert(t, request("POST", "/api/webhooks", `{"url":"ftp://example.com"}`)["error"], "Invalid webhook URL ftp://example.com")
+
+	gobot.Publish(robot.Event("pong"), 0)
+	gobot.Publish(robot.Event("ping"), 1)
+	select {
+	case d := <-deliveries:
+		var event jsonEventV2
+		json.Unmarshal(d.body, &event)
+		gobot.Assert(t, event.Robot, "Robot1")
+		gobot.Assert(t, event.Name, "ping")
+		gobot.Assert(t, event.Data, 1.0)
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write(d.body)
+		gobot.Assert(t, d.signature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	case <-time.After(5 * time.Second):
+		t.Fatal("No webhook delivery")
+	}
+	gobot.Assert(t, failures, 0)
+
+	gobot.Assert(t, request("DELETE", "/api/webhooks/"+id, "")["webhook"].(map[string]interface{})["id"], id)
+	gobot.Assert(t, request("DELETE", "/api/webhooks/"+id, "")["error"], "No Webhook found with the id "+id)
+	gobot.Publish(robot.Event("ping"), 2)
+	select {
+	case <-deliveries:
+		t.Fatal("Removed webhook delivered")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWebhookMatches(t *testing.T) {
+	hook := &Webhook{Robots: []string{"bot*"}, Devices: []string{"led"}}
+	gobot.Assert(t, hook.matches(jsonEventV2{Robot: "bot1", Device: "led", Name: "on"}), true)
+	gobot.Assert(t, hook.matches(jsonEventV2{Robot: "bot1", Device: "motor", Name: "on"}), false)
+	gobot.Assert(t, hook.matches(jsonEventV2{Robot: "bot1", Name: "on"}), false)
+	gobot.Assert(t, hook.matches(jsonEventV2{Robot: "rover", Device: "led", Name: "on"}), false)
+	gobot.Assert(t, (&Webhook{}).matches(jsonEventV2{Robot: "rover", Name: "on"}), true)
+}
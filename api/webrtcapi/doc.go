@@ -0,0 +1,27 @@
+/*
+Package webrtcapi negotiates WebRTC data channels with the peers of the robots
+of a Gobot over the api, for teleoperation: joystick input is streamed to a
+robot and its telemetry back with far lower latency than the REST API, and
+through NATs, see Teleop.
+
+Example:
+
+	gbot := gobot.NewGobot()
+	server := api.NewAPI(gbot)
+	teleop := webrtcapi.NewTeleop(gbot)
+	teleop.ICEServers = []webrtc.ICEServer{{URLs: []string{"stun:stun.l.google.com:19302"}}}
+	teleop.Handle(server)
+	server.Start()
+
+In the browser:
+
+	const pc = new RTCPeerConnection({iceServers: [{urls: "stun:stun.l.google.com:19302"}]});
+	const dc = pc.createDataChannel("teleop", {ordered: false, maxRetransmits: 0});
+	dc.onmessage = (e) => console.log(JSON.parse(e.data));
+	await pc.setLocalDescription(await pc.createOffer());
+	// wait for the ICE candidates, then
+	const res = await fetch("/api/robots/rover/webrtc", {method: "POST", body: JSON.stringify(pc.localDescription)});
+	await pc.setRemoteDescription(await res.json());
+	dc.send(JSON.stringify({device: "wheels", command: "Drive", params: {x: 0.5, y: 0}}));
+*/
+package webrtcapi
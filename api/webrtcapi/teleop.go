@@ -0,0 +1,354 @@
+package webrtcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/api"
+	"github.com/pion/webrtc/v3"
+)
+
+// Route is the route of the API receiving the offers of the peers of a robot
+const Route = "/api/robots/:robot/webrtc"
+
+// gatherTimeout bounds the gathering of the ICE candidates of an answer
+var gatherTimeout = 10 * time.Second
+
+// Teleop negotiates WebRTC data channels with the peers of the robots of a
+// Gobot, so joysticks and dashboards can stream commands to a robot and
+// receive its telemetry with far lower latency than the REST API, through
+// NATs.
+//
+// A peer POSTs its offer, a JSON session description with its ICE
+// candidates, to Route and receives the answer of the robot. Every message
+// received on its data channels is a command of the robot or one of its
+// devices, as JSON:
+//
+//	{"id": 1, "device": "wheels", "command": "Drive", "params": {"x": 0.5}}
+//
+// Its result is sent back as {"id": 1, "result": ...} or
+// {"id": 1, "error": "..."}, unless it has no id, which suits the high rate
+// input of a joystick. The commands are executed through the api as the
+// client of the offer would with the REST API, so they are authenticated,
+// authorized by UseRBAC, waited for by Drain and recorded by UseAudit the
+// same way. Offers are answered with 503 Service Unavailable while the api
+// drains. The events of the robot and its devices are sent on
+// every open data channel as {"robot", "device", "event", "data"}, filtered
+// by name with the "event" query parameters of the offer, e.g.
+// ?event=imu_*. Peers wanting the lowest latency open their data channel
+// unordered and without retransmits.
+type Teleop struct {
+	// ICEServers are the STUN and TURN servers used for NAT traversal
+	ICEServers []webrtc.ICEServer
+
+	gobot *gobot.Gobot
+	api   *api.API
+	peers map[*webrtc.PeerConnection]*session
+	mutex sync.Mutex
+}
+
+// session is the data channels of a peer of a robot
+type session struct {
+	robot *gobot.Robot
+	// api executes the commands as requested by offer
+	api      *api.API
+	offer    *http.Request
+	channels []*webrtc.DataChannel
+	stops    []func()
+	closed   chan struct{}
+	mutex    sync.Mutex
+}
+
+// message is a command received on a data channel
+type message struct {
+	ID      interface{}            `json:"id"`
+	Device  string                 `json:"device"`
+	Command string                 `json:"command"`
+	Params  map[string]interface{} `json:"params"`
+}
+
+// NewTeleop returns a new Teleop of the robots of g.
+func NewTeleop(g *gobot.Gobot) *Teleop {
+	return &Teleop{
+		gobot: g,
+		peers: make(map[*webrtc.PeerConnection]*session),
+	}
+}
+
+// Handle adds Route to a, so the API signals the data channels.
+func (t *Teleop) Handle(a *api.API) {
+	t.api = a
+	a.Post(Route, t.offer)
+}
+
+// Close closes the connections of every peer.
+func (t *Teleop) Close() error {
+	t.mutex.Lock()
+	peers := t.peers
+	t.peers = make(map[*webrtc.PeerConnection]*session)
+	t.mutex.Unlock()
+	for pc, s := range peers {
+		s.close()
+		pc.Close()
+	}
+	return nil
+}
+
+// offer returns the offer route handler.
+// Answers the offer of a peer of a robot, once the ICE candidates of the
+// answer are gathered.
+func (t *Teleop) offer(res http.ResponseWriter, req *http.Request) {
+	if t.api.Draining() {
+		http.Error(res, "Draining", http.StatusServiceUnavailable)
+		return
+	}
+	robot := t.gobot.Robot(req.URL.Query().Get(":robot"))
+	if robot == nil {
+		http.Error(res, "No Robot found with the name "+req.URL.Query().Get(":robot"), http.StatusNotFound)
+		return
+	}
+	filters := req.URL.Query()["event"]
+	if err := api.CheckEventFilters(filters); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var offer webrtc.SessionDescription
+	if err := json.NewDecoder(req.Body).Decode(&offer); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{ICEServers: t.ICEServers})
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s := &session{
+		robot:  robot,
+		api:    t.api,
+		offer:  req.Clone(context.Background()),
+		closed: make(chan struct{}),
+	}
+	t.mutex.Lock()
+	t.peers[pc] = s
+	t.mutex.Unlock()
+	pc.OnDataChannel(s.attach)
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		gobot.Log(gobot.InfoLevel, "WebRTC peer "+state.String(), gobot.Fields{"robot": robot.Name})
+		if state == webrtc.PeerConnectionStateFailed || state == webrtc.PeerConnectionStateClosed {
+			t.remove(pc)
+		}
+	})
+
+	answer, err := t.answer(pc, offer)
+	if err != nil {
+		t.remove(pc)
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.subscribe(filters)
+	res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(res).Encode(answer)
+}
+
+// answer returns the answer of pc to offer, with its ICE candidates
+func (t *Teleop) answer(pc *webrtc.PeerConnection, offer webrtc.SessionDescription) (*webrtc.SessionDescription, error) {
+	if err := pc.SetRemoteDescription(offer); err != nil {
+		return nil, err
+	}
+	answer, err := pc.CreateAnswer(nil)
+	if err != nil {
+		return nil, err
+	}
+	gathered := webrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(answer); err != nil {
+		return nil, err
+	}
+	select {
+	case <-gathered:
+	case <-time.After(gatherTimeout):
+		gobot.Log(gobot.WarnLevel, "Answering WebRTC offer before all ICE candidates are gathered", nil)
+	}
+	return pc.LocalDescription(), nil
+}
+
+// remove closes the connection of a peer and forgets it
+func (t *Teleop) remove(pc *webrtc.PeerConnection) {
+	t.mutex.Lock()
+	s, ok := t.peers[pc]
+	delete(t.peers, pc)
+	t.mutex.Unlock()
+	if ok {
+		s.close()
+		pc.Close()
+	}
+}
+
+// attach executes the commands received on dc and sends the events on it
+// once it is open
+func (s *session) attach(dc *webrtc.DataChannel) {
+	dc.OnOpen(func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.channels = append(s.channels, dc)
+	})
+	dc.OnClose(func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		for i, c := range s.channels {
+			if c == dc {
+				s.channels = append(s.channels[:i], s.channels[i+1:]...)
+				break
+			}
+		}
+	})
+	dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+		if reply := s.handle(msg.Data); reply != nil {
+			dc.Send(reply)
+		}
+	})
+}
+
+// handle executes the command of data and returns the reply to send, nil if
+// the command has no id
+func (s *session) handle(data []byte) []byte {
+	var m message
+	if err := json.Unmarshal(data, &m); err != nil {
+		reply, _ := json.Marshal(map[string]interface{}{"error": err.Error()})
+		return reply
+	}
+	result := s.execute(m)
+	if m.ID == nil {
+		if err, ok := result.(error); ok {
+			gobot.Log(gobot.WarnLevel, err.Error(), gobot.Fields{"robot": s.robot.Name, "command": m.Command})
+		}
+		return nil
+	}
+	body := map[string]interface{}{"id": m.ID, "result": result}
+	if err, ok := result.(error); ok {
+		body = map[string]interface{}{"id": m.ID, "error": err.Error()}
+	}
+	reply, err := json.Marshal(body)
+	if err != nil {
+		reply, _ = json.Marshal(map[string]interface{}{"id": m.ID, "error": err.Error()})
+	}
+	return reply
+}
+
+// execute executes the command of m through the api, as its REST route
+// requested by the client of the offer, and returns its result
+func (s *session) execute(m message) interface{} {
+	if m.Params == nil {
+		m.Params = make(map[string]interface{})
+	}
+	body, err := json.Marshal(m.Params)
+	if err != nil {
+		return err
+	}
+	path := "/api/robots/" + url.PathEscape(s.robot.Name)
+	if m.Device != "" {
+		path += "/devices/" + url.PathEscape(m.Device)
+	}
+	path += "/commands/" + url.PathEscape(m.Command)
+	req, err := http.NewRequest("POST", path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = s.offer.Header.Clone()
+	req.Header.Del("Content-Length")
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr, req.TLS = s.offer.RemoteAddr, s.offer.TLS
+	if token := s.offer.URL.Query().Get("access_token"); token != "" {
+		req.URL.RawQuery = url.Values{"access_token": {token}}.Encode()
+	}
+
+	res := httptest.NewRecorder()
+	s.api.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		return errors.New(strings.TrimSpace(res.Body.String()))
+	}
+	var reply struct {
+		Result interface{} `json:"result"`
+		Error  string      `json:"error"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &reply); err != nil {
+		return err
+	}
+	if reply.Error != "" {
+		return errors.New(reply.Error)
+	}
+	return reply.Result
+}
+
+// subscribe sends the events of the robot and its devices matching filters
+// on the open data channels until the session is closed
+func (s *session) subscribe(filters []string) {
+	send := func(device string) func(string, interface{}) {
+		return func(name string, data interface{}) {
+			select {
+			case <-s.closed:
+				return
+			default:
+			}
+			if !api.MatchEvent(filters, name) {
+				return
+			}
+			event, err := json.Marshal(api.NewJSONEvent(s.robot.Name, device, name, data))
+			if err != nil {
+				gobot.Log(gobot.ErrorLevel, err.Error(), gobot.Fields{"event": name})
+				return
+			}
+			s.mutex.Lock()
+			channels := append([]*webrtc.DataChannel{}, s.channels...)
+			s.mutex.Unlock()
+			for _, dc := range channels {
+				dc.Send(event)
+			}
+		}
+	}
+	eventers := map[string]gobot.Eventer{"": s.robot}
+	s.robot.Devices().Each(func(device gobot.Device) {
+		if eventer, ok := device.(gobot.Eventer); ok {
+			eventers[device.Name()] = eventer
+		}
+	})
+	for device, eventer := range eventers {
+		stop, err := eventer.OnPattern("*", send(device))
+		if err != nil {
+			continue
+		}
+		s.mutex.Lock()
+		select {
+		case <-s.closed:
+			// closed while subscribing
+			stop()
+		default:
+			s.stops = append(s.stops, stop)
+		}
+		s.mutex.Unlock()
+	}
+}
+
+// close stops sending events and unsubscribes from them
+func (s *session) close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	select {
+	case <-s.closed:
+	default:
+		close(s.closed)
+	}
+	for _, stop := range s.stops {
+		stop()
+	}
+	s.stops = nil
+}
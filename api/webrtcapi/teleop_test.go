@@ -0,0 +1,130 @@
+package webrtcapi
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/api"
+)
+
+func init() {
+	log.SetOutput(&bytes.Buffer{})
+}
+
+type testAdaptor struct {
+	name string
+}
+
+func (t *testAdaptor) Connect() (errs []error)  { return }
+func (t *testAdaptor) Finalize() (errs []error) { return }
+func (t *testAdaptor) Name() string             { return t.name }
+
+type testDriver struct {
+	name       string
+	connection gobot.Connection
+	gobot.Commander
+}
+
+func (t *testDriver) Start() (errs []error)        { return }
+func (t *testDriver) Halt() (errs []error)         { return }
+func (t *testDriver) Name() string                 { return t.name }
+func (t *testDriver) Connection() gobot.Connection { return t.connection }
+
+// initTestSession returns a session of the robot "rover" offered by offer,
+// along with its api, to start once its middleware is added
+func initTestSession(offer *http.Request) (*session, *api.API, *[]interface{}) {
+	driven := &[]interface{}{}
+	motors := &testAdaptor{name: "motors"}
+	wheels := &testDriver{name: "wheels", connection: motors, Commander: gobot.NewCommander()}
+	wheels.AddCommand("Drive", func(params map[string]interface{}) interface{} {
+		*driven = append(*driven, params["x"])
+		return params["x"]
+	})
+	wheels.AddCommand("Fail", func(params map[string]interface{}) interface{} { return errors.New("stalled") })
+	robot := gobot.NewRobot("rover", []gobot.Connection{motors}, []gobot.Device{wheels})
+	robot.AddCommand("Beep", func(params map[string]interface{}) interface{} { return "beep" })
+	g := gobot.NewGobot()
+	g.AddRobot(robot)
+	a := api.NewAPI(g)
+	a.Port = ""
+	if offer == nil {
+		offer, _ = http.NewRequest("POST", "/api/robots/rover/webrtc", nil)
+	}
+	return &session{robot: robot, api: a, offer: offer, closed: make(chan struct{})}, a, driven
+}
+
+func TestSessionHandle(t *testing.T) {
+	s, a, driven := initTestSession(nil)
+	a.Start()
+	defer a.Stop()
+
+	gobot.Assert(t, string(s.handle([]byte(`{"id": 1, "command": "Beep"}`))), `{"id":1,"result":"beep"}`)
+	gobot.Assert(t, string(s.handle([]byte(`{"id": "a", "device": "wheels", "command": "Fail"}`))),
+		`{"error":"stalled","id":"a"}`)
+	gobot.Assert(t, string(s.handle([]byte(`{"id": 2, "device": "tracks", "command": "Drive"}`))),
+		`{"error":"No Device found with the name tracks","id":2}`)
+	gobot.Assert(t, string(s.handle([]byte(`{"id": 3, "command": "Fly"}`))), `{"error":"Unknown Command","id":3}`)
+	gobot.Assert(t, string(s.handle([]byte(`{`))), `{"error":"unexpected end of JSON input"}`)
+
+	// the commands without an id are not answered
+	gobot.Assert(t, s.handle([]byte(`{"device": "wheels", "command": "Drive", "params": {"x": 0.5}}`)), []byte(nil))
+	gobot.Assert(t, *driven, []interface{}{0.5})
+}
+
+func TestTeleopOffer(t *testing.T) {
+	g := gobot.NewGobot()
+	g.AddRobot(gobot.NewRobot("rover"))
+	a := api.NewAPI(g)
+	NewTeleop(g).Handle(a)
+
+	request, _ := http.NewRequest("POST", "/api/robots/tank/webrtc", bytes.NewBufferString(`{}`))
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 404)
+
+	request, _ = http.NewRequest("POST", "/api/robots/rover/webrtc", bytes.NewBufferString(`{"type":`))
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 400)
+
+	request, _ = http.NewRequest("POST", "/api/robots/rover/webrtc?event=[", bytes.NewBufferString(`{}`))
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 400)
+}
+
+func TestSessionHandleRBAC(t *testing.T) {
+	offer, _ := http.NewRequest("POST", "/api/robots/rover/webrtc", nil)
+	offer.SetBasicAuth("bob", "secret")
+	s, a, driven := initTestSession(offer)
+	a.AddHandler(api.BasicAuth("bob", "secret"))
+	a.UseRBAC(&api.RBAC{
+		Roles:    map[string]*api.Role{"horn": {Commands: []string{"Beep"}}},
+		Bindings: map[string][]string{"bob": {"horn"}},
+	})
+	a.Start()
+	defer a.Stop()
+
+	gobot.Assert(t, string(s.handle([]byte(`{"id": 1, "command": "Beep"}`))), `{"id":1,"result":"beep"}`)
+	gobot.Assert(t, string(s.handle([]byte(`{"id": 2, "device": "wheels", "command": "Drive", "params": {"x": 1}}`))),
+		`{"error":"Forbidden","id":2}`)
+	gobot.Assert(t, len(*driven), 0)
+}
+
+func TestTeleopOfferDraining(t *testing.T) {
+	g := gobot.NewGobot()
+	g.AddRobot(gobot.NewRobot("rover"))
+	a := api.NewAPI(g)
+	NewTeleop(g).Handle(a)
+	a.Drain()
+
+	request, _ := http.NewRequest("POST", "/api/robots/rover/webrtc", bytes.NewBufferString(`{}`))
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobot.Assert(t, response.Code, 503)
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"code.google.com/p/go.net/websocket"
+)
+
+// webSocket is a server side WebSocket connection, which closes its Closed
+// channel once the client goes away
+type webSocket struct {
+	conn   *websocket.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+// serveWebSocket answers the WebSocket handshake of req and calls handler
+// with the connection, which is closed once handler returns. The JSON
+// messages of the client are sent to messages, or ignored if it is nil, and
+// anything else closes the connection. If protocol is not empty, the client
+// must ask for that subprotocol.
+func serveWebSocket(res http.ResponseWriter, req *http.Request, protocol string,
+	messages chan<- []byte, handler func(ws *webSocket)) {
+	if _, ok := res.(http.Hijacker); !ok {
+		http.Error(res, "WebSocket not supported by the server", http.StatusBadRequest)
+		return
+	}
+	websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			if protocol == "" {
+				config.Protocol = nil
+				return nil
+			}
+			for _, p := range config.Protocol {
+				if p == protocol {
+					config.Protocol = []string{protocol}
+					return nil
+				}
+			}
+			return errors.New("WebSocket subprotocol " + protocol + " required")
+		},
+		Handler: func(conn *websocket.Conn) {
+			ws := &webSocket{conn: conn, closed: make(chan struct{})}
+			defer ws.Close()
+			go ws.read(messages)
+			handler(ws)
+		},
+	}.ServeHTTP(res, req)
+}
+
+// headerContains returns true if one of the comma separated values of the
+// header name is value, ignoring case
+func headerContains(header http.Header, name string, value string) bool {
+	for _, v := range strings.Split(header.Get(name), ",") {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Closed returns a channel which is closed once the connection is closed
+func (ws *webSocket) Closed() <-chan struct{} {
+	return ws.closed
+}
+
+// WriteText writes data in a text frame
+func (ws *webSocket) WriteText(data []byte) error {
+	return websocket.Message.Send(ws.conn, string(data))
+}
+
+// Close sends a close frame and closes the connection
+func (ws *webSocket) Close() (err error) {
+	ws.once.Do(func() {
+		close(ws.closed)
+		err = ws.conn.Close()
+	})
+	return
+}
+
+// read reads the messages of the client until it closes the connection,
+// sending them to messages. The payloads of the frames of the client are
+// read as a stream, so the messages may be fragmented across frames.
+func (ws *webSocket) read(messages chan<- []byte) {
+	defer ws.Close()
+	if messages == nil {
+		io.Copy(ioutil.Discard, ws.conn)
+		return
+	}
+	decoder := json.NewDecoder(ws.conn)
+	for {
+		var msg json.RawMessage
+		if err := decoder.Decode(&msg); err != nil {
+			return
+		}
+		select {
+		case messages <- msg:
+		case <-ws.closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package gobot
+
+import "fmt"
+
+// APIServer is the interface which describes the behaviour for a server
+// exposing a Gobot, e.g. the HTTP API of the api package, a gRPC server or a
+// bridge executing commands received over MQTT. Any number of servers can be
+// added to a Gobot with AddAPIServer.
+type APIServer interface {
+	// Attach hands the server the Gobot it exposes.
+	Attach(g *Gobot)
+	// Start starts serving without blocking.
+	Start() error
+	// Stop stops serving.
+	Stop() error
+}
+
+// AddAPIServer attaches s to g. The servers are started by Start once the
+// robots have started, and stopped by Stop before the robots are stopped.
+func (g *Gobot) AddAPIServer(s APIServer) APIServer {
+	s.Attach(g)
+	g.apiServers = append(g.apiServers, s)
+	return s
+}
+
+// APIServers returns the servers added to g.
+func (g *Gobot) APIServers() []APIServer {
+	return append([]APIServer{}, g.apiServers...)
+}
+
+// startAPIServers starts the servers of g in the order they were added
+func (g *Gobot) startAPIServers() (errs []error) {
+	for _, s := range g.apiServers {
+		if err := s.Start(); err != nil {
+			errs = append(errs, fmt.Errorf("API server %T: %v", s, err))
+		}
+	}
+	return
+}
+
+// stopAPIServers stops the servers of g in the reverse order they were added
+func (g *Gobot) stopAPIServers() (errs []error) {
+	for i := len(g.apiServers) - 1; i >= 0; i-- {
+		s := g.apiServers[i]
+		if err := s.Stop(); err != nil {
+			errs = append(errs, fmt.Errorf("API server %T: %v", s, err))
+		}
+	}
+	return
+}
@@ -0,0 +1,50 @@
+package gobot
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+type testAPIServer struct {
+	gobot    *Gobot
+	calls    *[]string
+	name     string
+	startErr error
+}
+
+func (s *testAPIServer) Attach(g *Gobot) { s.gobot = g }
+
+func (s *testAPIServer) Start() error {
+	*s.calls = append(*s.calls, "start "+s.name)
+	return s.startErr
+}
+
+func (s *testAPIServer) Stop() error {
+	*s.calls = append(*s.calls, "stop "+s.name)
+	return nil
+}
+
+func TestGobotAPIServers(t *testing.T) {
+	g := initTestGobot()
+	calls := []string{}
+	http := &testAPIServer{name: "http", calls: &calls}
+	grpc := &testAPIServer{name: "grpc", calls: &calls}
+	g.AddAPIServer(http)
+	g.AddAPIServer(grpc)
+	Assert(t, http.gobot, g)
+	Assert(t, g.APIServers(), []APIServer{http, grpc})
+
+	Assert(t, len(g.Start()), 0)
+	Assert(t, calls, []string{"start http", "start grpc", "stop grpc", "stop http"})
+}
+
+func TestGobotAPIServerStartError(t *testing.T) {
+	g := initTestGobot()
+	calls := []string{}
+	g.AddAPIServer(&testAPIServer{name: "http", calls: &calls, startErr: errors.New("address in use")})
+	g.trap = func(c chan os.Signal) {}
+
+	errs := g.Start()
+	Assert(t, errs[0].Error(), "API server *gobot.testAPIServer: address in use")
+}
@@ -0,0 +1,66 @@
+package gobot
+
+import (
+	"path"
+	"sync"
+)
+
+// Message is a message robots exchange over the message bus of a Gobot
+type Message struct {
+	// From is the name of the robot which sent the message
+	From  string
+	Topic string
+	Data  interface{}
+}
+
+// messageBus delivers messages to the subscribers of their topic
+type messageBus struct {
+	subscriptions map[int]subscription
+	nextID        int
+	mutex         sync.RWMutex
+}
+
+// subscription is a callback subscribed to the topics matching pattern
+type subscription struct {
+	pattern string
+	f       func(Message)
+}
+
+func newMessageBus() *messageBus {
+	return &messageBus{subscriptions: make(map[int]subscription)}
+}
+
+// SendMessage publishes data on topic as the robot named from. Every
+// subscriber of the topic is executed with the Message in its own goroutine,
+// like the callbacks of an Event.
+func (g *Gobot) SendMessage(from string, topic string, data interface{}) {
+	msg := Message{From: from, Topic: topic, Data: data}
+	g.bus.mutex.RLock()
+	defer g.bus.mutex.RUnlock()
+	for _, s := range g.bus.subscriptions {
+		if matched, _ := path.Match(s.pattern, topic); matched {
+			go s.f(msg)
+		}
+	}
+}
+
+// OnMessage executes f with every message sent on a topic matching pattern,
+// which uses the syntax of path.Match, e.g. "distance" or "sensors/*".
+// Returns a function which cancels the subscription, or path.ErrBadPattern
+// if pattern is malformed.
+func (g *Gobot) OnMessage(pattern string, f func(Message)) (cancel func(), err error) {
+	if _, err = path.Match(pattern, ""); err != nil {
+		return
+	}
+	g.bus.mutex.Lock()
+	defer g.bus.mutex.Unlock()
+	id := g.bus.nextID
+	g.bus.nextID++
+	g.bus.subscriptions[id] = subscription{pattern: pattern, f: f}
+	cancel = func() {
+		g.bus.mutex.Lock()
+		defer g.bus.mutex.Unlock()
+		delete(g.bus.subscriptions, id)
+	}
+	return
+}
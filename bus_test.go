@@ -0,0 +1,37 @@
+package gobot
+
+import (
+	"path"
+	"testing"
+	"time"
+)
+
+func TestGobotMessageBus(t *testing.T) {
+	g := NewGobot()
+	distances := make(chan Message, 2)
+	all := make(chan Message, 2)
+
+	cancel, err := g.OnMessage("distance", func(msg Message) {
+		distances <- msg
+	})
+	Assert(t, err, nil)
+	_, err = g.OnMessage("*", func(msg Message) {
+		all <- msg
+	})
+	Assert(t, err, nil)
+
+	g.SendMessage("sensor", "distance", 42)
+	g.SendMessage("sensor", "light", 7)
+	<-time.After(5 * time.Millisecond)
+	Assert(t, len(distances), 1)
+	Assert(t, <-distances, Message{From: "sensor", Topic: "distance", Data: 42})
+	Assert(t, len(all), 2)
+
+	cancel()
+	g.SendMessage("sensor", "distance", 43)
+	<-time.After(5 * time.Millisecond)
+	Assert(t, len(distances), 0)
+
+	_, err = g.OnMessage("[", func(Message) {})
+	Assert(t, err, path.ErrBadPattern)
+}
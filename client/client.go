@@ -0,0 +1,278 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// The codes of the errors of the api
+const (
+	CodeNotFound      = "not_found"
+	CodeInvalidParams = "invalid_params"
+	CodeInvalidBody   = "invalid_body"
+	CodeCommandFailed = "command_failed"
+)
+
+// Error is an error answered by the api
+type Error struct {
+	// Status is the HTTP status code of the answer
+	Status int `json:"-"`
+	// Code is one of the Code constants, empty if the api did not answer
+	// with an error payload, e.g. 401 Not Authorized
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error returns the message of the error.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Command is a command and the parameters it takes
+type Command struct {
+	Name   string        `json:"name"`
+	Params []gobot.Param `json:"params"`
+}
+
+// Device is a device of a robot
+type Device struct {
+	Name       string    `json:"name"`
+	Driver     string    `json:"driver"`
+	Connection string    `json:"connection"`
+	Pin        string    `json:"pin,omitempty"`
+	Disabled   bool      `json:"disabled"`
+	Commands   []Command `json:"commands"`
+	Events     []string  `json:"events"`
+}
+
+// Robot is a robot, its connections and devices
+type Robot struct {
+	Name        string                  `json:"name"`
+	Tags        gobot.Tags              `json:"tags"`
+	Commands    []Command               `json:"commands"`
+	Connections []*gobot.JSONConnection `json:"connections"`
+	Devices     []Device                `json:"devices"`
+}
+
+// Event is an event published by a robot or one of its devices
+type Event struct {
+	Robot  string      `json:"robot"`
+	Device string      `json:"device,omitempty"`
+	Name   string      `json:"name"`
+	Data   interface{} `json:"data"`
+	Time   time.Time   `json:"time"`
+}
+
+// Client is a client of the api served at URL
+type Client struct {
+	// URL is the base URL of the api, e.g. "http://localhost:3000"
+	URL string
+	// HTTPClient sends the requests, http.DefaultClient when nil
+	HTTPClient *http.Client
+	// Token is sent as a bearer token when not empty, see api.UseJWT
+	Token string
+	// Username and Password are sent as basic authorization when Username
+	// is not empty
+	Username string
+	Password string
+}
+
+// New returns a client of the api served at url.
+func New(url string) *Client {
+	return &Client{URL: strings.TrimRight(url, "/")}
+}
+
+// Robots returns the robots of the Gobot, all of them or those with all the
+// tags of one of filters.
+func (c *Client) Robots(ctx context.Context, filters ...gobot.Tags) ([]Robot, error) {
+	query := url.Values{}
+	for _, filter := range filters {
+		pairs := []string{}
+		for key, value := range filter {
+			pairs = append(pairs, key+"="+value)
+		}
+		sort.Strings(pairs)
+		query.Add("tag", strings.Join(pairs, ","))
+	}
+	var body struct {
+		Robots []Robot `json:"robots"`
+	}
+	err := c.do(ctx, "GET", v2Path("robots"), query, nil, &body)
+	return body.Robots, err
+}
+
+// Robot returns the robot named name.
+func (c *Client) Robot(ctx context.Context, name string) (*Robot, error) {
+	robot := &Robot{}
+	if err := c.do(ctx, "GET", v2Path("robots", name), nil, nil, robot); err != nil {
+		return nil, err
+	}
+	return robot, nil
+}
+
+// Devices returns the devices of robot.
+func (c *Client) Devices(ctx context.Context, robot string) ([]Device, error) {
+	var body struct {
+		Devices []Device `json:"devices"`
+	}
+	err := c.do(ctx, "GET", v2Path("robots", robot, "devices"), nil, nil, &body)
+	return body.Devices, err
+}
+
+// Device returns the device of robot named name.
+func (c *Client) Device(ctx context.Context, robot string, name string) (*Device, error) {
+	device := &Device{}
+	if err := c.do(ctx, "GET", v2Path("robots", robot, "devices", name), nil, nil, device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// Commands returns the commands of device of robot, of robot if device is
+// empty, or of the Gobot if both are empty.
+func (c *Client) Commands(ctx context.Context, robot string, device string) ([]Command, error) {
+	var body struct {
+		Commands []Command `json:"commands"`
+	}
+	err := c.do(ctx, "GET", commanderPath(robot, device, "commands"), nil, nil, &body)
+	return body.Commands, err
+}
+
+// Execute executes command of device of robot with params and returns its
+// result. device and robot are empty for the commands of the robot and of
+// the Gobot, see Commands. A command returning an error is an *Error with
+// the CodeCommandFailed code.
+func (c *Client) Execute(ctx context.Context, robot string, device string, command string,
+	params map[string]interface{}) (interface{}, error) {
+	var body struct {
+		Result interface{} `json:"result"`
+	}
+	err := c.do(ctx, "POST", commanderPath(robot, device, "commands", command), nil, params, &body)
+	return body.Result, err
+}
+
+// ExecuteAsync executes command like Execute, in the background, and
+// returns its job, whose result can be polled with Job.
+func (c *Client) ExecuteAsync(ctx context.Context, robot string, device string, command string,
+	params map[string]interface{}) (*gobot.JSONJob, error) {
+	var body struct {
+		Job *gobot.JSONJob `json:"job"`
+	}
+	query := url.Values{"async": {"true"}}
+	err := c.do(ctx, "POST", commanderPath(robot, device, "commands", command), query, params, &body)
+	return body.Job, err
+}
+
+// Job returns the job with id of a command executed with ExecuteAsync.
+func (c *Client) Job(ctx context.Context, robot string, device string, id string) (*gobot.JSONJob, error) {
+	var body struct {
+		Job *gobot.JSONJob `json:"job"`
+	}
+	err := c.do(ctx, "GET", commanderPath(robot, device, "jobs", id), nil, nil, &body)
+	return body.Job, err
+}
+
+// request returns a request of the api, authorized with the credentials of
+// c
+func (c *Client) request(ctx context.Context, method string, path string, query url.Values,
+	body interface{}) (*http.Request, error) {
+	u := c.URL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	return req, nil
+}
+
+// send sends req, returning an *Error if the api answers with an error
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 400 {
+		defer res.Body.Close()
+		var body struct {
+			Error *Error `json:"error"`
+		}
+		if json.NewDecoder(res.Body).Decode(&body) != nil || body.Error == nil {
+			body.Error = &Error{Message: res.Status}
+		}
+		body.Error.Status = res.StatusCode
+		return nil, body.Error
+	}
+	return res, nil
+}
+
+// do sends a request of the api with the JSON of body, if not nil, and
+// decodes the JSON it answers into v
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values,
+	body interface{}, v interface{}) error {
+	req, err := c.request(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	res, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("Invalid answer of %v %v: %v", method, path, err)
+	}
+	return nil
+}
+
+// v2Path returns the path of the v2 route made of segments
+func v2Path(segments ...string) string {
+	path := "/api/v2"
+	for _, segment := range segments {
+		path += "/" + url.PathEscape(segment)
+	}
+	return path
+}
+
+// commanderPath returns the path of the v2 route made of segments under
+// device of robot, robot if device is empty, or the Gobot if both are empty
+func commanderPath(robot string, device string, segments ...string) string {
+	prefix := []string{}
+	if robot != "" {
+		prefix = append(prefix, "robots", robot)
+		if device != "" {
+			prefix = append(prefix, "devices", device)
+		}
+	}
+	return v2Path(append(prefix, segments...)...)
+}
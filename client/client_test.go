@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/api"
+)
+
+type nullWriter struct{}
+
+func (nullWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+// newTestServer returns a server of the api of a Gobot with a robot rover
+// tagged zone=a, with a Drive command, a Fail command and a bump event
+func newTestServer() (*httptest.Server, *gobot.Robot) {
+	log.SetOutput(nullWriter{})
+	g := gobot.NewGobot()
+	robot := gobot.NewRobot("rover", []gobot.Connection{}, []gobot.Device{}, nil)
+	robot.Tags = gobot.Tags{"zone": "a"}
+	robot.AddCommandWithParams("Drive", []gobot.Param{{Name: "speed", Type: gobot.ParamInt, Required: true}},
+		func(params map[string]interface{}) interface{} {
+			return params["speed"]
+		})
+	robot.AddCommand("Fail", func(params map[string]interface{}) interface{} {
+		return errors.New("stalled")
+	})
+	robot.AddEvent("bump")
+	g.AddRobot(robot)
+	a := api.NewAPI(g)
+	a.Port = ""
+	a.Start()
+	return httptest.NewServer(a), robot
+}
+
+func TestClient(t *testing.T) {
+	server, _ := newTestServer()
+	defer server.Close()
+	c := New(server.URL + "/")
+	ctx := context.Background()
+
+	robots, err := c.Robots(ctx)
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, len(robots), 1)
+	gobot.Assert(t, robots[0].Name, "rover")
+	robots, err = c.Robots(ctx, gobot.Tags{"zone": "b"})
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, len(robots), 0)
+
+	robot, err := c.Robot(ctx, "rover")
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, robot.Tags, gobot.Tags{"zone": "a"})
+	gobot.Assert(t, robot.Commands[0].Name, "Drive")
+
+	_, err = c.Robot(ctx, "rover2")
+	gobot.Assert(t, err, &Error{Status: http.StatusNotFound, Code: CodeNotFound,
+		Message: "No Robot found with the name rover2"})
+
+	commands, err := c.Commands(ctx, "rover", "")
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, len(commands), 2)
+	gobot.Assert(t, commands[0].Params[0].Name, "speed")
+
+	result, err := c.Execute(ctx, "rover", "", "Drive", map[string]interface{}{"speed": 10})
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, result, 10.0)
+	_, err = c.Execute(ctx, "rover", "", "Drive", nil)
+	gobot.Assert(t, err.(*Error).Code, CodeInvalidParams)
+	_, err = c.Execute(ctx, "rover", "", "Fail", nil)
+	gobot.Assert(t, err, &Error{Status: http.StatusInternalServerError, Code: CodeCommandFailed, Message: "stalled"})
+
+	job, err := c.ExecuteAsync(ctx, "rover", "", "Drive", map[string]interface{}{"speed": 5})
+	gobot.Assert(t, err, nil)
+	for job.Status == gobot.JobRunning {
+		time.Sleep(time.Millisecond)
+		job, err = c.Job(ctx, "rover", "", job.ID)
+		gobot.Assert(t, err, nil)
+	}
+	gobot.Assert(t, job.Result, 5.0)
+}
+
+func TestClientAuthorization(t *testing.T) {
+	g := gobot.NewGobot()
+	a := api.NewAPI(g)
+	a.Port = ""
+	a.AddHandler(api.BasicAuth("admin", "secret"))
+	a.Start()
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	c := New(server.URL)
+	_, err := c.Robots(context.Background())
+	gobot.Assert(t, err.(*Error).Status, http.StatusUnauthorized)
+
+	c.Username, c.Password = "admin", "secret"
+	robots, err := c.Robots(context.Background())
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, len(robots), 0)
+}
+
+func TestSubscribe(t *testing.T) {
+	server, robot := newTestServer()
+	defer server.Close()
+	c := New(server.URL)
+
+	sub, err := c.Subscribe(context.Background(), "rover", "", "bump")
+	gobot.Assert(t, err, nil)
+	// the stream may start after the first events
+	go func() {
+		for i := 0; i < 100; i++ {
+			gobot.Publish(robot.Event("bump"), i)
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+	select {
+	case event := <-sub.Events:
+		gobot.Assert(t, event.Robot, "rover")
+		gobot.Assert(t, event.Name, "bump")
+	case <-time.After(time.Second):
+		t.Fatal("No event received")
+	}
+	sub.Close()
+	for range sub.Events {
+	}
+	gobot.Assert(t, sub.Err(), nil)
+
+	_, err = c.Subscribe(context.Background(), "rover", "missing")
+	gobot.Assert(t, err.(*Error).Code, CodeNotFound)
+}
@@ -0,0 +1,34 @@
+/*
+Package client is a Go client of the /api/v2 routes of the Gobot api, so
+companion services can list robots, execute commands and subscribe to events
+without writing their own HTTP plumbing.
+
+Example:
+
+	c := client.New("http://localhost:3000")
+	c.Token = token
+
+	robots, err := c.Robots(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, robot := range robots {
+		fmt.Println(robot.Name, len(robot.Devices))
+	}
+
+	result, err := c.Execute(ctx, "rover", "motor", "Speed", map[string]interface{}{"speed": 128})
+	if e, ok := err.(*client.Error); ok && e.Code == client.CodeInvalidParams {
+		log.Println("bad speed:", e.Message)
+	}
+
+	sub, err := c.Subscribe(ctx, "rover", "", "button_*")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer sub.Close()
+	for event := range sub.Events {
+		fmt.Println(event.Device, event.Name, event.Data)
+	}
+	log.Println("stream ended:", sub.Err())
+*/
+package client
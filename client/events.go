@@ -0,0 +1,109 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// eventBuffer is the number of events buffered for a slow reader of a
+// Subscription, the stream is not read while it is full
+const eventBuffer = 64
+
+// Subscription is a stream of the events of a robot or device, see
+// Client.Subscribe
+type Subscription struct {
+	// Events receives the events, it is closed once the stream ends
+	Events <-chan Event
+
+	cancel context.CancelFunc
+	err    error
+	mutex  sync.Mutex
+}
+
+// Subscribe streams the events of device of robot, or of robot and all its
+// devices if device is empty, whose name matches one of filters, all events
+// if there are no filters. Filters accept the wildcards of path.Match, e.g.
+// "button_*". The stream ends when ctx is done, Close is called or the
+// connection is lost.
+func (c *Client) Subscribe(ctx context.Context, robot string, device string, filters ...string) (*Subscription, error) {
+	query := url.Values{}
+	for _, filter := range filters {
+		query.Add("event", filter)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	req, err := c.request(ctx, "GET", commanderPath(robot, device, "events"), query, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	res, err := c.send(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	events := make(chan Event, eventBuffer)
+	s := &Subscription{Events: events, cancel: cancel}
+	go func() {
+		defer close(events)
+		defer res.Body.Close()
+		s.setErr(readEvents(ctx, res.Body, events))
+	}()
+	return s, nil
+}
+
+// Close ends the stream.
+func (s *Subscription) Close() error {
+	s.cancel()
+	return nil
+}
+
+// Err returns the error which ended the stream, nil if it was ended by
+// Close or its context, or has not ended.
+func (s *Subscription) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+// setErr records the error which ended the stream
+func (s *Subscription) setErr(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.err = err
+}
+
+// readEvents sends the events of the Server-Sent Events stream r to events
+// until it ends or ctx is done, returning the error which ended it
+func readEvents(ctx context.Context, r io.Reader, events chan<- Event) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, "data:") {
+			// event names, heartbeat comments and blank lines
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(strings.TrimSpace(line[len("data:"):])), &event); err != nil {
+			return err
+		}
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package gobot
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time of gobot: Every, After, retries, supervisors,
+// cron jobs, health monitoring, Throttle and Debounce all wait on it. The
+// testutil package provides a FakeClock which tests can advance by hand, see
+// SetClock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTimer returns a ClockTimer sending the time on its channel after d.
+	NewTimer(d time.Duration) ClockTimer
+	// NewTicker returns a ClockTicker sending the time on its channel every
+	// d.
+	NewTicker(d time.Duration) ClockTicker
+	// AfterFunc calls f in its own goroutine after d, unless the returned
+	// ClockTimer is stopped first.
+	AfterFunc(d time.Duration, f func()) ClockTimer
+}
+
+// ClockTimer is a timer created by a Clock, like time.Timer
+type ClockTimer interface {
+	// C returns the channel the time is sent on.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing. Returns false if it has already
+	// fired or been stopped.
+	Stop() bool
+}
+
+// ClockTicker is a ticker created by a Clock, like time.Ticker
+type ClockTicker interface {
+	// C returns the channel the ticks are sent on.
+	C() <-chan time.Time
+	// Stop turns off the ticker.
+	Stop()
+}
+
+var (
+	currentClock Clock = realClock{}
+	clockMutex   sync.RWMutex
+)
+
+// SetClock sets the Clock used by gobot, nil restores the real clock. Set it
+// before starting the robots, e.g. at the start of a test:
+//
+//	clock := testutil.NewFakeClock(time.Now())
+//	gobot.SetClock(clock)
+//	defer gobot.SetClock(nil)
+func SetClock(c Clock) {
+	clockMutex.Lock()
+	defer clockMutex.Unlock()
+	if c == nil {
+		c = realClock{}
+	}
+	currentClock = c
+}
+
+// clock returns the Clock used by gobot
+func clock() Clock {
+	clockMutex.RLock()
+	defer clockMutex.RUnlock()
+	return currentClock
+}
+
+// realClock is the Clock of the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) ClockTimer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) ClockTicker {
+	return realTicker{time.NewTicker(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
@@ -0,0 +1,80 @@
+package cluster
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func init() {
+	log.SetOutput(&nullWriter{})
+}
+
+type nullWriter struct{}
+
+func (nullWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+func getJSON(t *testing.T, url string) (body map[string]interface{}) {
+	resp, err := http.Get(url)
+	gobot.Assert(t, err, nil)
+	defer resp.Body.Close()
+	json.NewDecoder(resp.Body).Decode(&body)
+	return
+}
+
+func TestCluster(t *testing.T) {
+	coordinator := NewCoordinator()
+	server := httptest.NewServer(coordinator)
+	defer server.Close()
+	pi1 := newTestMember("bot1")
+	defer pi1.Close()
+	pi2 := newTestMember("bot2")
+	defer pi2.Close()
+
+	m1, err := Join(server.URL, "pi1", pi1.URL, time.Minute)
+	gobot.Assert(t, err, nil)
+	m2, err := Join(server.URL, "pi2", pi2.URL, time.Minute)
+	gobot.Assert(t, err, nil)
+
+	members := getJSON(t, server.URL+"/cluster/members")["members"].([]interface{})
+	gobot.Assert(t, len(members), 2)
+	gobot.Assert(t, members[0].(map[string]interface{})["name"], "pi1")
+
+	robots := getJSON(t, server.URL+"/cluster/robots")
+	gobot.Assert(t, robots["robots"], map[string]interface{}{
+		"pi1": []interface{}{map[string]interface{}{"name": "bot1"}},
+		"pi2": []interface{}{map[string]interface{}{"name": "bot2"}},
+	})
+	gobot.Assert(t, robots["errors"], map[string]interface{}{})
+
+	result := getJSON(t, server.URL+"/cluster/members/pi2/api/robots/bot2/commands/ping?n=1")
+	gobot.Assert(t, result["result"], "pong n=1")
+
+	gobot.Assert(t, m2.Leave(), nil)
+	gobot.Assert(t, m2.Leave(), nil)
+	gobot.Assert(t, len(coordinator.Members()), 1)
+	result = getJSON(t, server.URL+"/cluster/members/pi2/api/robots")
+	gobot.Assert(t, result["error"], "No Member found with the name pi2")
+	m1.Leave()
+}
+
+func TestCoordinatorExpiry(t *testing.T) {
+	c := NewCoordinator()
+	c.TTL = 10 * time.Millisecond
+	gobot.Assert(t, c.Register(Member{Name: "pi1", URL: "http://pi1:3000"}), nil)
+	gobot.Assert(t, len(c.Members()), 1)
+	<-time.After(20 * time.Millisecond)
+	gobot.Assert(t, len(c.Members()), 0)
+}
+
+func TestJoinErrors(t *testing.T) {
+	server := httptest.NewServer(NewCoordinator())
+	defer server.Close()
+	_, err := Join(server.URL, "", "http://pi1:3000", time.Minute)
+	gobot.Assert(t, err.Error(), "Coordinator rejected member \"\": Member has no name")
+}
@@ -0,0 +1,225 @@
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmizerany/pat"
+	"github.com/hybridgroup/gobot"
+)
+
+// Member is a Gobot program registered with a Coordinator
+type Member struct {
+	Name string `json:"name"`
+	// URL is the base URL of the member's api, e.g. "http://pi1:3000"
+	URL      string    `json:"url"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Coordinator keeps track of the members of a cluster and serves their
+// robots over HTTP.
+type Coordinator struct {
+	// TTL is how long a member stays registered without renewing its
+	// registration. Defaults to 30 seconds.
+	TTL time.Duration
+	// Client is used to query the apis of the members
+	Client *http.Client
+
+	members map[string]Member
+	router  *pat.PatternServeMux
+	mutex   sync.Mutex
+}
+
+// NewCoordinator returns a new Coordinator without members.
+func NewCoordinator() *Coordinator {
+	c := &Coordinator{
+		TTL:     30 * time.Second,
+		Client:  &http.Client{Timeout: 5 * time.Second},
+		members: make(map[string]Member),
+		router:  pat.New(),
+	}
+	c.router.Get("/cluster/members", http.HandlerFunc(c.listMembers))
+	c.router.Post("/cluster/members", http.HandlerFunc(c.register))
+	c.router.Del("/cluster/members/:member", http.HandlerFunc(c.unregister))
+	c.router.Get("/cluster/robots", http.HandlerFunc(c.robots))
+	c.router.Add("GET", "/cluster/members/:member/", http.HandlerFunc(c.proxy))
+	c.router.Add("POST", "/cluster/members/:member/", http.HandlerFunc(c.proxy))
+	return c
+}
+
+// ServeHTTP serves the cluster routes.
+func (c *Coordinator) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	c.router.ServeHTTP(res, req)
+}
+
+// Register registers or renews the registration of member.
+func (c *Coordinator) Register(member Member) error {
+	if member.Name == "" {
+		return errors.New("Member has no name")
+	}
+	if _, err := url.Parse(member.URL); err != nil || member.URL == "" {
+		return fmt.Errorf("Member %q has an invalid url %q", member.Name, member.URL)
+	}
+	member.LastSeen = time.Now()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.members[member.Name]; !ok {
+		gobot.Log(gobot.InfoLevel, "Member joined", gobot.Fields{"member": member.Name, "url": member.URL})
+	}
+	c.members[member.Name] = member
+	return nil
+}
+
+// Unregister removes the named member.
+func (c *Coordinator) Unregister(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.members, name)
+}
+
+// Members returns the members whose registration has not expired, sorted by
+// name.
+func (c *Coordinator) Members() (members []Member) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for name, member := range c.members {
+		if time.Since(member.LastSeen) > c.TTL {
+			gobot.Log(gobot.WarnLevel, "Member expired", gobot.Fields{"member": name})
+			delete(c.members, name)
+			continue
+		}
+		members = append(members, member)
+	}
+	sort.Sort(byName(members))
+	return
+}
+
+// Member returns a member given a name. Returns false if there is no such
+// member.
+func (c *Coordinator) Member(name string) (Member, bool) {
+	for _, member := range c.Members() {
+		if member.Name == name {
+			return member, true
+		}
+	}
+	return Member{}, false
+}
+
+// listMembers writes the members as JSON
+func (c *Coordinator) listMembers(res http.ResponseWriter, req *http.Request) {
+	members := c.Members()
+	if members == nil {
+		members = []Member{}
+	}
+	writeJSON(map[string]interface{}{"members": members}, res)
+}
+
+// register registers the member in the request body
+func (c *Coordinator) register(res http.ResponseWriter, req *http.Request) {
+	var member Member
+	if err := json.NewDecoder(req.Body).Decode(&member); err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	if err := c.Register(member); err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	writeJSON(map[string]interface{}{"member": member.Name}, res)
+}
+
+// unregister removes the member named in the route
+func (c *Coordinator) unregister(res http.ResponseWriter, req *http.Request) {
+	c.Unregister(req.URL.Query().Get(":member"))
+	writeJSON(map[string]interface{}{"member": req.URL.Query().Get(":member")}, res)
+}
+
+// robots writes the robots of every member as JSON, and the errors of the
+// members which could not be queried
+func (c *Coordinator) robots(res http.ResponseWriter, req *http.Request) {
+	members := c.Members()
+	robots := make(map[string]interface{})
+	errs := make(map[string]string)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, member := range members {
+		wg.Add(1)
+		go func(member Member) {
+			defer wg.Done()
+			r, err := c.memberRobots(member)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errs[member.Name] = err.Error()
+				return
+			}
+			robots[member.Name] = r
+		}(member)
+	}
+	wg.Wait()
+	writeJSON(map[string]interface{}{"robots": robots, "errors": errs}, res)
+}
+
+// memberRobots queries the robots of member
+func (c *Coordinator) memberRobots(member Member) (robots interface{}, err error) {
+	resp, err := c.Client.Get(strings.TrimRight(member.URL, "/") + "/api/robots")
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Robots interface{} `json:"robots"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return
+	}
+	return body.Robots, nil
+}
+
+// proxy forwards the request to the api of the member named in the route
+func (c *Coordinator) proxy(res http.ResponseWriter, req *http.Request) {
+	name := req.URL.Query().Get(":member")
+	member, ok := c.Member(name)
+	if !ok {
+		res.WriteHeader(http.StatusNotFound)
+		writeJSON(map[string]interface{}{"error": "No Member found with the name " + name}, res)
+		return
+	}
+	target, _ := url.Parse(member.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	// flush event streams as they arrive
+	proxy.FlushInterval = 100 * time.Millisecond
+
+	req.URL.Path = strings.TrimPrefix(req.URL.Path, "/cluster/members/"+name)
+	query := req.URL.Query()
+	for key := range query {
+		if strings.HasPrefix(key, ":") {
+			query.Del(key)
+		}
+	}
+	req.URL.RawQuery = query.Encode()
+	proxy.ServeHTTP(res, req)
+}
+
+// writeJSON writes j as JSON in response
+func writeJSON(j interface{}, res http.ResponseWriter) {
+	data, _ := json.Marshal(j)
+	res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res.Write(data)
+}
+
+type byName []Member
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool { return b[i].Name < b[j].Name }
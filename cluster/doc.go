@@ -0,0 +1,28 @@
+/*
+Package cluster lets the Gobot programs running on several hosts, e.g. a few
+Raspberry Pis, be listed, commanded and watched from one place. Each program
+runs the Gobot api and joins a Coordinator, which lists the robots of every
+member and proxies requests to the api of a member.
+
+Example coordinator:
+
+	http.ListenAndServe(":4000", cluster.NewCoordinator())
+
+Example member:
+
+	gbot := gobot.NewGobot()
+	api.NewAPI(gbot).Start()
+	membership, err := cluster.Join("http://coordinator:4000", "pi1", "http://pi1:3000", 10*time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer membership.Leave()
+	gbot.Start()
+
+The coordinator then serves:
+
+	GET /cluster/members - the registered members
+	GET /cluster/robots - the robots of every member
+	/cluster/members/:member/api/... - the api of a member, e.g. its commands and event streams
+*/
+package cluster
@@ -0,0 +1,21 @@
+package cluster
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+// newTestMember returns a server answering like the api of a Gobot program
+// with a single robot
+func newTestMember(robot string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/robots", func(res http.ResponseWriter, req *http.Request) {
+		writeJSON(map[string]interface{}{"robots": []interface{}{
+			map[string]interface{}{"name": robot},
+		}}, res)
+	})
+	mux.HandleFunc("/api/robots/"+robot+"/commands/ping", func(res http.ResponseWriter, req *http.Request) {
+		writeJSON(map[string]interface{}{"result": "pong " + req.URL.RawQuery}, res)
+	})
+	return httptest.NewServer(mux)
+}
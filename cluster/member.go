@@ -0,0 +1,88 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// Membership is the registration of a Gobot program with a Coordinator
+type Membership struct {
+	coordinator string
+	member      Member
+	client      *http.Client
+	done        chan struct{}
+	leaveOnce   sync.Once
+}
+
+// Join registers the api served at apiURL as the member name of the
+// coordinator at coordinatorURL, and renews the registration every interval
+// until Leave is called. interval should be well below the TTL of the
+// coordinator. Returns an error if the first registration fails.
+func Join(coordinatorURL string, name string, apiURL string, interval time.Duration) (*Membership, error) {
+	m := &Membership{
+		coordinator: strings.TrimRight(coordinatorURL, "/"),
+		member:      Member{Name: name, URL: apiURL},
+		client:      &http.Client{Timeout: 5 * time.Second},
+		done:        make(chan struct{}),
+	}
+	if err := m.register(); err != nil {
+		return nil, err
+	}
+	go m.heartbeat(interval)
+	return m, nil
+}
+
+// Leave stops renewing the registration and unregisters the member.
+func (m *Membership) Leave() (err error) {
+	m.leaveOnce.Do(func() {
+		close(m.done)
+		req, _ := http.NewRequest("DELETE", m.coordinator+"/cluster/members/"+m.member.Name, nil)
+		var resp *http.Response
+		if resp, err = m.client.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	})
+	return
+}
+
+// heartbeat renews the registration every interval until Leave is called
+func (m *Membership) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			if err := m.register(); err != nil {
+				gobot.Log(gobot.WarnLevel, "Could not renew cluster membership",
+					gobot.Fields{"member": m.member.Name, "error": err})
+			}
+		}
+	}
+}
+
+// register registers the member with the coordinator
+func (m *Membership) register() error {
+	body, _ := json.Marshal(m.member)
+	resp, err := m.client.Post(m.coordinator+"/cluster/members", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var reply struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&reply)
+		return fmt.Errorf("Coordinator rejected member %q: %v", m.member.Name, reply.Error)
+	}
+	return nil
+}
@@ -1,7 +1,52 @@
 package gobot
 
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+)
+
 type commander struct {
-	commands map[string]func(map[string]interface{}) interface{}
+	commands   map[string]func(map[string]interface{}) interface{}
+	params     map[string][]Param
+	middleware []CommandMiddleware
+
+	jobs      map[string]*Job
+	jobOrder  []string
+	jobCount  int
+	jobDone   func(*Job)
+	jobsMutex sync.Mutex
+}
+
+// CommandMiddleware wraps the execution of the commands of a Commander. It is
+// called with the name and parameters of the command and next, which runs
+// the rest of the middleware and the command. A middleware can act before
+// and after calling next, rewrite the parameters passed to next, or return
+// without calling next, e.g. an error to reject the command.
+type CommandMiddleware func(name string, params map[string]interface{}, next func(map[string]interface{}) interface{}) interface{}
+
+// ParamType is the type of a command parameter
+type ParamType string
+
+// The types of command parameters
+const (
+	ParamString ParamType = "string"
+	ParamInt    ParamType = "int"
+	ParamFloat  ParamType = "float"
+	ParamBool   ParamType = "bool"
+)
+
+// Param describes a parameter of a command
+type Param struct {
+	Name     string    `json:"name"`
+	Type     ParamType `json:"type"`
+	Required bool      `json:"required"`
+	// Min and Max bound an int or float parameter when Min is less than Max
+	Min float64 `json:"min,omitempty"`
+	Max float64 `json:"max,omitempty"`
+	// Default is used when the parameter is not given
+	Default interface{} `json:"default,omitempty"`
 }
 
 // Commander is the interface which describes the behaviour for a Driver or Adaptor
@@ -13,17 +58,47 @@ type Commander interface {
 	Commands() (commands map[string]func(map[string]interface{}) interface{})
 	// AddCommand adds a command given a name.
 	AddCommand(name string, command func(map[string]interface{}) interface{})
+	// AddCommandWithParams adds a command given a name and the parameters
+	// it takes. The parameters are validated and coerced to their type with
+	// ValidateParams before command is called, a command called with invalid
+	// parameters returns the error instead of calling command.
+	AddCommandWithParams(name string, params []Param, command func(map[string]interface{}) interface{})
+	// Params returns the parameters of a command given a name. Returns nil
+	// if the command was added without parameters.
+	Params(name string) (params []Param)
+	// Use adds middleware wrapping every command returned by Command, the
+	// first middleware added runs first.
+	Use(middleware ...CommandMiddleware)
+	// ExecuteAsync runs the command given a name in the background and
+	// returns its Job right away, so slow commands such as a calibration do
+	// not block the caller. Returns ErrUnknownCommand if the command is not
+	// found.
+	ExecuteAsync(name string, params map[string]interface{}) (job *Job, err error)
+	// Job returns a Job started with ExecuteAsync given its ID. Returns nil
+	// if the job is not found, the oldest finished jobs are forgotten.
+	Job(id string) (job *Job)
 }
 
 // NewCommander returns a new Commander.
 func NewCommander() Commander {
 	return &commander{
 		commands: make(map[string]func(map[string]interface{}) interface{}),
+		params:   make(map[string][]Param),
+		jobs:     make(map[string]*Job),
 	}
 }
 
 func (c *commander) Command(name string) (command func(map[string]interface{}) interface{}) {
 	command, _ = c.commands[name]
+	if command == nil {
+		return
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		middleware, next := c.middleware[i], command
+		command = func(params map[string]interface{}) interface{} {
+			return middleware(name, params, next)
+		}
+	}
 	return
 }
 
@@ -33,4 +108,117 @@ func (c *commander) Commands() map[string]func(map[string]interface{}) interface
 
 func (c *commander) AddCommand(name string, command func(map[string]interface{}) interface{}) {
 	c.commands[name] = command
+	delete(c.params, name)
+}
+
+func (c *commander) AddCommandWithParams(name string, params []Param, command func(map[string]interface{}) interface{}) {
+	c.commands[name] = func(values map[string]interface{}) interface{} {
+		values, err := ValidateParams(params, values)
+		if err != nil {
+			return err
+		}
+		return command(values)
+	}
+	c.params[name] = params
+}
+
+func (c *commander) Params(name string) []Param {
+	return c.params[name]
+}
+
+func (c *commander) Use(middleware ...CommandMiddleware) {
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// commandParams returns the parameters of the commands of c which have any
+func commandParams(c Commander) (params map[string][]Param) {
+	for name := range c.Commands() {
+		if p := c.Params(name); p != nil {
+			if params == nil {
+				params = make(map[string][]Param)
+			}
+			params[name] = p
+		}
+	}
+	return
+}
+
+// ValidateParams checks values against params and returns a copy of values
+// with each parameter coerced to its type, e.g. the string "12" or the
+// float64 12 decoded from JSON to the int 12, and missing parameters set to
+// their default. Values which are not described by params are kept as is.
+func ValidateParams(params []Param, values map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	for name, value := range values {
+		result[name] = value
+	}
+	for _, param := range params {
+		value, ok := result[param.Name]
+		if !ok || value == nil {
+			if param.Required {
+				return nil, fmt.Errorf("Missing parameter %q", param.Name)
+			}
+			if param.Default != nil {
+				result[param.Name] = param.Default
+			}
+			continue
+		}
+		coerced, err := param.coerce(value)
+		if err != nil {
+			return nil, fmt.Errorf("Parameter %q: %v", param.Name, err)
+		}
+		result[param.Name] = coerced
+	}
+	return result, nil
+}
+
+// coerce converts value to the type of p and checks its range
+func (p Param) coerce(value interface{}) (interface{}, error) {
+	switch p.Type {
+	case ParamString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(value), nil
+	case ParamBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("%v is not a bool", value)
+	case ParamInt, ParamFloat:
+		var f float64
+		switch v := value.(type) {
+		case float64:
+			f = v
+		case float32:
+			f = float64(v)
+		case int:
+			f = float64(v)
+		case int64:
+			f = float64(v)
+		case string:
+			var err error
+			if f, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("%q is not a number", v)
+			}
+		default:
+			return nil, fmt.Errorf("%v is not a number", value)
+		}
+		if p.Min < p.Max && (f < p.Min || f > p.Max) {
+			return nil, fmt.Errorf("%v is out of range %v-%v", f, p.Min, p.Max)
+		}
+		if p.Type == ParamFloat {
+			return f, nil
+		}
+		if f != math.Trunc(f) {
+			return nil, fmt.Errorf("%v is not an int", f)
+		}
+		return int(f), nil
+	}
+	return value, nil
 }
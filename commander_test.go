@@ -1,6 +1,9 @@
 package gobot
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestCommaner(t *testing.T) {
 	c := NewCommander()
@@ -18,3 +21,56 @@ func TestCommaner(t *testing.T) {
 	command = c.Command("booyeah")
 	Assert(t, command, (func(map[string]interface{}) interface{})(nil))
 }
+
+func TestCommanderParams(t *testing.T) {
+	c := NewCommander()
+	c.AddCommandWithParams("Move", []Param{
+		{Name: "speed", Type: ParamInt, Required: true, Min: 0, Max: 255},
+		{Name: "reverse", Type: ParamBool, Default: false},
+	}, func(params map[string]interface{}) interface{} {
+		return params
+	})
+
+	Assert(t, len(c.Params("Move")), 2)
+	Assert(t, c.Command("Move")(map[string]interface{}{"speed": float64(100)}),
+		map[string]interface{}{"speed": 100, "reverse": false})
+	Assert(t, c.Command("Move")(map[string]interface{}{"speed": "12", "reverse": "true"}),
+		map[string]interface{}{"speed": 12, "reverse": true})
+	Assert(t, c.Command("Move")(map[string]interface{}{}),
+		errors.New("Missing parameter \"speed\""))
+	Assert(t, c.Command("Move")(map[string]interface{}{"speed": 300}),
+		errors.New("Parameter \"speed\": 300 is out of range 0-255"))
+	Assert(t, c.Command("Move")(map[string]interface{}{"speed": 1.5}),
+		errors.New("Parameter \"speed\": 1.5 is not an int"))
+	Assert(t, c.Command("Move")(map[string]interface{}{"speed": 1, "reverse": "maybe"}),
+		errors.New("Parameter \"reverse\": maybe is not a bool"))
+
+	c.AddCommand("Move", func(map[string]interface{}) interface{} { return nil })
+	Assert(t, c.Params("Move"), []Param(nil))
+}
+
+func TestCommanderMiddleware(t *testing.T) {
+	c := NewCommander()
+	c.AddCommand("Say", func(params map[string]interface{}) interface{} {
+		return params["message"]
+	})
+
+	calls := []string{}
+	c.Use(func(name string, params map[string]interface{}, next func(map[string]interface{}) interface{}) interface{} {
+		calls = append(calls, "before "+name)
+		result := next(params)
+		calls = append(calls, "after "+name)
+		return result
+	})
+	c.Use(func(name string, params map[string]interface{}, next func(map[string]interface{}) interface{}) interface{} {
+		if params["token"] != "secret" {
+			return errors.New("Unauthorized")
+		}
+		return next(map[string]interface{}{"message": "rewritten"})
+	})
+
+	Assert(t, c.Command("Say")(map[string]interface{}{"message": "hi", "token": "secret"}), "rewritten")
+	Assert(t, calls, []string{"before Say", "after Say"})
+	Assert(t, c.Command("Say")(map[string]interface{}{"message": "hi"}), errors.New("Unauthorized"))
+	Assert(t, c.Command("booyeah"), (func(map[string]interface{}) interface{})(nil))
+}
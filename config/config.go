@@ -0,0 +1,203 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"gopkg.in/yaml.v2"
+)
+
+// Config describes the robots of a Gobot, and the paths of the plugins
+// providing their adaptors and drivers, see LoadPlugin
+type Config struct {
+	Plugins []string `json:"plugins"`
+	Robots  []Robot  `json:"robots"`
+}
+
+// Robot describes a robot and its connections and devices
+type Robot struct {
+	Name        string            `json:"name"`
+	Tags        map[string]string `json:"tags"`
+	Connections []Connection      `json:"connections"`
+	Devices     []Device          `json:"devices"`
+}
+
+// Connection describes a connection, Adaptor is the name the adaptor was
+// registered with
+type Connection struct {
+	Name    string                 `json:"name"`
+	Adaptor string                 `json:"adaptor"`
+	Port    string                 `json:"port"`
+	Params  map[string]interface{} `json:"params"`
+}
+
+// Device describes a device, Driver is the name the driver was registered
+// with and Connection the name of the connection of the device
+type Device struct {
+	Name       string                 `json:"name"`
+	Driver     string                 `json:"driver"`
+	Connection string                 `json:"connection"`
+	Pin        string                 `json:"pin"`
+	Interval   Duration               `json:"interval"`
+	Params     map[string]interface{} `json:"params"`
+}
+
+// Duration is a time.Duration written as a string such as "10ms"
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string such as "10ms"
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %v", err)
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Load reads a JSON Config from r.
+func Load(r io.Reader) (*Config, error) {
+	c := &Config{}
+	if err := json.NewDecoder(r).Decode(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadYAML reads a YAML Config from r. It has the same fields as a JSON
+// Config, e.g. "interval" is a duration string such as "20ms".
+func LoadYAML(r io.Reader) (*Config, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err = yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(jsonValue(v)); err != nil {
+		return nil, err
+	}
+	return Load(bytes.NewReader(data))
+}
+
+// jsonValue returns v, decoded from YAML, with its maps keyed by strings so
+// it can be encoded as JSON
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = jsonValue(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = jsonValue(value)
+		}
+	}
+	return v
+}
+
+// LoadFile reads a Config from the file at path, as YAML if its extension is
+// ".yaml" or ".yml" and as JSON otherwise.
+func LoadFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	load := Load
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		load = LoadYAML
+	}
+	c, err := load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return c, nil
+}
+
+// Build returns a new Gobot with the robots, connections and devices of c.
+func (c *Config) Build() (*gobot.Gobot, error) {
+	for _, path := range c.Plugins {
+		if err := LoadPlugin(path); err != nil {
+			return nil, fmt.Errorf("Plugin %q: %v", path, err)
+		}
+	}
+	gbot := gobot.NewGobot()
+	for _, robot := range c.Robots {
+		r, err := robot.build()
+		if err != nil {
+			return nil, fmt.Errorf("Robot %q: %v", robot.Name, err)
+		}
+		gbot.AddRobot(r)
+	}
+	return gbot, nil
+}
+
+// build returns a new Robot with the connections and devices of r
+func (r Robot) build() (*gobot.Robot, error) {
+	robot := gobot.NewRobot(r.Name, gobot.Tags(r.Tags))
+	for _, connection := range r.Connections {
+		conn, err := connection.build()
+		if err != nil {
+			return nil, err
+		}
+		robot.AddConnection(conn)
+	}
+	for _, device := range r.Devices {
+		dev, err := device.build(robot)
+		if err != nil {
+			return nil, err
+		}
+		robot.AddDevice(dev)
+	}
+	return robot, nil
+}
+
+// build returns a new connection described by c
+func (c Connection) build() (gobot.Connection, error) {
+	factory, ok := adaptor(c.Adaptor)
+	if !ok {
+		return nil, fmt.Errorf("Connection %q: unknown adaptor %q", c.Name, c.Adaptor)
+	}
+	conn, err := factory(c)
+	if err != nil {
+		return nil, fmt.Errorf("Connection %q: %v", c.Name, err)
+	}
+	return conn, nil
+}
+
+// build returns a new device described by d on its connection of robot
+func (d Device) build(robot *gobot.Robot) (gobot.Device, error) {
+	factory, ok := driver(d.Driver)
+	if !ok {
+		return nil, fmt.Errorf("Device %q: unknown driver %q", d.Name, d.Driver)
+	}
+	conn := robot.Connection(d.Connection)
+	if conn == nil {
+		return nil, fmt.Errorf("Device %q: unknown connection %q", d.Name, d.Connection)
+	}
+	dev, err := factory(conn, d)
+	if err != nil {
+		return nil, fmt.Errorf("Device %q: %v", d.Name, err)
+	}
+	return dev, nil
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/firmata"
+	"github.com/hybridgroup/gobot/platforms/gpio"
+)
+
+const testConfig = `{
+	"robots": [{
+		"name": "bot",
+		"tags": {"zone": "lab"},
+		"connections": [
+			{"name": "arduino", "adaptor": "firmata", "port": "/dev/ttyACM0"}
+		],
+		"devices": [
+			{"name": "led", "driver": "led", "connection": "arduino", "pin": "13"},
+			{"name": "button", "driver": "button", "connection": "arduino", "pin": "2", "interval": "20ms"}
+		]
+	}]
+}`
+
+const testYAMLConfig = `
+robots:
+  - name: bot
+    tags:
+      zone: lab
+    connections:
+      - name: arduino
+        adaptor: firmata
+        port: /dev/ttyACM0
+    devices:
+      - {name: led, driver: led, connection: arduino, pin: "13"}
+      - name: button
+        driver: button
+        connection: arduino
+        pin: "2"
+        interval: 20ms
+        params:
+          1: one
+          modes: [input, pullup]
+`
+
+func TestLoad(t *testing.T) {
+	c, err := Load(strings.NewReader(testConfig))
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, c.Robots[0].Name, "bot")
+	gobot.Assert(t, c.Robots[0].Connections[0], Connection{
+		Name:    "arduino",
+		Adaptor: "firmata",
+		Port:    "/dev/ttyACM0",
+	})
+	gobot.Assert(t, c.Robots[0].Devices[1].Interval, Duration(20*time.Millisecond))
+
+	_, err = Load(strings.NewReader(`{"robots": [{"devices": [{"interval": "soon"}]}]}`))
+	gobot.Refute(t, err, nil)
+}
+
+func TestLoadYAML(t *testing.T) {
+	c, err := LoadYAML(strings.NewReader(testYAMLConfig))
+	gobot.Assert(t, err, nil)
+	fromJSON, _ := Load(strings.NewReader(testConfig))
+	gobot.Assert(t, c.Robots[0].Tags, fromJSON.Robots[0].Tags)
+	gobot.Assert(t, c.Robots[0].Connections, fromJSON.Robots[0].Connections)
+	gobot.Assert(t, c.Robots[0].Devices[0], fromJSON.Robots[0].Devices[0])
+	gobot.Assert(t, c.Robots[0].Devices[1].Interval, Duration(20*time.Millisecond))
+	gobot.Assert(t, c.Robots[0].Devices[1].Params, map[string]interface{}{
+		"1":     "one",
+		"modes": []interface{}{"input", "pullup"},
+	})
+
+	_, err = LoadYAML(strings.NewReader("robots: [{devices: [{interval: soon}]}]"))
+	gobot.Refute(t, err, nil)
+	_, err = LoadYAML(strings.NewReader("robots: ["))
+	gobot.Refute(t, err, nil)
+}
+
+func TestLoadFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gobot")
+	defer os.RemoveAll(dir)
+	for name, data := range map[string]string{
+		"robot.json": testConfig,
+		"robot.yaml": testYAMLConfig,
+		"robot.yml":  testYAMLConfig,
+	} {
+		path := filepath.Join(dir, name)
+		ioutil.WriteFile(path, []byte(data), 0644)
+		c, err := LoadFile(path)
+		gobot.Assert(t, err, nil)
+		gobot.Assert(t, c.Robots[0].Connections[0].Port, "/dev/ttyACM0")
+	}
+
+	// YAML is not read as JSON
+	path := filepath.Join(dir, "yaml.json")
+	ioutil.WriteFile(path, []byte(testYAMLConfig), 0644)
+	_, err := LoadFile(path)
+	gobot.Refute(t, err, nil)
+
+	_, err = LoadFile("does_not_exist.json")
+	gobot.Refute(t, err, nil)
+}
+
+func TestBuild(t *testing.T) {
+	c, _ := Load(strings.NewReader(testConfig))
+	gbot, err := c.Build()
+	gobot.Assert(t, err, nil)
+
+	robot := gbot.Robot("bot")
+	gobot.Refute(t, robot, (*gobot.Robot)(nil))
+	gobot.Assert(t, robot.Tags, gobot.Tags{"zone": "lab"})
+	arduino := robot.Connection("arduino").(*firmata.FirmataAdaptor)
+	gobot.Assert(t, arduino.Port(), "/dev/ttyACM0")
+	gobot.Assert(t, robot.Device("led").(*gpio.LedDriver).Pin(), "13")
+	gobot.Assert(t, robot.Device("button").(*gpio.ButtonDriver).Pin(), "2")
+}
+
+func TestBuildErrors(t *testing.T) {
+	c := &Config{Robots: []Robot{{
+		Name:        "bot",
+		Connections: []Connection{{Name: "loop", Adaptor: "loopback"}},
+	}}}
+	_, err := c.Build()
+	gobot.Assert(t, err, errors.New(`Robot "bot": Connection "loop": unknown adaptor "loopback"`))
+
+	RegisterAdaptor("loopback", func(c Connection) (gobot.Connection, error) {
+		return &testAdaptor{name: c.Name}, nil
+	})
+	c.Robots[0].Devices = []Device{{Name: "led", Driver: "led", Connection: "missing"}}
+	_, err = c.Build()
+	gobot.Assert(t, err, errors.New(`Robot "bot": Device "led": unknown connection "missing"`))
+
+	c.Robots[0].Devices = []Device{{Name: "led", Driver: "blinker", Connection: "loop"}}
+	_, err = c.Build()
+	gobot.Assert(t, err, errors.New(`Robot "bot": Device "led": unknown driver "blinker"`))
+}
+
+func TestBuildPluginError(t *testing.T) {
+	c, _ := Load(strings.NewReader(`{"plugins": ["/nonexistent/vendor.so"], "robots": []}`))
+	_, err := c.Build()
+	gobot.Refute(t, err, nil)
+	gobot.Assert(t, strings.HasPrefix(err.Error(), "Plugin \"/nonexistent/vendor.so\": "), true)
+}
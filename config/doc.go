@@ -0,0 +1,70 @@
+/*
+Package config builds a Gobot, its robots, connections and devices from a
+JSON or YAML description, so the wiring of a robot can be changed without
+recompiling it.
+
+Example:
+
+	{
+		"robots": [{
+			"name": "bot",
+			"tags": {"zone": "lab"},
+			"connections": [
+				{"name": "arduino", "adaptor": "firmata", "port": "/dev/ttyACM0"}
+			],
+			"devices": [
+				{"name": "led", "driver": "led", "connection": "arduino", "pin": "13"},
+				{"name": "button", "driver": "button", "connection": "arduino", "pin": "2", "interval": "20ms"}
+			]
+		}]
+	}
+
+	cfg, err := config.LoadFile("robot.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	gbot, err := cfg.Build()
+	if err != nil {
+		log.Fatal(err)
+	}
+	gbot.Robot("bot").Work = func() {
+		led := gbot.Robot("bot").Device("led").(*gpio.LedDriver)
+		gobot.Every(1*time.Second, func() {
+			led.Toggle()
+		})
+	}
+	gbot.Start()
+
+LoadFile reads files ending in ".yaml" or ".yml" as YAML, with the same
+fields:
+
+	robots:
+	  - name: bot
+	    tags: {zone: lab}
+	    connections:
+	      - {name: arduino, adaptor: firmata, port: /dev/ttyACM0}
+	    devices:
+	      - {name: led, driver: led, connection: arduino, pin: "13"}
+	      - {name: button, driver: button, connection: arduino, pin: "2", interval: 20ms}
+
+The "firmata" adaptor and the gpio drivers are registered by default, other
+adaptors and drivers can be added with RegisterAdaptor and RegisterDriver.
+Adaptors and drivers can also be provided by Go plugins listed under
+"plugins", which register them when loaded, without recompiling the program:
+
+	{
+		"plugins": ["/usr/lib/gobot/vendor.so"],
+		"robots": [...]
+	}
+
+A Reloader applies changes of the configuration to the running Gobot without
+restarting the process: new robots, connections and devices are started,
+changed ones are replaced and removed ones are halted. The file can be
+watched, or a new configuration pushed through the API, as YAML with a
+Content-Type of "application/yaml":
+
+	reloader := config.NewReloader(gbot, cfg)
+	reloader.WatchFile("robot.json", 1*time.Second)
+	a.Put("/api/config", reloader.ServeHTTP)
+*/
+package config
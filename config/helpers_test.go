@@ -0,0 +1,24 @@
+package config
+
+import "github.com/hybridgroup/gobot"
+
+type testAdaptor struct {
+	name string
+}
+
+func (t *testAdaptor) Finalize() (errs []error) { return }
+func (t *testAdaptor) Connect() (errs []error)  { return }
+func (t *testAdaptor) Name() string             { return t.name }
+
+type testDriver struct {
+	name       string
+	pin        string
+	connection gobot.Connection
+	started    bool
+	halted     bool
+}
+
+func (t *testDriver) Start() (errs []error)        { t.started = true; return }
+func (t *testDriver) Halt() (errs []error)         { t.halted = true; return }
+func (t *testDriver) Name() string                 { return t.name }
+func (t *testDriver) Connection() gobot.Connection { return t.connection }
@@ -0,0 +1,34 @@
+//go:build go1.8 && cgo && (linux || darwin || freebsd)
+// +build go1.8
+// +build cgo
+// +build linux darwin freebsd
+
+package config
+
+import "plugin"
+
+// LoadPlugin opens the Go plugin at path, built with -buildmode=plugin
+// against the same gobot sources, so the adaptors and drivers it registers
+// with RegisterAdaptor and RegisterDriver, from its init functions or an
+// exported func Register(), can be used by configurations. Loading the same
+// plugin again does nothing.
+func LoadPlugin(path string) error {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+	if plugins[path] {
+		return nil
+	}
+	p, err := plugin.Open(path)
+	if err != nil {
+		return err
+	}
+	if symbol, err := p.Lookup("Register"); err == nil {
+		register, ok := symbol.(func())
+		if !ok {
+			return errRegisterType
+		}
+		register()
+	}
+	plugins[path] = true
+	return nil
+}
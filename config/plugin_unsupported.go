@@ -0,0 +1,12 @@
+//go:build !go1.8 || !cgo || !(linux || darwin || freebsd)
+// +build !go1.8 !cgo !linux,!darwin,!freebsd
+
+package config
+
+import "errors"
+
+// LoadPlugin is not supported on this platform, Go plugins need cgo on
+// linux, darwin or freebsd.
+func LoadPlugin(path string) error {
+	return errors.New("plugins are not supported on this platform")
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/firmata"
+	"github.com/hybridgroup/gobot/platforms/gpio"
+)
+
+// AdaptorFactory returns a new connection described by c
+type AdaptorFactory func(c Connection) (gobot.Connection, error)
+
+// DriverFactory returns a new device described by d on connection
+type DriverFactory func(connection gobot.Connection, d Device) (gobot.Device, error)
+
+var (
+	registryMutex sync.Mutex
+	adaptors      = map[string]AdaptorFactory{}
+	drivers       = map[string]DriverFactory{}
+
+	// plugins holds the paths of the plugins loaded by LoadPlugin
+	pluginsMutex    sync.Mutex
+	plugins         = map[string]bool{}
+	errRegisterType = errors.New("plugin symbol Register is not a func()")
+)
+
+// RegisterAdaptor makes an adaptor available to configurations under name.
+// Registering a name again replaces the previous factory.
+func RegisterAdaptor(name string, factory AdaptorFactory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	adaptors[name] = factory
+}
+
+// RegisterDriver makes a driver available to configurations under name.
+// Registering a name again replaces the previous factory.
+func RegisterDriver(name string, factory DriverFactory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	drivers[name] = factory
+}
+
+// adaptor returns the factory of the adaptor registered as name
+func adaptor(name string) (factory AdaptorFactory, ok bool) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	factory, ok = adaptors[name]
+	return
+}
+
+// driver returns the factory of the driver registered as name
+func driver(name string) (factory DriverFactory, ok bool) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	factory, ok = drivers[name]
+	return
+}
+
+func init() {
+	RegisterAdaptor("firmata", func(c Connection) (gobot.Connection, error) {
+		return firmata.NewFirmataAdaptor(c.Name, c.Port), nil
+	})
+
+	RegisterDriver("led", func(connection gobot.Connection, d Device) (gobot.Device, error) {
+		a, ok := connection.(gpio.DigitalWriter)
+		if !ok {
+			return nil, notSupported(connection, "digital writes")
+		}
+		return gpio.NewLedDriver(a, d.Name, d.Pin), nil
+	})
+	RegisterDriver("motor", func(connection gobot.Connection, d Device) (gobot.Device, error) {
+		a, ok := connection.(gpio.DigitalWriter)
+		if !ok {
+			return nil, notSupported(connection, "digital writes")
+		}
+		return gpio.NewMotorDriver(a, d.Name, d.Pin), nil
+	})
+	RegisterDriver("servo", func(connection gobot.Connection, d Device) (gobot.Device, error) {
+		a, ok := connection.(gpio.ServoWriter)
+		if !ok {
+			return nil, notSupported(connection, "servo writes")
+		}
+		return gpio.NewServoDriver(a, d.Name, d.Pin), nil
+	})
+	RegisterDriver("direct_pin", func(connection gobot.Connection, d Device) (gobot.Device, error) {
+		return gpio.NewDirectPinDriver(connection, d.Name, d.Pin), nil
+	})
+	RegisterDriver("button", func(connection gobot.Connection, d Device) (gobot.Device, error) {
+		a, ok := connection.(gpio.DigitalReader)
+		if !ok {
+			return nil, notSupported(connection, "digital reads")
+		}
+		return gpio.NewButtonDriver(a, d.Name, d.Pin, intervals(d)...), nil
+	})
+	RegisterDriver("makey_button", func(connection gobot.Connection, d Device) (gobot.Device, error) {
+		a, ok := connection.(gpio.DigitalReader)
+		if !ok {
+			return nil, notSupported(connection, "digital reads")
+		}
+		return gpio.NewMakeyButtonDriver(a, d.Name, d.Pin, intervals(d)...), nil
+	})
+	RegisterDriver("analog_sensor", func(connection gobot.Connection, d Device) (gobot.Device, error) {
+		a, ok := connection.(gpio.AnalogReader)
+		if !ok {
+			return nil, notSupported(connection, "analog reads")
+		}
+		return gpio.NewAnalogSensorDriver(a, d.Name, d.Pin, intervals(d)...), nil
+	})
+}
+
+// intervals returns the polling interval of d as the optional argument of
+// the gpio driver constructors
+func intervals(d Device) []time.Duration {
+	if d.Interval == 0 {
+		return []time.Duration{}
+	}
+	return []time.Duration{time.Duration(d.Interval)}
+}
+
+// notSupported returns the error for a connection lacking what a driver needs
+func notSupported(connection gobot.Connection, what string) error {
+	return fmt.Errorf("connection %q does not support %v", connection.Name(), what)
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// Reloader reconciles a running Gobot with changes to the Config it was
+// built from, without restarting the process: robots, connections and
+// devices which were added are built and started, the ones which changed are
+// replaced, and the ones which were removed are halted and removed.
+type Reloader struct {
+	gobot  *gobot.Gobot
+	config *Config
+	mutex  sync.Mutex
+}
+
+// NewReloader returns a new Reloader of g, which was built from c.
+func NewReloader(g *gobot.Gobot, c *Config) *Reloader {
+	return &Reloader{gobot: g, config: c}
+}
+
+// Config returns the Config last applied.
+func (r *Reloader) Config() *Config {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.config
+}
+
+// Apply reconciles the Gobot with c. The errors of the robots which could
+// not be reconciled are returned, those robots are left as they were or
+// partly updated, and are reconciled again by the next Apply.
+// Apply may be called while the robots run, the robots, connections and
+// devices are added and removed under the locks of their collections, so
+// the API and the work of the robots see them before or after each change.
+func (r *Reloader) Apply(c *Config) (errs []error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, path := range c.Plugins {
+		if err := LoadPlugin(path); err != nil {
+			return []error{fmt.Errorf("Plugin %q: %v", path, err)}
+		}
+	}
+
+	previous := make(map[string]Robot)
+	for _, robot := range r.config.Robots {
+		previous[robot.Name] = robot
+	}
+	current := make(map[string]bool)
+	for _, robot := range c.Robots {
+		current[robot.Name] = true
+	}
+
+	for _, robot := range r.config.Robots {
+		if !current[robot.Name] {
+			gobot.Log(gobot.InfoLevel, "Removing robot", gobot.Fields{"robot": robot.Name})
+			if removed := r.gobot.RemoveRobot(robot.Name); removed != nil {
+				errs = append(errs, removed.Stop()...)
+			}
+		}
+	}
+	applied := &Config{Plugins: c.Plugins}
+	for _, robot := range c.Robots {
+		old, ok := previous[robot.Name]
+		var rerrs []error
+		if !ok || r.gobot.Robot(robot.Name) == nil {
+			rerrs = r.addRobot(robot)
+		} else {
+			rerrs = old.reconcile(robot, r.gobot.Robot(robot.Name))
+		}
+		for _, err := range rerrs {
+			errs = append(errs, fmt.Errorf("Robot %q: %v", robot.Name, err))
+		}
+		if len(rerrs) > 0 && ok {
+			// reconcile the robot again next time
+			robot = old
+		}
+		applied.Robots = append(applied.Robots, robot)
+	}
+	r.config = applied
+	for _, err := range errs {
+		gobot.Log(gobot.ErrorLevel, err.Error(), nil)
+	}
+	return
+}
+
+// addRobot builds, adds and starts robot
+func (r *Reloader) addRobot(robot Robot) []error {
+	gobot.Log(gobot.InfoLevel, "Adding robot", gobot.Fields{"robot": robot.Name})
+	built, err := robot.build()
+	if err != nil {
+		return []error{err}
+	}
+	return r.gobot.AddRobot(built).Start()
+}
+
+// reconcile updates robot, built from r, to be described by c
+func (r Robot) reconcile(c Robot, robot *gobot.Robot) (errs []error) {
+	robot.SetTags(gobot.Tags(c.Tags))
+
+	connections := make(map[string]Connection)
+	for _, connection := range c.Connections {
+		connections[connection.Name] = connection
+	}
+	devices := make(map[string]Device)
+	for _, device := range c.Devices {
+		devices[device.Name] = device
+	}
+
+	// remove the devices and connections which were removed or changed,
+	// along with the devices of changed connections
+	replaced := make(map[string]bool)
+	for _, connection := range r.Connections {
+		if !reflect.DeepEqual(connections[connection.Name], connection) {
+			replaced[connection.Name] = true
+		}
+	}
+	for _, device := range r.Devices {
+		if replaced[device.Connection] || !reflect.DeepEqual(devices[device.Name], device) {
+			gobot.Log(gobot.InfoLevel, "Removing device", gobot.Fields{"robot": robot.Name, "device": device.Name})
+			if removed := robot.RemoveDevice(device.Name); removed != nil {
+				errs = append(errs, removed.Halt()...)
+			}
+		}
+	}
+	for name := range replaced {
+		gobot.Log(gobot.InfoLevel, "Removing connection", gobot.Fields{"robot": robot.Name, "connection": name})
+		if removed := robot.RemoveConnection(name); removed != nil {
+			errs = append(errs, removed.Finalize()...)
+		}
+	}
+
+	// add the connections and devices which were added or changed
+	for _, connection := range c.Connections {
+		if robot.Connection(connection.Name) != nil {
+			continue
+		}
+		gobot.Log(gobot.InfoLevel, "Adding connection", gobot.Fields{"robot": robot.Name, "connection": connection.Name})
+		conn, err := connection.build()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if cerrs := conn.Connect(); len(cerrs) > 0 {
+			errs = append(errs, cerrs...)
+			continue
+		}
+		robot.AddConnection(conn)
+	}
+	for _, device := range c.Devices {
+		if robot.Device(device.Name) != nil {
+			continue
+		}
+		gobot.Log(gobot.InfoLevel, "Adding device", gobot.Fields{"robot": robot.Name, "device": device.Name})
+		dev, err := device.build(robot)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if derrs := dev.Start(); len(derrs) > 0 {
+			errs = append(errs, derrs...)
+			continue
+		}
+		robot.AddDevice(dev)
+	}
+	return
+}
+
+// WatchFile applies the Config in the file at path each time the file is
+// modified, checking it every interval. Stop the returned Timer to stop
+// watching. Errors loading or applying the file are logged.
+func (r *Reloader) WatchFile(path string, interval time.Duration) *gobot.Timer {
+	var (
+		mutex    sync.Mutex
+		modified time.Time
+	)
+	if info, err := os.Stat(path); err == nil {
+		modified = info.ModTime()
+	}
+	return gobot.Every(interval, func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().After(modified) {
+			return
+		}
+		modified = info.ModTime()
+		gobot.Log(gobot.InfoLevel, "Reloading configuration", gobot.Fields{"path": path})
+		c, err := LoadFile(path)
+		if err != nil {
+			gobot.Log(gobot.ErrorLevel, err.Error(), nil)
+			return
+		}
+		r.Apply(c)
+	})
+}
+
+// ServeHTTP applies the Config in the body of the request, YAML if its
+// Content-Type is "application/yaml", "application/x-yaml" or "text/yaml"
+// and JSON otherwise, so a new configuration can be pushed to a running
+// Gobot, e.g. with a.Put("/api/config", reloader.ServeHTTP) on an api.API.
+// Writes JSON with the errors of the robots which could not be reconciled.
+func (r *Reloader) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	load := Load
+	switch mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		load = LoadYAML
+	}
+	c, err := load(req.Body)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(res).Encode(map[string]interface{}{"error": err.Error()})
+		return
+	}
+	errs := []string{}
+	for _, err := range r.Apply(c) {
+		errs = append(errs, err.Error())
+	}
+	json.NewEncoder(res).Encode(map[string]interface{}{"errors": errs})
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func init() {
+	RegisterAdaptor("reload", func(c Connection) (gobot.Connection, error) {
+		return &testAdaptor{name: c.Name}, nil
+	})
+	RegisterDriver("reload", func(connection gobot.Connection, d Device) (gobot.Device, error) {
+		return &testDriver{name: d.Name, pin: d.Pin, connection: connection}, nil
+	})
+}
+
+const testReloadConfig = `{
+	"robots": [{
+		"name": "bot",
+		"connections": [{"name": "loop", "adaptor": "reload"}],
+		"devices": [
+			{"name": "led", "driver": "reload", "connection": "loop", "pin": "13"},
+			{"name": "button", "driver": "reload", "connection": "loop", "pin": "2"}
+		]
+	}]
+}`
+
+func TestReloaderApply(t *testing.T) {
+	c, _ := Load(strings.NewReader(testReloadConfig))
+	gbot, _ := c.Build()
+	reloader := NewReloader(gbot, c)
+	robot := gbot.Robot("bot")
+	led := robot.Device("led").(*testDriver)
+	button := robot.Device("button").(*testDriver)
+
+	c, _ = Load(strings.NewReader(`{
+		"robots": [{
+			"name": "bot",
+			"tags": {"zone": "lab"},
+			"connections": [{"name": "loop", "adaptor": "reload"}],
+			"devices": [
+				{"name": "led", "driver": "reload", "connection": "loop", "pin": "12"},
+				{"name": "buzzer", "driver": "reload", "connection": "loop", "pin": "3"}
+			]
+		}, {
+			"name": "rover",
+			"connections": [{"name": "loop", "adaptor": "reload"}]
+		}]
+	}`))
+	gobot.Assert(t, len(reloader.Apply(c)), 0)
+	gobot.Assert(t, reloader.Config(), c)
+
+	gobot.Assert(t, gbot.Robot("bot"), robot)
+	gobot.Assert(t, robot.Tags, gobot.Tags{"zone": "lab"})
+	gobot.Assert(t, led.halted, true)
+	gobot.Assert(t, button.halted, true)
+	gobot.Assert(t, robot.Device("button"), nil)
+	gobot.Assert(t, robot.Device("led").(*testDriver).pin, "12")
+	gobot.Assert(t, robot.Device("led").(*testDriver).started, true)
+	gobot.Assert(t, robot.Device("buzzer").(*testDriver).started, true)
+	gobot.Refute(t, gbot.Robot("rover"), (*gobot.Robot)(nil))
+
+	c, _ = Load(strings.NewReader(`{"robots": [{"name": "rover", "connections": [{"name": "loop", "adaptor": "reload"}]}]}`))
+	gobot.Assert(t, len(reloader.Apply(c)), 0)
+	gobot.Assert(t, gbot.Robot("bot"), (*gobot.Robot)(nil))
+	gobot.Refute(t, gbot.Robot("rover"), (*gobot.Robot)(nil))
+}
+
+func TestReloaderApplyConnectionChanged(t *testing.T) {
+	c, _ := Load(strings.NewReader(testReloadConfig))
+	gbot, _ := c.Build()
+	reloader := NewReloader(gbot, c)
+	robot := gbot.Robot("bot")
+	loop := robot.Connection("loop")
+
+	c, _ = Load(strings.NewReader(strings.Replace(testReloadConfig, `"adaptor": "reload"`, `"adaptor": "reload", "port": "/dev/ttyACM1"`, 1)))
+	gobot.Assert(t, len(reloader.Apply(c)), 0)
+	gobot.Assert(t, robot.Connection("loop") != loop, true)
+	gobot.Assert(t, robot.Device("led").Connection(), robot.Connection("loop"))
+	gobot.Assert(t, robot.Device("button").Connection(), robot.Connection("loop"))
+}
+
+func TestReloaderApplyWhileRunning(t *testing.T) {
+	c, _ := Load(strings.NewReader(testReloadConfig))
+	gbot, _ := c.Build()
+	reloader := NewReloader(gbot, c)
+	changed, _ := Load(strings.NewReader(strings.Replace(testReloadConfig, `"pin": "13"`, `"pin": "12"`, 1)))
+
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			if i%2 == 0 {
+				reloader.Apply(changed)
+			} else {
+				reloader.Apply(c)
+			}
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			gobot.Assert(t, gbot.Robot("bot").Devices().Len(), 2)
+			return
+		default:
+		}
+		gbot.Robots().Each(func(robot *gobot.Robot) {
+			robot.Devices().Each(func(device gobot.Device) {
+				robot.Device(device.Name())
+			})
+			robot.Connection("loop")
+		})
+	}
+}
+
+func TestReloaderApplyErrors(t *testing.T) {
+	c, _ := Load(strings.NewReader(testReloadConfig))
+	gbot, _ := c.Build()
+	reloader := NewReloader(gbot, c)
+
+	c, _ = Load(strings.NewReader(strings.Replace(testReloadConfig, `"pin": "13"`, `"pin": "13", "driver": "blinker"`, 1)))
+	errs := reloader.Apply(c)
+	gobot.Assert(t, errs, []error{errors.New(`Robot "bot": Device "led": unknown driver "blinker"`)})
+	gobot.Assert(t, gbot.Robot("bot").Device("led"), nil)
+
+	// the failed device is added once fixed
+	c, _ = Load(strings.NewReader(testReloadConfig))
+	gobot.Assert(t, len(reloader.Apply(c)), 0)
+	gobot.Refute(t, gbot.Robot("bot").Device("led"), nil)
+}
+
+func TestReloaderWatchFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gobot")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "robot.json")
+	ioutil.WriteFile(path, []byte(testReloadConfig), 0644)
+
+	c, _ := LoadFile(path)
+	gbot, _ := c.Build()
+	reloader := NewReloader(gbot, c)
+	timer := reloader.WatchFile(path, 5*time.Millisecond)
+	defer timer.Stop()
+
+	ioutil.WriteFile(path, []byte(`{"robots": []}`), 0644)
+	later := time.Now().Add(time.Second)
+	os.Chtimes(path, later, later)
+	deadline := time.Now().Add(time.Second)
+	for len(reloader.Config().Robots) > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	gobot.Assert(t, len(reloader.Config().Robots), 0)
+	gobot.Assert(t, gbot.Robot("bot"), (*gobot.Robot)(nil))
+}
+
+func TestReloaderServeHTTP(t *testing.T) {
+	c, _ := Load(strings.NewReader(testReloadConfig))
+	gbot, _ := c.Build()
+	reloader := NewReloader(gbot, c)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/api/config", strings.NewReader("{"))
+	reloader.ServeHTTP(res, req)
+	gobot.Assert(t, res.Code, http.StatusBadRequest)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/api/config", strings.NewReader(`{"robots": []}`))
+	reloader.ServeHTTP(res, req)
+	gobot.Assert(t, res.Code, http.StatusOK)
+	gobot.Assert(t, strings.TrimSpace(res.Body.String()), `{"errors":[]}`)
+	gobot.Assert(t, gbot.Robot("bot"), (*gobot.Robot)(nil))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/api/config", strings.NewReader(testYAMLConfig))
+	req.Header.Set("Content-Type", "application/yaml")
+	reloader.ServeHTTP(res, req)
+	gobot.Assert(t, res.Code, http.StatusOK)
+	gobot.Refute(t, gbot.Robot("bot"), (*gobot.Robot)(nil))
+	gobot.Assert(t, reloader.Config().Robots[0].Devices[1].Interval, Duration(20*time.Millisecond))
+}
@@ -2,8 +2,8 @@ package gobot
 
 import (
 	"fmt"
-	"log"
 	"reflect"
+	"time"
 )
 
 // JSONConnection is a JSON representation of a Connection.
@@ -40,30 +40,53 @@ func (c *Connections) Each(f func(Connection)) {
 
 // Start calls Connect on each Connection in c
 func (c *Connections) Start() (errs []error) {
-	log.Println("Starting connections...")
 	for _, connection := range *c {
-		info := "Starting connection " + connection.Name()
-
-		if porter, ok := connection.(Porter); ok {
-			info = info + " on port " + porter.Port()
+		if errs, _ = startConnection(connection, nil, 0); len(errs) > 0 {
+			return
 		}
+	}
+	return
+}
 
-		log.Println(info + "...")
+// startConnection calls Connect on connection within timeout, logging fields
+// with the connection
+func startConnection(connection Connection, fields Fields, timeout time.Duration) (errs []error, timedOut bool) {
+	info := Fields{"connection": connection.Name()}
+	for key, value := range fields {
+		info[key] = value
+	}
+	if porter, ok := connection.(Porter); ok {
+		info["port"] = porter.Port()
+	}
+	Log(InfoLevel, "Starting connection", info)
 
-		if errs = connection.Connect(); len(errs) > 0 {
-			for i, err := range errs {
-				errs[i] = fmt.Errorf("Connection %q: %v", connection.Name(), err)
-			}
-			return
+	if errs, timedOut = callWithTimeout("Connect", timeout, connection.Connect); len(errs) > 0 {
+		for i, err := range errs {
+			errs[i] = fmt.Errorf("Connection %q: %v", connection.Name(), err)
 		}
 	}
 	return
 }
 
-// Finalize calls Finalize on each Connection in c
+// Finalize calls Finalize on each Connection in c, in the reverse order they
+// were started
 func (c *Connections) Finalize() (errs []error) {
-	for _, connection := range *c {
-		if cerrs := connection.Finalize(); cerrs != nil {
+	return c.finalize(0, nil)
+}
+
+// finalize calls Finalize on each Connection in c in reverse order, giving
+// each of them timeout to return. Each Finalize is traced by the span
+// returned by start given the name of the connection, unless start is nil.
+func (c *Connections) finalize(timeout time.Duration, start func(name string) Span) (errs []error) {
+	for i := len(*c) - 1; i >= 0; i-- {
+		connection := (*c)[i]
+		var span Span = noopSpan{}
+		if start != nil {
+			span = start(connection.Name())
+		}
+		cerrs, _ := callWithTimeout("Finalize", timeout, connection.Finalize)
+		span.End(spanError(cerrs))
+		if cerrs != nil {
 			for i, err := range cerrs {
 				cerrs[i] = fmt.Errorf("Connection %q: %v", connection.Name(), err)
 			}
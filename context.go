@@ -0,0 +1,88 @@
+//go:build go1.7
+// +build go1.7
+
+package gobot
+
+import (
+	"context"
+	"time"
+)
+
+var _ context.Context = robotContext{}
+
+// robotContext is a context.Context which is cancelled when a robot stops
+type robotContext struct {
+	done <-chan struct{}
+}
+
+func (robotContext) Deadline() (deadline time.Time, ok bool) { return }
+func (c robotContext) Done() <-chan struct{}                 { return c.done }
+func (robotContext) Value(key interface{}) interface{}       { return nil }
+
+func (c robotContext) Err() error {
+	select {
+	case <-c.done:
+		return context.Canceled
+	default:
+		return nil
+	}
+}
+
+// Context returns a context.Context which is cancelled when the robot is
+// stopped. A robot which is started again after being stopped hands out a
+// new Context.
+func (r *Robot) Context() context.Context {
+	return robotContext{done: r.Stopped()}
+}
+
+// WorkContext sets the work of the robot to f, which is called with the
+// robot's Context when the robot starts so long running work can return once
+// the robot is stopped.
+func (r *Robot) WorkContext(f func(ctx context.Context)) {
+	r.Work = func() {
+		f(r.Context())
+	}
+}
+
+// EveryContext triggers f every t time until ctx is done. Like Every, it does
+// not wait for the previous execution of f to finish before it fires the
+// next f.
+func EveryContext(ctx context.Context, t time.Duration, f func()) {
+	ticker := clock().NewTicker(t)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				go f()
+			}
+		}
+	}()
+}
+
+// AfterContext triggers f after t duration, unless ctx is done before then.
+func AfterContext(ctx context.Context, t time.Duration, f func()) {
+	timer := clock().NewTimer(t)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C():
+			f()
+		}
+	}()
+}
+
+// Retry calls f until it returns nil, waiting between calls as described by
+// policy. It returns the error of the last call once policy.MaxAttempts
+// calls have failed, or ctx.Err() if ctx is done first.
+func Retry(ctx context.Context, policy RetryPolicy, f func() error) error {
+	if err := RetryUntil(ctx.Done(), policy, f); err != ErrRetryStopped {
+		return err
+	}
+	return ctx.Err()
+}
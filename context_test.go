@@ -0,0 +1,84 @@
+//go:build go1.7
+// +build go1.7
+
+package gobot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRobotContext(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := NewRobot("Robot1")
+	var ctx context.Context
+	r.WorkContext(func(c context.Context) {
+		ctx = c
+	})
+	Assert(t, len(r.Start()), 0)
+	Assert(t, ctx.Err(), nil)
+
+	r.Stop()
+	<-ctx.Done()
+	Assert(t, ctx.Err(), context.Canceled)
+
+	// restarting the robot hands out a new context
+	stopped := ctx
+	r.Start()
+	Assert(t, stopped.Err(), context.Canceled)
+	Assert(t, ctx.Err(), nil)
+}
+
+func TestEveryContext(t *testing.T) {
+	var calls int32
+	ctx, cancel := context.WithCancel(context.Background())
+	EveryContext(ctx, time.Millisecond, func() {
+		atomic.AddInt32(&calls, 1)
+	})
+	<-time.After(10 * time.Millisecond)
+	cancel()
+	<-time.After(2 * time.Millisecond)
+	stopped := atomic.LoadInt32(&calls)
+	Refute(t, stopped, int32(0))
+	<-time.After(10 * time.Millisecond)
+	Assert(t, atomic.LoadInt32(&calls), stopped)
+}
+
+func TestAfterContext(t *testing.T) {
+	fired := make(chan bool, 2)
+	AfterContext(context.Background(), time.Millisecond, func() {
+		fired <- true
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	AfterContext(ctx, 5*time.Millisecond, func() {
+		fired <- false
+	})
+	cancel()
+	<-time.After(10 * time.Millisecond)
+	Assert(t, len(fired), 1)
+	Assert(t, <-fired, true)
+}
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), RetryPolicy{}, func() error {
+		calls++
+		if calls < 2 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	Assert(t, err, nil)
+	Assert(t, calls, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Retry(ctx, RetryPolicy{Backoff: time.Hour}, func() error {
+		return errors.New("broken")
+	})
+	Assert(t, err, context.Canceled)
+}
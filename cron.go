@@ -0,0 +1,229 @@
+package gobot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cronDescriptors are the shorthands accepted by Cron
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField is the set of values one field of a cron spec matches
+type cronField struct {
+	values map[int]bool
+	any    bool
+}
+
+// schedule is a parsed cron spec
+type schedule struct {
+	minute, hour, dom, month, dow cronField
+}
+
+// CronJob is a function scheduled with Cron
+type CronJob struct {
+	schedule *schedule
+	location *time.Location
+	jitter   time.Duration
+	f        func()
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Cron calls f on the wall-clock schedule given by spec in the standard five
+// field cron format, "minute hour day-of-month month day-of-week", e.g.
+// "0 */5 * * *" at minute 0 of every fifth hour. Fields accept *, numbers,
+// ranges like 1-5, steps like */15 or 0-30/10 and comma separated lists of
+// those. Days of the week are numbered 0 (Sunday) to 6, 7 is also Sunday.
+// The shorthands @yearly, @monthly, @weekly, @daily and @hourly may be
+// used instead. Cron optionally accepts:
+//
+//	*time.Location: The timezone the schedule is in, defaults to time.Local
+//	time.Duration: A jitter, each call of f is delayed by a random duration up to it
+//
+// Like Every, f is called in a new goroutine without waiting for the
+// previous call to return. Returns an error if spec is invalid.
+func Cron(spec string, f func(), v ...interface{}) (*CronJob, error) {
+	s, err := parseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+	job := &CronJob{
+		schedule: s,
+		location: time.Local,
+		f:        f,
+		done:     make(chan struct{}),
+	}
+	for i := range v {
+		switch v[i].(type) {
+		case *time.Location:
+			job.location = v[i].(*time.Location)
+		case time.Duration:
+			job.jitter = v[i].(time.Duration)
+		}
+	}
+	if job.Next(clock().Now()).IsZero() {
+		return nil, fmt.Errorf("Cron spec %q never fires", spec)
+	}
+	go job.run()
+	return job, nil
+}
+
+// Next returns the first time after t at which the job is due, without
+// jitter. Returns the zero time if the schedule never fires.
+func (c *CronJob) Next(t time.Time) time.Time {
+	return c.schedule.next(t.In(c.location))
+}
+
+// Stop cancels the job. Calls of f in progress are not interrupted.
+func (c *CronJob) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// run calls f each time the job is due until it is stopped
+func (c *CronJob) run() {
+	for {
+		next := c.Next(clock().Now())
+		if next.IsZero() {
+			return
+		}
+		delay := next.Sub(clock().Now())
+		if c.jitter > 0 {
+			delay += time.Duration(Rand(int(c.jitter)))
+		}
+		timer := clock().NewTimer(delay)
+		select {
+		case <-c.done:
+			timer.Stop()
+			return
+		case <-timer.C():
+			go c.f()
+		}
+	}
+}
+
+// next returns the first time after t matching s, or the zero time if none
+// is found within five years
+func (s *schedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !s.month.values[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.hour.values[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !s.minute.values[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the day of t matches s. As in cron, when both
+// the day of the month and the day of the week are restricted either one
+// matching is enough.
+func (s *schedule) dayMatches(t time.Time) bool {
+	dom := s.dom.values[t.Day()]
+	dow := s.dow.values[int(t.Weekday())]
+	if s.dom.any || s.dow.any {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// parseCron parses a cron spec
+func parseCron(spec string) (s *schedule, err error) {
+	if descriptor, ok := cronDescriptors[spec]; ok {
+		spec = descriptor
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("Cron spec %q does not have 5 fields", spec)
+	}
+	s = &schedule{}
+	bounds := []struct {
+		field    *cronField
+		min, max int
+	}{
+		{&s.minute, 0, 59},
+		{&s.hour, 0, 23},
+		{&s.dom, 1, 31},
+		{&s.month, 1, 12},
+		{&s.dow, 0, 7},
+	}
+	for i, b := range bounds {
+		if *b.field, err = parseCronField(fields[i], b.min, b.max); err != nil {
+			return nil, fmt.Errorf("Cron spec %q: %v", spec, err)
+		}
+	}
+	if s.dow.values[7] {
+		s.dow.values[0] = true
+	}
+	return
+}
+
+// parseCronField parses one field of a cron spec whose values range from min
+// to max
+func parseCronField(field string, min int, max int) (c cronField, err error) {
+	c = cronField{values: make(map[int]bool), any: field == "*"}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return c, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+		first, last := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			if first, err = strconv.Atoi(bounds[0]); err != nil {
+				return c, fmt.Errorf("invalid range %q", part)
+			}
+			if last, err = strconv.Atoi(bounds[1]); err != nil {
+				return c, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			if first, err = strconv.Atoi(part); err != nil {
+				return c, fmt.Errorf("invalid value %q", part)
+			}
+			last = first
+			if step > 1 {
+				last = max
+			}
+		}
+		if first < min || last > max || first > last {
+			return c, fmt.Errorf("%q is out of range %v-%v", part, min, max)
+		}
+		for v := first; v <= last; v += step {
+			c.values[v] = true
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,68 @@
+package gobot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	utc := time.UTC
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2015, month, day, hour, minute, 0, 0, utc)
+	}
+	start := time.Date(2015, 1, 1, 12, 34, 56, 0, utc) // a Thursday
+
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", at(1, 1, 12, 35)},
+		{"0 */5 * * *", at(1, 1, 15, 0)},
+		{"30 8-10 * * *", at(1, 2, 8, 30)},
+		{"0,45 * * * *", at(1, 1, 12, 45)},
+		{"0 0 1 */3 *", at(4, 1, 0, 0)},
+		{"0 9 * * 1-5", at(1, 2, 9, 0)},
+		{"0 9 * * 7", at(1, 4, 9, 0)},
+		{"0 9 15 * 6", at(1, 3, 9, 0)},
+		{"@daily", at(1, 2, 0, 0)},
+		{"@monthly", at(2, 1, 0, 0)},
+	}
+	for _, test := range tests {
+		s, err := parseCron(test.spec)
+		Assert(t, err, nil)
+		Assert(t, s.next(start), test.next)
+	}
+}
+
+func TestCronTimezone(t *testing.T) {
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	job, err := Cron("0 12 * * *", func() {}, zone)
+	Assert(t, err, nil)
+	defer job.Stop()
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	Assert(t, job.Next(start).Equal(time.Date(2015, 1, 1, 10, 0, 0, 0, time.UTC)), true)
+}
+
+func TestCronErrors(t *testing.T) {
+	_, err := Cron("* * * *", func() {})
+	Assert(t, err, errors.New("Cron spec \"* * * *\" does not have 5 fields"))
+	_, err = Cron("60 * * * *", func() {})
+	Assert(t, err, errors.New("Cron spec \"60 * * * *\": \"60\" is out of range 0-59"))
+	_, err = Cron("*/0 * * * *", func() {})
+	Assert(t, err, errors.New("Cron spec \"*/0 * * * *\": invalid step in \"*/0\""))
+	_, err = Cron("0 0 30 2 *", func() {})
+	Assert(t, err, errors.New("Cron spec \"0 0 30 2 *\" never fires"))
+}
+
+func TestCronStop(t *testing.T) {
+	job, err := Cron("* * * * *", func() {}, time.Second)
+	Assert(t, err, nil)
+	job.Stop()
+	job.Stop()
+	select {
+	case <-job.done:
+	default:
+		t.Errorf("job was not stopped")
+	}
+}
@@ -0,0 +1,71 @@
+package gobot
+
+import (
+	"sync"
+	"time"
+)
+
+// Edge decides on which edge of a burst of calls Debounce calls f
+type Edge int
+
+const (
+	// TrailingEdge calls f with the data of the last call of a burst, once
+	// no call has been made for the wait
+	TrailingEdge Edge = 1 << iota
+	// LeadingEdge calls f with the data of the first call of a burst
+	LeadingEdge
+	// BothEdges calls f on the leading and the trailing edge, a burst of a
+	// single call only calls f once
+	BothEdges = LeadingEdge | TrailingEdge
+)
+
+// Debounce returns a function which treats calls made less than wait apart
+// as a single burst and only calls f on the given edge of the burst, e.g. to
+// ignore a bouncing button or limit switch:
+//
+//	gobot.On(button.Event("push"), gobot.Debounce(50*time.Millisecond, gobot.LeadingEdge, func(data interface{}) {
+//		fmt.Println("pushed")
+//	}))
+//
+// An Edge of 0 is TrailingEdge.
+func Debounce(wait time.Duration, edge Edge, f func(data interface{})) func(data interface{}) {
+	if edge == 0 {
+		edge = TrailingEdge
+	}
+	var (
+		mutex  sync.Mutex
+		timer  ClockTimer
+		burst  int
+		latest interface{}
+	)
+	var fire func(call int)
+	fire = func(call int) {
+		mutex.Lock()
+		if call != burst {
+			// a later call of the burst restarted the wait
+			mutex.Unlock()
+			return
+		}
+		data, calls := latest, burst
+		timer, burst, latest = nil, 0, nil
+		mutex.Unlock()
+		if edge&TrailingEdge != 0 && (edge&LeadingEdge == 0 || calls > 1) {
+			f(data)
+		}
+	}
+	return func(data interface{}) {
+		mutex.Lock()
+		leading := burst == 0
+		burst++
+		latest = data
+		if timer != nil {
+			timer.Stop()
+		}
+		call := burst
+		timer = clock().AfterFunc(wait, func() { fire(call) })
+		mutex.Unlock()
+		if leading && edge&LeadingEdge != 0 {
+			f(data)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package gobot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDebounce(t *testing.T) {
+	r := &recorder{}
+	f := Debounce(20*time.Millisecond, TrailingEdge, r.record)
+	f(1)
+	f(2)
+	<-time.After(10 * time.Millisecond)
+	f(3)
+	<-time.After(10 * time.Millisecond)
+	// the wait restarts with each call
+	Assert(t, len(r.recorded()), 0)
+
+	<-time.After(20 * time.Millisecond)
+	Assert(t, r.recorded(), []interface{}{3})
+}
+
+func TestDebounceLeadingEdge(t *testing.T) {
+	r := &recorder{}
+	f := Debounce(20*time.Millisecond, LeadingEdge, r.record)
+	f(1)
+	f(2)
+	f(3)
+	Assert(t, r.recorded(), []interface{}{1})
+
+	<-time.After(30 * time.Millisecond)
+	Assert(t, r.recorded(), []interface{}{1})
+	f(4)
+	Assert(t, r.recorded(), []interface{}{1, 4})
+}
+
+func TestDebounceBothEdges(t *testing.T) {
+	r := &recorder{}
+	f := Debounce(20*time.Millisecond, BothEdges, r.record)
+	f(1)
+	<-time.After(30 * time.Millisecond)
+	// a single call is only passed on once
+	Assert(t, r.recorded(), []interface{}{1})
+
+	f(2)
+	f(3)
+	<-time.After(30 * time.Millisecond)
+	Assert(t, r.recorded(), []interface{}{1, 2, 3})
+}
+
+func TestOnDebounced(t *testing.T) {
+	e := NewEvent()
+	values := make(chan interface{}, 10)
+	OnDebounced(e, 20*time.Millisecond, 0, func(data interface{}) {
+		values <- data
+	})
+
+	publishSpaced(e, 3)
+	select {
+	case v := <-values:
+		Assert(t, v, 2)
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("value was not handled")
+	}
+	select {
+	case v := <-values:
+		t.Fatalf("unexpected value %v", v)
+	case <-time.After(40 * time.Millisecond):
+	}
+
+	Assert(t, OnDebounced(nil, time.Millisecond, 0, func(interface{}) {}), ErrUnknownEvent)
+}
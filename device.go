@@ -2,16 +2,20 @@ package gobot
 
 import (
 	"fmt"
-	"log"
 	"reflect"
+	"strings"
+	"time"
 )
 
 // JSONDevice is a JSON representation of a Device.
 type JSONDevice struct {
-	Name       string   `json:"name"`
-	Driver     string   `json:"driver"`
-	Connection string   `json:"connection"`
-	Commands   []string `json:"commands"`
+	Name          string             `json:"name"`
+	Driver        string             `json:"driver"`
+	Connection    string             `json:"connection"`
+	Commands      []string           `json:"commands"`
+	CommandParams map[string][]Param `json:"command_params,omitempty"`
+	// Disabled is true for a device taken offline with Robot.DisableDevice
+	Disabled bool `json:"disabled"`
 }
 
 // NewJSONDevice returns a JSONDevice given a Device.
@@ -29,6 +33,7 @@ func NewJSONDevice(device Device) *JSONDevice {
 		for command := range commander.Commands() {
 			jsonDevice.Commands = append(jsonDevice.Commands, command)
 		}
+		jsonDevice.CommandParams = commandParams(commander)
 	}
 	return jsonDevice
 }
@@ -51,35 +56,112 @@ func (d *Devices) Each(f func(Device)) {
 	}
 }
 
-// Start calls Start on each Device in d
-func (d *Devices) Start() (errs []error) {
-	log.Println("Starting devices...")
+// Sort orders the Devices in d so every Device comes after the devices it
+// depends on, keeping the order they were added in otherwise. Returns an
+// error if a Device depends on an unknown device or the dependencies form a
+// cycle, in which case d is left untouched.
+func (d *Devices) Sort() error {
+	byName := make(map[string]Device)
 	for _, device := range *d {
-		info := "Starting device " + device.Name()
+		byName[device.Name()] = device
+	}
 
-		if pinner, ok := device.(Pinner); ok {
-			info = info + " on pin " + pinner.Pin()
-		}
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int)
+	path := []string{}
+	sorted := Devices{}
 
-		log.Println(info + "...")
-		if errs = device.Start(); len(errs) > 0 {
-			for i, err := range errs {
-				errs[i] = fmt.Errorf("Device %q: %v", device.Name(), err)
+	var visit func(device Device) error
+	visit = func(device Device) error {
+		name := device.Name()
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i := range path {
+				if path[i] == name {
+					return fmt.Errorf("Dependency cycle: %v",
+						strings.Join(append(path[i:], name), " -> "))
+				}
 			}
+		}
+		state[name] = visiting
+		path = append(path, name)
+		if depender, ok := device.(Depender); ok {
+			for _, dependency := range depender.Dependencies() {
+				dep, ok := byName[dependency]
+				if !ok {
+					return fmt.Errorf("Device %q depends on unknown device %q", name, dependency)
+				}
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		sorted = append(sorted, device)
+		return nil
+	}
+
+	for _, device := range *d {
+		if err := visit(device); err != nil {
+			return err
+		}
+	}
+	*d = sorted
+	return nil
+}
+
+// Start calls Start on each Device in d
+func (d *Devices) Start() (errs []error) {
+	for _, device := range *d {
+		if errs, _ = startDevice(device, nil, 0); len(errs) > 0 {
 			return
 		}
 	}
 	return
 }
 
-// Halt calls Halt on each Device in d
+// startDevice calls Start on device within timeout, logging fields with the
+// device
+func startDevice(device Device, fields Fields, timeout time.Duration) (errs []error, timedOut bool) {
+	info := Fields{"device": device.Name()}
+	for key, value := range fields {
+		info[key] = value
+	}
+	if pinner, ok := device.(Pinner); ok {
+		info["pin"] = pinner.Pin()
+	}
+	Log(InfoLevel, "Starting device", info)
+	if errs, timedOut = callWithTimeout("Start", timeout, device.Start); len(errs) > 0 {
+		errs = deviceErrors(device.Name(), "Start", errs)
+	}
+	return
+}
+
+// Halt calls Halt on each Device in d, in the reverse order they were started
 func (d *Devices) Halt() (errs []error) {
-	for _, device := range *d {
-		if derrs := device.Halt(); len(derrs) > 0 {
-			for i, err := range derrs {
-				derrs[i] = fmt.Errorf("Device %q: %v", device.Name(), err)
-			}
-			errs = append(errs, derrs...)
+	return d.halt(0, nil)
+}
+
+// halt calls Halt on each Device in d in reverse order, giving each of them
+// timeout to return. Each Halt is traced by the span returned by start given
+// the name of the device, unless start is nil.
+func (d *Devices) halt(timeout time.Duration, start func(name string) Span) (errs []error) {
+	for i := len(*d) - 1; i >= 0; i-- {
+		device := (*d)[i]
+		var span Span = noopSpan{}
+		if start != nil {
+			span = start(device.Name())
+		}
+		derrs, _ := callWithTimeout("Halt", timeout, device.Halt)
+		span.End(spanError(derrs))
+		if len(derrs) > 0 {
+			errs = append(errs, deviceErrors(device.Name(), "Halt", derrs)...)
 		}
 	}
 	return
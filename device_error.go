@@ -0,0 +1,87 @@
+package gobot
+
+import "fmt"
+
+// DeviceError is an error of a device, telling what the device was doing and
+// whether trying again may succeed, so a missing sensor can be told apart
+// from a read which timed out. Devices publish DeviceErrors on their "error"
+// event, and the robot publishes the errors of all its devices on its
+// "device_error" event.
+type DeviceError struct {
+	Device string
+	// Op is what the device was doing, e.g. "Start", "Halt" or
+	// "DigitalRead"
+	Op  string
+	Err error
+	// Retryable is true if trying again may succeed, e.g. after a timeout
+	// or a garbled read, and false if the device needs attention, e.g. when
+	// it is not connected
+	Retryable bool
+}
+
+// NewDeviceError returns a new DeviceError.
+func NewDeviceError(device string, op string, err error, retryable bool) *DeviceError {
+	return &DeviceError{
+		Device:    device,
+		Op:        op,
+		Err:       err,
+		Retryable: retryable,
+	}
+}
+
+func (e *DeviceError) Error() string {
+	return fmt.Sprintf("Device %q: %v", e.Device, e.Err)
+}
+
+// IsRetryable returns true if err is a Retryable DeviceError or a
+// TimeoutError.
+func IsRetryable(err error) bool {
+	switch e := err.(type) {
+	case *DeviceError:
+		return e.Retryable
+	case TimeoutError:
+		return true
+	}
+	return false
+}
+
+// deviceErrors returns errs as DeviceErrors of device, the errors of op
+func deviceErrors(device string, op string, errs []error) []error {
+	for i, err := range errs {
+		if _, ok := err.(*DeviceError); !ok {
+			errs[i] = NewDeviceError(device, op, err, IsRetryable(err))
+		}
+	}
+	return errs
+}
+
+// forwardDeviceErrors publishes the errors published on the "error" event of
+// device on the "device_error" event of the robot, as DeviceErrors
+func (r *Robot) forwardDeviceErrors(device Device) {
+	eventer, ok := device.(Eventer)
+	if !ok || eventer.Event("error") == nil {
+		return
+	}
+	name := device.Name()
+	On(eventer.Event("error"), func(data interface{}) {
+		err, ok := data.(*DeviceError)
+		if !ok {
+			cause, ok := data.(error)
+			if !ok {
+				cause = fmt.Errorf("%v", data)
+			}
+			err = NewDeviceError(name, "", cause, IsRetryable(cause))
+		}
+		Publish(r.Event("device_error"), err)
+	})
+}
+
+// publishDeviceErrors publishes errs, the DeviceErrors returned by a device,
+// on the "device_error" event of the robot
+func (r *Robot) publishDeviceErrors(errs []error) {
+	for _, err := range errs {
+		if err, ok := err.(*DeviceError); ok {
+			Publish(r.Event("device_error"), err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package gobot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeviceError(t *testing.T) {
+	err := NewDeviceError("sensor", "I2cRead", errors.New("no ack"), true)
+	Assert(t, err.Error(), "Device \"sensor\": no ack")
+	Assert(t, IsRetryable(err), true)
+	Assert(t, IsRetryable(NewDeviceError("sensor", "Start", errors.New("not found"), false)), false)
+	Assert(t, IsRetryable(TimeoutError{Op: "Start"}), true)
+	Assert(t, IsRetryable(errors.New("no ack")), false)
+}
+
+func TestRobotDeviceErrorEvent(t *testing.T) {
+	driver := newTestDriver(newTestAdaptor("Connection1", "/dev/null"), "sensor", "0")
+	driver.AddEvent("error")
+	r := NewRobot("bot", []Device{driver})
+	errs := make(chan *DeviceError, 1)
+	On(r.Event("device_error"), func(data interface{}) {
+		errs <- data.(*DeviceError)
+	})
+
+	published := NewDeviceError("sensor", "I2cRead", errors.New("no ack"), true)
+	Publish(driver.Event("error"), published)
+	select {
+	case err := <-errs:
+		Assert(t, err, published)
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("device_error was not published")
+	}
+
+	// plain errors are wrapped
+	Publish(driver.Event("error"), errors.New("no ack"))
+	select {
+	case err := <-errs:
+		Assert(t, err, NewDeviceError("sensor", "", errors.New("no ack"), false))
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("device_error was not published")
+	}
+}
+
+func TestRobotDeviceErrorOnStart(t *testing.T) {
+	r := NewRobot("bot", []Device{newFlakyDriver("sensor", 1)})
+	errs := make(chan *DeviceError, 1)
+	On(r.Event("device_error"), func(data interface{}) {
+		errs <- data.(*DeviceError)
+	})
+
+	Assert(t, r.Start(), []error{NewDeviceError("sensor", "Start", errors.New("flaky"), false)})
+	select {
+	case err := <-errs:
+		Assert(t, err.Op, "Start")
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("device_error was not published")
+	}
+}
@@ -0,0 +1,69 @@
+package gobot
+
+import "fmt"
+
+// DisableDevice halts the named device and takes it offline: it is left out
+// of health checks, not restarted by its supervisor, and not started or
+// halted with the robot until it is enabled again with EnableDevice. The
+// name of the device is published on "device_disabled".
+func (r *Robot) DisableDevice(name string) (errs []error) {
+	device := r.Device(name)
+	if device == nil {
+		return []error{fmt.Errorf("Device %q: not found", name)}
+	}
+	r.supervisorMutex.Lock()
+	if r.disabled[name] {
+		r.supervisorMutex.Unlock()
+		return
+	}
+	r.disabled[name] = true
+	r.supervisorMutex.Unlock()
+
+	Log(InfoLevel, "Disabling device", Fields{"robot": r.Name, "device": name})
+	errs, _ = callWithTimeout("Halt", r.HaltTimeout, device.Halt)
+	errs = deviceErrors(name, "Halt", errs)
+	r.publishDeviceErrors(errs)
+	Publish(r.Event("device_disabled"), name)
+	return
+}
+
+// EnableDevice starts the named device disabled by DisableDevice and brings
+// it back online. The device stays disabled if it fails to start. The name
+// of the device is published on "device_enabled".
+func (r *Robot) EnableDevice(name string) (errs []error) {
+	device := r.Device(name)
+	if device == nil {
+		return []error{fmt.Errorf("Device %q: not found", name)}
+	}
+	if !r.DeviceDisabled(name) {
+		return
+	}
+	if errs, _ = startDevice(device, Fields{"robot": r.Name}, r.StartTimeout); len(errs) > 0 {
+		r.publishDeviceErrors(errs)
+		return
+	}
+	r.supervisorMutex.Lock()
+	delete(r.disabled, name)
+	r.supervisorMutex.Unlock()
+	Publish(r.Event("device_enabled"), name)
+	return
+}
+
+// DeviceDisabled returns true if the named device has been disabled with
+// DisableDevice.
+func (r *Robot) DeviceDisabled(name string) bool {
+	r.supervisorMutex.Lock()
+	defer r.supervisorMutex.Unlock()
+	return r.disabled[name]
+}
+
+// enabledDevices returns the devices of the robot which are not disabled
+func (r *Robot) enabledDevices() *Devices {
+	devices := &Devices{}
+	for _, device := range *r.Devices() {
+		if !r.DeviceDisabled(device.Name()) {
+			*devices = append(*devices, device)
+		}
+	}
+	return devices
+}
@@ -0,0 +1,74 @@
+package gobot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRobotDisableDevice(t *testing.T) {
+	halted := []string{}
+	sensor := newRecordingDriver("sensor", &halted)
+	motor := newRecordingDriver("motor", &halted)
+	r := NewRobot("bot", []Connection{newTestAdaptor("Connection1", "/dev/null")}, []Device{sensor, motor})
+	disabled := make(chan interface{}, 1)
+	On(r.Event("device_disabled"), func(data interface{}) {
+		disabled <- data
+	})
+	r.Start()
+
+	Assert(t, len(r.DisableDevice("sensor")), 0)
+	Assert(t, r.DeviceDisabled("sensor"), true)
+	Assert(t, halted, []string{"sensor"})
+	select {
+	case name := <-disabled:
+		Assert(t, name, "sensor")
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("device_disabled was not published")
+	}
+	Assert(t, NewJSONRobot(r).Devices[0].Disabled, true)
+
+	// disabling twice does not halt again
+	r.DisableDevice("sensor")
+	Assert(t, halted, []string{"sensor"})
+
+	// disabled devices are not halted with the robot
+	r.Stop()
+	Assert(t, halted, []string{"sensor", "motor"})
+
+	Assert(t, r.DisableDevice("lidar")[0].Error(), "Device \"lidar\": not found")
+}
+
+func TestRobotEnableDevice(t *testing.T) {
+	r := NewRobot("bot", []Device{newFlakyDriver("sensor", 1)})
+	r.DisableDevice("sensor")
+	enabled := make(chan interface{}, 1)
+	On(r.Event("device_enabled"), func(data interface{}) {
+		enabled <- data
+	})
+
+	// stays disabled when failing to start
+	Assert(t, len(r.EnableDevice("sensor")), 1)
+	Assert(t, r.DeviceDisabled("sensor"), true)
+
+	Assert(t, len(r.EnableDevice("sensor")), 0)
+	Assert(t, r.DeviceDisabled("sensor"), false)
+	select {
+	case name := <-enabled:
+		Assert(t, name, "sensor")
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("device_enabled was not published")
+	}
+
+	Assert(t, r.EnableDevice("lidar")[0].Error(), "Device \"lidar\": not found")
+}
+
+func TestRobotDisabledDeviceHealth(t *testing.T) {
+	device := newHealthDriver("sensor")
+	device.setHealth(errors.New("no answer"))
+	r := NewRobot("bot", []Device{device})
+	Assert(t, len(r.CheckHealth()), 1)
+
+	r.DisableDevice("sensor")
+	Assert(t, len(r.CheckHealth()), 0)
+}
@@ -0,0 +1,137 @@
+package gobot
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy decides what happens to a value published while the queue
+// of a callback is full
+type OverflowPolicy int
+
+const (
+	// DropNewest drops the value being published
+	DropNewest OverflowPolicy = iota
+	// DropOldest drops the oldest queued value to make room for the value
+	// being published
+	DropOldest
+	// Block waits until there is room in the queue, which holds up the
+	// other callbacks of the Event and fills up its buffer
+	Block
+)
+
+// Dispatch describes how the values published on an Event are handed to a
+// callback. The zero Dispatch executes the callback in a new goroutine for
+// every value.
+type Dispatch struct {
+	// Workers is the number of goroutines executing the callback. When set,
+	// values are queued for the workers instead of each getting a goroutine.
+	Workers int
+	// QueueSize is the number of values queued while all workers are busy
+	QueueSize int
+	// Overflow decides what happens to values published while the queue is
+	// full
+	Overflow OverflowPolicy
+	// Ordered hands the values to the callback one at a time, in the order
+	// they were published, by executing it in a single worker
+	Ordered bool
+	// Debounce treats values published less than Debounce apart as a
+	// single burst and only executes the callback on the DebounceEdge of
+	// the burst, see Debounce
+	Debounce     time.Duration
+	DebounceEdge Edge
+}
+
+// SetDispatch sets how values are handed to the callbacks subscribed to e
+// from now on, callbacks subscribed before keep their Dispatch.
+func (e *Event) SetDispatch(d Dispatch) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.dispatch = d
+}
+
+// getDispatch returns the Dispatch set on e
+func (e *Event) getDispatch() Dispatch {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.dispatch
+}
+
+// newCallback returns a callback of e executing f as described by d,
+// starting its workers if it has any.
+func newCallback(e *Event, f func(interface{}), once bool, d Dispatch) callback {
+	if d.Debounce > 0 {
+		f = Debounce(d.Debounce, d.DebounceEdge, f)
+	}
+	c := callback{id: atomic.AddUint64(&callbackSeq, 1), f: f, once: once, overflow: d.Overflow}
+	workers := d.Workers
+	if d.Ordered {
+		workers = 1
+	}
+	if workers > 0 {
+		c.queue = make(chan interface{}, d.QueueSize)
+		c.stop = make(chan bool)
+		for i := 0; i < workers; i++ {
+			go func() {
+				for {
+					select {
+					case data, ok := <-c.queue:
+						if !ok {
+							return
+						}
+						e.run(f, data)
+					case <-c.stop:
+						return
+					}
+				}
+			}()
+		}
+	}
+	return c
+}
+
+// call hands data published on e to the callback, applying its overflow
+// policy when its queue is full.
+func (c callback) call(e *Event, data interface{}) {
+	if c.queue == nil {
+		go e.run(c.f, data)
+		return
+	}
+	c.enqueue(data, &e.dropped)
+	if c.once {
+		close(c.queue)
+	}
+}
+
+// enqueue queues data for the workers of the callback, blocking or dropping
+// a value as described by its overflow policy when the queue is full.
+// Dropped values are counted in dropped.
+func (c callback) enqueue(data interface{}, dropped *uint64) {
+	if c.overflow == Block {
+		select {
+		case c.queue <- data:
+		case <-c.stop:
+		}
+		return
+	}
+	for {
+		select {
+		case c.queue <- data:
+			return
+		default:
+		}
+		if c.overflow != DropOldest || cap(c.queue) == 0 {
+			// nothing is queued while the workers of an unbuffered queue
+			// are busy, so data is the oldest value
+			atomic.AddUint64(dropped, 1)
+			return
+		}
+		select {
+		case <-c.queue:
+			atomic.AddUint64(dropped, 1)
+		default:
+			// the workers emptied the queue in the meantime, so there is
+			// room for data now
+		}
+	}
+}
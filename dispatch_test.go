@@ -0,0 +1,214 @@
+package gobot
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingHandler counts the calls running at the same time until released
+type blockingHandler struct {
+	release chan bool
+	mutex   sync.Mutex
+	running int
+	max     int
+	handled int
+}
+
+func (h *blockingHandler) handle(data interface{}) {
+	h.mutex.Lock()
+	h.running++
+	if h.running > h.max {
+		h.max = h.running
+	}
+	h.mutex.Unlock()
+	<-h.release
+	h.mutex.Lock()
+	h.running--
+	h.handled++
+	h.mutex.Unlock()
+}
+
+func (h *blockingHandler) stats() (max int, handled int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.max, h.handled
+}
+
+func publishSpaced(e *Event, n int) {
+	for i := 0; i < n; i++ {
+		Publish(e, i)
+		<-time.After(2 * time.Millisecond)
+	}
+}
+
+func TestEventDispatchWorkers(t *testing.T) {
+	e := NewEvent()
+	e.SetDispatch(Dispatch{Workers: 2, QueueSize: 10})
+	h := &blockingHandler{release: make(chan bool)}
+	On(e, h.handle)
+
+	publishSpaced(e, 5)
+	close(h.release)
+	<-time.After(10 * time.Millisecond)
+
+	max, handled := h.stats()
+	Assert(t, max, 2)
+	Assert(t, handled, 5)
+}
+
+func TestEventDispatchDropsWhenQueueFull(t *testing.T) {
+	e := NewEvent()
+	h := &blockingHandler{release: make(chan bool)}
+	OnWithDispatch(e, Dispatch{Workers: 1, QueueSize: 1}, h.handle)
+
+	// one value is handled, one queued and the rest dropped
+	publishSpaced(e, 4)
+	close(h.release)
+	<-time.After(10 * time.Millisecond)
+
+	_, handled := h.stats()
+	Assert(t, handled, 2)
+	Assert(t, e.Dropped(), uint64(2))
+}
+
+func TestEventDispatchOnce(t *testing.T) {
+	e := NewEvent()
+	e.SetDispatch(Dispatch{Workers: 1, QueueSize: 1})
+	h := &blockingHandler{release: make(chan bool)}
+	close(h.release)
+	Once(e, h.handle)
+
+	publishSpaced(e, 3)
+	_, handled := h.stats()
+	Assert(t, handled, 1)
+}
+
+func TestSetDispatch(t *testing.T) {
+	e := NewEventer()
+	e.AddEvent("test")
+	SetDispatch(e, Dispatch{Workers: 3})
+	Assert(t, e.Event("test").dispatch, Dispatch{Workers: 3})
+}
+
+func TestEventDispatchDropOldest(t *testing.T) {
+	e := NewEvent()
+	values := make(chan interface{}, 10)
+	release := make(chan bool)
+	OnWithDispatch(e, Dispatch{Workers: 1, QueueSize: 1, Overflow: DropOldest}, func(data interface{}) {
+		<-release
+		values <- data
+	})
+
+	// 0 is being handled, 1 and 2 are dropped for the newer values
+	publishSpaced(e, 4)
+	close(release)
+	<-time.After(10 * time.Millisecond)
+
+	Assert(t, len(values), 2)
+	Assert(t, <-values, 0)
+	Assert(t, <-values, 3)
+	Assert(t, e.Dropped(), uint64(2))
+}
+
+func TestCallbackEnqueueDropped(t *testing.T) {
+	var dropped uint64
+	c := callback{queue: make(chan interface{}, 1), overflow: DropOldest}
+	c.enqueue(1, &dropped)
+	Assert(t, dropped, uint64(0))
+	// the oldest value makes room for the newest one
+	c.enqueue(2, &dropped)
+	Assert(t, dropped, uint64(1))
+	Assert(t, <-c.queue, 2)
+
+	c = callback{queue: make(chan interface{}, 1), overflow: DropNewest}
+	c.enqueue(1, &dropped)
+	c.enqueue(2, &dropped)
+	Assert(t, dropped, uint64(2))
+	Assert(t, <-c.queue, 1)
+
+	// an unbuffered queue has no older value to drop
+	c = callback{queue: make(chan interface{}), overflow: DropOldest}
+	c.enqueue(1, &dropped)
+	Assert(t, dropped, uint64(3))
+}
+
+func TestCallbackEnqueueDropOldestWhileDraining(t *testing.T) {
+	var dropped uint64
+	c := callback{queue: make(chan interface{}, 1), overflow: DropOldest}
+	handled := make(chan int, 1)
+	go func() {
+		n := 0
+		for data := range c.queue {
+			n++
+			if data == 999 {
+				handled <- n
+				return
+			}
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		c.enqueue(i, &dropped)
+	}
+	// the newest value is never dropped, and only the values which were
+	// actually dropped are counted
+	select {
+	case n := <-handled:
+		Assert(t, uint64(n)+atomic.LoadUint64(&dropped), uint64(1000))
+	case <-time.After(time.Second):
+		t.Fatal("the newest value was dropped")
+	}
+}
+
+func TestEventDispatchBlock(t *testing.T) {
+	e := NewBufferedEvent(10)
+	h := &blockingHandler{release: make(chan bool)}
+	OnWithDispatch(e, Dispatch{Workers: 1, Overflow: Block}, h.handle)
+
+	publishSpaced(e, 4)
+	close(h.release)
+	<-time.After(10 * time.Millisecond)
+
+	_, handled := h.stats()
+	Assert(t, handled, 4)
+	Assert(t, e.Dropped(), uint64(0))
+}
+
+func TestEventDropped(t *testing.T) {
+	e := &Event{Chan: make(chan interface{}, 1)}
+	e.Write(1)
+	e.Write(2)
+	Assert(t, e.Dropped(), uint64(1))
+}
+
+func TestEventerAddBufferedEvent(t *testing.T) {
+	e := NewEventer()
+	e.AddBufferedEvent("analog_read", 64)
+	Assert(t, cap(e.Event("analog_read").Chan), 64)
+}
+
+func TestOnOrdered(t *testing.T) {
+	e := NewBufferedEvent(20)
+	e.SetDispatch(Dispatch{QueueSize: 20})
+	values := make(chan interface{}, 20)
+	OnOrdered(e, func(data interface{}) {
+		// later values are handled faster, which reorders concurrent calls
+		<-time.After(time.Duration(10-data.(int)) * time.Millisecond)
+		values <- data
+	})
+
+	for i := 0; i < 10; i++ {
+		Publish(e, i)
+	}
+	for i := 0; i < 10; i++ {
+		select {
+		case v := <-values:
+			Assert(t, v, i)
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("value was not handled")
+		}
+	}
+
+	Assert(t, OnOrdered(nil, func(interface{}) {}), ErrUnknownEvent)
+}
@@ -16,3 +16,11 @@ type Driver interface {
 type Pinner interface {
 	Pin() string
 }
+
+// Depender is the interface that describes a driver which needs other devices
+// of its robot to be started before it, e.g. an i2c driver behind a
+// multiplexer or a motor driver behind an enable relay.
+type Depender interface {
+	// Dependencies returns the names of the devices the Driver depends on
+	Dependencies() []string
+}
@@ -0,0 +1,120 @@
+package gobot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Action is a write an adaptor was asked to make while its robot was dry run
+type Action struct {
+	Connection string
+	Method     string
+	Args       []interface{}
+	Time       time.Time
+}
+
+// DryRunner is the interface which describes the behaviour for an Adaptor
+// which can be dry run. While dry run an adaptor does not touch its
+// hardware: connecting and finalizing do nothing, reads return zero values
+// and writes are handed to a recorder instead of being made.
+type DryRunner interface {
+	// DryRun starts dry running, handing the writes to record. A nil record
+	// stops dry running.
+	DryRun(record func(method string, args ...interface{}))
+	// DryRunning returns true while dry running.
+	DryRunning() bool
+	// Record hands a write to the recorder and returns true while dry
+	// running, in which case the write must not be made.
+	Record(method string, args ...interface{}) bool
+}
+
+type dryRunner struct {
+	record func(method string, args ...interface{})
+	mutex  sync.RWMutex
+}
+
+// NewDryRunner returns a new DryRunner to be embedded in an Adaptor.
+func NewDryRunner() DryRunner {
+	return &dryRunner{}
+}
+
+func (d *dryRunner) DryRun(record func(method string, args ...interface{})) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.record = record
+}
+
+func (d *dryRunner) DryRunning() bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.record != nil
+}
+
+func (d *dryRunner) Record(method string, args ...interface{}) bool {
+	d.mutex.RLock()
+	record := d.record
+	d.mutex.RUnlock()
+	if record == nil {
+		return false
+	}
+	record(method, args...)
+	return true
+}
+
+// Actions returns the writes recorded while the robot was dry run, oldest
+// first.
+func (r *Robot) Actions() []Action {
+	r.actionsMutex.Lock()
+	defer r.actionsMutex.Unlock()
+	return append([]Action{}, r.actions...)
+}
+
+// startDryRun makes every connection of the robot dry run, discarding the
+// actions of a previous dry run. Returns an error for the first connection
+// which is not a DryRunner.
+func (r *Robot) startDryRun() error {
+	r.actionsMutex.Lock()
+	r.actions = nil
+	r.actionsMutex.Unlock()
+	for _, connection := range *r.Connections() {
+		if _, ok := connection.(DryRunner); !ok {
+			return fmt.Errorf("Connection %q: can not be dry run", connection.Name())
+		}
+	}
+	for _, connection := range *r.Connections() {
+		name := connection.Name()
+		connection.(DryRunner).DryRun(func(method string, args ...interface{}) {
+			r.recordAction(Action{
+				Connection: name,
+				Method:     method,
+				Args:       args,
+				Time:       clock().Now(),
+			})
+		})
+	}
+	return nil
+}
+
+// stopDryRun makes the connections of the robot touch their hardware again
+func (r *Robot) stopDryRun() {
+	for _, connection := range *r.Connections() {
+		if dryRunner, ok := connection.(DryRunner); ok {
+			dryRunner.DryRun(nil)
+		}
+	}
+}
+
+// recordAction logs action and publishes it on the "action" event
+func (r *Robot) recordAction(action Action) {
+	r.actionsMutex.Lock()
+	r.actions = append(r.actions, action)
+	r.actionsMutex.Unlock()
+	Log(InfoLevel, "Dry run", Fields{
+		"robot":      r.Name,
+		"connection": action.Connection,
+		"method":     action.Method,
+		"args":       fmt.Sprint(action.Args...),
+	})
+	Publish(r.Event("action"), action)
+}
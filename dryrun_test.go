@@ -0,0 +1,55 @@
+package gobot
+
+import (
+	"errors"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestRobotDryRun(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	adaptor := newDryRunAdaptor("arduino")
+	var writeErr error
+	r := NewRobot("Robot1",
+		[]Connection{adaptor},
+		func() {
+			writeErr = adaptor.DigitalWrite("13", 1)
+		},
+	)
+	r.DryRun = true
+
+	Assert(t, len(r.Start()), 0)
+	Assert(t, writeErr, nil)
+	actions := r.Actions()
+	Assert(t, len(actions), 1)
+	Assert(t, actions[0].Connection, "arduino")
+	Assert(t, actions[0].Method, "DigitalWrite")
+	Assert(t, actions[0].Args, []interface{}{"13", byte(1)})
+
+	Assert(t, len(r.Stop()), 0)
+	Assert(t, adaptor.DryRunning(), false)
+	Assert(t, adaptor.DigitalWrite("13", 0), errors.New("no hardware"))
+}
+
+func TestRobotDryRunUnsupported(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := NewRobot("Robot1",
+		[]Connection{newTestAdaptor("sphero", "/dev/null")},
+	)
+	r.DryRun = true
+	Assert(t, r.Start(), []error{errors.New("Connection \"sphero\": can not be dry run")})
+}
+
+func TestGobotDryRun(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	g := NewGobot()
+	g.DryRun = true
+	g.trap = func(c chan os.Signal) {
+		c <- os.Interrupt
+	}
+	adaptor := newDryRunAdaptor("arduino")
+	r := g.AddRobot(NewRobot("Robot1", []Connection{adaptor}))
+	Assert(t, len(g.Start()), 0)
+	Assert(t, r.DryRun, true)
+}
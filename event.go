@@ -1,20 +1,72 @@
 package gobot
 
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
 type callback struct {
-	f    func(interface{})
-	once bool
+	id       uint64
+	f        func(interface{})
+	once     bool
+	queue    chan interface{}
+	stop     chan bool
+	overflow OverflowPolicy
 }
 
+// callbackSeq is the id of the last callback subscribed to an event
+var callbackSeq uint64
+
 // Event executes the list of Callbacks when Chan is written to.
 type Event struct {
-	Chan      chan interface{}
+	// dropped is first so it is 64-bit aligned for atomic access
+	dropped uint64
+	Chan    chan interface{}
+	// Callbacks are guarded by the mutex of the Event, subscribe with On
+	// and its variants instead of changing them
 	Callbacks []callback
+	history   *eventHistory
+	name      string
+	replay    *replayBuffer
+	dispatch  Dispatch
+	mutex     sync.Mutex
+
+	onPanic    func(value interface{}, stack []byte)
+	panicMutex sync.RWMutex
+}
+
+// Record is a value published on an Event and the time it was published at
+type Record struct {
+	Data interface{}
+	Time time.Time
+	// Seq orders the values retained from all the events, later values
+	// have greater sequence numbers
+	Seq uint64
+}
+
+// eventSeq is the sequence number of the last value retained from an event
+var eventSeq uint64
+
+// eventHistory is a ring buffer of the last values published on an Event
+type eventHistory struct {
+	records []Record
+	next    int
+	full    bool
+	mutex   sync.Mutex
 }
 
 // NewEvent returns a new Event which is now listening for data.
 func NewEvent() *Event {
+	return NewBufferedEvent(1)
+}
+
+// NewBufferedEvent returns a new Event which is now listening for data and
+// buffers up to size values published while its callbacks are being
+// dispatched.
+func NewBufferedEvent(size int) *Event {
 	e := &Event{
-		Chan:      make(chan interface{}, 1),
+		Chan:      make(chan interface{}, size),
 		Callbacks: []callback{},
 	}
 	go func() {
@@ -26,24 +78,123 @@ func NewEvent() *Event {
 }
 
 // Write writes data to the Event, it will not block and will not buffer if there
-// are no active subscribers to the Event.
+// are no active subscribers to the Event. Data written while the buffer of
+// the Event is full is dropped.
 func (e *Event) Write(data interface{}) {
+	h, r := e.history, e.replay
+	if h != nil || (r != nil && r.retaining()) {
+		record := Record{Data: data, Time: clock().Now(), Seq: atomic.AddUint64(&eventSeq, 1)}
+		if h != nil {
+			h.mutex.Lock()
+			h.records[h.next] = record
+			h.next = (h.next + 1) % len(h.records)
+			h.full = h.full || h.next == 0
+			h.mutex.Unlock()
+		}
+		if r != nil {
+			r.add(EventRecord{Name: e.name, Record: record})
+		}
+	}
 	select {
 	case e.Chan <- data:
 	default:
+		atomic.AddUint64(&e.dropped, 1)
 	}
 }
 
+// Dropped returns the number of values published on e which were dropped,
+// because the buffer of e or the queue of a callback was full.
+func (e *Event) Dropped() uint64 {
+	return atomic.LoadUint64(&e.dropped)
+}
+
+// Subscribers returns the number of callbacks subscribed to e.
+func (e *Event) Subscribers() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return len(e.Callbacks)
+}
+
 // Read executes all Callbacks when new data is available.
 func (e *Event) Read() {
 	for s := range e.Chan {
-		tmp := []callback{}
-		for i := range e.Callbacks {
-			go e.Callbacks[i].f(s)
-			if !e.Callbacks[i].once {
-				tmp = append(tmp, e.Callbacks[i])
+		e.mutex.Lock()
+		callbacks := e.Callbacks
+		e.mutex.Unlock()
+		for _, c := range callbacks {
+			c.call(e, s)
+			if c.once {
+				e.removeCallback(c.id)
 			}
 		}
-		e.Callbacks = tmp
+	}
+}
+
+// addCallback subscribes c to e and returns its id
+func (e *Event) addCallback(c callback) uint64 {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.Callbacks = append(e.Callbacks, c)
+	return c.id
+}
+
+// removeCallback unsubscribes the callback with id from e, returning false
+// if it is not subscribed
+func (e *Event) removeCallback(id uint64) (c callback, ok bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	callbacks := []callback{}
+	for _, callback := range e.Callbacks {
+		if callback.id == id {
+			c, ok = callback, true
+		} else {
+			callbacks = append(callbacks, callback)
+		}
+	}
+	e.Callbacks = callbacks
+	return
+}
+
+// off unsubscribes the callback with id from e and stops its workers
+func (e *Event) off(id uint64) {
+	if c, ok := e.removeCallback(id); ok && c.stop != nil {
+		close(c.stop)
+	}
+}
+
+// Retain makes e keep the last n values published on it, so they can be
+// inspected with History and Replay or handed to new subscribers with
+// OnWithHistory. A n of 0 or less stops retaining values. Call Retain
+// before values are published on e.
+func (e *Event) Retain(n int) {
+	if n <= 0 {
+		e.history = nil
+		return
+	}
+	e.history = &eventHistory{records: make([]Record, n)}
+}
+
+// History returns the values retained by e, oldest first. Returns nil unless
+// Retain has been called.
+func (e *Event) History() (records []Record) {
+	h := e.history
+	if h == nil {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.full {
+		records = append(records, h.records[h.next:]...)
+	}
+	return append(records, h.records[:h.next]...)
+}
+
+// Replay calls f with each value retained by e which was published between
+// from and to, oldest first.
+func (e *Event) Replay(from time.Time, to time.Time, f func(data interface{})) {
+	for _, record := range e.History() {
+		if !record.Time.Before(from) && !record.Time.After(to) {
+			f(record.Data)
+		}
 	}
 }
@@ -1,36 +1,195 @@
 package gobot
 
+import (
+	"path"
+	"sync"
+)
+
 type eventer struct {
-	events map[string]*Event
+	events   map[string]*Event
+	patterns []*patternCallback
+	replay   *replayBuffer
+	mutex    sync.Mutex
+}
+
+// EventRecord is a value published on an event of an Eventer, retained in
+// its replay buffer
+type EventRecord struct {
+	// Name is the name of the event
+	Name string
+	Record
+}
+
+// replayBuffer is a ring buffer of the last values published on all the
+// events of an Eventer
+type replayBuffer struct {
+	records []EventRecord
+	next    int
+	full    bool
+	mutex   sync.Mutex
+}
+
+// patternCallback is a callback subscribed to the events matching pattern
+type patternCallback struct {
+	pattern string
+	f       func(name string, data interface{})
+	// ids are the ids of the callbacks subscribed to each matching event
+	ids map[*Event]uint64
 }
 
 // Eventer is the interface which describes behaviour for a Driver or Adaptor
 // which uses events.
 type Eventer interface {
-	// Events returns the Event map.
+	// Events returns a copy of the Event map, safe to range over while
+	// events are being added.
 	Events() (events map[string]*Event)
 	// Event returns an Event by name. Returns nil if the Event is not found.
 	Event(name string) (event *Event)
 	// AddEvent adds a new Event given a name.
 	AddEvent(name string)
+	// AddBufferedEvent adds a new Event given a name, which buffers up to
+	// size values published while its callbacks are being dispatched.
+	AddBufferedEvent(name string, size int)
+	// OnPattern executes f with the name and data of every event published
+	// whose name matches pattern, including events added later, until stop
+	// is called. Patterns use the syntax of path.Match, e.g.
+	// "digital_read*" or "*". Returns path.ErrBadPattern if pattern is
+	// malformed.
+	OnPattern(pattern string, f func(name string, data interface{})) (stop func(), err error)
+	// RetainEvents makes the Eventer keep the last n values published on
+	// any of its events, including events added later, in its replay
+	// buffer. A n of 0 or less stops retaining values.
+	RetainEvents(n int)
+	// EventsSince returns the values of the replay buffer with a sequence
+	// number greater than seq, oldest first, see Record.Seq.
+	EventsSince(seq uint64) []EventRecord
 }
 
 // NewEventer returns a new Eventer.
 func NewEventer() Eventer {
 	return &eventer{
 		events: make(map[string]*Event),
+		replay: &replayBuffer{},
 	}
 }
 
 func (e *eventer) Events() map[string]*Event {
-	return e.events
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	events := make(map[string]*Event, len(e.events))
+	for name, event := range e.events {
+		events[name] = event
+	}
+	return events
 }
 
 func (e *eventer) Event(name string) (event *Event) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
 	event, _ = e.events[name]
 	return
 }
 
 func (e *eventer) AddEvent(name string) {
-	e.events[name] = NewEvent()
+	e.AddBufferedEvent(name, 1)
+}
+
+func (e *eventer) AddBufferedEvent(name string, size int) {
+	event := NewBufferedEvent(size)
+	event.name = name
+	event.replay = e.replay
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.events[name] = event
+	for _, p := range e.patterns {
+		p.subscribe(name, event)
+	}
+}
+
+func (e *eventer) OnPattern(pattern string, f func(name string, data interface{})) (stop func(), err error) {
+	if _, err = path.Match(pattern, ""); err != nil {
+		return
+	}
+	p := &patternCallback{pattern: pattern, f: f, ids: make(map[*Event]uint64)}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.patterns = append(e.patterns, p)
+	for name, event := range e.events {
+		p.subscribe(name, event)
+	}
+	return func() { e.offPattern(p) }, nil
+}
+
+// offPattern unsubscribes p from the events it is subscribed to and from
+// the events added later
+func (e *eventer) offPattern(p *patternCallback) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for i, pattern := range e.patterns {
+		if pattern == p {
+			e.patterns = append(e.patterns[:i:i], e.patterns[i+1:]...)
+			break
+		}
+	}
+	for event, id := range p.ids {
+		event.off(id)
+	}
+	p.ids = make(map[*Event]uint64)
+}
+
+func (e *eventer) RetainEvents(n int) {
+	e.replay.mutex.Lock()
+	defer e.replay.mutex.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	e.replay.records = make([]EventRecord, n)
+	e.replay.next = 0
+	e.replay.full = false
+}
+
+func (e *eventer) EventsSince(seq uint64) (records []EventRecord) {
+	r := e.replay
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	all := r.records[:r.next]
+	if r.full {
+		all = append(append([]EventRecord{}, r.records[r.next:]...), all...)
+	}
+	for _, record := range all {
+		if record.Seq > seq {
+			records = append(records, record)
+		}
+	}
+	return
+}
+
+// retaining returns true if r retains values
+func (r *replayBuffer) retaining() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.records) > 0
+}
+
+// add retains record, overwriting the oldest value once r is full
+func (r *replayBuffer) add(record EventRecord) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.records) == 0 {
+		return
+	}
+	r.records[r.next] = record
+	r.next = (r.next + 1) % len(r.records)
+	r.full = r.full || r.next == 0
+}
+
+// subscribe executes the callback of p with name and the data published on
+// event, if name matches the pattern of p
+func (p *patternCallback) subscribe(name string, event *Event) {
+	if matched, _ := path.Match(p.pattern, name); !matched {
+		return
+	}
+	p.ids[event] = event.addCallback(newCallback(event, func(data interface{}) {
+		p.f(name, data)
+	}, false, event.getDispatch()))
 }
@@ -1,6 +1,10 @@
 package gobot
 
-import "testing"
+import (
+	"path"
+	"testing"
+	"time"
+)
 
 func TestEventer(t *testing.T) {
 	e := NewEventer()
@@ -16,3 +20,89 @@ func TestEventer(t *testing.T) {
 	event = e.Event("booyeah")
 	Assert(t, event, (*Event)(nil))
 }
+
+func TestEventerEventsCopy(t *testing.T) {
+	e := NewEventer()
+	e.AddEvent("test")
+
+	events := e.Events()
+	done := make(chan bool)
+	go func() {
+		e.AddEvent("added")
+		done <- true
+	}()
+	for range events {
+	}
+	<-done
+
+	Assert(t, len(events), 1)
+	Assert(t, len(e.Events()), 2)
+}
+
+func TestEventerOnPattern(t *testing.T) {
+	e := NewEventer()
+	e.AddEvent("digital_read_2")
+	e.AddEvent("analog_read_0")
+
+	names := make(chan string, 3)
+	stop, err := e.OnPattern("digital_read*", func(name string, data interface{}) {
+		names <- name
+	})
+	Assert(t, err, nil)
+	// events added later are matched too
+	e.AddEvent("digital_read_3")
+
+	Publish(e.Event("digital_read_2"), 1)
+	<-time.After(5 * time.Millisecond)
+	Publish(e.Event("analog_read_0"), 512)
+	<-time.After(5 * time.Millisecond)
+	Publish(e.Event("digital_read_3"), 0)
+	<-time.After(5 * time.Millisecond)
+
+	Assert(t, len(names), 2)
+	Assert(t, <-names, "digital_read_2")
+	Assert(t, <-names, "digital_read_3")
+
+	// stopping unsubscribes from the matching events and the events added
+	// later
+	stop()
+	e.AddEvent("digital_read_4")
+	Publish(e.Event("digital_read_2"), 1)
+	Publish(e.Event("digital_read_4"), 1)
+	<-time.After(5 * time.Millisecond)
+	Assert(t, len(names), 0)
+	Assert(t, e.Event("digital_read_2").Subscribers(), 0)
+
+	_, err = e.OnPattern("[", func(string, interface{}) {})
+	Assert(t, err, path.ErrBadPattern)
+}
+
+func TestEventerRetainEvents(t *testing.T) {
+	e := NewEventer()
+	e.AddEvent("a")
+	Publish(e.Event("a"), 0)
+	Assert(t, len(e.EventsSince(0)), 0)
+
+	e.RetainEvents(3)
+	// events added later are retained too
+	e.AddEvent("b")
+	for i := 1; i <= 4; i++ {
+		Publish(e.Event("a"), i)
+		Publish(e.Event("b"), -i)
+	}
+	records := e.EventsSince(0)
+	Assert(t, len(records), 3)
+	Assert(t, records[0].Name, "b")
+	Assert(t, records[0].Data, -3)
+	Assert(t, records[1].Data, 4)
+	Assert(t, records[2].Data, -4)
+
+	since := e.EventsSince(records[1].Seq)
+	Assert(t, len(since), 1)
+	Assert(t, since[0].Data, -4)
+	Assert(t, len(e.EventsSince(records[2].Seq)), 0)
+
+	e.RetainEvents(0)
+	Publish(e.Event("a"), 5)
+	Assert(t, len(e.EventsSince(0)), 0)
+}
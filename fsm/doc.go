@@ -0,0 +1,29 @@
+/*
+Package fsm provides a finite state machine to structure the behaviour of a
+robot, instead of a switch statement in its work.
+
+A Machine has named transitions from one or more states to another state,
+optionally guarded by a function which has to allow the transition. Hooks are
+executed when a state is exited and entered, and each transition is published
+on the "transition" event of an Eventer such as the robot.
+
+Example:
+
+	robot := gobot.NewRobot("rover", []gobot.Device{bumper, motor})
+	machine := fsm.New("idle", robot)
+	machine.AddTransition(fsm.Transition{Name: "drive", From: []string{"idle"}, To: "driving"})
+	machine.AddTransition(fsm.Transition{Name: "stop", From: []string{"driving"}, To: "idle"})
+	machine.OnEnter("driving", func() { motor.Speed(200) })
+	machine.OnExit("driving", func() { motor.Off() })
+
+	robot.Work = func() {
+		gobot.On(bumper.Event("push"), func(data interface{}) {
+			if machine.Is("driving") {
+				machine.Fire("stop")
+			} else {
+				machine.Fire("drive")
+			}
+		})
+	}
+*/
+package fsm
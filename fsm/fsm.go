@@ -0,0 +1,170 @@
+package fsm
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// TransitionEvent is the name of the event a Machine publishes its
+// transitions on
+const TransitionEvent = "transition"
+
+// ErrUnknownTransition is the error resulting if the specified transition
+// does not exist
+var ErrUnknownTransition = errors.New("Transition does not exist")
+
+// Transition describes how a Machine moves from one state to another
+type Transition struct {
+	Name string
+	// From are the states the transition can be fired in, empty for any
+	// state
+	From []string
+	To   string
+	// Guard is called before the transition is made, which is rejected if
+	// Guard returns false
+	Guard func() bool
+}
+
+// Transitioned is published on the "transition" event once a transition has
+// been made
+type Transitioned struct {
+	Transition string
+	From       string
+	To         string
+}
+
+// Machine is a finite state machine
+type Machine struct {
+	current     string
+	transitions map[string]Transition
+	enter       map[string][]func()
+	exit        map[string][]func()
+	event       *gobot.Event
+	mutex       sync.RWMutex
+	fireMutex   sync.Mutex
+}
+
+// New returns a new Machine in the initial state. Transitions are published
+// on the "transition" event of eventer, which is added if eventer does not
+// have it. A nil eventer does not publish the transitions.
+func New(initial string, eventer gobot.Eventer) *Machine {
+	m := &Machine{
+		current:     initial,
+		transitions: make(map[string]Transition),
+		enter:       make(map[string][]func()),
+		exit:        make(map[string][]func()),
+	}
+	if eventer != nil {
+		if eventer.Event(TransitionEvent) == nil {
+			eventer.AddEvent(TransitionEvent)
+		}
+		m.event = eventer.Event(TransitionEvent)
+	}
+	return m
+}
+
+// AddTransition adds t to the machine, replacing a transition of the same
+// name.
+func (m *Machine) AddTransition(t Transition) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.transitions[t.Name] = t
+}
+
+// OnEnter adds f to the functions executed when state is entered.
+func (m *Machine) OnEnter(state string, f func()) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.enter[state] = append(m.enter[state], f)
+}
+
+// OnExit adds f to the functions executed when state is exited.
+func (m *Machine) OnExit(state string, f func()) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.exit[state] = append(m.exit[state], f)
+}
+
+// Current returns the current state.
+func (m *Machine) Current() string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.current
+}
+
+// Is returns true if state is the current state.
+func (m *Machine) Is(state string) bool {
+	return m.Current() == state
+}
+
+// Can returns true if the named transition can be fired in the current
+// state. The guard of the transition is not called.
+func (m *Machine) Can(name string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	t, ok := m.transitions[name]
+	return ok && t.from(m.current)
+}
+
+// Fire makes the named transition: the exit hooks of the current state are
+// executed, the state changes and the enter hooks of the new state are
+// executed. Returns ErrUnknownTransition if the transition does not exist, or
+// an error if it can not be fired in the current state or its guard rejects
+// it. Transitions are made one at a time, so hooks and guards must not call
+// Fire themselves, but may start a goroutine doing so.
+func (m *Machine) Fire(name string) error {
+	m.fireMutex.Lock()
+	defer m.fireMutex.Unlock()
+
+	m.mutex.RLock()
+	t, ok := m.transitions[name]
+	from := m.current
+	exit := m.exit[from]
+	enter := m.enter[t.To]
+	m.mutex.RUnlock()
+
+	if !ok {
+		return ErrUnknownTransition
+	}
+	if !t.from(from) {
+		return fmt.Errorf("Transition %q: can not be fired in state %q", name, from)
+	}
+	if t.Guard != nil && !t.Guard() {
+		return fmt.Errorf("Transition %q: rejected by guard in state %q", name, from)
+	}
+
+	for _, f := range exit {
+		f()
+	}
+	m.mutex.Lock()
+	m.current = t.To
+	m.mutex.Unlock()
+	for _, f := range enter {
+		f()
+	}
+
+	if m.event != nil {
+		gobot.Publish(m.event, Transitioned{
+			Transition: name,
+			From:       from,
+			To:         t.To,
+		})
+	}
+	return nil
+}
+
+// from returns true if t can be fired in state
+func (t Transition) from(state string) bool {
+	if len(t.From) == 0 {
+		return true
+	}
+	for _, s := range t.From {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package fsm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func newTestMachine(eventer gobot.Eventer) *Machine {
+	m := New("idle", eventer)
+	m.AddTransition(Transition{Name: "drive", From: []string{"idle"}, To: "driving"})
+	m.AddTransition(Transition{Name: "stop", From: []string{"driving"}, To: "idle"})
+	m.AddTransition(Transition{Name: "fault", To: "error"})
+	return m
+}
+
+func TestMachineFire(t *testing.T) {
+	m := newTestMachine(nil)
+	gobot.Assert(t, m.Current(), "idle")
+	gobot.Assert(t, m.Can("drive"), true)
+	gobot.Assert(t, m.Can("stop"), false)
+
+	gobot.Assert(t, m.Fire("drive"), nil)
+	gobot.Assert(t, m.Is("driving"), true)
+
+	err := m.Fire("drive")
+	gobot.Assert(t, err.Error(), "Transition \"drive\": can not be fired in state \"driving\"")
+	gobot.Assert(t, m.Current(), "driving")
+
+	// transitions without From can be fired in any state
+	gobot.Assert(t, m.Fire("fault"), nil)
+	gobot.Assert(t, m.Current(), "error")
+
+	gobot.Assert(t, m.Fire("fly"), ErrUnknownTransition)
+	gobot.Assert(t, m.Can("fly"), false)
+}
+
+func TestMachineGuard(t *testing.T) {
+	m := newTestMachine(nil)
+	ready := false
+	m.AddTransition(Transition{
+		Name:  "drive",
+		From:  []string{"idle"},
+		To:    "driving",
+		Guard: func() bool { return ready },
+	})
+
+	err := m.Fire("drive")
+	gobot.Assert(t, err.Error(), "Transition \"drive\": rejected by guard in state \"idle\"")
+	gobot.Assert(t, m.Current(), "idle")
+
+	ready = true
+	gobot.Assert(t, m.Fire("drive"), nil)
+	gobot.Assert(t, m.Current(), "driving")
+}
+
+func TestMachineHooks(t *testing.T) {
+	m := newTestMachine(nil)
+	calls := []string{}
+	m.OnExit("idle", func() {
+		calls = append(calls, "exit idle in "+m.Current())
+	})
+	m.OnEnter("driving", func() {
+		calls = append(calls, "enter driving in "+m.Current())
+	})
+	m.OnEnter("idle", func() {
+		calls = append(calls, "enter idle")
+	})
+
+	m.Fire("drive")
+	gobot.Assert(t, calls, []string{"exit idle in idle", "enter driving in driving"})
+}
+
+func TestMachineTransitionEvent(t *testing.T) {
+	robot := gobot.NewRobot("rover")
+	m := newTestMachine(robot)
+	transitions := make(chan Transitioned, 1)
+	gobot.On(robot.Event(TransitionEvent), func(data interface{}) {
+		transitions <- data.(Transitioned)
+	})
+
+	m.Fire("drive")
+	select {
+	case transition := <-transitions:
+		gobot.Assert(t, transition, Transitioned{Transition: "drive", From: "idle", To: "driving"})
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("transition was not published")
+	}
+}
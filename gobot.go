@@ -1,11 +1,18 @@
 package gobot
 
 import (
-	"log"
+	"errors"
 	"os"
 	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
+// ErrShutdownTimeout is returned by Stop when the robots have not halted
+// within the ShutdownTimeout
+var ErrShutdownTimeout = errors.New("Shutdown timed out")
+
 // JSONGobot is a JSON representation of a Gobot.
 type JSONGobot struct {
 	Robots   []*JSONRobot `json:"robots"`
@@ -23,7 +30,7 @@ func NewJSONGobot(gobot *Gobot) *JSONGobot {
 		jsonGobot.Commands = append(jsonGobot.Commands, command)
 	}
 
-	gobot.robots.Each(func(r *Robot) {
+	gobot.Robots().Each(func(r *Robot) {
 		jsonGobot.Robots = append(jsonGobot.Robots, NewJSONRobot(r))
 	})
 	return jsonGobot
@@ -32,37 +39,78 @@ func NewJSONGobot(gobot *Gobot) *JSONGobot {
 // Gobot is the main type of your Gobot application and contains a collection of
 // Robots, API commands and Events.
 type Gobot struct {
-	robots *Robots
-	trap   func(chan os.Signal)
+	// ShutdownTimeout is how long Stop waits for the robots to halt, 0 waits
+	// until they have halted
+	ShutdownTimeout time.Duration
+	// DryRun dry runs every robot, see Robot.DryRun
+	DryRun bool
+	// Tracer traces every robot which has no Tracer, see Robot.Tracer
+	Tracer Tracer
+
+	robots      *Robots
+	robotsMutex sync.RWMutex
+	apiServers  []APIServer
+	bus         *messageBus
+	trap        func(chan os.Signal)
+	interrupt   chan os.Signal
+	stopped     chan struct{}
+	stopErrs    []error
+	mutex       sync.Mutex
 	Commander
 	Eventer
 }
 
-// NewGobot returns a new Gobot
+// NewGobot returns a new Gobot. Jobs started with ExecuteAsync are published
+// on its "job_done" event once finished.
 func NewGobot() *Gobot {
-	return &Gobot{
+	g := &Gobot{
 		robots: &Robots{},
+		bus:    newMessageBus(),
 		trap: func(c chan os.Signal) {
-			signal.Notify(c, os.Interrupt)
+			signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 		},
 		Commander: NewCommander(),
 		Eventer:   NewEventer(),
 	}
+	g.AddEvent("job_done")
+	publishJobs(g.Commander, g.Event("job_done"))
+	return g
 }
 
 // Start calls the Start method on each robot in it's collection of robots, and
-// stops all robots on reception of a SIGINT. Start will block the execution of
-// your main function until it receives the SIGINT.
+// stops all robots on reception of a SIGINT or SIGTERM. Start will block the
+// execution of your main function until it receives the signal.
 func (g *Gobot) Start() (errs []error) {
-	if rerrs := g.robots.Start(); len(rerrs) > 0 {
+	if g.DryRun {
+		g.Robots().Each(func(r *Robot) {
+			r.DryRun = true
+		})
+	}
+	if g.Tracer != nil {
+		g.Robots().Each(func(r *Robot) {
+			if r.Tracer == nil {
+				r.Tracer = g.Tracer
+			}
+		})
+	}
+	if rerrs := g.Robots().Start(); len(rerrs) > 0 {
 		for _, err := range rerrs {
-			log.Println("Error:", err)
+			Log(ErrorLevel, err.Error(), nil)
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		for _, err := range g.startAPIServers() {
+			Log(ErrorLevel, err.Error(), nil)
 			errs = append(errs, err)
 		}
 	}
 
 	c := make(chan os.Signal, 1)
 	g.trap(c)
+	g.mutex.Lock()
+	g.interrupt, g.stopped = c, make(chan struct{})
+	g.mutex.Unlock()
 	if len(errs) > 0 {
 		// there was an error during start, so we immediatly pass the interrupt
 		// in order to disconnect the initialized robots, connections and devices
@@ -71,36 +119,118 @@ func (g *Gobot) Start() (errs []error) {
 
 	// waiting for interrupt coming on the channel
 	_ = <-c
-	g.robots.Each(func(r *Robot) {
-		log.Println("Stopping Robot", r.Name, "...")
-		if herrs := r.Devices().Halt(); len(herrs) > 0 {
-			for _, err := range herrs {
-				log.Println("Error:", err)
-				errs = append(errs, err)
-			}
-		}
-		if cerrs := r.Connections().Finalize(); len(cerrs) > 0 {
-			for _, err := range cerrs {
-				log.Println("Error:", err)
-				errs = append(errs, err)
+	serrs := g.Stop()
+	g.mutex.Lock()
+	g.interrupt, g.stopErrs = nil, serrs
+	close(g.stopped)
+	g.mutex.Unlock()
+	return append(errs, serrs...)
+}
+
+// Shutdown stops g as Start does on reception of a SIGINT, e.g. for an API
+// server to halt the robots before the host reboots, and returns once they
+// have halted, with the errors of Stop. Shutdown calls Stop if Start is not
+// running.
+func (g *Gobot) Shutdown() []error {
+	g.mutex.Lock()
+	c, stopped := g.interrupt, g.stopped
+	g.mutex.Unlock()
+	if c == nil {
+		return g.Stop()
+	}
+	select {
+	case c <- os.Interrupt:
+	default:
+	}
+	<-stopped
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.stopErrs
+}
+
+// Stop stops the API servers, then the robots in the reverse order they were
+// added. Stop returns ErrShutdownTimeout if the robots have not stopped
+// within the ShutdownTimeout.
+func (g *Gobot) Stop() (errs []error) {
+	for _, err := range g.stopAPIServers() {
+		Log(ErrorLevel, err.Error(), nil)
+		errs = append(errs, err)
+	}
+	done := make(chan []error, 1)
+	go func() {
+		var serrs []error
+		robots := g.Robots()
+		for i := robots.Len() - 1; i >= 0; i-- {
+			for _, err := range (*robots)[i].Stop() {
+				Log(ErrorLevel, err.Error(), nil)
+				serrs = append(serrs, err)
 			}
 		}
-	})
-	return errs
+		done <- serrs
+	}()
+
+	if g.ShutdownTimeout <= 0 {
+		return append(errs, <-done...)
+	}
+	select {
+	case serrs := <-done:
+		errs = append(errs, serrs...)
+	case <-time.After(g.ShutdownTimeout):
+		Log(ErrorLevel, ErrShutdownTimeout.Error(), nil)
+		errs = append(errs, ErrShutdownTimeout)
+	}
+	return
 }
 
-// Robots returns all robots associated with this Gobot.
-func (g *Gobot) Robots() *Robots {
-	return g.robots
+// Robots returns all robots associated with this Gobot. Given filters, only
+// the robots whose tags match every filter are returned, e.g.
+// g.Robots(Tags{"zone": "warehouse-a"}). The robots are a snapshot, which
+// robots added or removed afterwards do not change.
+func (g *Gobot) Robots(filters ...Tags) *Robots {
+	g.robotsMutex.RLock()
+	all := g.robots
+	g.robotsMutex.RUnlock()
+	if len(filters) == 0 {
+		return all
+	}
+	robots := &Robots{}
+	for _, robot := range *all {
+		matched := true
+		for _, filter := range filters {
+			matched = matched && robot.CurrentTags().Match(filter)
+		}
+		if matched {
+			*robots = append(*robots, robot)
+		}
+	}
+	return robots
 }
 
 // AddRobot adds a new robot to the internal collection of robots. Returns the
 // added robot
 func (g *Gobot) AddRobot(r *Robot) *Robot {
-	*g.robots = append(*g.robots, r)
+	g.robotsMutex.Lock()
+	defer g.robotsMutex.Unlock()
+	robots := append(append(Robots{}, *g.robots...), r)
+	g.robots = &robots
 	return r
 }
 
+// RemoveRobot removes the named robot from the collection of robots, without
+// stopping it. Returns the removed Robot, nil if the Robot does not exist.
+func (g *Gobot) RemoveRobot(name string) *Robot {
+	g.robotsMutex.Lock()
+	defer g.robotsMutex.Unlock()
+	for i, robot := range *g.robots {
+		if robot.Name == name {
+			robots := append(append(Robots{}, (*g.robots)[:i]...), (*g.robots)[i+1:]...)
+			g.robots = &robots
+			return robot
+		}
+	}
+	return nil
+}
+
 // Robot returns a robot given name. Returns nil if the Robot does not exist.
 func (g *Gobot) Robot(name string) *Robot {
 	for _, robot := range *g.Robots() {
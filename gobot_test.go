@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestConnectionEach(t *testing.T) {
@@ -55,6 +56,26 @@ func TestGobotRobot(t *testing.T) {
 	Assert(t, g.Robot("Robot1").Connections().Len(), 3)
 }
 
+func TestGobotRemoveRobot(t *testing.T) {
+	g := initTestGobot()
+	robot := g.Robot("Robot1")
+	Assert(t, g.RemoveRobot("Robot1"), robot)
+	Assert(t, g.Robot("Robot1"), (*Robot)(nil))
+	Assert(t, g.RemoveRobot("Robot1"), (*Robot)(nil))
+
+	device := robot.Device("Device1")
+	Assert(t, robot.RemoveDevice("Device1"), device)
+	Assert(t, robot.Device("Device1"), (Device)(nil))
+	Assert(t, robot.Devices().Len(), 2)
+	Assert(t, robot.RemoveDevice("Device1"), (Device)(nil))
+
+	connection := robot.Connection("Connection1")
+	Assert(t, robot.RemoveConnection("Connection1"), connection)
+	Assert(t, robot.Connection("Connection1"), (Connection)(nil))
+	Assert(t, robot.Connections().Len(), 2)
+	Assert(t, robot.RemoveConnection("Connection1"), (Connection)(nil))
+}
+
 func TestGobotToJSON(t *testing.T) {
 	g := initTestGobot()
 	g.AddCommand("test_function", func(params map[string]interface{}) interface{} {
@@ -121,3 +142,132 @@ func TestGobotStartErrors(t *testing.T) {
 
 	Assert(t, len(g.Start()), 2)
 }
+
+func TestRobotStop(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	halted := []string{}
+	r := NewRobot("Robot1",
+		[]Device{newRecordingDriver("Device1", &halted), newRecordingDriver("Device2", &halted)},
+	)
+	Assert(t, len(r.Start()), 0)
+	Assert(t, len(r.Stop()), 0)
+	Assert(t, halted, []string{"Device2", "Device1"})
+	select {
+	case <-r.Stopped():
+	default:
+		t.Errorf("Stopped was not closed")
+	}
+	// stopping twice is fine
+	Assert(t, len(r.Stop()), 0)
+
+	// a restarted robot is running again
+	r.Start()
+	select {
+	case <-r.Stopped():
+		t.Errorf("Stopped was not reset")
+	default:
+	}
+}
+
+func TestGobotStopTimeout(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	halted := []string{}
+	driver := newRecordingDriver("Device1", &halted)
+	driver.block = make(chan bool)
+	g := NewGobot()
+	g.AddRobot(NewRobot("Robot1", []Device{driver}))
+	g.ShutdownTimeout = 5 * time.Millisecond
+	Assert(t, g.Stop(), []error{ErrShutdownTimeout})
+	close(driver.block)
+}
+
+func TestGobotShutdown(t *testing.T) {
+	g := initTestGobot()
+	g.trap = func(c chan os.Signal) {}
+	done := make(chan []error)
+	go func() { done <- g.Start() }()
+	for started := false; !started; time.Sleep(time.Millisecond) {
+		g.mutex.Lock()
+		started = g.interrupt != nil
+		g.mutex.Unlock()
+	}
+
+	errs := g.Shutdown()
+	select {
+	case serrs := <-done:
+		Assert(t, serrs, errs)
+	case <-time.After(time.Second):
+		t.Fatal("Start did not return")
+	}
+}
+
+func TestRobotDeviceDependencies(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	halted := []string{}
+	motor := newRecordingDriver("motor", &halted)
+	motor.dependencies = []string{"relay"}
+	sensor := newRecordingDriver("sensor", &halted)
+	sensor.dependencies = []string{"mux"}
+	r := NewRobot("Robot1", []Device{
+		motor, sensor, newRecordingDriver("relay", &halted), newRecordingDriver("mux", &halted),
+	})
+	Assert(t, len(r.Start()), 0)
+	names := []string{}
+	r.Devices().Each(func(d Device) { names = append(names, d.Name()) })
+	Assert(t, names, []string{"relay", "motor", "mux", "sensor"})
+
+	Assert(t, len(r.Stop()), 0)
+	Assert(t, halted, []string{"sensor", "mux", "motor", "relay"})
+}
+
+func TestRobotDeviceDependencyErrors(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	halted := []string{}
+	a := newRecordingDriver("a", &halted)
+	a.dependencies = []string{"b"}
+	b := newRecordingDriver("b", &halted)
+	b.dependencies = []string{"c"}
+	c := newRecordingDriver("c", &halted)
+	c.dependencies = []string{"b"}
+	r := NewRobot("Robot1", []Device{a, b, c})
+	Assert(t, r.Start(), []error{errors.New("Dependency cycle: b -> c -> b")})
+
+	c.dependencies = []string{"d"}
+	Assert(t, r.Start(), []error{errors.New("Device \"c\" depends on unknown device \"d\"")})
+}
+
+func TestRobotStartConcurrency(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	tracker := &startTracker{}
+	r := NewRobot("Robot1", []Device{
+		newSlowDriver("sensor1", tracker, "mux"),
+		newSlowDriver("sensor2", tracker, "mux"),
+		newSlowDriver("sensor3", tracker, "mux"),
+		newSlowDriver("mux", tracker),
+		newSlowDriver("led", tracker),
+	})
+	r.StartConcurrency = 2
+	Assert(t, len(r.Start()), 0)
+	Assert(t, tracker.max, 2)
+	Assert(t, len(tracker.started), 5)
+	for i, name := range tracker.started {
+		if name == "mux" {
+			break
+		}
+		if name != "led" {
+			t.Errorf("%v started before mux (position %v)", name, i)
+		}
+	}
+
+	// no more devices are started after one fails
+	tracker = &startTracker{}
+	broken := newSlowDriver("mux", tracker)
+	broken.err = errors.New("no ack")
+	r = NewRobot("Robot1", []Device{
+		broken,
+		newSlowDriver("sensor1", tracker, "mux"),
+	})
+	r.StartConcurrency = 2
+	Assert(t, r.Start(), []error{NewDeviceError("mux", "Start", errors.New("no ack"), false)})
+	Assert(t, tracker.started, []string{"mux"})
+}
@@ -0,0 +1,144 @@
+package gobot
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Group is a named set of devices of a robot which are driven together, e.g.
+// the "left_wheels" or the "headlights".
+type Group struct {
+	Name    string
+	robot   *Robot
+	devices []string
+}
+
+// JSONGroup is a JSON representation of a Group.
+type JSONGroup struct {
+	Name    string        `json:"name"`
+	Devices []*JSONDevice `json:"devices"`
+}
+
+// NewJSONGroup returns a JSONGroup given a Group.
+func NewJSONGroup(group *Group) *JSONGroup {
+	jsonGroup := &JSONGroup{
+		Name:    group.Name,
+		Devices: []*JSONDevice{},
+	}
+	group.Devices().Each(func(device Device) {
+		jsonDevice := NewJSONDevice(device)
+		jsonDevice.Disabled = group.robot.DeviceDisabled(device.Name())
+		jsonGroup.Devices = append(jsonGroup.Devices, jsonDevice)
+	})
+	return jsonGroup
+}
+
+// AddGroup adds a group of the named devices of the robot, replacing a
+// group of the same name. Returns an error if a device does not exist.
+func (r *Robot) AddGroup(name string, devices ...string) (*Group, error) {
+	for _, device := range devices {
+		if r.Device(device) == nil {
+			return nil, fmt.Errorf("Group %q: unknown device %q", name, device)
+		}
+	}
+	group := &Group{Name: name, robot: r, devices: devices}
+	r.groupsMutex.Lock()
+	defer r.groupsMutex.Unlock()
+	for i, g := range r.groups {
+		if g.Name == name {
+			r.groups[i] = group
+			return group, nil
+		}
+	}
+	r.groups = append(r.groups, group)
+	return group, nil
+}
+
+// Group returns a group given a name. Returns nil if the Group does not
+// exist.
+func (r *Robot) Group(name string) *Group {
+	r.groupsMutex.Lock()
+	defer r.groupsMutex.Unlock()
+	for _, group := range r.groups {
+		if group.Name == name {
+			return group
+		}
+	}
+	return nil
+}
+
+// Groups returns the groups of the robot in the order they were added.
+func (r *Robot) Groups() []*Group {
+	r.groupsMutex.Lock()
+	defer r.groupsMutex.Unlock()
+	return append([]*Group{}, r.groups...)
+}
+
+// Devices returns the devices of the group.
+func (g *Group) Devices() *Devices {
+	devices := &Devices{}
+	for _, name := range g.devices {
+		if device := g.robot.Device(name); device != nil {
+			*devices = append(*devices, device)
+		}
+	}
+	return devices
+}
+
+// Start starts the devices of the group which are not disabled, in the order
+// they were added to the group, within the StartTimeout of the robot.
+func (g *Group) Start() (errs []error) {
+	for _, device := range *g.Devices() {
+		if derrs := g.robot.startDevice(device, nil); len(derrs) > 0 {
+			errs = append(errs, derrs...)
+		}
+	}
+	return
+}
+
+// Halt halts the devices of the group which are not disabled, in the
+// reverse order they were added to the group, within the HaltTimeout of the
+// robot.
+func (g *Group) Halt() (errs []error) {
+	devices := &Devices{}
+	for _, device := range *g.Devices() {
+		if !g.robot.DeviceDisabled(device.Name()) {
+			*devices = append(*devices, device)
+		}
+	}
+	errs = devices.halt(g.robot.HaltTimeout, nil)
+	g.robot.publishDeviceErrors(errs)
+	return
+}
+
+// Execute executes the named command of each device of the group which is
+// not disabled at the same time, and returns the results by device name.
+// The result of a device without the command is ErrUnknownCommand.
+func (g *Group) Execute(command string, params map[string]interface{}) map[string]interface{} {
+	results := make(map[string]interface{})
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, device := range *g.Devices() {
+		name := device.Name()
+		if g.robot.DeviceDisabled(name) {
+			continue
+		}
+		commander, ok := device.(Commander)
+		if !ok || commander.Command(command) == nil {
+			mutex.Lock()
+			results[name] = ErrUnknownCommand
+			mutex.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(f func(map[string]interface{}) interface{}) {
+			defer wg.Done()
+			result := f(params)
+			mutex.Lock()
+			defer mutex.Unlock()
+			results[name] = result
+		}(commander.Command(command))
+	}
+	wg.Wait()
+	return results
+}
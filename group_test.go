@@ -0,0 +1,72 @@
+package gobot
+
+import (
+	"errors"
+	"testing"
+)
+
+func newGroupRobot(halted *[]string) *Robot {
+	adaptor := newTestAdaptor("Connection1", "/dev/null")
+	left := newRecordingDriver("left_wheel", halted)
+	right := newRecordingDriver("right_wheel", halted)
+	lidar := newRecordingDriver("lidar", halted)
+	for _, wheel := range []*recordingDriver{left, right} {
+		name := wheel.Name()
+		wheel.AddCommand("Speed", func(params map[string]interface{}) interface{} {
+			return name + " at " + params["speed"].(string)
+		})
+	}
+	return NewRobot("rover", []Connection{adaptor}, []Device{left, right, lidar})
+}
+
+func TestRobotAddGroup(t *testing.T) {
+	r := newGroupRobot(&[]string{})
+	group, err := r.AddGroup("wheels", "left_wheel", "right_wheel")
+	Assert(t, err, nil)
+	Assert(t, r.Group("wheels"), group)
+	Assert(t, group.Devices().Len(), 2)
+	Assert(t, r.Groups(), []*Group{group})
+
+	_, err = r.AddGroup("sensors", "lidar", "sonar")
+	Assert(t, err, errors.New("Group \"sensors\": unknown device \"sonar\""))
+	Assert(t, r.Group("sensors"), (*Group)(nil))
+
+	// a group of the same name is replaced
+	replaced, _ := r.AddGroup("wheels", "left_wheel")
+	Assert(t, r.Groups(), []*Group{replaced})
+
+	jsonRobot := NewJSONRobot(r)
+	Assert(t, len(jsonRobot.Groups), 1)
+	Assert(t, jsonRobot.Groups[0].Name, "wheels")
+	Assert(t, jsonRobot.Groups[0].Devices[0].Name, "left_wheel")
+}
+
+func TestGroupStartHalt(t *testing.T) {
+	halted := []string{}
+	r := newGroupRobot(&halted)
+	group, _ := r.AddGroup("all", "left_wheel", "right_wheel", "lidar")
+	Assert(t, len(group.Start()), 0)
+
+	r.DisableDevice("lidar")
+	halted = halted[:0]
+	Assert(t, len(group.Halt()), 0)
+	Assert(t, halted, []string{"right_wheel", "left_wheel"})
+}
+
+func TestGroupExecute(t *testing.T) {
+	r := newGroupRobot(&[]string{})
+	group, _ := r.AddGroup("all", "left_wheel", "right_wheel", "lidar")
+
+	Assert(t, group.Execute("Speed", map[string]interface{}{"speed": "50"}), map[string]interface{}{
+		"left_wheel":  "left_wheel at 50",
+		"right_wheel": "right_wheel at 50",
+		"lidar":       ErrUnknownCommand,
+	})
+
+	// disabled devices are skipped
+	r.DisableDevice("left_wheel")
+	Assert(t, group.Execute("Speed", map[string]interface{}{"speed": "0"}), map[string]interface{}{
+		"right_wheel": "right_wheel at 0",
+		"lidar":       ErrUnknownCommand,
+	})
+}
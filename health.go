@@ -0,0 +1,73 @@
+package gobot
+
+import (
+	"fmt"
+	"time"
+)
+
+// HealthChecker is the interface that describes a driver or adaptor which
+// can tell whether it is working, e.g. whether its i2c device still answers
+type HealthChecker interface {
+	// Health returns an error describing why the Driver or Adaptor is
+	// unhealthy, or nil
+	Health() error
+}
+
+// CheckHealth calls Health on each connection and device of the robot which
+// is a HealthChecker and returns the errors of the unhealthy ones. Disabled
+// devices are not checked.
+func (r *Robot) CheckHealth() (errs []error) {
+	r.Connections().Each(func(connection Connection) {
+		if checker, ok := connection.(HealthChecker); ok {
+			if err := checker.Health(); err != nil {
+				errs = append(errs, fmt.Errorf("Connection %q: %v", connection.Name(), err))
+			}
+		}
+	})
+	r.enabledDevices().Each(func(device Device) {
+		if checker, ok := device.(HealthChecker); ok {
+			if err := checker.Health(); err != nil {
+				errs = append(errs, NewDeviceError(device.Name(), "Health", err, true))
+			}
+		}
+	})
+	return
+}
+
+// MonitorHealth makes the robot call CheckHealth every interval while it is
+// running. The errors of connections and devices which become unhealthy are
+// published on the "unhealthy" event, and the robot's name is published on
+// the "healthy" event once all of them are healthy again. Call MonitorHealth
+// before starting the robot, an interval of 0 turns monitoring off.
+func (r *Robot) MonitorHealth(interval time.Duration) {
+	r.healthInterval = interval
+}
+
+// monitorHealth polls the health of the robot until stopped is closed
+func (r *Robot) monitorHealth(stopped <-chan struct{}) {
+	ticker := clock().NewTicker(r.healthInterval)
+	defer ticker.Stop()
+
+	unhealthy := make(map[string]bool)
+	for {
+		select {
+		case <-stopped:
+			return
+		case <-ticker.C():
+		}
+		errs := r.CheckHealth()
+		current := make(map[string]bool)
+		for _, err := range errs {
+			current[err.Error()] = true
+			if !unhealthy[err.Error()] {
+				Log(WarnLevel, "Unhealthy", Fields{"robot": r.Name, "error": err})
+				Publish(r.Event("unhealthy"), err)
+			}
+		}
+		if len(errs) == 0 && len(unhealthy) > 0 {
+			Log(InfoLevel, "Healthy again", Fields{"robot": r.Name})
+			Publish(r.Event("healthy"), r.Name)
+		}
+		unhealthy = current
+	}
+}
@@ -0,0 +1,53 @@
+package gobot
+
+import (
+	"errors"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestRobotCheckHealth(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	driver := newHealthDriver("sensor")
+	r := NewRobot("Robot1", []Device{driver, newTestDriver(newTestAdaptor("Connection1", "/dev/null"), "led", "13")})
+	Assert(t, len(r.CheckHealth()), 0)
+	driver.setHealth(errors.New("i2c bus stuck"))
+	Assert(t, r.CheckHealth(), []error{NewDeviceError("sensor", "Health", errors.New("i2c bus stuck"), true)})
+}
+
+func TestRobotMonitorHealth(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	driver := newHealthDriver("sensor")
+	r := NewRobot("Robot1", []Device{driver})
+	r.MonitorHealth(time.Millisecond)
+
+	unhealthy := make(chan error, 10)
+	healthy := make(chan interface{}, 10)
+	On(r.Event("unhealthy"), func(data interface{}) {
+		unhealthy <- data.(error)
+	})
+	On(r.Event("healthy"), func(data interface{}) {
+		healthy <- data
+	})
+	Assert(t, len(r.Start()), 0)
+	defer r.Stop()
+
+	driver.setHealth(errors.New("i2c bus stuck"))
+	select {
+	case err := <-unhealthy:
+		Assert(t, err, NewDeviceError("sensor", "Health", errors.New("i2c bus stuck"), true))
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("unhealthy was not published")
+	}
+
+	driver.setHealth(nil)
+	select {
+	case name := <-healthy:
+		Assert(t, name, "Robot1")
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("healthy was not published")
+	}
+	// transitions are only published once
+	Assert(t, len(unhealthy), 0)
+}
@@ -1,5 +1,11 @@
 package gobot
 
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
 type NullReadWriteCloser struct{}
 
 func (NullReadWriteCloser) Write(p []byte) (int, error) {
@@ -74,6 +80,104 @@ func newTestAdaptor(name string, port string) *testAdaptor {
 	}
 }
 
+// dryRunAdaptor fails to connect unless it is dry run
+type dryRunAdaptor struct {
+	*testAdaptor
+	DryRunner
+}
+
+func newDryRunAdaptor(name string) *dryRunAdaptor {
+	return &dryRunAdaptor{
+		testAdaptor: newTestAdaptor(name, "/dev/null"),
+		DryRunner:   NewDryRunner(),
+	}
+}
+
+func (d *dryRunAdaptor) Connect() (errs []error) {
+	if d.DryRunning() {
+		return
+	}
+	return []error{errors.New("no hardware")}
+}
+
+func (d *dryRunAdaptor) DigitalWrite(pin string, level byte) (err error) {
+	if d.Record("DigitalWrite", pin, level) {
+		return
+	}
+	return errors.New("no hardware")
+}
+
+// ioAdaptor traces its writes
+type ioAdaptor struct {
+	*testAdaptor
+	IOTracer
+}
+
+func newIOAdaptor(name string) *ioAdaptor {
+	return &ioAdaptor{
+		testAdaptor: newTestAdaptor(name, "/dev/null"),
+		IOTracer:    NewIOTracer(),
+	}
+}
+
+func (i *ioAdaptor) DigitalWrite(pin string, level byte) (err error) {
+	defer i.StartIO("DigitalWrite")(&err)
+	if level > 1 {
+		err = errors.New("invalid level")
+	}
+	return
+}
+
+// testTracer records the spans it started
+type testTracer struct {
+	spans []*testSpan
+	mutex sync.Mutex
+}
+
+type testSpan struct {
+	name       string
+	parent     *testSpan
+	attributes map[string]string
+	ended      bool
+	err        error
+}
+
+func (t *testTracer) StartSpan(parent Span, name string, attributes map[string]string) Span {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	span := &testSpan{name: name, attributes: attributes}
+	if parent != nil {
+		span.parent = parent.(*testSpan)
+	}
+	t.spans = append(t.spans, span)
+	return span
+}
+
+func (s *testSpan) End(err error) {
+	s.ended = true
+	s.err = err
+}
+
+// ExtractSpan returns a "remote" span holding the carrier as attributes
+func (t *testTracer) ExtractSpan(carrier map[string]string) Span {
+	if carrier["traceparent"] == "" {
+		return nil
+	}
+	return &testSpan{name: "remote", attributes: carrier}
+}
+
+// span returns the latest span started given a name
+func (t *testTracer) span(name string) *testSpan {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for i := len(t.spans) - 1; i >= 0; i-- {
+		if t.spans[i].name == name {
+			return t.spans[i]
+		}
+	}
+	return nil
+}
+
 func newTestRobot(name string) *Robot {
 	adaptor1 := newTestAdaptor("Connection1", "/dev/null")
 	adaptor2 := newTestAdaptor("Connection2", "/dev/null")
@@ -91,3 +195,163 @@ func newTestRobot(name string) *Robot {
 
 	return r
 }
+
+// recordingDriver records the order devices are halted in
+type recordingDriver struct {
+	*testDriver
+	halted       *[]string
+	block        chan bool
+	startBlock   chan bool
+	dependencies []string
+}
+
+func (r *recordingDriver) Start() (errs []error) {
+	if r.startBlock != nil {
+		<-r.startBlock
+	}
+	return
+}
+
+func (r *recordingDriver) Dependencies() []string { return r.dependencies }
+
+func (r *recordingDriver) Halt() (errs []error) {
+	if r.block != nil {
+		<-r.block
+	}
+	*r.halted = append(*r.halted, r.Name())
+	return
+}
+
+func newRecordingDriver(name string, halted *[]string) *recordingDriver {
+	return &recordingDriver{
+		testDriver: newTestDriver(newTestAdaptor("Connection1", "/dev/null"), name, "0"),
+		halted:     halted,
+	}
+}
+
+// flakyDriver fails to start the first failures times
+type flakyDriver struct {
+	*testDriver
+	failures int
+	starts   int
+	mutex    sync.Mutex
+}
+
+func (f *flakyDriver) Start() (errs []error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.starts++
+	if f.starts <= f.failures {
+		return []error{errors.New("flaky")}
+	}
+	return
+}
+
+func (f *flakyDriver) Halt() (errs []error) { return }
+
+func newFlakyDriver(name string, failures int) *flakyDriver {
+	f := &flakyDriver{
+		testDriver: newTestDriver(newTestAdaptor("Connection1", "/dev/null"), name, "0"),
+		failures:   failures,
+	}
+	f.AddEvent("error")
+	return f
+}
+
+// healthDriver is a driver whose health can be changed
+type healthDriver struct {
+	*testDriver
+	err   error
+	mutex sync.Mutex
+}
+
+func (h *healthDriver) Health() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.err
+}
+
+func (h *healthDriver) setHealth(err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.err = err
+}
+
+func newHealthDriver(name string) *healthDriver {
+	return &healthDriver{
+		testDriver: newTestDriver(newTestAdaptor("Connection1", "/dev/null"), name, "0"),
+	}
+}
+
+// slowDriver takes a while to start and records when it started
+type slowDriver struct {
+	*testDriver
+	tracker      *startTracker
+	dependencies []string
+	err          error
+}
+
+// startTracker records the order slowDrivers finished starting in and how
+// many were starting at once
+type startTracker struct {
+	started []string
+	active  int
+	max     int
+	mutex   sync.Mutex
+}
+
+func (s *slowDriver) Dependencies() []string { return s.dependencies }
+
+func (s *slowDriver) Start() (errs []error) {
+	s.tracker.mutex.Lock()
+	s.tracker.active++
+	if s.tracker.active > s.tracker.max {
+		s.tracker.max = s.tracker.active
+	}
+	s.tracker.mutex.Unlock()
+
+	<-time.After(5 * time.Millisecond)
+
+	s.tracker.mutex.Lock()
+	defer s.tracker.mutex.Unlock()
+	s.tracker.active--
+	s.tracker.started = append(s.tracker.started, s.Name())
+	if s.err != nil {
+		return []error{s.err}
+	}
+	return
+}
+
+func newSlowDriver(name string, tracker *startTracker, dependencies ...string) *slowDriver {
+	return &slowDriver{
+		testDriver:   newTestDriver(newTestAdaptor("Connection1", "/dev/null"), name, "0"),
+		tracker:      tracker,
+		dependencies: dependencies,
+	}
+}
+
+// snapshotDriver is a driver with a single value as state
+type snapshotDriver struct {
+	*testDriver
+	value float64
+}
+
+func (s *snapshotDriver) Snapshot() map[string]interface{} {
+	return map[string]interface{}{"value": s.value}
+}
+
+func (s *snapshotDriver) Restore(state map[string]interface{}) error {
+	value, ok := state["value"].(float64)
+	if !ok {
+		return errors.New("value is missing")
+	}
+	s.value = value
+	return nil
+}
+
+func newSnapshotDriver(name string, value float64) *snapshotDriver {
+	return &snapshotDriver{
+		testDriver: newTestDriver(newTestAdaptor("Connection1", "/dev/null"), name, "3"),
+		value:      value,
+	}
+}
@@ -0,0 +1,187 @@
+package gobot
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrUnknownCommand is the error resulting if the specified command does not
+// exist
+var ErrUnknownCommand = errors.New("Command does not exist")
+
+// maxFinishedJobs is the number of finished jobs a Commander keeps
+const maxFinishedJobs = 100
+
+// JobStatus is the status of a Job
+type JobStatus string
+
+// The statuses of a Job
+const (
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	// JobFailed is the status of a job whose command returned an error
+	JobFailed JobStatus = "failed"
+)
+
+// Job is a command executed in the background by ExecuteAsync
+type Job struct {
+	ID      string
+	Command string
+	Started time.Time
+
+	status   JobStatus
+	result   interface{}
+	finished time.Time
+	done     chan struct{}
+	mutex    sync.Mutex
+}
+
+// JSONJob is a JSON representation of a Job.
+type JSONJob struct {
+	ID       string      `json:"id"`
+	Command  string      `json:"command"`
+	Status   JobStatus   `json:"status"`
+	Result   interface{} `json:"result,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	Started  time.Time   `json:"started"`
+	Finished *time.Time  `json:"finished,omitempty"`
+}
+
+// NewJSONJob returns a JSONJob given a Job.
+func NewJSONJob(job *Job) *JSONJob {
+	status, result := job.Status(), job.Result()
+	j := &JSONJob{
+		ID:      job.ID,
+		Command: job.Command,
+		Status:  status,
+		Started: job.Started,
+	}
+	if err, ok := result.(error); ok {
+		j.Error = err.Error()
+	} else {
+		j.Result = result
+	}
+	if status != JobRunning {
+		finished := job.Finished()
+		j.Finished = &finished
+	}
+	return j
+}
+
+// Status returns the status of the job
+func (j *Job) Status() JobStatus {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.status
+}
+
+// Result returns the value returned by the command, nil while the job is
+// running
+func (j *Job) Result() interface{} {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.result
+}
+
+// Finished returns the time the job finished at, the zero time while the job
+// is running
+func (j *Job) Finished() time.Time {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.finished
+}
+
+// Done returns a channel which is closed once the job has finished
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait waits for the job to finish and returns the value returned by the
+// command
+func (j *Job) Wait() interface{} {
+	<-j.done
+	return j.Result()
+}
+
+// finish records the result of the command and closes the done channel
+func (j *Job) finish(result interface{}) {
+	j.mutex.Lock()
+	j.result = result
+	j.status = JobDone
+	if _, ok := result.(error); ok {
+		j.status = JobFailed
+	}
+	j.finished = clock().Now()
+	j.mutex.Unlock()
+	close(j.done)
+}
+
+func (c *commander) ExecuteAsync(name string, params map[string]interface{}) (*Job, error) {
+	command := c.Command(name)
+	if command == nil {
+		return nil, ErrUnknownCommand
+	}
+
+	c.jobsMutex.Lock()
+	c.jobCount++
+	job := &Job{
+		ID:      strconv.Itoa(c.jobCount),
+		Command: name,
+		Started: clock().Now(),
+		status:  JobRunning,
+		done:    make(chan struct{}),
+	}
+	c.jobs[job.ID] = job
+	c.jobOrder = append(c.jobOrder, job.ID)
+	c.pruneJobs()
+	jobDone := c.jobDone
+	c.jobsMutex.Unlock()
+
+	go func() {
+		job.finish(command(params))
+		if jobDone != nil {
+			jobDone(job)
+		}
+	}()
+	return job, nil
+}
+
+func (c *commander) Job(id string) *Job {
+	c.jobsMutex.Lock()
+	defer c.jobsMutex.Unlock()
+	return c.jobs[id]
+}
+
+// pruneJobs forgets the oldest finished jobs beyond maxFinishedJobs
+func (c *commander) pruneJobs() {
+	finished := 0
+	for _, id := range c.jobOrder {
+		if c.jobs[id].Status() != JobRunning {
+			finished++
+		}
+	}
+	order := c.jobOrder[:0]
+	for _, id := range c.jobOrder {
+		if finished > maxFinishedJobs && c.jobs[id].Status() != JobRunning {
+			delete(c.jobs, id)
+			finished--
+			continue
+		}
+		order = append(order, id)
+	}
+	c.jobOrder = order
+}
+
+// publishJobs publishes the jobs of c on event once they have finished, if
+// c is the Commander returned by NewCommander
+func publishJobs(c Commander, event *Event) {
+	if c, ok := c.(*commander); ok {
+		c.jobsMutex.Lock()
+		defer c.jobsMutex.Unlock()
+		c.jobDone = func(job *Job) {
+			Publish(event, job)
+		}
+	}
+}
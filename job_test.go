@@ -0,0 +1,83 @@
+package gobot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCommanderExecuteAsync(t *testing.T) {
+	c := NewCommander()
+	release := make(chan bool)
+	c.AddCommand("calibrate", func(params map[string]interface{}) interface{} {
+		<-release
+		return params["axis"]
+	})
+
+	job, err := c.ExecuteAsync("calibrate", map[string]interface{}{"axis": "x"})
+	Assert(t, err, nil)
+	Assert(t, job.Command, "calibrate")
+	Assert(t, job.Status(), JobRunning)
+	Assert(t, job.Result(), nil)
+	Assert(t, c.Job(job.ID), job)
+
+	close(release)
+	Assert(t, job.Wait(), "x")
+	Assert(t, job.Status(), JobDone)
+	Refute(t, job.Finished().IsZero(), true)
+
+	_, err = c.ExecuteAsync("home", nil)
+	Assert(t, err, ErrUnknownCommand)
+	Assert(t, c.Job("42"), (*Job)(nil))
+}
+
+func TestCommanderExecuteAsyncFailed(t *testing.T) {
+	c := NewCommander()
+	c.AddCommandWithParams("move", []Param{{Name: "steps", Type: ParamInt, Required: true}},
+		func(params map[string]interface{}) interface{} {
+			return params["steps"]
+		},
+	)
+	job, _ := c.ExecuteAsync("move", map[string]interface{}{})
+	<-job.Done()
+	Assert(t, job.Status(), JobFailed)
+
+	j := NewJSONJob(job)
+	Assert(t, j.Status, JobFailed)
+	Assert(t, j.Error, "Missing parameter \"steps\"")
+	Assert(t, j.Result, nil)
+}
+
+func TestCommanderPrunesFinishedJobs(t *testing.T) {
+	c := NewCommander()
+	c.AddCommand("ping", func(params map[string]interface{}) interface{} {
+		return "pong"
+	})
+	first, _ := c.ExecuteAsync("ping", nil)
+	<-first.Done()
+	for i := 0; i < maxFinishedJobs; i++ {
+		job, _ := c.ExecuteAsync("ping", nil)
+		<-job.Done()
+	}
+	c.ExecuteAsync("ping", nil)
+	Assert(t, c.Job(first.ID), (*Job)(nil))
+}
+
+func TestRobotJobDone(t *testing.T) {
+	r := NewRobot("Robot1")
+	r.AddCommand("home", func(params map[string]interface{}) interface{} {
+		return errors.New("limit switch not found")
+	})
+	done := make(chan *Job, 1)
+	On(r.Event("job_done"), func(data interface{}) {
+		done <- data.(*Job)
+	})
+	job, _ := r.ExecuteAsync("home", nil)
+	select {
+	case j := <-done:
+		Assert(t, j, job)
+		Assert(t, j.Status(), JobFailed)
+	case <-time.After(10 * time.Millisecond):
+		t.Error("job_done was not published")
+	}
+}
@@ -0,0 +1,122 @@
+package gobot
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Level is the severity of a log message
+type Level int
+
+const (
+	// DebugLevel is used for chatty messages, e.g. the traffic of an adaptor
+	DebugLevel Level = iota
+	// InfoLevel is used for the lifecycle of robots, connections and devices
+	InfoLevel
+	// WarnLevel is used for recoverable problems
+	WarnLevel
+	// ErrorLevel is used for failures
+	ErrorLevel
+)
+
+// String returns the name of the level
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "DEBUG"
+	case InfoLevel:
+		return "INFO"
+	case WarnLevel:
+		return "WARNING"
+	case ErrorLevel:
+		return "ERROR"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// Fields are the key value pairs attached to a log message, e.g. the name of
+// the robot or device it is about
+type Fields map[string]interface{}
+
+// Logger is the interface gobot logs through. Implement it to route the logs
+// into another logging library, see SetLogger.
+type Logger interface {
+	// Log logs msg at level with fields, which may be nil
+	Log(level Level, msg string, fields Fields)
+}
+
+var (
+	logger      Logger = NewStdLogger(nil, InfoLevel)
+	loggerMutex sync.RWMutex
+)
+
+// SetLogger makes gobot, its api and platforms log through l. A nil Logger
+// silences them.
+func SetLogger(l Logger) {
+	loggerMutex.Lock()
+	defer loggerMutex.Unlock()
+	if l == nil {
+		l = discardLogger{}
+	}
+	logger = l
+}
+
+// Log logs msg at level with fields through the Logger set with SetLogger.
+func Log(level Level, msg string, fields Fields) {
+	loggerMutex.RLock()
+	l := logger
+	loggerMutex.RUnlock()
+	l.Log(level, msg, fields)
+}
+
+// stdLogger logs through a *log.Logger
+type stdLogger struct {
+	logger *log.Logger
+	level  Level
+}
+
+// NewStdLogger returns a Logger which writes the messages of level and above
+// to l, followed by their fields sorted by key:
+//
+//	Starting device device=led pin=13 robot=bot
+//
+// Messages other than InfoLevel are prefixed with their level. A nil l
+// writes to the standard logger of the log package, which is the default.
+func NewStdLogger(l *log.Logger, level Level) Logger {
+	return &stdLogger{logger: l, level: level}
+}
+
+func (s *stdLogger) Log(level Level, msg string, fields Fields) {
+	if level < s.level {
+		return
+	}
+	line := msg
+	if level != InfoLevel {
+		line = level.String() + ": " + msg
+	}
+	if len(fields) > 0 {
+		keys := []string{}
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		pairs := []string{line}
+		for _, key := range keys {
+			pairs = append(pairs, fmt.Sprintf("%v=%v", key, fields[key]))
+		}
+		line = strings.Join(pairs, " ")
+	}
+	if s.logger != nil {
+		s.logger.Println(line)
+	} else {
+		log.Println(line)
+	}
+}
+
+// discardLogger drops every message
+type discardLogger struct{}
+
+func (discardLogger) Log(Level, string, Fields) {}
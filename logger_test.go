@@ -0,0 +1,54 @@
+package gobot
+
+import (
+	"bytes"
+	"log"
+	"sync"
+	"testing"
+)
+
+type testLogger struct {
+	levels   []Level
+	messages []string
+	fields   []Fields
+	mutex    sync.Mutex
+}
+
+func (t *testLogger) Log(level Level, msg string, fields Fields) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.levels = append(t.levels, level)
+	t.messages = append(t.messages, msg)
+	t.fields = append(t.fields, fields)
+}
+
+// logged returns the messages and fields logged from other goroutines
+func (t *testLogger) logged() ([]string, []Fields) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.messages, t.fields
+}
+
+func TestStdLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewStdLogger(log.New(buf, "", 0), InfoLevel)
+	l.Log(DebugLevel, "hidden", nil)
+	l.Log(InfoLevel, "Starting device", Fields{"robot": "bot", "device": "led", "pin": "13"})
+	l.Log(WarnLevel, "careful", nil)
+	Assert(t, buf.String(), "Starting device device=led pin=13 robot=bot\nWARNING: careful\n")
+}
+
+func TestSetLogger(t *testing.T) {
+	l := &testLogger{}
+	SetLogger(l)
+	defer SetLogger(NewStdLogger(nil, InfoLevel))
+
+	NewRobot("Robot1", []Device{newTestDriver(newTestAdaptor("Connection1", "/dev/null"), "Device1", "0")})
+	Assert(t, l.messages, []string{"Initializing robot", "Initializing device"})
+	Assert(t, l.levels, []Level{InfoLevel, InfoLevel})
+	Assert(t, l.fields[1], Fields{"robot": "Robot1", "device": "Device1"})
+
+	SetLogger(nil)
+	Log(ErrorLevel, "silenced", nil)
+	Assert(t, len(l.messages), 2)
+}
@@ -0,0 +1,28 @@
+/*
+Package metrics counts what the robots of a Gobot are doing, the events they
+publish, the commands they execute, device errors, work loop iterations and
+adaptor reconnects, and exports the counts in the Prometheus text format.
+The events and errors of devices are labelled with the device, so
+Prometheus can compute the event rate of each device.
+
+Example:
+
+	gbot := gobot.NewGobot()
+	robot := gbot.AddRobot(gobot.NewRobot("bot", []gobot.Device{button}))
+
+	m := metrics.New()
+	m.InstrumentGobot(gbot)
+	robot.Work = func() {
+		m.Every(robot, 100*time.Millisecond, func() {
+			// ...
+		})
+	}
+
+	http.Handle("/metrics", m)
+	go http.ListenAndServe(":9100", nil)
+	gbot.Start()
+
+The api package serves the counters at /metrics of the api with
+API.UseMetrics.
+*/
+package metrics
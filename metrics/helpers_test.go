@@ -0,0 +1,28 @@
+package metrics
+
+import "github.com/hybridgroup/gobot"
+
+type testDriver struct {
+	name string
+	gobot.Eventer
+	gobot.Commander
+}
+
+func (t *testDriver) Start() (errs []error)        { return }
+func (t *testDriver) Halt() (errs []error)         { return }
+func (t *testDriver) Name() string                 { return t.name }
+func (t *testDriver) Connection() gobot.Connection { return nil }
+
+func newTestDriver(name string) *testDriver {
+	t := &testDriver{
+		name:      name,
+		Eventer:   gobot.NewEventer(),
+		Commander: gobot.NewCommander(),
+	}
+	t.AddEvent("data")
+	t.AddEvent("error")
+	t.AddCommand("Ping", func(params map[string]interface{}) interface{} {
+		return "pong"
+	})
+	return t
+}
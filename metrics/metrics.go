@@ -0,0 +1,220 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// The names of the counters kept for each robot
+const (
+	EventsPublished  = "gobot_events_published_total"
+	CommandsExecuted = "gobot_commands_executed_total"
+	DeviceErrors     = "gobot_device_errors_total"
+	WorkIterations   = "gobot_work_iterations_total"
+	Reconnects       = "gobot_adaptor_reconnects_total"
+)
+
+var help = map[string]string{
+	EventsPublished:  "Events published by the robot, its connections and devices.",
+	CommandsExecuted: "Commands of the robot and its devices executed.",
+	DeviceErrors:     "Errors published by the devices of the robot.",
+	WorkIterations:   "Iterations of the work loops of the robot.",
+	Reconnects:       "Connections of the robot restarted by its supervisor.",
+}
+
+// Metrics holds the counters of one or more robots. It is an http.Handler
+// serving them in the Prometheus text format.
+type Metrics struct {
+	counters map[string]map[series]uint64
+	mutex    sync.Mutex
+}
+
+// series identifies the counter of a robot, or of one of its devices
+type series struct {
+	robot  string
+	device string
+}
+
+// New returns a new Metrics with every counter at zero.
+func New() *Metrics {
+	m := &Metrics{
+		counters: make(map[string]map[series]uint64),
+	}
+	for name := range help {
+		m.counters[name] = make(map[series]uint64)
+	}
+	return m
+}
+
+// Add adds delta to the named counter of robot.
+func (m *Metrics) Add(name string, robot string, delta uint64) {
+	m.AddDevice(name, robot, "", delta)
+}
+
+// AddDevice adds delta to the named counter of the device of robot.
+func (m *Metrics) AddDevice(name string, robot string, device string, delta uint64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.counters[name] == nil {
+		m.counters[name] = make(map[series]uint64)
+	}
+	m.counters[name][series{robot: robot, device: device}] += delta
+}
+
+// Value returns the named counter of robot, including the counters of its
+// devices.
+func (m *Metrics) Value(name string, robot string) (value uint64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for s, v := range m.counters[name] {
+		if s.robot == robot {
+			value += v
+		}
+	}
+	return
+}
+
+// DeviceValue returns the named counter of the device of robot.
+func (m *Metrics) DeviceValue(name string, robot string, device string) uint64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.counters[name][series{robot: robot, device: device}]
+}
+
+// InstrumentGobot instruments every robot of g, see Instrument.
+func (m *Metrics) InstrumentGobot(g *gobot.Gobot) {
+	g.Robots().Each(m.Instrument)
+}
+
+// Instrument counts the events published by r, its connections and devices,
+// the "error" events of its devices, the connections restarted by its
+// supervisor and the executions of the commands of r and its devices.
+// The events and errors of the devices are counted per device, so their
+// rates can be graphed. Commands added after Instrument are not counted.
+func (m *Metrics) Instrument(r *gobot.Robot) {
+	m.countEvents(r, r.Name, "")
+	gobot.On(r.Event("connection_restarted"), func(interface{}) {
+		m.Add(Reconnects, r.Name, 1)
+	})
+	m.countCommands(r, r.Name)
+
+	r.Connections().Each(func(connection gobot.Connection) {
+		m.countEvents(connection, r.Name, "")
+	})
+	r.Devices().Each(func(device gobot.Device) {
+		name := device.Name()
+		m.countEvents(device, r.Name, name)
+		if eventer, ok := device.(gobot.Eventer); ok && eventer.Event("error") != nil {
+			gobot.On(eventer.Event("error"), func(interface{}) {
+				m.AddDevice(DeviceErrors, r.Name, name, 1)
+			})
+		}
+		m.countCommands(device, r.Name)
+	})
+}
+
+// Every calls gobot.Every with f, counting each call of f as an iteration of
+// the work of r.
+func (m *Metrics) Every(r *gobot.Robot, t time.Duration, f func()) {
+	gobot.Every(t, func() {
+		m.Add(WorkIterations, r.Name, 1)
+		f()
+	})
+}
+
+// countEvents counts the events published by v, if it is a gobot.Eventer,
+// as those of device if it is not empty
+func (m *Metrics) countEvents(v interface{}, robot string, device string) {
+	eventer, ok := v.(gobot.Eventer)
+	if !ok {
+		return
+	}
+	for _, event := range eventer.Events() {
+		gobot.On(event, func(interface{}) {
+			m.AddDevice(EventsPublished, robot, device, 1)
+		})
+	}
+}
+
+// countCommands wraps the commands of v, if it is a gobot.Commander, to
+// count their executions
+func (m *Metrics) countCommands(v interface{}, robot string) {
+	commander, ok := v.(gobot.Commander)
+	if !ok {
+		return
+	}
+	for name, command := range commander.Commands() {
+		command := command
+		counted := func(params map[string]interface{}) interface{} {
+			m.Add(CommandsExecuted, robot, 1)
+			return command(params)
+		}
+		// keep the parameters of the command for the api
+		if params := commander.Params(name); params != nil {
+			commander.AddCommandWithParams(name, params, counted)
+		} else {
+			commander.AddCommand(name, counted)
+		}
+	}
+}
+
+// WritePrometheus writes the counters to w in the Prometheus text format.
+func (m *Metrics) WritePrometheus(w io.Writer) (err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	names := []string{}
+	for name := range m.counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if text, ok := help[name]; ok {
+			if _, err = fmt.Fprintf(w, "# HELP %v %v\n", name, text); err != nil {
+				return
+			}
+		}
+		if _, err = fmt.Fprintf(w, "# TYPE %v counter\n", name); err != nil {
+			return
+		}
+		keys := []series{}
+		for s := range m.counters[name] {
+			keys = append(keys, s)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].robot != keys[j].robot {
+				return keys[i].robot < keys[j].robot
+			}
+			return keys[i].device < keys[j].device
+		})
+		for _, s := range keys {
+			labels := "robot=\"" + escape(s.robot) + "\""
+			if s.device != "" {
+				labels += ",device=\"" + escape(s.device) + "\""
+			}
+			if _, err = fmt.Fprintf(w, "%v{%v} %v\n", name, labels, m.counters[name][s]); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// ServeHTTP serves the counters in the Prometheus text format.
+func (m *Metrics) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WritePrometheus(res)
+}
+
+// escape escapes a Prometheus label value
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
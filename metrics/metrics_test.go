@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func init() {
+	log.SetOutput(&bytes.Buffer{})
+}
+
+func TestInstrument(t *testing.T) {
+	driver := newTestDriver("sensor")
+	robot := gobot.NewRobot("bot", []gobot.Device{driver})
+	m := New()
+	m.Instrument(robot)
+
+	gobot.Publish(driver.Event("data"), 1)
+	<-time.After(5 * time.Millisecond)
+	gobot.Publish(driver.Event("error"), errors.New("read failed"))
+	<-time.After(5 * time.Millisecond)
+	gobot.Publish(robot.Event("connection_restarted"), gobot.ConnectionRestarted{})
+	<-time.After(5 * time.Millisecond)
+
+	gobot.Assert(t, driver.Command("Ping")(nil), "pong")
+
+	// the error is also published on the "device_error" event of the robot
+	gobot.Assert(t, m.Value(EventsPublished, "bot"), uint64(4))
+	gobot.Assert(t, m.Value(DeviceErrors, "bot"), uint64(1))
+	gobot.Assert(t, m.Value(Reconnects, "bot"), uint64(1))
+	gobot.Assert(t, m.Value(CommandsExecuted, "bot"), uint64(1))
+	gobot.Assert(t, m.DeviceValue(EventsPublished, "bot", "sensor"), uint64(2))
+	gobot.Assert(t, m.DeviceValue(DeviceErrors, "bot", "sensor"), uint64(1))
+}
+
+func TestEvery(t *testing.T) {
+	robot := gobot.NewRobot("bot")
+	m := New()
+	m.Every(robot, time.Millisecond, func() {})
+	<-time.After(10 * time.Millisecond)
+	gobot.Refute(t, m.Value(WorkIterations, "bot"), uint64(0))
+}
+
+func TestWritePrometheus(t *testing.T) {
+	m := New()
+	m.Add(CommandsExecuted, "bot", 2)
+	m.Add(CommandsExecuted, `say "hi"`, 1)
+
+	buf := &bytes.Buffer{}
+	gobot.Assert(t, m.WritePrometheus(buf), nil)
+	gobot.Assert(t, strings.Contains(buf.String(),
+		"# TYPE gobot_commands_executed_total counter\n"+
+			"gobot_commands_executed_total{robot=\"bot\"} 2\n"+
+			"gobot_commands_executed_total{robot=\"say \\\"hi\\\"\"} 1\n"), true)
+	gobot.Assert(t, strings.Contains(buf.String(),
+		"# TYPE gobot_events_published_total counter\n"), true)
+
+	m.AddDevice(EventsPublished, "bot", "sensor", 3)
+	m.Add(EventsPublished, "bot", 1)
+	buf.Reset()
+	m.WritePrometheus(buf)
+	gobot.Assert(t, strings.Contains(buf.String(),
+		"gobot_events_published_total{robot=\"bot\"} 1\n"+
+			"gobot_events_published_total{robot=\"bot\",device=\"sensor\"} 3\n"), true)
+	gobot.Assert(t, m.Value(EventsPublished, "bot"), uint64(4))
+}
+
+func TestServeHTTP(t *testing.T) {
+	m := New()
+	m.Add(DeviceErrors, "bot", 1)
+	request, _ := http.NewRequest("GET", "/metrics", nil)
+	response := httptest.NewRecorder()
+	m.ServeHTTP(response, request)
+	gobot.Assert(t, response.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	gobot.Assert(t, strings.Contains(response.Body.String(),
+		"gobot_device_errors_total{robot=\"bot\"} 1\n"), true)
+}
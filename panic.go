@@ -0,0 +1,123 @@
+package gobot
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// workRestartDelay is how long PanicRestartWork waits before running the
+// work of a robot again
+var workRestartDelay = 1 * time.Second
+
+// PanicPolicy decides what a Robot does when its work, or a callback
+// subscribed to the events of the robot or its devices and connections,
+// panics
+type PanicPolicy int
+
+const (
+	// PanicCrash lets the panic crash the program
+	PanicCrash PanicPolicy = iota
+	// PanicRestartWork recovers the panic, publishes it on
+	// "panic_recovered" and runs the work again a second later if it was
+	// the work which panicked
+	PanicRestartWork
+	// PanicHaltRobot recovers the panic, publishes it on "panic_recovered"
+	// and stops the robot
+	PanicHaltRobot
+)
+
+// PanicRecovered is published on a Robot's "panic_recovered" event when a
+// panic has been recovered
+type PanicRecovered struct {
+	Robot string
+	Value interface{}
+	Stack string
+}
+
+// recoverPanics makes the panics of the callbacks of e be recovered and
+// handed to f
+func (e *Event) recoverPanics(f func(value interface{}, stack []byte)) {
+	e.panicMutex.Lock()
+	defer e.panicMutex.Unlock()
+	e.onPanic = f
+}
+
+// run executes f with data, handing a panic of f to the handler set with
+// recoverPanics
+func (e *Event) run(f func(interface{}), data interface{}) {
+	e.panicMutex.RLock()
+	handler := e.onPanic
+	e.panicMutex.RUnlock()
+	if handler != nil {
+		defer func() {
+			if value := recover(); value != nil {
+				handler(value, debug.Stack())
+			}
+		}()
+	}
+	f(data)
+}
+
+// recoverPanics makes the panics of the callbacks of the events of the
+// robot and its devices and connections be handled by the PanicPolicy
+func (r *Robot) recoverPanics() {
+	handler := func(value interface{}, stack []byte) {
+		r.panicked(value, stack, false)
+	}
+	eventers := []Eventer{r.Eventer}
+	for _, connection := range *r.Connections() {
+		if eventer, ok := connection.(Eventer); ok {
+			eventers = append(eventers, eventer)
+		}
+	}
+	for _, device := range *r.Devices() {
+		if eventer, ok := device.(Eventer); ok {
+			eventers = append(eventers, eventer)
+		}
+	}
+	for _, eventer := range eventers {
+		for _, event := range eventer.Events() {
+			event.recoverPanics(handler)
+		}
+	}
+}
+
+// runWork runs the work of the robot, recovering a panic unless the
+// PanicPolicy is PanicCrash
+func (r *Robot) runWork() {
+	if r.PanicPolicy != PanicCrash {
+		defer func() {
+			if value := recover(); value != nil {
+				r.panicked(value, debug.Stack(), true)
+			}
+		}()
+	}
+	r.Work()
+}
+
+// panicked publishes a recovered panic and applies the PanicPolicy
+func (r *Robot) panicked(value interface{}, stack []byte, work bool) {
+	Log(ErrorLevel, "Recovered panic", Fields{"robot": r.Name, "panic": fmt.Sprint(value)})
+	Publish(r.Event("panic_recovered"), PanicRecovered{
+		Robot: r.Name,
+		Value: value,
+		Stack: string(stack),
+	})
+	switch r.PanicPolicy {
+	case PanicRestartWork:
+		if work {
+			stopped := r.Stopped()
+			go func() {
+				select {
+				case <-stopped:
+				case <-clock().NewTimer(workRestartDelay).C():
+					Log(InfoLevel, "Restarting work", Fields{"robot": r.Name})
+					r.runWork()
+				}
+			}()
+		}
+	case PanicHaltRobot:
+		go r.Stop()
+	}
+}
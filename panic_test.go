@@ -0,0 +1,70 @@
+package gobot
+
+import (
+	"log"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRobotPanicRestartWork(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	workRestartDelay = 1 * time.Millisecond
+	defer func() { workRestartDelay = 1 * time.Second }()
+
+	var runs int32
+	r := NewRobot("Robot1", func() {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			panic("sensor unplugged")
+		}
+	})
+	r.PanicPolicy = PanicRestartWork
+	recovered := make(chan PanicRecovered, 1)
+	On(r.Event("panic_recovered"), func(data interface{}) {
+		recovered <- data.(PanicRecovered)
+	})
+
+	Assert(t, len(r.Start()), 0)
+	select {
+	case p := <-recovered:
+		Assert(t, p.Robot, "Robot1")
+		Assert(t, p.Value, "sensor unplugged")
+		Assert(t, strings.Contains(p.Stack, "runWork"), true)
+	case <-time.After(10 * time.Millisecond):
+		t.Error("panic_recovered was not published")
+	}
+	<-time.After(10 * time.Millisecond)
+	Assert(t, atomic.LoadInt32(&runs), int32(2))
+	r.Stop()
+}
+
+func TestRobotPanicHaltRobot(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	driver := newTestDriver(newTestAdaptor("Connection1", "/dev/null"), "Device1", "0")
+	r := NewRobot("Robot1",
+		[]Connection{driver.connection},
+		[]Device{driver},
+		func() {
+			On(driver.Event("DriverCommand"), func(data interface{}) {
+				panic("callback failed")
+			})
+		},
+	)
+	r.PanicPolicy = PanicHaltRobot
+
+	Assert(t, len(r.Start()), 0)
+	Publish(driver.Event("DriverCommand"), "DriverCommand")
+	select {
+	case <-r.Stopped():
+	case <-time.After(10 * time.Millisecond):
+		t.Error("robot was not halted")
+	}
+}
+
+func TestRobotPanicCrashDoesNotRecover(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := NewRobot("Robot1")
+	Assert(t, len(r.Start()), 0)
+	Assert(t, r.Event("panic_recovered").onPanic == nil, true)
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/platforms/gpio"
@@ -19,13 +20,17 @@ import (
 var _ gobot.Adaptor = (*BeagleboneAdaptor)(nil)
 
 var _ gpio.DigitalReader = (*BeagleboneAdaptor)(nil)
+var _ gpio.DigitalWatcher = (*BeagleboneAdaptor)(nil)
 var _ gpio.DigitalWriter = (*BeagleboneAdaptor)(nil)
+var _ gpio.PulseTrainReader = (*BeagleboneAdaptor)(nil)
 var _ gpio.AnalogReader = (*BeagleboneAdaptor)(nil)
 var _ gpio.PwmWriter = (*BeagleboneAdaptor)(nil)
 var _ gpio.ServoWriter = (*BeagleboneAdaptor)(nil)
 
 var _ i2c.I2c = (*BeagleboneAdaptor)(nil)
 
+var _ gobot.DryRunner = (*BeagleboneAdaptor)(nil)
+
 var slots = "/sys/devices/bone_capemgr.*"
 var ocp = "/sys/devices/ocp.*"
 var usrLed = "/sys/devices/ocp.3/gpio-leds.8/leds/beaglebone:green:"
@@ -133,6 +138,7 @@ type BeagleboneAdaptor struct {
 	ocp         string
 	helper      string
 	slots       string
+	gobot.DryRunner
 }
 
 // NewBeagleboneAdaptor returns a new BeagleboneAdaptor with specified name
@@ -141,6 +147,7 @@ func NewBeagleboneAdaptor(name string) *BeagleboneAdaptor {
 		name:        name,
 		digitalPins: make([]sysfs.DigitalPin, 120),
 		pwmPins:     make(map[string]*pwmPin),
+		DryRunner:   gobot.NewDryRunner(),
 	}
 
 	g, _ := glob(ocp)
@@ -156,6 +163,9 @@ func (b *BeagleboneAdaptor) Name() string { return b.name }
 
 // Connect initializes the pwm and analog dts.
 func (b *BeagleboneAdaptor) Connect() (errs []error) {
+	if b.DryRunning() {
+		return
+	}
 	if err := ensureSlot(b.slots, "cape-bone-iio"); err != nil {
 		return []error{err}
 	}
@@ -175,6 +185,9 @@ func (b *BeagleboneAdaptor) Connect() (errs []error) {
 
 // Finalize releases all i2c devices and exported analog, digital, pwm pins.
 func (b *BeagleboneAdaptor) Finalize() (errs []error) {
+	if b.DryRunning() {
+		return
+	}
 	for _, pin := range b.pwmPins {
 		if pin != nil {
 			if err := pin.release(); err != nil {
@@ -199,11 +212,17 @@ func (b *BeagleboneAdaptor) Finalize() (errs []error) {
 
 // PwmWrite writes the 0-254 value to the specified pin
 func (b *BeagleboneAdaptor) PwmWrite(pin string, val byte) (err error) {
+	if b.Record("PwmWrite", pin, val) {
+		return
+	}
 	return b.pwmWrite(pin, val)
 }
 
 // ServoWrite writes the 0-180 degree val to the specified pin.
 func (b *BeagleboneAdaptor) ServoWrite(pin string, val byte) (err error) {
+	if b.Record("ServoWrite", pin, val) {
+		return
+	}
 	i, err := b.pwmPin(pin)
 	if err != nil {
 		return err
@@ -215,6 +234,9 @@ func (b *BeagleboneAdaptor) ServoWrite(pin string, val byte) (err error) {
 
 // DigitalRead returns a digital value from specified pin
 func (b *BeagleboneAdaptor) DigitalRead(pin string) (val int, err error) {
+	if b.DryRunning() {
+		return
+	}
 	sysfsPin, err := b.digitalPin(pin, sysfs.IN)
 	if err != nil {
 		return
@@ -222,9 +244,50 @@ func (b *BeagleboneAdaptor) DigitalRead(pin string) (val int, err error) {
 	return sysfsPin.Read()
 }
 
+// WatchDigital calls f with the value of pin each time it changes, as
+// signalled by the interrupts of the gpio, until stop is called
+func (b *BeagleboneAdaptor) WatchDigital(pin string, f func(val int)) (stop func(), err error) {
+	if b.DryRunning() {
+		return func() {}, nil
+	}
+	sysfsPin, err := b.digitalPin(pin, sysfs.IN)
+	if err != nil {
+		return
+	}
+	return sysfs.Watch(sysfsPin, f)
+}
+
+// PulseIn waits up to timeout for pin to reach level and returns how long it
+// stays at level, timed by polling the pin, see sysfs.PulseIn
+func (b *BeagleboneAdaptor) PulseIn(pin string, level byte, timeout time.Duration) (d time.Duration, err error) {
+	if b.DryRunning() {
+		return
+	}
+	sysfsPin, err := b.digitalPin(pin, sysfs.IN)
+	if err != nil {
+		return
+	}
+	return sysfs.PulseIn(sysfsPin, int(level), timeout)
+}
+
+// PulseTrain times n consecutive pulses of pin at level, see sysfs.PulseTrain
+func (b *BeagleboneAdaptor) PulseTrain(pin string, level byte, n int, timeout time.Duration) (pulses []time.Duration, err error) {
+	if b.DryRunning() {
+		return make([]time.Duration, n), nil
+	}
+	sysfsPin, err := b.digitalPin(pin, sysfs.IN)
+	if err != nil {
+		return
+	}
+	return sysfs.PulseTrain(sysfsPin, int(level), n, timeout)
+}
+
 // DigitalWrite writes a digital value to specified pin.
 // valid usr pin values are usr0, usr1, usr2 and usr3
 func (b *BeagleboneAdaptor) DigitalWrite(pin string, val byte) (err error) {
+	if b.Record("DigitalWrite", pin, val) {
+		return
+	}
 	if strings.Contains(pin, "usr") {
 		fi, err := sysfs.OpenFile(usrLed+pin+"/brightness", os.O_WRONLY|os.O_APPEND, 0666)
 		defer fi.Close()
@@ -243,6 +306,9 @@ func (b *BeagleboneAdaptor) DigitalWrite(pin string, val byte) (err error) {
 
 // AnalogRead returns an analog value from specified pin
 func (b *BeagleboneAdaptor) AnalogRead(pin string) (val int, err error) {
+	if b.DryRunning() {
+		return
+	}
 	analogPin, err := b.translateAnalogPin(pin)
 	if err != nil {
 		return
@@ -266,18 +332,27 @@ func (b *BeagleboneAdaptor) AnalogRead(pin string) (val int, err error) {
 
 // I2cStart starts a i2c device in specified address on i2c bus /dev/i2c-1
 func (b *BeagleboneAdaptor) I2cStart(address byte) (err error) {
+	if b.Record("I2cStart", address) {
+		return
+	}
 	b.i2cDevice, err = sysfs.NewI2cDevice("/dev/i2c-1", address)
 	return
 }
 
 // I2cWrite writes data to i2c device
 func (b *BeagleboneAdaptor) I2cWrite(data []byte) (err error) {
+	if b.Record("I2cWrite", data) {
+		return
+	}
 	_, err = b.i2cDevice.Write(data)
 	return err
 }
 
 // I2cRead returns size bytes from the i2c device
 func (b *BeagleboneAdaptor) I2cRead(size uint) (data []byte, err error) {
+	if b.DryRunning() {
+		return make([]byte, size), nil
+	}
 	data = make([]byte, size)
 	_, err = b.i2cDevice.Read(data)
 	return
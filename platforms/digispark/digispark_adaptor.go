@@ -14,6 +14,8 @@ var _ gpio.DigitalWriter = (*DigisparkAdaptor)(nil)
 var _ gpio.PwmWriter = (*DigisparkAdaptor)(nil)
 var _ gpio.ServoWriter = (*DigisparkAdaptor)(nil)
 
+var _ gobot.DryRunner = (*DigisparkAdaptor)(nil)
+
 // ErrConnection is the error resulting of a connection error with the digispark
 var ErrConnection = errors.New("connection error")
 
@@ -24,12 +26,14 @@ type DigisparkAdaptor struct {
 	servo      bool
 	pwm        bool
 	connect    func(*DigisparkAdaptor) (err error)
+	gobot.DryRunner
 }
 
 // NewDigisparkAdaptor returns a new DigisparkAdaptor with specified name
 func NewDigisparkAdaptor(name string) *DigisparkAdaptor {
 	return &DigisparkAdaptor{
-		name: name,
+		name:      name,
+		DryRunner: gobot.NewDryRunner(),
 		connect: func(d *DigisparkAdaptor) (err error) {
 			d.littleWire = littleWireConnect()
 			if d.littleWire.(*littleWire).lwHandle == nil {
@@ -45,6 +49,9 @@ func (d *DigisparkAdaptor) Name() string { return d.name }
 
 // Connect starts a connection to the digispark
 func (d *DigisparkAdaptor) Connect() (errs []error) {
+	if d.DryRunning() {
+		return
+	}
 	if err := d.connect(d); err != nil {
 		return []error{err}
 	}
@@ -56,6 +63,9 @@ func (d *DigisparkAdaptor) Finalize() (errs []error) { return }
 
 // DigitalWrite writes a value to the pin. Acceptable values are 1 or 0.
 func (d *DigisparkAdaptor) DigitalWrite(pin string, level byte) (err error) {
+	if d.Record("DigitalWrite", pin, level) {
+		return
+	}
 	p, err := strconv.Atoi(pin)
 
 	if err != nil {
@@ -71,6 +81,9 @@ func (d *DigisparkAdaptor) DigitalWrite(pin string, level byte) (err error) {
 
 // PwmWrite writes the 0-254 value to the specified pin
 func (d *DigisparkAdaptor) PwmWrite(pin string, value byte) (err error) {
+	if d.Record("PwmWrite", pin, value) {
+		return
+	}
 	if d.pwm == false {
 		if err = d.littleWire.pwmInit(); err != nil {
 			return
@@ -87,6 +100,9 @@ func (d *DigisparkAdaptor) PwmWrite(pin string, value byte) (err error) {
 
 // ServoWrite writes the 0-180 degree val to the specified pin.
 func (d *DigisparkAdaptor) ServoWrite(pin string, angle uint8) (err error) {
+	if d.Record("ServoWrite", pin, angle) {
+		return
+	}
 	if d.servo == false {
 		if err = d.littleWire.servoInit(); err != nil {
 			return
@@ -0,0 +1,66 @@
+package firmata
+
+import "time"
+
+// The Timestamp of a reading is the time the message carrying it was read
+// from the board. It holds a monotonic clock reading, so the interval
+// between two samples is Timestamp.Sub of the earlier one.
+
+// AnalogReading is published on the "analog_read" events
+type AnalogReading struct {
+	Channel   byte
+	Pin       byte
+	Value     int
+	Timestamp time.Time
+}
+
+// DigitalReading is published on the "digital_read" events
+type DigitalReading struct {
+	Pin       byte
+	Value     int
+	Timestamp time.Time
+}
+
+// I2cReply is published on the "i2c_reply" event
+type I2cReply struct {
+	Address   byte
+	Register  byte
+	Data      []byte
+	Timestamp time.Time
+}
+
+// PingReading is published on the "ping_read" event, Echo is 0 if no echo
+// was received in time
+type PingReading struct {
+	Pin       byte
+	Echo      time.Duration
+	Timestamp time.Time
+}
+
+// DHTReading is published on the "dht_read" event, Data holds the five
+// bytes sent by the sensor, checksum included
+type DHTReading struct {
+	Pin       byte
+	Data      []byte
+	Timestamp time.Time
+}
+
+// SysexFrame is published on the "unknown_sysex" and "malformed_sysex" events
+type SysexFrame struct {
+	Command byte
+	// Data is the payload between the command and the end of the frame
+	Data []byte
+}
+
+// newSysexFrame returns the SysexFrame of a frame starting with startSysex
+func newSysexFrame(frame []byte) SysexFrame {
+	s := SysexFrame{Data: []byte{}}
+	if len(frame) > 1 {
+		s.Command = frame[1]
+		s.Data = append(s.Data, frame[2:]...)
+	}
+	if len(s.Data) > 0 && s.Data[len(s.Data)-1] == endSysex {
+		s.Data = s.Data[:len(s.Data)-1]
+	}
+	return s
+}
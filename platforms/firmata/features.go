@@ -0,0 +1,80 @@
+package firmata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Features is a set of features of the firmware running on a board
+type Features uint
+
+const (
+	// FeatureAnalog is analog input
+	FeatureAnalog Features = 1 << iota
+	// FeaturePwm is pwm output
+	FeaturePwm
+	// FeatureServo is servo output
+	FeatureServo
+	// FeatureI2c is i2c requests
+	FeatureI2c
+	// FeatureOneWire is OneWire requests
+	FeatureOneWire
+	// FeatureStepper is stepper motor control
+	FeatureStepper
+	// FeatureSerial is software and hardware serial ports
+	FeatureSerial
+	// FeatureScheduler is the task scheduler of ConfigurableFirmata
+	FeatureScheduler
+)
+
+var featureNames = []struct {
+	feature Features
+	name    string
+}{
+	{FeatureAnalog, "analog"},
+	{FeaturePwm, "pwm"},
+	{FeatureServo, "servo"},
+	{FeatureI2c, "i2c"},
+	{FeatureOneWire, "onewire"},
+	{FeatureStepper, "stepper"},
+	{FeatureSerial, "serial"},
+	{FeatureScheduler, "scheduler"},
+}
+
+// featureModes maps the pin modes of a capability response to features
+var featureModes = map[byte]Features{
+	analog:     FeatureAnalog,
+	pwm:        FeaturePwm,
+	servo:      FeatureServo,
+	i2cMode:    FeatureI2c,
+	oneWire:    FeatureOneWire,
+	stepper:    FeatureStepper,
+	serialMode: FeatureSerial,
+}
+
+// Has returns true if every feature in features is in the set
+func (f Features) Has(features Features) bool {
+	return f&features == features
+}
+
+// String returns the names of the features in the set separated by commas
+func (f Features) String() string {
+	names := []string{}
+	for _, n := range featureNames {
+		if f.Has(n.feature) {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// ErrUnsupportedFeature is returned by methods which need a feature the
+// firmware of the board does not have, instead of sending commands the
+// board would ignore
+type ErrUnsupportedFeature struct {
+	Feature Features
+}
+
+func (e ErrUnsupportedFeature) Error() string {
+	return fmt.Sprintf("firmata: board does not support %v", e.Feature)
+}
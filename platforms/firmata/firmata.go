@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"math"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/hybridgroup/gobot"
@@ -18,6 +21,10 @@ const (
 	analog                   byte = 0x02
 	pwm                      byte = 0x03
 	servo                    byte = 0x04
+	i2cMode                  byte = 0x06
+	oneWire                  byte = 0x07
+	stepper                  byte = 0x08
+	serialMode               byte = 0x0A
 	low                      byte = 0
 	high                     byte = 1
 	reportVersion            byte = 0xF9
@@ -39,7 +46,10 @@ const (
 	pinStateResponse         byte = 0x6E
 	analogMappingQuery       byte = 0x69
 	analogMappingResponse    byte = 0x6A
+	extendedAnalog           byte = 0x6F
 	stringData               byte = 0x71
+	dhtRead                  byte = 0x64
+	pingRead                 byte = 0x75
 	i2CRequest               byte = 0x76
 	i2CReply                 byte = 0x77
 	i2CConfig                byte = 0x78
@@ -50,18 +60,70 @@ const (
 	i2CModeStopReading       byte = 0x03
 )
 
+// maxPin is the highest pin the protocol can address, pins are sent as a
+// single 7-bit data byte
+const maxPin = 0x7F
+
 var defaultInitTimeInterval = 1 * time.Second
 
+// minSysexLength is the shortest valid frame, including the start, command
+// and end bytes, of each sysex response which carries data
+var minSysexLength = map[byte]int{
+	capabilityResponse:    3,
+	analogMappingResponse: 3,
+	pinStateResponse:      6,
+	i2CReply:              7,
+	firmwareQuery:         5,
+	pingRead:              13,
+	dhtRead:               14,
+}
+
 type board struct {
 	serial           io.ReadWriteCloser
 	pins             []pin
-	analogPins       []byte
+	analogPins       map[byte]byte
 	firmwareName     string
 	majorVersion     byte
 	minorVersion     byte
+	firmwareMajor    byte
+	firmwareMinor    byte
+	requiredVersion  *Version
 	connected        bool
-	events           map[string]*gobot.Event
+	events           gobot.Eventer
 	initTimeInterval time.Duration
+	analogReporting  map[byte]bool
+	digitalReporting map[byte]bool
+	pinModes         map[byte]byte
+	aliases          map[byte][]string
+	logger           *log.Logger
+	autoReporting    bool
+	handshakeTimeout time.Duration
+	profile          *BoardProfile
+	rawStrings       bool
+	writeInterval    time.Duration
+	lastWrite        time.Time
+	writeWindow      time.Duration
+	pendingPorts     map[byte]bool
+	pinFeatures      Features
+	deadbands        map[byte]int
+	lastAnalog       map[byte]int
+	queueCapacity    int
+	queuePolicy      WriteQueuePolicy
+	queue            chan []byte
+	queueWriter      sync.WaitGroup
+	queueClosed      bool
+	queueErr         error
+	queueMutex       sync.RWMutex
+	mutex            sync.Mutex
+	writeMutex       sync.Mutex
+}
+
+// ReportingState is the analog and digital reporting requested from a board.
+// Analog maps analog channels and Digital maps digital ports to whether
+// reporting is turned on.
+type ReportingState struct {
+	Analog  map[byte]bool
+	Digital map[byte]bool
 }
 
 type pin struct {
@@ -74,18 +136,37 @@ type pin struct {
 // newBoard creates a new board connected in specified serial port.
 // Adds following events: "firmware_query", "capability_query",
 // "analog_mapping_query", "report_version", "i2c_reply",
-// "string_data", "firmware_query"
-func newBoard(sp io.ReadWriteCloser) *board {
+// "i2c_scan_complete", "ping_read", "dht_read", "string_data",
+// "unknown_sysex", "malformed_sysex", "firmware_query", "io_error"
+func newBoard(sp io.ReadWriteCloser, options ...Option) *board {
 	board := &board{
 		majorVersion:     0,
 		minorVersion:     0,
 		serial:           sp,
 		firmwareName:     "",
 		pins:             []pin{},
-		analogPins:       []byte{},
+		analogPins:       make(map[byte]byte),
 		connected:        false,
-		events:           make(map[string]*gobot.Event),
+		events:           gobot.NewEventer(),
 		initTimeInterval: defaultInitTimeInterval,
+		analogReporting:  make(map[byte]bool),
+		digitalReporting: make(map[byte]bool),
+		pinModes:         make(map[byte]byte),
+		aliases:          make(map[byte][]string),
+		pendingPorts:     make(map[byte]bool),
+		deadbands:        make(map[byte]int),
+		lastAnalog:       make(map[byte]int),
+		autoReporting:    true,
+	}
+
+	for _, option := range options {
+		option(board)
+	}
+
+	if board.queueCapacity > 0 {
+		board.queue = make(chan []byte, board.queueCapacity)
+		board.queueWriter.Add(1)
+		go board.writeQueued()
 	}
 
 	for _, s := range []string{
@@ -94,23 +175,32 @@ func newBoard(sp io.ReadWriteCloser) *board {
 		"analog_mapping_query",
 		"report_version",
 		"i2c_reply",
+		"i2c_scan_complete",
+		"ping_read",
+		"dht_read",
 		"string_data",
+		"unknown_sysex",
+		"malformed_sysex",
 		"firmware_query",
+		"io_error",
 	} {
-		board.events[s] = gobot.NewEvent()
+		board.events.AddEvent(s)
 	}
 
 	return board
 }
 
 // connect starts connection to board.
-// Queries report version until connected
+// Queries report version until connected, or until the handshake timeout
+// has passed if one is set.
 func (b *board) connect() (err error) {
 	if b.connected == false {
+		b.logf("Resetting board")
 		if err = b.reset(); err != nil {
 			return err
 		}
 		b.initBoard()
+		start := time.Now()
 		for {
 			if err = b.queryReportVersion(); err != nil {
 				return err
@@ -122,27 +212,159 @@ func (b *board) connect() (err error) {
 			if b.connected == true {
 				break
 			}
+			if b.handshakeTimeout > 0 && time.Since(start) > b.handshakeTimeout {
+				b.logf("Handshake timed out after %v", b.handshakeTimeout)
+				return ErrHandshakeTimeout
+			}
+		}
+		b.logf("Connected to %v", b.versionInfo())
+		if required := b.requiredVersion; required != nil &&
+			!b.versionInfo().AtLeast(required.Major, required.Minor) {
+			return fmt.Errorf("firmata: %v is too old, protocol version %v.%v or newer is required",
+				b.versionInfo(), required.Major, required.Minor)
 		}
 	}
 	return
 }
 
 // initBoard initializes board by listening for "firware_query", "capability_query"
-// and "analog_mapping_query" events
+// and "analog_mapping_query" events. If the board has a profile, the pins are
+// seeded from it and the board is connected as soon as it reports its version.
 func (b *board) initBoard() {
-	gobot.Once(b.events["firmware_query"], func(data interface{}) {
+	if b.profile != nil {
+		b.seedPins(*b.profile)
+		gobot.Once(b.events.Event("report_version"), func(data interface{}) {
+			b.handshakeComplete()
+		})
+		return
+	}
+
+	gobot.Once(b.events.Event("firmware_query"), func(data interface{}) {
+		b.logf("Querying capabilities")
 		b.queryCapabilities()
 	})
 
-	gobot.Once(b.events["capability_query"], func(data interface{}) {
+	gobot.Once(b.events.Event("capability_query"), func(data interface{}) {
+		b.logf("Querying analog mapping")
 		b.queryAnalogMapping()
 	})
 
-	gobot.Once(b.events["analog_mapping_query"], func(data interface{}) {
+	gobot.Once(b.events.Event("analog_mapping_query"), func(data interface{}) {
+		b.handshakeComplete()
+	})
+}
+
+// handshakeComplete turns on the default reporting and marks the board as
+// connected
+func (b *board) handshakeComplete() {
+	if b.autoReporting {
 		b.togglePinReporting(0, high, reportDigital)
 		b.togglePinReporting(1, high, reportDigital)
-		b.connected = true
-	})
+	}
+	b.connected = true
+}
+
+// seedPins fills the pin table from profile instead of the capability and
+// analog mapping responses
+func (b *board) seedPins(profile BoardProfile) {
+	for i := 0; i < profile.Pins; i++ {
+		b.addPin([]byte{})
+	}
+	for channel, pin := range profile.AnalogPins {
+		b.mapAnalogPin(pin, channel)
+	}
+	b.mutex.Lock()
+	b.pinFeatures = profile.Features
+	b.mutex.Unlock()
+}
+
+// addPin appends a pin supporting modes to the pin table
+func (b *board) addPin(modes []byte) {
+	b.mutex.Lock()
+	for _, mode := range modes {
+		b.pinFeatures |= featureModes[mode]
+	}
+	b.mutex.Unlock()
+	b.pins = append(b.pins, pin{modes, output, 0, 127})
+	b.events.AddEvent(fmt.Sprintf("digital_read_%v", len(b.pins)-1))
+	b.events.AddEvent(fmt.Sprintf("pin_%v_state", len(b.pins)-1))
+}
+
+// mapAnalogPin maps analog channel to pin
+func (b *board) mapAnalogPin(pin byte, channel byte) {
+	if int(pin) >= len(b.pins) {
+		return
+	}
+	b.pins[pin].analogChannel = channel
+	if channel == 127 {
+		return
+	}
+	b.mutex.Lock()
+	b.analogPins[channel] = pin
+	b.mutex.Unlock()
+	b.events.AddEvent(fmt.Sprintf("analog_read_%v", channel))
+}
+
+// logf logs to the logger set with WithLogger, or through gobot.Log at
+// gobot.DebugLevel otherwise
+func (b *board) logf(format string, v ...interface{}) {
+	if b.logger != nil {
+		b.logger.Printf(format, v...)
+		return
+	}
+	gobot.Log(gobot.DebugLevel, fmt.Sprintf(format, v...), gobot.Fields{"platform": "firmata"})
+}
+
+// addAlias registers alias as a name of pin. The "digital_read", "analog_read"
+// and "pin_state" events of pin are also published under alias.
+func (b *board) addAlias(alias string, pin byte) {
+	b.mutex.Lock()
+	b.aliases[pin] = append(b.aliases[pin], alias)
+	b.mutex.Unlock()
+	for _, event := range []string{"digital_read_%v", "analog_read_%v", "pin_%v_state"} {
+		b.events.AddEvent(fmt.Sprintf(event, alias))
+	}
+}
+
+// publishAliases publishes data on the event named by format for each alias
+// of pin.
+func (b *board) publishAliases(format string, pin byte, data interface{}) {
+	b.mutex.Lock()
+	aliases := b.aliases[pin]
+	b.mutex.Unlock()
+	for _, alias := range aliases {
+		gobot.Publish(b.events.Event(fmt.Sprintf(format, alias)), data)
+	}
+}
+
+// analogChanged returns false if value is within the deadband of the last
+// value published for channel, otherwise value is recorded as published.
+func (b *board) analogChanged(channel byte, value int) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	deadband, ok := b.deadbands[channel]
+	if !ok {
+		return true
+	}
+	if last, ok := b.lastAnalog[channel]; ok {
+		if diff := value - last; diff <= deadband && diff >= -deadband {
+			return false
+		}
+	}
+	b.lastAnalog[channel] = value
+	return true
+}
+
+// lastAnalogValue returns the last value published for channel, if it has
+// a deadband.
+func (b *board) lastAnalogValue(channel byte) (value int, ok bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, filtered := b.deadbands[channel]; !filtered {
+		return 0, false
+	}
+	value, ok = b.lastAnalog[channel]
+	return
 }
 
 // readAndProcess reads from serial port and parses data.
@@ -161,36 +383,192 @@ func (b *board) reset() error {
 
 // setPinMode writes pin mode bytes for specified pin.
 func (b *board) setPinMode(pin byte, mode byte) error {
+	if err := b.checkPin(pin); err != nil {
+		return err
+	}
 	b.pins[pin].mode = mode
+	b.mutex.Lock()
+	b.pinModes[pin] = mode
+	b.mutex.Unlock()
 	return b.write([]byte{pinMode, pin, mode})
 }
 
+// configuredPinModes returns a copy of the pin modes set with setPinMode.
+func (b *board) configuredPinModes() map[byte]byte {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	modes := make(map[byte]byte)
+	for pin, mode := range b.pinModes {
+		modes[pin] = mode
+	}
+	return modes
+}
+
+// modeNames are the names of the pin modes, see pinStates
+var modeNames = map[byte]string{
+	input:      "input",
+	output:     "output",
+	analog:     "analog",
+	pwm:        "pwm",
+	servo:      "servo",
+	i2cMode:    "i2c",
+	oneWire:    "onewire",
+	stepper:    "stepper",
+	serialMode: "serial",
+}
+
+// pinStates returns the mode and value of each pin whose mode was set with
+// setPinMode, by pin number.
+func (b *board) pinStates() map[string]gobot.PinState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	states := make(map[string]gobot.PinState)
+	for pin, mode := range b.pinModes {
+		name, ok := modeNames[mode]
+		if !ok {
+			name = fmt.Sprintf("0x%02X", mode)
+		}
+		states[strconv.Itoa(int(pin))] = gobot.PinState{Mode: name, Value: b.pins[pin].value}
+	}
+	return states
+}
+
+// restorePinModes sets the pin modes in modes again, e.g. after the board
+// has been reset.
+func (b *board) restorePinModes(modes map[byte]byte) error {
+	for pin := 0; pin < len(b.pins); pin++ {
+		if mode, ok := modes[byte(pin)]; ok {
+			if err := b.setPinMode(byte(pin), mode); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkPin returns an error if pin is not a pin of the board the protocol
+// can address
+func (b *board) checkPin(pin byte) error {
+	if pin > maxPin {
+		return fmt.Errorf("pin %v is out of the range of firmata pins 0-%v", pin, maxPin)
+	}
+	if int(pin) >= len(b.pins) {
+		return fmt.Errorf("pin %v is not a pin of the board", pin)
+	}
+	return nil
+}
+
 // digitalWrite is used to send a digital value to a specified pin.
+// Digital messages address 16 ports of 8 pins, so pins up to 127 can be
+// written. If a write window is set, writes to the same port within the
+// window are sent as a single digital message at the end of the window.
 func (b *board) digitalWrite(pin byte, value byte) error {
-	port := byte(math.Floor(float64(pin) / 8))
-	portValue := byte(0)
+	if err := b.checkPin(pin); err != nil {
+		return err
+	}
+	port := pin / 8
 
+	b.mutex.Lock()
 	b.pins[pin].value = int(value)
+	if b.writeWindow > 0 {
+		if !b.pendingPorts[port] {
+			b.pendingPorts[port] = true
+			time.AfterFunc(b.writeWindow, func() {
+				if err := b.flushPort(port); err != nil {
+					b.logf("Digital write to port %v failed: %v", port, err)
+				}
+			})
+		}
+		b.mutex.Unlock()
+		return nil
+	}
+	b.mutex.Unlock()
+	return b.writePort(port)
+}
 
+// writePort sends the values of the pins of port in a digital message
+func (b *board) writePort(port byte) error {
+	portValue := byte(0)
+
+	b.mutex.Lock()
 	for i := byte(0); i < 8; i++ {
+		// the last port of a board may have fewer than 8 pins
+		if int(8*port+i) >= len(b.pins) {
+			break
+		}
 		if b.pins[8*port+i].value != 0 {
 			portValue = portValue | (1 << i)
 		}
 	}
+	b.mutex.Unlock()
 	return b.write([]byte{digitalMessage | port, portValue & 0x7F, (portValue >> 7) & 0x7F})
 }
 
-// analogWrite writes value to specified pin
+// flushPort sends the coalesced digital writes to port, if there are any
+func (b *board) flushPort(port byte) error {
+	b.mutex.Lock()
+	pending := b.pendingPorts[port]
+	delete(b.pendingPorts, port)
+	b.mutex.Unlock()
+	if !pending {
+		return nil
+	}
+	return b.writePort(port)
+}
+
+// flushDigitalWrites sends the coalesced digital writes to every port
+// without waiting for the write window to end.
+func (b *board) flushDigitalWrites() error {
+	for port := byte(0); port <= 0x0F; port++ {
+		if err := b.flushPort(port); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// analogWrite writes value to specified pin. Analog messages only address
+// pins 0-15, higher pins up to 127 are written with an extended analog
+// sysex.
 func (b *board) analogWrite(pin byte, value byte) error {
+	if err := b.checkPin(pin); err != nil {
+		return err
+	}
 	b.pins[pin].value = int(value)
+	if pin > 0x0F {
+		return b.write([]byte{startSysex, extendedAnalog, pin,
+			value & 0x7F, (value >> 7) & 0x7F, endSysex})
+	}
 	return b.write([]byte{analogMessage | pin, value & 0x7F, (value >> 7) & 0x7F})
 }
 
+// analogPin returns the pin mapped to analog channel.
+func (b *board) analogPin(channel byte) (pin byte, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	pin, ok := b.analogPins[channel]
+	if !ok {
+		return 0, fmt.Errorf("no pin is mapped to analog channel %v", channel)
+	}
+	return pin, nil
+}
+
 // version returns board version following MAYOR.minor convention.
 func (b *board) version() string {
 	return fmt.Sprintf("%v.%v", b.majorVersion, b.minorVersion)
 }
 
+// versionInfo returns the protocol and firmware version reported by the board.
+func (b *board) versionInfo() Version {
+	return Version{
+		Major:         b.majorVersion,
+		Minor:         b.minorVersion,
+		FirmwareName:  b.firmwareName,
+		FirmwareMajor: b.firmwareMajor,
+		FirmwareMinor: b.firmwareMinor,
+	}
+}
+
 // queryFirmware writes bytes to query firmware from board.
 func (b *board) queryFirmware() error {
 	return b.write([]byte{startSysex, firmwareQuery, endSysex})
@@ -216,28 +594,159 @@ func (b *board) queryAnalogMapping() error {
 	return b.write([]byte{startSysex, analogMappingQuery, endSysex})
 }
 
-// togglePinReporting is used to change pin reporting mode.
+// togglePinReporting is used to change pin reporting mode. pin is the analog
+// channel for reportAnalog and the port for reportDigital. The protocol can
+// only report analog channels 0-15 and digital ports 0-15.
 func (b *board) togglePinReporting(pin byte, state byte, mode byte) error {
+	if pin > 0x0F {
+		return fmt.Errorf("reporting is not supported for pin %v", pin)
+	}
+	b.mutex.Lock()
+	switch mode {
+	case reportAnalog:
+		b.analogReporting[pin] = state == high
+	case reportDigital:
+		b.digitalReporting[pin] = state == high
+	}
+	b.mutex.Unlock()
 	return b.write([]byte{mode | pin, state})
 }
 
+// reportingState returns a copy of the reporting requested from the board.
+func (b *board) reportingState() ReportingState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	state := ReportingState{
+		Analog:  make(map[byte]bool),
+		Digital: make(map[byte]bool),
+	}
+	for channel, on := range b.analogReporting {
+		state.Analog[channel] = on
+	}
+	for port, on := range b.digitalReporting {
+		state.Digital[port] = on
+	}
+	return state
+}
+
+// restoreReporting requests the reporting turned on in state again, e.g.
+// after the board has been reset.
+func (b *board) restoreReporting(state ReportingState) error {
+	for _, mode := range []byte{reportAnalog, reportDigital} {
+		pins := state.Analog
+		if mode == reportDigital {
+			pins = state.Digital
+		}
+		for _, pin := range sortedPins(pins) {
+			if err := b.togglePinReporting(pin, high, mode); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortedPins returns the pins turned on in pins in ascending order
+func sortedPins(pins map[byte]bool) []byte {
+	ret := []byte{}
+	for pin := 0; pin < 128; pin++ {
+		if pins[byte(pin)] {
+			ret = append(ret, byte(pin))
+		}
+	}
+	return ret
+}
+
+// features returns the features of the board, detected from the pin modes
+// of the capability response or taken from the board profile. The scheduler
+// is assumed to be present on ConfigurableFirmata.
+func (b *board) features() Features {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	features := b.pinFeatures
+	if strings.HasPrefix(b.firmwareName, "ConfigurableFirmata") {
+		features |= FeatureScheduler
+	}
+	return features
+}
+
+// requireFeature returns ErrUnsupportedFeature if the board lacks feature
+func (b *board) requireFeature(feature Features) error {
+	if !b.features().Has(feature) {
+		return ErrUnsupportedFeature{Feature: feature}
+	}
+	return nil
+}
+
 // i2cReadRequest reads from slaveAddress.
 func (b *board) i2cReadRequest(slaveAddress byte, numBytes uint) error {
 	return b.write([]byte{startSysex, i2CRequest, slaveAddress, (i2CModeRead << 3),
 		byte(numBytes & 0x7F), byte(((numBytes >> 7) & 0x7F)), endSysex})
 }
 
+// i2cReadRegisterRequest reads numBytes from register of slaveAddress.
+func (b *board) i2cReadRegisterRequest(slaveAddress byte, register byte, numBytes uint) error {
+	return b.write([]byte{startSysex, i2CRequest, slaveAddress, (i2CModeRead << 3),
+		register & 0x7F, (register >> 7) & 0x7F,
+		byte(numBytes & 0x7F), byte(((numBytes >> 7) & 0x7F)), endSysex})
+}
+
 // i2cWriteRequest writes to slaveAddress.
 func (b *board) i2cWriteRequest(slaveAddress byte, data []byte) error {
 	ret := []byte{startSysex, i2CRequest, slaveAddress, (i2CModeWrite << 3)}
-	for _, val := range data {
-		ret = append(ret, byte(val&0x7F))
-		ret = append(ret, byte((val>>7)&0x7F))
-	}
+	ret = append(ret, encode7Bit(data)...)
 	ret = append(ret, endSysex)
 	return b.write(ret)
 }
 
+// pingReadRequest asks the board to send a HIGH pulse of pulse microseconds
+// on pin and to time the echo on the same pin, for up to timeout
+// microseconds.
+func (b *board) pingReadRequest(pin byte, pulse uint32, timeout uint32) error {
+	ret := []byte{startSysex, pingRead, pin, high}
+	for _, value := range []uint32{pulse, timeout} {
+		ret = append(ret, encode7Bit([]byte{byte(value >> 24), byte(value >> 16), byte(value >> 8), byte(value)})...)
+	}
+	return b.write(append(ret, endSysex))
+}
+
+// dhtReadRequest asks the board to send the start signal of a DHT sensor of
+// model, 11 or 22, on pin and to read the bytes the sensor answers with.
+func (b *board) dhtReadRequest(pin byte, model byte) error {
+	return b.write([]byte{startSysex, dhtRead, pin, model, endSysex})
+}
+
+// stringWrite sends str to the board as string data. Each character is sent
+// as two 7-bit bytes, unless the board uses raw strings.
+func (b *board) stringWrite(str string) error {
+	data := []byte(str)
+	if !b.rawStrings {
+		data = encode7Bit(data)
+	}
+	ret := append([]byte{startSysex, stringData}, data...)
+	return b.write(append(ret, endSysex))
+}
+
+// encode7Bit splits each byte of data into two 7-bit bytes, least
+// significant bits first
+func encode7Bit(data []byte) []byte {
+	ret := []byte{}
+	for _, val := range data {
+		ret = append(ret, byte(val&0x7F), byte((val>>7)&0x7F))
+	}
+	return ret
+}
+
+// decode7Bit joins pairs of 7-bit bytes back into bytes, a trailing unpaired
+// byte is dropped
+func decode7Bit(data []byte) []byte {
+	ret := []byte{}
+	for i := 0; i+1 < len(data); i += 2 {
+		ret = append(ret, data[i]|data[i+1]<<7)
+	}
+	return ret
+}
+
 // i2xConfig returns i2c configuration.
 func (b *board) i2cConfig(data []byte) error {
 	ret := []byte{startSysex, i2CConfig}
@@ -249,16 +758,108 @@ func (b *board) i2cConfig(data []byte) error {
 	return b.write(ret)
 }
 
-// write is used to send commands to serial port
+// write is used to send commands to serial port. With a write queue the
+// commands are queued and sent by the writer goroutine, and an error of an
+// earlier queued write is returned.
 func (b *board) write(commands []byte) (err error) {
-	_, err = b.serial.Write(commands[:])
+	if b.queue == nil {
+		return b.writeNow(commands)
+	}
+
+	b.queueMutex.RLock()
+	defer b.queueMutex.RUnlock()
+	if b.queueClosed {
+		return ErrWriteQueueClosed
+	}
+	if err = b.takeQueueErr(); err != nil {
+		return
+	}
+	commands = append([]byte{}, commands...)
+	switch b.queuePolicy {
+	case QueueBlock:
+		b.queue <- commands
+	case QueueDrop:
+		select {
+		case b.queue <- commands:
+		default:
+			b.logf("Write queue full, dropped 0x%x", commands)
+		}
+	case QueueError:
+		select {
+		case b.queue <- commands:
+		default:
+			return ErrWriteQueueFull
+		}
+	}
+	return
+}
+
+// writeQueued sends the queued commands until it receives nil, which marks
+// the end of the queue
+func (b *board) writeQueued() {
+	for commands := range b.queue {
+		if commands == nil {
+			break
+		}
+		if err := b.writeNow(commands); err != nil {
+			b.logf("Queued write failed: %v", err)
+			b.mutex.Lock()
+			b.queueErr = err
+			b.mutex.Unlock()
+		}
+	}
+	b.queueWriter.Done()
+}
+
+// takeQueueErr returns and clears the error of the last failed queued write
+func (b *board) takeQueueErr() (err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	err, b.queueErr = b.queueErr, nil
+	return
+}
+
+// drainQueue stops accepting writes and waits until every queued write has
+// been sent. It is a no-op without a write queue.
+func (b *board) drainQueue() error {
+	if b.queue == nil {
+		return nil
+	}
+	b.queueMutex.Lock()
+	if !b.queueClosed {
+		b.queueClosed = true
+		b.queue <- nil
+	}
+	b.queueMutex.Unlock()
+	b.queueWriter.Wait()
+	return b.takeQueueErr()
+}
+
+// writeNow writes commands to the serial port. If a write interval is set,
+// writeNow waits until it has passed since the previous write. A failed write
+// is published on "io_error".
+func (b *board) writeNow(commands []byte) (err error) {
+	b.writeMutex.Lock()
+	defer b.writeMutex.Unlock()
+	if b.writeInterval > 0 {
+		if wait := b.writeInterval - time.Since(b.lastWrite); wait > 0 {
+			<-time.After(wait)
+		}
+		defer func() { b.lastWrite = time.Now() }()
+	}
+	if _, err = b.serial.Write(commands[:]); err != nil {
+		gobot.Publish(b.events.Event("io_error"), err)
+	}
 	return
 }
 
-// read returns buffer reading from serial port (1024 bytes)
+// read returns buffer reading from serial port (1024 bytes). A failed read is
+// published on "io_error".
 func (b *board) read() (buf []byte, err error) {
 	buf = make([]byte, 1024)
-	_, err = b.serial.Read(buf)
+	if _, err = b.serial.Read(buf); err != nil {
+		gobot.Publish(b.events.Event("io_error"), err)
+	}
 	return
 }
 
@@ -267,8 +868,10 @@ func (b *board) read() (buf []byte, err error) {
 // digitalMessageRangeStart.
 // And the following responses: capability, analog mapping, pin state,
 // i2c, firmwareQuery, string data.
-// If neither of those messages is received, then data is treated as "bad_byte"
+// Other sysex commands are published on "unknown_sysex", and responses too
+// short to hold their data on "malformed_sysex".
 func (b *board) process(data []byte) (err error) {
+	received := time.Now()
 	buf := bytes.NewBuffer(data)
 	for {
 		messageType, err := buf.ReadByte()
@@ -284,7 +887,7 @@ func (b *board) process(data []byte) (err error) {
 			if b.minorVersion, err = buf.ReadByte(); err != nil {
 				return err
 			}
-			gobot.Publish(b.events["report_version"], b.version())
+			gobot.Publish(b.events.Event("report_version"), b.version())
 		case analogMessageRangeStart <= messageType &&
 			analogMessageRangeEnd >= messageType:
 
@@ -298,17 +901,26 @@ func (b *board) process(data []byte) (err error) {
 			}
 
 			value := uint(leastSignificantByte) | uint(mostSignificantByte)<<7
-			pin := (messageType & 0x0F)
-
-			b.pins[b.analogPins[pin]].value = int(value)
-			gobot.Publish(b.events[fmt.Sprintf("analog_read_%v", pin)],
-				[]byte{
-					byte(value >> 24),
-					byte(value >> 16),
-					byte(value >> 8),
-					byte(value & 0xff),
-				},
-			)
+			channel := (messageType & 0x0F)
+
+			// analog messages can arrive before the analog mapping is known,
+			// e.g. when reconnecting to a board which is still reporting
+			pin, err := b.analogPin(channel)
+			if err != nil {
+				continue
+			}
+			b.pins[pin].value = int(value)
+			if !b.analogChanged(channel, int(value)) {
+				continue
+			}
+			reading := AnalogReading{
+				Channel:   channel,
+				Pin:       pin,
+				Value:     int(value),
+				Timestamp: received,
+			}
+			gobot.Publish(b.events.Event(fmt.Sprintf("analog_read_%v", channel)), reading)
+			b.publishAliases("analog_read_%v", pin, reading)
 		case digitalMessageRangeStart <= messageType &&
 			digitalMessageRangeEnd >= messageType:
 
@@ -325,11 +937,20 @@ func (b *board) process(data []byte) (err error) {
 
 			for i := 0; i < 8; i++ {
 				pinNumber := (8*byte(port) + byte(i))
-				pin := b.pins[pinNumber]
+				// digital messages can arrive before the capabilities are known
+				if int(pinNumber) >= len(b.pins) {
+					break
+				}
+				pin := &b.pins[pinNumber]
 				if byte(pin.mode) == input {
 					pin.value = int((portValue >> (byte(i) & 0x07)) & 0x01)
-					gobot.Publish(b.events[fmt.Sprintf("digital_read_%v", pinNumber)],
-						[]byte{byte(pin.value & 0xff)})
+					reading := DigitalReading{
+						Pin:       pinNumber,
+						Value:     pin.value,
+						Timestamp: received,
+					}
+					gobot.Publish(b.events.Event(fmt.Sprintf("digital_read_%v", pinNumber)), reading)
+					b.publishAliases("digital_read_%v", pinNumber, reading)
 				}
 			}
 		case startSysex == messageType:
@@ -345,23 +966,27 @@ func (b *board) process(data []byte) (err error) {
 					break
 				}
 			}
+			if len(currentBuffer) < 2 || len(currentBuffer) < minSysexLength[currentBuffer[1]] ||
+				(currentBuffer[1] == pinStateResponse && int(currentBuffer[2]) >= len(b.pins)) {
+				b.logf("Malformed sysex: 0x%x", currentBuffer)
+				gobot.Publish(b.events.Event("malformed_sysex"), newSysexFrame(currentBuffer))
+				continue
+			}
 			command := currentBuffer[1]
 			switch command {
 			case capabilityResponse:
 				supportedModes := 0
 				n := 0
 
-				for _, val := range currentBuffer[2:(len(currentBuffer) - 5)] {
+				for _, val := range currentBuffer[2:(len(currentBuffer) - 1)] {
 					if val == 127 {
 						modes := []byte{}
-						for _, mode := range []byte{input, output, analog, pwm, servo} {
+						for mode := byte(0); mode < 32; mode++ {
 							if (supportedModes & (1 << mode)) != 0 {
 								modes = append(modes, mode)
 							}
 						}
-						b.pins = append(b.pins, pin{modes, output, 0, 0})
-						b.events[fmt.Sprintf("digital_read_%v", len(b.pins)-1)] = gobot.NewEvent()
-						b.events[fmt.Sprintf("pin_%v_state", len(b.pins)-1)] = gobot.NewEvent()
+						b.addPin(modes)
 						supportedModes = 0
 						n = 0
 						continue
@@ -372,22 +997,13 @@ func (b *board) process(data []byte) (err error) {
 					}
 					n ^= 1
 				}
-				gobot.Publish(b.events["capability_query"], nil)
+				gobot.Publish(b.events.Event("capability_query"), nil)
 			case analogMappingResponse:
-				pinIndex := byte(0)
-
-				for _, val := range currentBuffer[2 : len(b.pins)-1] {
-
-					b.pins[pinIndex].analogChannel = val
-
-					if val != 127 {
-						b.analogPins = append(b.analogPins, pinIndex)
-					}
-					b.events[fmt.Sprintf("analog_read_%v", pinIndex)] = gobot.NewEvent()
-					pinIndex++
+				for pinIndex, val := range currentBuffer[2:(len(currentBuffer) - 1)] {
+					b.mapAnalogPin(byte(pinIndex), val)
 				}
 
-				gobot.Publish(b.events["analog_mapping_query"], nil)
+				gobot.Publish(b.events.Event("analog_mapping_query"), nil)
 			case pinStateResponse:
 				pin := b.pins[currentBuffer[2]]
 				pin.mode = currentBuffer[3]
@@ -400,32 +1016,35 @@ func (b *board) process(data []byte) (err error) {
 					pin.value = int(uint(pin.value) | uint(currentBuffer[6])<<14)
 				}
 
-				gobot.Publish(b.events[fmt.Sprintf("pin_%v_state", currentBuffer[2])],
-					map[string]int{
-						"pin":   int(currentBuffer[2]),
-						"mode":  int(pin.mode),
-						"value": int(pin.value),
-					},
-				)
+				state := map[string]int{
+					"pin":   int(currentBuffer[2]),
+					"mode":  int(pin.mode),
+					"value": int(pin.value),
+				}
+				gobot.Publish(b.events.Event(fmt.Sprintf("pin_%v_state", currentBuffer[2])), state)
+				b.publishAliases("pin_%v_state", currentBuffer[2], state)
 			case i2CReply:
-				i2cReply := map[string][]byte{
-					"slave_address": []byte{byte(currentBuffer[2]) | byte(currentBuffer[3])<<7},
-					"register":      []byte{byte(currentBuffer[4]) | byte(currentBuffer[5])<<7},
-					"data":          []byte{byte(currentBuffer[6]) | byte(currentBuffer[7])<<7},
+				i2cReply := I2cReply{
+					Address:   byte(currentBuffer[2]) | byte(currentBuffer[3])<<7,
+					Register:  byte(currentBuffer[4]) | byte(currentBuffer[5])<<7,
+					Data:      []byte{},
+					Timestamp: received,
 				}
-				for i := 8; i < len(currentBuffer); i = i + 2 {
+				// replies to zero length reads carry no data
+				for i := 6; i < len(currentBuffer); i = i + 2 {
 					if currentBuffer[i] == byte(0xF7) {
 						break
 					}
 					if i+2 > len(currentBuffer) {
 						break
 					}
-					i2cReply["data"] = append(i2cReply["data"],
+					i2cReply.Data = append(i2cReply.Data,
 						byte(currentBuffer[i])|byte(currentBuffer[i+1])<<7,
 					)
 				}
-				gobot.Publish(b.events["i2c_reply"], i2cReply)
+				gobot.Publish(b.events.Event("i2c_reply"), i2cReply)
 			case firmwareQuery:
+				b.firmwareMajor, b.firmwareMinor = currentBuffer[2], currentBuffer[3]
 				name := []byte{}
 				for _, val := range currentBuffer[4:(len(currentBuffer) - 1)] {
 					if val != 0 {
@@ -433,12 +1052,33 @@ func (b *board) process(data []byte) (err error) {
 					}
 				}
 				b.firmwareName = string(name[:])
-				gobot.Publish(b.events["firmware_query"], b.firmwareName)
+				gobot.Publish(b.events.Event("firmware_query"), b.firmwareName)
+			case pingRead:
+				echo := decode7Bit(currentBuffer[4:12])
+				micros := uint32(echo[0])<<24 | uint32(echo[1])<<16 | uint32(echo[2])<<8 | uint32(echo[3])
+				gobot.Publish(b.events.Event("ping_read"), PingReading{
+					Pin:       currentBuffer[2] | currentBuffer[3]<<7,
+					Echo:      time.Duration(micros) * time.Microsecond,
+					Timestamp: received,
+				})
+			case dhtRead:
+				gobot.Publish(b.events.Event("dht_read"), DHTReading{
+					Pin:       currentBuffer[2],
+					Data:      decode7Bit(currentBuffer[3:13]),
+					Timestamp: received,
+				})
 			case stringData:
 				str := currentBuffer[2:len(currentBuffer)]
-				gobot.Publish(b.events["string_data"], string(str[:len(str)]))
+				if len(str) > 0 && str[len(str)-1] == endSysex {
+					str = str[:len(str)-1]
+				}
+				if !b.rawStrings {
+					str = decode7Bit(str)
+				}
+				gobot.Publish(b.events.Event("string_data"), string(str))
 			default:
-				return fmt.Errorf("bad byte: 0x%x", command)
+				b.logf("Unknown sysex command: 0x%x", command)
+				gobot.Publish(b.events.Event("unknown_sysex"), newSysexFrame(currentBuffer))
 			}
 		}
 	}
@@ -20,9 +20,15 @@ var _ gpio.DigitalWriter = (*FirmataAdaptor)(nil)
 var _ gpio.AnalogReader = (*FirmataAdaptor)(nil)
 var _ gpio.PwmWriter = (*FirmataAdaptor)(nil)
 var _ gpio.ServoWriter = (*FirmataAdaptor)(nil)
+var _ gpio.PingReader = (*FirmataAdaptor)(nil)
+var _ gpio.DHTReader = (*FirmataAdaptor)(nil)
 
 var _ i2c.I2c = (*FirmataAdaptor)(nil)
 
+var _ gobot.DryRunner = (*FirmataAdaptor)(nil)
+var _ gobot.PinStater = (*FirmataAdaptor)(nil)
+var _ gobot.IOTracer = (*FirmataAdaptor)(nil)
+
 // FirmataAdaptor is the Gobot Adaptor for Firmata based boards
 type FirmataAdaptor struct {
 	name       string
@@ -30,13 +36,20 @@ type FirmataAdaptor struct {
 	board      *board
 	i2cAddress byte
 	conn       io.ReadWriteCloser
+	aliases    map[string]int
+	options    []Option
+	retry      *gobot.RetryPolicy
 	connect    func(string) (io.ReadWriteCloser, error)
+	gobot.DryRunner
+	gobot.IOTracer
 }
 
 // NewFirmataAdaptor returns a new FirmataAdaptor with specified name and optionally accepts:
 //
 //	string: port the FirmataAdaptor uses to connect to a serial port with a baude rate of 57600
 //	io.ReadWriteCloser: connection the FirmataAdaptor uses to communication with the hardware
+//	Option: configures the connection, e.g. WithRetryInterval or WithHandshakeTimeout
+//	gobot.RetryPolicy: retries opening the serial port, e.g. while a board is plugged in
 //
 // If an io.ReadWriteCloser is not supplied, the FirmataAdaptor will open a connection
 // to a serial port with a baude rate of 57600. If an io.ReadWriteCloser
@@ -44,12 +57,15 @@ type FirmataAdaptor struct {
 // string port as a label to be displayed in the log and api.
 func NewFirmataAdaptor(name string, args ...interface{}) *FirmataAdaptor {
 	f := &FirmataAdaptor{
-		name: name,
-		port: "",
-		conn: nil,
+		name:    name,
+		port:    "",
+		conn:    nil,
+		aliases: make(map[string]int),
 		connect: func(port string) (io.ReadWriteCloser, error) {
 			return serial.OpenPort(&serial.Config{Name: port, Baud: 57600})
 		},
+		DryRunner: gobot.NewDryRunner(),
+		IOTracer:  gobot.NewIOTracer(),
 	}
 
 	for _, arg := range args {
@@ -58,29 +74,144 @@ func NewFirmataAdaptor(name string, args ...interface{}) *FirmataAdaptor {
 			f.port = arg.(string)
 		case io.ReadWriteCloser:
 			f.conn = arg.(io.ReadWriteCloser)
+		case Option:
+			f.options = append(f.options, arg.(Option))
+		case gobot.RetryPolicy:
+			policy := arg.(gobot.RetryPolicy)
+			f.retry = &policy
 		}
 	}
 
 	return f
 }
 
-// Connect starts a connection to the board.
+// Connect starts a connection to the board. When reconnecting, the reporting
+// requested from the previous connection is requested again.
 func (f *FirmataAdaptor) Connect() (errs []error) {
+	if f.DryRunning() {
+		return
+	}
 	if f.conn == nil {
+		if err := f.openPort(); err != nil {
+			return []error{err}
+		}
+	}
+	reporting := ReportingState{}
+	if f.board != nil {
+		reporting = f.board.reportingState()
+		f.board.drainQueue()
+	}
+	f.board = f.newBoard()
+	if err := f.board.connect(); err != nil {
+		return []error{err}
+	}
+	if err := f.board.restoreReporting(reporting); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// openPort opens the serial port, retrying as described by the
+// gobot.RetryPolicy given to NewFirmataAdaptor.
+func (f *FirmataAdaptor) openPort() error {
+	open := func() error {
 		sp, err := f.connect(f.Port())
 		if err != nil {
-			return []error{err}
+			return err
 		}
 		f.conn = sp
+		return nil
 	}
-	f.board = newBoard(f.conn)
-	f.board.connect()
-	return
+	if f.retry == nil {
+		return open()
+	}
+	return gobot.RetryUntil(nil, *f.retry, open)
+}
+
+// Reset sends a system reset to the board and then requests the analog and
+// digital reporting which was turned on before the reset again.
+func (f *FirmataAdaptor) Reset() (err error) {
+	if f.Record("Reset") {
+		return
+	}
+	reporting := f.board.reportingState()
+	if err = f.board.reset(); err != nil {
+		return
+	}
+	return f.board.restoreReporting(reporting)
+}
+
+// Reboot resets the board and discards everything known about it, then runs
+// the handshake again and restores the pin modes and the analog and digital
+// reporting which were configured before the reboot.
+func (f *FirmataAdaptor) Reboot() (err error) {
+	if f.Record("Reboot") {
+		return
+	}
+	modes := f.board.configuredPinModes()
+	reporting := f.board.reportingState()
+	if err = f.board.drainQueue(); err != nil {
+		return
+	}
+	f.board = f.newBoard()
+	if err = f.board.connect(); err != nil {
+		return
+	}
+	if err = f.board.restorePinModes(modes); err != nil {
+		return
+	}
+	return f.board.restoreReporting(reporting)
+}
+
+// Version returns the protocol and firmware version reported by the board
+func (f *FirmataAdaptor) Version() Version {
+	if f.board == nil {
+		return Version{}
+	}
+	return f.board.versionInfo()
+}
+
+// ReportingState returns the analog and digital reporting requested from
+// the board.
+func (f *FirmataAdaptor) ReportingState() ReportingState {
+	if f.board == nil {
+		return ReportingState{Analog: map[byte]bool{}, Digital: map[byte]bool{}}
+	}
+	return f.board.reportingState()
+}
+
+// PinStates returns the mode and the value last read or written of each pin
+// whose mode has been set, by pin number.
+func (f *FirmataAdaptor) PinStates() map[string]gobot.PinState {
+	if f.board == nil {
+		return map[string]gobot.PinState{}
+	}
+	return f.board.pinStates()
 }
 
-// Disconnect closes the io connection to the board
+// newBoard returns a new board on the adaptors connection, configured with
+// the adaptors options and with its pin aliases registered.
+func (f *FirmataAdaptor) newBoard() *board {
+	b := newBoard(f.conn, f.options...)
+	for alias, pin := range f.aliases {
+		b.addAlias(alias, byte(pin))
+	}
+	return b
+}
+
+// Disconnect sends pending digital and queued writes and closes the io
+// connection to the board
 func (f *FirmataAdaptor) Disconnect() (err error) {
+	if f.DryRunning() {
+		return
+	}
 	if f.board != nil {
+		if err = f.board.flushDigitalWrites(); err != nil {
+			return
+		}
+		if err = f.board.drainQueue(); err != nil {
+			return
+		}
 		return f.board.serial.Close()
 	}
 	return errors.New("no board connected")
@@ -102,7 +233,11 @@ func (f *FirmataAdaptor) Name() string { return f.name }
 
 // ServoWrite writes the 0-180 degree angle to the specified pin.
 func (f *FirmataAdaptor) ServoWrite(pin string, angle byte) (err error) {
-	p, err := strconv.Atoi(pin)
+	if f.Record("ServoWrite", pin, angle) {
+		return
+	}
+	defer f.StartIO("ServoWrite")(&err)
+	p, err := f.pinNumber(pin)
 	if err != nil {
 		return err
 	}
@@ -117,7 +252,11 @@ func (f *FirmataAdaptor) ServoWrite(pin string, angle byte) (err error) {
 
 // PwmWrite writes the 0-254 value to the specified pin
 func (f *FirmataAdaptor) PwmWrite(pin string, level byte) (err error) {
-	p, err := strconv.Atoi(pin)
+	if f.Record("PwmWrite", pin, level) {
+		return
+	}
+	defer f.StartIO("PwmWrite")(&err)
+	p, err := f.pinNumber(pin)
 	if err != nil {
 		return err
 	}
@@ -132,7 +271,11 @@ func (f *FirmataAdaptor) PwmWrite(pin string, level byte) (err error) {
 
 // DigitalWrite writes a value to the pin. Acceptable values are 1 or 0.
 func (f *FirmataAdaptor) DigitalWrite(pin string, level byte) (err error) {
-	p, err := strconv.Atoi(pin)
+	if f.Record("DigitalWrite", pin, level) {
+		return
+	}
+	defer f.StartIO("DigitalWrite")(&err)
+	p, err := f.pinNumber(pin)
 	if err != nil {
 		return
 	}
@@ -149,24 +292,28 @@ func (f *FirmataAdaptor) DigitalWrite(pin string, level byte) (err error) {
 // DigitalRead retrieves digital value from specified pin.
 // Returns -1 if the response from the board has timed out
 func (f *FirmataAdaptor) DigitalRead(pin string) (val int, err error) {
+	if f.DryRunning() {
+		return
+	}
+	defer f.StartIO("DigitalRead")(&err)
 	ret := make(chan int)
 
-	p, err := strconv.Atoi(pin)
+	p, err := f.pinNumber(pin)
 	if err != nil {
 		return
 	}
 	if err = f.board.setPinMode(byte(p), input); err != nil {
 		return
 	}
-	if err = f.board.togglePinReporting(byte(p), high, reportDigital); err != nil {
+	if err = f.board.togglePinReporting(byte(p/8), high, reportDigital); err != nil {
 		return
 	}
 	if err = f.board.readAndProcess(); err != nil {
 		return
 	}
 
-	gobot.Once(f.board.events[fmt.Sprintf("digital_read_%v", pin)], func(data interface{}) {
-		ret <- int(data.([]byte)[0])
+	gobot.Once(f.board.events.Event(fmt.Sprintf("digital_read_%v", pin)), func(data interface{}) {
+		ret <- data.(DigitalReading).Value
 	})
 
 	select {
@@ -177,18 +324,28 @@ func (f *FirmataAdaptor) DigitalRead(pin string) (val int, err error) {
 	return -1, nil
 }
 
-// AnalogRead retrieves value from analog pin.
+// AnalogRead retrieves value from analog pin. pin is the analog channel,
+// e.g. "0" for A0, which is translated to a digital pin with the analog
+// mapping reported by the board. Only the channels 0-15 can be reported by
+// the protocol. If the channel has a deadband, readings within it return the
+// last published value.
 // Returns -1 if the response from the board has timed out
 func (f *FirmataAdaptor) AnalogRead(pin string) (val int, err error) {
+	if f.DryRunning() {
+		return
+	}
+	defer f.StartIO("AnalogRead")(&err)
 	ret := make(chan int)
 
-	p, err := strconv.Atoi(pin)
+	p, err := f.analogPinNumber(pin)
 	if err != nil {
 		return
 	}
-	// NOTE pins are numbered A0-A5, which translate to digital pins 14-19
-	p = f.digitalPin(p)
-	if err = f.board.setPinMode(byte(p), analog); err != nil {
+	digitalPin, err := f.board.analogPin(byte(p))
+	if err != nil {
+		return
+	}
+	if err = f.board.setPinMode(digitalPin, analog); err != nil {
 		return
 	}
 
@@ -200,9 +357,8 @@ func (f *FirmataAdaptor) AnalogRead(pin string) (val int, err error) {
 		return
 	}
 
-	gobot.Once(f.board.events[fmt.Sprintf("analog_read_%v", pin)], func(data interface{}) {
-		b := data.([]byte)
-		ret <- int(uint(b[0])<<24 | uint(b[1])<<16 | uint(b[2])<<8 | uint(b[3]))
+	gobot.Once(f.board.events.Event(fmt.Sprintf("analog_read_%v", pin)), func(data interface{}) {
+		ret <- data.(AnalogReading).Value
 	})
 
 	select {
@@ -210,16 +366,172 @@ func (f *FirmataAdaptor) AnalogRead(pin string) (val int, err error) {
 		return data, nil
 	case <-time.After(10 * time.Millisecond):
 	}
+	if value, ok := f.board.lastAnalogValue(byte(p)); ok {
+		return value, nil
+	}
 	return -1, nil
 }
 
-// digitalPin converts pin number to digital mapping
-func (f *FirmataAdaptor) digitalPin(pin int) int {
-	return pin + 14
+// AddPinAlias registers alias as a name for the digital pin number pin. The
+// alias can be used in place of the pin number by all pin methods, and the
+// "digital_read", "analog_read" and "pin_state" events of the pin are also
+// published under the alias, e.g. "digital_read_led".
+func (f *FirmataAdaptor) AddPinAlias(alias string, pin int) {
+	f.aliases[alias] = pin
+	if f.board != nil {
+		f.board.addAlias(alias, byte(pin))
+	}
+}
+
+// pinNumber returns the digital pin number of a pin alias or number, one of
+// the pins 0-127 the protocol can address
+func (f *FirmataAdaptor) pinNumber(pin string) (int, error) {
+	p, ok := f.aliases[pin]
+	if !ok {
+		var err error
+		if p, err = strconv.Atoi(pin); err != nil {
+			return 0, err
+		}
+	}
+	if p < 0 || p > maxPin {
+		return 0, fmt.Errorf("pin %v is out of the range of firmata pins 0-%v", pin, maxPin)
+	}
+	return p, nil
+}
+
+// analogPinNumber returns the analog channel of a pin alias or analog pin
+// number, one of the channels 0-15 the protocol can report
+func (f *FirmataAdaptor) analogPinNumber(pin string) (int, error) {
+	p, ok := f.aliases[pin]
+	if ok {
+		if p >= len(f.board.pins) || f.board.pins[p].analogChannel == 127 {
+			return 0, fmt.Errorf("pin %v is not an analog pin", pin)
+		}
+		p = int(f.board.pins[p].analogChannel)
+	} else {
+		var err error
+		if p, err = strconv.Atoi(pin); err != nil {
+			return 0, err
+		}
+	}
+	if p < 0 || p > 0x0F {
+		return 0, fmt.Errorf("analog pin %v is out of the range of reported channels 0-15", pin)
+	}
+	return p, nil
+}
+
+// StringWrite sends str to the board as string data
+func (f *FirmataAdaptor) StringWrite(str string) (err error) {
+	if f.Record("StringWrite", str) {
+		return nil
+	}
+	defer f.StartIO("StringWrite")(&err)
+	return f.board.stringWrite(str)
+}
+
+// Features returns the features of the firmware running on the board
+func (f *FirmataAdaptor) Features() Features {
+	if f.board == nil {
+		return 0
+	}
+	return f.board.features()
+}
+
+// PingRead sends a 10µs trigger pulse and returns how long the echo lasts,
+// timed by the board with the ping sysex of PingFirmata. The trigger and
+// echo of the sensor must be wired to the same pin. Returns 0 if no echo
+// was received within timeout.
+func (f *FirmataAdaptor) PingRead(triggerPin string, echoPin string, timeout time.Duration) (echo time.Duration, err error) {
+	if f.DryRunning() {
+		return
+	}
+	defer f.StartIO("PingRead")(&err)
+	if triggerPin != echoPin {
+		return 0, errors.New("firmata: the ping sysex needs the trigger and echo on the same pin")
+	}
+	p, err := f.pinNumber(triggerPin)
+	if err != nil {
+		return
+	}
+	ret := make(chan interface{}, 1)
+	gobot.Once(f.board.events.Event("ping_read"), func(data interface{}) {
+		if reading := data.(PingReading); int(reading.Pin) == p {
+			ret <- reading.Echo
+		}
+	})
+	if err = f.board.pingReadRequest(byte(p), 10, uint32(timeout/time.Microsecond)); err != nil {
+		return
+	}
+
+	// the board answers once the echo has ended or timed out
+	reply, ok, err := f.awaitReply(ret, timeout+10*time.Millisecond)
+	if err != nil {
+		return
+	} else if !ok {
+		return 0, errors.New("firmata: no ping reply, the firmware may not support the ping sysex")
+	}
+	return reply.(time.Duration), nil
+}
+
+// DHTRead sends the start signal of a DHT sensor of model on pin and returns
+// the five bytes the sensor answers with, read by the board with the DHT
+// sysex.
+func (f *FirmataAdaptor) DHTRead(pin string, model gpio.DHTModel) (data []byte, err error) {
+	if f.DryRunning() {
+		return make([]byte, 5), nil
+	}
+	defer f.StartIO("DHTRead")(&err)
+	p, err := f.pinNumber(pin)
+	if err != nil {
+		return
+	}
+	ret := make(chan interface{}, 1)
+	gobot.Once(f.board.events.Event("dht_read"), func(data interface{}) {
+		if reading := data.(DHTReading); int(reading.Pin) == p {
+			ret <- reading.Data
+		}
+	})
+	if err = f.board.dhtReadRequest(byte(p), byte(model)); err != nil {
+		return
+	}
+
+	// the sensor answers within 25ms of the start signal of a DHT11
+	reply, ok, err := f.awaitReply(ret, 50*time.Millisecond)
+	if err != nil {
+		return
+	} else if !ok {
+		return nil, errors.New("firmata: no DHT reply, the firmware may not support the DHT sysex")
+	}
+	return reply.([]byte), nil
+}
+
+// awaitReply reads and processes the messages of the board until a reply is
+// sent to ret, or timeout expires, in which case ok is false
+func (f *FirmataAdaptor) awaitReply(ret chan interface{}, timeout time.Duration) (reply interface{}, ok bool, err error) {
+	expired := time.After(timeout)
+	for {
+		if err = f.board.readAndProcess(); err != nil {
+			return
+		}
+		select {
+		case reply = <-ret:
+			return reply, true, nil
+		case <-expired:
+			return
+		case <-time.After(time.Millisecond):
+		}
+	}
 }
 
 // I2cStart starts an i2c device at specified address
 func (f *FirmataAdaptor) I2cStart(address byte) (err error) {
+	if f.Record("I2cStart", address) {
+		return
+	}
+	defer f.StartIO("I2cStart")(&err)
+	if err = f.board.requireFeature(FeatureI2c); err != nil {
+		return
+	}
 	f.i2cAddress = address
 	return f.board.i2cConfig([]byte{0})
 }
@@ -227,7 +539,14 @@ func (f *FirmataAdaptor) I2cStart(address byte) (err error) {
 // I2cRead returns size bytes from the i2c device
 // Returns an empty array if the response from the board has timed out
 func (f *FirmataAdaptor) I2cRead(size uint) (data []byte, err error) {
+	if f.DryRunning() {
+		return make([]byte, size), nil
+	}
+	defer f.StartIO("I2cRead")(&err)
 	ret := make(chan []byte)
+	if err = f.board.requireFeature(FeatureI2c); err != nil {
+		return
+	}
 	if err = f.board.i2cReadRequest(f.i2cAddress, size); err != nil {
 		return
 	}
@@ -236,8 +555,8 @@ func (f *FirmataAdaptor) I2cRead(size uint) (data []byte, err error) {
 		return
 	}
 
-	gobot.Once(f.board.events["i2c_reply"], func(data interface{}) {
-		ret <- data.(map[string][]byte)["data"]
+	gobot.Once(f.board.events.Event("i2c_reply"), func(data interface{}) {
+		ret <- data.(I2cReply).Data
 	})
 
 	select {
@@ -248,7 +567,114 @@ func (f *FirmataAdaptor) I2cRead(size uint) (data []byte, err error) {
 	return []byte{}, nil
 }
 
+// I2cReadRegister reads size bytes from register of the i2c device at
+// address. Returns an empty array if the response from the board has timed out
+func (f *FirmataAdaptor) I2cReadRegister(address byte, register byte, size uint) (data []byte, err error) {
+	if f.DryRunning() {
+		return make([]byte, size), nil
+	}
+	defer f.StartIO("I2cReadRegister")(&err)
+	ret := make(chan []byte, 1)
+	if err = f.board.requireFeature(FeatureI2c); err != nil {
+		return
+	}
+	gobot.Once(f.board.events.Event("i2c_reply"), func(data interface{}) {
+		reply := data.(I2cReply)
+		if reply.Address == address && reply.Register == register {
+			select {
+			case ret <- reply.Data:
+			default:
+			}
+		}
+	})
+	if err = f.board.i2cReadRegisterRequest(address, register, size); err != nil {
+		return
+	}
+	if err = f.board.readAndProcess(); err != nil {
+		return
+	}
+
+	select {
+	case data := <-ret:
+		return data, nil
+	case <-time.After(10 * time.Millisecond):
+	}
+	return []byte{}, nil
+}
+
+// I2cWriteRegister writes data to register of the i2c device at address
+func (f *FirmataAdaptor) I2cWriteRegister(address byte, register byte, data []byte) (err error) {
+	if f.Record("I2cWriteRegister", address, register, data) {
+		return nil
+	}
+	defer f.StartIO("I2cWriteRegister")(&err)
+	if err := f.board.requireFeature(FeatureI2c); err != nil {
+		return err
+	}
+	return f.board.i2cWriteRequest(address, append([]byte{register}, data...))
+}
+
+// I2cScan probes the i2c addresses 0x08-0x77 with one byte reads and
+// returns the addresses which answered. StandardFirmata replies to every
+// read, to those of an address without a device with the string "I2C: Too
+// few bytes received" and no data, so the devices are told apart by the data
+// of their reply. The addresses are also published on the
+// "i2c_scan_complete" event.
+func (f *FirmataAdaptor) I2cScan() (addresses []byte, err error) {
+	addresses = []byte{}
+	if f.DryRunning() {
+		return
+	}
+	if err = f.board.requireFeature(FeatureI2c); err != nil {
+		return
+	}
+	if err = f.board.i2cConfig([]byte{0}); err != nil {
+		return
+	}
+	replies := make(chan interface{}, 8)
+	stop, err := gobot.OnUntilStopped(f.board.events.Event("i2c_reply"), func(data interface{}) {
+		select {
+		case replies <- data:
+		default:
+		}
+	})
+	if err != nil {
+		return
+	}
+	defer stop()
+	for address := byte(0x08); address <= 0x77; address++ {
+		if err = f.board.i2cReadRequest(address, 1); err != nil {
+			return
+		}
+		deadline := time.Now().Add(10 * time.Millisecond)
+		for {
+			reply, ok, err := f.awaitReply(replies, time.Until(deadline))
+			if err != nil {
+				return addresses, err
+			} else if !ok {
+				break
+			}
+			// replies to the previous addresses may arrive late
+			if r := reply.(I2cReply); r.Address == address {
+				if len(r.Data) > 0 {
+					addresses = append(addresses, address)
+				}
+				break
+			}
+		}
+	}
+	gobot.Publish(f.board.events.Event("i2c_scan_complete"), addresses)
+	return
+}
+
 // I2cWrite writes data to i2c device
 func (f *FirmataAdaptor) I2cWrite(data []byte) (err error) {
+	if f.Record("I2cWrite", data) {
+		return
+	}
+	defer f.StartIO("I2cWrite")(&err)
+	if err = f.board.requireFeature(FeatureI2c); err != nil {
+		return
+	}
 	return f.board.i2cWriteRequest(f.i2cAddress, data)
 }
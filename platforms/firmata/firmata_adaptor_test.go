@@ -1,13 +1,18 @@
 package firmata
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/firmata/firmatatest"
+	"github.com/hybridgroup/gobot/platforms/gpio"
 )
 
 var connect = func(a *FirmataAdaptor) []error {
@@ -78,6 +83,56 @@ func TestFirmataAdaptorConnect(t *testing.T) {
 	gobot.Assert(t, len(connect(a)), 0)
 }
 
+func TestFirmataAdaptorConnectRetry(t *testing.T) {
+	attempts := 0
+	a := NewFirmataAdaptor("board", "/dev/null", gobot.RetryPolicy{MaxAttempts: 3})
+	a.connect = func(port string) (io.ReadWriteCloser, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("connect error")
+		}
+		return &NullReadWriteCloser{}, nil
+	}
+	gobot.Assert(t, len(connect(a)), 0)
+	gobot.Assert(t, attempts, 3)
+
+	attempts = 0
+	a = NewFirmataAdaptor("board", "/dev/null", gobot.RetryPolicy{MaxAttempts: 2})
+	a.connect = func(port string) (io.ReadWriteCloser, error) {
+		attempts++
+		return nil, errors.New("connect error")
+	}
+	gobot.Assert(t, a.Connect()[0], errors.New("connect error"))
+	gobot.Assert(t, attempts, 2)
+}
+
+func TestFirmataAdaptorDryRun(t *testing.T) {
+	actions := []string{}
+	a := NewFirmataAdaptor("board", "/dev/null")
+	a.connect = func(port string) (io.ReadWriteCloser, error) {
+		return nil, errors.New("connect error")
+	}
+	a.DryRun(func(method string, args ...interface{}) {
+		actions = append(actions, fmt.Sprint(method, args))
+	})
+
+	gobot.Assert(t, len(a.Connect()), 0)
+	gobot.Assert(t, a.DigitalWrite("13", 1), nil)
+	gobot.Assert(t, a.ServoWrite("9", 90), nil)
+	val, err := a.AnalogRead("0")
+	gobot.Assert(t, val, 0)
+	gobot.Assert(t, err, nil)
+	data, _ := a.I2cRead(2)
+	gobot.Assert(t, data, []byte{0, 0})
+	addresses, err := a.I2cScan()
+	gobot.Assert(t, addresses, []byte{})
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, a.Reset(), nil)
+	gobot.Assert(t, a.Reboot(), nil)
+	gobot.Assert(t, len(a.Finalize()), 0)
+	gobot.Assert(t, actions, []string{"DigitalWrite[13 1]", "ServoWrite[9 90]", "Reset[]", "Reboot[]"})
+}
+
 func TestFirmataAdaptorServoWrite(t *testing.T) {
 	a := initTestFirmataAdaptor()
 	a.ServoWrite("1", 50)
@@ -102,8 +157,8 @@ func TestFirmataAdaptorDigitalRead(t *testing.T) {
 
 	go func() {
 		<-time.After(5 * time.Millisecond)
-		gobot.Publish(a.board.events[fmt.Sprintf("digital_read_%v", pinNumber)],
-			[]byte{0x01})
+		gobot.Publish(a.board.events.Event(fmt.Sprintf("digital_read_%v", pinNumber)),
+			DigitalReading{Pin: 1, Value: 0x01})
 	}()
 	val, _ = a.DigitalRead(pinNumber)
 	gobot.Assert(t, val, 0x01)
@@ -119,14 +174,8 @@ func TestFirmataAdaptorAnalogRead(t *testing.T) {
 	value := 133
 	go func() {
 		<-time.After(5 * time.Millisecond)
-		gobot.Publish(a.board.events[fmt.Sprintf("analog_read_%v", pinNumber)],
-			[]byte{
-				byte(value >> 24),
-				byte(value >> 16),
-				byte(value >> 8),
-				byte(value & 0xff),
-			},
-		)
+		gobot.Publish(a.board.events.Event(fmt.Sprintf("analog_read_%v", pinNumber)),
+			AnalogReading{Channel: 1, Pin: 15, Value: value})
 	}()
 	val, _ = a.AnalogRead(pinNumber)
 	gobot.Assert(t, val, 133)
@@ -143,11 +192,10 @@ func TestFirmataAdaptorI2cRead(t *testing.T) {
 	gobot.Assert(t, data, []byte{})
 
 	i := []byte{100}
-	i2cReply := map[string][]byte{}
-	i2cReply["data"] = i
+	i2cReply := I2cReply{Data: i}
 	go func() {
 		<-time.After(5 * time.Millisecond)
-		gobot.Publish(a.board.events["i2c_reply"], i2cReply)
+		gobot.Publish(a.board.events.Event("i2c_reply"), i2cReply)
 	}()
 	data, _ = a.I2cRead(1)
 	gobot.Assert(t, data, i)
@@ -156,3 +204,352 @@ func TestFirmataAdaptorI2cWrite(t *testing.T) {
 	a := initTestFirmataAdaptor()
 	a.I2cWrite([]byte{0x00, 0x01})
 }
+
+func TestFirmataAdaptorWithTestBoard(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	board := firmatatest.NewBoard()
+	board.SetI2cReply(0x21, []byte{0x01, 0x02})
+	a := NewFirmataAdaptor("board", board)
+
+	gobot.Assert(t, len(a.Connect()), 0)
+	gobot.Assert(t, a.board.connected, true)
+	gobot.Assert(t, a.board.firmwareName, "StandardFirmata.ino")
+	gobot.Assert(t, a.board.version(), "2.3")
+
+	a.DigitalWrite("13", 1)
+	gobot.Assert(t, board.PinMode(13), output)
+	gobot.Assert(t, board.PinValue(13), 1)
+
+	a.ServoWrite("9", 90)
+	gobot.Assert(t, board.PinMode(9), servo)
+	gobot.Assert(t, board.PinValue(9), 90)
+
+	a.I2cStart(0x21)
+	a.I2cWrite([]byte{0x03})
+	gobot.Assert(t, board.I2cWrites(0x21), [][]byte{[]byte{0x03}})
+
+	gobot.Assert(t, len(a.Finalize()), 0)
+	gobot.Assert(t, board.Closed(), true)
+}
+
+func TestFirmataAdaptorMega(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	board := firmatatest.NewBoard()
+	board.CapabilityResponse = firmatatest.MegaCapabilityResponse
+	board.AnalogMappingResponse = firmatatest.MegaAnalogMappingResponse
+	a := NewFirmataAdaptor("board", board)
+	gobot.Assert(t, len(a.Connect()), 0)
+	gobot.Assert(t, len(a.board.pins), 70)
+
+	// A15 is pin 69
+	board.SetPinValue(69, 742)
+	val, _ := a.AnalogRead("15")
+	gobot.Assert(t, val, 742)
+	gobot.Assert(t, board.PinMode(69), analog)
+
+	// pins above 15 are written with an extended analog sysex
+	gobot.Assert(t, a.ServoWrite("45", 120), nil)
+	gobot.Assert(t, board.PinValue(45), 120)
+
+	gobot.Assert(t, a.DigitalWrite("53", 1), nil)
+	gobot.Assert(t, board.PinValue(53), 1)
+
+	_, err := a.AnalogRead("16")
+	gobot.Refute(t, err, nil)
+
+	// firmata pins are 7 bit, and the board only has 70 of them
+	gobot.Refute(t, a.DigitalWrite("128", 1), nil)
+	gobot.Refute(t, a.PwmWrite("200", 1), nil)
+	gobot.Refute(t, a.ServoWrite("100", 90), nil)
+}
+
+func TestFirmataAdaptorOptions(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	var logs bytes.Buffer
+	board := firmatatest.NewBoard()
+	a := NewFirmataAdaptor("board", board,
+		WithLogger(log.New(&logs, "", 0)),
+		WithoutAutoReporting(),
+	)
+	gobot.Assert(t, len(a.Connect()), 0)
+	gobot.Assert(t, a.ReportingState().Digital, map[byte]bool{})
+	gobot.Assert(t, strings.Contains(logs.String(), "Connected to StandardFirmata.ino 2.3"), true)
+
+	// a board which never answers
+	a = NewFirmataAdaptor("board", &NullReadWriteCloser{},
+		WithRetryInterval(time.Millisecond),
+		WithHandshakeTimeout(5*time.Millisecond),
+	)
+	gobot.Assert(t, a.Connect(), []error{ErrHandshakeTimeout})
+}
+
+func TestFirmataAdaptorVersion(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	gobot.Assert(t, NewFirmataAdaptor("board").Version(), Version{})
+
+	board := firmatatest.NewBoard()
+	a := NewFirmataAdaptor("board", board, RequireVersion(2, 3))
+	gobot.Assert(t, len(a.Connect()), 0)
+	gobot.Assert(t, a.Version(), Version{
+		Major:         2,
+		Minor:         3,
+		FirmwareName:  "StandardFirmata.ino",
+		FirmwareMajor: 2,
+		FirmwareMinor: 3,
+	})
+	gobot.Assert(t, a.Version().String(), "StandardFirmata.ino 2.3 (protocol 2.3)")
+
+	a = NewFirmataAdaptor("board", firmatatest.NewBoard(), RequireVersion(2, 6))
+	errs := a.Connect()
+	gobot.Assert(t, len(errs), 1)
+	gobot.Assert(t, errs[0].Error(),
+		"firmata: StandardFirmata.ino 2.3 (protocol 2.3) is too old, protocol version 2.6 or newer is required")
+}
+
+func TestFirmataAdaptorFeatures(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	a := NewFirmataAdaptor("board", firmatatest.NewBoard())
+	gobot.Assert(t, a.Features(), Features(0))
+	a.Connect()
+	gobot.Assert(t, a.Features(), FeatureAnalog|FeaturePwm|FeatureServo|FeatureI2c)
+	gobot.Assert(t, a.Features().String(), "analog,pwm,servo,i2c")
+
+	// a board with digital pins only
+	board := firmatatest.NewBoard()
+	board.FirmwareName = "ConfigurableFirmata.ino"
+	board.CapabilityResponse = []byte{240, 108, 0, 1, 1, 1, 127, 0, 1, 1, 1, 127, 247}
+	board.AnalogMappingResponse = []byte{240, 106, 127, 127, 247}
+	a = NewFirmataAdaptor("board", board)
+	a.Connect()
+	gobot.Assert(t, a.Features(), FeatureScheduler)
+	err := a.I2cStart(0x21)
+	gobot.Assert(t, err, ErrUnsupportedFeature{Feature: FeatureI2c})
+	gobot.Assert(t, err.Error(), "firmata: board does not support i2c")
+	_, err = a.I2cScan()
+	gobot.Assert(t, err, ErrUnsupportedFeature{Feature: FeatureI2c})
+
+	a = NewFirmataAdaptor("board", firmatatest.NewBoard(), WithProfile(MegaProfile))
+	a.Connect()
+	gobot.Assert(t, a.Features().Has(FeatureI2c), true)
+}
+
+func TestFirmataAdaptorWriteQueue(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	board := firmatatest.NewBoard()
+	a := NewFirmataAdaptor("board", board, WithWriteQueue(16, QueueBlock))
+	gobot.Assert(t, len(a.Connect()), 0)
+	gobot.Assert(t, a.DigitalWrite("13", 1), nil)
+	// queued writes are sent before disconnecting
+	gobot.Assert(t, len(a.Finalize()), 0)
+	gobot.Assert(t, board.PinValue(13), 1)
+	gobot.Assert(t, a.DigitalWrite("13", 0), ErrWriteQueueClosed)
+}
+
+func TestFirmataAdaptorProfile(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	board := firmatatest.NewBoard()
+	a := NewFirmataAdaptor("board", board,
+		WithProfile(UnoProfile),
+		WithHandshakeTimeout(time.Second),
+	)
+	gobot.Assert(t, len(a.Connect()), 0)
+	gobot.Assert(t, len(a.board.pins), 20)
+	gobot.Assert(t, bytes.Contains(board.Written(), []byte{startSysex, capabilityQuery}), false)
+	gobot.Assert(t, bytes.Contains(board.Written(), []byte{startSysex, analogMappingQuery}), false)
+
+	board.SetPinValue(15, 675)
+	val, _ := a.AnalogRead("1")
+	gobot.Assert(t, val, 675)
+	gobot.Assert(t, board.PinMode(15), analog)
+}
+
+func TestFirmataAdaptorI2cRegister(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	board := firmatatest.NewBoard()
+	board.SetI2cRegister(0x68, 0x75, []byte{0x68})
+	board.SetI2cRegister(0x68, 0x3B, []byte{0x01, 0xFF})
+	a := NewFirmataAdaptor("board", board)
+	a.Connect()
+
+	data, err := a.I2cReadRegister(0x68, 0x75, 1)
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, data, []byte{0x68})
+	data, _ = a.I2cReadRegister(0x68, 0x3B, 2)
+	gobot.Assert(t, data, []byte{0x01, 0xFF})
+
+	gobot.Assert(t, a.I2cWriteRegister(0x68, 0x6B, []byte{0x00}), nil)
+	gobot.Assert(t, board.I2cWrites(0x68), [][]byte{[]byte{0x6B, 0x00}})
+}
+
+func TestFirmataAdaptorI2cScan(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	board := firmatatest.NewBoard()
+	board.ReadTimeout = time.Millisecond
+	// the board answers every address, those without a device with no data
+	board.SetI2cReply(0x21, []byte{0x00})
+	board.SetI2cReply(0x48, []byte{0x01, 0x02})
+	a := NewFirmataAdaptor("board", board)
+	a.Connect()
+
+	sem := make(chan []byte, 1)
+	gobot.Once(a.board.events.Event("i2c_scan_complete"), func(data interface{}) {
+		sem <- data.([]byte)
+	})
+	addresses, err := a.I2cScan()
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, addresses, []byte{0x21, 0x48})
+	select {
+	case data := <-sem:
+		gobot.Assert(t, data, []byte{0x21, 0x48})
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("i2c_scan_complete was not published")
+	}
+	// the scan does not leave callbacks consuming later replies
+	gobot.Assert(t, a.board.events.Event("i2c_reply").Subscribers(), 0)
+	data, _ := a.I2cReadRegister(0x48, 0, 2)
+	gobot.Assert(t, data, []byte{0x01, 0x02})
+}
+
+func TestFirmataAdaptorReportingState(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	gobot.Assert(t, NewFirmataAdaptor("board").ReportingState(), ReportingState{
+		Analog:  map[byte]bool{},
+		Digital: map[byte]bool{},
+	})
+
+	board := firmatatest.NewBoard()
+	a := NewFirmataAdaptor("board", board)
+	a.Connect()
+	a.AnalogRead("1")
+	a.DigitalRead("10")
+	gobot.Assert(t, a.ReportingState().Analog, map[byte]bool{1: true})
+	gobot.Assert(t, a.ReportingState().Digital[1], true)
+
+	// reporting is requested again after a reset
+	before := len(board.Written())
+	gobot.Assert(t, a.Reset(), nil)
+	gobot.Assert(t, board.Written()[before:before+5], []byte{systemReset, 0xC1, 1, 0xD0, 1})
+
+	// and after reconnecting
+	a.Connect()
+	gobot.Assert(t, a.ReportingState().Analog, map[byte]bool{1: true})
+	gobot.Assert(t, a.ReportingState().Digital[1], true)
+}
+
+func TestFirmataAdaptorPinStates(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	gobot.Assert(t, NewFirmataAdaptor("board").PinStates(), map[string]gobot.PinState{})
+
+	a := NewFirmataAdaptor("board", firmatatest.NewBoard())
+	a.Connect()
+	a.DigitalWrite("13", 1)
+	a.ServoWrite("9", 90)
+	gobot.Assert(t, a.PinStates(), map[string]gobot.PinState{
+		"9":  {Mode: "servo", Value: 90},
+		"13": {Mode: "output", Value: 1},
+	})
+}
+
+func TestFirmataAdaptorPingRead(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	board := firmatatest.NewBoard()
+	board.SetPingEcho(7, 1166*time.Microsecond)
+	a := NewFirmataAdaptor("board", board)
+	a.Connect()
+
+	echo, err := a.PingRead("7", "7", 40*time.Millisecond)
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, echo, 1166*time.Microsecond)
+
+	_, err = a.PingRead("7", "8", 40*time.Millisecond)
+	gobot.Refute(t, err, nil)
+	// the board does not answer on pin 8
+	_, err = a.PingRead("8", "8", 5*time.Millisecond)
+	gobot.Assert(t, err.Error(), "firmata: no ping reply, the firmware may not support the ping sysex")
+}
+
+func TestFirmataAdaptorDHTRead(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	board := firmatatest.NewBoard()
+	board.SetDHTData(4, []byte{0x02, 0x8C, 0x01, 0x5F, 0xEE})
+	a := NewFirmataAdaptor("board", board)
+	a.Connect()
+
+	data, err := a.DHTRead("4", gpio.DHT22)
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, data, []byte{0x02, 0x8C, 0x01, 0x5F, 0xEE})
+
+	// the board does not answer on pin 5
+	_, err = a.DHTRead("5", gpio.DHT22)
+	gobot.Assert(t, err.Error(), "firmata: no DHT reply, the firmware may not support the DHT sysex")
+}
+
+func TestFirmataAdaptorReboot(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	board := firmatatest.NewBoard()
+	a := NewFirmataAdaptor("board", board)
+	a.Connect()
+	a.DigitalWrite("13", 1)
+	a.ServoWrite("9", 90)
+	a.AnalogRead("1")
+
+	board.FirmwareName = "ConfigurableFirmata.ino"
+	before := len(board.Written())
+	gobot.Assert(t, a.Reboot(), nil)
+	gobot.Assert(t, board.Written()[before], systemReset)
+	gobot.Assert(t, a.board.firmwareName, "ConfigurableFirmata.ino")
+	gobot.Assert(t, a.board.configuredPinModes(), map[byte]byte{9: servo, 13: output, 15: analog})
+	gobot.Assert(t, board.PinMode(9), servo)
+	gobot.Assert(t, board.PinMode(15), analog)
+	gobot.Assert(t, a.ReportingState().Analog, map[byte]bool{1: true})
+}
+
+func TestFirmataAdaptorPinAlias(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	board := firmatatest.NewBoard()
+	a := NewFirmataAdaptor("board", board)
+	a.AddPinAlias("led", 13)
+	a.Connect()
+	a.AddPinAlias("A1", 15)
+
+	gobot.Assert(t, a.DigitalWrite("led", 1), nil)
+	gobot.Assert(t, board.PinValue(13), 1)
+	gobot.Assert(t, a.PwmWrite("led", 100), nil)
+	gobot.Assert(t, board.PinValue(13), 100)
+
+	a.AnalogRead("A1")
+	gobot.Assert(t, a.ReportingState().Analog, map[byte]bool{1: true})
+	gobot.Assert(t, board.PinMode(15), analog)
+
+	_, err := a.DigitalRead("estop")
+	gobot.Refute(t, err, nil)
+
+	// events are published under the alias as well
+	sem := make(chan int)
+	gobot.Once(a.board.events.Event("analog_read_A1"), func(data interface{}) {
+		sem <- data.(AnalogReading).Value
+	})
+	a.board.process([]byte{0xE1, 0x23, 0x05})
+	select {
+	case val := <-sem:
+		gobot.Assert(t, val, 675)
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("analog_read_A1 was not published")
+	}
+
+	a.board.pins[13].mode = input
+	gobot.Once(a.board.events.Event("digital_read_led"), func(data interface{}) {
+		sem <- data.(DigitalReading).Value
+	})
+	a.board.process([]byte{0x91, 0x20, 0x00})
+	select {
+	case val := <-sem:
+		gobot.Assert(t, val, 1)
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("digital_read_led was not published")
+	}
+
+	// aliases survive a reconnect
+	a.Connect()
+	gobot.Refute(t, a.board.events.Event("digital_read_led"), (*gobot.Event)(nil))
+}
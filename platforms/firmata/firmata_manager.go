@@ -0,0 +1,272 @@
+package firmata
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var _ gobot.Adaptor = (*FirmataManager)(nil)
+
+// boardEventQueue is the number of board events queued for each callback of
+// "board_event" while it is busy
+const boardEventQueue = 64
+
+// BoardEvent is published by a FirmataManager for each event of one of its boards
+type BoardEvent struct {
+	Board string
+	Event string
+	Data  interface{}
+}
+
+// FirmataManager owns several FirmataAdaptors, e.g. multiple Arduinos on
+// different serial ports, handles their connect and reconnect lifecycles and
+// multiplexes their events tagged with the board name.
+type FirmataManager struct {
+	name   string
+	boards []*FirmataAdaptor
+	reopen map[string]bool
+	// Retry describes how long apart a board whose reads or writes failed is
+	// reconnected, and after how many attempts the manager gives up.
+	// Defaults to a backoff of 1 second, doubled up to 1 minute, forever.
+	Retry        gobot.RetryPolicy
+	forwarding   map[string]forwarding
+	reconnecting map[string]bool
+	stopped      <-chan struct{}
+	stop         context.CancelFunc
+	mutex        sync.Mutex
+	gobot.Eventer
+}
+
+// forwarding is the subscription forwarding the events of the board of the
+// current connection of a FirmataAdaptor
+type forwarding struct {
+	board *board
+	stop  func()
+}
+
+// NewFirmataManager returns a new FirmataManager given a name.
+//
+// Adds the following events:
+//
+//	"connect" - the name of a board which has connected
+//	"disconnect" - the name of a board which has disconnected
+//	"board_event" - a BoardEvent for every event published by a board
+//	"reconnect_failed" - the error of a board the manager gave up reconnecting
+//
+// The callbacks of "board_event" are handed one BoardEvent at a time, in the
+// order they were forwarded, and drop the oldest of the queued BoardEvents
+// while they are busy. Set another gobot.Dispatch on the event before
+// subscribing to change this.
+func NewFirmataManager(name string) *FirmataManager {
+	m := &FirmataManager{
+		name:         name,
+		boards:       []*FirmataAdaptor{},
+		reopen:       make(map[string]bool),
+		Retry:        gobot.RetryPolicy{Backoff: time.Second, MaxBackoff: time.Minute},
+		forwarding:   make(map[string]forwarding),
+		reconnecting: make(map[string]bool),
+		Eventer:      gobot.NewEventer(),
+	}
+
+	m.AddEvent("connect")
+	m.AddEvent("disconnect")
+	m.AddBufferedEvent("board_event", boardEventQueue)
+	m.Event("board_event").SetDispatch(gobot.Dispatch{
+		Ordered:   true,
+		QueueSize: boardEventQueue,
+		Overflow:  gobot.DropOldest,
+	})
+	m.AddEvent("reconnect_failed")
+
+	return m
+}
+
+// AddBoard adds a new board to the manager and returns its FirmataAdaptor.
+// args are the same as for NewFirmataAdaptor.
+func (m *FirmataManager) AddBoard(name string, args ...interface{}) *FirmataAdaptor {
+	f := NewFirmataAdaptor(name, args...)
+	// boards given a port are reopened on reconnect, boards given an
+	// io.ReadWriteCloser keep using it
+	m.reopen[name] = f.conn == nil
+	m.boards = append(m.boards, f)
+	return f
+}
+
+// Board returns a board given a name. Returns nil if the board does not exist.
+func (m *FirmataManager) Board(name string) *FirmataAdaptor {
+	for _, board := range m.boards {
+		if board.Name() == name {
+			return board
+		}
+	}
+	return nil
+}
+
+// Boards returns all boards owned by the manager.
+func (m *FirmataManager) Boards() []*FirmataAdaptor {
+	return m.boards
+}
+
+// Name returns the FirmataManagers name
+func (m *FirmataManager) Name() string { return m.name }
+
+// Connect connects each board in turn and stops at the first board which
+// fails to connect. From then on, a board whose reads or writes fail is
+// reconnected as described by Retry, until Finalize is called.
+func (m *FirmataManager) Connect() (errs []error) {
+	m.mutex.Lock()
+	if m.stop == nil {
+		ctx, stop := context.WithCancel(context.Background())
+		m.stopped, m.stop = ctx.Done(), stop
+	}
+	m.mutex.Unlock()
+	for _, board := range m.boards {
+		if errs = m.connect(board); len(errs) > 0 {
+			return
+		}
+	}
+	return
+}
+
+// Reconnect disconnects the named board and connects it again. Boards added
+// with an io.ReadWriteCloser are not closed, the handshake is run again on
+// the same connection instead.
+func (m *FirmataManager) Reconnect(name string) (errs []error) {
+	board := m.Board(name)
+	if board == nil {
+		return []error{fmt.Errorf("No board found with the name %v", name)}
+	}
+	// failed reads and writes of the connection being closed do not
+	// reconnect the board again
+	m.mutex.Lock()
+	supervised := m.reconnecting[name]
+	m.reconnecting[name] = true
+	m.mutex.Unlock()
+	if !supervised {
+		defer func() {
+			m.mutex.Lock()
+			delete(m.reconnecting, name)
+			m.mutex.Unlock()
+		}()
+	}
+	return m.reconnect(board)
+}
+
+// Finalize stops reconnecting boards and disconnects every board.
+func (m *FirmataManager) Finalize() (errs []error) {
+	m.mutex.Lock()
+	if m.stop != nil {
+		m.stop()
+		m.stopped, m.stop = nil, nil
+	}
+	m.mutex.Unlock()
+	for _, board := range m.boards {
+		if err := m.disconnect(board); err != nil {
+			errs = append(errs, fmt.Errorf("Board %q: %v", board.Name(), err))
+		}
+	}
+	m.mutex.Lock()
+	for name, f := range m.forwarding {
+		f.stop()
+		delete(m.forwarding, name)
+	}
+	m.mutex.Unlock()
+	return
+}
+
+// reconnect disconnects board, reopening its port if it was given one, and
+// connects it again
+func (m *FirmataManager) reconnect(board *FirmataAdaptor) (errs []error) {
+	if m.reopen[board.Name()] {
+		m.disconnect(board)
+		board.conn = nil
+	}
+	return m.connect(board)
+}
+
+// connect connects board and multiplexes its events into "board_event"
+func (m *FirmataManager) connect(board *FirmataAdaptor) (errs []error) {
+	if errs = board.Connect(); len(errs) > 0 {
+		for i, err := range errs {
+			errs[i] = fmt.Errorf("Board %q: %v", board.Name(), err)
+		}
+		return
+	}
+	m.forward(board)
+	gobot.Publish(m.Event("connect"), board.Name())
+	return
+}
+
+// disconnect disconnects board
+func (m *FirmataManager) disconnect(board *FirmataAdaptor) (err error) {
+	if err = board.Disconnect(); err == nil {
+		gobot.Publish(m.Event("disconnect"), board.Name())
+	}
+	return
+}
+
+// forward republishes every value published on the events of the current
+// connection of f as a BoardEvent, including events added later, and
+// supervises f when its reads or writes fail. The events of the previous
+// connection of f are no longer forwarded.
+func (m *FirmataManager) forward(f *FirmataAdaptor) {
+	b, name := f.board, f.Name()
+	stop, _ := b.events.OnPattern("*", func(event string, data interface{}) {
+		if event == "io_error" {
+			m.supervise(f, b)
+		}
+		gobot.Publish(m.Event("board_event"), BoardEvent{
+			Board: name,
+			Event: event,
+			Data:  data,
+		})
+	})
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if previous, ok := m.forwarding[name]; ok {
+		previous.stop()
+	}
+	m.forwarding[name] = forwarding{board: b, stop: stop}
+}
+
+// supervise reconnects f, whose board b had a read or write fail, retrying
+// as described by Retry. Nothing is done if b is no longer the board of the
+// current connection of f, if f is already being reconnected or if the
+// manager has been finalized. Publishes the last error on
+// "reconnect_failed" when giving up.
+func (m *FirmataManager) supervise(f *FirmataAdaptor, b *board) {
+	name := f.Name()
+	m.mutex.Lock()
+	stop := m.stopped
+	if stop == nil || m.reconnecting[name] || m.forwarding[name].board != b {
+		m.mutex.Unlock()
+		return
+	}
+	m.reconnecting[name] = true
+	retry := m.Retry
+	m.mutex.Unlock()
+
+	go func() {
+		err := gobot.RetryUntil(stop, retry, func() error {
+			select {
+			case <-stop:
+				return gobot.ErrRetryStopped
+			default:
+			}
+			if errs := m.reconnect(f); len(errs) > 0 {
+				return errs[0]
+			}
+			return nil
+		})
+		m.mutex.Lock()
+		delete(m.reconnecting, name)
+		m.mutex.Unlock()
+		if err != nil && err != gobot.ErrRetryStopped {
+			gobot.Publish(m.Event("reconnect_failed"), err)
+		}
+	}()
+}
@@ -0,0 +1,202 @@
+package firmata
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/firmata/firmatatest"
+)
+
+func initTestFirmataManager() (*FirmataManager, *firmatatest.Board, *firmatatest.Board) {
+	defaultInitTimeInterval = 0 * time.Second
+	uno := firmatatest.NewBoard()
+	leonardo := firmatatest.NewBoard()
+	m := NewFirmataManager("boards")
+	m.AddBoard("uno", "/dev/ttyACM0", uno)
+	m.AddBoard("leonardo", "/dev/ttyACM1", leonardo)
+	return m, uno, leonardo
+}
+
+func TestFirmataManager(t *testing.T) {
+	m, _, _ := initTestFirmataManager()
+	gobot.Assert(t, m.Name(), "boards")
+	gobot.Assert(t, len(m.Boards()), 2)
+	gobot.Assert(t, m.Board("uno").Port(), "/dev/ttyACM0")
+	gobot.Assert(t, m.Board("mega"), (*FirmataAdaptor)(nil))
+}
+
+func TestFirmataManagerConnect(t *testing.T) {
+	m, _, _ := initTestFirmataManager()
+	gobot.Assert(t, len(m.Connect()), 0)
+	gobot.Assert(t, m.Board("uno").board.connected, true)
+	gobot.Assert(t, m.Board("leonardo").board.connected, true)
+
+	sem := make(chan BoardEvent, 1)
+	gobot.On(m.Event("board_event"), func(data interface{}) {
+		if e := data.(BoardEvent); e.Event == "i2c_reply" {
+			sem <- e
+		}
+	})
+	gobot.Publish(m.Board("leonardo").board.events.Event("i2c_reply"), "data")
+	select {
+	case e := <-sem:
+		gobot.Assert(t, e, BoardEvent{Board: "leonardo", Event: "i2c_reply", Data: "data"})
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("board_event was not published")
+	}
+
+	m = NewFirmataManager("boards")
+	m.AddBoard("uno", "/dev/null")
+	m.Board("uno").connect = func(port string) (io.ReadWriteCloser, error) {
+		return nil, errors.New("connect error")
+	}
+	gobot.Assert(t, m.Connect()[0], errors.New("Board \"uno\": connect error"))
+}
+
+func TestFirmataManagerReconnect(t *testing.T) {
+	m, uno, _ := initTestFirmataManager()
+	m.Connect()
+
+	before := len(uno.Written())
+	gobot.Assert(t, len(m.Reconnect("uno")), 0)
+	gobot.Assert(t, uno.Closed(), false)
+	gobot.Assert(t, uno.Written()[before], systemReset)
+
+	gobot.Assert(t, m.Reconnect("mega")[0], errors.New("No board found with the name mega"))
+
+	opened := ""
+	m = NewFirmataManager("boards")
+	m.AddBoard("uno", "/dev/ttyACM0")
+	m.Board("uno").connect = func(port string) (io.ReadWriteCloser, error) {
+		opened = port
+		return firmatatest.NewBoard(), nil
+	}
+	m.Connect()
+	opened = ""
+	sem := make(chan string, 1)
+	gobot.Once(m.Event("disconnect"), func(data interface{}) {
+		sem <- data.(string)
+	})
+	gobot.Assert(t, len(m.Reconnect("uno")), 0)
+	gobot.Assert(t, opened, "/dev/ttyACM0")
+	select {
+	case name := <-sem:
+		gobot.Assert(t, name, "uno")
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("disconnect was not published")
+	}
+}
+
+func TestFirmataManagerFinalize(t *testing.T) {
+	m, uno, leonardo := initTestFirmataManager()
+	m.Connect()
+	gobot.Assert(t, len(m.Finalize()), 0)
+	gobot.Assert(t, uno.Closed(), true)
+	gobot.Assert(t, leonardo.Closed(), true)
+
+	m = NewFirmataManager("boards")
+	m.AddBoard("uno", "/dev/null")
+	gobot.Assert(t, m.Finalize()[0], errors.New("Board \"uno\": no board connected"))
+}
+
+func TestFirmataManagerForward(t *testing.T) {
+	m, _, _ := initTestFirmataManager()
+	m.Connect()
+	events := make(chan BoardEvent, 16)
+	gobot.On(m.Event("board_event"), func(data interface{}) {
+		if e := data.(BoardEvent); e.Event == "custom" || e.Event == "string_data" {
+			events <- e
+		}
+	})
+
+	// events added after connecting are forwarded too
+	old := m.Board("uno").board
+	old.events.AddEvent("custom")
+	gobot.Publish(old.events.Event("custom"), 1)
+	select {
+	case e := <-events:
+		gobot.Assert(t, e, BoardEvent{Board: "uno", Event: "custom", Data: 1})
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("board_event was not published")
+	}
+
+	// only the events of the current connection are forwarded, once
+	m.Reconnect("uno")
+	gobot.Publish(old.events.Event("string_data"), "old")
+	gobot.Publish(m.Board("uno").board.events.Event("string_data"), "new")
+	select {
+	case e := <-events:
+		gobot.Assert(t, e, BoardEvent{Board: "uno", Event: "string_data", Data: "new"})
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("board_event was not published")
+	}
+	select {
+	case e := <-events:
+		t.Errorf("unexpected board_event %v", e)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestFirmataManagerSupervise(t *testing.T) {
+	defaultInitTimeInterval = 0 * time.Second
+	boards := make(chan *firmatatest.Board, 4)
+	m := NewFirmataManager("boards")
+	m.Retry = gobot.RetryPolicy{Backoff: time.Millisecond}
+	m.AddBoard("uno", "/dev/ttyACM0")
+	m.Board("uno").connect = func(port string) (io.ReadWriteCloser, error) {
+		b := firmatatest.NewBoard()
+		boards <- b
+		return b, nil
+	}
+	connected := make(chan string, 2)
+	gobot.On(m.Event("connect"), func(data interface{}) {
+		connected <- data.(string)
+	})
+	gobot.Assert(t, len(m.Connect()), 0)
+	<-connected
+
+	(<-boards).Close()
+	gobot.Refute(t, m.Board("uno").DigitalWrite("13", 1), nil)
+	select {
+	case name := <-connected:
+		gobot.Assert(t, name, "uno")
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("uno was not reconnected")
+	}
+	gobot.Assert(t, len(boards), 1)
+	gobot.Assert(t, m.Board("uno").DigitalWrite("13", 1), nil)
+
+	// gives up after Retry.MaxAttempts
+	m.Retry = gobot.RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}
+	m.Board("uno").connect = func(port string) (io.ReadWriteCloser, error) {
+		return nil, errors.New("connect error")
+	}
+	failed := make(chan error, 1)
+	gobot.On(m.Event("reconnect_failed"), func(data interface{}) {
+		failed <- data.(error)
+	})
+	(<-boards).Close()
+	m.Board("uno").DigitalWrite("13", 1)
+	select {
+	case err := <-failed:
+		gobot.Assert(t, err, errors.New("Board \"uno\": connect error"))
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("reconnect_failed was not published")
+	}
+
+	// finalized boards are not reconnected
+	m = NewFirmataManager("boards")
+	m.Retry = gobot.RetryPolicy{Backoff: time.Millisecond}
+	uno := firmatatest.NewBoard()
+	m.AddBoard("uno", "/dev/ttyACM0", uno)
+	m.Connect()
+	m.Finalize()
+	m.Board("uno").DigitalWrite("13", 1)
+	<-time.After(10 * time.Millisecond)
+	m.mutex.Lock()
+	gobot.Assert(t, len(m.reconnecting), 0)
+	m.mutex.Unlock()
+}
@@ -1,10 +1,12 @@
 package firmata
 
 import (
+	"bytes"
 	"testing"
 	"time"
 
 	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/firmata/firmatatest"
 )
 
 type NullReadWriteCloser struct{}
@@ -66,7 +68,7 @@ func TestProcess(t *testing.T) {
 	b := initTestFirmata()
 	sem := make(chan bool)
 	//reportVersion
-	gobot.Once(b.events["report_version"], func(data interface{}) {
+	gobot.Once(b.events.Event("report_version"), func(data interface{}) {
 		gobot.Assert(t, data.(string), "1.17")
 		sem <- true
 	})
@@ -77,11 +79,9 @@ func TestProcess(t *testing.T) {
 		t.Errorf("report_version was not published")
 	}
 	//analogMessageRangeStart
-	gobot.Once(b.events["analog_read_0"], func(data interface{}) {
-		b := data.([]byte)
-		gobot.Assert(t,
-			int(uint(b[0])<<24|uint(b[1])<<16|uint(b[2])<<8|uint(b[3])),
-			675)
+	gobot.Once(b.events.Event("analog_read_0"), func(data interface{}) {
+		gobot.Assert(t, data.(AnalogReading).Value, 675)
+		gobot.Assert(t, data.(AnalogReading).Pin, byte(14))
 		sem <- true
 	})
 	b.process([]byte{0xE0, 0x23, 0x05})
@@ -90,11 +90,8 @@ func TestProcess(t *testing.T) {
 	case <-time.After(10 * time.Millisecond):
 		t.Errorf("analog_read_0 was not published")
 	}
-	gobot.Once(b.events["analog_read_1"], func(data interface{}) {
-		b := data.([]byte)
-		gobot.Assert(t,
-			int(uint(b[0])<<24|uint(b[1])<<16|uint(b[2])<<8|uint(b[3])),
-			803)
+	gobot.Once(b.events.Event("analog_read_1"), func(data interface{}) {
+		gobot.Assert(t, data.(AnalogReading).Value, 803)
 		sem <- true
 	})
 	b.process([]byte{0xE1, 0x23, 0x06})
@@ -105,8 +102,8 @@ func TestProcess(t *testing.T) {
 	}
 	//digitalMessageRangeStart
 	b.pins[2].mode = input
-	gobot.Once(b.events["digital_read_2"], func(data interface{}) {
-		gobot.Assert(t, int(data.([]byte)[0]), 1)
+	gobot.Once(b.events.Event("digital_read_2"), func(data interface{}) {
+		gobot.Assert(t, data.(DigitalReading).Value, 1)
 		sem <- true
 	})
 	b.process([]byte{0x90, 0x04, 0x00})
@@ -115,16 +112,13 @@ func TestProcess(t *testing.T) {
 	case <-time.After(10 * time.Millisecond):
 		t.Errorf("digital_read_2 was not published")
 	}
-	gobot.Once(b.events["analog_read_1"], func(data interface{}) {
-		b := data.([]byte)
-		gobot.Assert(t,
-			int(uint(b[0])<<24|uint(b[1])<<16|uint(b[2])<<8|uint(b[3])),
-			803)
+	gobot.Once(b.events.Event("analog_read_1"), func(data interface{}) {
+		gobot.Assert(t, data.(AnalogReading).Value, 803)
 		sem <- true
 	})
 	b.pins[4].mode = input
-	gobot.Once(b.events["digital_read_4"], func(data interface{}) {
-		gobot.Assert(t, int(data.([]byte)[0]), 1)
+	gobot.Once(b.events.Event("digital_read_4"), func(data interface{}) {
+		gobot.Assert(t, data.(DigitalReading).Value, 1)
 		sem <- true
 	})
 	b.process([]byte{0x90, 0x16, 0x00})
@@ -134,7 +128,7 @@ func TestProcess(t *testing.T) {
 		t.Errorf("digital_read_4 was not published")
 	}
 	//pinStateResponse
-	gobot.Once(b.events["pin_13_state"], func(data interface{}) {
+	gobot.Once(b.events.Event("pin_13_state"), func(data interface{}) {
 		gobot.Assert(t, data, map[string]int{
 			"pin":   13,
 			"mode":  1,
@@ -149,12 +143,12 @@ func TestProcess(t *testing.T) {
 		t.Errorf("pin_13_state was not published")
 	}
 	//i2cReply
-	gobot.Once(b.events["i2c_reply"], func(data interface{}) {
-		i2cReply := map[string][]byte{
-			"slave_address": []byte{9},
-			"register":      []byte{0},
-			"data":          []byte{152, 1, 154}}
-		gobot.Assert(t, data.(map[string][]byte), i2cReply)
+	gobot.Once(b.events.Event("i2c_reply"), func(data interface{}) {
+		reply := data.(I2cReply)
+		gobot.Assert(t, reply.Address, byte(9))
+		gobot.Assert(t, reply.Register, byte(0))
+		gobot.Assert(t, reply.Data, []byte{152, 1, 154})
+		gobot.Refute(t, reply.Timestamp.IsZero(), true)
 		sem <- true
 	})
 	b.process([]byte{240, 119, 9, 0, 0, 0, 24, 1, 1, 0, 26, 1, 247})
@@ -164,7 +158,7 @@ func TestProcess(t *testing.T) {
 		t.Errorf("i2c_reply was not published")
 	}
 	//firmwareName
-	gobot.Once(b.events["firmware_query"], func(data interface{}) {
+	gobot.Once(b.events.Event("firmware_query"), func(data interface{}) {
 		gobot.Assert(t, data.(string), "StandardFirmata.ino")
 		sem <- true
 	})
@@ -177,14 +171,210 @@ func TestProcess(t *testing.T) {
 		t.Errorf("firmware_query was not published")
 	}
 	//stringData
-	gobot.Once(b.events["string_data"], func(data interface{}) {
+	gobot.Once(b.events.Event("string_data"), func(data interface{}) {
 		gobot.Assert(t, data.(string), "Hello Firmata!")
 		sem <- true
 	})
-	b.process(append([]byte{240, 0x71}, []byte("Hello Firmata!")...))
+	b.process(append(append([]byte{240, 0x71}, encode7Bit([]byte("Hello Firmata!"))...), 247))
 	select {
 	case <-sem:
 	case <-time.After(10 * time.Millisecond):
 		t.Errorf("string_data was not published")
 	}
 }
+
+func TestStringData(t *testing.T) {
+	serial := firmatatest.NewBoard()
+	b := newBoard(serial)
+	b.stringWrite("Hi")
+	gobot.Assert(t, serial.Written(), []byte{240, 0x71, 'H', 0, 'i', 0, 247})
+
+	// firmwares sending 8-bit strings
+	serial = firmatatest.NewBoard()
+	b = newBoard(serial, WithRawStrings())
+	b.stringWrite("Hi")
+	gobot.Assert(t, serial.Written(), []byte{240, 0x71, 'H', 'i', 247})
+
+	sem := make(chan string)
+	gobot.Once(b.events.Event("string_data"), func(data interface{}) {
+		sem <- data.(string)
+	})
+	b.process([]byte{240, 0x71, 'H', 'i', 247})
+	select {
+	case str := <-sem:
+		gobot.Assert(t, str, "Hi")
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("string_data was not published")
+	}
+}
+
+func TestReportingState(t *testing.T) {
+	serial := firmatatest.NewBoard()
+	b := newBoard(serial)
+	b.togglePinReporting(1, high, reportAnalog)
+	b.togglePinReporting(0, high, reportDigital)
+	b.togglePinReporting(2, high, reportDigital)
+	b.togglePinReporting(2, low, reportDigital)
+	gobot.Assert(t, b.reportingState(), ReportingState{
+		Analog:  map[byte]bool{1: true},
+		Digital: map[byte]bool{0: true, 2: false},
+	})
+
+	b = newBoard(serial)
+	before := len(serial.Written())
+	b.restoreReporting(ReportingState{
+		Analog:  map[byte]bool{3: true, 1: true},
+		Digital: map[byte]bool{1: true, 2: false},
+	})
+	gobot.Assert(t, serial.Written()[before:], []byte{0xC1, 1, 0xC3, 1, 0xD1, 1})
+	gobot.Assert(t, b.reportingState().Analog, map[byte]bool{1: true, 3: true})
+}
+
+func TestAnalogMapping(t *testing.T) {
+	b := initTestFirmata()
+	gobot.Assert(t, len(b.pins), 20)
+	gobot.Assert(t, b.analogPins, map[byte]byte{0: 14, 1: 15, 2: 16, 3: 17, 4: 18, 5: 19})
+	gobot.Assert(t, b.pins[19].analogChannel, byte(5))
+
+	_, err := b.analogPin(6)
+	gobot.Refute(t, err, nil)
+
+	// the last port of the uno only has 4 pins
+	gobot.Assert(t, b.digitalWrite(19, 1), nil)
+	gobot.Refute(t, b.digitalWrite(20, 1), nil)
+	gobot.Refute(t, b.analogWrite(130, 1), nil)
+	gobot.Refute(t, b.setPinMode(128, output), nil)
+}
+
+func TestWriteInterval(t *testing.T) {
+	serial := firmatatest.NewBoard()
+	b := newBoard(serial, WithWriteInterval(5*time.Millisecond))
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		b.queryReportVersion()
+	}
+	gobot.Assert(t, time.Since(start) >= 10*time.Millisecond, true)
+	gobot.Assert(t, serial.Written(), []byte{reportVersion, reportVersion, reportVersion})
+}
+
+func TestUnknownSysex(t *testing.T) {
+	b := initTestFirmata()
+	sem := make(chan SysexFrame)
+	gobot.Once(b.events.Event("unknown_sysex"), func(data interface{}) {
+		sem <- data.(SysexFrame)
+	})
+	b.process([]byte{240, 0x60, 1, 2, 247})
+	select {
+	case frame := <-sem:
+		gobot.Assert(t, frame, SysexFrame{Command: 0x60, Data: []byte{1, 2}})
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("unknown_sysex was not published")
+	}
+
+	gobot.Once(b.events.Event("malformed_sysex"), func(data interface{}) {
+		sem <- data.(SysexFrame)
+	})
+	b.process([]byte{240, i2CReply, 9, 247})
+	select {
+	case frame := <-sem:
+		gobot.Assert(t, frame, SysexFrame{Command: i2CReply, Data: []byte{9}})
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("malformed_sysex was not published")
+	}
+
+	// a pin state of a pin the board does not have
+	gobot.Once(b.events.Event("malformed_sysex"), func(data interface{}) {
+		sem <- data.(SysexFrame)
+	})
+	b.process([]byte{240, pinStateResponse, 100, 1, 1, 247})
+	select {
+	case frame := <-sem:
+		gobot.Assert(t, frame.Command, pinStateResponse)
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("malformed_sysex was not published")
+	}
+}
+
+func TestDigitalWriteWindow(t *testing.T) {
+	serial := firmatatest.NewBoard()
+	b := newBoard(serial, WithDigitalWriteWindow(5*time.Millisecond))
+	b.seedPins(UnoProfile)
+	for pin := byte(2); pin < 8; pin++ {
+		gobot.Assert(t, b.digitalWrite(pin, 1), nil)
+	}
+	b.digitalWrite(13, 1)
+	gobot.Assert(t, len(serial.Written()), 0)
+
+	<-time.After(20 * time.Millisecond)
+	// one message per port
+	gobot.Assert(t, len(serial.Written()), 6)
+	gobot.Assert(t, bytes.Contains(serial.Written(), []byte{digitalMessage, 0x7C, 0x01}), true)
+	gobot.Assert(t, bytes.Contains(serial.Written(), []byte{digitalMessage | 1, 0x20, 0x00}), true)
+
+	b.digitalWrite(3, 0)
+	gobot.Assert(t, b.flushDigitalWrites(), nil)
+	gobot.Assert(t, serial.Written()[6:], []byte{digitalMessage, 0x74, 0x01})
+}
+
+func TestAnalogDeadband(t *testing.T) {
+	b := newBoard(firmatatest.NewBoard(), WithAnalogDeadband(0, 4))
+	b.seedPins(UnoProfile)
+	values := make(chan int, 10)
+	gobot.On(b.events.Event("analog_read_0"), func(data interface{}) {
+		values <- data.(AnalogReading).Value
+	})
+	for _, value := range []int{500, 503, 497, 505, 509} {
+		b.process([]byte{analogMessage, byte(value & 0x7F), byte(value >> 7)})
+		<-time.After(time.Millisecond)
+	}
+	gobot.Assert(t, <-values, 500)
+	gobot.Assert(t, <-values, 505)
+	gobot.Assert(t, len(values), 0)
+	gobot.Assert(t, b.pins[14].value, 509)
+
+	value, ok := b.lastAnalogValue(0)
+	gobot.Assert(t, value, 505)
+	gobot.Assert(t, ok, true)
+}
+
+// stalledWriter is a connection whose writes block until released
+type stalledWriter struct {
+	NullReadWriteCloser
+	release chan bool
+	written chan []byte
+}
+
+func (s *stalledWriter) Write(p []byte) (int, error) {
+	<-s.release
+	s.written <- p
+	return len(p), nil
+}
+
+func TestWriteQueue(t *testing.T) {
+	conn := &stalledWriter{release: make(chan bool, 10), written: make(chan []byte, 10)}
+	b := newBoard(conn, WithWriteQueue(1, QueueError))
+	// the first write is taken by the stalled writer, the second fills the queue
+	gobot.Assert(t, b.queryReportVersion(), nil)
+	<-time.After(time.Millisecond)
+	gobot.Assert(t, b.reset(), nil)
+	gobot.Assert(t, b.queryFirmware(), ErrWriteQueueFull)
+
+	conn.release <- true
+	conn.release <- true
+	gobot.Assert(t, b.drainQueue(), nil)
+	gobot.Assert(t, <-conn.written, []byte{reportVersion})
+	gobot.Assert(t, <-conn.written, []byte{systemReset})
+	gobot.Assert(t, b.reset(), ErrWriteQueueClosed)
+
+	conn = &stalledWriter{release: make(chan bool, 10), written: make(chan []byte, 10)}
+	b = newBoard(conn, WithWriteQueue(1, QueueDrop))
+	b.queryReportVersion()
+	<-time.After(time.Millisecond)
+	b.reset()
+	gobot.Assert(t, b.queryFirmware(), nil)
+	conn.release <- true
+	conn.release <- true
+	conn.release <- true
+	b.drainQueue()
+	gobot.Assert(t, len(conn.written), 2)
+}
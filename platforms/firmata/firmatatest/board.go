@@ -0,0 +1,565 @@
+package firmatatest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	input                 byte = 0x00
+	output                byte = 0x01
+	analog                byte = 0x02
+	pwm                   byte = 0x03
+	servo                 byte = 0x04
+	i2c                   byte = 0x06
+	reportVersion         byte = 0xF9
+	systemReset           byte = 0xFF
+	digitalMessage        byte = 0x90
+	analogMessage         byte = 0xE0
+	reportAnalog          byte = 0xC0
+	reportDigital         byte = 0xD0
+	pinMode               byte = 0xF4
+	startSysex            byte = 0xF0
+	endSysex              byte = 0xF7
+	capabilityQuery       byte = 0x6B
+	capabilityResponse    byte = 0x6C
+	pinStateQuery         byte = 0x6D
+	pinStateResponse      byte = 0x6E
+	analogMappingQuery    byte = 0x69
+	analogMappingResponse byte = 0x6A
+	extendedAnalog        byte = 0x6F
+	i2CRequest            byte = 0x76
+	i2CReply              byte = 0x77
+	pingRead              byte = 0x75
+	dhtRead               byte = 0x64
+	firmwareQuery         byte = 0x79
+	stringData            byte = 0x71
+	i2CModeWrite          byte = 0x00
+	i2CModeRead           byte = 0x01
+)
+
+var _ io.ReadWriteCloser = (*Board)(nil)
+
+// ErrClosed is the error returned when reading from or writing to a closed Board
+var ErrClosed = errors.New("firmatatest: board is closed")
+
+var (
+	// UnoCapabilityResponse is the capability response sysex of an Arduino Uno r3
+	UnoCapabilityResponse = []byte{240, 108, 127, 127, 0, 1, 1, 1, 4, 14, 127, 0,
+		1, 1, 1, 3, 8, 4, 14, 127, 0, 1, 1, 1, 4, 14, 127, 0, 1, 1, 1, 3, 8, 4, 14,
+		127, 0, 1, 1, 1, 3, 8, 4, 14, 127, 0, 1, 1, 1, 4, 14, 127, 0, 1, 1, 1, 4,
+		14, 127, 0, 1, 1, 1, 3, 8, 4, 14, 127, 0, 1, 1, 1, 3, 8, 4, 14, 127, 0, 1,
+		1, 1, 3, 8, 4, 14, 127, 0, 1, 1, 1, 4, 14, 127, 0, 1, 1, 1, 4, 14, 127, 0,
+		1, 1, 1, 2, 10, 127, 0, 1, 1, 1, 2, 10, 127, 0, 1, 1, 1, 2, 10, 127, 0, 1,
+		1, 1, 2, 10, 127, 0, 1, 1, 1, 2, 10, 6, 1, 127, 0, 1, 1, 1, 2, 10, 6, 1,
+		127, 247}
+	// UnoAnalogMappingResponse is the analog mapping response sysex of an Arduino Uno r3
+	UnoAnalogMappingResponse = []byte{240, 106, 127, 127, 127, 127, 127, 127, 127,
+		127, 127, 127, 127, 127, 127, 127, 0, 1, 2, 3, 4, 5, 247}
+	// MegaCapabilityResponse is the capability response sysex of an Arduino
+	// Mega 2560, which has 70 pins
+	MegaCapabilityResponse = megaCapabilityResponse()
+	// MegaAnalogMappingResponse is the analog mapping response sysex of an
+	// Arduino Mega 2560, which maps pins 54-69 to analog channels 0-15
+	MegaAnalogMappingResponse = megaAnalogMappingResponse()
+)
+
+// megaCapabilityResponse builds the capability response of a Mega 2560
+// running StandardFirmata. Pins 0 and 1 are used by the serial port.
+func megaCapabilityResponse() []byte {
+	response := []byte{startSysex, capabilityResponse}
+	for p := 0; p < 70; p++ {
+		if p >= 2 {
+			response = append(response, input, 1, output, 1)
+			if p >= 54 {
+				response = append(response, analog, 10)
+			}
+			if (p >= 2 && p <= 13) || (p >= 44 && p <= 46) {
+				response = append(response, pwm, 8)
+			}
+			response = append(response, servo, 14)
+			if p == 20 || p == 21 {
+				response = append(response, i2c, 1)
+			}
+		}
+		response = append(response, 127)
+	}
+	return append(response, endSysex)
+}
+
+// megaAnalogMappingResponse builds the analog mapping response of a Mega 2560
+func megaAnalogMappingResponse() []byte {
+	response := []byte{startSysex, analogMappingResponse}
+	for p := 0; p < 70; p++ {
+		if p >= 54 {
+			response = append(response, byte(p-54))
+		} else {
+			response = append(response, 127)
+		}
+	}
+	return append(response, endSysex)
+}
+
+type pin struct {
+	mode  byte
+	value int
+}
+
+// Board is a fake Firmata board implementing io.ReadWriteCloser. Commands
+// written to it are decoded and answered the way StandardFirmata would answer
+// them, replies are queued and handed out by Read as a stream of bytes.
+type Board struct {
+	// FirmwareName is reported in response to a firmware query
+	FirmwareName string
+	// MajorVersion and MinorVersion are the reported protocol version
+	MajorVersion byte
+	MinorVersion byte
+	// CapabilityResponse is the sysex sent in response to a capability query
+	CapabilityResponse []byte
+	// AnalogMappingResponse is the sysex sent in response to an analog mapping query
+	AnalogMappingResponse []byte
+	// ReadTimeout is how long Read waits for a reply before returning no data
+	ReadTimeout time.Duration
+
+	mutex          sync.Mutex
+	pins           map[byte]*pin
+	i2cReplies     map[byte][]byte
+	i2cRegisters   map[byte]map[int][]byte
+	i2cWrites      map[byte][][]byte
+	pingEchoes     map[byte]time.Duration
+	dhtData        map[byte][]byte
+	analogReports  map[byte]bool
+	digitalReports map[byte]bool
+	pending        [][]byte
+	written        []byte
+	notify         chan bool
+	closed         bool
+}
+
+// NewBoard returns a new Board which identifies itself as an Arduino Uno r3
+// running StandardFirmata 2.3.
+func NewBoard() *Board {
+	return &Board{
+		FirmwareName:          "StandardFirmata.ino",
+		MajorVersion:          2,
+		MinorVersion:          3,
+		CapabilityResponse:    UnoCapabilityResponse,
+		AnalogMappingResponse: UnoAnalogMappingResponse,
+		ReadTimeout:           10 * time.Millisecond,
+		pins:                  make(map[byte]*pin),
+		i2cReplies:            make(map[byte][]byte),
+		i2cRegisters:          make(map[byte]map[int][]byte),
+		i2cWrites:             make(map[byte][][]byte),
+		pingEchoes:            make(map[byte]time.Duration),
+		dhtData:               make(map[byte][]byte),
+		analogReports:         make(map[byte]bool),
+		digitalReports:        make(map[byte]bool),
+		notify:                make(chan bool, 1),
+	}
+}
+
+// SetPinValue programs the value the board reads on pin. If reporting is
+// enabled for the pin's port or analog channel, the new value is reported.
+func (b *Board) SetPinValue(pin byte, value int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.pin(pin).value = value
+	if channel, ok := b.analogChannel(pin); ok && b.analogReports[channel] {
+		b.queueAnalog(channel, value)
+	}
+	if b.digitalReports[pin/8] {
+		b.queueDigitalPort(pin / 8)
+	}
+}
+
+// PinValue returns the value of pin, as last set by SetPinValue or written
+// by the client.
+func (b *Board) PinValue(pin byte) int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.pin(pin).value
+}
+
+// PinMode returns the mode the client last set on pin.
+func (b *Board) PinMode(pin byte) byte {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.pin(pin).mode
+}
+
+// SetI2cReply programs the data returned by i2c read requests to address.
+// Like StandardFirmata, the board answers reads of more bytes than it has,
+// e.g. of an address without a reply, where there is no device, with the
+// string "I2C: Too few bytes received" and a reply with the bytes it has.
+func (b *Board) SetI2cReply(address byte, data []byte) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.i2cReplies[address] = data
+}
+
+// SetI2cRegister programs the data returned by i2c read requests for
+// register of the device at address.
+func (b *Board) SetI2cRegister(address byte, register byte, data []byte) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.i2cRegisters[address] == nil {
+		b.i2cRegisters[address] = make(map[int][]byte)
+	}
+	b.i2cRegisters[address][int(register)] = data
+}
+
+// SetPingEcho programs the echo the board times in answer to ping sysex
+// requests on pin, 0 for no echo. Requests on pins without an echo are not
+// answered, like StandardFirmata which lacks the ping sysex.
+func (b *Board) SetPingEcho(pin byte, echo time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.pingEchoes[pin] = echo
+}
+
+// SetDHTData programs the bytes the board reads from a DHT sensor in answer
+// to DHT sysex requests on pin. Requests on pins without data are not
+// answered, like StandardFirmata which lacks the DHT sysex.
+func (b *Board) SetDHTData(pin byte, data []byte) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.dhtData[pin] = data
+}
+
+// I2cWrites returns every i2c write request sent to address, in order.
+func (b *Board) I2cWrites(address byte) [][]byte {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.i2cWrites[address]
+}
+
+// Written returns every byte written to the board by the client.
+func (b *Board) Written() []byte {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]byte{}, b.written...)
+}
+
+// Queue queues raw bytes to be read by the client as a single message.
+func (b *Board) Queue(message []byte) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.queue(message)
+}
+
+// Closed returns true if Close has been called.
+func (b *Board) Closed() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.closed
+}
+
+// Write decodes the Firmata commands in p and queues the board's replies.
+func (b *Board) Write(p []byte) (n int, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		return 0, ErrClosed
+	}
+	b.written = append(b.written, p...)
+	b.process(p)
+	return len(p), nil
+}
+
+// Read copies the queued messages into p, the part of a message which does
+// not fit is kept for the next Read. If no message is queued within
+// ReadTimeout, Read returns 0 bytes.
+func (b *Board) Read(p []byte) (n int, err error) {
+	select {
+	case <-b.notify:
+	case <-time.After(b.ReadTimeout):
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		return 0, ErrClosed
+	}
+	for len(b.pending) > 0 && n < len(p) {
+		copied := copy(p[n:], b.pending[0])
+		n += copied
+		if b.pending[0] = b.pending[0][copied:]; len(b.pending[0]) == 0 {
+			b.pending = b.pending[1:]
+		}
+	}
+	if len(b.pending) > 0 {
+		b.signal()
+	}
+	return
+}
+
+// Close closes the board, subsequent reads and writes return ErrClosed.
+func (b *Board) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.closed = true
+	b.signal()
+	return nil
+}
+
+// process decodes every message in data
+func (b *Board) process(data []byte) {
+	buf := bytes.NewBuffer(data)
+	for {
+		messageType, err := buf.ReadByte()
+		if err != nil {
+			return
+		}
+		switch {
+		case messageType == systemReset:
+			b.analogReports = make(map[byte]bool)
+			b.digitalReports = make(map[byte]bool)
+			// like an Arduino rebooting, announce the version and firmware
+			b.queue([]byte{reportVersion, b.MajorVersion, b.MinorVersion})
+			b.queue(b.firmwareResponse())
+		case messageType == reportVersion:
+			b.queue([]byte{reportVersion, b.MajorVersion, b.MinorVersion})
+		case messageType == pinMode:
+			pin, mode := next(buf), next(buf)
+			b.pin(pin).mode = mode
+			// StandardFirmata starts reporting an analog pin once it is put in
+			// analog mode
+			if channel, ok := b.analogChannel(pin); ok {
+				b.analogReports[channel] = mode == analog
+				if mode == analog {
+					b.queueAnalog(channel, b.pin(pin).value)
+				}
+			}
+		case messageType&0xF0 == digitalMessage:
+			port := messageType & 0x0F
+			value := int(next(buf)) | int(next(buf))<<7
+			for i := byte(0); i < 8; i++ {
+				b.pin(8*port + i).value = (value >> i) & 0x01
+			}
+		case messageType&0xF0 == analogMessage:
+			b.pin(messageType & 0x0F).value = int(next(buf)) | int(next(buf))<<7
+		case messageType&0xF0 == reportAnalog:
+			channel := messageType & 0x0F
+			b.analogReports[channel] = next(buf) != 0
+			if b.analogReports[channel] {
+				if pin, ok := b.analogPin(channel); ok {
+					b.queueAnalog(channel, b.pin(pin).value)
+				}
+			}
+		case messageType&0xF0 == reportDigital:
+			port := messageType & 0x0F
+			b.digitalReports[port] = next(buf) != 0
+			if b.digitalReports[port] {
+				b.queueDigitalPort(port)
+			}
+		case messageType == startSysex:
+			sysex := []byte{}
+			for {
+				c, err := buf.ReadByte()
+				if err != nil || c == endSysex {
+					break
+				}
+				sysex = append(sysex, c)
+			}
+			b.processSysex(sysex)
+		}
+	}
+}
+
+// processSysex answers a sysex command, data excludes the start and end bytes
+func (b *Board) processSysex(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	switch data[0] {
+	case firmwareQuery:
+		b.queue(b.firmwareResponse())
+	case capabilityQuery:
+		b.queue(b.CapabilityResponse)
+	case analogMappingQuery:
+		b.queue(b.AnalogMappingResponse)
+	case extendedAnalog:
+		if len(data) < 3 {
+			return
+		}
+		value := 0
+		for i, val := range data[2:] {
+			value |= int(val&0x7F) << (7 * uint(i))
+		}
+		b.pin(data[1]).value = value
+	case pinStateQuery:
+		if len(data) < 2 {
+			return
+		}
+		p := b.pin(data[1])
+		b.queue([]byte{startSysex, pinStateResponse, data[1], p.mode,
+			byte(p.value & 0x7F), byte((p.value >> 7) & 0x7F), endSysex})
+	case pingRead:
+		if len(data) < 2 {
+			return
+		}
+		echo, ok := b.pingEchoes[data[1]]
+		if !ok {
+			return
+		}
+		micros := uint32(echo / time.Microsecond)
+		message := []byte{startSysex, pingRead, data[1] & 0x7F, data[1] >> 7}
+		for _, val := range []byte{byte(micros >> 24), byte(micros >> 16), byte(micros >> 8), byte(micros)} {
+			message = append(message, val&0x7F, val>>7)
+		}
+		b.queue(append(message, endSysex))
+	case dhtRead:
+		if len(data) < 3 {
+			return
+		}
+		reading, ok := b.dhtData[data[1]]
+		if !ok {
+			return
+		}
+		message := []byte{startSysex, dhtRead, data[1]}
+		for _, val := range reading {
+			message = append(message, val&0x7F, val>>7)
+		}
+		b.queue(append(message, endSysex))
+	case i2CRequest:
+		if len(data) < 3 {
+			return
+		}
+		address := data[1]
+		switch (data[2] >> 3) & 0x03 {
+		case i2CModeWrite:
+			b.i2cWrites[address] = append(b.i2cWrites[address], decode(data[3:]))
+		case i2CModeRead:
+			size, register := 0, -1
+			switch {
+			case len(data) >= 7:
+				// a read request which names the register to read
+				register = int(data[3]) | int(data[4])<<7
+				size = int(data[5]) | int(data[6])<<7
+			case len(data) >= 5:
+				size = int(data[3]) | int(data[4])<<7
+			}
+			reply := b.i2cReplies[address]
+			if data, found := b.i2cRegisters[address][register]; found {
+				reply = data
+			}
+			if len(reply) > size {
+				reply = reply[:size]
+			} else if len(reply) < size {
+				b.queueString("I2C: Too few bytes received")
+			}
+			if register < 0 {
+				register = 0
+			}
+			message := []byte{startSysex, i2CReply, address & 0x7F, address >> 7,
+				byte(register & 0x7F), byte(register >> 7)}
+			for _, val := range reply {
+				message = append(message, val&0x7F, val>>7)
+			}
+			b.queue(append(message, endSysex))
+		}
+	}
+}
+
+// queueString queues str as string data
+func (b *Board) queueString(str string) {
+	message := []byte{startSysex, stringData}
+	for _, c := range []byte(str) {
+		message = append(message, c&0x7F, c>>7)
+	}
+	b.queue(append(message, endSysex))
+}
+
+// firmwareResponse returns the firmware query reply sysex
+func (b *Board) firmwareResponse() []byte {
+	response := []byte{startSysex, firmwareQuery, b.MajorVersion, b.MinorVersion}
+	for _, c := range []byte(b.FirmwareName) {
+		response = append(response, c&0x7F, c>>7)
+	}
+	return append(response, endSysex)
+}
+
+// pin returns the state of pin, creating it if needed
+func (b *Board) pin(number byte) *pin {
+	p, ok := b.pins[number]
+	if !ok {
+		p = &pin{}
+		b.pins[number] = p
+	}
+	return p
+}
+
+// analogChannel returns the analog channel pin is mapped to
+func (b *Board) analogChannel(pin byte) (channel byte, ok bool) {
+	mapping := b.analogMapping()
+	if int(pin) < len(mapping) && mapping[pin] != 127 {
+		return mapping[pin], true
+	}
+	return 0, false
+}
+
+// analogPin returns the pin mapped to analog channel
+func (b *Board) analogPin(channel byte) (pin byte, ok bool) {
+	for i, c := range b.analogMapping() {
+		if c == channel {
+			return byte(i), true
+		}
+	}
+	return 0, false
+}
+
+// analogMapping returns the pin to channel mapping of AnalogMappingResponse
+func (b *Board) analogMapping() []byte {
+	if len(b.AnalogMappingResponse) < 3 {
+		return []byte{}
+	}
+	return b.AnalogMappingResponse[2 : len(b.AnalogMappingResponse)-1]
+}
+
+// queueAnalog queues an analog message for channel
+func (b *Board) queueAnalog(channel byte, value int) {
+	b.queue([]byte{analogMessage | channel, byte(value & 0x7F), byte((value >> 7) & 0x7F)})
+}
+
+// queueDigitalPort queues a digital message with the value of the input pins of port
+func (b *Board) queueDigitalPort(port byte) {
+	value := 0
+	for i := byte(0); i < 8; i++ {
+		if p := b.pin(8*port + i); p.mode == input && p.value != 0 {
+			value |= 1 << i
+		}
+	}
+	b.queue([]byte{digitalMessage | port, byte(value & 0x7F), byte((value >> 7) & 0x7F)})
+}
+
+// queue appends message to the pending replies and wakes up a waiting Read
+func (b *Board) queue(message []byte) {
+	b.pending = append(b.pending, append([]byte{}, message...))
+	b.signal()
+}
+
+// signal wakes up a waiting Read without blocking
+func (b *Board) signal() {
+	select {
+	case b.notify <- true:
+	default:
+	}
+}
+
+// next returns the next byte of buf, or 0 if buf is empty
+func next(buf *bytes.Buffer) byte {
+	c, _ := buf.ReadByte()
+	return c
+}
+
+// decode joins 7-bit byte pairs back into bytes
+func decode(data []byte) []byte {
+	ret := []byte{}
+	for i := 0; i+1 < len(data); i += 2 {
+		ret = append(ret, data[i]|data[i+1]<<7)
+	}
+	return ret
+}
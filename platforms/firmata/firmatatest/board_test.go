@@ -0,0 +1,128 @@
+package firmatatest
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func read(b *Board) []byte {
+	buf := make([]byte, 1024)
+	n, _ := b.Read(buf)
+	return buf[:n]
+}
+
+func TestBoardHandshake(t *testing.T) {
+	b := NewBoard()
+	b.Write([]byte{systemReset})
+	gobot.Assert(t, read(b)[:3], []byte{reportVersion, 2, 3})
+
+	b.Write([]byte{reportVersion})
+	gobot.Assert(t, read(b), []byte{reportVersion, 2, 3})
+
+	b.Write([]byte{startSysex, capabilityQuery, endSysex})
+	gobot.Assert(t, read(b), UnoCapabilityResponse)
+
+	b.Write([]byte{startSysex, analogMappingQuery, endSysex})
+	gobot.Assert(t, read(b), UnoAnalogMappingResponse)
+
+	gobot.Assert(t, b.Written()[0], systemReset)
+}
+
+func TestBoardFirmwareQuery(t *testing.T) {
+	b := NewBoard()
+	b.FirmwareName = "Ab"
+	b.Write([]byte{startSysex, firmwareQuery, endSysex})
+	gobot.Assert(t, read(b),
+		[]byte{startSysex, firmwareQuery, 2, 3, 'A', 0, 'b', 0, endSysex})
+}
+
+func TestBoardDigital(t *testing.T) {
+	b := NewBoard()
+	b.Write([]byte{digitalMessage | 1, 0x05, 0x00})
+	gobot.Assert(t, b.PinValue(8), 1)
+	gobot.Assert(t, b.PinValue(9), 0)
+	gobot.Assert(t, b.PinValue(10), 1)
+
+	b.Write([]byte{pinMode, 2, input})
+	gobot.Assert(t, b.PinMode(2), input)
+	b.SetPinValue(2, 1)
+	b.Write([]byte{reportDigital | 0, 1})
+	gobot.Assert(t, read(b), []byte{digitalMessage, 0x04, 0x00})
+
+	b.SetPinValue(2, 0)
+	gobot.Assert(t, read(b), []byte{digitalMessage, 0x00, 0x00})
+}
+
+func TestBoardAnalog(t *testing.T) {
+	b := NewBoard()
+	b.SetPinValue(15, 675)
+	b.Write([]byte{pinMode, 15, analog})
+	gobot.Assert(t, read(b), []byte{analogMessage | 1, 0x23, 0x05})
+
+	b.Write([]byte{analogMessage | 3, 0x7F, 0x01})
+	gobot.Assert(t, b.PinValue(3), 255)
+}
+
+func TestBoardPinState(t *testing.T) {
+	b := NewBoard()
+	b.Write([]byte{pinMode, 13, 1, digitalMessage | 1, 0x20, 0x00})
+	b.Write([]byte{startSysex, pinStateQuery, 13, endSysex})
+	gobot.Assert(t, read(b), []byte{startSysex, pinStateResponse, 13, 1, 1, 0, endSysex})
+}
+
+func TestBoardI2c(t *testing.T) {
+	b := NewBoard()
+	b.SetI2cReply(0x21, []byte{0x01, 0xFF, 0x03})
+	b.Write([]byte{startSysex, i2CRequest, 0x21, i2CModeRead << 3, 2, 0, endSysex})
+	gobot.Assert(t, read(b),
+		[]byte{startSysex, i2CReply, 0x21, 0, 0, 0, 0x01, 0, 0x7F, 1, endSysex})
+
+	b.SetI2cRegister(0x21, 0x0F, []byte{0x33})
+	b.Write([]byte{startSysex, i2CRequest, 0x21, i2CModeRead << 3, 0x0F, 0, 1, 0, endSysex})
+	gobot.Assert(t, read(b),
+		[]byte{startSysex, i2CReply, 0x21, 0, 0x0F, 0, 0x33, 0, endSysex})
+
+	// no device at 0x22
+	b.Write([]byte{startSysex, i2CRequest, 0x22, i2CModeRead << 3, 0, 0, endSysex})
+	gobot.Assert(t, read(b), []byte{startSysex, i2CReply, 0x22, 0, 0, 0, endSysex})
+	b.Write([]byte{startSysex, i2CRequest, 0x22, i2CModeRead << 3, 1, 0, endSysex})
+	reply := read(b)
+	gobot.Assert(t, reply[:4], []byte{startSysex, 0x71, 'I', 0})
+	gobot.Assert(t, reply[len(reply)-7:], []byte{startSysex, i2CReply, 0x22, 0, 0, 0, endSysex})
+
+	b.Write([]byte{startSysex, i2CRequest, 0x21, i2CModeWrite << 3, 'A', 0, endSysex})
+	gobot.Assert(t, b.I2cWrites(0x21), [][]byte{[]byte{'A'}})
+}
+
+func TestBoardRead(t *testing.T) {
+	b := NewBoard()
+	gobot.Assert(t, len(read(b)), 0)
+
+	b.Queue([]byte{1, 2})
+	b.Queue([]byte{3, 4})
+	buf := make([]byte, 3)
+	n, _ := b.Read(buf)
+	gobot.Assert(t, buf[:n], []byte{1, 2, 3})
+	n, _ = b.Read(buf)
+	gobot.Assert(t, buf[:n], []byte{4})
+
+	// a message longer than p is read over several reads
+	b.Queue([]byte{startSysex, firmwareQuery, 2, 3, 'A', 0, 'b', 0, endSysex})
+	n, _ = b.Read(buf)
+	gobot.Assert(t, buf[:n], []byte{startSysex, firmwareQuery, 2})
+	n, _ = b.Read(buf)
+	gobot.Assert(t, buf[:n], []byte{3, 'A', 0})
+	n, _ = b.Read(buf)
+	gobot.Assert(t, buf[:n], []byte{'b', 0, endSysex})
+}
+
+func TestBoardClose(t *testing.T) {
+	b := NewBoard()
+	gobot.Assert(t, b.Close(), nil)
+	gobot.Assert(t, b.Closed(), true)
+	_, err := b.Write([]byte{reportVersion})
+	gobot.Assert(t, err, ErrClosed)
+	_, err = b.Read(make([]byte, 1))
+	gobot.Assert(t, err, ErrClosed)
+}
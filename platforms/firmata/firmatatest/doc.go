@@ -0,0 +1,29 @@
+/*
+Package firmatatest provides a scriptable fake Firmata board for testing code
+built on top of the firmata adaptor without any hardware attached.
+
+Example:
+
+	board := firmatatest.NewBoard()
+	board.SetPinValue(2, 1)
+	board.SetI2cReply(0x1E, []byte{0x01, 0x02})
+
+	adaptor := firmata.NewFirmataAdaptor("arduino", board)
+	adaptor.Connect()
+
+	// ... exercise drivers using adaptor ...
+
+	board.PinValue(13) // value last written to pin 13
+
+A Recorder logs the session with a real board to a file, which a Replayer can
+later play back in place of the board to turn a field bug into a regression
+test:
+
+	session, _ := os.Open("session.log")
+	replayer, _ := firmatatest.NewReplayer(session)
+	adaptor := firmata.NewFirmataAdaptor("arduino", replayer)
+
+For further information refer to firmata readme:
+https://github.com/hybridgroup/gobot/blob/master/platforms/firmata/README.md
+*/
+package firmatatest
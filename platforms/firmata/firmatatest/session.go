@@ -0,0 +1,225 @@
+package firmatatest
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	directionWrite = "w"
+	directionRead  = "r"
+)
+
+var _ io.ReadWriteCloser = (*Recorder)(nil)
+var _ io.ReadWriteCloser = (*Replayer)(nil)
+
+// Recorder wraps the connection to a real board and logs every byte
+// exchanged with it. Each chunk is logged as a line holding the time elapsed
+// since the Recorder was created in nanoseconds, the direction ("w" for
+// bytes written to the board, "r" for bytes read from it) and the bytes in
+// hex:
+//
+//	1502000 w f9
+//	1873000 r f90203
+//
+// A recorded session can be played back with a Replayer.
+type Recorder struct {
+	conn  io.ReadWriteCloser
+	log   io.Writer
+	start time.Time
+	mutex sync.Mutex
+}
+
+// NewRecorder returns a new Recorder which logs the session on conn to log.
+//
+// Example:
+//
+//	port, _ := serial.OpenPort(&serial.Config{Name: "/dev/ttyACM0", Baud: 57600})
+//	session, _ := os.Create("session.log")
+//	firmataAdaptor := firmata.NewFirmataAdaptor("arduino", firmatatest.NewRecorder(port, session))
+func NewRecorder(conn io.ReadWriteCloser, log io.Writer) *Recorder {
+	return &Recorder{
+		conn:  conn,
+		log:   log,
+		start: time.Now(),
+	}
+}
+
+// Write writes p to the board and logs the bytes written.
+func (r *Recorder) Write(p []byte) (n int, err error) {
+	n, err = r.conn.Write(p)
+	r.record(directionWrite, p[:n])
+	return
+}
+
+// Read reads from the board and logs the bytes read.
+func (r *Recorder) Read(p []byte) (n int, err error) {
+	n, err = r.conn.Read(p)
+	r.record(directionRead, p[:n])
+	return
+}
+
+// Close closes the board connection, and the log if it is an io.Closer.
+func (r *Recorder) Close() error {
+	err := r.conn.Close()
+	if closer, ok := r.log.(io.Closer); ok {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// record logs data sent in direction
+func (r *Recorder) record(direction string, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	fmt.Fprintf(r.log, "%d %s %x\n", time.Since(r.start).Nanoseconds(), direction, data)
+}
+
+// replayRead is a recorded read which is replayed once after bytes have been
+// written
+type replayRead struct {
+	after int
+	data  []byte
+}
+
+// Replayer plays back the board side of a session logged by a Recorder.
+// Recorded reads are only handed out once the client has written everything
+// it wrote before them in the recording, so replies never arrive before the
+// request that caused them. Writes which diverge from the recording fail.
+type Replayer struct {
+	// ReadTimeout is how long Read waits for the client to catch up with
+	// the recording before returning no data
+	ReadTimeout time.Duration
+
+	expected []byte
+	written  int
+	reads    []replayRead
+	mutex    sync.Mutex
+	notify   chan bool
+	closed   bool
+}
+
+// NewReplayer returns a new Replayer given a session logged by a Recorder.
+func NewReplayer(session io.Reader) (*Replayer, error) {
+	r := &Replayer{
+		ReadTimeout: 10 * time.Millisecond,
+		expected:    []byte{},
+		reads:       []replayRead{},
+		notify:      make(chan bool, 1),
+	}
+
+	scanner := bufio.NewScanner(session)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var elapsed int64
+		var direction, data string
+		if _, err := fmt.Sscanf(scanner.Text(), "%d %s %s", &elapsed, &direction, &data); err != nil {
+			return nil, fmt.Errorf("session line %v: %v", line, err)
+		}
+		buf, err := hex.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("session line %v: %v", line, err)
+		}
+		switch direction {
+		case directionWrite:
+			r.expected = append(r.expected, buf...)
+		case directionRead:
+			r.reads = append(r.reads, replayRead{after: len(r.expected), data: buf})
+		default:
+			return nil, fmt.Errorf("session line %v: unknown direction %q", line, direction)
+		}
+	}
+	return r, scanner.Err()
+}
+
+// Write compares p with the writes in the recording and returns an error
+// describing the first differing byte.
+func (r *Replayer) Write(p []byte) (n int, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.closed {
+		return 0, ErrClosed
+	}
+	for n = range p {
+		if r.written >= len(r.expected) {
+			return n, fmt.Errorf("replay: unexpected write 0x%x past the end of the recording", p[n:])
+		}
+		if p[n] != r.expected[r.written] {
+			return n, fmt.Errorf("replay: wrote 0x%x at offset %v, recording has 0x%x",
+				p[n], r.written, r.expected[r.written])
+		}
+		r.written++
+	}
+	r.signal()
+	return len(p), nil
+}
+
+// Read returns the next recorded read once the client has caught up with the
+// recording. Returns io.EOF once every recorded read has been replayed.
+func (r *Replayer) Read(p []byte) (n int, err error) {
+	timeout := time.After(r.ReadTimeout)
+	for {
+		r.mutex.Lock()
+		if r.closed {
+			r.mutex.Unlock()
+			return 0, ErrClosed
+		}
+		if len(r.reads) == 0 {
+			r.mutex.Unlock()
+			return 0, io.EOF
+		}
+		if r.written >= r.reads[0].after {
+			n = copy(p, r.reads[0].data)
+			if n < len(r.reads[0].data) {
+				r.reads[0].data = r.reads[0].data[n:]
+			} else {
+				r.reads = r.reads[1:]
+			}
+			r.mutex.Unlock()
+			return
+		}
+		r.mutex.Unlock()
+
+		select {
+		case <-r.notify:
+		case <-timeout:
+			return 0, nil
+		}
+	}
+}
+
+// Done returns true once every recorded write and read has been replayed.
+func (r *Replayer) Done() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.written == len(r.expected) && len(r.reads) == 0
+}
+
+// Close closes the Replayer, subsequent reads and writes return ErrClosed.
+func (r *Replayer) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.closed = true
+	r.signal()
+	return nil
+}
+
+// signal wakes up a waiting Read without blocking
+func (r *Replayer) signal() {
+	select {
+	case r.notify <- true:
+	default:
+	}
+}
@@ -0,0 +1,80 @@
+package firmatatest
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestRecorder(t *testing.T) {
+	var session bytes.Buffer
+	r := NewRecorder(NewBoard(), &session)
+
+	r.Write([]byte{reportVersion})
+	buf := make([]byte, 1024)
+	n, _ := r.Read(buf)
+	gobot.Assert(t, buf[:n], []byte{reportVersion, 2, 3})
+
+	lines := strings.Split(strings.TrimSpace(session.String()), "\n")
+	gobot.Assert(t, len(lines), 2)
+	gobot.Assert(t, strings.Fields(lines[0])[1:], []string{"w", "f9"})
+	gobot.Assert(t, strings.Fields(lines[1])[1:], []string{"r", "f90203"})
+	gobot.Assert(t, r.Close(), nil)
+}
+
+func TestReplayer(t *testing.T) {
+	r, err := NewReplayer(strings.NewReader("10 w f9\n20 r f90203\n\n30 w ff\n"))
+	gobot.Assert(t, err, nil)
+
+	// the reply is held back until the request is written
+	buf := make([]byte, 1024)
+	n, _ := r.Read(buf)
+	gobot.Assert(t, n, 0)
+
+	r.Write([]byte{reportVersion})
+	n, _ = r.Read(buf)
+	gobot.Assert(t, buf[:n], []byte{reportVersion, 2, 3})
+	gobot.Assert(t, r.Done(), false)
+
+	_, err = r.Write([]byte{reportVersion})
+	gobot.Refute(t, err, nil)
+	_, err = r.Write([]byte{systemReset})
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, r.Done(), true)
+
+	_, err = r.Read(buf)
+	gobot.Assert(t, err, io.EOF)
+	_, err = r.Write([]byte{systemReset})
+	gobot.Refute(t, err, nil)
+}
+
+func TestReplayerRecording(t *testing.T) {
+	var session bytes.Buffer
+	rec := NewRecorder(NewBoard(), &session)
+	rec.Write([]byte{startSysex, capabilityQuery, endSysex})
+	rec.Read(make([]byte, 1024))
+
+	r, err := NewReplayer(&session)
+	gobot.Assert(t, err, nil)
+	r.Write([]byte{startSysex, capabilityQuery, endSysex})
+	buf := make([]byte, 1024)
+	n, _ := r.Read(buf)
+	gobot.Assert(t, buf[:n], UnoCapabilityResponse)
+}
+
+func TestReplayerErrors(t *testing.T) {
+	_, err := NewReplayer(strings.NewReader("10 x f9\n"))
+	gobot.Refute(t, err, nil)
+	_, err = NewReplayer(strings.NewReader("10 w zz\n"))
+	gobot.Refute(t, err, nil)
+	_, err = NewReplayer(strings.NewReader("w f9\n"))
+	gobot.Refute(t, err, nil)
+
+	r, _ := NewReplayer(strings.NewReader("10 r f9\n"))
+	r.Close()
+	_, err = r.Read(make([]byte, 1))
+	gobot.Assert(t, err, ErrClosed)
+}
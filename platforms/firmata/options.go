@@ -0,0 +1,135 @@
+package firmata
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+var (
+	// ErrHandshakeTimeout is returned when connecting to a board which does
+	// not complete the handshake within the timeout set with
+	// WithHandshakeTimeout.
+	ErrHandshakeTimeout = errors.New("firmata: handshake timed out")
+	// ErrWriteQueueFull is returned by writes when the write queue is full
+	// and its policy is QueueError.
+	ErrWriteQueueFull = errors.New("firmata: write queue is full")
+	// ErrWriteQueueClosed is returned by writes after the board has been
+	// disconnected.
+	ErrWriteQueueClosed = errors.New("firmata: write queue is closed")
+)
+
+// WriteQueuePolicy decides what a write does when the write queue is full
+type WriteQueuePolicy int
+
+const (
+	// QueueBlock waits until there is room in the queue
+	QueueBlock WriteQueuePolicy = iota
+	// QueueDrop discards the write
+	QueueDrop
+	// QueueError fails the write with ErrWriteQueueFull
+	QueueError
+)
+
+// Option configures how a FirmataAdaptor talks to its board. Options are
+// passed to NewFirmataAdaptor along with the port or connection.
+type Option func(*board)
+
+// WithRetryInterval sets how long to wait for a reply before querying the
+// report version again during the handshake. Defaults to 1 second.
+func WithRetryInterval(interval time.Duration) Option {
+	return func(b *board) {
+		b.initTimeInterval = interval
+	}
+}
+
+// WithLogger logs the handshake and unexpected data received from the board
+// to logger. By default they are logged through gobot.Log at
+// gobot.DebugLevel.
+func WithLogger(logger *log.Logger) Option {
+	return func(b *board) {
+		b.logger = logger
+	}
+}
+
+// WithoutAutoReporting stops the digital reporting of ports 0 and 1 from
+// being turned on once connected. Reporting is still turned on for the
+// pins read with DigitalRead and AnalogRead.
+func WithoutAutoReporting() Option {
+	return func(b *board) {
+		b.autoReporting = false
+	}
+}
+
+// WithHandshakeTimeout makes connecting fail with ErrHandshakeTimeout if
+// the board has not completed the handshake after timeout. By default
+// connecting waits until the board answers.
+func WithHandshakeTimeout(timeout time.Duration) Option {
+	return func(b *board) {
+		b.handshakeTimeout = timeout
+	}
+}
+
+// WithProfile skips the capability and analog mapping queries when
+// connecting and seeds the pin table from profile instead. The board is
+// connected as soon as it reports its version, which is faster on slow
+// boards and works with firmwares which do not answer those queries.
+func WithProfile(profile BoardProfile) Option {
+	return func(b *board) {
+		b.profile = &profile
+	}
+}
+
+// WithRawStrings sends and receives string data one byte per character, for
+// firmwares which do not split characters into two 7-bit bytes.
+func WithRawStrings() Option {
+	return func(b *board) {
+		b.rawStrings = true
+	}
+}
+
+// WithWriteInterval keeps at least interval between the messages written to
+// the board, for boards which drop bytes when commands are sent back to
+// back, e.g. clones with a CH340 serial chip.
+func WithWriteInterval(interval time.Duration) Option {
+	return func(b *board) {
+		b.writeInterval = interval
+	}
+}
+
+// RequireVersion makes connecting fail if the board speaks a protocol
+// version older than major.minor, e.g. when a feature needs a newer sketch.
+func RequireVersion(major byte, minor byte) Option {
+	return func(b *board) {
+		b.requiredVersion = &Version{Major: major, Minor: minor}
+	}
+}
+
+// WithDigitalWriteWindow coalesces the digital writes to pins of the same
+// port made within window into a single digital message, which is sent
+// once the window has passed. Pending writes are sent when disconnecting.
+func WithDigitalWriteWindow(window time.Duration) Option {
+	return func(b *board) {
+		b.writeWindow = window
+	}
+}
+
+// WithAnalogDeadband only publishes the "analog_read" events of channel when
+// the value has changed by more than counts since the last published value,
+// so noisy inputs do not flood the event bus.
+func WithAnalogDeadband(channel byte, counts int) Option {
+	return func(b *board) {
+		b.deadbands[channel] = counts
+	}
+}
+
+// WithWriteQueue sends commands from a writer goroutine through a queue
+// holding up to capacity writes, so a stalled serial port does not block
+// the code calling DigitalWrite and friends. policy decides what happens
+// to writes while the queue is full.
+func WithWriteQueue(capacity int, policy WriteQueuePolicy) Option {
+	return func(b *board) {
+		b.queueCapacity = capacity
+		b.queuePolicy = policy
+	}
+}
@@ -0,0 +1,39 @@
+package firmata
+
+// BoardProfile describes the pins of a board. Connecting with WithProfile
+// seeds the pin table from the profile instead of querying the board.
+type BoardProfile struct {
+	// Pins is the number of pins of the board
+	Pins int
+	// AnalogPins maps analog channels to pin numbers
+	AnalogPins map[byte]byte
+	// Features are the features of the firmware running on the board
+	Features Features
+}
+
+// standardFirmataFeatures are the features of StandardFirmata
+const standardFirmataFeatures = FeatureAnalog | FeaturePwm | FeatureServo | FeatureI2c
+
+var (
+	// UnoProfile is the BoardProfile of an Arduino Uno r3
+	UnoProfile = BoardProfile{
+		Pins:       20,
+		AnalogPins: analogPinRange(14, 6),
+		Features:   standardFirmataFeatures,
+	}
+	// MegaProfile is the BoardProfile of an Arduino Mega 2560
+	MegaProfile = BoardProfile{
+		Pins:       70,
+		AnalogPins: analogPinRange(54, 16),
+		Features:   standardFirmataFeatures,
+	}
+)
+
+// analogPinRange maps count analog channels to consecutive pins starting at first
+func analogPinRange(first byte, count byte) map[byte]byte {
+	pins := make(map[byte]byte)
+	for channel := byte(0); channel < count; channel++ {
+		pins[channel] = first + channel
+	}
+	return pins
+}
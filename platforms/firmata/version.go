@@ -0,0 +1,26 @@
+package firmata
+
+import "fmt"
+
+// Version is the protocol and firmware version reported by a board
+type Version struct {
+	// Major and Minor are the Firmata protocol version
+	Major byte
+	Minor byte
+	// FirmwareName is the name of the sketch running on the board
+	FirmwareName string
+	// FirmwareMajor and FirmwareMinor are the version of the sketch
+	FirmwareMajor byte
+	FirmwareMinor byte
+}
+
+// AtLeast returns true if the protocol version is major.minor or newer
+func (v Version) AtLeast(major byte, minor byte) bool {
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
+
+// String returns the firmware name and version followed by the protocol version
+func (v Version) String() string {
+	return fmt.Sprintf("%v %v.%v (protocol %v.%v)",
+		v.FirmwareName, v.FirmwareMajor, v.FirmwareMinor, v.Major, v.Minor)
+}
@@ -63,7 +63,7 @@ func (a *AnalogSensorDriver) Start() (errs []error) {
 		for {
 			newValue, err := a.Read()
 			if err != nil {
-				gobot.Publish(a.Event(Error), err)
+				gobot.Publish(a.Event(Error), gobot.NewDeviceError(a.Name(), "AnalogRead", err, true))
 			} else if newValue != value && newValue != -1 {
 				value = newValue
 				gobot.Publish(a.Event(Data), value)
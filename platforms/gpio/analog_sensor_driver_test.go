@@ -50,7 +50,10 @@ func TestAnalogSensorDriverStart(t *testing.T) {
 	}
 
 	gobot.Once(d.Event(Error), func(data interface{}) {
-		gobot.Assert(t, data.(error).Error(), "read error")
+		err := data.(*gobot.DeviceError)
+		gobot.Assert(t, err.Op, "AnalogRead")
+		gobot.Assert(t, err.Err.Error(), "read error")
+		gobot.Assert(t, err.Retryable, true)
 		sem <- true
 	})
 
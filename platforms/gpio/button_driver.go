@@ -10,29 +10,48 @@ var _ gobot.Driver = (*ButtonDriver)(nil)
 
 // ButtonDriver Represents a digital Button
 type ButtonDriver struct {
-	Active     bool
+	Active bool
+	// ActiveLow is true for a button which reads 0 when pushed, e.g. wired
+	// to ground with a pull-up resistor
+	ActiveLow bool
+	// DebounceTime is how long the button must read a new state before it
+	// changes, 0 to change on the first read
+	DebounceTime time.Duration
+	// LongPressTime is how long the button must be held for its release to
+	// be a long press
+	LongPressTime time.Duration
+	// DoublePressTime is the longest time between the presses of a double
+	// press
+	DoublePressTime time.Duration
+
 	pin        string
 	name       string
 	halt       chan bool
 	interval   time.Duration
 	connection DigitalReader
+	pressed    time.Time
+	lastPress  time.Time
 	gobot.Eventer
 }
 
 // NewButtonDriver returns a new ButtonDriver with a polling interval of
-// 10 Milliseconds given a DigitalReader, name and pin.
+// 10 Milliseconds given a DigitalReader, name and pin. Long presses last a
+// second and the presses of double presses are at most 300 Milliseconds
+// apart.
 //
 // Optinally accepts:
 //  time.Duration: Interval at which the ButtonDriver is polled for new information
 func NewButtonDriver(a DigitalReader, name string, pin string, v ...time.Duration) *ButtonDriver {
 	b := &ButtonDriver{
-		name:       name,
-		connection: a,
-		pin:        pin,
-		Active:     false,
-		Eventer:    gobot.NewEventer(),
-		interval:   10 * time.Millisecond,
-		halt:       make(chan bool),
+		name:            name,
+		connection:      a,
+		pin:             pin,
+		Active:          false,
+		LongPressTime:   time.Second,
+		DoublePressTime: 300 * time.Millisecond,
+		Eventer:         gobot.NewEventer(),
+		interval:        10 * time.Millisecond,
+		halt:            make(chan bool),
 	}
 
 	if len(v) > 0 {
@@ -41,6 +60,8 @@ func NewButtonDriver(a DigitalReader, name string, pin string, v ...time.Duratio
 
 	b.AddEvent(Push)
 	b.AddEvent(Release)
+	b.AddEvent(LongPress)
+	b.AddEvent(DoublePress)
 	b.AddEvent(Error)
 
 	return b
@@ -49,19 +70,31 @@ func NewButtonDriver(a DigitalReader, name string, pin string, v ...time.Duratio
 // Start starts the ButtonDriver and polls the state of the button at the given interval.
 //
 // Emits the Events:
-// 	Push int - On button push
+// 	Push int - On button push, also named Press
 //	Release int - On button release
+//	LongPress time.Duration - On the release of a button held for LongPressTime, with how long it was held
+//	DoublePress int - On the second push of a double press
 //	Error error - On button error
 func (b *ButtonDriver) Start() (errs []error) {
 	state := 0
+	candidate, since := 0, time.Now()
 	go func() {
 		for {
 			newValue, err := b.connection.DigitalRead(b.Pin())
 			if err != nil {
-				gobot.Publish(b.Event(Error), err)
-			} else if newValue != state && newValue != -1 {
-				state = newValue
-				b.update(newValue)
+				gobot.Publish(b.Event(Error), gobot.NewDeviceError(b.Name(), "DigitalRead", err, true))
+			} else if newValue != -1 {
+				if b.ActiveLow {
+					newValue = 1 - newValue
+				}
+				now := time.Now()
+				if newValue != candidate {
+					candidate, since = newValue, now
+				}
+				if candidate != state && now.Sub(since) >= b.DebounceTime {
+					state = candidate
+					b.update(newValue, now)
+				}
 			}
 			select {
 			case <-time.After(b.interval):
@@ -88,12 +121,23 @@ func (b *ButtonDriver) Pin() string { return b.pin }
 // Connection returns the ButtonDrivers Connection
 func (b *ButtonDriver) Connection() gobot.Connection { return b.connection.(gobot.Connection) }
 
-func (b *ButtonDriver) update(newValue int) {
+func (b *ButtonDriver) update(newValue int, now time.Time) {
 	if newValue == 1 {
 		b.Active = true
 		gobot.Publish(b.Event(Push), newValue)
+		if !b.lastPress.IsZero() && now.Sub(b.lastPress) <= b.DoublePressTime {
+			gobot.Publish(b.Event(DoublePress), newValue)
+			// a third press starts a new double press
+			b.lastPress = time.Time{}
+		} else {
+			b.lastPress = now
+		}
+		b.pressed = now
 	} else {
 		b.Active = false
 		gobot.Publish(b.Event(Release), newValue)
+		if held := now.Sub(b.pressed); !b.pressed.IsZero() && held >= b.LongPressTime {
+			gobot.Publish(b.Event(LongPress), held)
+		}
 	}
 }
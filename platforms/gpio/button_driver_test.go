@@ -2,6 +2,7 @@ package gpio
 
 import (
 	"errors"
+	"sort"
 	"testing"
 	"time"
 
@@ -99,3 +100,103 @@ func TestButtonDriverStart(t *testing.T) {
 	}
 
 }
+
+func TestButtonDriverPresses(t *testing.T) {
+	d := initTestButtonDriver()
+	gobot.Assert(t, d.LongPressTime, time.Second)
+	gobot.Assert(t, d.DoublePressTime, 300*time.Millisecond)
+
+	events := make(chan string, 10)
+	for _, name := range []string{Press, Release, LongPress, DoublePress} {
+		name := name
+		gobot.On(d.Event(name), func(data interface{}) {
+			if name == LongPress {
+				gobot.Assert(t, data.(time.Duration), 2*time.Second)
+			}
+			events <- name
+		})
+	}
+	// events of different names are not published in order
+	expect := func(names ...string) {
+		published := []string{}
+		for range names {
+			select {
+			case event := <-events:
+				published = append(published, event)
+			case <-time.After(50 * time.Millisecond):
+				t.Fatalf("Button Events %v were not published, got %v", names, published)
+			}
+		}
+		sort.Strings(names)
+		sort.Strings(published)
+		gobot.Assert(t, published, names)
+	}
+
+	now := time.Now()
+	d.update(1, now)
+	d.update(0, now.Add(2*time.Second))
+	expect(Press, Release, LongPress)
+
+	now = now.Add(time.Minute)
+	d.update(1, now)
+	d.update(0, now.Add(100*time.Millisecond))
+	d.update(1, now.Add(200*time.Millisecond))
+	expect(Press, Release, Press, DoublePress)
+	d.update(0, now.Add(300*time.Millisecond))
+	d.update(1, now.Add(400*time.Millisecond))
+	expect(Release, Press)
+	select {
+	case event := <-events:
+		t.Errorf("Button Event %q should not be published", event)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestButtonDriverDebounce(t *testing.T) {
+	sem := make(chan bool, 10)
+	reads := 0
+	testAdaptorDigitalRead = func() (val int, err error) {
+		// bounces for the first reads, then stays pushed
+		reads++
+		if reads < 6 {
+			return reads % 2, nil
+		}
+		return 1, nil
+	}
+	d := NewButtonDriver(newGpioTestAdaptor("adaptor"), "bot", "1", time.Millisecond)
+	d.DebounceTime = 5 * time.Millisecond
+	gobot.On(d.Event(Push), func(data interface{}) {
+		sem <- true
+	})
+	gobot.Assert(t, len(d.Start()), 0)
+
+	time.Sleep(50 * time.Millisecond)
+	gobot.Assert(t, len(d.Halt()), 0)
+	gobot.Assert(t, len(sem), 1)
+	gobot.Assert(t, d.Active, true)
+}
+
+func TestButtonDriverActiveLow(t *testing.T) {
+	sem := make(chan bool, 1)
+	testAdaptorDigitalRead = func() (val int, err error) {
+		return 1, nil
+	}
+	d := initTestButtonDriver()
+	d.ActiveLow = true
+	gobot.Once(d.Event(Push), func(data interface{}) {
+		sem <- true
+	})
+	gobot.Assert(t, len(d.Start()), 0)
+	time.Sleep(30 * time.Millisecond)
+	gobot.Assert(t, d.Active, false)
+
+	testAdaptorDigitalRead = func() (val int, err error) {
+		return 0, nil
+	}
+	select {
+	case <-sem:
+	case <-time.After(30 * time.Millisecond):
+		t.Errorf("Button Event \"Push\" was not published")
+	}
+	gobot.Assert(t, len(d.Halt()), 0)
+}
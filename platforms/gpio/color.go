@@ -0,0 +1,98 @@
+package gpio
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Color is a color of an RGB led, by the levels of its red, green and blue
+// channels
+type Color struct {
+	R, G, B byte
+}
+
+// Colors are the colors by the names ParseColor and the API Commands accept
+var Colors = map[string]Color{
+	"black":   {0, 0, 0},
+	"white":   {255, 255, 255},
+	"red":     {255, 0, 0},
+	"green":   {0, 255, 0},
+	"blue":    {0, 0, 255},
+	"yellow":  {255, 255, 0},
+	"cyan":    {0, 255, 255},
+	"magenta": {255, 0, 255},
+	"orange":  {255, 165, 0},
+	"purple":  {128, 0, 128},
+	"pink":    {255, 192, 203},
+	"warm":    {255, 147, 41},
+}
+
+// ParseColor returns the color named name in Colors, or written in hex as
+// "#rrggbb"
+func ParseColor(name string) (Color, error) {
+	if c, ok := Colors[strings.ToLower(name)]; ok {
+		return c, nil
+	}
+	if len(name) == 7 && name[0] == '#' {
+		if v, err := strconv.ParseUint(name[1:], 16, 32); err == nil {
+			return Color{byte(v >> 16), byte(v >> 8), byte(v)}, nil
+		}
+	}
+	return Color{}, fmt.Errorf("Unknown color %v", name)
+}
+
+// HSV returns the color of hue h, in degrees, saturation s and value v,
+// from 0 to 1
+func HSV(h float64, s float64, v float64) Color {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	s = math.Max(0, math.Min(1, s))
+	v = math.Max(0, math.Min(1, v))
+
+	chroma := v * s
+	x := chroma * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g = chroma, x
+	case h < 120:
+		r, g = x, chroma
+	case h < 180:
+		g, b = chroma, x
+	case h < 240:
+		g, b = x, chroma
+	case h < 300:
+		r, b = x, chroma
+	default:
+		r, b = chroma, x
+	}
+	m := v - chroma
+	level := func(c float64) byte { return byte(255*(c+m) + 0.5) }
+	return Color{level(r), level(g), level(b)}
+}
+
+// Blend returns the color a fraction t, from 0 to 1, of the way from c to
+// to
+func (c Color) Blend(to Color, t float64) Color {
+	level := func(from, to byte) byte {
+		return byte(float64(from) + (float64(to)-float64(from))*t + 0.5)
+	}
+	return Color{level(c.R, to.R), level(c.G, to.G), level(c.B, to.B)}
+}
+
+// Gamma returns the color with gamma correction, so that its levels are
+// perceived linearly by the eye when written to a led. A gamma of 1 leaves
+// the color as it is.
+func (c Color) Gamma(gamma float64) Color {
+	level := func(l byte) byte { return byte(255*math.Pow(float64(l)/255, gamma) + 0.5) }
+	return Color{level(c.R), level(c.G), level(c.B)}
+}
+
+// String returns the color in hex, as "#rrggbb"
+func (c Color) String() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
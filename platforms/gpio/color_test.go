@@ -0,0 +1,43 @@
+package gpio
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestParseColor(t *testing.T) {
+	c, err := ParseColor("Orange")
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, c, Color{255, 165, 0})
+	c, err = ParseColor("#0a80ff")
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, c, Color{10, 128, 255})
+	gobot.Assert(t, c.String(), "#0a80ff")
+
+	_, err = ParseColor("#0a80fg")
+	gobot.Assert(t, err, errors.New("Unknown color #0a80fg"))
+	_, err = ParseColor("chartreuse")
+	gobot.Assert(t, err, errors.New("Unknown color chartreuse"))
+}
+
+func TestHSV(t *testing.T) {
+	gobot.Assert(t, HSV(0, 1, 1), Color{255, 0, 0})
+	gobot.Assert(t, HSV(120, 1, 1), Color{0, 255, 0})
+	gobot.Assert(t, HSV(240, 1, 1), Color{0, 0, 255})
+	gobot.Assert(t, HSV(-60, 1, 1), Color{255, 0, 255})
+	gobot.Assert(t, HSV(420, 1, 1), Color{255, 255, 0})
+	gobot.Assert(t, HSV(30, 0, 0.5), Color{128, 128, 128})
+	gobot.Assert(t, HSV(0, 2, 0), Color{0, 0, 0})
+}
+
+func TestColorBlendAndGamma(t *testing.T) {
+	c := Color{0, 100, 255}
+	gobot.Assert(t, c.Blend(Color{255, 100, 0}, 0), c)
+	gobot.Assert(t, c.Blend(Color{255, 100, 0}, 0.5), Color{128, 100, 128})
+	gobot.Assert(t, c.Blend(Color{255, 100, 0}, 1), Color{255, 100, 0})
+
+	gobot.Assert(t, c.Gamma(1), c)
+	gobot.Assert(t, c.Gamma(2), Color{0, 39, 255})
+}
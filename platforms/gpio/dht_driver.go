@@ -0,0 +1,231 @@
+package gpio
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var _ gobot.Driver = (*DHTDriver)(nil)
+
+// ErrDHTChecksum is the error resulting when the bytes sent by a DHT sensor
+// do not add up to their checksum, e.g. when a bit was missed
+var ErrDHTChecksum = errors.New("DHT checksum mismatch")
+
+// DHTModel is the model of a DHT sensor
+type DHTModel byte
+
+const (
+	// DHT11 is the DHT11 sensor, which reads whole degrees and percents
+	DHT11 DHTModel = 11
+	// DHT22 is the DHT22 or AM2302 sensor, which reads tenths of them
+	DHT22 DHTModel = 22
+)
+
+// dhtBitThreshold separates the 26-28µs HIGH pulses a DHT sensor sends for 0
+// bits from the 70µs ones it sends for 1 bits
+const dhtBitThreshold = 50 * time.Microsecond
+
+// DHTDriver represents a DHT11 or DHT22 temperature and humidity sensor
+type DHTDriver struct {
+	name        string
+	pin         string
+	model       DHTModel
+	halt        chan bool
+	interval    time.Duration
+	temperature float64
+	humidity    float64
+	mutex       sync.Mutex
+	connection  gobot.Adaptor
+	gobot.Eventer
+	gobot.Commander
+}
+
+// NewDHTDriver returns a new DHTDriver with a polling interval of 2 Seconds,
+// the shortest a DHT22 supports, given a DHTReader or PulseTrainReader,
+// name, pin and model of the sensor.
+//
+// Optinally accepts:
+// 	time.Duration: Interval at which the sensor is read
+//
+// Adds the following API Commands:
+// 	"Read" - See DHTDriver.Read
+func NewDHTDriver(a gobot.Adaptor, name string, pin string, model DHTModel, v ...time.Duration) *DHTDriver {
+	d := &DHTDriver{
+		name:       name,
+		pin:        pin,
+		model:      model,
+		connection: a,
+		interval:   2 * time.Second,
+		halt:       make(chan bool),
+		Eventer:    gobot.NewEventer(),
+		Commander:  gobot.NewCommander(),
+	}
+
+	if len(v) > 0 {
+		d.interval = v[0]
+	}
+
+	d.AddEvent(Temperature)
+	d.AddEvent(Humidity)
+	d.AddEvent(Error)
+
+	d.AddCommand("Read", func(params map[string]interface{}) interface{} {
+		temperature, humidity, err := d.Read()
+		if err != nil {
+			return err
+		}
+		return map[string]interface{}{"temperature": temperature, "humidity": humidity}
+	})
+
+	return d
+}
+
+// Start starts the DHTDriver and reads the sensor at the given interval.
+//
+// Emits the Events:
+//	Temperature float64 - The temperature read, in degrees Celsius
+//	Humidity float64 - The relative humidity read, in percents
+//	Error error - On error reading the sensor
+func (d *DHTDriver) Start() (errs []error) {
+	_, dht := d.connection.(DHTReader)
+	_, train := d.connection.(PulseTrainReader)
+	if !dht && !train {
+		return []error{ErrPulseUnsupported}
+	}
+	go func() {
+		for {
+			if temperature, humidity, err := d.Read(); err != nil {
+				gobot.Publish(d.Event(Error), gobot.NewDeviceError(d.Name(), "Read", err, true))
+			} else {
+				gobot.Publish(d.Event(Temperature), temperature)
+				gobot.Publish(d.Event(Humidity), humidity)
+			}
+			select {
+			case <-time.After(d.interval):
+			case <-d.halt:
+				return
+			}
+		}
+	}()
+	return
+}
+
+// Halt stops reading the sensor
+func (d *DHTDriver) Halt() (errs []error) {
+	d.halt <- true
+	return
+}
+
+// Name returns the DHTDrivers name
+func (d *DHTDriver) Name() string { return d.name }
+
+// Pin returns the DHTDrivers pin
+func (d *DHTDriver) Pin() string { return d.pin }
+
+// Model returns the DHTDrivers sensor model
+func (d *DHTDriver) Model() DHTModel { return d.model }
+
+// Connection returns the DHTDrivers Connection
+func (d *DHTDriver) Connection() gobot.Connection { return d.connection.(gobot.Connection) }
+
+// Temperature returns the last temperature read, in degrees Celsius
+func (d *DHTDriver) Temperature() float64 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.temperature
+}
+
+// Humidity returns the last relative humidity read, in percents
+func (d *DHTDriver) Humidity() float64 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.humidity
+}
+
+// Read reads the sensor and returns the temperature in degrees Celsius and
+// the relative humidity in percents. Returns ErrDHTChecksum if the bytes
+// sent by the sensor were corrupted.
+func (d *DHTDriver) Read() (temperature float64, humidity float64, err error) {
+	data, err := d.read()
+	if err != nil {
+		return
+	}
+	if temperature, humidity, err = d.decode(data); err != nil {
+		return
+	}
+	d.mutex.Lock()
+	d.temperature, d.humidity = temperature, humidity
+	d.mutex.Unlock()
+	return
+}
+
+// read returns the five bytes sent by the sensor, read by the connection if
+// it is a DHTReader, or else by sending the start signal and timing the
+// HIGH pulse of each bit with the PulseTrainReader
+func (d *DHTDriver) read() ([]byte, error) {
+	switch c := d.connection.(type) {
+	case DHTReader:
+		return c.DHTRead(d.pin, d.model)
+	case PulseTrainReader:
+		if err := c.DigitalWrite(d.pin, 0); err != nil {
+			return nil, err
+		}
+		time.Sleep(d.startSignal())
+		// reading the pin releases the line instead of driving it HIGH, the
+		// pull-up raises it until the sensor answers with an 80µs LOW, which
+		// is waited for so that the release is not timed as a pulse
+		if _, err := c.PulseTrain(d.pin, 0, 1, time.Millisecond); err != nil {
+			return nil, err
+		}
+		// the 80µs HIGH response of the sensor, then 40 bits
+		pulses, err := c.PulseTrain(d.pin, 1, 41, time.Millisecond)
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, 5)
+		for i, pulse := range pulses[1:] {
+			if pulse > dhtBitThreshold {
+				data[i/8] |= 1 << uint(7-i%8)
+			}
+		}
+		return data, nil
+	}
+	return nil, ErrPulseUnsupported
+}
+
+// startSignal returns how long the pin is held LOW to wake the sensor up
+func (d *DHTDriver) startSignal() time.Duration {
+	if d.model == DHT11 {
+		return 18 * time.Millisecond
+	}
+	return time.Millisecond
+}
+
+// decode returns the temperature and humidity of the bytes sent by the
+// sensor, after checking their checksum
+func (d *DHTDriver) decode(data []byte) (temperature float64, humidity float64, err error) {
+	if len(data) != 5 {
+		return 0, 0, fmt.Errorf("DHT sent %v bytes instead of 5", len(data))
+	}
+	if data[0]+data[1]+data[2]+data[3] != data[4] {
+		return 0, 0, ErrDHTChecksum
+	}
+	if d.model == DHT11 {
+		humidity = float64(data[0]) + float64(data[1])/10
+		temperature = float64(data[2]) + float64(data[3]&0x7F)/10
+		if data[3]&0x80 != 0 {
+			temperature = -temperature
+		}
+		return
+	}
+	humidity = float64(uint16(data[0])<<8|uint16(data[1])) / 10
+	temperature = float64(uint16(data[2]&0x7F)<<8|uint16(data[3])) / 10
+	if data[2]&0x80 != 0 {
+		temperature = -temperature
+	}
+	return
+}
@@ -0,0 +1,145 @@
+package gpio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// dhtPulses returns the HIGH pulses a DHT sensor sends for data
+func dhtPulses(data []byte) []time.Duration {
+	pulses := []time.Duration{80 * time.Microsecond}
+	for _, b := range data {
+		for i := uint(0); i < 8; i++ {
+			if b&(0x80>>i) != 0 {
+				pulses = append(pulses, 70*time.Microsecond)
+			} else {
+				pulses = append(pulses, 28*time.Microsecond)
+			}
+		}
+	}
+	return pulses
+}
+
+func TestDHTDriver(t *testing.T) {
+	d := NewDHTDriver(newGpioTestAdaptor("adaptor"), "bot", "4", DHT22)
+	gobot.Assert(t, d.Name(), "bot")
+	gobot.Assert(t, d.Pin(), "4")
+	gobot.Assert(t, d.Model(), DHT22)
+	gobot.Assert(t, d.Connection().Name(), "adaptor")
+	gobot.Assert(t, d.interval, 2*time.Second)
+
+	d = NewDHTDriver(newGpioTestAdaptor("adaptor"), "bot", "4", DHT22, 30*time.Second)
+	gobot.Assert(t, d.interval, 30*time.Second)
+
+	gobot.Assert(t, d.Start()[0], ErrPulseUnsupported)
+}
+
+func TestDHTDriverRead(t *testing.T) {
+	a := &gpioTestDHTAdaptor{data: []byte{0x02, 0x8C, 0x01, 0x5F, 0xEE}}
+	d := NewDHTDriver(a, "bot", "4", DHT22)
+
+	temperature, humidity, err := d.Read()
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, temperature, 35.1)
+	gobot.Assert(t, humidity, 65.2)
+	gobot.Assert(t, d.Temperature(), 35.1)
+	gobot.Assert(t, d.Humidity(), 65.2)
+	gobot.Assert(t, d.Command("Read")(nil), map[string]interface{}{"temperature": 35.1, "humidity": 65.2})
+
+	a.data = []byte{0x02, 0x8C, 0x80, 0x65, 0x73}
+	temperature, _, err = d.Read()
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, temperature, -10.1)
+
+	a.data = []byte{0x02, 0x8C, 0x01, 0x5F, 0xEF}
+	_, _, err = d.Read()
+	gobot.Assert(t, err, ErrDHTChecksum)
+	gobot.Assert(t, d.Command("Read")(nil), ErrDHTChecksum)
+	gobot.Assert(t, d.Temperature(), -10.1)
+
+	a.data = []byte{0x02}
+	_, _, err = d.Read()
+	gobot.Assert(t, err.Error(), "DHT sent 1 bytes instead of 5")
+
+	d = NewDHTDriver(&gpioTestDHTAdaptor{data: []byte{45, 0, 23, 0, 68}}, "bot", "4", DHT11)
+	temperature, humidity, err = d.Read()
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, temperature, 23.0)
+	gobot.Assert(t, humidity, 45.0)
+}
+
+func TestDHTDriverPulseTrain(t *testing.T) {
+	testAdaptorDigitalWrite = func() (err error) {
+		return nil
+	}
+	a := &gpioTestPulseAdaptor{
+		gpioTestAdaptor: *newGpioTestAdaptor("adaptor"),
+		pulses:          dhtPulses([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEE}),
+	}
+	d := NewDHTDriver(a, "bot", "4", DHT22)
+
+	temperature, humidity, err := d.Read()
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, temperature, 35.1)
+	gobot.Assert(t, humidity, 65.2)
+
+	// a missed bit shifts the rest
+	a.pulses = append(dhtPulses([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEE})[2:], 28*time.Microsecond)
+	_, _, err = d.Read()
+	gobot.Assert(t, err, ErrDHTChecksum)
+}
+
+func TestDHTDriverPulseTrainRelease(t *testing.T) {
+	// the pull-up raises the line released by the host until the sensor
+	// answers with an 80µs LOW, then each HIGH pulse follows a 50µs LOW
+	line := []gpioTestLevel{{1, 30 * time.Microsecond}}
+	for i, pulse := range dhtPulses([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEE}) {
+		low := 50 * time.Microsecond
+		if i == 0 {
+			low = 80 * time.Microsecond
+		}
+		line = append(line, gpioTestLevel{0, low}, gpioTestLevel{1, pulse})
+	}
+	a := &gpioTestLineAdaptor{line: line}
+	d := NewDHTDriver(a, "bot", "4", DHT22)
+
+	temperature, humidity, err := d.Read()
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, temperature, 35.1)
+	gobot.Assert(t, humidity, 65.2)
+	// the line is released without being driven HIGH
+	gobot.Assert(t, a.writes, []byte{0})
+}
+
+func TestDHTDriverStart(t *testing.T) {
+	sem := make(chan bool, 1)
+	a := &gpioTestDHTAdaptor{data: []byte{0x02, 0x8C, 0x01, 0x5F, 0xEE}}
+	d := NewDHTDriver(a, "bot", "4", DHT22, 10*time.Millisecond)
+
+	gobot.Once(d.Event(Humidity), func(data interface{}) {
+		gobot.Assert(t, data.(float64), 65.2)
+		sem <- true
+	})
+	gobot.Assert(t, len(d.Start()), 0)
+
+	select {
+	case <-sem:
+	case <-time.After(50 * time.Millisecond):
+		t.Errorf("DHT Event \"Humidity\" was not published")
+	}
+
+	gobot.Once(d.Event(Error), func(data interface{}) {
+		gobot.Assert(t, data.(*gobot.DeviceError).Err, ErrDHTChecksum)
+		sem <- true
+	})
+	a.set([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEF})
+
+	select {
+	case <-sem:
+	case <-time.After(50 * time.Millisecond):
+		t.Errorf("DHT Event \"Error\" was not published")
+	}
+	gobot.Assert(t, len(d.Halt()), 0)
+}
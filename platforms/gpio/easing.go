@@ -0,0 +1,49 @@
+package gpio
+
+import "fmt"
+
+// Easing maps the elapsed fraction t of a transition, from 0 to 1, to the
+// fraction of the change made by then
+type Easing func(t float64) float64
+
+// Easings are the easings by the names the API Commands accept
+var Easings = map[string]Easing{
+	"linear":      Linear,
+	"ease_in":     EaseIn,
+	"ease_out":    EaseOut,
+	"ease_in_out": EaseInOut,
+}
+
+// Linear makes the change at a constant rate
+func Linear(t float64) float64 { return t }
+
+// EaseIn starts slowly and speeds up, cubically
+func EaseIn(t float64) float64 { return t * t * t }
+
+// EaseOut starts quickly and slows down, cubically
+func EaseOut(t float64) float64 {
+	t = 1 - t
+	return 1 - t*t*t
+}
+
+// EaseInOut starts and ends slowly, cubically
+func EaseInOut(t float64) float64 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	t = 2 - 2*t
+	return 1 - t*t*t/2
+}
+
+// easingParam returns the easing named by the "easing" param of an API
+// Command, Linear when it is absent
+func easingParam(params map[string]interface{}) (Easing, error) {
+	name, ok := params["easing"].(string)
+	if !ok {
+		return Linear, nil
+	}
+	if easing, ok := Easings[name]; ok {
+		return easing, nil
+	}
+	return nil, fmt.Errorf("Unknown easing %v", name)
+}
@@ -0,0 +1,26 @@
+package gpio
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestEasings(t *testing.T) {
+	for name, easing := range Easings {
+		gobot.Assert(t, easing(0) == 0 && easing(1) == 1, true)
+		gobot.Assert(t, easing(0.25) <= easing(0.5) && easing(0.5) <= easing(0.75), true)
+		if name != "linear" {
+			gobot.Refute(t, easing(0.25), 0.25)
+		}
+	}
+	gobot.Assert(t, EaseInOut(0.5), 0.5)
+	gobot.Assert(t, EaseIn(0.5), 0.125)
+	gobot.Assert(t, EaseOut(0.5), 0.875)
+
+	easing, err := easingParam(map[string]interface{}{})
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, easing(0.25), 0.25)
+	_, err = easingParam(map[string]interface{}{"easing": "bounce"})
+	gobot.Refute(t, err, nil)
+}
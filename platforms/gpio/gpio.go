@@ -2,6 +2,8 @@ package gpio
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/hybridgroup/gobot"
 )
@@ -25,6 +27,9 @@ var (
 	// ErrServoOutOfRange is the error resulting when a driver attempts to use
 	// hardware capabilities which a connection does not support
 	ErrServoOutOfRange = errors.New("servo angle must be between 0-180")
+	// ErrPulseUnsupported is the error resulting when a driver attempts to time
+	// pulses with a connection which is neither a PingReader nor a PulseReader
+	ErrPulseUnsupported = errors.New("Pulse timing is not supported by this platform")
 )
 
 const (
@@ -32,10 +37,34 @@ const (
 	Release = "release"
 	// Push event
 	Push = "push"
+	// Press event, the Push event under the name used by the press events
+	Press = Push
+	// LongPress event
+	LongPress = "long_press"
+	// DoublePress event
+	DoublePress = "double_press"
 	// Error event
 	Error = "error"
 	// Data event
 	Data = "data"
+	// Distance event
+	Distance = "distance"
+	// Temperature event
+	Temperature = "temperature"
+	// Humidity event
+	Humidity = "humidity"
+	// Position event
+	Position = "position"
+	// Clockwise event
+	Clockwise = "clockwise"
+	// CounterClockwise event
+	CounterClockwise = "counter_clockwise"
+	// MoveComplete event
+	MoveComplete = "move_complete"
+	// MotionDetected event
+	MotionDetected = "motion_detected"
+	// MotionStopped event
+	MotionStopped = "motion_stopped"
 )
 
 // PwmWriter interface represents an Adaptor which has Pwm capabilities
@@ -67,3 +96,63 @@ type DigitalReader interface {
 	gobot.Adaptor
 	DigitalRead(string) (val int, err error)
 }
+
+// DigitalWatcher interface represents an Adaptor which notifies the changes
+// of digital pins as they happen, e.g. on the interrupts of a Linux GPIO
+type DigitalWatcher interface {
+	DigitalReader
+	// WatchDigital calls f with the value of pin each time it changes, until
+	// stop is called.
+	WatchDigital(pin string, f func(val int)) (stop func(), err error)
+}
+
+// PulseReader interface represents an Adaptor which can time digital pulses
+// to the microsecond, e.g. by polling a Linux GPIO
+type PulseReader interface {
+	DigitalWriter
+	// PulseIn waits up to timeout for pin to reach level and returns how long
+	// it stays at level, up to timeout.
+	PulseIn(pin string, level byte, timeout time.Duration) (time.Duration, error)
+}
+
+// PulseTrainReader interface represents a PulseReader which can time
+// consecutive pulses without missing the ones which follow each other by a
+// few microseconds
+type PulseTrainReader interface {
+	PulseReader
+	// PulseTrain times n consecutive pulses of pin at level, reading it as an
+	// input, waiting for each of them up to timeout.
+	PulseTrain(pin string, level byte, n int, timeout time.Duration) ([]time.Duration, error)
+}
+
+// PingReader interface represents an Adaptor which triggers an ultrasonic
+// sensor and times its echo itself, e.g. with the Firmata ping sysex
+type PingReader interface {
+	gobot.Adaptor
+	// PingRead sends a trigger pulse on triggerPin and returns how long the
+	// echo on echoPin lasts, waiting for it up to timeout.
+	PingRead(triggerPin string, echoPin string, timeout time.Duration) (time.Duration, error)
+}
+
+// DHTReader interface represents an Adaptor which reads the five bytes sent
+// by a DHT sensor itself, e.g. with the Firmata DHT sysex
+type DHTReader interface {
+	gobot.Adaptor
+	// DHTRead sends the start signal of model on pin and returns the bytes the
+	// sensor answers with, checksum included.
+	DHTRead(pin string, model DHTModel) ([]byte, error)
+}
+
+// stateByte returns the number stored under key in a snapshot state, which
+// is a float64 once the snapshot has been read back from JSON
+func stateByte(state map[string]interface{}, key string) (byte, error) {
+	switch v := state[key].(type) {
+	case float64:
+		return byte(v), nil
+	case byte:
+		return v, nil
+	case int:
+		return byte(v), nil
+	}
+	return 0, fmt.Errorf("snapshot has no %v", key)
+}
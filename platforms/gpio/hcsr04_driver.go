@@ -0,0 +1,175 @@
+package gpio
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var _ gobot.Driver = (*HCSR04Driver)(nil)
+
+// ErrNoEcho is the error resulting when an ultrasonic sensor does not
+// receive the echo of its pulse, e.g. when nothing is in range
+var ErrNoEcho = errors.New("no echo received")
+
+// speedOfSound is the speed of sound in air at 20°C, in centimeters per
+// second
+const speedOfSound = 34300.0
+
+// HCSR04Driver represents an HC-SR04 ultrasonic distance sensor
+type HCSR04Driver struct {
+	name       string
+	triggerPin string
+	echoPin    string
+	halt       chan bool
+	interval   time.Duration
+	timeout    time.Duration
+	distance   float64
+	mutex      sync.Mutex
+	connection gobot.Adaptor
+	gobot.Eventer
+	gobot.Commander
+}
+
+// NewHCSR04Driver returns a new HCSR04Driver with a polling interval of 100
+// Milliseconds given a PingReader or PulseReader, name, and the pins the
+// trigger and echo of the sensor are wired to. A PingReader such as Firmata
+// may need both to be wired to a single pin.
+//
+// Optinally accepts:
+// 	time.Duration: Interval at which the distance is measured
+//
+// Adds the following API Commands:
+// 	"Measure" - See HCSR04Driver.Measure
+// 	"Distance" - See HCSR04Driver.Distance
+func NewHCSR04Driver(a gobot.Adaptor, name string, triggerPin string, echoPin string, v ...time.Duration) *HCSR04Driver {
+	h := &HCSR04Driver{
+		name:       name,
+		triggerPin: triggerPin,
+		echoPin:    echoPin,
+		connection: a,
+		interval:   100 * time.Millisecond,
+		// the echo of the sensor lasts 38ms when nothing is in range
+		timeout:   40 * time.Millisecond,
+		halt:      make(chan bool),
+		Eventer:   gobot.NewEventer(),
+		Commander: gobot.NewCommander(),
+	}
+
+	if len(v) > 0 {
+		h.interval = v[0]
+	}
+
+	h.AddEvent(Distance)
+	h.AddEvent(Error)
+
+	h.AddCommand("Measure", func(params map[string]interface{}) interface{} {
+		distance, err := h.Measure()
+		if err != nil {
+			return err
+		}
+		return distance
+	})
+	h.AddCommand("Distance", func(params map[string]interface{}) interface{} {
+		return h.Distance()
+	})
+
+	return h
+}
+
+// Start starts the HCSR04Driver and measures the distance at the given
+// interval.
+//
+// Emits the Events:
+//	Distance float64 - The distance measured, in centimeters
+//	Error error - On error measuring the distance
+func (h *HCSR04Driver) Start() (errs []error) {
+	_, ping := h.connection.(PingReader)
+	_, pulse := h.connection.(PulseReader)
+	if !ping && !pulse {
+		return []error{ErrPulseUnsupported}
+	}
+	go func() {
+		for {
+			if distance, err := h.Measure(); err != nil {
+				gobot.Publish(h.Event(Error), gobot.NewDeviceError(h.Name(), "Measure", err, true))
+			} else {
+				gobot.Publish(h.Event(Distance), distance)
+			}
+			select {
+			case <-time.After(h.interval):
+			case <-h.halt:
+				return
+			}
+		}
+	}()
+	return
+}
+
+// Halt stops measuring the distance
+func (h *HCSR04Driver) Halt() (errs []error) {
+	h.halt <- true
+	return
+}
+
+// Name returns the HCSR04Drivers name
+func (h *HCSR04Driver) Name() string { return h.name }
+
+// Pin returns the HCSR04Drivers trigger pin
+func (h *HCSR04Driver) Pin() string { return h.triggerPin }
+
+// EchoPin returns the HCSR04Drivers echo pin
+func (h *HCSR04Driver) EchoPin() string { return h.echoPin }
+
+// Connection returns the HCSR04Drivers Connection
+func (h *HCSR04Driver) Connection() gobot.Connection { return h.connection.(gobot.Connection) }
+
+// Distance returns the last distance measured, in centimeters
+func (h *HCSR04Driver) Distance() float64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.distance
+}
+
+// Measure triggers the sensor and returns the distance of the object which
+// echoed its pulse, in centimeters. Returns ErrNoEcho if nothing is in
+// range.
+func (h *HCSR04Driver) Measure() (distance float64, err error) {
+	echo, err := h.echo()
+	if err != nil {
+		return
+	}
+	if echo <= 0 || echo >= h.timeout {
+		return 0, ErrNoEcho
+	}
+	// the pulse travels to the object and back
+	distance = float64(echo) * speedOfSound / 2 / float64(time.Second)
+	h.mutex.Lock()
+	h.distance = distance
+	h.mutex.Unlock()
+	return
+}
+
+// echo returns how long the echo of a trigger pulse lasts, timed by the
+// connection if it is a PingReader, or else by sending the 10µs trigger
+// pulse and timing the echo with the PulseReader
+func (h *HCSR04Driver) echo() (time.Duration, error) {
+	switch c := h.connection.(type) {
+	case PingReader:
+		return c.PingRead(h.triggerPin, h.echoPin, h.timeout)
+	case PulseReader:
+		for _, step := range []struct {
+			level byte
+			wait  time.Duration
+		}{{0, 2 * time.Microsecond}, {1, 10 * time.Microsecond}, {0, 0}} {
+			if err := c.DigitalWrite(h.triggerPin, step.level); err != nil {
+				return 0, err
+			}
+			time.Sleep(step.wait)
+		}
+		return c.PulseIn(h.echoPin, 1, h.timeout)
+	}
+	return 0, ErrPulseUnsupported
+}
@@ -0,0 +1,86 @@
+package gpio
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestHCSR04Driver(t *testing.T) {
+	d := NewHCSR04Driver(newGpioTestAdaptor("adaptor"), "bot", "1", "2")
+	gobot.Assert(t, d.Name(), "bot")
+	gobot.Assert(t, d.Pin(), "1")
+	gobot.Assert(t, d.EchoPin(), "2")
+	gobot.Assert(t, d.Connection().Name(), "adaptor")
+	gobot.Assert(t, d.interval, 100*time.Millisecond)
+
+	d = NewHCSR04Driver(newGpioTestAdaptor("adaptor"), "bot", "1", "2", 30*time.Second)
+	gobot.Assert(t, d.interval, 30*time.Second)
+}
+
+func TestHCSR04DriverStartUnsupported(t *testing.T) {
+	d := NewHCSR04Driver(newGpioTestAdaptor("adaptor"), "bot", "1", "2")
+	gobot.Assert(t, d.Start()[0], ErrPulseUnsupported)
+	_, err := d.Measure()
+	gobot.Assert(t, err, ErrPulseUnsupported)
+}
+
+func TestHCSR04DriverMeasure(t *testing.T) {
+	testAdaptorDigitalWrite = func() (err error) {
+		return nil
+	}
+	a := &gpioTestPulseAdaptor{gpioTestAdaptor: *newGpioTestAdaptor("adaptor"), echo: time.Millisecond}
+	d := NewHCSR04Driver(a, "bot", "1", "2")
+
+	distance, err := d.Measure()
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, distance, 17.15)
+	gobot.Assert(t, d.Distance(), 17.15)
+	gobot.Assert(t, d.Command("Distance")(nil), 17.15)
+
+	a.echo = 0
+	_, err = d.Measure()
+	gobot.Assert(t, err, ErrNoEcho)
+	a.echo = 40 * time.Millisecond
+	gobot.Assert(t, d.Command("Measure")(nil), ErrNoEcho)
+	gobot.Assert(t, d.Distance(), 17.15)
+
+	a.err = errors.New("read error")
+	_, err = d.Measure()
+	gobot.Assert(t, err, a.err)
+}
+
+func TestHCSR04DriverPingRead(t *testing.T) {
+	a := &gpioTestPingAdaptor{}
+	d := NewHCSR04Driver(a, "bot", "7", "7")
+
+	distance, err := d.Measure()
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, distance, 17.15)
+	gobot.Assert(t, a.trigger, "7")
+	gobot.Assert(t, a.echo, "7")
+}
+
+func TestHCSR04DriverStart(t *testing.T) {
+	sem := make(chan bool, 1)
+	a := &gpioTestPulseAdaptor{gpioTestAdaptor: *newGpioTestAdaptor("adaptor"), echo: time.Millisecond}
+	d := NewHCSR04Driver(a, "bot", "1", "2", 10*time.Millisecond)
+	testAdaptorDigitalWrite = func() (err error) {
+		return nil
+	}
+
+	gobot.Once(d.Event(Distance), func(data interface{}) {
+		gobot.Assert(t, data.(float64), 17.15)
+		sem <- true
+	})
+	gobot.Assert(t, len(d.Start()), 0)
+
+	select {
+	case <-sem:
+	case <-time.After(50 * time.Millisecond):
+		t.Errorf("HCSR04 Event \"Distance\" was not published")
+	}
+	gobot.Assert(t, len(d.Halt()), 0)
+}
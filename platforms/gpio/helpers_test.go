@@ -1,5 +1,11 @@
 package gpio
 
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
 type gpioTestBareAdaptor struct{}
 
 func (t *gpioTestBareAdaptor) Connect() (errs []error)  { return }
@@ -59,3 +65,182 @@ func newGpioTestAdaptor(name string) *gpioTestAdaptor {
 		port: "/dev/null",
 	}
 }
+
+type gpioTestPulseAdaptor struct {
+	gpioTestAdaptor
+	echo   time.Duration
+	pulses []time.Duration
+	err    error
+}
+
+func (t *gpioTestPulseAdaptor) PulseIn(string, byte, time.Duration) (time.Duration, error) {
+	return t.echo, t.err
+}
+
+func (t *gpioTestPulseAdaptor) PulseTrain(string, byte, int, time.Duration) ([]time.Duration, error) {
+	return t.pulses, t.err
+}
+
+// gpioTestLevel is a level the line of a pin is at for duration
+type gpioTestLevel struct {
+	level    byte
+	duration time.Duration
+}
+
+// gpioTestLineAdaptor times the pulses of the levels of line, in order,
+// as if they followed the digital writes
+type gpioTestLineAdaptor struct {
+	gpioTestBareAdaptor
+	writes []byte
+	line   []gpioTestLevel
+}
+
+func (t *gpioTestLineAdaptor) DigitalWrite(pin string, level byte) error {
+	t.writes = append(t.writes, level)
+	return nil
+}
+
+func (t *gpioTestLineAdaptor) PulseIn(pin string, level byte, timeout time.Duration) (time.Duration, error) {
+	pulses, err := t.PulseTrain(pin, level, 1, timeout)
+	if err != nil {
+		return 0, err
+	}
+	return pulses[0], nil
+}
+
+func (t *gpioTestLineAdaptor) PulseTrain(pin string, level byte, n int, timeout time.Duration) ([]time.Duration, error) {
+	pulses := []time.Duration{}
+	for len(pulses) < n {
+		if len(t.line) == 0 {
+			return nil, errors.New("timed out waiting for a pulse")
+		}
+		if t.line[0].level == level {
+			pulses = append(pulses, t.line[0].duration)
+		}
+		t.line = t.line[1:]
+	}
+	return pulses, nil
+}
+
+type gpioTestPingAdaptor struct {
+	gpioTestBareAdaptor
+	trigger string
+	echo    string
+}
+
+func (t *gpioTestPingAdaptor) PingRead(trigger string, echo string, timeout time.Duration) (time.Duration, error) {
+	t.trigger, t.echo = trigger, echo
+	return time.Millisecond, nil
+}
+
+type gpioTestDHTAdaptor struct {
+	gpioTestBareAdaptor
+	mutex sync.Mutex
+	data  []byte
+}
+
+func (t *gpioTestDHTAdaptor) DHTRead(string, DHTModel) ([]byte, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.data, nil
+}
+
+func (t *gpioTestDHTAdaptor) set(data []byte) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.data = data
+}
+
+type gpioTestPinsAdaptor struct {
+	gpioTestBareAdaptor
+	mutex  sync.Mutex
+	values map[string]int
+	writes int
+}
+
+func newGpioTestPinsAdaptor() *gpioTestPinsAdaptor {
+	return &gpioTestPinsAdaptor{values: make(map[string]int)}
+}
+
+func (t *gpioTestPinsAdaptor) DigitalRead(pin string) (int, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.values[pin], nil
+}
+
+func (t *gpioTestPinsAdaptor) DigitalWrite(pin string, val byte) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.values[pin] = int(val)
+	t.writes++
+	return nil
+}
+
+func (t *gpioTestPinsAdaptor) get(pin string) int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.values[pin]
+}
+
+func (t *gpioTestPinsAdaptor) set(pin string, val int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.values[pin] = val
+}
+
+type gpioTestWatchAdaptor struct {
+	gpioTestPinsAdaptor
+	watchers map[string]func(int)
+}
+
+func (t *gpioTestWatchAdaptor) WatchDigital(pin string, f func(int)) (func(), error) {
+	t.watchers[pin] = f
+	return func() { delete(t.watchers, pin) }, nil
+}
+
+type gpioTestPwmAdaptor struct {
+	gpioTestDigitalWriter
+	write func(level byte)
+}
+
+func (t *gpioTestPwmAdaptor) PwmWrite(pin string, level byte) error {
+	t.write(level)
+	return nil
+}
+
+type gpioTestRecordingWriter struct {
+	*gpioTestPinsAdaptor
+	write func(val byte)
+}
+
+func (t *gpioTestRecordingWriter) DigitalWrite(pin string, val byte) error {
+	t.write(val)
+	return t.gpioTestPinsAdaptor.DigitalWrite(pin, val)
+}
+
+type gpioTestLevelsAdaptor struct {
+	gpioTestPinsAdaptor
+	err error
+}
+
+func newGpioTestLevelsAdaptor() *gpioTestLevelsAdaptor {
+	return &gpioTestLevelsAdaptor{gpioTestPinsAdaptor: *newGpioTestPinsAdaptor()}
+}
+
+func (t *gpioTestLevelsAdaptor) PwmWrite(pin string, level byte) error {
+	t.mutex.Lock()
+	err := t.err
+	t.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return t.DigitalWrite(pin, level)
+}
+
+func (t *gpioTestLevelsAdaptor) levels(pins ...string) []int {
+	levels := []int{}
+	for _, pin := range pins {
+		levels = append(levels, t.get(pin))
+	}
+	return levels
+}
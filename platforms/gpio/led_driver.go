@@ -1,16 +1,31 @@
 package gpio
 
-import "github.com/hybridgroup/gobot"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
 
 var _ gobot.Driver = (*LedDriver)(nil)
 
+// ledFrame is the interval at which fades update the brightness of a led
+const ledFrame = 20 * time.Millisecond
+
 // LedDriver represents a digital Led
 type LedDriver struct {
 	pin        string
 	name       string
 	connection DigitalWriter
 	high       bool
+	level      byte
+	effect     *gobot.Timer
+	mutex      sync.Mutex
 	gobot.Commander
+	gobot.Eventer
 }
 
 // NewLedDriver return a new LedDriver given a DigitalWriter, name and pin.
@@ -20,6 +35,10 @@ type LedDriver struct {
 //	"Toggle" - See LedDriver.Toggle
 //	"On" - See LedDriver.On
 //	"Off" - See LedDriver.Off
+//	"FadeTo" - See LedDriver.FadeTo, with a duration in milliseconds
+//	"Breathe" - See LedDriver.Breathe, with a period in milliseconds
+//	"Blink" - See LedDriver.Blink, with a unit in milliseconds
+//	"StopEffect" - See LedDriver.StopEffect
 func NewLedDriver(a DigitalWriter, name string, pin string) *LedDriver {
 	l := &LedDriver{
 		name:       name,
@@ -27,8 +46,11 @@ func NewLedDriver(a DigitalWriter, name string, pin string) *LedDriver {
 		connection: a,
 		high:       false,
 		Commander:  gobot.NewCommander(),
+		Eventer:    gobot.NewEventer(),
 	}
 
+	l.AddEvent(Error)
+
 	l.AddCommand("Brightness", func(params map[string]interface{}) interface{} {
 		level := byte(params["level"].(float64))
 		return l.Brightness(level)
@@ -46,14 +68,39 @@ func NewLedDriver(a DigitalWriter, name string, pin string) *LedDriver {
 		return l.Off()
 	})
 
+	l.AddCommand("FadeTo", func(params map[string]interface{}) interface{} {
+		duration := time.Duration(params["duration"].(float64)) * time.Millisecond
+		return l.FadeTo(byte(params["level"].(float64)), duration)
+	})
+
+	l.AddCommand("Breathe", func(params map[string]interface{}) interface{} {
+		return l.Breathe(time.Duration(params["period"].(float64)) * time.Millisecond)
+	})
+
+	l.AddCommand("Blink", func(params map[string]interface{}) interface{} {
+		unit := time.Duration(params["unit"].(float64)) * time.Millisecond
+		return l.Blink(params["pattern"].(string), unit, int(params["repeat"].(float64)))
+	})
+
+	l.AddCommand("StopEffect", func(params map[string]interface{}) interface{} {
+		l.StopEffect()
+		return nil
+	})
+
 	return l
 }
 
 // Start implements the Driver interface
+//
+// Emits the Events:
+//	Error error - On error playing an effect, which stops it
 func (l *LedDriver) Start() (errs []error) { return }
 
-// Halt implements the Driver interface
-func (l *LedDriver) Halt() (errs []error) { return }
+// Halt stops the effect of the led, see LedDriver.StopEffect
+func (l *LedDriver) Halt() (errs []error) {
+	l.StopEffect()
+	return
+}
 
 // Name returns the LedDrivers name
 func (l *LedDriver) Name() string { return l.name }
@@ -68,24 +115,35 @@ func (l *LedDriver) Connection() gobot.Connection {
 
 // State return true if the led is On and false if the led is Off
 func (l *LedDriver) State() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
 	return l.high
 }
 
 // On sets the led to a high state.
 func (l *LedDriver) On() (err error) {
-	if err = l.connection.DigitalWrite(l.Pin(), 1); err != nil {
-		return
-	}
-	l.high = true
-	return
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.write(true)
 }
 
 // Off sets the led to a low state.
 func (l *LedDriver) Off() (err error) {
-	if err = l.connection.DigitalWrite(l.Pin(), 0); err != nil {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.write(false)
+}
+
+// write sets the led to a high state if high or else to a low state
+func (l *LedDriver) write(high bool) (err error) {
+	level := byte(0)
+	if high {
+		level = 1
+	}
+	if err = l.connection.DigitalWrite(l.Pin(), level); err != nil {
 		return
 	}
-	l.high = false
+	l.high, l.level = high, 255*level
 	return
 }
 
@@ -99,10 +157,153 @@ func (l *LedDriver) Toggle() (err error) {
 	return
 }
 
+// Snapshot implements the gobot.Snapshotter interface, the state holds
+// whether the led is on
+func (l *LedDriver) Snapshot() map[string]interface{} {
+	return map[string]interface{}{"on": l.State()}
+}
+
+// Restore implements the gobot.Snapshotter interface, turning the led on or
+// off again
+func (l *LedDriver) Restore(state map[string]interface{}) error {
+	on, ok := state["on"].(bool)
+	if !ok {
+		return errors.New("snapshot has no on")
+	}
+	if on {
+		return l.On()
+	}
+	return l.Off()
+}
+
 // Brightness sets the led to the specified level of brightness
 func (l *LedDriver) Brightness(level byte) (err error) {
-	if writer, ok := l.connection.(PwmWriter); ok {
-		return writer.PwmWrite(l.Pin(), level)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.brightness(level)
+}
+
+// brightness sets the led to level of brightness with the PwmWriter
+func (l *LedDriver) brightness(level byte) (err error) {
+	writer, ok := l.connection.(PwmWriter)
+	if !ok {
+		return ErrPwmWriteUnsupported
+	}
+	if err = writer.PwmWrite(l.Pin(), level); err != nil {
+		return
+	}
+	l.high, l.level = level > 0, level
+	return
+}
+
+// FadeTo fades the brightness of the led from its current level to level
+// over duration, in the background. It replaces the effect in progress.
+func (l *LedDriver) FadeTo(level byte, duration time.Duration) error {
+	if _, ok := l.connection.(PwmWriter); !ok {
+		return ErrPwmWriteUnsupported
+	}
+	frames := int(duration / ledFrame)
+	if frames < 1 {
+		frames = 1
+	}
+	l.mutex.Lock()
+	from := float64(l.level)
+	l.mutex.Unlock()
+	l.animate(ledFrame, func(frame int) (bool, error) {
+		t := float64(frame) / float64(frames)
+		return frame >= frames, l.brightness(byte(from + (float64(level)-from)*t + 0.5))
+	})
+	return nil
+}
+
+// Breathe fades the led in and out, each breath taking period, until the
+// effect is stopped. It replaces the effect in progress.
+func (l *LedDriver) Breathe(period time.Duration) error {
+	if _, ok := l.connection.(PwmWriter); !ok {
+		return ErrPwmWriteUnsupported
+	}
+	frames := int(period / ledFrame)
+	if frames < 2 {
+		frames = 2
+	}
+	l.animate(ledFrame, func(frame int) (bool, error) {
+		// in for the first half of the period and out for the second
+		t := 1 - math.Abs(1-2*float64(frame%frames)/float64(frames))
+		return false, l.brightness(byte(255*EaseInOut(t) + 0.5))
+	})
+	return nil
+}
+
+// Blink blinks the led following pattern, in which "." is on for one unit,
+// "-" is on for three units, each followed by a unit off, and " " is off
+// for two more units, as in morse code, e.g. "... --- ...". The pattern is
+// played repeat times, or until the effect is stopped if repeat is 0, and
+// the led is turned off at the end. It replaces the effect in progress.
+func (l *LedDriver) Blink(pattern string, unit time.Duration, repeat int) error {
+	levels := []bool{}
+	for _, c := range pattern {
+		switch c {
+		case '.':
+			levels = append(levels, true, false)
+		case '-':
+			levels = append(levels, true, true, true, false)
+		case ' ':
+			levels = append(levels, false, false)
+		default:
+			return fmt.Errorf("Invalid character %q in blink pattern", c)
+		}
+	}
+	if len(levels) == 0 {
+		return errors.New("Empty blink pattern")
+	}
+	l.animate(unit, func(frame int) (bool, error) {
+		if repeat > 0 && frame >= repeat*len(levels) {
+			return true, l.write(false)
+		}
+		return false, l.write(levels[frame%len(levels)])
+	})
+	return nil
+}
+
+// StopEffect stops the effect in progress, leaving the led as it is.
+func (l *LedDriver) StopEffect() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.effect != nil {
+		l.effect.Stop()
+		l.effect = nil
+	}
+}
+
+// animate replaces the effect in progress with f, called with the number of
+// each frame every interval until it is done, fails or the effect is
+// stopped. The first frame is played right away.
+func (l *LedDriver) animate(interval time.Duration, f func(frame int) (done bool, err error)) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.effect != nil {
+		l.effect.Stop()
+	}
+	var effect *gobot.Timer
+	frame := 0
+	play := func() {
+		l.mutex.Lock()
+		if l.effect != effect {
+			l.mutex.Unlock()
+			return
+		}
+		done, err := f(frame)
+		frame++
+		if done || err != nil {
+			effect.Stop()
+			l.effect = nil
+		}
+		l.mutex.Unlock()
+		if err != nil {
+			gobot.Publish(l.Event(Error), gobot.NewDeviceError(l.Name(), "Effect", err, true))
+		}
 	}
-	return ErrPwmWriteUnsupported
+	effect = gobot.Every(interval, play)
+	l.effect = effect
+	go play()
 }
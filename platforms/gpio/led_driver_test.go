@@ -2,7 +2,9 @@ package gpio
 
 import (
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/hybridgroup/gobot"
 )
@@ -77,3 +79,113 @@ func TestLedDriverBrightness(t *testing.T) {
 	}
 	gobot.Assert(t, d.Brightness(150), errors.New("pwm error"))
 }
+
+func TestLedDriverSnapshot(t *testing.T) {
+	d := initTestLedDriver(newGpioTestAdaptor("adaptor"))
+	d.On()
+	state := d.Snapshot()
+	gobot.Assert(t, state, map[string]interface{}{"on": true})
+
+	d.Off()
+	gobot.Assert(t, d.Restore(state), nil)
+	gobot.Assert(t, d.State(), true)
+	gobot.Assert(t, d.Restore(map[string]interface{}{}), errors.New("snapshot has no on"))
+}
+
+func TestLedDriverFadeTo(t *testing.T) {
+	var mutex sync.Mutex
+	levels := []byte{}
+	a := &gpioTestPwmAdaptor{write: func(level byte) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		levels = append(levels, level)
+	}}
+	d := NewLedDriver(a, "bot", "1")
+
+	gobot.Assert(t, d.FadeTo(200, 100*time.Millisecond), nil)
+	time.Sleep(200 * time.Millisecond)
+	mutex.Lock()
+	gobot.Assert(t, len(levels), 6)
+	gobot.Assert(t, levels[0], byte(0))
+	gobot.Assert(t, levels[2], byte(80))
+	gobot.Assert(t, levels[5], byte(200))
+	mutex.Unlock()
+	gobot.Assert(t, d.State(), true)
+
+	gobot.Assert(t, d.FadeTo(0, time.Second), nil)
+	time.Sleep(50 * time.Millisecond)
+	d.StopEffect()
+	mutex.Lock()
+	stopped := len(levels)
+	mutex.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	mutex.Lock()
+	gobot.Assert(t, len(levels), stopped)
+	gobot.Assert(t, levels[len(levels)-1] > 180, true)
+	mutex.Unlock()
+
+	gobot.Assert(t, NewLedDriver(&gpioTestDigitalWriter{}, "bot", "1").FadeTo(0, time.Second), ErrPwmWriteUnsupported)
+}
+
+func TestLedDriverBreathe(t *testing.T) {
+	var mutex sync.Mutex
+	levels := []byte{}
+	a := &gpioTestPwmAdaptor{write: func(level byte) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		levels = append(levels, level)
+	}}
+	d := NewLedDriver(a, "bot", "1")
+
+	gobot.Assert(t, d.Command("Breathe")(map[string]interface{}{"period": 80.0}), nil)
+	time.Sleep(190 * time.Millisecond)
+	gobot.Assert(t, len(d.Halt()), 0)
+	mutex.Lock()
+	defer mutex.Unlock()
+	// two breaths of 4 frames
+	gobot.Assert(t, levels[:9], []byte{0, 128, 255, 128, 0, 128, 255, 128, 0})
+}
+
+func TestLedDriverBlink(t *testing.T) {
+	var mutex sync.Mutex
+	levels := []int{}
+	a := newGpioTestPinsAdaptor()
+	d := NewLedDriver(&gpioTestRecordingWriter{a, func(val byte) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		levels = append(levels, int(val))
+	}}, "bot", "1")
+
+	gobot.Assert(t, d.Blink(". -", 5*time.Millisecond, 2), nil)
+	time.Sleep(150 * time.Millisecond)
+	mutex.Lock()
+	gobot.Assert(t, levels, []int{1, 0, 0, 0, 1, 1, 1, 0, 1, 0, 0, 0, 1, 1, 1, 0, 0})
+	mutex.Unlock()
+	gobot.Assert(t, d.State(), false)
+
+	gobot.Assert(t, d.Blink(".x", time.Millisecond, 1), errors.New("Invalid character 'x' in blink pattern"))
+	gobot.Assert(t, d.Blink("", time.Millisecond, 1), errors.New("Empty blink pattern"))
+}
+
+func TestLedDriverEffectError(t *testing.T) {
+	sem := make(chan bool, 1)
+	d := initTestLedDriver(newGpioTestAdaptor("adaptor"))
+	testAdaptorDigitalWrite = func() (err error) {
+		return errors.New("write error")
+	}
+	defer func() {
+		testAdaptorDigitalWrite = func() (err error) {
+			return nil
+		}
+	}()
+	gobot.Once(d.Event(Error), func(data interface{}) {
+		gobot.Assert(t, data.(*gobot.DeviceError).Err, errors.New("write error"))
+		sem <- true
+	})
+	d.Blink("...", time.Millisecond, 0)
+	select {
+	case <-sem:
+	case <-time.After(50 * time.Millisecond):
+		t.Errorf("Led Event \"Error\" was not published")
+	}
+}
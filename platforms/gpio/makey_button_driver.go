@@ -67,7 +67,7 @@ func (b *MakeyButtonDriver) Start() (errs []error) {
 		for {
 			newValue, err := b.connection.DigitalRead(b.Pin())
 			if err != nil {
-				gobot.Publish(b.Event(Error), err)
+				gobot.Publish(b.Event(Error), gobot.NewDeviceError(b.Name(), "DigitalRead", err, true))
 			} else if newValue != state && newValue != -1 {
 				state = newValue
 				if newValue == 0 {
@@ -174,6 +174,42 @@ func (m *MotorDriver) Direction(direction string) (err error) {
 	return
 }
 
+// Snapshot implements the gobot.Snapshotter interface, the state holds the
+// current mode, state, speed and direction
+func (m *MotorDriver) Snapshot() map[string]interface{} {
+	return map[string]interface{}{
+		"mode":      m.CurrentMode,
+		"state":     m.CurrentState,
+		"speed":     m.CurrentSpeed,
+		"direction": m.CurrentDirection,
+	}
+}
+
+// Restore implements the gobot.Snapshotter interface, setting the direction
+// and then the state or speed of the motor again
+func (m *MotorDriver) Restore(state map[string]interface{}) (err error) {
+	mode, _ := state["mode"].(string)
+	direction, _ := state["direction"].(string)
+	if direction != "" {
+		if err = m.Direction(direction); err != nil {
+			return
+		}
+	}
+	if mode == "digital" {
+		var s byte
+		if s, err = stateByte(state, "state"); err != nil {
+			return
+		}
+		m.CurrentMode = mode
+		return m.changeState(s)
+	}
+	speed, err := stateByte(state, "speed")
+	if err != nil {
+		return
+	}
+	return m.Speed(speed)
+}
+
 func (m *MotorDriver) isDigital() bool {
 	if m.CurrentMode == "digital" {
 		return true
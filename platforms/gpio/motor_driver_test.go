@@ -108,3 +108,19 @@ func TestMotorDriverDirection(t *testing.T) {
 	d.Direction("forward")
 	d.Direction("backward")
 }
+
+func TestMotorDriverSnapshot(t *testing.T) {
+	d := initTestMotorDriver()
+	d.Backward(100)
+	state := d.Snapshot()
+	gobot.Assert(t, state, map[string]interface{}{
+		"mode": "analog", "state": byte(0), "speed": byte(100), "direction": "backward",
+	})
+
+	d.Forward(0)
+	gobot.Assert(t, d.Restore(map[string]interface{}{
+		"mode": "analog", "state": 0.0, "speed": 100.0, "direction": "backward",
+	}), nil)
+	gobot.Assert(t, d.CurrentSpeed, byte(100))
+	gobot.Assert(t, d.CurrentDirection, "backward")
+}
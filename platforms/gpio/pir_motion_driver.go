@@ -0,0 +1,148 @@
+package gpio
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var _ gobot.Driver = (*PIRMotionDriver)(nil)
+
+// PIRMotionDriver represents a PIR motion sensor, such as the HC-SR501
+type PIRMotionDriver struct {
+	// WarmUpTime is how long the readings of the sensor are ignored after
+	// the driver starts, while the sensor settles
+	WarmUpTime time.Duration
+	// HoldOff is how long the sensor must read no motion before the motion
+	// stops, so that the sensor retriggering within it extends the motion
+	// instead of starting a new one
+	HoldOff time.Duration
+
+	name       string
+	pin        string
+	halt       chan bool
+	interval   time.Duration
+	connection DigitalReader
+	started    time.Time
+	motion     bool
+	since      time.Time
+	lastSeen   time.Time
+	mutex      sync.Mutex
+	gobot.Eventer
+	gobot.Commander
+}
+
+// NewPIRMotionDriver returns a new PIRMotionDriver with a polling interval
+// of 10 Milliseconds and a WarmUpTime of a minute, which the HC-SR501 needs
+// after power up, given a DigitalReader, name and pin.
+//
+// Optinally accepts:
+// 	time.Duration: Interval at which the sensor is polled
+//
+// Adds the following API Commands:
+// 	"Motion" - See PIRMotionDriver.Motion
+func NewPIRMotionDriver(a DigitalReader, name string, pin string, v ...time.Duration) *PIRMotionDriver {
+	p := &PIRMotionDriver{
+		WarmUpTime: time.Minute,
+		name:       name,
+		pin:        pin,
+		connection: a,
+		interval:   10 * time.Millisecond,
+		halt:       make(chan bool),
+		Eventer:    gobot.NewEventer(),
+		Commander:  gobot.NewCommander(),
+	}
+
+	if len(v) > 0 {
+		p.interval = v[0]
+	}
+
+	p.AddEvent(MotionDetected)
+	p.AddEvent(MotionStopped)
+	p.AddEvent(Error)
+
+	p.AddCommand("Motion", func(params map[string]interface{}) interface{} {
+		return p.Motion()
+	})
+
+	return p
+}
+
+// Start starts the PIRMotionDriver and polls the sensor at the given
+// interval, once WarmUpTime has passed.
+//
+// Emits the Events:
+//	MotionDetected int - When motion starts
+//	MotionStopped time.Duration - When motion stops, with how long it lasted
+//	Error error - On error reading the sensor
+func (p *PIRMotionDriver) Start() (errs []error) {
+	p.mutex.Lock()
+	p.started, p.motion = time.Now(), false
+	p.mutex.Unlock()
+	go func() {
+		for {
+			if val, err := p.connection.DigitalRead(p.Pin()); err != nil {
+				gobot.Publish(p.Event(Error), gobot.NewDeviceError(p.Name(), "DigitalRead", err, true))
+			} else if val != -1 {
+				p.update(val, time.Now())
+			}
+			select {
+			case <-time.After(p.interval):
+			case <-p.halt:
+				return
+			}
+		}
+	}()
+	return
+}
+
+// Halt stops polling the sensor
+func (p *PIRMotionDriver) Halt() (errs []error) {
+	p.halt <- true
+	return
+}
+
+// Name returns the PIRMotionDrivers name
+func (p *PIRMotionDriver) Name() string { return p.name }
+
+// Pin returns the PIRMotionDrivers pin
+func (p *PIRMotionDriver) Pin() string { return p.pin }
+
+// Connection returns the PIRMotionDrivers Connection
+func (p *PIRMotionDriver) Connection() gobot.Connection { return p.connection.(gobot.Connection) }
+
+// Motion returns whether motion is detected
+func (p *PIRMotionDriver) Motion() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.motion
+}
+
+// update handles the reading val of the sensor at now
+func (p *PIRMotionDriver) update(val int, now time.Time) {
+	p.mutex.Lock()
+	if now.Sub(p.started) < p.WarmUpTime {
+		p.mutex.Unlock()
+		return
+	}
+	event, lasted := "", time.Duration(0)
+	if val == 1 {
+		p.lastSeen = now
+		if !p.motion {
+			p.motion, p.since = true, now
+			event = MotionDetected
+		}
+	} else if p.motion && now.Sub(p.lastSeen) >= p.HoldOff {
+		p.motion = false
+		event, lasted = MotionStopped, now.Sub(p.since)
+	}
+	p.mutex.Unlock()
+
+	switch event {
+	case MotionDetected:
+		gobot.Publish(p.Event(MotionDetected), val)
+	case MotionStopped:
+		gobot.Publish(p.Event(MotionStopped), lasted)
+	}
+}
@@ -0,0 +1,97 @@
+package gpio
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestPIRMotionDriver(t *testing.T) {
+	p := NewPIRMotionDriver(newGpioTestAdaptor("adaptor"), "pir", "1")
+	gobot.Assert(t, p.Name(), "pir")
+	gobot.Assert(t, p.Pin(), "1")
+	gobot.Assert(t, p.Connection().Name(), "adaptor")
+	gobot.Assert(t, p.WarmUpTime, time.Minute)
+	gobot.Assert(t, p.interval, 10*time.Millisecond)
+
+	p = NewPIRMotionDriver(newGpioTestAdaptor("adaptor"), "pir", "1", 30*time.Second)
+	gobot.Assert(t, p.interval, 30*time.Second)
+}
+
+func TestPIRMotionDriverStart(t *testing.T) {
+	a := newGpioTestPinsAdaptor()
+	p := NewPIRMotionDriver(a, "pir", "1", time.Millisecond)
+	p.WarmUpTime = 0
+	detected, stopped := make(chan int, 1), make(chan time.Duration, 1)
+	gobot.On(p.Event(MotionDetected), func(data interface{}) { detected <- data.(int) })
+	gobot.On(p.Event(MotionStopped), func(data interface{}) { stopped <- data.(time.Duration) })
+
+	gobot.Assert(t, len(p.Start()), 0)
+	a.set("1", 1)
+	select {
+	case val := <-detected:
+		gobot.Assert(t, val, 1)
+	case <-time.After(time.Second):
+		t.Errorf("MotionDetected was not published")
+	}
+	gobot.Assert(t, p.Command("Motion")(nil), true)
+	a.set("1", 0)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Errorf("MotionStopped was not published")
+	}
+	gobot.Assert(t, p.Motion(), false)
+	gobot.Assert(t, len(p.Halt()), 0)
+
+	testAdaptorDigitalRead = func() (val int, err error) {
+		return 0, errors.New("read error")
+	}
+	defer func() {
+		testAdaptorDigitalRead = func() (val int, err error) { return 1, nil }
+	}()
+	p = NewPIRMotionDriver(newGpioTestAdaptor("adaptor"), "pir", "1", time.Millisecond)
+	errs := make(chan error, 1)
+	gobot.Once(p.Event(Error), func(data interface{}) { errs <- data.(error) })
+	gobot.Assert(t, len(p.Start()), 0)
+	select {
+	case err := <-errs:
+		gobot.Assert(t, err.(*gobot.DeviceError).Err, errors.New("read error"))
+	case <-time.After(time.Second):
+		t.Errorf("Error was not published")
+	}
+	gobot.Assert(t, len(p.Halt()), 0)
+}
+
+func TestPIRMotionDriverWarmUpAndHoldOff(t *testing.T) {
+	p := NewPIRMotionDriver(newGpioTestAdaptor("adaptor"), "pir", "1")
+	p.HoldOff = 2 * time.Second
+	detected, stopped := make(chan int, 2), make(chan time.Duration, 2)
+	gobot.On(p.Event(MotionDetected), func(data interface{}) { detected <- data.(int) })
+	gobot.On(p.Event(MotionStopped), func(data interface{}) { stopped <- data.(time.Duration) })
+	start := time.Now()
+	p.started = start
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	// readings during the warm up are ignored
+	p.update(1, at(10*time.Second))
+	gobot.Assert(t, p.Motion(), false)
+
+	p.update(1, at(time.Minute))
+	gobot.Assert(t, p.Motion(), true)
+	p.update(0, at(time.Minute+time.Second))
+	gobot.Assert(t, p.Motion(), true)
+	// retriggering within the hold off extends the motion
+	p.update(1, at(time.Minute+1500*time.Millisecond))
+	p.update(0, at(time.Minute+3*time.Second))
+	gobot.Assert(t, p.Motion(), true)
+	p.update(0, at(time.Minute+4*time.Second))
+	gobot.Assert(t, p.Motion(), false)
+
+	<-time.After(10 * time.Millisecond)
+	gobot.Assert(t, len(detected), 1)
+	gobot.Assert(t, len(stopped), 1)
+	gobot.Assert(t, <-stopped, 4*time.Second)
+}
@@ -0,0 +1,218 @@
+package gpio
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var _ gobot.Driver = (*RelayDriver)(nil)
+
+// RelayDriver represents a relay, or a relay board channel
+type RelayDriver struct {
+	// ActiveLow is whether the relay is energized by a LOW level on its pin,
+	// as most relay boards are
+	ActiveLow bool
+	// SafeState is the state, true for on, the relay is set to when the
+	// driver starts and halts
+	SafeState bool
+
+	name       string
+	pin        string
+	connection DigitalWriter
+	on         bool
+	pulse      *gobot.Timer
+	interlock  *RelayInterlock
+	mutex      sync.Mutex
+	gobot.Eventer
+	gobot.Commander
+}
+
+// NewRelayDriver returns a new active high RelayDriver with an off
+// SafeState given a DigitalWriter, name and pin.
+//
+// Adds the following API Commands:
+// 	"On" - See RelayDriver.On
+// 	"Off" - See RelayDriver.Off
+// 	"Toggle" - See RelayDriver.Toggle
+// 	"Pulse" - See RelayDriver.Pulse, with a duration in milliseconds
+// 	"State" - See RelayDriver.State
+func NewRelayDriver(a DigitalWriter, name string, pin string) *RelayDriver {
+	r := &RelayDriver{
+		name:       name,
+		pin:        pin,
+		connection: a,
+		Eventer:    gobot.NewEventer(),
+		Commander:  gobot.NewCommander(),
+	}
+
+	r.AddEvent(Error)
+
+	r.AddCommand("On", func(params map[string]interface{}) interface{} {
+		return r.On()
+	})
+	r.AddCommand("Off", func(params map[string]interface{}) interface{} {
+		return r.Off()
+	})
+	r.AddCommand("Toggle", func(params map[string]interface{}) interface{} {
+		return r.Toggle()
+	})
+	r.AddCommand("Pulse", func(params map[string]interface{}) interface{} {
+		return r.Pulse(time.Duration(params["duration"].(float64)) * time.Millisecond)
+	})
+	r.AddCommand("State", func(params map[string]interface{}) interface{} {
+		return r.State()
+	})
+
+	return r
+}
+
+// Start sets the relay to its SafeState.
+//
+// Emits the Events:
+//	Error error - On error turning the relay off at the end of a pulse
+func (r *RelayDriver) Start() (errs []error) {
+	if err := r.safe(); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// Halt cancels the pulse of the relay and sets it to its SafeState
+func (r *RelayDriver) Halt() (errs []error) {
+	if err := r.safe(); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// safe sets the relay to its SafeState
+func (r *RelayDriver) safe() error {
+	if r.SafeState {
+		return r.On()
+	}
+	return r.Off()
+}
+
+// Name returns the RelayDrivers name
+func (r *RelayDriver) Name() string { return r.name }
+
+// Pin returns the RelayDrivers pin
+func (r *RelayDriver) Pin() string { return r.pin }
+
+// Connection returns the RelayDrivers Connection
+func (r *RelayDriver) Connection() gobot.Connection { return r.connection.(gobot.Connection) }
+
+// State returns true if the relay is on and false if it is off
+func (r *RelayDriver) State() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.on
+}
+
+// On turns the relay on, cancelling its pulse. The other relays of its
+// interlock are turned off first.
+func (r *RelayDriver) On() (err error) {
+	if r.interlock != nil {
+		r.interlock.mutex.Lock()
+		defer r.interlock.mutex.Unlock()
+		for _, other := range r.interlock.relays {
+			if other == r {
+				continue
+			}
+			if err = other.Off(); err != nil {
+				return
+			}
+		}
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cancelPulse()
+	return r.write(true)
+}
+
+// Off turns the relay off, cancelling its pulse.
+func (r *RelayDriver) Off() (err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cancelPulse()
+	return r.write(false)
+}
+
+// Toggle turns the relay on if it is off and off if it is on
+func (r *RelayDriver) Toggle() (err error) {
+	if r.State() {
+		return r.Off()
+	}
+	return r.On()
+}
+
+// Pulse turns the relay on, then off again after duration in the
+// background, unless it is turned on or off before then.
+func (r *RelayDriver) Pulse(duration time.Duration) (err error) {
+	if err = r.On(); err != nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var pulse *gobot.Timer
+	pulse = gobot.After(duration, func() {
+		r.mutex.Lock()
+		if r.pulse != pulse {
+			r.mutex.Unlock()
+			return
+		}
+		r.pulse = nil
+		err := r.write(false)
+		r.mutex.Unlock()
+		if err != nil {
+			gobot.Publish(r.Event(Error), gobot.NewDeviceError(r.Name(), "Pulse", err, false))
+		}
+	})
+	r.pulse = pulse
+	return
+}
+
+// cancelPulse cancels the pulse in progress, if any
+func (r *RelayDriver) cancelPulse() {
+	if r.pulse != nil {
+		r.pulse.Stop()
+		r.pulse = nil
+	}
+}
+
+// write sets the pin of the relay to the level energizing it if on, or
+// else to the level releasing it
+func (r *RelayDriver) write(on bool) (err error) {
+	level := byte(0)
+	if on != r.ActiveLow {
+		level = 1
+	}
+	if err = r.connection.DigitalWrite(r.pin, level); err != nil {
+		return
+	}
+	r.on = on
+	return
+}
+
+// RelayInterlock is a group of mutually exclusive relays, such as the
+// relays driving a motor in either direction: turning one on turns the
+// others off first.
+type RelayInterlock struct {
+	relays []*RelayDriver
+	mutex  sync.Mutex
+}
+
+// NewRelayInterlock returns a new RelayInterlock of relays. A relay belongs
+// to one RelayInterlock at most.
+func NewRelayInterlock(relays ...*RelayDriver) *RelayInterlock {
+	i := &RelayInterlock{relays: relays}
+	for _, r := range relays {
+		r.interlock = i
+	}
+	return i
+}
+
+// Relays returns the relays of the RelayInterlock
+func (i *RelayInterlock) Relays() []*RelayDriver { return i.relays }
@@ -0,0 +1,100 @@
+package gpio
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestRelayDriver(t *testing.T) {
+	a := newGpioTestPinsAdaptor()
+	r := NewRelayDriver(a, "relay", "1")
+	gobot.Assert(t, r.Name(), "relay")
+	gobot.Assert(t, r.Pin(), "1")
+	gobot.Assert(t, r.Connection().Name(), "")
+
+	gobot.Assert(t, r.On(), nil)
+	gobot.Assert(t, r.State(), true)
+	gobot.Assert(t, a.get("1"), 1)
+	gobot.Assert(t, r.Toggle(), nil)
+	gobot.Assert(t, r.State(), false)
+	gobot.Assert(t, a.get("1"), 0)
+	gobot.Assert(t, r.Command("Toggle")(nil), nil)
+	gobot.Assert(t, r.Command("State")(nil), true)
+	gobot.Assert(t, r.Command("Off")(nil), nil)
+	gobot.Assert(t, r.Command("State")(nil), false)
+}
+
+func TestRelayDriverActiveLow(t *testing.T) {
+	a := newGpioTestPinsAdaptor()
+	r := NewRelayDriver(a, "relay", "1")
+	r.ActiveLow = true
+
+	gobot.Assert(t, len(r.Start()), 0)
+	gobot.Assert(t, r.State(), false)
+	gobot.Assert(t, a.get("1"), 1)
+	gobot.Assert(t, r.On(), nil)
+	gobot.Assert(t, a.get("1"), 0)
+	gobot.Assert(t, len(r.Halt()), 0)
+	gobot.Assert(t, r.State(), false)
+	gobot.Assert(t, a.get("1"), 1)
+}
+
+func TestRelayDriverSafeState(t *testing.T) {
+	a := newGpioTestPinsAdaptor()
+	r := NewRelayDriver(a, "relay", "1")
+	r.SafeState = true
+
+	gobot.Assert(t, len(r.Start()), 0)
+	gobot.Assert(t, r.State(), true)
+	gobot.Assert(t, r.Off(), nil)
+	gobot.Assert(t, len(r.Halt()), 0)
+	gobot.Assert(t, r.State(), true)
+
+	testAdaptorDigitalWrite = func() (err error) { return errors.New("write error") }
+	defer func() { testAdaptorDigitalWrite = func() (err error) { return nil } }()
+	r = NewRelayDriver(newGpioTestAdaptor("adaptor"), "relay", "1")
+	gobot.Assert(t, r.Start()[0], errors.New("write error"))
+}
+
+func TestRelayDriverPulse(t *testing.T) {
+	a := newGpioTestPinsAdaptor()
+	r := NewRelayDriver(a, "relay", "1")
+
+	gobot.Assert(t, r.Pulse(10*time.Millisecond), nil)
+	gobot.Assert(t, r.State(), true)
+	<-time.After(50 * time.Millisecond)
+	gobot.Assert(t, r.State(), false)
+	gobot.Assert(t, a.get("1"), 0)
+
+	// turning the relay on cancels the pulse
+	gobot.Assert(t, r.Command("Pulse")(map[string]interface{}{"duration": 10.0}), nil)
+	gobot.Assert(t, r.On(), nil)
+	<-time.After(50 * time.Millisecond)
+	gobot.Assert(t, r.State(), true)
+
+	gobot.Assert(t, r.Pulse(10*time.Millisecond), nil)
+	gobot.Assert(t, len(r.Halt()), 0)
+	gobot.Assert(t, r.State(), false)
+}
+
+func TestRelayInterlock(t *testing.T) {
+	a := newGpioTestPinsAdaptor()
+	up := NewRelayDriver(a, "up", "1")
+	down := NewRelayDriver(a, "down", "2")
+	i := NewRelayInterlock(up, down)
+	gobot.Assert(t, i.Relays(), []*RelayDriver{up, down})
+
+	gobot.Assert(t, up.On(), nil)
+	gobot.Assert(t, a.get("1"), 1)
+	gobot.Assert(t, down.On(), nil)
+	gobot.Assert(t, up.State(), false)
+	gobot.Assert(t, down.State(), true)
+	gobot.Assert(t, a.get("1"), 0)
+	gobot.Assert(t, a.get("2"), 1)
+	gobot.Assert(t, up.Pulse(time.Millisecond), nil)
+	gobot.Assert(t, down.State(), false)
+	gobot.Assert(t, a.get("2"), 0)
+}
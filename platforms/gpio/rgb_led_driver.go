@@ -0,0 +1,217 @@
+package gpio
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var _ gobot.Driver = (*RgbLedDriver)(nil)
+
+// RgbLedDriver represents an RGB led, driven by a PWM pin for each of its
+// red, green and blue channels
+type RgbLedDriver struct {
+	// CommonAnode is whether the led has a common anode, so that its
+	// channels are lit by LOW levels, rather than a common cathode
+	CommonAnode bool
+	// Gamma is the gamma correction applied to the colors written to the
+	// led, 1 to write them as they are
+	Gamma float64
+
+	name       string
+	pins       [3]string
+	connection PwmWriter
+	color      Color
+	effect     *gobot.Timer
+	mutex      sync.Mutex
+	gobot.Eventer
+	gobot.Commander
+}
+
+// NewRgbLedDriver returns a new common cathode RgbLedDriver with a Gamma of
+// 2.2 given a PwmWriter, name and the pins of the red, green and blue
+// channels.
+//
+// Adds the following API Commands:
+// 	"SetRGB" - See RgbLedDriver.SetRGB
+// 	"SetHSV" - See RgbLedDriver.SetHSV
+// 	"SetColor" - See RgbLedDriver.SetColor
+// 	"FadeTo" - See RgbLedDriver.FadeTo, with a color name and a duration in milliseconds
+// 	"StopFade" - See RgbLedDriver.StopFade
+// 	"Off" - See RgbLedDriver.Off
+// 	"Color" - See RgbLedDriver.Color, in hex
+func NewRgbLedDriver(a PwmWriter, name string, redPin string, greenPin string, bluePin string) *RgbLedDriver {
+	l := &RgbLedDriver{
+		Gamma:      2.2,
+		name:       name,
+		pins:       [3]string{redPin, greenPin, bluePin},
+		connection: a,
+		Eventer:    gobot.NewEventer(),
+		Commander:  gobot.NewCommander(),
+	}
+
+	l.AddEvent(Error)
+
+	l.AddCommand("SetRGB", func(params map[string]interface{}) interface{} {
+		return l.SetRGB(byte(params["r"].(float64)), byte(params["g"].(float64)), byte(params["b"].(float64)))
+	})
+	l.AddCommand("SetHSV", func(params map[string]interface{}) interface{} {
+		return l.SetHSV(params["h"].(float64), params["s"].(float64), params["v"].(float64))
+	})
+	l.AddCommand("SetColor", func(params map[string]interface{}) interface{} {
+		return l.SetColor(params["color"].(string))
+	})
+	l.AddCommand("FadeTo", func(params map[string]interface{}) interface{} {
+		color, err := ParseColor(params["color"].(string))
+		if err != nil {
+			return err
+		}
+		return l.FadeTo(color, time.Duration(params["duration"].(float64))*time.Millisecond)
+	})
+	l.AddCommand("StopFade", func(params map[string]interface{}) interface{} {
+		l.StopFade()
+		return nil
+	})
+	l.AddCommand("Off", func(params map[string]interface{}) interface{} {
+		return l.Off()
+	})
+	l.AddCommand("Color", func(params map[string]interface{}) interface{} {
+		return l.Color().String()
+	})
+
+	return l
+}
+
+// Start implements the Driver interface
+//
+// Emits the Events:
+//	Error error - On error fading the led, which stops the fade
+func (l *RgbLedDriver) Start() (errs []error) { return }
+
+// Halt stops the fade of the led
+func (l *RgbLedDriver) Halt() (errs []error) {
+	l.StopFade()
+	return
+}
+
+// Name returns the RgbLedDrivers name
+func (l *RgbLedDriver) Name() string { return l.name }
+
+// Pin returns the RgbLedDrivers red pin
+func (l *RgbLedDriver) Pin() string { return l.pins[0] }
+
+// Pins returns the RgbLedDrivers red, green and blue pins
+func (l *RgbLedDriver) Pins() [3]string { return l.pins }
+
+// Connection returns the RgbLedDrivers Connection
+func (l *RgbLedDriver) Connection() gobot.Connection { return l.connection.(gobot.Connection) }
+
+// Color returns the color of the led, before gamma correction
+func (l *RgbLedDriver) Color() Color {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.color
+}
+
+// SetRGB sets the led to the color of levels r, g and b, stopping the fade
+// in progress
+func (l *RgbLedDriver) SetRGB(r byte, g byte, b byte) error {
+	return l.set(Color{r, g, b})
+}
+
+// SetHSV sets the led to the color of hue h, in degrees, saturation s and
+// value v, from 0 to 1, stopping the fade in progress
+func (l *RgbLedDriver) SetHSV(h float64, s float64, v float64) error {
+	return l.set(HSV(h, s, v))
+}
+
+// SetColor sets the led to the color named name, see ParseColor, stopping
+// the fade in progress
+func (l *RgbLedDriver) SetColor(name string) error {
+	color, err := ParseColor(name)
+	if err != nil {
+		return err
+	}
+	return l.set(color)
+}
+
+// Off turns the led off, stopping the fade in progress
+func (l *RgbLedDriver) Off() error {
+	return l.set(Color{})
+}
+
+// set stops the fade in progress and writes color
+func (l *RgbLedDriver) set(color Color) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.stopFade()
+	return l.write(color)
+}
+
+// FadeTo fades the led from its current color to color over duration, in
+// the background. It replaces the fade in progress.
+func (l *RgbLedDriver) FadeTo(color Color, duration time.Duration) error {
+	frames := int(duration / ledFrame)
+	if frames < 1 {
+		frames = 1
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.stopFade()
+	from := l.color
+	var effect *gobot.Timer
+	frame := 0
+	play := func() {
+		l.mutex.Lock()
+		if l.effect != effect {
+			l.mutex.Unlock()
+			return
+		}
+		frame++
+		err := l.write(from.Blend(color, float64(frame)/float64(frames)))
+		if frame >= frames || err != nil {
+			l.stopFade()
+		}
+		l.mutex.Unlock()
+		if err != nil {
+			gobot.Publish(l.Event(Error), gobot.NewDeviceError(l.Name(), "FadeTo", err, true))
+		}
+	}
+	effect = gobot.Every(ledFrame, play)
+	l.effect = effect
+	return nil
+}
+
+// StopFade stops the fade in progress, leaving the led as it is.
+func (l *RgbLedDriver) StopFade() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.stopFade()
+}
+
+// stopFade stops the fade in progress, if any
+func (l *RgbLedDriver) stopFade() {
+	if l.effect != nil {
+		l.effect.Stop()
+		l.effect = nil
+	}
+}
+
+// write writes color to the pins of the led, gamma corrected
+func (l *RgbLedDriver) write(color Color) (err error) {
+	corrected := color
+	if l.Gamma > 0 && l.Gamma != 1 {
+		corrected = color.Gamma(l.Gamma)
+	}
+	for i, level := range []byte{corrected.R, corrected.G, corrected.B} {
+		if l.CommonAnode {
+			level = 255 - level
+		}
+		if err = l.connection.PwmWrite(l.pins[i], level); err != nil {
+			return
+		}
+	}
+	l.color = color
+	return
+}
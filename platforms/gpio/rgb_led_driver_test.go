@@ -0,0 +1,96 @@
+package gpio
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestRgbLedDriver(t *testing.T) {
+	a := newGpioTestLevelsAdaptor()
+	l := NewRgbLedDriver(a, "rgb", "1", "2", "3")
+	l.Gamma = 1
+	gobot.Assert(t, l.Name(), "rgb")
+	gobot.Assert(t, l.Pin(), "1")
+	gobot.Assert(t, l.Pins(), [3]string{"1", "2", "3"})
+	gobot.Assert(t, len(l.Start()), 0)
+
+	gobot.Assert(t, l.SetRGB(10, 20, 30), nil)
+	gobot.Assert(t, a.levels("1", "2", "3"), []int{10, 20, 30})
+	gobot.Assert(t, l.Color(), Color{10, 20, 30})
+	gobot.Assert(t, l.SetHSV(240, 1, 1), nil)
+	gobot.Assert(t, a.levels("1", "2", "3"), []int{0, 0, 255})
+	gobot.Assert(t, l.SetColor("yellow"), nil)
+	gobot.Assert(t, a.levels("1", "2", "3"), []int{255, 255, 0})
+	gobot.Assert(t, l.SetColor("teal"), errors.New("Unknown color teal"))
+	gobot.Assert(t, l.Off(), nil)
+	gobot.Assert(t, a.levels("1", "2", "3"), []int{0, 0, 0})
+
+	gobot.Assert(t, l.Command("SetRGB")(map[string]interface{}{"r": 1.0, "g": 2.0, "b": 3.0}), nil)
+	gobot.Assert(t, l.Command("Color")(nil), "#010203")
+	gobot.Assert(t, l.Command("SetHSV")(map[string]interface{}{"h": 0.0, "s": 1.0, "v": 1.0}), nil)
+	gobot.Assert(t, l.Command("SetColor")(map[string]interface{}{"color": "#00ff00"}), nil)
+	gobot.Assert(t, l.Command("Color")(nil), "#00ff00")
+	gobot.Assert(t, l.Command("Off")(nil), nil)
+	gobot.Assert(t, l.Command("Color")(nil), "#000000")
+
+	a.err = errors.New("pwm error")
+	gobot.Assert(t, l.SetRGB(1, 1, 1), errors.New("pwm error"))
+	gobot.Assert(t, l.Color(), Color{})
+}
+
+func TestRgbLedDriverCommonAnodeAndGamma(t *testing.T) {
+	a := newGpioTestLevelsAdaptor()
+	l := NewRgbLedDriver(a, "rgb", "1", "2", "3")
+	gobot.Assert(t, l.Gamma, 2.2)
+
+	gobot.Assert(t, l.SetRGB(255, 128, 0), nil)
+	gobot.Assert(t, a.levels("1", "2", "3"), []int{255, 56, 0})
+	gobot.Assert(t, l.Color(), Color{255, 128, 0})
+
+	l.CommonAnode = true
+	gobot.Assert(t, l.SetRGB(255, 128, 0), nil)
+	gobot.Assert(t, a.levels("1", "2", "3"), []int{0, 199, 255})
+}
+
+func TestRgbLedDriverFadeTo(t *testing.T) {
+	a := newGpioTestLevelsAdaptor()
+	l := NewRgbLedDriver(a, "rgb", "1", "2", "3")
+	l.Gamma = 1
+
+	gobot.Assert(t, l.FadeTo(Color{255, 0, 100}, 100*time.Millisecond), nil)
+	<-time.After(50 * time.Millisecond)
+	c := l.Color()
+	gobot.Assert(t, c.R > 0 && c.R < 255, true)
+	<-time.After(150 * time.Millisecond)
+	gobot.Assert(t, l.Color(), Color{255, 0, 100})
+	gobot.Assert(t, a.levels("1", "2", "3"), []int{255, 0, 100})
+
+	// setting a color stops the fade
+	gobot.Assert(t, l.Command("FadeTo")(map[string]interface{}{"color": "black", "duration": 100.0}), nil)
+	gobot.Assert(t, l.SetColor("blue"), nil)
+	<-time.After(150 * time.Millisecond)
+	gobot.Assert(t, l.Color(), Color{0, 0, 255})
+	gobot.Assert(t, l.Command("FadeTo")(map[string]interface{}{"color": "teal", "duration": 100.0}), errors.New("Unknown color teal"))
+
+	gobot.Assert(t, l.FadeTo(Color{}, time.Second), nil)
+	gobot.Assert(t, l.Command("StopFade")(nil), nil)
+	<-time.After(50 * time.Millisecond)
+	gobot.Assert(t, l.Color(), Color{0, 0, 255})
+	gobot.Assert(t, len(l.Halt()), 0)
+
+	errs := make(chan error, 1)
+	gobot.Once(l.Event(Error), func(data interface{}) { errs <- data.(error) })
+	a.mutex.Lock()
+	a.err = errors.New("pwm error")
+	a.mutex.Unlock()
+	gobot.Assert(t, l.FadeTo(Color{}, 100*time.Millisecond), nil)
+	select {
+	case err := <-errs:
+		gobot.Assert(t, err.(*gobot.DeviceError).Err, errors.New("pwm error"))
+	case <-time.After(time.Second):
+		t.Errorf("Error was not published")
+	}
+}
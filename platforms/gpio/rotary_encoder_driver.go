@@ -0,0 +1,246 @@
+package gpio
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var _ gobot.Driver = (*RotaryEncoderDriver)(nil)
+
+// quadratureSteps is the step, 1 clockwise and -1 counter clockwise, of each
+// transition of the state of the two pins of an encoder, indexed by the
+// previous state << 2 | the new state. Transitions skipping a state are 0.
+var quadratureSteps = [16]int{0, -1, 1, 0, 1, 0, 0, -1, -1, 0, 0, 1, 0, 1, -1, 0}
+
+// The indexes of the levels of the pins of an encoder
+const (
+	encoderA = iota
+	encoderB
+	encoderButton
+)
+
+// RotaryEncoderDriver represents a quadrature rotary encoder, with an
+// optional push button
+type RotaryEncoderDriver struct {
+	// StepsPerDetent is the number of transitions from one detent of the
+	// encoder to the next, 4 for most encoders
+	StepsPerDetent int
+
+	name       string
+	pins       [3]string
+	halt       chan bool
+	interval   time.Duration
+	connection DigitalReader
+	stops      []func()
+	levels     [3]int
+	state      int
+	steps      int
+	position   int
+	mutex      sync.Mutex
+	gobot.Eventer
+	gobot.Commander
+}
+
+// NewRotaryEncoderDriver returns a new RotaryEncoderDriver with a polling
+// interval of 1 Millisecond given a DigitalReader, name, the pins of the A
+// and B signals of the encoder and the pin of its push button, empty if it
+// has none. The pins are watched instead of polled if the DigitalReader is
+// a DigitalWatcher.
+//
+// Optinally accepts:
+// 	time.Duration: Interval at which the pins are polled
+//
+// Adds the following API Commands:
+// 	"Position" - See RotaryEncoderDriver.Position
+// 	"Reset" - See RotaryEncoderDriver.Reset
+func NewRotaryEncoderDriver(a DigitalReader, name string, pinA string, pinB string, buttonPin string, v ...time.Duration) *RotaryEncoderDriver {
+	r := &RotaryEncoderDriver{
+		StepsPerDetent: 4,
+		name:           name,
+		pins:           [3]string{pinA, pinB, buttonPin},
+		connection:     a,
+		interval:       time.Millisecond,
+		halt:           make(chan bool),
+		Eventer:        gobot.NewEventer(),
+		Commander:      gobot.NewCommander(),
+	}
+
+	if len(v) > 0 {
+		r.interval = v[0]
+	}
+
+	r.AddEvent(Position)
+	r.AddEvent(Clockwise)
+	r.AddEvent(CounterClockwise)
+	r.AddEvent(Push)
+	r.AddEvent(Release)
+	r.AddEvent(Error)
+
+	r.AddCommand("Position", func(params map[string]interface{}) interface{} {
+		return r.Position()
+	})
+	r.AddCommand("Reset", func(params map[string]interface{}) interface{} {
+		r.Reset()
+		return nil
+	})
+
+	return r
+}
+
+// Start starts the RotaryEncoderDriver and decodes the signals of its pins,
+// watching them if the connection is a DigitalWatcher or else polling them
+// at the given interval.
+//
+// Emits the Events:
+//	Position int - The position, on each detent
+//	Clockwise int - The position, on each detent turned clockwise
+//	CounterClockwise int - The position, on each detent turned counter clockwise
+//	Push int - On button push
+//	Release int - On button release
+//	Error error - On error reading the pins
+func (r *RotaryEncoderDriver) Start() (errs []error) {
+	for i, pin := range r.pins {
+		if pin == "" {
+			continue
+		}
+		val, err := r.connection.DigitalRead(pin)
+		if err != nil {
+			return []error{err}
+		}
+		r.levels[i] = val
+	}
+	r.state = r.levels[encoderA]<<1 | r.levels[encoderB]
+
+	if w, ok := r.connection.(DigitalWatcher); ok && r.watch(w) == nil {
+		return
+	}
+	go func() {
+		for {
+			for i, pin := range r.pins {
+				if pin == "" {
+					continue
+				}
+				if val, err := r.connection.DigitalRead(pin); err != nil {
+					gobot.Publish(r.Event(Error), gobot.NewDeviceError(r.Name(), "DigitalRead", err, true))
+				} else if val != -1 {
+					r.update(i, val)
+				}
+			}
+			select {
+			case <-time.After(r.interval):
+			case <-r.halt:
+				return
+			}
+		}
+	}()
+	return
+}
+
+// watch watches the pins of the encoder, stopping the watches already
+// started if one of them fails
+func (r *RotaryEncoderDriver) watch(w DigitalWatcher) error {
+	for i, pin := range r.pins {
+		if pin == "" {
+			continue
+		}
+		i := i
+		stop, err := w.WatchDigital(pin, func(val int) { r.update(i, val) })
+		if err != nil {
+			r.unwatch()
+			return err
+		}
+		r.stops = append(r.stops, stop)
+	}
+	return nil
+}
+
+// unwatch stops watching the pins of the encoder
+func (r *RotaryEncoderDriver) unwatch() {
+	for _, stop := range r.stops {
+		stop()
+	}
+	r.stops = nil
+}
+
+// Halt stops decoding the signals of the encoder
+func (r *RotaryEncoderDriver) Halt() (errs []error) {
+	if r.stops != nil {
+		r.unwatch()
+		return
+	}
+	r.halt <- true
+	return
+}
+
+// Name returns the RotaryEncoderDrivers name
+func (r *RotaryEncoderDriver) Name() string { return r.name }
+
+// Pin returns the RotaryEncoderDrivers A pin
+func (r *RotaryEncoderDriver) Pin() string { return r.pins[encoderA] }
+
+// PinB returns the RotaryEncoderDrivers B pin
+func (r *RotaryEncoderDriver) PinB() string { return r.pins[encoderB] }
+
+// ButtonPin returns the RotaryEncoderDrivers push button pin
+func (r *RotaryEncoderDriver) ButtonPin() string { return r.pins[encoderButton] }
+
+// Connection returns the RotaryEncoderDrivers Connection
+func (r *RotaryEncoderDriver) Connection() gobot.Connection { return r.connection.(gobot.Connection) }
+
+// Position returns the number of detents the encoder was turned clockwise
+// since it was started or reset, negative if it was turned counter clockwise
+func (r *RotaryEncoderDriver) Position() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.position
+}
+
+// Reset sets the position of the encoder back to 0
+func (r *RotaryEncoderDriver) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.position, r.steps = 0, 0
+}
+
+// update decodes the new level val of the pin with index i
+func (r *RotaryEncoderDriver) update(i int, val int) {
+	r.mutex.Lock()
+	if r.levels[i] == val {
+		r.mutex.Unlock()
+		return
+	}
+	r.levels[i] = val
+	if i == encoderButton {
+		r.mutex.Unlock()
+		if val == 1 {
+			gobot.Publish(r.Event(Push), val)
+		} else {
+			gobot.Publish(r.Event(Release), val)
+		}
+		return
+	}
+
+	state := r.levels[encoderA]<<1 | r.levels[encoderB]
+	r.steps += quadratureSteps[r.state<<2|state]
+	r.state = state
+	direction := ""
+	if r.steps >= r.StepsPerDetent {
+		r.position++
+		direction = Clockwise
+	} else if r.steps <= -r.StepsPerDetent {
+		r.position--
+		direction = CounterClockwise
+	}
+	position := r.position
+	if direction != "" {
+		r.steps = 0
+	}
+	r.mutex.Unlock()
+
+	if direction != "" {
+		gobot.Publish(r.Event(direction), position)
+		gobot.Publish(r.Event(Position), position)
+	}
+}
@@ -0,0 +1,126 @@
+package gpio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// clockwise are the levels of the A and B pins of an encoder turned one
+// detent clockwise from rest
+var clockwise = [][2]int{{0, 1}, {0, 0}, {1, 0}, {1, 1}}
+
+// counterClockwise are the levels of the A and B pins of an encoder turned
+// one detent counter clockwise from rest
+var counterClockwise = [][2]int{{1, 0}, {0, 0}, {0, 1}, {1, 1}}
+
+// turn updates the pins of d with the levels of a turn
+func turn(d *RotaryEncoderDriver, levels [][2]int) {
+	for _, level := range levels {
+		d.update(encoderA, level[0])
+		d.update(encoderB, level[1])
+	}
+}
+
+func TestRotaryEncoderDriver(t *testing.T) {
+	d := NewRotaryEncoderDriver(newGpioTestPinsAdaptor(), "bot", "1", "2", "3")
+	gobot.Assert(t, d.Name(), "bot")
+	gobot.Assert(t, d.Pin(), "1")
+	gobot.Assert(t, d.PinB(), "2")
+	gobot.Assert(t, d.ButtonPin(), "3")
+	gobot.Assert(t, d.interval, time.Millisecond)
+	gobot.Assert(t, d.StepsPerDetent, 4)
+
+	d = NewRotaryEncoderDriver(newGpioTestPinsAdaptor(), "bot", "1", "2", "", 30*time.Second)
+	gobot.Assert(t, d.interval, 30*time.Second)
+}
+
+func TestRotaryEncoderDriverDecode(t *testing.T) {
+	d := NewRotaryEncoderDriver(newGpioTestPinsAdaptor(), "bot", "1", "2", "")
+	d.levels, d.state = [3]int{1, 1, 0}, 3
+
+	turn(d, clockwise)
+	gobot.Assert(t, d.Position(), 1)
+
+	// turning back
+	turn(d, counterClockwise)
+	gobot.Assert(t, d.Position(), 0)
+	turn(d, counterClockwise)
+	gobot.Assert(t, d.Position(), -1)
+	gobot.Assert(t, d.Command("Position")(nil), -1)
+
+	// bouncing on a single transition does not move
+	for i := 0; i < 5; i++ {
+		d.update(encoderA, 0)
+		d.update(encoderA, 1)
+	}
+	gobot.Assert(t, d.Position(), -1)
+
+	d.Command("Reset")(nil)
+	gobot.Assert(t, d.Position(), 0)
+}
+
+func TestRotaryEncoderDriverStart(t *testing.T) {
+	sem := make(chan int, 1)
+	a := newGpioTestPinsAdaptor()
+	a.set("1", 1)
+	a.set("2", 1)
+	d := NewRotaryEncoderDriver(a, "bot", "1", "2", "3")
+
+	gobot.Once(d.Event(Clockwise), func(data interface{}) {
+		sem <- data.(int)
+	})
+	gobot.Assert(t, len(d.Start()), 0)
+
+	for _, levels := range clockwise {
+		a.set("1", levels[0])
+		a.set("2", levels[1])
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case position := <-sem:
+		gobot.Assert(t, position, 1)
+	case <-time.After(50 * time.Millisecond):
+		t.Errorf("RotaryEncoder Event \"Clockwise\" was not published")
+	}
+
+	gobot.Once(d.Event(Push), func(data interface{}) {
+		sem <- data.(int)
+	})
+	a.set("3", 1)
+	select {
+	case <-sem:
+	case <-time.After(50 * time.Millisecond):
+		t.Errorf("RotaryEncoder Event \"Push\" was not published")
+	}
+	gobot.Assert(t, len(d.Halt()), 0)
+}
+
+func TestRotaryEncoderDriverWatch(t *testing.T) {
+	sem := make(chan int, 1)
+	a := &gpioTestWatchAdaptor{gpioTestPinsAdaptor: *newGpioTestPinsAdaptor(), watchers: make(map[string]func(int))}
+	a.set("1", 1)
+	a.set("2", 1)
+	d := NewRotaryEncoderDriver(a, "bot", "1", "2", "")
+
+	gobot.Once(d.Event(Position), func(data interface{}) {
+		sem <- data.(int)
+	})
+	gobot.Assert(t, len(d.Start()), 0)
+	gobot.Assert(t, len(a.watchers), 2)
+
+	for _, levels := range clockwise {
+		a.watchers["1"](levels[0])
+		a.watchers["2"](levels[1])
+	}
+	select {
+	case position := <-sem:
+		gobot.Assert(t, position, 1)
+	case <-time.After(50 * time.Millisecond):
+		t.Errorf("RotaryEncoder Event \"Position\" was not published")
+	}
+
+	gobot.Assert(t, len(d.Halt()), 0)
+	gobot.Assert(t, len(a.watchers), 0)
+}
@@ -1,15 +1,38 @@
 package gpio
 
-import "github.com/hybridgroup/gobot"
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
 
 var _ gobot.Driver = (*ServoDriver)(nil)
 
+// servoFrame is the interval at which eased moves update the angle of a
+// servo, the 50Hz of the pulses of hobby servos
+const servoFrame = 20 * time.Millisecond
+
+// ServoMove is an eased move of a servo to Angle over Duration
+type ServoMove struct {
+	Angle    uint8
+	Duration time.Duration
+	// Easing is Linear when nil
+	Easing Easing
+}
+
 // ServoDriver Represents a Servo
 type ServoDriver struct {
 	name       string
 	pin        string
 	connection ServoWriter
+	moves      []ServoMove
+	generation int
+	moving     bool
+	mutex      sync.Mutex
 	gobot.Commander
+	gobot.Eventer
 	CurrentAngle byte
 }
 
@@ -20,15 +43,23 @@ type ServoDriver struct {
 //	"Min" - See ServoDriver.Min
 //	"Center" - See ServoDriver.Center
 //	"Max" - See ServoDriver.Max
+//	"MoveTo" - See ServoDriver.MoveTo, with a duration in milliseconds and
+//	the name of one of Easings
+//	"Sweep" - See ServoDriver.Sweep, likewise
+//	"Stop" - See ServoDriver.Stop
 func NewServoDriver(a ServoWriter, name string, pin string) *ServoDriver {
 	s := &ServoDriver{
 		name:         name,
 		connection:   a,
 		pin:          pin,
 		Commander:    gobot.NewCommander(),
+		Eventer:      gobot.NewEventer(),
 		CurrentAngle: 0,
 	}
 
+	s.AddEvent(MoveComplete)
+	s.AddEvent(Error)
+
 	s.AddCommand("Move", func(params map[string]interface{}) interface{} {
 		angle := byte(params["angle"].(float64))
 		return s.Move(angle)
@@ -42,6 +73,27 @@ func NewServoDriver(a ServoWriter, name string, pin string) *ServoDriver {
 	s.AddCommand("Max", func(params map[string]interface{}) interface{} {
 		return s.Max()
 	})
+	s.AddCommand("MoveTo", func(params map[string]interface{}) interface{} {
+		easing, err := easingParam(params)
+		if err != nil {
+			return err
+		}
+		duration := time.Duration(params["duration"].(float64)) * time.Millisecond
+		return s.MoveTo(byte(params["angle"].(float64)), duration, easing)
+	})
+	s.AddCommand("Sweep", func(params map[string]interface{}) interface{} {
+		easing, err := easingParam(params)
+		if err != nil {
+			return err
+		}
+		duration := time.Duration(params["duration"].(float64)) * time.Millisecond
+		return s.Sweep(byte(params["min"].(float64)), byte(params["max"].(float64)), duration, easing,
+			int(params["count"].(float64)))
+	})
+	s.AddCommand("Stop", func(params map[string]interface{}) interface{} {
+		s.Stop()
+		return nil
+	})
 
 	return s
 
@@ -57,20 +109,53 @@ func (s *ServoDriver) Pin() string { return s.pin }
 func (s *ServoDriver) Connection() gobot.Connection { return s.connection.(gobot.Connection) }
 
 // Start implements the Driver interface
+//
+// Emits the Events:
+//	MoveComplete uint8 - The angle, once an eased move has reached it
+//	Error error - On error moving the servo, which drops the queued moves
 func (s *ServoDriver) Start() (errs []error) { return }
 
-// Halt implements the Driver interface
-func (s *ServoDriver) Halt() (errs []error) { return }
+// Halt stops the moves of the servo, see ServoDriver.Stop
+func (s *ServoDriver) Halt() (errs []error) {
+	s.Stop()
+	return
+}
 
 // Move sets the servo to the specified angle. Acceptable angles are 0-180
 func (s *ServoDriver) Move(angle uint8) (err error) {
 	if !(angle >= 0 && angle <= 180) {
 		return ErrServoOutOfRange
 	}
+	s.mutex.Lock()
 	s.CurrentAngle = angle
+	s.mutex.Unlock()
 	return s.connection.ServoWrite(s.Pin(), s.angleToSpan(angle))
 }
 
+// Angle returns the angle the servo was last moved to. Unlike CurrentAngle
+// it can be read while an eased move is in progress.
+func (s *ServoDriver) Angle() uint8 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.CurrentAngle
+}
+
+// Snapshot implements the gobot.Snapshotter interface, the state holds the
+// current angle
+func (s *ServoDriver) Snapshot() map[string]interface{} {
+	return map[string]interface{}{"angle": s.Angle()}
+}
+
+// Restore implements the gobot.Snapshotter interface, moving the servo back
+// to the angle
+func (s *ServoDriver) Restore(state map[string]interface{}) error {
+	angle, err := stateByte(state, "angle")
+	if err != nil {
+		return err
+	}
+	return s.Move(angle)
+}
+
 // Min sets the servo to it's minimum position
 func (s *ServoDriver) Min() (err error) {
 	return s.Move(0)
@@ -89,3 +174,128 @@ func (s *ServoDriver) Max() (err error) {
 func (s *ServoDriver) angleToSpan(angle byte) byte {
 	return byte(angle * (255 / 180))
 }
+
+// MoveTo moves the servo to angle over duration in the background, easing
+// the move with easing, Linear when nil. It replaces the moves in progress
+// or queued. MoveComplete is published with the angle once it is reached.
+func (s *ServoDriver) MoveTo(angle uint8, duration time.Duration, easing Easing) error {
+	if angle > 180 {
+		return ErrServoOutOfRange
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.moves = nil
+	s.generation++
+	s.queue(ServoMove{Angle: angle, Duration: duration, Easing: easing})
+	return nil
+}
+
+// Queue queues moves after the moves in progress or queued, so that a
+// sequence of moves runs in the background. MoveComplete is published at
+// the end of each of them.
+func (s *ServoDriver) Queue(moves ...ServoMove) error {
+	for _, move := range moves {
+		if move.Angle > 180 {
+			return ErrServoOutOfRange
+		}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.queue(moves...)
+	return nil
+}
+
+// Sweep queues count sweeps of the servo from min to max and back, each way
+// taking duration, see ServoDriver.Queue.
+func (s *ServoDriver) Sweep(min uint8, max uint8, duration time.Duration, easing Easing, count int) error {
+	moves := []ServoMove{}
+	for i := 0; i < count; i++ {
+		moves = append(moves, ServoMove{Angle: max, Duration: duration, Easing: easing},
+			ServoMove{Angle: min, Duration: duration, Easing: easing})
+	}
+	return s.Queue(moves...)
+}
+
+// Stop stops the move in progress at the current angle, and drops the
+// queued ones.
+func (s *ServoDriver) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.moves = nil
+	s.generation++
+}
+
+// Moving returns whether the servo is moving or has queued moves
+func (s *ServoDriver) Moving() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.moving
+}
+
+// queue appends moves to the queue, starting to run it if it is not
+func (s *ServoDriver) queue(moves ...ServoMove) {
+	s.moves = append(s.moves, moves...)
+	if !s.moving && len(s.moves) > 0 {
+		s.moving = true
+		go s.run()
+	}
+}
+
+// run runs the queued moves until there are no more
+func (s *ServoDriver) run() {
+	for {
+		s.mutex.Lock()
+		if len(s.moves) == 0 {
+			s.moving = false
+			s.mutex.Unlock()
+			return
+		}
+		move := s.moves[0]
+		s.moves = s.moves[1:]
+		generation := s.generation
+		s.mutex.Unlock()
+
+		if err := s.ease(move, generation); err != nil {
+			s.mutex.Lock()
+			s.moves = nil
+			s.mutex.Unlock()
+			gobot.Publish(s.Event(Error), gobot.NewDeviceError(s.Name(), "MoveTo", err, true))
+		}
+	}
+}
+
+// ease moves the servo as described by move, one frame at a time, unless
+// the generation of the moves changes
+func (s *ServoDriver) ease(move ServoMove, generation int) error {
+	easing := move.Easing
+	if easing == nil {
+		easing = Linear
+	}
+	from := float64(s.Angle())
+	start := time.Now()
+	for {
+		t := 1.0
+		if move.Duration > 0 {
+			t = math.Min(float64(time.Since(start))/float64(move.Duration), 1)
+		}
+		angle := from + (float64(move.Angle)-from)*easing(t)
+		angle = math.Max(0, math.Min(180, math.Floor(angle+0.5)))
+
+		s.mutex.Lock()
+		stopped, current := s.generation != generation, s.CurrentAngle
+		s.mutex.Unlock()
+		if stopped {
+			return nil
+		}
+		if byte(angle) != current {
+			if err := s.Move(byte(angle)); err != nil {
+				return err
+			}
+		}
+		if t == 1 {
+			gobot.Publish(s.Event(MoveComplete), move.Angle)
+			return nil
+		}
+		time.Sleep(servoFrame)
+	}
+}
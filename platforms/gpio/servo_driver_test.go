@@ -3,6 +3,7 @@ package gpio
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/hybridgroup/gobot"
 )
@@ -73,3 +74,108 @@ func TestServoDriverCenter(t *testing.T) {
 	d.Center()
 	gobot.Assert(t, d.CurrentAngle, uint8(90))
 }
+
+func TestServoDriverSnapshot(t *testing.T) {
+	d := initTestServoDriver()
+	testAdaptorServoWrite = func() (err error) {
+		return nil
+	}
+	d.Move(45)
+	gobot.Assert(t, d.Snapshot(), map[string]interface{}{"angle": uint8(45)})
+
+	d.Move(0)
+	gobot.Assert(t, d.Restore(map[string]interface{}{"angle": 45.0}), nil)
+	gobot.Assert(t, d.CurrentAngle, uint8(45))
+	gobot.Assert(t, d.Restore(map[string]interface{}{}), errors.New("snapshot has no angle"))
+}
+
+func TestServoDriverMoveTo(t *testing.T) {
+	sem := make(chan uint8, 1)
+	d := initTestServoDriver()
+	testAdaptorServoWrite = func() (err error) {
+		return nil
+	}
+	gobot.Assert(t, d.MoveTo(200, time.Second, Linear), ErrServoOutOfRange)
+
+	gobot.Once(d.Event(MoveComplete), func(data interface{}) {
+		sem <- data.(uint8)
+	})
+	gobot.Assert(t, d.MoveTo(90, 100*time.Millisecond, EaseInOut), nil)
+	time.Sleep(50 * time.Millisecond)
+	gobot.Assert(t, d.Angle() > 20 && d.Angle() < 70, true)
+	gobot.Assert(t, d.Moving(), true)
+	select {
+	case angle := <-sem:
+		gobot.Assert(t, angle, uint8(90))
+	case <-time.After(200 * time.Millisecond):
+		t.Errorf("Servo Event \"MoveComplete\" was not published")
+	}
+	gobot.Assert(t, d.Angle(), uint8(90))
+
+	// a move replaces the one in progress
+	d.MoveTo(0, time.Second, Linear)
+	time.Sleep(50 * time.Millisecond)
+	gobot.Once(d.Event(MoveComplete), func(data interface{}) {
+		sem <- data.(uint8)
+	})
+	d.Command("MoveTo")(map[string]interface{}{"angle": 180.0, "duration": 0.0, "easing": "ease_out"})
+	select {
+	case angle := <-sem:
+		gobot.Assert(t, angle, uint8(180))
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Servo Event \"MoveComplete\" was not published")
+	}
+
+	err := d.Command("MoveTo")(map[string]interface{}{"angle": 0.0, "duration": 0.0, "easing": "bounce"})
+	gobot.Assert(t, err, errors.New("Unknown easing bounce"))
+}
+
+func TestServoDriverSweep(t *testing.T) {
+	sem := make(chan uint8, 4)
+	d := initTestServoDriver()
+	testAdaptorServoWrite = func() (err error) {
+		return nil
+	}
+	gobot.On(d.Event(MoveComplete), func(data interface{}) {
+		sem <- data.(uint8)
+	})
+	gobot.Assert(t, d.Sweep(10, 170, 10*time.Millisecond, nil, 2), nil)
+	for _, expected := range []uint8{170, 10, 170, 10} {
+		select {
+		case angle := <-sem:
+			gobot.Assert(t, angle, expected)
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("Servo Event \"MoveComplete\" was not published")
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	gobot.Assert(t, d.Moving(), false)
+
+	d.Queue(ServoMove{Angle: 90, Duration: time.Second})
+	time.Sleep(50 * time.Millisecond)
+	gobot.Assert(t, len(d.Halt()), 0)
+	angle := d.Angle()
+	time.Sleep(50 * time.Millisecond)
+	gobot.Assert(t, d.Angle(), angle)
+	gobot.Assert(t, d.Moving(), false)
+}
+
+func TestServoDriverMoveToError(t *testing.T) {
+	sem := make(chan bool, 1)
+	d := initTestServoDriver()
+	testAdaptorServoWrite = func() (err error) {
+		return errors.New("pwm error")
+	}
+	gobot.Once(d.Event(Error), func(data interface{}) {
+		gobot.Assert(t, data.(*gobot.DeviceError).Err, errors.New("pwm error"))
+		sem <- true
+	})
+	d.Sweep(0, 180, 10*time.Millisecond, Linear, 3)
+	select {
+	case <-sem:
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Servo Event \"Error\" was not published")
+	}
+	time.Sleep(10 * time.Millisecond)
+	gobot.Assert(t, d.Moving(), false)
+}
@@ -0,0 +1,157 @@
+package gpio
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var _ gobot.Driver = (*ShiftRegisterDriver)(nil)
+var _ gobot.Adaptor = (*ShiftRegisterDriver)(nil)
+var _ DigitalWriter = (*ShiftRegisterDriver)(nil)
+
+// ShiftRegisterDriver represents a chain of 74HC595 shift registers. It is
+// also a DigitalWriter whose pins are the outputs of the chain, "0" to "7"
+// on the first register, "8" to "15" on the second and so on, so that other
+// drivers can drive the outputs as if they were pins:
+//
+//	leds := gpio.NewShiftRegisterDriver(firmataAdaptor, "leds", "2", "3", "4", 2)
+//	led := gpio.NewLedDriver(leds, "led", "9")
+type ShiftRegisterDriver struct {
+	name       string
+	dataPin    string
+	clockPin   string
+	latchPin   string
+	outputs    []byte
+	connection DigitalWriter
+	mutex      sync.Mutex
+	gobot.Commander
+}
+
+// NewShiftRegisterDriver returns a new ShiftRegisterDriver given a
+// DigitalWriter, name, the pins wired to the data (DS), clock (SHCP) and
+// latch (STCP) pins of the first register, and the number of registers in
+// the chain.
+//
+// Adds the following API Commands:
+// 	"DigitalWrite" - See ShiftRegisterDriver.DigitalWrite
+// 	"Clear" - See ShiftRegisterDriver.Clear
+func NewShiftRegisterDriver(a DigitalWriter, name string, dataPin string, clockPin string, latchPin string, count int) *ShiftRegisterDriver {
+	s := &ShiftRegisterDriver{
+		name:       name,
+		dataPin:    dataPin,
+		clockPin:   clockPin,
+		latchPin:   latchPin,
+		outputs:    make([]byte, count),
+		connection: a,
+		Commander:  gobot.NewCommander(),
+	}
+
+	s.AddCommand("DigitalWrite", func(params map[string]interface{}) interface{} {
+		return s.DigitalWrite(params["pin"].(string), byte(params["level"].(float64)))
+	})
+	s.AddCommand("Clear", func(params map[string]interface{}) interface{} {
+		return s.Clear()
+	})
+
+	return s
+}
+
+// Start turns all the outputs of the chain off.
+func (s *ShiftRegisterDriver) Start() (errs []error) {
+	if err := s.Clear(); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// Halt implements the Driver interface
+func (s *ShiftRegisterDriver) Halt() (errs []error) { return }
+
+// Connect implements the Adaptor interface, the outputs are driven through
+// the connection of the driver
+func (s *ShiftRegisterDriver) Connect() (errs []error) { return }
+
+// Finalize implements the Adaptor interface
+func (s *ShiftRegisterDriver) Finalize() (errs []error) { return }
+
+// Name returns the ShiftRegisterDrivers name
+func (s *ShiftRegisterDriver) Name() string { return s.name }
+
+// Pin returns the ShiftRegisterDrivers data pin
+func (s *ShiftRegisterDriver) Pin() string { return s.dataPin }
+
+// ClockPin returns the ShiftRegisterDrivers clock pin
+func (s *ShiftRegisterDriver) ClockPin() string { return s.clockPin }
+
+// LatchPin returns the ShiftRegisterDrivers latch pin
+func (s *ShiftRegisterDriver) LatchPin() string { return s.latchPin }
+
+// Connection returns the ShiftRegisterDrivers Connection
+func (s *ShiftRegisterDriver) Connection() gobot.Connection { return s.connection.(gobot.Connection) }
+
+// Outputs returns the levels of the outputs, a byte per register with the
+// level of output n of the register in bit n.
+func (s *ShiftRegisterDriver) Outputs() []byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]byte{}, s.outputs...)
+}
+
+// DigitalWrite sets the output pin of the chain to level, 0 or 1, and
+// shifts the outputs out.
+func (s *ShiftRegisterDriver) DigitalWrite(pin string, level byte) (err error) {
+	n, err := strconv.Atoi(pin)
+	if err != nil || n < 0 || n >= len(s.outputs)*8 {
+		return fmt.Errorf("Invalid shift register pin %v", pin)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if level == 0 {
+		s.outputs[n/8] &^= 1 << uint(n%8)
+	} else {
+		s.outputs[n/8] |= 1 << uint(n%8)
+	}
+	return s.shift()
+}
+
+// Write sets the levels of all the outputs, a byte per register as returned
+// by Outputs, and shifts them out at once.
+func (s *ShiftRegisterDriver) Write(outputs []byte) (err error) {
+	if len(outputs) != len(s.outputs) {
+		return fmt.Errorf("Shift register chain has %v registers, got %v bytes", len(s.outputs), len(outputs))
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	copy(s.outputs, outputs)
+	return s.shift()
+}
+
+// Clear turns all the outputs off.
+func (s *ShiftRegisterDriver) Clear() (err error) {
+	return s.Write(make([]byte, len(s.outputs)))
+}
+
+// shift shifts the outputs into the chain, the last register first and the
+// highest output of each register first, then latches them
+func (s *ShiftRegisterDriver) shift() (err error) {
+	if err = s.connection.DigitalWrite(s.latchPin, 0); err != nil {
+		return
+	}
+	for i := len(s.outputs) - 1; i >= 0; i-- {
+		for bit := uint(8); bit > 0; bit-- {
+			if err = s.connection.DigitalWrite(s.dataPin, (s.outputs[i]>>(bit-1))&1); err != nil {
+				return
+			}
+			if err = s.connection.DigitalWrite(s.clockPin, 1); err != nil {
+				return
+			}
+			if err = s.connection.DigitalWrite(s.clockPin, 0); err != nil {
+				return
+			}
+		}
+	}
+	return s.connection.DigitalWrite(s.latchPin, 1)
+}
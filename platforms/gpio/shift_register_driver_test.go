@@ -0,0 +1,88 @@
+package gpio
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// gpioTest595 simulates a chain of 74HC595 wired to the pins "data",
+// "clock" and "latch"
+type gpioTest595 struct {
+	gpioTestBareAdaptor
+	data     byte
+	clock    byte
+	latch    byte
+	register []byte
+	outputs  []byte
+}
+
+func newGpioTest595(count int) *gpioTest595 {
+	return &gpioTest595{register: make([]byte, count), outputs: make([]byte, count)}
+}
+
+func (t *gpioTest595) DigitalWrite(pin string, val byte) error {
+	switch pin {
+	case "data":
+		t.data = val
+	case "clock":
+		if val == 1 && t.clock == 0 {
+			// each register shifts its highest bit into the next one
+			for i := len(t.register) - 1; i >= 0; i-- {
+				in := t.data
+				if i > 0 {
+					in = t.register[i-1] >> 7
+				}
+				t.register[i] = t.register[i]<<1 | in
+			}
+		}
+		t.clock = val
+	case "latch":
+		if val == 1 && t.latch == 0 {
+			copy(t.outputs, t.register)
+		}
+		t.latch = val
+	}
+	return nil
+}
+
+func TestShiftRegisterDriver(t *testing.T) {
+	a := newGpioTest595(2)
+	d := NewShiftRegisterDriver(a, "leds", "data", "clock", "latch", 2)
+	gobot.Assert(t, d.Name(), "leds")
+	gobot.Assert(t, d.Pin(), "data")
+	gobot.Assert(t, d.ClockPin(), "clock")
+	gobot.Assert(t, d.LatchPin(), "latch")
+	gobot.Assert(t, len(d.Start()), 0)
+	gobot.Assert(t, a.outputs, []byte{0, 0})
+
+	gobot.Assert(t, d.DigitalWrite("0", 1), nil)
+	gobot.Assert(t, d.DigitalWrite("9", 1), nil)
+	gobot.Assert(t, d.DigitalWrite("15", 1), nil)
+	gobot.Assert(t, a.outputs, []byte{0x01, 0x82})
+	gobot.Assert(t, d.Outputs(), []byte{0x01, 0x82})
+	gobot.Assert(t, d.Command("DigitalWrite")(map[string]interface{}{"pin": "0", "level": 0.0}), nil)
+	gobot.Assert(t, a.outputs, []byte{0x00, 0x82})
+
+	gobot.Assert(t, d.Write([]byte{0xA5, 0x3C}), nil)
+	gobot.Assert(t, a.outputs, []byte{0xA5, 0x3C})
+	gobot.Assert(t, d.Write([]byte{0xA5}), errors.New("Shift register chain has 2 registers, got 1 bytes"))
+	gobot.Assert(t, d.Command("Clear")(nil), nil)
+	gobot.Assert(t, a.outputs, []byte{0, 0})
+
+	gobot.Assert(t, d.DigitalWrite("16", 1), errors.New("Invalid shift register pin 16"))
+	gobot.Assert(t, d.DigitalWrite("led", 1), errors.New("Invalid shift register pin led"))
+}
+
+func TestShiftRegisterDriverVirtualPins(t *testing.T) {
+	a := newGpioTest595(1)
+	d := NewShiftRegisterDriver(a, "leds", "data", "clock", "latch", 1)
+	led := NewLedDriver(d, "led", "3")
+	gobot.Assert(t, led.Connection().Name(), "leds")
+
+	gobot.Assert(t, led.On(), nil)
+	gobot.Assert(t, a.outputs, []byte{0x08})
+	gobot.Assert(t, led.Toggle(), nil)
+	gobot.Assert(t, a.outputs, []byte{0x00})
+}
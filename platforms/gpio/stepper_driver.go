@@ -0,0 +1,410 @@
+package gpio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var _ gobot.Driver = (*StepperDriver)(nil)
+
+// ErrStepperMoving is the error resulting when a stepper is homed while it
+// is moving
+var ErrStepperMoving = errors.New("stepper is moving")
+
+// ErrStepperSpeed is the error resulting when a stepper is given a speed or
+// acceleration which is not positive, at which it would never take a step
+// or step without delay
+var ErrStepperSpeed = errors.New("stepper speed and acceleration must be positive")
+
+// StepperMode is how a stepper is wired
+type StepperMode int
+
+const (
+	// StepperStepDir is a stepper driven by a driver board, such as the
+	// A4988, with a step pin and a direction pin
+	StepperStepDir StepperMode = iota
+	// StepperTwoWire is a stepper driven through an H-bridge by two pins
+	StepperTwoWire
+	// StepperFourWire is a stepper driven through an H-bridge, such as the
+	// ULN2003 or L298N, by four pins
+	StepperFourWire
+)
+
+// stepperPins is the number of pins of each StepperMode
+var stepperPins = map[StepperMode]int{StepperStepDir: 2, StepperTwoWire: 2, StepperFourWire: 4}
+
+// stepperPhases are the levels of the pins of a StepperTwoWire or
+// StepperFourWire stepper at each phase of a full step sequence
+var stepperPhases = map[StepperMode][4][]byte{
+	StepperTwoWire:  {{1, 0}, {1, 1}, {0, 1}, {0, 0}},
+	StepperFourWire: {{1, 0, 1, 0}, {0, 1, 1, 0}, {0, 1, 0, 1}, {1, 0, 0, 1}},
+}
+
+// StepperDriver represents a stepper motor. Moves accelerate up to
+// MaxSpeed and decelerate to a stop at their target, following a
+// trapezoidal speed profile.
+type StepperDriver struct {
+	// MaxSpeed is the top speed of moves, in steps per second
+	MaxSpeed float64
+	// Acceleration is the acceleration and deceleration of moves, in steps
+	// per second per second
+	Acceleration float64
+	// HomingSpeed is the constant speed of Home, in steps per second
+	HomingSpeed float64
+
+	name       string
+	mode       StepperMode
+	pins       []string
+	connection DigitalWriter
+	position   int
+	target     int
+	speed      float64
+	direction  int
+	moving     bool
+	mutex      sync.Mutex
+	gobot.Eventer
+	gobot.Commander
+}
+
+// NewStepperDriver returns a new StepperDriver with a MaxSpeed of 200 steps
+// per second and an Acceleration of 400 steps per second per second given
+// a DigitalWriter, name, mode and the pins of the mode: the step and
+// direction pins of StepperStepDir, or the pins of the coils of
+// StepperTwoWire and StepperFourWire.
+//
+// Adds the following API Commands:
+// 	"MoveTo" - See StepperDriver.MoveTo
+// 	"MoveBy" - See StepperDriver.MoveBy
+// 	"SetSpeed" - See StepperDriver.SetSpeed
+// 	"SetAcceleration" - See StepperDriver.SetAcceleration
+// 	"Stop" - See StepperDriver.Stop
+// 	"Position" - See StepperDriver.Position
+func NewStepperDriver(a DigitalWriter, name string, mode StepperMode, pins ...string) *StepperDriver {
+	s := &StepperDriver{
+		MaxSpeed:     200,
+		Acceleration: 400,
+		HomingSpeed:  50,
+		name:         name,
+		mode:         mode,
+		pins:         pins,
+		connection:   a,
+		direction:    1,
+		Eventer:      gobot.NewEventer(),
+		Commander:    gobot.NewCommander(),
+	}
+
+	s.AddEvent(MoveComplete)
+	s.AddEvent(Error)
+
+	s.AddCommand("MoveTo", func(params map[string]interface{}) interface{} {
+		return s.MoveTo(int(params["position"].(float64)))
+	})
+	s.AddCommand("MoveBy", func(params map[string]interface{}) interface{} {
+		return s.MoveBy(int(params["steps"].(float64)))
+	})
+	s.AddCommand("SetSpeed", func(params map[string]interface{}) interface{} {
+		return s.SetSpeed(params["speed"].(float64))
+	})
+	s.AddCommand("SetAcceleration", func(params map[string]interface{}) interface{} {
+		return s.SetAcceleration(params["acceleration"].(float64))
+	})
+	s.AddCommand("Stop", func(params map[string]interface{}) interface{} {
+		s.Stop()
+		return nil
+	})
+	s.AddCommand("Position", func(params map[string]interface{}) interface{} {
+		return s.Position()
+	})
+
+	return s
+}
+
+// Start checks the pins of the StepperDriver against its mode.
+//
+// Emits the Events:
+//	MoveComplete int - The position, once a move has reached its target
+//	Error error - On error stepping, which ends the move
+func (s *StepperDriver) Start() (errs []error) {
+	if err := s.checkPins(); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// checkPins returns an error if the pins of the stepper do not match its
+// mode
+func (s *StepperDriver) checkPins() error {
+	if n, ok := stepperPins[s.mode]; !ok {
+		return fmt.Errorf("Unknown stepper mode %v", s.mode)
+	} else if len(s.pins) != n {
+		return fmt.Errorf("Stepper mode %v needs %v pins, got %v", s.mode, n, len(s.pins))
+	}
+	return nil
+}
+
+// Halt stops the stepper immediately, without decelerating
+func (s *StepperDriver) Halt() (errs []error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.target, s.speed = s.position, 0
+	return
+}
+
+// Name returns the StepperDrivers name
+func (s *StepperDriver) Name() string { return s.name }
+
+// Pin returns the StepperDrivers first pin, the step pin of StepperStepDir
+func (s *StepperDriver) Pin() string {
+	if len(s.pins) == 0 {
+		return ""
+	}
+	return s.pins[0]
+}
+
+// Pins returns the StepperDrivers pins
+func (s *StepperDriver) Pins() []string { return s.pins }
+
+// Connection returns the StepperDrivers Connection
+func (s *StepperDriver) Connection() gobot.Connection { return s.connection.(gobot.Connection) }
+
+// Position returns the position of the stepper, in steps from where it was
+// started or homed
+func (s *StepperDriver) Position() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.position
+}
+
+// Moving returns whether the stepper is moving
+func (s *StepperDriver) Moving() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.moving
+}
+
+// SetSpeed sets the MaxSpeed of the moves, in steps per second, including
+// the current one. Returns ErrStepperSpeed if speed is not positive.
+func (s *StepperDriver) SetSpeed(speed float64) error {
+	if speed <= 0 {
+		return ErrStepperSpeed
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.MaxSpeed = speed
+	return nil
+}
+
+// SetAcceleration sets the Acceleration of the moves, in steps per second
+// per second, including the current one. Returns ErrStepperSpeed if
+// acceleration is not positive.
+func (s *StepperDriver) SetAcceleration(acceleration float64) error {
+	if acceleration <= 0 {
+		return ErrStepperSpeed
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.Acceleration = acceleration
+	return nil
+}
+
+// SetHomingSpeed sets the HomingSpeed, in steps per second. Returns
+// ErrStepperSpeed if speed is not positive.
+func (s *StepperDriver) SetHomingSpeed(speed float64) error {
+	if speed <= 0 {
+		return ErrStepperSpeed
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.HomingSpeed = speed
+	return nil
+}
+
+// MoveTo moves the stepper to position in the background, changing the
+// target of the current move if there is one. MoveComplete is published
+// once the stepper reaches it. Returns ErrStepperSpeed if the MaxSpeed or
+// Acceleration of the stepper is not positive.
+func (s *StepperDriver) MoveTo(position int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.validProfile() {
+		return ErrStepperSpeed
+	}
+	s.target = position
+	if !s.moving {
+		s.moving = true
+		go s.run()
+	}
+	return nil
+}
+
+// MoveBy moves the stepper by steps from its target, see MoveTo.
+func (s *StepperDriver) MoveBy(steps int) error {
+	s.mutex.Lock()
+	target := s.target + steps
+	s.mutex.Unlock()
+	return s.MoveTo(target)
+}
+
+// validProfile returns true if the MaxSpeed and Acceleration of the stepper
+// are positive
+func (s *StepperDriver) validProfile() bool {
+	return s.MaxSpeed > 0 && s.Acceleration > 0
+}
+
+// Stop decelerates the stepper to a stop, ending the current move.
+func (s *StepperDriver) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.target = s.position + s.direction*int(math.Ceil(s.stoppingDistance()))
+}
+
+// Home steps the stepper at HomingSpeed in direction, 1 or -1, until
+// limitPin reads 1, then sets its position to 0. Returns
+// ErrDigitalReadUnsupported if the connection is not a DigitalReader, and
+// ErrStepperSpeed if HomingSpeed is not positive.
+func (s *StepperDriver) Home(limitPin string, direction int) (err error) {
+	reader, ok := s.connection.(DigitalReader)
+	if !ok {
+		return ErrDigitalReadUnsupported
+	}
+	s.mutex.Lock()
+	if s.moving {
+		s.mutex.Unlock()
+		return ErrStepperMoving
+	} else if s.HomingSpeed <= 0 {
+		s.mutex.Unlock()
+		return ErrStepperSpeed
+	}
+	s.moving = true
+	if direction >= 0 {
+		direction = 1
+	} else {
+		direction = -1
+	}
+	delay := time.Duration(float64(time.Second) / s.HomingSpeed)
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		s.moving = false
+		if err == nil {
+			s.position, s.target = 0, 0
+		}
+		s.mutex.Unlock()
+	}()
+	for {
+		val, err := reader.DigitalRead(limitPin)
+		if err != nil {
+			return err
+		} else if val == 1 {
+			return nil
+		}
+		s.mutex.Lock()
+		s.position += direction
+		position := s.position
+		s.mutex.Unlock()
+		if err = s.step(position, direction); err != nil {
+			return err
+		}
+		time.Sleep(delay)
+	}
+}
+
+// stoppingDistance returns the number of steps the stepper needs to
+// decelerate to a stop from its speed
+func (s *StepperDriver) stoppingDistance() float64 {
+	return s.speed * s.speed / (2 * s.Acceleration)
+}
+
+// run steps the stepper until it reaches its target, accelerating by one
+// step worth of Acceleration at each step, or decelerating when it is
+// within its stopping distance of the target or moving away from it
+func (s *StepperDriver) run() {
+	for {
+		s.mutex.Lock()
+		distance := s.target - s.position
+		if distance == 0 {
+			s.moving, s.speed = false, 0
+			position := s.position
+			s.mutex.Unlock()
+			gobot.Publish(s.Event(MoveComplete), position)
+			return
+		}
+		want := 1
+		if distance < 0 {
+			want, distance = -1, -distance
+		}
+		if s.speed == 0 {
+			s.direction = want
+		}
+		if !s.validProfile() {
+			// MaxSpeed or Acceleration were changed during the move
+			s.moving, s.speed, s.target = false, 0, s.position
+			s.mutex.Unlock()
+			gobot.Publish(s.Event(Error), gobot.NewDeviceError(s.Name(), "Step", ErrStepperSpeed, false))
+			return
+		}
+
+		minimum := 2 * s.Acceleration
+		speed2 := s.speed * s.speed
+		if s.direction != want || s.stoppingDistance() >= float64(distance) {
+			speed2 -= minimum
+			if speed2 < minimum && s.direction != want {
+				// stopped, reverse on the next step
+				s.speed = 0
+				s.mutex.Unlock()
+				continue
+			}
+			speed2 = math.Max(speed2, minimum)
+		} else {
+			speed2 = math.Min(speed2+minimum, s.MaxSpeed*s.MaxSpeed)
+		}
+		s.speed = math.Sqrt(speed2)
+		s.position += s.direction
+		position, direction := s.position, s.direction
+		delay := time.Duration(float64(time.Second) / s.speed)
+		s.mutex.Unlock()
+
+		if err := s.step(position, direction); err != nil {
+			s.mutex.Lock()
+			s.moving, s.speed, s.target = false, 0, s.position
+			s.mutex.Unlock()
+			gobot.Publish(s.Event(Error), gobot.NewDeviceError(s.Name(), "Step", err, false))
+			return
+		}
+		time.Sleep(delay)
+	}
+}
+
+// step drives the pins of the stepper to take a step in direction to
+// position
+func (s *StepperDriver) step(position int, direction int) error {
+	if err := s.checkPins(); err != nil {
+		return err
+	}
+	if s.mode == StepperStepDir {
+		level := byte(0)
+		if direction > 0 {
+			level = 1
+		}
+		if err := s.connection.DigitalWrite(s.pins[1], level); err != nil {
+			return err
+		}
+		if err := s.connection.DigitalWrite(s.pins[0], 1); err != nil {
+			return err
+		}
+		return s.connection.DigitalWrite(s.pins[0], 0)
+	}
+	phase := ((position % 4) + 4) % 4
+	for i, level := range stepperPhases[s.mode][phase] {
+		if err := s.connection.DigitalWrite(s.pins[i], level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
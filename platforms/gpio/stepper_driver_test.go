@@ -0,0 +1,155 @@
+package gpio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// newTestStepper returns a fast StepperDriver of a with mode and pins
+func newTestStepper(a DigitalWriter, mode StepperMode, pins ...string) *StepperDriver {
+	s := NewStepperDriver(a, "bot", mode, pins...)
+	s.MaxSpeed, s.Acceleration = 5000, 500000
+	return s
+}
+
+// waitMove waits for s to publish MoveComplete and returns its position
+func waitMove(t *testing.T, s *StepperDriver, move func()) int {
+	sem := make(chan int, 1)
+	gobot.Once(s.Event(MoveComplete), func(data interface{}) {
+		sem <- data.(int)
+	})
+	move()
+	select {
+	case position := <-sem:
+		return position
+	case <-time.After(time.Second):
+		t.Errorf("Stepper Event \"MoveComplete\" was not published")
+	}
+	return 0
+}
+
+func TestStepperDriver(t *testing.T) {
+	s := NewStepperDriver(newGpioTestPinsAdaptor(), "bot", StepperFourWire, "1", "2", "3", "4")
+	gobot.Assert(t, s.Name(), "bot")
+	gobot.Assert(t, s.Pin(), "1")
+	gobot.Assert(t, s.Pins(), []string{"1", "2", "3", "4"})
+	gobot.Assert(t, s.MaxSpeed, 200.0)
+	gobot.Assert(t, s.Acceleration, 400.0)
+	gobot.Assert(t, len(s.Start()), 0)
+
+	s = NewStepperDriver(newGpioTestPinsAdaptor(), "bot", StepperStepDir, "1")
+	gobot.Assert(t, s.Start()[0].Error(), "Stepper mode 0 needs 2 pins, got 1")
+	gobot.Assert(t, s.SetSpeed(10), nil)
+	gobot.Assert(t, s.MaxSpeed, 10.0)
+	gobot.Assert(t, s.SetAcceleration(20), nil)
+	gobot.Assert(t, s.Acceleration, 20.0)
+	gobot.Assert(t, s.SetHomingSpeed(30), nil)
+	gobot.Assert(t, s.HomingSpeed, 30.0)
+}
+
+func TestStepperDriverSpeedNotPositive(t *testing.T) {
+	a := newGpioTestPinsAdaptor()
+	s := newTestStepper(a, StepperStepDir, "step", "dir")
+	gobot.Assert(t, s.SetSpeed(0), ErrStepperSpeed)
+	gobot.Assert(t, s.SetSpeed(-10), ErrStepperSpeed)
+	gobot.Assert(t, s.SetAcceleration(0), ErrStepperSpeed)
+	gobot.Assert(t, s.SetHomingSpeed(0), ErrStepperSpeed)
+	gobot.Assert(t, s.MaxSpeed, 5000.0)
+	gobot.Assert(t, s.Command("SetSpeed")(map[string]interface{}{"speed": 0.0}), ErrStepperSpeed)
+	gobot.Assert(t, s.Command("SetAcceleration")(map[string]interface{}{"acceleration": -1.0}), ErrStepperSpeed)
+
+	s.HomingSpeed = 0
+	gobot.Assert(t, s.Home("limit", 1), ErrStepperSpeed)
+	gobot.Assert(t, s.Moving(), false)
+
+	s.Acceleration = 0
+	gobot.Assert(t, s.MoveTo(10), ErrStepperSpeed)
+	gobot.Assert(t, s.Command("MoveBy")(map[string]interface{}{"steps": 10.0}), ErrStepperSpeed)
+	gobot.Assert(t, s.Moving(), false)
+	gobot.Assert(t, a.writes, 0)
+}
+
+func TestStepperDriverMoveTo(t *testing.T) {
+	a := newGpioTestPinsAdaptor()
+	s := newTestStepper(a, StepperFourWire, "1", "2", "3", "4")
+
+	gobot.Assert(t, waitMove(t, s, func() { s.MoveTo(102) }), 102)
+	gobot.Assert(t, s.Position(), 102)
+	gobot.Assert(t, s.Moving(), false)
+	gobot.Assert(t, s.speed, 0.0)
+	gobot.Assert(t, a.writes, 102*4)
+	// phase 2 of the full step sequence
+	gobot.Assert(t, []int{a.get("1"), a.get("2"), a.get("3"), a.get("4")}, []int{0, 1, 0, 1})
+
+	gobot.Assert(t, waitMove(t, s, func() { s.MoveBy(-103) }), -1)
+	gobot.Assert(t, []int{a.get("1"), a.get("2"), a.get("3"), a.get("4")}, []int{1, 0, 0, 1})
+
+	gobot.Assert(t, waitMove(t, s, func() { s.Command("MoveTo")(map[string]interface{}{"position": 5.0}) }), 5)
+	gobot.Assert(t, s.Command("Position")(nil), 5)
+}
+
+func TestStepperDriverStepDir(t *testing.T) {
+	a := newGpioTestPinsAdaptor()
+	s := newTestStepper(a, StepperStepDir, "step", "dir")
+
+	waitMove(t, s, func() { s.MoveTo(10) })
+	gobot.Assert(t, a.get("dir"), 1)
+	gobot.Assert(t, a.get("step"), 0)
+	gobot.Assert(t, a.writes, 30)
+	waitMove(t, s, func() { s.MoveTo(0) })
+	gobot.Assert(t, a.get("dir"), 0)
+}
+
+func TestStepperDriverAcceleration(t *testing.T) {
+	s := newTestStepper(newGpioTestPinsAdaptor(), StepperTwoWire, "1", "2")
+	s.MaxSpeed, s.Acceleration = 5000, 20000
+
+	// reversing while moving decelerates before turning back
+	waitMove(t, s, func() {
+		s.MoveTo(1000)
+		for s.Position() < 100 {
+			time.Sleep(time.Millisecond)
+		}
+		s.mutex.Lock()
+		// the speed after n steps is sqrt(2 * Acceleration * n)
+		gobot.Assert(t, s.speed > 1950 && s.speed < 2300, true)
+		s.mutex.Unlock()
+		s.MoveTo(0)
+	})
+	gobot.Assert(t, s.Position(), 0)
+}
+
+func TestStepperDriverStop(t *testing.T) {
+	s := newTestStepper(newGpioTestPinsAdaptor(), StepperTwoWire, "1", "2")
+	s.MaxSpeed, s.Acceleration = 1000, 20000
+
+	position := waitMove(t, s, func() {
+		s.MoveTo(10000)
+		for s.Position() < 100 {
+			time.Sleep(time.Millisecond)
+		}
+		s.Command("Stop")(nil)
+	})
+	// 25 steps are needed to stop from 1000 steps per second
+	gobot.Assert(t, position > 100 && position < 200, true)
+}
+
+func TestStepperDriverHome(t *testing.T) {
+	a := newGpioTestPinsAdaptor()
+	s := newTestStepper(a, StepperStepDir, "step", "dir")
+	s.HomingSpeed = 10000
+	waitMove(t, s, func() { s.MoveTo(20) })
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		a.set("limit", 1)
+	}()
+	gobot.Assert(t, s.Home("limit", -1), nil)
+	gobot.Assert(t, s.Position(), 0)
+	gobot.Assert(t, a.get("dir"), 0)
+
+	s = newTestStepper(&gpioTestDigitalWriter{}, StepperStepDir, "step", "dir")
+	gobot.Assert(t, s.Home("limit", -1), ErrDigitalReadUnsupported)
+}
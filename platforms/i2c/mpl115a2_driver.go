@@ -70,20 +70,20 @@ func (h *MPL115A2Driver) Start() (errs []error) {
 	go func() {
 		for {
 			if err := h.connection.I2cWrite([]byte{MPL115A2_REGISTER_STARTCONVERSION, 0}); err != nil {
-				gobot.Publish(h.Event(Error), err)
+				gobot.Publish(h.Event(Error), gobot.NewDeviceError(h.Name(), "I2cWrite", err, true))
 				continue
 
 			}
 			<-time.After(5 * time.Millisecond)
 
 			if err := h.connection.I2cWrite([]byte{MPL115A2_REGISTER_PRESSURE_MSB}); err != nil {
-				gobot.Publish(h.Event(Error), err)
+				gobot.Publish(h.Event(Error), gobot.NewDeviceError(h.Name(), "I2cWrite", err, true))
 				continue
 			}
 
 			ret, err := h.connection.I2cRead(4)
 			if err != nil {
-				gobot.Publish(h.Event(Error), err)
+				gobot.Publish(h.Event(Error), gobot.NewDeviceError(h.Name(), "I2cRead", err, true))
 				continue
 			}
 			if len(ret) == 4 {
@@ -71,13 +71,13 @@ func (h *MPU6050Driver) Start() (errs []error) {
 	go func() {
 		for {
 			if err := h.connection.I2cWrite([]byte{MPU6050_RA_ACCEL_XOUT_H}); err != nil {
-				gobot.Publish(h.Event(Error), err)
+				gobot.Publish(h.Event(Error), gobot.NewDeviceError(h.Name(), "I2cWrite", err, true))
 				continue
 			}
 
 			ret, err := h.connection.I2cRead(14)
 			if err != nil {
-				gobot.Publish(h.Event(Error), err)
+				gobot.Publish(h.Event(Error), gobot.NewDeviceError(h.Name(), "I2cRead", err, true))
 				continue
 			}
 			buf := bytes.NewBuffer(ret)
@@ -63,21 +63,21 @@ func (w *WiichuckDriver) Start() (errs []error) {
 	go func() {
 		for {
 			if err := w.connection.I2cWrite([]byte{0x40, 0x00}); err != nil {
-				gobot.Publish(w.Event(Error), err)
+				gobot.Publish(w.Event(Error), gobot.NewDeviceError(w.Name(), "I2cWrite", err, true))
 				continue
 			}
 			if err := w.connection.I2cWrite([]byte{0x00}); err != nil {
-				gobot.Publish(w.Event(Error), err)
+				gobot.Publish(w.Event(Error), gobot.NewDeviceError(w.Name(), "I2cWrite", err, true))
 				continue
 			}
 			newValue, err := w.connection.I2cRead(6)
 			if err != nil {
-				gobot.Publish(w.Event(Error), err)
+				gobot.Publish(w.Event(Error), gobot.NewDeviceError(w.Name(), "I2cRead", err, true))
 				continue
 			}
 			if len(newValue) == 6 {
 				if err = w.update(newValue); err != nil {
-					gobot.Publish(w.Event(Error), err)
+					gobot.Publish(w.Event(Error), gobot.NewDeviceError(w.Name(), "Decode", err, true))
 					continue
 				}
 			}
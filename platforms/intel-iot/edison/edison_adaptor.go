@@ -21,6 +21,8 @@ var _ gpio.PwmWriter = (*EdisonAdaptor)(nil)
 
 var _ i2c.I2c = (*EdisonAdaptor)(nil)
 
+var _ gobot.DryRunner = (*EdisonAdaptor)(nil)
+
 func writeFile(path string, data []byte) (i int, err error) {
 	file, err := sysfs.OpenFile(path, os.O_WRONLY, 0644)
 	defer file.Close()
@@ -67,6 +69,7 @@ type EdisonAdaptor struct {
 	pwmPins     map[int]*pwmPin
 	i2cDevice   io.ReadWriteCloser
 	connect     func(e *EdisonAdaptor) (err error)
+	gobot.DryRunner
 }
 
 var sysfsPinMap = map[string]sysfsPin{
@@ -193,7 +196,8 @@ func changePinMode(pin, mode string) (err error) {
 // NewEdisonAdaptor returns a new EdisonAdaptor with specified name
 func NewEdisonAdaptor(name string) *EdisonAdaptor {
 	return &EdisonAdaptor{
-		name: name,
+		name:      name,
+		DryRunner: gobot.NewDryRunner(),
 		connect: func(e *EdisonAdaptor) (err error) {
 			e.tristate = sysfs.NewDigitalPin(214)
 			if err = e.tristate.Export(); err != nil {
@@ -262,6 +266,9 @@ func (e *EdisonAdaptor) Name() string { return e.name }
 
 // Connect initializes the Edison for use with the Arduino beakout board
 func (e *EdisonAdaptor) Connect() (errs []error) {
+	if e.DryRunning() {
+		return
+	}
 	e.digitalPins = make(map[int]sysfs.DigitalPin)
 	e.pwmPins = make(map[int]*pwmPin)
 	if err := e.connect(e); err != nil {
@@ -272,6 +279,9 @@ func (e *EdisonAdaptor) Connect() (errs []error) {
 
 // Finalize releases all i2c devices and exported analog, digital, pwm pins.
 func (e *EdisonAdaptor) Finalize() (errs []error) {
+	if e.DryRunning() {
+		return
+	}
 	if err := e.tristate.Unexport(); err != nil {
 		errs = append(errs, err)
 	}
@@ -382,6 +392,9 @@ func (e *EdisonAdaptor) digitalPin(pin string, dir string) (sysfsPin sysfs.Digit
 
 // DigitalRead reads digital value from pin
 func (e *EdisonAdaptor) DigitalRead(pin string) (i int, err error) {
+	if e.DryRunning() {
+		return
+	}
 	sysfsPin, err := e.digitalPin(pin, "in")
 	if err != nil {
 		return
@@ -391,6 +404,9 @@ func (e *EdisonAdaptor) DigitalRead(pin string) (i int, err error) {
 
 // DigitalWrite writes a value to the pin. Acceptable values are 1 or 0.
 func (e *EdisonAdaptor) DigitalWrite(pin string, val byte) (err error) {
+	if e.Record("DigitalWrite", pin, val) {
+		return
+	}
 	sysfsPin, err := e.digitalPin(pin, "out")
 	if err != nil {
 		return
@@ -400,6 +416,9 @@ func (e *EdisonAdaptor) DigitalWrite(pin string, val byte) (err error) {
 
 // PwmWrite writes the 0-254 value to the specified pin
 func (e *EdisonAdaptor) PwmWrite(pin string, val byte) (err error) {
+	if e.Record("PwmWrite", pin, val) {
+		return
+	}
 	sysPin := sysfsPinMap[pin]
 	if sysPin.pwmPin != -1 {
 		if e.pwmPins[sysPin.pwmPin] == nil {
@@ -433,6 +452,9 @@ func (e *EdisonAdaptor) PwmWrite(pin string, val byte) (err error) {
 
 // AnalogRead returns value from analog reading of specified pin
 func (e *EdisonAdaptor) AnalogRead(pin string) (val int, err error) {
+	if e.DryRunning() {
+		return
+	}
 	buf, err := readFile(
 		"/sys/bus/iio/devices/iio:device1/in_voltage" + pin + "_raw",
 	)
@@ -444,6 +466,9 @@ func (e *EdisonAdaptor) AnalogRead(pin string) (val int, err error) {
 
 // I2cStart initializes i2c device for addresss
 func (e *EdisonAdaptor) I2cStart(address byte) (err error) {
+	if e.Record("I2cStart", address) {
+		return
+	}
 	if err = e.tristate.Write(sysfs.LOW); err != nil {
 		return
 	}
@@ -493,12 +518,18 @@ func (e *EdisonAdaptor) I2cStart(address byte) (err error) {
 
 // I2cWrite writes data to i2c device
 func (e *EdisonAdaptor) I2cWrite(data []byte) (err error) {
+	if e.Record("I2cWrite", data) {
+		return
+	}
 	_, err = e.i2cDevice.Write(data)
 	return
 }
 
 // I2cRead returns size bytes from the i2c device
 func (e *EdisonAdaptor) I2cRead(size uint) (data []byte, err error) {
+	if e.DryRunning() {
+		return make([]byte, size), nil
+	}
 	data = make([]byte, size)
 	_, err = e.i2cDevice.Read(data)
 	return
@@ -12,6 +12,9 @@ type MqttAdaptor struct {
 	Host     string
 	clientID string
 	client   *mqtt.MqttClient
+	// Retry retries connecting to the broker as described by the policy when
+	// set, and makes Connect return the error once it gives up
+	Retry *gobot.RetryPolicy
 }
 
 // NewMqttAdaptor creates a new mqtt adaptor with specified name, host and client id
@@ -28,7 +31,17 @@ func (a *MqttAdaptor) Name() string { return a.name }
 func (a *MqttAdaptor) Connect() (errs []error) {
 	opts := createClientOptions(a.clientID, a.Host)
 	a.client = mqtt.NewClient(opts)
-	a.client.Start()
+	if a.Retry == nil {
+		a.client.Start()
+		return
+	}
+	err := gobot.RetryUntil(nil, *a.Retry, func() (err error) {
+		_, err = a.client.Start()
+		return
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
 	return
 }
 
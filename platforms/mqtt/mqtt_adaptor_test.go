@@ -16,6 +16,12 @@ func TestMqttAdaptorConnect(t *testing.T) {
 	gobot.Assert(t, len(a.Connect()), 0)
 }
 
+func TestMqttAdaptorConnectRetry(t *testing.T) {
+	a := NewMqttAdaptor("mqtt", "tcp://localhost:1", "client")
+	a.Retry = &gobot.RetryPolicy{MaxAttempts: 2}
+	gobot.Refute(t, len(a.Connect()), 0)
+}
+
 func TestMqttAdaptorFinalize(t *testing.T) {
 	a := initTestMqttAdaptor()
 	gobot.Assert(t, len(a.Finalize()), 0)
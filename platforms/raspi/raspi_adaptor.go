@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/platforms/gpio"
@@ -16,10 +17,14 @@ import (
 var _ gobot.Adaptor = (*RaspiAdaptor)(nil)
 
 var _ gpio.DigitalReader = (*RaspiAdaptor)(nil)
+var _ gpio.DigitalWatcher = (*RaspiAdaptor)(nil)
 var _ gpio.DigitalWriter = (*RaspiAdaptor)(nil)
+var _ gpio.PulseTrainReader = (*RaspiAdaptor)(nil)
 
 var _ i2c.I2c = (*RaspiAdaptor)(nil)
 
+var _ gobot.DryRunner = (*RaspiAdaptor)(nil)
+
 var readFile = func() ([]byte, error) {
 	return ioutil.ReadFile("/proc/cpuinfo")
 }
@@ -30,6 +35,7 @@ type RaspiAdaptor struct {
 	i2cLocation string
 	digitalPins map[int]sysfs.DigitalPin
 	i2cDevice   io.ReadWriteCloser
+	gobot.DryRunner
 }
 
 var pins = map[string]map[string]int{
@@ -124,6 +130,7 @@ func NewRaspiAdaptor(name string) *RaspiAdaptor {
 	r := &RaspiAdaptor{
 		name:        name,
 		digitalPins: make(map[int]sysfs.DigitalPin),
+		DryRunner:   gobot.NewDryRunner(),
 	}
 	content, _ := readFile()
 	for _, v := range strings.Split(string(content), "\n") {
@@ -154,6 +161,9 @@ func (r *RaspiAdaptor) Connect() (errs []error) {
 
 // Finalize closes connection to board and pins
 func (r *RaspiAdaptor) Finalize() (errs []error) {
+	if r.DryRunning() {
+		return
+	}
 	for _, pin := range r.digitalPins {
 		if pin != nil {
 			if err := pin.Unexport(); err != nil {
@@ -198,6 +208,9 @@ func (r *RaspiAdaptor) digitalPin(pin string, dir string) (sysfsPin sysfs.Digita
 
 // DigitalRead reads digital value from pin
 func (r *RaspiAdaptor) DigitalRead(pin string) (val int, err error) {
+	if r.DryRunning() {
+		return
+	}
 	sysfsPin, err := r.digitalPin(pin, sysfs.IN)
 	if err != nil {
 		return
@@ -205,8 +218,49 @@ func (r *RaspiAdaptor) DigitalRead(pin string) (val int, err error) {
 	return sysfsPin.Read()
 }
 
+// WatchDigital calls f with the value of pin each time it changes, as
+// signalled by the interrupts of the gpio, until stop is called
+func (r *RaspiAdaptor) WatchDigital(pin string, f func(val int)) (stop func(), err error) {
+	if r.DryRunning() {
+		return func() {}, nil
+	}
+	sysfsPin, err := r.digitalPin(pin, sysfs.IN)
+	if err != nil {
+		return
+	}
+	return sysfs.Watch(sysfsPin, f)
+}
+
+// PulseIn waits up to timeout for pin to reach level and returns how long it
+// stays at level, timed by polling the pin, see sysfs.PulseIn
+func (r *RaspiAdaptor) PulseIn(pin string, level byte, timeout time.Duration) (d time.Duration, err error) {
+	if r.DryRunning() {
+		return
+	}
+	sysfsPin, err := r.digitalPin(pin, sysfs.IN)
+	if err != nil {
+		return
+	}
+	return sysfs.PulseIn(sysfsPin, int(level), timeout)
+}
+
+// PulseTrain times n consecutive pulses of pin at level, see sysfs.PulseTrain
+func (r *RaspiAdaptor) PulseTrain(pin string, level byte, n int, timeout time.Duration) (pulses []time.Duration, err error) {
+	if r.DryRunning() {
+		return make([]time.Duration, n), nil
+	}
+	sysfsPin, err := r.digitalPin(pin, sysfs.IN)
+	if err != nil {
+		return
+	}
+	return sysfs.PulseTrain(sysfsPin, int(level), n, timeout)
+}
+
 // DigitalWrite writes digital value to specified pin
 func (r *RaspiAdaptor) DigitalWrite(pin string, val byte) (err error) {
+	if r.Record("DigitalWrite", pin, val) {
+		return
+	}
 	sysfsPin, err := r.digitalPin(pin, sysfs.OUT)
 	if err != nil {
 		return err
@@ -216,18 +270,27 @@ func (r *RaspiAdaptor) DigitalWrite(pin string, val byte) (err error) {
 
 // I2cStart starts a i2c device in specified address
 func (r *RaspiAdaptor) I2cStart(address byte) (err error) {
+	if r.Record("I2cStart", address) {
+		return
+	}
 	r.i2cDevice, err = sysfs.NewI2cDevice(r.i2cLocation, address)
 	return err
 }
 
 // I2CWrite writes data to i2c device
 func (r *RaspiAdaptor) I2cWrite(data []byte) (err error) {
+	if r.Record("I2cWrite", data) {
+		return
+	}
 	_, err = r.i2cDevice.Write(data)
 	return
 }
 
 // I2cRead returns value from i2c device using specified size
 func (r *RaspiAdaptor) I2cRead(size uint) (data []byte, err error) {
+	if r.DryRunning() {
+		return make([]byte, size), nil
+	}
 	data = make([]byte, size)
 	_, err = r.i2cDevice.Read(data)
 	return
@@ -21,11 +21,14 @@ var _ gpio.DigitalWriter = (*SparkCoreAdaptor)(nil)
 var _ gpio.AnalogReader = (*SparkCoreAdaptor)(nil)
 var _ gpio.PwmWriter = (*SparkCoreAdaptor)(nil)
 
+var _ gobot.DryRunner = (*SparkCoreAdaptor)(nil)
+
 type SparkCoreAdaptor struct {
 	name        string
 	DeviceID    string
 	AccessToken string
 	APIServer   string
+	gobot.DryRunner
 }
 
 type Event struct {
@@ -50,6 +53,7 @@ func NewSparkCoreAdaptor(name string, deviceID string, accessToken string) *Spar
 		DeviceID:    deviceID,
 		AccessToken: accessToken,
 		APIServer:   "https://api.spark.io",
+		DryRunner:   gobot.NewDryRunner(),
 	}
 }
 func (s *SparkCoreAdaptor) Name() string { return s.name }
@@ -66,6 +70,9 @@ func (s *SparkCoreAdaptor) Finalize() (errs []error) {
 
 // AnalogRead reads analog ping value using spark cloud api
 func (s *SparkCoreAdaptor) AnalogRead(pin string) (val int, err error) {
+	if s.DryRunning() {
+		return
+	}
 	params := url.Values{
 		"params":       {pin},
 		"access_token": {s.AccessToken},
@@ -84,11 +91,17 @@ func (s *SparkCoreAdaptor) AnalogRead(pin string) (val int, err error) {
 
 // PwmWrite writes in pin using analog write api
 func (s *SparkCoreAdaptor) PwmWrite(pin string, level byte) (err error) {
+	if s.Record("PwmWrite", pin, level) {
+		return
+	}
 	return s.AnalogWrite(pin, level)
 }
 
 // AnalogWrite writes analog pin with specified level using spark cloud api
 func (s *SparkCoreAdaptor) AnalogWrite(pin string, level byte) (err error) {
+	if s.Record("AnalogWrite", pin, level) {
+		return
+	}
 	params := url.Values{
 		"params":       {fmt.Sprintf("%v,%v", pin, level)},
 		"access_token": {s.AccessToken},
@@ -100,6 +113,9 @@ func (s *SparkCoreAdaptor) AnalogWrite(pin string, level byte) (err error) {
 
 // DigitalWrite writes to a digital pin using spark cloud api
 func (s *SparkCoreAdaptor) DigitalWrite(pin string, level byte) (err error) {
+	if s.Record("DigitalWrite", pin, level) {
+		return
+	}
 	params := url.Values{
 		"params":       {fmt.Sprintf("%v,%v", pin, s.pinLevel(level))},
 		"access_token": {s.AccessToken},
@@ -111,6 +127,9 @@ func (s *SparkCoreAdaptor) DigitalWrite(pin string, level byte) (err error) {
 
 // DigitalRead reads from digital pin using spark cloud api
 func (s *SparkCoreAdaptor) DigitalRead(pin string) (val int, err error) {
+	if s.DryRunning() {
+		return
+	}
 	params := url.Values{
 		"params":       {pin},
 		"access_token": {s.AccessToken},
@@ -194,6 +213,9 @@ func (s *SparkCoreAdaptor) Variable(name string) (result string, err error) {
 // Takes a String as the only argument and returns an Int.
 // If function is not defined in core, it will time out
 func (s *SparkCoreAdaptor) Function(name string, args string) (val int, err error) {
+	if s.Record("Function", name, args) {
+		return
+	}
 	params := url.Values{
 		"args":         {args},
 		"access_token": {s.AccessToken},
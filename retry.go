@@ -0,0 +1,74 @@
+package gobot
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRetryStopped is returned by RetryUntil when it is stopped before f
+// succeeded
+var ErrRetryStopped = errors.New("Retry stopped")
+
+// RetryPolicy describes how often and how long apart Retry and RetryUntil
+// call a failing function again.
+type RetryPolicy struct {
+	// MaxAttempts is the number of calls after which to give up, 0 keeps
+	// calling until stopped
+	MaxAttempts int
+	// Backoff is how long to wait after the first failed call, doubled after
+	// each further failed call
+	Backoff time.Duration
+	// MaxBackoff caps the wait between calls, 0 does not cap it
+	MaxBackoff time.Duration
+	// Jitter adds a random duration up to Jitter to each wait, so clients
+	// failing together do not all retry at the same time
+	Jitter time.Duration
+}
+
+// delay returns how long to wait after the given failed attempt
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := backoff(p.Backoff, p.MaxBackoff, attempt)
+	if p.Jitter > 0 {
+		d += time.Duration(Rand(int(p.Jitter)))
+	}
+	return d
+}
+
+// backoff doubles base for each attempt after the first, capped at max
+// unless max is 0.
+func backoff(base, max time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if max > 0 && d >= max {
+			break
+		}
+	}
+	if max > 0 && d > max {
+		d = max
+	}
+	return d
+}
+
+// RetryUntil calls f until it returns nil, waiting between calls as
+// described by policy. It returns the error of the last call once
+// policy.MaxAttempts calls have failed, or ErrRetryStopped if stop is closed
+// first. A nil stop channel never stops.
+func RetryUntil(stop <-chan struct{}, policy RetryPolicy, f func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil {
+			return nil
+		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return err
+		}
+		timer := clock().NewTimer(policy.delay(attempt))
+		select {
+		case <-stop:
+			timer.Stop()
+			return ErrRetryStopped
+		case <-timer.C():
+		}
+	}
+}
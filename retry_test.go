@@ -0,0 +1,48 @@
+package gobot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryUntil(t *testing.T) {
+	calls := 0
+	err := RetryUntil(nil, RetryPolicy{}, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	Assert(t, err, nil)
+	Assert(t, calls, 3)
+
+	calls = 0
+	err = RetryUntil(nil, RetryPolicy{MaxAttempts: 2}, func() error {
+		calls++
+		return errors.New("broken")
+	})
+	Assert(t, err, errors.New("broken"))
+	Assert(t, calls, 2)
+
+	stop := make(chan struct{})
+	close(stop)
+	err = RetryUntil(stop, RetryPolicy{Backoff: time.Hour}, func() error {
+		return errors.New("broken")
+	})
+	Assert(t, err, ErrRetryStopped)
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{Backoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	Assert(t, p.delay(1), 10*time.Millisecond)
+	Assert(t, p.delay(3), 40*time.Millisecond)
+	Assert(t, p.delay(10), 50*time.Millisecond)
+
+	p.Jitter = 5 * time.Millisecond
+	for i := 0; i < 10; i++ {
+		d := p.delay(1)
+		Assert(t, d >= 10*time.Millisecond && d < 15*time.Millisecond, true)
+	}
+}
@@ -2,21 +2,26 @@ package gobot
 
 import (
 	"fmt"
-	"log"
+	"sync"
+	"time"
 )
 
 // JSONRobot a JSON representation of a Robot.
 type JSONRobot struct {
-	Name        string            `json:"name"`
-	Commands    []string          `json:"commands"`
-	Connections []*JSONConnection `json:"connections"`
-	Devices     []*JSONDevice     `json:"devices"`
+	Name          string             `json:"name"`
+	Tags          Tags               `json:"tags,omitempty"`
+	Commands      []string           `json:"commands"`
+	CommandParams map[string][]Param `json:"command_params,omitempty"`
+	Connections   []*JSONConnection  `json:"connections"`
+	Devices       []*JSONDevice      `json:"devices"`
+	Groups        []*JSONGroup       `json:"groups,omitempty"`
 }
 
 // NewJSONRobot returns a JSONRobot given a Robot.
 func NewJSONRobot(robot *Robot) *JSONRobot {
 	jsonRobot := &JSONRobot{
 		Name:        robot.Name,
+		Tags:        robot.CurrentTags(),
 		Commands:    []string{},
 		Connections: []*JSONConnection{},
 		Devices:     []*JSONDevice{},
@@ -25,12 +30,17 @@ func NewJSONRobot(robot *Robot) *JSONRobot {
 	for command := range robot.Commands() {
 		jsonRobot.Commands = append(jsonRobot.Commands, command)
 	}
+	jsonRobot.CommandParams = commandParams(robot)
 
 	robot.Devices().Each(func(device Device) {
 		jsonDevice := NewJSONDevice(device)
+		jsonDevice.Disabled = robot.DeviceDisabled(device.Name())
 		jsonRobot.Connections = append(jsonRobot.Connections, NewJSONConnection(robot.Connection(jsonDevice.Connection)))
 		jsonRobot.Devices = append(jsonRobot.Devices, jsonDevice)
 	})
+	for _, group := range robot.Groups() {
+		jsonRobot.Groups = append(jsonRobot.Groups, NewJSONGroup(group))
+	}
 	return jsonRobot
 }
 
@@ -38,10 +48,64 @@ func NewJSONRobot(robot *Robot) *JSONRobot {
 // It containes it's own work routine and a collection of
 // custom commands to control a robot remotely via the Gobot api.
 type Robot struct {
-	Name        string
-	Work        func()
-	connections *Connections
-	devices     *Devices
+	Name string
+	Tags Tags
+	Work func()
+	// StartTimeout is how long each connection and device may take to
+	// start, 0 waits until they have started
+	StartTimeout time.Duration
+	// HaltTimeout is how long each device and connection may take to halt,
+	// 0 waits until they have halted
+	HaltTimeout time.Duration
+	// SkipTimedOutDevices makes Start carry on with the remaining devices
+	// when a device does not start within the StartTimeout, instead of
+	// failing. The error of the device is published on "device_timeout".
+	SkipTimedOutDevices bool
+	// StartConcurrency is how many devices Start starts at once. Devices are
+	// only started once the devices they depend on have started. 0 or 1
+	// starts them one after the other.
+	StartConcurrency int
+	// DryRun makes the connections record the writes they are asked to make
+	// instead of touching the hardware, see DryRunner and Actions. Work and
+	// timers run as usual.
+	DryRun bool
+	// PanicPolicy decides what happens when the work, or a callback of the
+	// events of the robot or its devices and connections, panics. Panics
+	// crash the program by default.
+	PanicPolicy PanicPolicy
+	// Tracer starts spans tracing the start and stop of the robot, its
+	// devices and connections, the execution of commands and the reads and
+	// writes of connections which are IOTracers. Nil does not trace.
+	Tracer Tracer
+
+	connections      *Connections
+	devices          *Devices
+	collectionsMutex sync.RWMutex
+	stopped          chan struct{}
+	stopOnce         *sync.Once
+
+	healthInterval time.Duration
+
+	supervisor            Supervisor
+	deviceSupervisors     map[string]Supervisor
+	connectionSupervisors map[string]Supervisor
+	restarting            map[string]bool
+	disabled              map[string]bool
+	watched               map[*Event]bool
+	supervisorMutex       sync.Mutex
+
+	actions      []Action
+	actionsMutex sync.Mutex
+
+	traced       bool
+	commandSpans []*commandSpan
+	spanMutex    sync.Mutex
+
+	groups      []*Group
+	groupsMutex sync.Mutex
+
+	tagsMutex sync.RWMutex
+
 	Commander
 	Eventer
 }
@@ -79,7 +143,24 @@ func (r *Robots) Each(f func(*Robot)) {
 // 	[]Connection: Connections which are automatically started and stopped with the robot
 //	[]Device: Devices which are automatically started and stopped with the robot
 //	func(): The work routine the robot will execute once all devices and connections have been initialized and started
+//	Supervisor: How failing devices and connections are restarted, see SuperviseDevice and SuperviseConnection
+//	Tags: Labels of the robot, see Gobot.Robots
 // A name will be automaically generated if no name is supplied.
+//
+// Adds the following events:
+//
+//	"device_restarted" - a DeviceRestarted when a supervised device has been restarted
+//	"connection_restarted" - a ConnectionRestarted when a supervised connection has been restarted
+//	"restart_failed" - a RestartFailed when a supervisor gives up restarting a device or connection
+//	"unhealthy" - the error of a connection or device which became unhealthy, see MonitorHealth
+//	"healthy" - the robot's name when all its connections and devices are healthy again
+//	"device_timeout" - the error of a device skipped by SkipTimedOutDevices
+//	"action" - an Action recorded while the robot is dry run
+//	"job_done" - a Job started with ExecuteAsync which has finished
+//	"panic_recovered" - a PanicRecovered when a panic was recovered, see PanicPolicy
+//	"device_disabled" - the name of a device taken offline with DisableDevice
+//	"device_enabled" - the name of a device brought back online with EnableDevice
+//	"device_error" - a *DeviceError published by a device, or returned when starting or halting it
 func NewRobot(name string, v ...interface{}) *Robot {
 	if name == "" {
 		name = fmt.Sprintf("%X", Rand(int(^uint(0)>>1)))
@@ -90,70 +171,296 @@ func NewRobot(name string, v ...interface{}) *Robot {
 		connections: &Connections{},
 		devices:     &Devices{},
 		Work:        nil,
-		Eventer:     NewEventer(),
-		Commander:   NewCommander(),
+		stopped:     make(chan struct{}),
+		stopOnce:    &sync.Once{},
+
+		deviceSupervisors:     make(map[string]Supervisor),
+		connectionSupervisors: make(map[string]Supervisor),
+		restarting:            make(map[string]bool),
+		disabled:              make(map[string]bool),
+		watched:               make(map[*Event]bool),
+
+		Eventer:   NewEventer(),
+		Commander: NewCommander(),
 	}
 
-	log.Println("Initializing Robot", r.Name, "...")
+	r.AddEvent("device_restarted")
+	r.AddEvent("connection_restarted")
+	r.AddEvent("restart_failed")
+	r.AddEvent("unhealthy")
+	r.AddEvent("healthy")
+	r.AddEvent("device_timeout")
+	r.AddEvent("action")
+	r.AddEvent("job_done")
+	r.AddEvent("panic_recovered")
+	r.AddEvent("device_disabled")
+	r.AddEvent("device_enabled")
+	r.AddEvent("device_error")
+	publishJobs(r.Commander, r.Event("job_done"))
+
+	Log(InfoLevel, "Initializing robot", Fields{"robot": r.Name})
 
 	for i := range v {
 		switch v[i].(type) {
 		case []Connection:
-			log.Println("Initializing connections...")
 			for _, connection := range v[i].([]Connection) {
 				c := r.AddConnection(connection)
-				log.Println("Initializing connection", c.Name(), "...")
+				Log(InfoLevel, "Initializing connection", Fields{"robot": r.Name, "connection": c.Name()})
 			}
 		case []Device:
-			log.Println("Initializing devices...")
 			for _, device := range v[i].([]Device) {
 				d := r.AddDevice(device)
-				log.Println("Initializing device", d.Name(), "...")
+				Log(InfoLevel, "Initializing device", Fields{"robot": r.Name, "device": d.Name()})
 			}
 		case func():
 			r.Work = v[i].(func())
+		case Supervisor:
+			r.supervisor = v[i].(Supervisor)
+		case Tags:
+			r.Tags = v[i].(Tags)
 		}
 	}
 
 	return r
 }
 
-// Start a Robot's Connections, Devices, and work.
+// Start a Robot's Connections, Devices, and work. Devices are started after
+// the devices they depend on, see Depender. Connections and devices
+// supervised with RestartAlways which fail to start are restarted in the
+// background instead of failing the start.
 func (r *Robot) Start() (errs []error) {
-	log.Println("Starting Robot", r.Name, "...")
-	if cerrs := r.Connections().Start(); len(cerrs) > 0 {
-		errs = append(errs, cerrs...)
+	Log(InfoLevel, "Starting robot", Fields{"robot": r.Name})
+	span := startSpan(r.Tracer, nil, "robot.start", map[string]string{"robot": r.Name})
+	defer func() { span.End(spanError(errs)) }()
+	r.supervisorMutex.Lock()
+	select {
+	case <-r.stopped:
+		// restarting a stopped robot
+		r.stopped = make(chan struct{})
+		r.stopOnce = &sync.Once{}
+	default:
+	}
+	r.supervisorMutex.Unlock()
+	if err := r.sortDevices(); err != nil {
+		errs = append(errs, err)
 		return
 	}
-	if derrs := r.Devices().Start(); len(derrs) > 0 {
-		errs = append(errs, derrs...)
+	if r.DryRun {
+		if err := r.startDryRun(); err != nil {
+			errs = append(errs, err)
+			return
+		}
+	}
+	if r.Tracer != nil {
+		r.trace()
+	}
+	for _, connection := range *r.Connections() {
+		cspan := startSpan(r.Tracer, span, "connection.start", map[string]string{"robot": r.Name, "connection": connection.Name()})
+		cerrs, _ := startConnection(connection, Fields{"robot": r.Name}, r.StartTimeout)
+		cspan.End(spanError(cerrs))
+		if len(cerrs) > 0 {
+			if r.connectionSupervisor(connection.Name()).Policy != RestartAlways {
+				errs = append(errs, cerrs...)
+				return
+			}
+			r.restartConnection(connection, cerrs)
+		}
+	}
+	if r.StartConcurrency > 1 {
+		errs = r.startDevicesConcurrently(span)
+	} else {
+		for _, device := range *r.Devices() {
+			if errs = r.startDevice(device, span); len(errs) > 0 {
+				break
+			}
+		}
+	}
+	if len(errs) > 0 {
 		return
 	}
+	r.superviseFailures()
+	if r.healthInterval > 0 {
+		go r.monitorHealth(r.Stopped())
+	}
+	if r.PanicPolicy != PanicCrash {
+		r.recoverPanics()
+	}
 	if r.Work != nil {
-		log.Println("Starting work...")
-		r.Work()
+		Log(InfoLevel, "Starting work", Fields{"robot": r.Name})
+		r.runWork()
+	}
+	return
+}
+
+// startDevice starts device in a child span of parent, returning the errors
+// which fail the start of the robot
+func (r *Robot) startDevice(device Device, parent Span) (errs []error) {
+	if r.DeviceDisabled(device.Name()) {
+		return nil
+	}
+	span := startSpan(r.Tracer, parent, "device.start", map[string]string{"robot": r.Name, "device": device.Name()})
+	errs, timedOut := startDevice(device, Fields{"robot": r.Name}, r.StartTimeout)
+	span.End(spanError(errs))
+	r.publishDeviceErrors(errs)
+	if timedOut && r.SkipTimedOutDevices {
+		Log(ErrorLevel, "Skipping device", Fields{"robot": r.Name, "device": device.Name(), "error": errs[0]})
+		Publish(r.Event("device_timeout"), errs[0])
+		return nil
+	}
+	if len(errs) > 0 && r.deviceSupervisor(device.Name()).Policy == RestartAlways {
+		r.restartDevice(device, errs)
+		return nil
 	}
 	return
 }
 
-// Devices returns all devices associated with this Robot.
+// startDevicesConcurrently starts up to StartConcurrency devices at once,
+// each once the devices it depends on have started. No more devices are
+// started after one fails, the errors are returned once the devices being
+// started have returned.
+func (r *Robot) startDevicesConcurrently(parent Span) (errs []error) {
+	waiting := make(map[string]int)
+	dependents := make(map[string][]Device)
+	ready := []Device{}
+	for _, device := range *r.Devices() {
+		if depender, ok := device.(Depender); ok {
+			for _, dependency := range depender.Dependencies() {
+				waiting[device.Name()]++
+				dependents[dependency] = append(dependents[dependency], device)
+			}
+		}
+		if waiting[device.Name()] == 0 {
+			ready = append(ready, device)
+		}
+	}
+
+	type result struct {
+		device Device
+		errs   []error
+	}
+	results := make(chan result)
+	running := 0
+	for len(ready) > 0 || running > 0 {
+		for len(ready) > 0 && running < r.StartConcurrency && len(errs) == 0 {
+			device := ready[0]
+			ready = ready[1:]
+			running++
+			go func() {
+				results <- result{device: device, errs: r.startDevice(device, parent)}
+			}()
+		}
+		if running == 0 {
+			break
+		}
+		res := <-results
+		running--
+		if len(res.errs) > 0 {
+			errs = append(errs, res.errs...)
+			continue
+		}
+		for _, dependent := range dependents[res.device.Name()] {
+			if waiting[dependent.Name()]--; waiting[dependent.Name()] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	return
+}
+
+// Stop closes the channel returned by Stopped so work loops can return, then
+// halts the devices and finalizes the connections in the reverse order they
+// were started.
+func (r *Robot) Stop() (errs []error) {
+	Log(InfoLevel, "Stopping robot", Fields{"robot": r.Name})
+	span := startSpan(r.Tracer, nil, "robot.stop", map[string]string{"robot": r.Name})
+	defer func() { span.End(spanError(errs)) }()
+	r.supervisorMutex.Lock()
+	r.stopOnce.Do(func() {
+		close(r.stopped)
+	})
+	r.supervisorMutex.Unlock()
+	derrs := r.enabledDevices().halt(r.HaltTimeout, func(name string) Span {
+		return startSpan(r.Tracer, span, "device.halt", map[string]string{"robot": r.Name, "device": name})
+	})
+	r.publishDeviceErrors(derrs)
+	errs = append(errs, derrs...)
+	errs = append(errs, r.Connections().finalize(r.HaltTimeout, func(name string) Span {
+		return startSpan(r.Tracer, span, "connection.finalize", map[string]string{"robot": r.Name, "connection": name})
+	})...)
+	if r.DryRun {
+		r.stopDryRun()
+	}
+	return
+}
+
+// Stopped returns a channel which is closed when the robot is stopped. Long
+// running work should return once it is closed.
+func (r *Robot) Stopped() <-chan struct{} {
+	r.supervisorMutex.Lock()
+	defer r.supervisorMutex.Unlock()
+	return r.stopped
+}
+
+// Devices returns all devices associated with this Robot. The devices are
+// a snapshot, which devices added or removed afterwards do not change, so
+// they can be used while the robot is reconfigured.
 func (r *Robot) Devices() *Devices {
+	r.collectionsMutex.RLock()
+	defer r.collectionsMutex.RUnlock()
 	return r.devices
 }
 
 // AddDevice adds a new Device to the robots collection of devices. Returns the
 // added device.
 func (r *Robot) AddDevice(d Device) Device {
-	*r.devices = append(*r.Devices(), d)
+	r.spanMutex.Lock()
+	if r.traced {
+		r.traceDevice(d)
+	}
+	r.collectionsMutex.Lock()
+	devices := append(append(Devices{}, *r.devices...), d)
+	r.devices = &devices
+	r.collectionsMutex.Unlock()
+	r.spanMutex.Unlock()
+	r.forwardDeviceErrors(d)
 	return d
 }
 
+// RemoveDevice removes the named device from the robots collection of devices,
+// without halting it. Returns the removed Device, nil if the Device does not
+// exist.
+func (r *Robot) RemoveDevice(name string) Device {
+	r.collectionsMutex.Lock()
+	defer r.collectionsMutex.Unlock()
+	for i, device := range *r.devices {
+		if device.Name() == name {
+			devices := append(append(Devices{}, (*r.devices)[:i]...), (*r.devices)[i+1:]...)
+			r.devices = &devices
+			return device
+		}
+	}
+	return nil
+}
+
+// sortDevices sorts the devices of the robot after the devices they depend
+// on, see Devices.Sort
+func (r *Robot) sortDevices() error {
+	r.collectionsMutex.Lock()
+	defer r.collectionsMutex.Unlock()
+	devices := append(Devices{}, *r.devices...)
+	if err := devices.Sort(); err != nil {
+		return err
+	}
+	r.devices = &devices
+	return nil
+}
+
 // Device returns a device given a name. Returns nil if the Device does not exist.
 func (r *Robot) Device(name string) Device {
 	if r == nil {
 		return nil
 	}
-	for _, device := range *r.devices {
+	for _, device := range *r.Devices() {
 		if device.Name() == name {
 			return device
 		}
@@ -161,25 +468,52 @@ func (r *Robot) Device(name string) Device {
 	return nil
 }
 
-// Connections returns all connections associated with this robot.
+// Connections returns all connections associated with this robot. The
+// connections are a snapshot, like the devices of Devices.
 func (r *Robot) Connections() *Connections {
+	r.collectionsMutex.RLock()
+	defer r.collectionsMutex.RUnlock()
 	return r.connections
 }
 
 // AddConnection adds a new connection to the robots collection of connections.
 // Returns the added connection.
 func (r *Robot) AddConnection(c Connection) Connection {
-	*r.connections = append(*r.Connections(), c)
+	r.spanMutex.Lock()
+	defer r.spanMutex.Unlock()
+	if r.traced {
+		r.traceConnection(c)
+	}
+	r.collectionsMutex.Lock()
+	defer r.collectionsMutex.Unlock()
+	connections := append(append(Connections{}, *r.connections...), c)
+	r.connections = &connections
 	return c
 }
 
+// RemoveConnection removes the named connection from the robots collection of
+// connections, without finalizing it. Returns the removed Connection, nil if
+// the Connection does not exist.
+func (r *Robot) RemoveConnection(name string) Connection {
+	r.collectionsMutex.Lock()
+	defer r.collectionsMutex.Unlock()
+	for i, connection := range *r.connections {
+		if connection.Name() == name {
+			connections := append(append(Connections{}, (*r.connections)[:i]...), (*r.connections)[i+1:]...)
+			r.connections = &connections
+			return connection
+		}
+	}
+	return nil
+}
+
 // Connection returns a connection given a name. Returns nil if the Connection
 // does not exist.
 func (r *Robot) Connection(name string) Connection {
 	if r == nil {
 		return nil
 	}
-	for _, connection := range *r.connections {
+	for _, connection := range *r.Connections() {
 		if connection.Name() == name {
 			return connection
 		}
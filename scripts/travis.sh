@@ -1,5 +1,5 @@
 #!/bin/bash
-PACKAGES=('gobot' 'gobot/api' 'gobot/platforms/intel-iot/edison' 'gobot/sysfs' $(ls ./platforms | sed -e 's/^/gobot\/platforms\//'))
+PACKAGES=('gobot' 'gobot/api' 'gobot/cluster' 'gobot/platforms/intel-iot/edison' 'gobot/platforms/firmata/firmatatest' 'gobot/config' 'gobot/metrics' 'gobot/sysfs' 'gobot/fsm' 'gobot/testutil' 'gobot/api/grpcapi' 'gobot/api/mqttapi' 'gobot/api/coapapi' 'gobot/api/webrtcapi' 'gobot/client' $(ls ./platforms | sed -e 's/^/gobot\/platforms\//'))
 EXITCODE=0
 
 go get code.google.com/p/go.tools/cmd/cover
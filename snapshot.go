@@ -0,0 +1,88 @@
+package gobot
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Snapshotter is the interface that describes a driver whose state can be
+// saved and applied again, e.g. after the board has been reset
+type Snapshotter interface {
+	// Snapshot returns the state of the Driver. The state must survive
+	// being written as JSON and read back, numbers come back as float64.
+	Snapshot() map[string]interface{}
+	// Restore applies a state returned by Snapshot
+	Restore(state map[string]interface{}) error
+}
+
+// RobotSnapshot is the saved state of the devices of a robot, see
+// Robot.Snapshot
+type RobotSnapshot struct {
+	Robot   string                    `json:"robot"`
+	Time    time.Time                 `json:"time"`
+	Devices map[string]DeviceSnapshot `json:"devices"`
+}
+
+// DeviceSnapshot is the saved state of a device
+type DeviceSnapshot struct {
+	Driver string                 `json:"driver"`
+	Pin    string                 `json:"pin,omitempty"`
+	State  map[string]interface{} `json:"state"`
+}
+
+// Snapshot returns the state of every device of the robot which is a
+// Snapshotter.
+func (r *Robot) Snapshot() RobotSnapshot {
+	snapshot := RobotSnapshot{
+		Robot:   r.Name,
+		Time:    clock().Now(),
+		Devices: make(map[string]DeviceSnapshot),
+	}
+	r.Devices().Each(func(device Device) {
+		snapshotter, ok := device.(Snapshotter)
+		if !ok {
+			return
+		}
+		d := DeviceSnapshot{
+			Driver: reflect.TypeOf(device).String(),
+			State:  snapshotter.Snapshot(),
+		}
+		if pinner, ok := device.(Pinner); ok {
+			d.Pin = pinner.Pin()
+		}
+		snapshot.Devices[device.Name()] = d
+	})
+	return snapshot
+}
+
+// Restore applies the state of each device in snapshot to the device of the
+// robot with the same name, in the order the devices were started. Returns
+// an error for each device which is missing, has another driver or fails to
+// restore its state.
+func (r *Robot) Restore(snapshot RobotSnapshot) (errs []error) {
+	restored := make(map[string]bool)
+	r.Devices().Each(func(device Device) {
+		d, ok := snapshot.Devices[device.Name()]
+		if !ok {
+			return
+		}
+		restored[device.Name()] = true
+		if driver := reflect.TypeOf(device).String(); driver != d.Driver {
+			errs = append(errs, fmt.Errorf("Device %q: snapshot of a %v can not be restored to a %v",
+				device.Name(), d.Driver, driver))
+			return
+		}
+		if snapshotter, ok := device.(Snapshotter); ok {
+			if err := snapshotter.Restore(d.State); err != nil {
+				errs = append(errs, fmt.Errorf("Device %q: %v", device.Name(), err))
+			}
+		}
+	})
+	for name := range snapshot.Devices {
+		if !restored[name] {
+			errs = append(errs, fmt.Errorf("Device %q: not found", name))
+		}
+	}
+	return
+}
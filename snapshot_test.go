@@ -0,0 +1,48 @@
+package gobot
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"testing"
+)
+
+func TestRobotSnapshot(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	servo := newSnapshotDriver("servo", 90)
+	r := NewRobot("Robot1", []Device{servo, newTestDriver(newTestAdaptor("Connection1", "/dev/null"), "led", "13")})
+
+	snapshot := r.Snapshot()
+	Assert(t, snapshot.Robot, "Robot1")
+	Assert(t, snapshot.Devices, map[string]DeviceSnapshot{
+		"servo": {Driver: "*gobot.snapshotDriver", Pin: "3", State: map[string]interface{}{"value": 90.0}},
+	})
+
+	// snapshots survive a JSON round trip
+	data, err := json.Marshal(snapshot)
+	Assert(t, err, nil)
+	var restored RobotSnapshot
+	Assert(t, json.Unmarshal(data, &restored), nil)
+
+	servo.value = 0
+	Assert(t, len(r.Restore(restored)), 0)
+	Assert(t, servo.value, 90.0)
+}
+
+func TestRobotRestoreErrors(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := NewRobot("Robot1", []Device{
+		newSnapshotDriver("servo", 90),
+		newTestDriver(newTestAdaptor("Connection1", "/dev/null"), "led", "13"),
+	})
+	errs := r.Restore(RobotSnapshot{Devices: map[string]DeviceSnapshot{
+		"servo": {Driver: "*gobot.snapshotDriver", State: map[string]interface{}{}},
+		"led":   {Driver: "*gpio.LedDriver"},
+		"motor": {Driver: "*gpio.MotorDriver"},
+	}})
+	Assert(t, errs, []error{
+		errors.New("Device \"servo\": value is missing"),
+		errors.New("Device \"led\": snapshot of a *gpio.LedDriver can not be restored to a *gobot.testDriver"),
+		errors.New("Device \"motor\": not found"),
+	})
+}
@@ -0,0 +1,212 @@
+package gobot
+
+import (
+	"fmt"
+	"time"
+)
+
+// RestartPolicy decides when a supervised device or connection is restarted
+type RestartPolicy int
+
+const (
+	// RestartNever leaves failed devices and connections alone
+	RestartNever RestartPolicy = iota
+	// RestartOnFailure restarts a device or connection which publishes an
+	// "error" event
+	RestartOnFailure
+	// RestartAlways also keeps restarting a device or connection which fails
+	// to start, instead of failing the start of the robot
+	RestartAlways
+)
+
+// Supervisor describes how a Robot restarts a failing device or connection.
+// A device is restarted by halting and starting it again, a connection by
+// finalizing and connecting it again.
+type Supervisor struct {
+	Policy RestartPolicy
+	// MaxRetries is the number of consecutive failed restarts after which
+	// the supervisor gives up, 0 retries forever
+	MaxRetries int
+	// Backoff is how long to wait before the first restart, doubled after
+	// each failed restart
+	Backoff time.Duration
+	// MaxBackoff caps the wait between restarts, 0 does not cap it
+	MaxBackoff time.Duration
+}
+
+// DeviceRestarted is published on a Robot's "device_restarted" event when a
+// supervised device has been restarted
+type DeviceRestarted struct {
+	Device   string
+	Attempts int
+}
+
+// ConnectionRestarted is published on a Robot's "connection_restarted" event
+// when a supervised connection has been restarted
+type ConnectionRestarted struct {
+	Connection string
+	Attempts   int
+}
+
+// RestartFailed is published on a Robot's "restart_failed" event when a
+// supervisor gives up restarting a device or connection
+type RestartFailed struct {
+	Name   string
+	Errors []error
+}
+
+// delay returns how long to wait before the given restart attempt
+func (s Supervisor) delay(attempt int) time.Duration {
+	return backoff(s.Backoff, s.MaxBackoff, attempt)
+}
+
+// SuperviseDevice supervises the named device with s instead of the
+// Supervisor the Robot was created with.
+func (r *Robot) SuperviseDevice(name string, s Supervisor) {
+	r.supervisorMutex.Lock()
+	defer r.supervisorMutex.Unlock()
+	r.deviceSupervisors[name] = s
+}
+
+// SuperviseConnection supervises the named connection with s instead of the
+// Supervisor the Robot was created with.
+func (r *Robot) SuperviseConnection(name string, s Supervisor) {
+	r.supervisorMutex.Lock()
+	defer r.supervisorMutex.Unlock()
+	r.connectionSupervisors[name] = s
+}
+
+// deviceSupervisor returns the Supervisor of the named device
+func (r *Robot) deviceSupervisor(name string) Supervisor {
+	r.supervisorMutex.Lock()
+	defer r.supervisorMutex.Unlock()
+	if s, ok := r.deviceSupervisors[name]; ok {
+		return s
+	}
+	return r.supervisor
+}
+
+// connectionSupervisor returns the Supervisor of the named connection
+func (r *Robot) connectionSupervisor(name string) Supervisor {
+	r.supervisorMutex.Lock()
+	defer r.supervisorMutex.Unlock()
+	if s, ok := r.connectionSupervisors[name]; ok {
+		return s
+	}
+	return r.supervisor
+}
+
+// superviseFailures restarts the supervised devices and connections which
+// publish an "error" event
+func (r *Robot) superviseFailures() {
+	r.Connections().Each(func(connection Connection) {
+		if r.connectionSupervisor(connection.Name()).Policy == RestartNever {
+			return
+		}
+		r.watchErrors(connection, func(errs []error) {
+			r.restartConnection(connection, errs)
+		})
+	})
+	r.Devices().Each(func(device Device) {
+		if r.deviceSupervisor(device.Name()).Policy == RestartNever {
+			return
+		}
+		r.watchErrors(device, func(errs []error) {
+			r.restartDevice(device, errs)
+		})
+	})
+}
+
+// watchErrors calls f with the data published on the "error" event of v, if
+// it has one. Each event is only watched once.
+func (r *Robot) watchErrors(v interface{}, f func([]error)) {
+	eventer, ok := v.(Eventer)
+	if !ok {
+		return
+	}
+	event := eventer.Event("error")
+	if event == nil {
+		return
+	}
+	r.supervisorMutex.Lock()
+	defer r.supervisorMutex.Unlock()
+	if r.watched[event] {
+		return
+	}
+	r.watched[event] = true
+	On(event, func(data interface{}) {
+		err, ok := data.(error)
+		if !ok {
+			err = fmt.Errorf("%v", data)
+		}
+		f([]error{err})
+	})
+}
+
+// restartDevice restarts device in the background
+func (r *Robot) restartDevice(device Device, cause []error) {
+	name := device.Name()
+	if r.DeviceDisabled(name) {
+		return
+	}
+	go r.restart("device", name, r.deviceSupervisor(name), cause,
+		func() []error {
+			device.Halt()
+			return device.Start()
+		},
+		func(attempts int) {
+			Publish(r.Event("device_restarted"), DeviceRestarted{Device: name, Attempts: attempts})
+		},
+	)
+}
+
+// restartConnection restarts connection in the background
+func (r *Robot) restartConnection(connection Connection, cause []error) {
+	name := connection.Name()
+	go r.restart("connection", name, r.connectionSupervisor(name), cause,
+		func() []error {
+			connection.Finalize()
+			return connection.Connect()
+		},
+		func(attempts int) {
+			Publish(r.Event("connection_restarted"), ConnectionRestarted{Connection: name, Attempts: attempts})
+		},
+	)
+}
+
+// restart calls start until it succeeds, the supervisor gives up or the
+// robot is stopped. Only one restart of the same device or connection runs
+// at a time, failures reported meanwhile are ignored.
+func (r *Robot) restart(kind string, name string, s Supervisor, cause []error, start func() []error, restarted func(int)) {
+	r.supervisorMutex.Lock()
+	key := kind + " " + name
+	if r.restarting[key] {
+		r.supervisorMutex.Unlock()
+		return
+	}
+	r.restarting[key] = true
+	r.supervisorMutex.Unlock()
+	stopped := r.Stopped()
+
+	defer func() {
+		r.supervisorMutex.Lock()
+		delete(r.restarting, key)
+		r.supervisorMutex.Unlock()
+	}()
+
+	errs := cause
+	for attempt := 1; s.MaxRetries == 0 || attempt <= s.MaxRetries; attempt++ {
+		Log(WarnLevel, "Restarting "+kind, Fields{"robot": r.Name, kind: name, "errors": errs, "attempt": attempt})
+		select {
+		case <-stopped:
+			return
+		case <-clock().NewTimer(s.delay(attempt)).C():
+		}
+		if errs = start(); len(errs) == 0 {
+			restarted(attempt)
+			return
+		}
+	}
+	Log(ErrorLevel, "Giving up restarting "+kind, Fields{"robot": r.Name, kind: name, "errors": errs})
+	Publish(r.Event("restart_failed"), RestartFailed{Name: name, Errors: errs})
+}
@@ -0,0 +1,84 @@
+package gobot
+
+import (
+	"errors"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestSupervisorDelay(t *testing.T) {
+	s := Supervisor{Backoff: 10 * time.Millisecond, MaxBackoff: 30 * time.Millisecond}
+	Assert(t, s.delay(1), 10*time.Millisecond)
+	Assert(t, s.delay(2), 20*time.Millisecond)
+	Assert(t, s.delay(3), 30*time.Millisecond)
+	Assert(t, s.delay(100), 30*time.Millisecond)
+}
+
+func TestRobotRestartOnFailure(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	driver := newFlakyDriver("sensor", 0)
+	r := NewRobot("Robot1", []Device{driver},
+		Supervisor{Policy: RestartOnFailure, Backoff: time.Millisecond},
+	)
+	Assert(t, len(r.Start()), 0)
+
+	restarted := make(chan DeviceRestarted, 1)
+	On(r.Event("device_restarted"), func(data interface{}) {
+		restarted <- data.(DeviceRestarted)
+	})
+	Publish(driver.Event("error"), errors.New("i2c read failed"))
+
+	select {
+	case data := <-restarted:
+		Assert(t, data, DeviceRestarted{Device: "sensor", Attempts: 1})
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("device_restarted was not published")
+	}
+	r.Stop()
+}
+
+func TestRobotRestartAlways(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	driver := newFlakyDriver("sensor", 2)
+	r := NewRobot("Robot1", []Device{driver})
+	Assert(t, r.Start(), []error{NewDeviceError("sensor", "Start", errors.New("flaky"), false)})
+
+	driver.starts = 0
+	r.SuperviseDevice("sensor", Supervisor{Policy: RestartAlways, Backoff: time.Millisecond})
+	restarted := make(chan DeviceRestarted, 1)
+	On(r.Event("device_restarted"), func(data interface{}) {
+		restarted <- data.(DeviceRestarted)
+	})
+	Assert(t, len(r.Start()), 0)
+
+	select {
+	case data := <-restarted:
+		Assert(t, data, DeviceRestarted{Device: "sensor", Attempts: 2})
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("device_restarted was not published")
+	}
+	r.Stop()
+}
+
+func TestRobotRestartFailed(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	driver := newFlakyDriver("sensor", 10)
+	r := NewRobot("Robot1", []Device{driver},
+		Supervisor{Policy: RestartAlways, MaxRetries: 2, Backoff: time.Millisecond},
+	)
+	failed := make(chan RestartFailed, 1)
+	On(r.Event("restart_failed"), func(data interface{}) {
+		failed <- data.(RestartFailed)
+	})
+	Assert(t, len(r.Start()), 0)
+
+	select {
+	case data := <-failed:
+		Assert(t, data, RestartFailed{Name: "sensor", Errors: []error{errors.New("flaky")}})
+		Assert(t, driver.starts, 3)
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("restart_failed was not published")
+	}
+	r.Stop()
+}
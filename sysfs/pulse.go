@@ -0,0 +1,60 @@
+package sysfs
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrPulseTimeout is returned by PulseIn when the pin does not reach the
+// level of the pulse in time
+var ErrPulseTimeout = errors.New("sysfs: timed out waiting for a pulse")
+
+// PulseIn waits up to timeout for pin to reach level, then returns how long
+// it stays at level, up to timeout. The pin is polled as fast as it can be
+// read, so the duration is only accurate to the time a read takes, a few
+// microseconds on a Raspberry Pi.
+func PulseIn(pin DigitalPin, level int, timeout time.Duration) (time.Duration, error) {
+	pulses, err := PulseTrain(pin, level, 1, timeout)
+	if err != nil {
+		return 0, err
+	}
+	return pulses[0], nil
+}
+
+// PulseTrain is similar to PulseIn except that it times n consecutive
+// pulses, polling the pin without pause between them, so that none is
+// missed when the pulses are only microseconds apart, e.g. the bits sent by
+// a DHT sensor. timeout applies to each pulse.
+func PulseTrain(pin DigitalPin, level int, n int, timeout time.Duration) ([]time.Duration, error) {
+	pulses := make([]time.Duration, n)
+	for i := range pulses {
+		deadline := time.Now().Add(timeout)
+		for {
+			value, err := pin.Read()
+			if err != nil {
+				return nil, err
+			}
+			if value == level {
+				break
+			}
+			if time.Now().After(deadline) {
+				return nil, ErrPulseTimeout
+			}
+		}
+		start := time.Now()
+		for {
+			value, err := pin.Read()
+			if err != nil {
+				return nil, err
+			}
+			if elapsed := time.Since(start); value != level || elapsed >= timeout {
+				if elapsed > timeout {
+					elapsed = timeout
+				}
+				pulses[i] = elapsed
+				break
+			}
+		}
+	}
+	return pulses, nil
+}
@@ -0,0 +1,71 @@
+package sysfs
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// pulsePin is a DigitalPin reading high between from and to
+type pulsePin struct {
+	DigitalPin
+	from time.Time
+	to   time.Time
+	err  error
+}
+
+func (p *pulsePin) Read() (int, error) {
+	if now := time.Now(); now.After(p.from) && now.Before(p.to) {
+		return HIGH, p.err
+	}
+	return LOW, p.err
+}
+
+func TestPulseIn(t *testing.T) {
+	now := time.Now()
+	pin := &pulsePin{from: now.Add(2 * time.Millisecond), to: now.Add(7 * time.Millisecond)}
+	d, err := PulseIn(pin, HIGH, 20*time.Millisecond)
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, d > 4*time.Millisecond && d < 6*time.Millisecond, true)
+
+	now = time.Now()
+	pin = &pulsePin{from: now, to: now.Add(time.Second)}
+	d, err = PulseIn(pin, HIGH, 5*time.Millisecond)
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, d, 5*time.Millisecond)
+
+	_, err = PulseIn(&pulsePin{}, HIGH, time.Millisecond)
+	gobot.Assert(t, err, ErrPulseTimeout)
+
+	_, err = PulseIn(&pulsePin{err: errors.New("read error")}, HIGH, time.Millisecond)
+	gobot.Assert(t, err, errors.New("read error"))
+}
+
+// trainPin is a DigitalPin reading the levels of values in turn
+type trainPin struct {
+	DigitalPin
+	values []int
+}
+
+func (p *trainPin) Read() (int, error) {
+	if len(p.values) == 0 {
+		return LOW, nil
+	}
+	value := p.values[0]
+	p.values = p.values[1:]
+	return value, nil
+}
+
+func TestPulseTrain(t *testing.T) {
+	pin := &trainPin{values: []int{LOW, HIGH, HIGH, LOW, LOW, HIGH, LOW, HIGH, HIGH, HIGH}}
+	pulses, err := PulseTrain(pin, HIGH, 2, 20*time.Millisecond)
+	gobot.Assert(t, err, nil)
+	gobot.Assert(t, len(pulses), 2)
+	gobot.Assert(t, pulses[0] > 0, true)
+	gobot.Assert(t, pin.values, []int{HIGH, HIGH, HIGH})
+
+	_, err = PulseTrain(&trainPin{values: []int{HIGH, LOW}}, HIGH, 2, time.Millisecond)
+	gobot.Assert(t, err, ErrPulseTimeout)
+}
@@ -0,0 +1,32 @@
+package sysfs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrWatchUnsupported is returned by Watch for pins which are not gpios of
+// the sysfs interface, or on systems without interrupt support
+var ErrWatchUnsupported = errors.New("sysfs: watching pins is not supported")
+
+// Watch calls f with the value of pin each time it changes, as signalled by
+// the interrupts of the gpio, until stop is called. pin must be an input
+// returned by NewDigitalPin.
+func Watch(pin DigitalPin, f func(val int)) (stop func(), err error) {
+	d, ok := pin.(*digitalPin)
+	if !ok {
+		return nil, ErrWatchUnsupported
+	}
+	if _, err = writeFile(fmt.Sprintf("%v/%v/edge", GPIOPATH, d.label), []byte("both")); err != nil {
+		return
+	}
+	file, err := OpenFile(fmt.Sprintf("%v/%v/value", GPIOPATH, d.label), os.O_RDONLY, 0644)
+	if err != nil {
+		return
+	}
+	if stop, err = watchFile(file, f); err != nil {
+		file.Close()
+	}
+	return
+}
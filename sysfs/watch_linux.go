@@ -0,0 +1,55 @@
+package sysfs
+
+import (
+	"sync"
+	"syscall"
+)
+
+// watchPeriod is how long, in milliseconds, the watch of a pin waits for an
+// interrupt before checking whether it was stopped
+const watchPeriod = 100
+
+// watchFile waits for the interrupts signalled on the value file of a gpio
+// with epoll and calls f with the value read after each of them
+func watchFile(file File, f func(val int)) (func(), error) {
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	fd := int(file.Fd())
+	event := syscall.EpollEvent{Events: syscall.EPOLLPRI | syscall.EPOLLERR, Fd: int32(fd)}
+	if err = syscall.EpollCtl(epfd, syscall.EPOLL_CTL_ADD, fd, &event); err != nil {
+		syscall.Close(epfd)
+		return nil, err
+	}
+
+	done := make(chan bool)
+	go func() {
+		defer file.Close()
+		defer syscall.Close(epfd)
+		events := make([]syscall.EpollEvent, 1)
+		buf := make([]byte, 1)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			n, err := syscall.EpollWait(epfd, events, watchPeriod)
+			if err != nil && err != syscall.EINTR {
+				return
+			}
+			if n < 1 {
+				continue
+			}
+			// the value is read from the start to clear the interrupt
+			if _, err := file.ReadAt(buf, 0); err != nil {
+				return
+			}
+			f(int(buf[0] - '0'))
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }, nil
+}
@@ -0,0 +1,35 @@
+package sysfs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func TestWatch(t *testing.T) {
+	_, err := Watch(&pulsePin{}, func(int) {})
+	gobot.Assert(t, err, ErrWatchUnsupported)
+
+	// TestDigitalPin leaves writeFile failing
+	writeFile = func(path string, data []byte) (int, error) {
+		file, err := OpenFile(path, os.O_WRONLY, 0644)
+		if err != nil {
+			return 0, err
+		}
+		return file.Write(data)
+	}
+	fs := NewMockFilesystem([]string{
+		"/sys/class/gpio/gpio10/value",
+	})
+	SetFilesystem(fs)
+	_, err = Watch(NewDigitalPin(10), func(int) {})
+	gobot.Refute(t, err, nil)
+
+	fs.Add("/sys/class/gpio/gpio10/edge")
+	// mock files cannot be waited on
+	fs.Files["/sys/class/gpio/gpio10/value"].fd = ^uintptr(0)
+	_, err = Watch(NewDigitalPin(10), func(int) {})
+	gobot.Refute(t, err, nil)
+	gobot.Assert(t, fs.Files["/sys/class/gpio/gpio10/edge"].Contents, "both")
+}
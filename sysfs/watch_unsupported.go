@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package sysfs
+
+// watchFile returns ErrWatchUnsupported, interrupts are only supported on
+// Linux
+func watchFile(file File, f func(val int)) (func(), error) {
+	return nil, ErrWatchUnsupported
+}
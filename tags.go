@@ -0,0 +1,53 @@
+package gobot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tags are labels attached to a Robot, e.g. {"zone": "warehouse-a", "type":
+// "agv"}, so a subset of robots can be addressed with Gobot.Robots.
+type Tags map[string]string
+
+// ParseTags parses tags written as comma separated key=value pairs, e.g.
+// "zone=warehouse-a,type=agv".
+func ParseTags(s string) (Tags, error) {
+	tags := Tags{}
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("Tag %q is not a key=value pair", pair)
+		}
+		tags[kv[0]] = kv[1]
+	}
+	return tags, nil
+}
+
+// Match returns true if t has every tag of filter with the same value
+func (t Tags) Match(filter Tags) bool {
+	for key, value := range filter {
+		if v, ok := t[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// SetTags replaces the tags of the robot, e.g. when it is reconfigured
+// while it is running.
+func (r *Robot) SetTags(tags Tags) {
+	r.tagsMutex.Lock()
+	defer r.tagsMutex.Unlock()
+	r.Tags = tags
+}
+
+// CurrentTags returns the tags of the robot, safe to read while SetTags
+// replaces them.
+func (r *Robot) CurrentTags() Tags {
+	r.tagsMutex.RLock()
+	defer r.tagsMutex.RUnlock()
+	return r.Tags
+}
@@ -0,0 +1,56 @@
+package gobot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tags, err := ParseTags("zone=warehouse-a, type=agv")
+	Assert(t, err, nil)
+	Assert(t, tags, Tags{"zone": "warehouse-a", "type": "agv"})
+
+	tags, err = ParseTags("")
+	Assert(t, tags, Tags{})
+
+	_, err = ParseTags("zone")
+	Assert(t, err, errors.New("Tag \"zone\" is not a key=value pair"))
+}
+
+func TestTagsMatch(t *testing.T) {
+	tags := Tags{"zone": "warehouse-a", "type": "agv"}
+	Assert(t, tags.Match(Tags{"zone": "warehouse-a"}), true)
+	Assert(t, tags.Match(Tags{"zone": "warehouse-b"}), false)
+	Assert(t, tags.Match(Tags{"owner": "ops"}), false)
+	Assert(t, tags.Match(nil), true)
+}
+
+func TestGobotRobotsFilter(t *testing.T) {
+	g := NewGobot()
+	agv := g.AddRobot(NewRobot("agv", Tags{"zone": "warehouse-a", "type": "agv"}))
+	g.AddRobot(NewRobot("arm", Tags{"zone": "warehouse-a", "type": "arm"}))
+	g.AddRobot(NewRobot("drone"))
+
+	Assert(t, g.Robots().Len(), 3)
+	Assert(t, g.Robots(Tags{"zone": "warehouse-a"}).Len(), 2)
+	Assert(t, *g.Robots(Tags{"zone": "warehouse-a"}, Tags{"type": "agv"}), Robots{agv})
+	Assert(t, g.Robots(Tags{"zone": "warehouse-b"}).Len(), 0)
+	Assert(t, NewJSONRobot(agv).Tags, agv.Tags)
+}
+
+func TestRobotSetTags(t *testing.T) {
+	g := NewGobot()
+	agv := g.AddRobot(NewRobot("agv", Tags{"zone": "warehouse-a"}))
+
+	done := make(chan bool)
+	go func() {
+		agv.SetTags(Tags{"zone": "warehouse-b"})
+		done <- true
+	}()
+	g.Robots(Tags{"zone": "warehouse-a"})
+	NewJSONGobot(g)
+	<-done
+
+	Assert(t, agv.CurrentTags(), Tags{"zone": "warehouse-b"})
+	Assert(t, g.Robots(Tags{"zone": "warehouse-b"}).Len(), 1)
+}
@@ -0,0 +1,170 @@
+package testutil
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var _ gobot.Clock = (*FakeClock)(nil)
+
+// FakeClock is a gobot.Clock whose time only passes when it is advanced
+// with Add or Set.
+type FakeClock struct {
+	now     time.Time
+	waiters []*fakeTimer
+	mutex   sync.Mutex
+	changed *sync.Cond
+}
+
+// fakeTimer is a timer or ticker waiting for a FakeClock
+type fakeTimer struct {
+	clock  *FakeClock
+	when   time.Time
+	period time.Duration
+	c      chan time.Time
+	f      func()
+}
+
+// NewFakeClock returns a new FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.changed = sync.NewCond(&c.mutex)
+	return c
+}
+
+// Now returns the time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// NewTimer returns a timer firing once the clock has been advanced by d.
+func (c *FakeClock) NewTimer(d time.Duration) gobot.ClockTimer {
+	return c.wait(&fakeTimer{when: c.Now().Add(d), c: make(chan time.Time, 1)})
+}
+
+// NewTicker returns a ticker ticking each time the clock has been advanced
+// by another d.
+func (c *FakeClock) NewTicker(d time.Duration) gobot.ClockTicker {
+	return fakeTicker{c.wait(&fakeTimer{when: c.Now().Add(d), period: d, c: make(chan time.Time, 1)})}
+}
+
+// AfterFunc calls f in its own goroutine once the clock has been advanced by
+// d.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) gobot.ClockTimer {
+	return c.wait(&fakeTimer{when: c.Now().Add(d), f: f})
+}
+
+// Add advances the clock by d, firing the timers and tickers due in the order
+// they are due.
+func (c *FakeClock) Add(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set advances the clock to now, firing the timers and tickers due in the
+// order they are due. The clock does not go back if now is before its time.
+func (c *FakeClock) Set(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for {
+		next := -1
+		for i, t := range c.waiters {
+			if !t.when.After(now) && (next < 0 || t.when.Before(c.waiters[next].when)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		t := c.waiters[next]
+		if t.when.After(c.now) {
+			c.now = t.when
+		}
+		if t.period > 0 {
+			t.when = t.when.Add(t.period)
+		} else {
+			c.remove(t)
+		}
+		t.fire(c.now)
+	}
+	if now.After(c.now) {
+		c.now = now
+	}
+	c.changed.Broadcast()
+}
+
+// Waiters returns the number of timers and tickers waiting for the clock.
+func (c *FakeClock) Waiters() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil blocks until n timers and tickers are waiting for the clock,
+// e.g. until a goroutine under test has started waiting before advancing
+// the clock.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for len(c.waiters) < n {
+		c.changed.Wait()
+	}
+}
+
+// wait adds t to the timers waiting for the clock
+func (c *FakeClock) wait(t *fakeTimer) *fakeTimer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	t.clock = c
+	c.waiters = append(c.waiters, t)
+	c.changed.Broadcast()
+	return t
+}
+
+// remove removes t from the timers waiting for the clock, returns false if
+// it was not waiting
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	for i, w := range c.waiters {
+		if w == t {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			c.changed.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// fire sends now on the channel of t, dropping it if the previous value has
+// not been received like a time.Ticker, or calls the function of t
+func (t *fakeTimer) fire(now time.Time) {
+	if t.f != nil {
+		go t.f()
+		return
+	}
+	select {
+	case t.c <- now:
+	default:
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	return t.clock.remove(t)
+}
+
+// fakeTicker is a fakeTimer firing every period
+type fakeTicker struct {
+	*fakeTimer
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeTimer.Stop()
+}
@@ -0,0 +1,125 @@
+package testutil
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var epoch = time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func received(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-c:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFakeClockTimer(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	timer := clock.NewTimer(time.Second)
+	gobot.Assert(t, clock.Waiters(), 1)
+
+	clock.Add(999 * time.Millisecond)
+	_, ok := received(timer.C())
+	gobot.Assert(t, ok, false)
+
+	clock.Add(time.Millisecond)
+	now, ok := received(timer.C())
+	gobot.Assert(t, ok, true)
+	gobot.Assert(t, now, epoch.Add(time.Second))
+	gobot.Assert(t, clock.Waiters(), 0)
+	gobot.Assert(t, timer.Stop(), false)
+
+	timer = clock.NewTimer(time.Second)
+	gobot.Assert(t, timer.Stop(), true)
+	clock.Add(time.Second)
+	_, ok = received(timer.C())
+	gobot.Assert(t, ok, false)
+}
+
+func TestFakeClockTicker(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	ticker := clock.NewTicker(time.Second)
+	ticks := 0
+	for i := 0; i < 3; i++ {
+		clock.Add(time.Second)
+		if _, ok := received(ticker.C()); ok {
+			ticks++
+		}
+	}
+	gobot.Assert(t, ticks, 3)
+
+	// ticks are dropped while the previous tick has not been received
+	clock.Add(3 * time.Second)
+	now, _ := received(ticker.C())
+	gobot.Assert(t, now, epoch.Add(4*time.Second))
+	gobot.Assert(t, clock.Now(), epoch.Add(6*time.Second))
+
+	ticker.Stop()
+	gobot.Assert(t, clock.Waiters(), 0)
+}
+
+func TestFakeClockAfterFunc(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	called := make(chan bool, 1)
+	clock.AfterFunc(time.Minute, func() { called <- true })
+
+	clock.Set(epoch.Add(time.Minute))
+	select {
+	case <-called:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("function was not called")
+	}
+}
+
+func TestFakeClockEvery(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	gobot.SetClock(clock)
+	defer gobot.SetClock(nil)
+
+	calls := make(chan bool, 10)
+	timer := gobot.Every(time.Hour, func() { calls <- true })
+	clock.BlockUntil(1)
+	for i := 0; i < 3; i++ {
+		clock.Add(time.Hour)
+		select {
+		case <-calls:
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("function was not called")
+		}
+	}
+	timer.Stop()
+}
+
+func TestFakeClockRetry(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	gobot.SetClock(clock)
+	defer gobot.SetClock(nil)
+
+	attempts := 0
+	done := make(chan error)
+	go func() {
+		done <- gobot.RetryUntil(nil, gobot.RetryPolicy{MaxAttempts: 3, Backoff: time.Minute}, func() error {
+			attempts++
+			return errors.New("not ready")
+		})
+	}()
+	// a minute, then two minutes between the attempts
+	clock.BlockUntil(1)
+	clock.Add(time.Minute)
+	clock.BlockUntil(1)
+	clock.Add(2 * time.Minute)
+
+	select {
+	case err := <-done:
+		gobot.Assert(t, err, errors.New("not ready"))
+		gobot.Assert(t, attempts, 3)
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("retry did not give up")
+	}
+}
@@ -0,0 +1,18 @@
+/*
+Package testutil provides helpers to test gobot programs, such as a FakeClock
+which makes the timers of gobot fire when a test advances it instead of
+after real time has passed.
+
+Example:
+
+	func TestBlink(t *testing.T) {
+		clock := testutil.NewFakeClock(time.Now())
+		gobot.SetClock(clock)
+		defer gobot.SetClock(nil)
+
+		robot.Start()
+		clock.Add(time.Second)
+		// assert the led was toggled once
+	}
+*/
+package testutil
@@ -0,0 +1,50 @@
+package gobot
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle returns a function which calls f at most once every interval,
+// e.g. to drive a display at 5Hz from a 100Hz analog stream:
+//
+//	gobot.On(sensor.Event("data"), gobot.Throttle(200*time.Millisecond, true, func(data interface{}) {
+//		display.Write(data)
+//	}))
+//
+// Calls made less than interval after the last call of f are dropped. With
+// trailing set, f is called once more with the data of the last dropped call
+// when the interval has passed, so the final value is not lost.
+func Throttle(interval time.Duration, trailing bool, f func(data interface{})) func(data interface{}) {
+	var (
+		mutex   sync.Mutex
+		last    time.Time
+		pending bool
+		latest  interface{}
+	)
+	return func(data interface{}) {
+		mutex.Lock()
+		wait := interval - clock().Now().Sub(last)
+		if wait <= 0 {
+			last = clock().Now()
+			mutex.Unlock()
+			f(data)
+			return
+		}
+		if trailing {
+			latest = data
+			if !pending {
+				pending = true
+				clock().AfterFunc(wait, func() {
+					mutex.Lock()
+					data := latest
+					pending, latest = false, nil
+					last = clock().Now()
+					mutex.Unlock()
+					f(data)
+				})
+			}
+		}
+		mutex.Unlock()
+	}
+}
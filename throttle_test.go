@@ -0,0 +1,54 @@
+package gobot
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder records the data it is called with
+type recorder struct {
+	mutex sync.Mutex
+	calls []interface{}
+}
+
+func (r *recorder) record(data interface{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.calls = append(r.calls, data)
+}
+
+func (r *recorder) recorded() []interface{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]interface{}{}, r.calls...)
+}
+
+func TestThrottle(t *testing.T) {
+	r := &recorder{}
+	f := Throttle(20*time.Millisecond, false, r.record)
+	f(1)
+	f(2)
+	f(3)
+	Assert(t, r.recorded(), []interface{}{1})
+
+	<-time.After(25 * time.Millisecond)
+	f(4)
+	Assert(t, r.recorded(), []interface{}{1, 4})
+}
+
+func TestThrottleTrailing(t *testing.T) {
+	r := &recorder{}
+	f := Throttle(20*time.Millisecond, true, r.record)
+	f(1)
+	f(2)
+	f(3)
+	Assert(t, r.recorded(), []interface{}{1})
+
+	<-time.After(30 * time.Millisecond)
+	Assert(t, r.recorded(), []interface{}{1, 3})
+
+	// the trailing call starts a new interval
+	f(4)
+	Assert(t, r.recorded(), []interface{}{1, 3})
+}
@@ -0,0 +1,37 @@
+package gobot
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeoutError is returned when a connection or device has not started or
+// halted within the timeout of its robot
+type TimeoutError struct {
+	// Op is the call which timed out, e.g. "Start"
+	Op      string
+	Timeout time.Duration
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("%v timed out after %v", e.Op, e.Timeout)
+}
+
+// callWithTimeout calls f and returns its errors, or a TimeoutError if f has
+// not returned within timeout. f keeps running in the background after a
+// timeout. A timeout of 0 or less waits until f returns.
+func callWithTimeout(op string, timeout time.Duration, f func() []error) (errs []error, timedOut bool) {
+	if timeout <= 0 {
+		return f(), false
+	}
+	done := make(chan []error, 1)
+	go func() {
+		done <- f()
+	}()
+	select {
+	case errs = <-done:
+		return errs, false
+	case <-time.After(timeout):
+		return []error{TimeoutError{Op: op, Timeout: timeout}}, true
+	}
+}
@@ -0,0 +1,46 @@
+package gobot
+
+import (
+	"log"
+	"testing"
+	"time"
+)
+
+func TestRobotStartTimeout(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	halted := []string{}
+	stuck := newRecordingDriver("sensor", &halted)
+	stuck.startBlock = make(chan bool)
+	defer close(stuck.startBlock)
+	r := NewRobot("Robot1", []Device{stuck, newRecordingDriver("led", &halted)})
+	r.StartTimeout = 5 * time.Millisecond
+
+	Assert(t, r.Start(), []error{NewDeviceError("sensor", "Start", TimeoutError{Op: "Start", Timeout: 5 * time.Millisecond}, true)})
+
+	timeouts := make(chan error, 1)
+	On(r.Event("device_timeout"), func(data interface{}) {
+		timeouts <- data.(error)
+	})
+	r.SkipTimedOutDevices = true
+	Assert(t, len(r.Start()), 0)
+	select {
+	case err := <-timeouts:
+		Assert(t, err, NewDeviceError("sensor", "Start", TimeoutError{Op: "Start", Timeout: 5 * time.Millisecond}, true))
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("device_timeout was not published")
+	}
+}
+
+func TestRobotHaltTimeout(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	halted := []string{}
+	stuck := newRecordingDriver("sensor", &halted)
+	stuck.block = make(chan bool)
+	defer close(stuck.block)
+	r := NewRobot("Robot1", []Device{newRecordingDriver("led", &halted), stuck})
+	r.HaltTimeout = 5 * time.Millisecond
+	Assert(t, len(r.Start()), 0)
+
+	Assert(t, r.Stop(), []error{NewDeviceError("sensor", "Halt", TimeoutError{Op: "Halt", Timeout: 5 * time.Millisecond}, true)})
+	Assert(t, halted, []string{"led"})
+}
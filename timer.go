@@ -0,0 +1,55 @@
+package gobot
+
+import (
+	"sync"
+	"time"
+)
+
+// Timer is a function scheduled with Every or After
+type Timer struct {
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newTimer() *Timer {
+	return &Timer{done: make(chan struct{})}
+}
+
+// Stop cancels the timer. Calls of its function in progress are not
+// interrupted.
+func (t *Timer) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
+}
+
+// Done returns a channel which is closed when the timer is stopped, or for
+// a timer returned by After once its function has returned.
+func (t *Timer) Done() <-chan struct{} {
+	return t.done
+}
+
+// Every is like the Every function, except that the timer is stopped when
+// the robot is stopped.
+func (r *Robot) Every(t time.Duration, f func()) *Timer {
+	return r.stopWithRobot(Every(t, f))
+}
+
+// After is like the After function, except that f is not called if the
+// robot is stopped first.
+func (r *Robot) After(t time.Duration, f func()) *Timer {
+	return r.stopWithRobot(After(t, f))
+}
+
+// stopWithRobot stops timer when the robot is stopped
+func (r *Robot) stopWithRobot(timer *Timer) *Timer {
+	stopped := r.Stopped()
+	go func() {
+		select {
+		case <-stopped:
+			timer.Stop()
+		case <-timer.Done():
+		}
+	}()
+	return timer
+}
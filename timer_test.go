@@ -0,0 +1,62 @@
+package gobot
+
+import (
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEveryStop(t *testing.T) {
+	var i int32
+	timer := Every(1*time.Millisecond, func() {
+		atomic.AddInt32(&i, 1)
+	})
+	<-time.After(5 * time.Millisecond)
+	timer.Stop()
+	<-timer.Done()
+	<-time.After(2 * time.Millisecond)
+	calls := atomic.LoadInt32(&i)
+	Refute(t, calls, int32(0))
+	<-time.After(5 * time.Millisecond)
+	Assert(t, atomic.LoadInt32(&i), calls)
+}
+
+func TestAfterStop(t *testing.T) {
+	var i int32
+	timer := After(1*time.Millisecond, func() {
+		atomic.AddInt32(&i, 1)
+	})
+	<-timer.Done()
+	Assert(t, atomic.LoadInt32(&i), int32(1))
+
+	timer = After(1*time.Millisecond, func() {
+		atomic.AddInt32(&i, 1)
+	})
+	timer.Stop()
+	<-time.After(3 * time.Millisecond)
+	Assert(t, atomic.LoadInt32(&i), int32(1))
+}
+
+func TestRobotTimers(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := NewRobot("Robot1")
+	var every, after *Timer
+	r.Work = func() {
+		every = r.Every(1*time.Millisecond, func() {})
+		after = r.After(time.Hour, func() {})
+	}
+	Assert(t, len(r.Start()), 0)
+	r.Stop()
+
+	select {
+	case <-every.Done():
+	case <-time.After(10 * time.Millisecond):
+		t.Error("Every was not stopped with the robot")
+	}
+	select {
+	case <-after.Done():
+	case <-time.After(10 * time.Millisecond):
+		t.Error("After was not stopped with the robot")
+	}
+}
@@ -0,0 +1,218 @@
+package gobot
+
+import "sync"
+
+// Tracer starts the spans tracing what a robot does: starting and stopping,
+// starting and halting its devices and connections, executing commands and
+// the reads and writes of connections which are IOTracers. It can be backed
+// by OpenTelemetry by wrapping a trace.Tracer, keeping the context.Context
+// of each span in the Span to start its children from.
+type Tracer interface {
+	// StartSpan starts a span given a name, its parent, nil for a root span,
+	// and attributes describing it.
+	StartSpan(parent Span, name string, attributes map[string]string) Span
+}
+
+// Span is an operation traced by a Tracer
+type Span interface {
+	// End ends the span, recording err if it is not nil.
+	End(err error)
+}
+
+// TraceParam is the command parameter holding the Span a command is executed
+// for, e.g. the span of the client of an api request continuing its trace,
+// which the span of the command is a child of. It is removed from the
+// parameters before the command runs.
+const TraceParam = "trace_span"
+
+// TraceExtractor is the interface which describes the behaviour for a Tracer
+// which continues the traces of remote callers, such as the clients of the
+// api, from the trace context they propagate.
+type TraceExtractor interface {
+	// ExtractSpan returns the span of the caller described by carrier, the
+	// "traceparent" and "tracestate" fields of W3C Trace Context, nil if
+	// carrier holds no valid trace context.
+	ExtractSpan(carrier map[string]string) Span
+}
+
+// IOTracer is the interface which describes the behaviour for an Adaptor
+// whose reads and writes can be traced.
+type IOTracer interface {
+	// TraceIO starts tracing the reads and writes, trace is called when one
+	// starts and returns the function ending it. A nil trace stops tracing.
+	TraceIO(trace func(method string) func(err error))
+	// StartIO is called by the adaptor when a read or write starts and
+	// returns the function to call with the address of its error once it
+	// has ended, e.g.
+	//
+	//	defer a.StartIO("DigitalWrite")(&err)
+	StartIO(method string) func(err *error)
+}
+
+type ioTracer struct {
+	trace func(method string) func(err error)
+	mutex sync.RWMutex
+}
+
+// NewIOTracer returns a new IOTracer to be embedded in an Adaptor.
+func NewIOTracer() IOTracer {
+	return &ioTracer{}
+}
+
+func (t *ioTracer) TraceIO(trace func(method string) func(err error)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.trace = trace
+}
+
+func (t *ioTracer) StartIO(method string) func(err *error) {
+	t.mutex.RLock()
+	trace := t.trace
+	t.mutex.RUnlock()
+	if trace == nil {
+		return func(*error) {}
+	}
+	end := trace(method)
+	return func(err *error) {
+		end(*err)
+	}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) End(error) {}
+
+// startSpan starts a span with t, or returns a span doing nothing if t is
+// nil
+func startSpan(t Tracer, parent Span, name string, attributes map[string]string) Span {
+	if t == nil {
+		return noopSpan{}
+	}
+	if _, ok := parent.(noopSpan); ok {
+		parent = nil
+	}
+	return t.StartSpan(parent, name, attributes)
+}
+
+// spanError returns the first of errs, nil if there are none
+func spanError(errs []error) error {
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// trace makes the commands of r and its devices and the reads and writes of
+// its connections start spans with the Tracer of r, along with those of the
+// devices and connections added afterwards. Reads and writes are children of
+// the latest command still executing on a device of their connection, or
+// else on the robot, since the connections can not tell which command they
+// are executing for.
+func (r *Robot) trace() {
+	r.spanMutex.Lock()
+	defer r.spanMutex.Unlock()
+	if r.traced {
+		return
+	}
+	r.traced = true
+
+	r.Use(r.traceCommand(nil))
+	for _, device := range *r.Devices() {
+		r.traceDevice(device)
+	}
+	for _, connection := range *r.Connections() {
+		r.traceConnection(connection)
+	}
+}
+
+// traceDevice traces the commands of device, if it is a Commander
+func (r *Robot) traceDevice(device Device) {
+	if commander, ok := device.(Commander); ok {
+		commander.Use(r.traceCommand(device))
+	}
+}
+
+// traceConnection traces the reads and writes of connection, if it is an
+// IOTracer
+func (r *Robot) traceConnection(connection Connection) {
+	if tracer, ok := connection.(IOTracer); ok {
+		name := connection.Name()
+		tracer.TraceIO(func(method string) func(error) {
+			span := startSpan(r.Tracer, r.commandSpan(name), "io", map[string]string{
+				"robot":      r.Name,
+				"connection": name,
+				"method":     method,
+			})
+			return span.End
+		})
+	}
+}
+
+// commandSpan is the span of a command still executing on the robot, or on
+// a device of connection
+type commandSpan struct {
+	span       Span
+	device     bool
+	connection string
+}
+
+// traceCommand returns a CommandMiddleware starting a span for each command
+// executed, of the robot or of device if it is not nil. The span is a child
+// of the span of the TraceParam parameter, if any.
+func (r *Robot) traceCommand(device Device) CommandMiddleware {
+	return func(name string, params map[string]interface{}, next func(map[string]interface{}) interface{}) interface{} {
+		attributes := map[string]string{"robot": r.Name, "command": name}
+		executing := &commandSpan{device: device != nil}
+		if device != nil {
+			attributes["device"] = device.Name()
+			if connection := device.Connection(); connection != nil {
+				executing.connection = connection.Name()
+			}
+		}
+		parent, _ := params[TraceParam].(Span)
+		if _, ok := params[TraceParam]; ok {
+			rest := make(map[string]interface{}, len(params))
+			for key, value := range params {
+				if key != TraceParam {
+					rest[key] = value
+				}
+			}
+			params = rest
+		}
+		executing.span = startSpan(r.Tracer, parent, "command", attributes)
+		r.spanMutex.Lock()
+		r.commandSpans = append(r.commandSpans, executing)
+		r.spanMutex.Unlock()
+
+		result := next(params)
+
+		r.spanMutex.Lock()
+		for i, c := range r.commandSpans {
+			if c == executing {
+				r.commandSpans = append(r.commandSpans[:i], r.commandSpans[i+1:]...)
+				break
+			}
+		}
+		r.spanMutex.Unlock()
+		err, _ := result.(error)
+		executing.span.End(err)
+		return result
+	}
+}
+
+// commandSpan returns the span of the latest command still executing on a
+// device of connection, or else on the robot, nil if there is none
+func (r *Robot) commandSpan(connection string) Span {
+	r.spanMutex.Lock()
+	defer r.spanMutex.Unlock()
+	var robot Span
+	for i := len(r.commandSpans) - 1; i >= 0; i-- {
+		c := r.commandSpans[i]
+		if c.device && c.connection == connection {
+			return c.span
+		} else if !c.device && robot == nil {
+			robot = c.span
+		}
+	}
+	return robot
+}
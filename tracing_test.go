@@ -0,0 +1,154 @@
+package gobot
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestRobotTracer(t *testing.T) {
+	tracer := &testTracer{}
+	adaptor := newIOAdaptor("io")
+	driver := newTestDriver(adaptor.testAdaptor, "led", "13")
+	driver.AddCommand("Write", func(params map[string]interface{}) interface{} {
+		return adaptor.DigitalWrite("13", params["level"].(byte))
+	})
+	r := NewRobot("bot", []Connection{adaptor}, []Device{driver})
+	r.Tracer = tracer
+
+	Assert(t, len(r.Start()), 0)
+	start := tracer.span("robot.start")
+	Assert(t, start.ended, true)
+	Assert(t, start.attributes, map[string]string{"robot": "bot"})
+	Assert(t, tracer.span("connection.start").parent, start)
+	Assert(t, tracer.span("device.start").parent, start)
+	Assert(t, tracer.span("device.start").attributes["device"], "led")
+
+	driver.Command("Write")(map[string]interface{}{"level": byte(1)})
+	command := tracer.span("command")
+	Assert(t, command.attributes, map[string]string{"robot": "bot", "device": "led", "command": "Write"})
+	Assert(t, command.err, nil)
+	io := tracer.span("io")
+	Assert(t, io.parent, command)
+	Assert(t, io.attributes["method"], "DigitalWrite")
+
+	driver.Command("Write")(map[string]interface{}{"level": byte(2)})
+	Assert(t, tracer.span("command").err, errors.New("invalid level"))
+	Assert(t, tracer.span("io").err, errors.New("invalid level"))
+
+	// reads and writes outside of commands are root spans
+	adaptor.DigitalWrite("13", 0)
+	Assert(t, tracer.span("io").parent, (*testSpan)(nil))
+
+	r.Stop()
+	stop := tracer.span("robot.stop")
+	Assert(t, stop.ended, true)
+	Assert(t, tracer.span("device.halt").parent, stop)
+	Assert(t, tracer.span("connection.finalize").parent, stop)
+
+	// restarting does not trace commands twice
+	r.Start()
+	spans := len(tracer.spans)
+	driver.Command("Write")(map[string]interface{}{"level": byte(1)})
+	Assert(t, len(tracer.spans), spans+2)
+}
+
+func TestRobotTracerDeviceError(t *testing.T) {
+	tracer := &testTracer{}
+	r := newTestRobot("bot")
+	r.Tracer = tracer
+	testDriverStart = func() (errs []error) {
+		return []error{errors.New("start failed")}
+	}
+	defer func() { testDriverStart = func() (errs []error) { return } }()
+
+	errs := r.Start()
+	Assert(t, len(errs), 1)
+	Assert(t, tracer.span("device.start").err, errs[0])
+	Assert(t, tracer.span("robot.start").err, errs[0])
+}
+
+func TestGobotTracer(t *testing.T) {
+	tracer := &testTracer{}
+	g := NewGobot()
+	g.trap = func(c chan os.Signal) { c <- os.Interrupt }
+	g.Tracer = tracer
+	r := g.AddRobot(newTestRobot("bot"))
+	g.Start()
+	Assert(t, r.Tracer, Tracer(tracer))
+	Refute(t, tracer.span("robot.start"), (*testSpan)(nil))
+}
+
+func TestRobotTracerParentParam(t *testing.T) {
+	tracer := &testTracer{}
+	driver := newTestDriver(newTestAdaptor("Connection1", "/dev/null"), "led", "13")
+	var params map[string]interface{}
+	driver.AddCommand("Params", func(p map[string]interface{}) interface{} {
+		params = p
+		return nil
+	})
+	r := NewRobot("bot", []Connection{}, []Device{driver})
+	r.Tracer = tracer
+	r.Start()
+
+	remote := tracer.ExtractSpan(map[string]string{"traceparent": "00-01-02-01"})
+	driver.Command("Params")(map[string]interface{}{"a": 1.0, TraceParam: remote})
+	Assert(t, tracer.span("command").parent, remote.(*testSpan))
+	Assert(t, params, map[string]interface{}{"a": 1.0})
+}
+
+func TestRobotTracerConnections(t *testing.T) {
+	tracer := &testTracer{}
+	a := newIOAdaptor("a")
+	b := newIOAdaptor("b")
+	driver := newTestDriver(a.testAdaptor, "led", "13")
+	running, done, finished := make(chan bool), make(chan bool), make(chan bool)
+	driver.AddCommand("Run", func(params map[string]interface{}) interface{} {
+		running <- true
+		<-done
+		return a.DigitalWrite("13", 1)
+	})
+	r := NewRobot("bot", []Connection{a, b}, []Device{driver})
+	r.AddCommand("Write", func(params map[string]interface{}) interface{} {
+		return b.DigitalWrite("13", 1)
+	})
+	r.Tracer = tracer
+	r.Start()
+
+	go func() {
+		driver.Command("Run")(map[string]interface{}{})
+		close(finished)
+	}()
+	<-running
+	run := tracer.span("command")
+	// the command of led does not use b
+	b.DigitalWrite("13", 1)
+	Assert(t, tracer.span("io").parent, (*testSpan)(nil))
+	r.Command("Write")(map[string]interface{}{})
+	Assert(t, tracer.span("io").parent, tracer.span("command"))
+	Assert(t, tracer.span("io").attributes["connection"], "b")
+
+	done <- true
+	<-finished
+	Assert(t, tracer.span("io").attributes["connection"], "a")
+	Assert(t, tracer.span("io").parent, run)
+}
+
+func TestRobotTracerAddDevice(t *testing.T) {
+	tracer := &testTracer{}
+	r := NewRobot("bot", []Connection{}, []Device{})
+	r.Tracer = tracer
+	r.Start()
+
+	adaptor := r.AddConnection(newIOAdaptor("io")).(*ioAdaptor)
+	driver := newTestDriver(adaptor.testAdaptor, "led", "13")
+	driver.AddCommand("Write", func(params map[string]interface{}) interface{} {
+		return adaptor.DigitalWrite("13", 1)
+	})
+	r.AddDevice(driver)
+
+	driver.Command("Write")(map[string]interface{}{})
+	command := tracer.span("command")
+	Assert(t, command.attributes["device"], "led")
+	Assert(t, tracer.span("io").parent, command)
+}
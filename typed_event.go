@@ -0,0 +1,76 @@
+//go:build go1.18
+// +build go1.18
+
+package gobot
+
+import "fmt"
+
+// TypedEvent is an Event whose data is of type T, so publishing or handling
+// data of another type is a compile error instead of a failed type
+// assertion in a callback.
+type TypedEvent[T any] struct {
+	*Event
+}
+
+// NewTypedEvent returns a new TypedEvent which is now listening for data.
+func NewTypedEvent[T any]() *TypedEvent[T] {
+	return &TypedEvent[T]{Event: NewEvent()}
+}
+
+// Typed returns a TypedEvent view of e, e.g. of an event returned by the
+// Event method of an Eventer.
+//
+// Example:
+//
+//	readings := gobot.Typed[firmata.AnalogReading](arduino.Event("analog_read_0"))
+//	readings.On(func(reading firmata.AnalogReading) {
+//		fmt.Println(reading.Value)
+//	})
+func Typed[T any](e *Event) *TypedEvent[T] {
+	return &TypedEvent[T]{Event: e}
+}
+
+// Publish emits val to all subscribers of e. Returns ErrUnknownEvent if the
+// Event does not exist.
+func (e *TypedEvent[T]) Publish(val T) error {
+	return Publish(e.Event, val)
+}
+
+// On executes f when e is published to. Data which is not a T, published on
+// the underlying Event by untyped code, is logged and skipped. Returns
+// ErrUnknownEvent if the Event does not exist.
+func (e *TypedEvent[T]) On(f func(T)) error {
+	return On(e.Event, typed(f))
+}
+
+// Once is similar to On except that it only executes f one time.
+func (e *TypedEvent[T]) Once(f func(T)) error {
+	return Once(e.Event, typed(f))
+}
+
+// OnTyped executes f with the data published on e, which is expected to be a
+// T. It is short for Typed[T](e).On(f).
+func OnTyped[T any](e *Event, f func(T)) error {
+	return Typed[T](e).On(f)
+}
+
+// OnceTyped is similar to OnTyped except that it only executes f one time.
+func OnceTyped[T any](e *Event, f func(T)) error {
+	return Typed[T](e).Once(f)
+}
+
+// typed converts f into an untyped callback
+func typed[T any](f func(T)) func(interface{}) {
+	return func(data interface{}) {
+		val, ok := data.(T)
+		if !ok {
+			var want T
+			Log(WarnLevel, "Skipped event data of unexpected type", Fields{
+				"type": fmt.Sprintf("%T", data),
+				"want": fmt.Sprintf("%T", want),
+			})
+			return
+		}
+		f(val)
+	}
+}
@@ -0,0 +1,57 @@
+//go:build go1.18
+// +build go1.18
+
+package gobot
+
+import (
+	"testing"
+	"time"
+)
+
+type testReading struct {
+	Pin   int
+	Value int
+}
+
+func TestTypedEvent(t *testing.T) {
+	e := NewTypedEvent[testReading]()
+	readings := make(chan testReading, 1)
+	Assert(t, e.On(func(r testReading) {
+		readings <- r
+	}), nil)
+	Assert(t, e.Publish(testReading{Pin: 1, Value: 512}), nil)
+	select {
+	case r := <-readings:
+		Assert(t, r, testReading{Pin: 1, Value: 512})
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("typed event was not handled")
+	}
+}
+
+func TestOnTyped(t *testing.T) {
+	l := &testLogger{}
+	SetLogger(l)
+	defer SetLogger(NewStdLogger(nil, InfoLevel))
+
+	e := NewEvent()
+	values := make(chan int, 2)
+	Assert(t, OnTyped(e, func(v int) {
+		values <- v
+	}), nil)
+
+	// data of another type is skipped
+	Publish(e, "not an int")
+	<-time.After(5 * time.Millisecond)
+	Publish(e, 42)
+	select {
+	case v := <-values:
+		Assert(t, v, 42)
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("typed event was not handled")
+	}
+	messages, fields := l.logged()
+	Assert(t, messages, []string{"Skipped event data of unexpected type"})
+	Assert(t, fields[0], Fields{"type": "string", "want": "int"})
+
+	Assert(t, OnTyped(nil, func(v int) {}), ErrUnknownEvent)
+}
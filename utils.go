@@ -4,7 +4,6 @@ import (
 	"crypto/rand"
 	"errors"
 	"fmt"
-	"log"
 	"math"
 	"math/big"
 	"reflect"
@@ -22,7 +21,7 @@ var (
 var eventError = func(e *Event) (err error) {
 	if e == nil {
 		err = ErrUnknownEvent
-		log.Println(err.Error())
+		Log(ErrorLevel, err.Error(), nil)
 		return
 	}
 	return
@@ -54,22 +53,43 @@ func Refute(t *testing.T, a interface{}, b interface{}) {
 	}
 }
 
-// Every triggers f every t time until the end of days. It does not wait for the
-// previous execution of f to finish before it fires the next f.
-func Every(t time.Duration, f func()) {
-	c := time.Tick(t)
+// Every triggers f every t time until the returned Timer is stopped. It does
+// not wait for the previous execution of f to finish before it fires the
+// next f.
+func Every(t time.Duration, f func()) *Timer {
+	timer := newTimer()
+	ticker := clock().NewTicker(t)
 
 	go func() {
+		defer ticker.Stop()
 		for {
-			<-c
-			go f()
+			select {
+			case <-timer.done:
+				return
+			case <-ticker.C():
+				go f()
+			}
 		}
 	}()
+	return timer
 }
 
-// After triggers f after t duration.
-func After(t time.Duration, f func()) {
-	time.AfterFunc(t, f)
+// After triggers f after t duration, unless the returned Timer is stopped
+// before then.
+func After(t time.Duration, f func()) *Timer {
+	timer := newTimer()
+	after := clock().NewTimer(t)
+
+	go func() {
+		select {
+		case <-timer.done:
+			after.Stop()
+		case <-after.C():
+			f()
+			timer.Stop()
+		}
+	}()
+	return timer
 }
 
 // Publish emits val to all subscribers of e. Returns ErrUnknownEvent if Event
@@ -85,7 +105,7 @@ func Publish(e *Event, val interface{}) (err error) {
 // does not exist.
 func On(e *Event, f func(s interface{})) (err error) {
 	if err = eventError(e); err == nil {
-		e.Callbacks = append(e.Callbacks, callback{f, false})
+		e.addCallback(newCallback(e, f, false, e.getDispatch()))
 	}
 	return
 }
@@ -94,11 +114,92 @@ func On(e *Event, f func(s interface{})) (err error) {
 //ErrUnknownEvent if Event does not exist.
 func Once(e *Event, f func(s interface{})) (err error) {
 	if err = eventError(e); err == nil {
-		e.Callbacks = append(e.Callbacks, callback{f, true})
+		e.addCallback(newCallback(e, f, true, e.getDispatch()))
+	}
+	return
+}
+
+// OnUntilStopped is similar to On except that f is only executed until the
+// returned stop is called. Returns ErrUnknownEvent if Event does not exist.
+func OnUntilStopped(e *Event, f func(s interface{})) (stop func(), err error) {
+	if err = eventError(e); err != nil {
+		return
+	}
+	id := e.addCallback(newCallback(e, f, false, e.getDispatch()))
+	return func() { e.off(id) }, nil
+}
+
+// OnWithHistory is similar to On except that f is first executed with the
+// values retained by e, see Event.Retain, one at a time and before
+// OnWithHistory returns, so that f is only subscribed to the values
+// published later once it has seen the history. Returns ErrUnknownEvent if
+// Event does not exist.
+func OnWithHistory(e *Event, f func(s interface{})) (err error) {
+	if err = eventError(e); err == nil {
+		for _, record := range e.History() {
+			e.run(f, record.Data)
+		}
+		err = On(e, f)
+	}
+	return
+}
+
+// OnWithDispatch is similar to On except that f is executed as described by
+// d instead of the Dispatch set on e. Returns ErrUnknownEvent if Event does
+// not exist.
+func OnWithDispatch(e *Event, d Dispatch, f func(s interface{})) (err error) {
+	if err = eventError(e); err == nil {
+		e.addCallback(newCallback(e, f, false, d))
+	}
+	return
+}
+
+// OnOrdered is similar to On except that f is executed with one value at a
+// time, in the order the values were published, while the other callbacks
+// of e keep being executed concurrently. Values published while f is busy
+// are queued as described by the Dispatch set on e, e.g. set a QueueSize to
+// not miss transitions of a digital pin. Returns ErrUnknownEvent if Event
+// does not exist.
+func OnOrdered(e *Event, f func(s interface{})) (err error) {
+	if err = eventError(e); err == nil {
+		d := e.getDispatch()
+		d.Ordered = true
+		err = OnWithDispatch(e, d, f)
+	}
+	return
+}
+
+// OnDebounced is similar to On except that values published less than wait
+// apart are treated as a single burst and f is only executed on the given
+// edge of the burst, see Debounce. Returns ErrUnknownEvent if Event does not
+// exist.
+func OnDebounced(e *Event, wait time.Duration, edge Edge, f func(s interface{})) (err error) {
+	if err = eventError(e); err == nil {
+		d := e.getDispatch()
+		d.Debounce = wait
+		d.DebounceEdge = edge
+		err = OnWithDispatch(e, d, f)
 	}
 	return
 }
 
+// SetDispatch sets how values are handed to the callbacks subscribed to the
+// events of eventer from now on, see Event.SetDispatch. Events added to
+// eventer later are not affected.
+func SetDispatch(eventer Eventer, d Dispatch) {
+	for _, event := range eventer.Events() {
+		event.SetDispatch(d)
+	}
+}
+
+// Retain makes every event of eventer keep the last n values published on
+// it, see Event.Retain. Events added to eventer later are not affected.
+func Retain(eventer Eventer, n int) {
+	for _, event := range eventer.Events() {
+		event.Retain(n)
+	}
+}
+
 // Rand returns a positive random int up to max
 func Rand(max int) int {
 	i, _ := rand.Int(rand.Reader, big.NewInt(int64(max)))
@@ -94,6 +94,62 @@ func TestOn(t *testing.T) {
 	})
 	Assert(t, err, ErrUnknownEvent)
 }
+func TestOnWithHistory(t *testing.T) {
+	e := NewEvent()
+	e.Retain(2)
+	for i := 1; i <= 3; i++ {
+		Publish(e, i)
+	}
+	// let the values be dispatched, so that they are only seen as history
+	<-time.After(1 * time.Millisecond)
+	values := make(chan int, 3)
+	OnWithHistory(e, func(data interface{}) {
+		values <- data.(int)
+	})
+	// the history is handed over before OnWithHistory returns
+	Assert(t, len(values), 2)
+	Publish(e, 4)
+	<-time.After(1 * time.Millisecond)
+	Assert(t, len(values), 3)
+	Assert(t, []int{<-values, <-values, <-values}, []int{2, 3, 4})
+
+	Assert(t, OnWithHistory(nil, func(interface{}) {}), ErrUnknownEvent)
+}
+
+func TestEventReplay(t *testing.T) {
+	e := NewEvent()
+	Assert(t, len(e.History()), 0)
+	e.Retain(5)
+	Publish(e, "glitch")
+	from := time.Now()
+	Publish(e, 1)
+	Publish(e, 2)
+	to := time.Now()
+	<-time.After(1 * time.Millisecond)
+	Publish(e, 3)
+
+	Assert(t, len(e.History()), 4)
+	replayed := []interface{}{}
+	e.Replay(from, to, func(data interface{}) {
+		replayed = append(replayed, data)
+	})
+	Assert(t, replayed, []interface{}{1, 2})
+
+	e.Retain(0)
+	Assert(t, len(e.History()), 0)
+}
+
+func TestRetain(t *testing.T) {
+	eventer := NewEventer()
+	eventer.AddEvent("a")
+	eventer.AddEvent("b")
+	Retain(eventer, 1)
+	Publish(eventer.Event("a"), 1)
+	Publish(eventer.Event("a"), 2)
+	Assert(t, eventer.Event("a").History()[0].Data, 2)
+	Assert(t, len(eventer.Event("b").History()), 0)
+}
+
 func TestOnce(t *testing.T) {
 	i := 0
 	e := NewEvent()
@@ -116,6 +172,25 @@ func TestOnce(t *testing.T) {
 	Assert(t, err, ErrUnknownEvent)
 }
 
+func TestOnUntilStopped(t *testing.T) {
+	values := make(chan int, 2)
+	e := NewEvent()
+	stop, err := OnUntilStopped(e, func(data interface{}) {
+		values <- data.(int)
+	})
+	Assert(t, err, nil)
+	Publish(e, 10)
+	Assert(t, <-values, 10)
+	stop()
+	Assert(t, e.Subscribers(), 0)
+	Publish(e, 20)
+	<-time.After(1 * time.Millisecond)
+	Assert(t, len(values), 0)
+
+	_, err = OnUntilStopped((*Event)(nil), func(data interface{}) {})
+	Assert(t, err, ErrUnknownEvent)
+}
+
 func TestFromScale(t *testing.T) {
 	Assert(t, FromScale(5, 0, 10), 0.5)
 }